@@ -57,9 +57,15 @@ func (cli *DockerCli) CmdBuild(args ...string) error {
 	flCPUSetCpus := cmd.String([]string{"-cpuset-cpus"}, "", "CPUs in which to allow execution (0-3, 0,1)")
 	flCPUSetMems := cmd.String([]string{"-cpuset-mems"}, "", "MEMs in which to allow execution (0-3, 0,1)")
 	flCgroupParent := cmd.String([]string{"-cgroup-parent"}, "", "Optional parent cgroup for the container")
+	flNetworkMode := cmd.String([]string{"-network"}, "default", "Connect the intermediate containers to a network")
+	flDNS := opts.NewListOpts(opts.ValidateIPAddress)
+	cmd.Var(&flDNS, []string{"-dns"}, "Set custom DNS servers for the intermediate containers")
 	flBuildArg := opts.NewListOpts(opts.ValidateEnv)
 	cmd.Var(&flBuildArg, []string{"-build-arg"}, "Set build-time variables")
+	flSecretBuildArg := opts.NewListOpts(opts.ValidateEnv)
+	cmd.Var(&flSecretBuildArg, []string{"-secret-build-arg"}, "Set build-time variables kept out of the image history")
 	isolation := cmd.String([]string{"-isolation"}, "", "Container isolation level")
+	remoteContextFlag := cmd.Bool([]string{"-remote-context"}, false, "Let the daemon fetch the URL or git repository given as context")
 
 	ulimits := make(map[string]*units.Ulimit)
 	flUlimits := runconfigopts.NewUlimitOpt(&ulimits)
@@ -83,6 +89,14 @@ func (cli *DockerCli) CmdBuild(args ...string) error {
 
 	specifiedContext := cmd.Arg(0)
 
+	isRemote = *remoteContextFlag
+	if isRemote && !urlutil.IsURL(specifiedContext) && !urlutil.IsGitURL(specifiedContext) {
+		return fmt.Errorf("--remote-context requires a URL or a git repository as context")
+	}
+	if isRemote && isTrusted() {
+		return fmt.Errorf("--remote-context cannot be used with content trust, the FROM lines of the Dockerfile could not be verified")
+	}
+
 	var (
 		contextDir    string
 		tempDir       string
@@ -98,92 +112,114 @@ func (cli *DockerCli) CmdBuild(args ...string) error {
 		buildBuff = bytes.NewBuffer(nil)
 	}
 
-	switch {
-	case specifiedContext == "-":
-		tempDir, relDockerfile, err = getContextFromReader(cli.in, *dockerfileName)
-	case urlutil.IsGitURL(specifiedContext) && hasGit:
-		tempDir, relDockerfile, err = getContextFromGitURL(specifiedContext, *dockerfileName)
-	case urlutil.IsURL(specifiedContext):
-		tempDir, relDockerfile, err = getContextFromURL(progBuff, specifiedContext, *dockerfileName)
-	default:
-		contextDir, relDockerfile, err = getContextFromLocalDir(specifiedContext, *dockerfileName)
-	}
-
-	if err != nil {
-		if *suppressOutput && urlutil.IsURL(specifiedContext) {
-			fmt.Fprintln(cli.err, progBuff)
+	var (
+		body           io.Reader
+		vcsRef, vcsURL string
+		resolvedTags   []*resolvedTag
+	)
+	if isRemote {
+		// The context is fetched by the daemon, the Dockerfile is not
+		// read by the client.
+		relDockerfile = *dockerfileName
+		if urlutil.IsGitURL(specifiedContext) {
+			vcsURL = specifiedContext
+		}
+	} else {
+		switch {
+		case specifiedContext == "-":
+			tempDir, relDockerfile, err = getContextFromReader(cli.in, *dockerfileName)
+		case urlutil.IsGitURL(specifiedContext) && hasGit:
+			tempDir, relDockerfile, err = getContextFromGitURL(specifiedContext, *dockerfileName)
+		case urlutil.IsURL(specifiedContext):
+			tempDir, relDockerfile, err = getContextFromURL(progBuff, specifiedContext, *dockerfileName)
+		default:
+			contextDir, relDockerfile, err = getContextFromLocalDir(specifiedContext, *dockerfileName)
 		}
-		return fmt.Errorf("unable to prepare context: %s", err)
-	}
 
-	if tempDir != "" {
-		defer os.RemoveAll(tempDir)
-		contextDir = tempDir
-	}
+		if err != nil {
+			if *suppressOutput && urlutil.IsURL(specifiedContext) {
+				fmt.Fprintln(cli.err, progBuff)
+			}
+			return fmt.Errorf("unable to prepare context: %s", err)
+		}
 
-	// Resolve the FROM lines in the Dockerfile to trusted digest references
-	// using Notary. On a successful build, we must tag the resolved digests
-	// to the original name specified in the Dockerfile.
-	newDockerfile, resolvedTags, err := rewriteDockerfileFrom(filepath.Join(contextDir, relDockerfile), cli.trustedReference)
-	if err != nil {
-		return fmt.Errorf("unable to process Dockerfile: %v", err)
-	}
-	defer newDockerfile.Close()
+		if tempDir != "" {
+			defer os.RemoveAll(tempDir)
+			contextDir = tempDir
+		}
 
-	// And canonicalize dockerfile name to a platform-independent one
-	relDockerfile, err = archive.CanonicalTarNameForPath(relDockerfile)
-	if err != nil {
-		return fmt.Errorf("cannot canonicalize dockerfile path %s: %v", relDockerfile, err)
-	}
+		if hasGit && specifiedContext != "-" {
+			vcsRef = getGitRevision(contextDir)
+			if urlutil.IsGitURL(specifiedContext) {
+				vcsURL = specifiedContext
+			}
+		}
 
-	f, err := os.Open(filepath.Join(contextDir, ".dockerignore"))
-	if err != nil && !os.IsNotExist(err) {
-		return err
-	}
+		// Resolve the FROM lines in the Dockerfile to trusted digest references
+		// using Notary. On a successful build, we must tag the resolved digests
+		// to the original name specified in the Dockerfile.
+		var newDockerfile *trustedDockerfile
+		newDockerfile, resolvedTags, err = rewriteDockerfileFrom(filepath.Join(contextDir, relDockerfile), cli.trustedReference)
+		if err != nil {
+			return fmt.Errorf("unable to process Dockerfile: %v", err)
+		}
+		defer newDockerfile.Close()
 
-	var excludes []string
-	if err == nil {
-		excludes, err = dockerignore.ReadAll(f)
+		// And canonicalize dockerfile name to a platform-independent one
+		relDockerfile, err = archive.CanonicalTarNameForPath(relDockerfile)
 		if err != nil {
+			return fmt.Errorf("cannot canonicalize dockerfile path %s: %v", relDockerfile, err)
+		}
+
+		f, err := os.Open(filepath.Join(contextDir, ".dockerignore"))
+		if err != nil && !os.IsNotExist(err) {
 			return err
 		}
-	}
 
-	if err := validateContextDirectory(contextDir, excludes); err != nil {
-		return fmt.Errorf("Error checking context: '%s'.", err)
-	}
+		var excludes []string
+		if err == nil {
+			excludes, err = dockerignore.ReadAll(f)
+			if err != nil {
+				return err
+			}
+		}
 
-	// If .dockerignore mentions .dockerignore or the Dockerfile
-	// then make sure we send both files over to the daemon
-	// because Dockerfile is, obviously, needed no matter what, and
-	// .dockerignore is needed to know if either one needs to be
-	// removed. The daemon will remove them for us, if needed, after it
-	// parses the Dockerfile. Ignore errors here, as they will have been
-	// caught by validateContextDirectory above.
-	var includes = []string{"."}
-	keepThem1, _ := fileutils.Matches(".dockerignore", excludes)
-	keepThem2, _ := fileutils.Matches(relDockerfile, excludes)
-	if keepThem1 || keepThem2 {
-		includes = append(includes, ".dockerignore", relDockerfile)
-	}
+		if err := validateContextDirectory(contextDir, excludes); err != nil {
+			return fmt.Errorf("Error checking context: '%s'.", err)
+		}
 
-	context, err = archive.TarWithOptions(contextDir, &archive.TarOptions{
-		Compression:     archive.Uncompressed,
-		ExcludePatterns: excludes,
-		IncludeFiles:    includes,
-	})
-	if err != nil {
-		return err
-	}
+		// If .dockerignore mentions .dockerignore or the Dockerfile
+		// then make sure we send both files over to the daemon
+		// because Dockerfile is, obviously, needed no matter what, and
+		// .dockerignore is needed to know if either one needs to be
+		// removed. The daemon will remove them for us, if needed, after it
+		// parses the Dockerfile. Ignore errors here, as they will have been
+		// caught by validateContextDirectory above.
+		var includes = []string{"."}
+		keepThem1, _ := fileutils.Matches(".dockerignore", excludes)
+		keepThem2, _ := fileutils.Matches(relDockerfile, excludes)
+		if keepThem1 || keepThem2 {
+			includes = append(includes, ".dockerignore", relDockerfile)
+		}
+
+		context, err = archive.TarWithOptions(contextDir, &archive.TarOptions{
+			Compression:     archive.Uncompressed,
+			ExcludePatterns: excludes,
+			IncludeFiles:    includes,
+		})
+		if err != nil {
+			return err
+		}
 
-	// Wrap the tar archive to replace the Dockerfile entry with the rewritten
-	// Dockerfile which uses trusted pulls.
-	context = replaceDockerfileTarWrapper(context, newDockerfile, relDockerfile)
+		// Wrap the tar archive to replace the Dockerfile entry with the rewritten
+		// Dockerfile which uses trusted pulls.
+		context = replaceDockerfileTarWrapper(context, newDockerfile, relDockerfile)
 
-	// Setup an upload progress bar
-	progressOutput := streamformatter.NewStreamFormatter().NewProgressOutput(progBuff, true)
+		// Setup an upload progress bar
+		progressOutput := streamformatter.NewStreamFormatter().NewProgressOutput(progBuff, true)
 
-	var body io.Reader = progress.NewProgressReader(context, progressOutput, 0, "", "Sending build context to Docker daemon")
+		body = progress.NewProgressReader(context, progressOutput, 0, "", "Sending build context to Docker daemon")
+	}
 
 	var memory int64
 	if *flMemoryString != "" {
@@ -230,11 +266,17 @@ func (cli *DockerCli) CmdBuild(args ...string) error {
 		CPUQuota:       *flCPUQuota,
 		CPUPeriod:      *flCPUPeriod,
 		CgroupParent:   *flCgroupParent,
+		NetworkMode:    *flNetworkMode,
+		DNS:            flDNS.GetAll(),
 		ShmSize:        *flShmSize,
 		Dockerfile:     relDockerfile,
 		Ulimits:        flUlimits.GetList(),
 		BuildArgs:      flBuildArg.GetAll(),
 		AuthConfigs:    cli.configFile.AuthConfigs,
+
+		SecretBuildArgs: flSecretBuildArg.GetAll(),
+		VCSRef:          vcsRef,
+		VCSURL:          vcsURL,
 	}
 
 	response, err := cli.client.ImageBuild(options)
@@ -492,6 +534,18 @@ func getContextFromGitURL(gitURL, dockerfileName string) (absContextDir, relDock
 	return getDockerfileRelPath(absContextDir, dockerfileName)
 }
 
+// getGitRevision returns the commit checked out in the git working tree
+// containing dir, or an empty string if dir is not in a git working tree.
+func getGitRevision(dir string) string {
+	cmd := exec.Command("git", "rev-parse", "HEAD")
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
 // getContextFromURL uses a remote URL as context for a `docker build`. The
 // remote resource is downloaded as either a Dockerfile or a context tar
 // archive and stored in a temporary directory used as the context directory.
@@ -3,6 +3,7 @@ package client
 import (
 	"fmt"
 	"strings"
+	"time"
 
 	Cli "github.com/docker/docker/cli"
 	"github.com/docker/docker/pkg/ioutils"
@@ -26,6 +27,9 @@ func (cli *DockerCli) CmdInfo(args ...string) error {
 
 	fmt.Fprintf(cli.out, "Containers: %d\n", info.Containers)
 	fmt.Fprintf(cli.out, "Images: %d\n", info.Images)
+	if info.RestoreStatus != nil && info.RestoreStatus.InProgress {
+		fmt.Fprintf(cli.out, "Restoring Containers: %d of %d loaded\n", info.RestoreStatus.Loaded, info.RestoreStatus.Total)
+	}
 	ioutils.FprintfIfNotEmpty(cli.out, "Server Version: %s\n", info.ServerVersion)
 	ioutils.FprintfIfNotEmpty(cli.out, "Storage Driver: %s\n", info.Driver)
 	if info.DriverStatus != nil {
@@ -39,6 +43,10 @@ func (cli *DockerCli) CmdInfo(args ...string) error {
 		}
 
 	}
+	if info.StorageStatus != nil && info.StorageStatus.Degraded {
+		fmt.Fprintf(cli.out, " Storage Degraded Since: %s\n", info.StorageStatus.Since.Local().Format(time.RFC3339))
+		fmt.Fprintf(cli.err, "WARNING: No containers can be created until space is freed: %s\n", info.StorageStatus.Error)
+	}
 	ioutils.FprintfIfNotEmpty(cli.out, "Execution Driver: %s\n", info.ExecutionDriver)
 	ioutils.FprintfIfNotEmpty(cli.out, "Logging Driver: %s\n", info.LoggingDriver)
 
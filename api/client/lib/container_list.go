@@ -21,6 +21,10 @@ func (cli *Client) ContainerList(options types.ContainerListOptions) ([]types.Co
 		query.Set("limit", strconv.Itoa(options.Limit))
 	}
 
+	if options.Offset > 0 {
+		query.Set("offset", strconv.Itoa(options.Offset))
+	}
+
 	if options.Since != "" {
 		query.Set("since", options.Since)
 	}
@@ -7,10 +7,13 @@ import (
 
 // ContainerRestart stops and starts a container again.
 // It makes the daemon to wait for the container to be up again for
-// a specific amount of time, given the timeout.
-func (cli *Client) ContainerRestart(containerID string, timeout int) error {
+// a specific amount of time, given the timeout. If the timeout is nil,
+// the stop timeout of the container is used.
+func (cli *Client) ContainerRestart(containerID string, timeout *int) error {
 	query := url.Values{}
-	query.Set("t", strconv.Itoa(timeout))
+	if timeout != nil {
+		query.Set("t", strconv.Itoa(*timeout))
+	}
 	resp, err := cli.post("/containers/"+containerID+"/restart", query, nil, nil)
 	ensureReaderClosed(resp)
 	return err
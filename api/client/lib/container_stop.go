@@ -7,9 +7,12 @@ import (
 
 // ContainerStop stops a container without terminating the process.
 // The process is blocked until the container stops or the timeout expires.
-func (cli *Client) ContainerStop(containerID string, timeout int) error {
+// If the timeout is nil, the stop timeout of the container is used.
+func (cli *Client) ContainerStop(containerID string, timeout *int) error {
 	query := url.Values{}
-	query.Set("t", strconv.Itoa(timeout))
+	if timeout != nil {
+		query.Set("t", strconv.Itoa(*timeout))
+	}
 	resp, err := cli.post("/containers/"+containerID+"/stop", query, nil, nil)
 	ensureReaderClosed(resp)
 	return err
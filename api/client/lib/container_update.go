@@ -4,9 +4,9 @@ import (
 	"github.com/docker/docker/api/types/container"
 )
 
-// ContainerUpdate updates resources of a container
-func (cli *Client) ContainerUpdate(containerID string, hostConfig container.HostConfig) error {
-	resp, err := cli.post("/containers/"+containerID+"/update", nil, hostConfig, nil)
+// ContainerUpdate updates resources and the restart policy of a container
+func (cli *Client) ContainerUpdate(containerID string, updateConfig container.UpdateConfig) error {
+	resp, err := cli.post("/containers/"+containerID+"/update", nil, updateConfig, nil)
 	ensureReaderClosed(resp)
 	return err
 }
@@ -0,0 +1,23 @@
+package lib
+
+import (
+	"io"
+	"net/url"
+)
+
+// ContainerExportRWLayer retrieves the read-write layer of a container,
+// as a tar archive holding the layer tar and its manifest, and returns it
+// as a io.ReadCloser. The container is paused while its layer is read if
+// pause is set. It's up to the caller to close the stream.
+func (cli *Client) ContainerExportRWLayer(containerID string, pause bool) (io.ReadCloser, error) {
+	query := url.Values{}
+	if !pause {
+		query.Set("pause", "0")
+	}
+	serverResp, err := cli.get("/containers/"+containerID+"/rwlayer", query, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return serverResp.body, nil
+}
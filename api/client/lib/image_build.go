@@ -86,6 +86,13 @@ func imageBuildOptionsToQuery(options types.ImageBuildOptions) (url.Values, erro
 	query.Set("memswap", strconv.FormatInt(options.MemorySwap, 10))
 	query.Set("cgroupparent", options.CgroupParent)
 
+	if options.NetworkMode != "" {
+		query.Set("networkmode", options.NetworkMode)
+	}
+	for _, dns := range options.DNS {
+		query.Add("dns", dns)
+	}
+
 	if options.ShmSize != "" {
 		parsedShmSize, err := units.RAMInBytes(options.ShmSize)
 		if err != nil {
@@ -103,12 +110,32 @@ func imageBuildOptionsToQuery(options types.ImageBuildOptions) (url.Values, erro
 	query.Set("ulimits", string(ulimitsJSON))
 
 	buildArgs := convertKVStringsToMap(options.BuildArgs)
+	secretBuildArgs := []string{}
+	for name, value := range convertKVStringsToMap(options.SecretBuildArgs) {
+		buildArgs[name] = value
+		secretBuildArgs = append(secretBuildArgs, name)
+	}
 	buildArgsJSON, err := json.Marshal(buildArgs)
 	if err != nil {
 		return query, err
 	}
 	query.Set("buildargs", string(buildArgsJSON))
 
+	if len(secretBuildArgs) > 0 {
+		secretBuildArgsJSON, err := json.Marshal(secretBuildArgs)
+		if err != nil {
+			return query, err
+		}
+		query.Set("secretbuildargs", string(secretBuildArgsJSON))
+	}
+
+	if options.VCSRef != "" {
+		query.Set("vcsref", options.VCSRef)
+	}
+	if options.VCSURL != "" {
+		query.Set("vcsurl", options.VCSURL)
+	}
+
 	return query, nil
 }
 
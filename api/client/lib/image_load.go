@@ -9,9 +9,14 @@ import (
 
 // ImageLoad loads an image in the docker host from the client host.
 // It's up to the caller to close the io.ReadCloser returned by
-// this function.
-func (cli *Client) ImageLoad(input io.Reader) (types.ImageLoadResponse, error) {
-	resp, err := cli.postRaw("/images/load", url.Values{}, input, nil)
+// this function. Unless quiet is true, the progress of the load is
+// returned as a JSON stream.
+func (cli *Client) ImageLoad(input io.Reader, quiet bool) (types.ImageLoadResponse, error) {
+	query := url.Values{}
+	if !quiet {
+		query.Set("quiet", "0")
+	}
+	resp, err := cli.postRaw("/images/load", query, input, nil)
 	if err != nil {
 		return types.ImageLoadResponse{}, err
 	}
@@ -17,9 +17,15 @@ func (cli *DockerCli) CmdRestart(args ...string) error {
 
 	cmd.ParseFlags(args, true)
 
+	// Without -t, the daemon uses the stop timeout of each container.
+	var timeout *int
+	if cmd.IsSet("t") || cmd.IsSet("-time") {
+		timeout = nSeconds
+	}
+
 	var errNames []string
 	for _, name := range cmd.Args() {
-		if err := cli.client.ContainerRestart(name, *nSeconds); err != nil {
+		if err := cli.client.ContainerRestart(name, timeout); err != nil {
 			fmt.Fprintf(cli.err, "%s\n", err)
 			errNames = append(errNames, name)
 		} else {
@@ -19,9 +19,15 @@ func (cli *DockerCli) CmdStop(args ...string) error {
 
 	cmd.ParseFlags(args, true)
 
+	// Without -t, the daemon uses the stop timeout of each container.
+	var timeout *int
+	if cmd.IsSet("t") || cmd.IsSet("-time") {
+		timeout = nSeconds
+	}
+
 	var errNames []string
 	for _, name := range cmd.Args() {
-		if err := cli.client.ContainerStop(name, *nSeconds); err != nil {
+		if err := cli.client.ContainerStop(name, timeout); err != nil {
 			fmt.Fprintf(cli.err, "%s\n", err)
 			errNames = append(errNames, name)
 		} else {
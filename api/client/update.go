@@ -6,10 +6,11 @@ import (
 	"github.com/docker/docker/api/types/container"
 	Cli "github.com/docker/docker/cli"
 	flag "github.com/docker/docker/pkg/mflag"
+	runconfigopts "github.com/docker/docker/runconfig/opts"
 	"github.com/docker/go-units"
 )
 
-// CmdUpdate updates resources of one or more containers.
+// CmdUpdate updates resources and the restart policy of one or more containers.
 //
 // Usage: docker update [OPTIONS] CONTAINER [CONTAINER...]
 func (cli *DockerCli) CmdUpdate(args ...string) error {
@@ -24,6 +25,7 @@ func (cli *DockerCli) CmdUpdate(args ...string) error {
 	flMemoryReservation := cmd.String([]string{"-memory-reservation"}, "", "Memory soft limit")
 	flMemorySwap := cmd.String([]string{"-memory-swap"}, "", "Total memory (memory + swap), '-1' to disable swap")
 	flKernelMemory := cmd.String([]string{"-kernel-memory"}, "", "Kernel memory limit")
+	flRestartPolicy := cmd.String([]string{"-restart"}, "", "Restart policy to apply when a container exits")
 
 	cmd.Require(flag.Min, 1)
 	cmd.ParseFlags(args, true)
@@ -68,6 +70,14 @@ func (cli *DockerCli) CmdUpdate(args ...string) error {
 		}
 	}
 
+	var restartPolicy container.RestartPolicy
+	if *flRestartPolicy != "" {
+		restartPolicy, err = runconfigopts.ParseRestartPolicy(*flRestartPolicy)
+		if err != nil {
+			return err
+		}
+	}
+
 	resources := container.Resources{
 		BlkioWeight:       *flBlkioWeight,
 		CpusetCpus:        *flCpusetCpus,
@@ -81,14 +91,15 @@ func (cli *DockerCli) CmdUpdate(args ...string) error {
 		CPUQuota:          *flCPUQuota,
 	}
 
-	hostConfig := container.HostConfig{
-		Resources: resources,
+	updateConfig := container.UpdateConfig{
+		Resources:     resources,
+		RestartPolicy: restartPolicy,
 	}
 
 	names := cmd.Args()
 	var errNames []string
 	for _, name := range names {
-		if err := cli.client.ContainerUpdate(name, hostConfig); err != nil {
+		if err := cli.client.ContainerUpdate(name, updateConfig); err != nil {
 			fmt.Fprintf(cli.err, "%s\n", err)
 			errNames = append(errNames, name)
 		} else {
@@ -53,19 +53,42 @@ func debugRequestMiddleware(handler httputils.APIFunc) httputils.APIFunc {
 	}
 }
 
+// restoreMiddleware rejects the requests modifying the state of the daemon,
+// other than container creations, while it restores its containers in the
+// background.
+func (s *Server) restoreMiddleware(handler httputils.APIFunc) httputils.APIFunc {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+		if s.restoring() && r.Method != "GET" && r.Method != "HEAD" && !strings.HasSuffix(r.URL.Path, "/containers/create") {
+			return errors.ErrorCodeRestoring.WithArgs()
+		}
+		return handler(ctx, w, r, vars)
+	}
+}
+
 // authorizationMiddleware perform authorization on the request.
 func (s *Server) authorizationMiddleware(handler httputils.APIFunc) httputils.APIFunc {
 	return func(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
 		// FIXME: fill when authN gets in
 		// User and UserAuthNMethod are taken from AuthN plugins
 		// Currently tracked in https://github.com/docker/docker/pull/13994
+		// Until then, they are the identity of the client process on local
+		// sockets.
 		user := ""
 		userAuthNMethod := ""
+		id := s.peers.get(r.RemoteAddr)
+		if id != nil {
+			user = id.User
+			userAuthNMethod = id.AuthNMethod
+		}
 		authCtx := authorization.NewCtx(s.authZPlugins, user, userAuthNMethod, r.Method, r.RequestURI)
 
 		if err := authCtx.AuthZRequest(w, r); err != nil {
+			if id != nil {
+				logrus.Errorf("AuthZRequest for %s %s from %s returned error: %s", r.Method, r.RequestURI, id, err)
+				return authorizationError(err)
+			}
 			logrus.Errorf("AuthZRequest for %s %s returned error: %s", r.Method, r.RequestURI, err)
-			return err
+			return authorizationError(err)
 		}
 
 		rw := authorization.NewResponseModifier(w)
@@ -77,12 +100,25 @@ func (s *Server) authorizationMiddleware(handler httputils.APIFunc) httputils.AP
 
 		if err := authCtx.AuthZResponse(rw, r); err != nil {
 			logrus.Errorf("AuthZResponse for %s %s returned error: %s", r.Method, r.RequestURI, err)
-			return err
+			return authorizationError(err)
 		}
 		return nil
 	}
 }
 
+// authorizationError converts the errors of the authorization plugins to
+// error codes, so that a denied request is answered with a 403 status and
+// the reason given by the plugin.
+func authorizationError(err error) error {
+	switch e := err.(type) {
+	case *authorization.DeniedError:
+		return errors.ErrorCodeAuthZDenied.WithArgs(e.Plugin, e.Msg)
+	case *authorization.PluginError:
+		return errors.ErrorCodeAuthZPluginFailed.WithArgs(e.Plugin, e.Err)
+	}
+	return err
+}
+
 // userAgentMiddleware checks the User-Agent header looking for a valid docker client spec.
 func (s *Server) userAgentMiddleware(handler httputils.APIFunc) httputils.APIFunc {
 	return func(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
@@ -174,6 +210,10 @@ func (s *Server) handleWithGlobalMiddlewares(handler httputils.APIFunc) httputil
 		middlewares = append(middlewares, s.authorizationMiddleware)
 	}
 
+	if s.restoring != nil {
+		middlewares = append(middlewares, s.restoreMiddleware)
+	}
+
 	h := handler
 	for _, m := range middlewares {
 		h = m(h)
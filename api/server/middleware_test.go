@@ -1,6 +1,7 @@
 package server
 
 import (
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -8,6 +9,7 @@ import (
 	"github.com/docker/distribution/registry/api/errcode"
 	"github.com/docker/docker/api/server/httputils"
 	"github.com/docker/docker/errors"
+	"github.com/docker/docker/pkg/authorization"
 	"golang.org/x/net/context"
 )
 
@@ -55,3 +57,106 @@ func TestVersionMiddlewareWithErrors(t *testing.T) {
 		t.Fatalf("Expected ErrorCodeNewerClientVersion, got %v", err)
 	}
 }
+
+// fakeAuthZPlugin answers the authorization requests with res, or fails
+// with err.
+type fakeAuthZPlugin struct {
+	res authorization.Response
+	err error
+}
+
+func (p *fakeAuthZPlugin) Name() string {
+	return "fake"
+}
+
+func (p *fakeAuthZPlugin) AuthZRequest(*authorization.Request) (*authorization.Response, error) {
+	return &p.res, p.err
+}
+
+func (p *fakeAuthZPlugin) AuthZResponse(*authorization.Request) (*authorization.Response, error) {
+	return &p.res, p.err
+}
+
+func TestAuthorizationMiddlewareErrors(t *testing.T) {
+	handled := false
+	handler := func(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+		handled = true
+		return nil
+	}
+
+	tests := []struct {
+		plugin   *fakeAuthZPlugin
+		code     errcode.ErrorCode
+		message  string
+		httpCode int
+	}{
+		{&fakeAuthZPlugin{res: authorization.Response{Msg: "not an admin"}}, errors.ErrorCodeAuthZDenied, "authorization denied by plugin fake: not an admin", http.StatusForbidden},
+		{&fakeAuthZPlugin{res: authorization.Response{Err: "no policy"}}, errors.ErrorCodeAuthZPluginFailed, "plugin fake failed with error: no policy", http.StatusInternalServerError},
+		{&fakeAuthZPlugin{err: fmt.Errorf("connection refused")}, errors.ErrorCodeAuthZPluginFailed, "plugin fake failed with error: connection refused", http.StatusInternalServerError},
+	}
+	for _, test := range tests {
+		s := &Server{peers: newPeerConns(), authZPlugins: []authorization.Plugin{test.plugin}}
+		h := s.authorizationMiddleware(handler)
+
+		req, _ := http.NewRequest("POST", "/containers/create", nil)
+		err := h(context.Background(), httptest.NewRecorder(), req, map[string]string{})
+		derr, ok := err.(errcode.Error)
+		if !ok || derr.ErrorCode() != test.code {
+			t.Fatalf("Expected %v, got %v", test.code, err)
+		}
+		if derr.Message != test.message {
+			t.Fatalf("Expected message %q, got %q", test.message, derr.Message)
+		}
+		if handled {
+			t.Fatal("Expected the handler not to be called for a denied request")
+		}
+
+		resp := httptest.NewRecorder()
+		httputils.WriteError(resp, err)
+		if resp.Code != test.httpCode {
+			t.Fatalf("Expected status %d, got %d", test.httpCode, resp.Code)
+		}
+	}
+}
+
+func TestRestoreMiddleware(t *testing.T) {
+	handler := func(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+		return nil
+	}
+
+	restoring := true
+	s := &Server{restoring: func() bool { return restoring }}
+	h := s.restoreMiddleware(handler)
+
+	tests := []struct {
+		method   string
+		path     string
+		rejected bool
+	}{
+		{"GET", "/containers/json", false},
+		{"HEAD", "/containers/abc/archive", false},
+		{"POST", "/containers/create", false},
+		{"POST", "/v1.22/containers/create", false},
+		{"POST", "/containers/abc/start", true},
+		{"DELETE", "/containers/abc", true},
+		{"POST", "/images/create", true},
+	}
+	for _, test := range tests {
+		req, _ := http.NewRequest(test.method, test.path, nil)
+		err := h(context.Background(), httptest.NewRecorder(), req, map[string]string{})
+		if !test.rejected && err != nil {
+			t.Fatalf("Expected %s %s to be accepted, got %v", test.method, test.path, err)
+		}
+		if test.rejected {
+			if derr, ok := err.(errcode.Error); !ok || derr.ErrorCode() != errors.ErrorCodeRestoring {
+				t.Fatalf("Expected %s %s to be rejected, got %v", test.method, test.path, err)
+			}
+		}
+	}
+
+	restoring = false
+	req, _ := http.NewRequest("POST", "/containers/abc/start", nil)
+	if err := h(context.Background(), httptest.NewRecorder(), req, map[string]string{}); err != nil {
+		t.Fatalf("Expected the request to be accepted once restored, got %v", err)
+	}
+}
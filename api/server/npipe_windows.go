@@ -0,0 +1,499 @@
+// +build windows
+
+package server
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"syscall"
+	"time"
+	"unsafe"
+)
+
+var (
+	modkernel32 = syscall.NewLazyDLL("kernel32.dll")
+	modadvapi32 = syscall.NewLazyDLL("advapi32.dll")
+
+	procCreateNamedPipeW            = modkernel32.NewProc("CreateNamedPipeW")
+	procConnectNamedPipe            = modkernel32.NewProc("ConnectNamedPipe")
+	procGetNamedPipeClientProcessID = modkernel32.NewProc("GetNamedPipeClientProcessId")
+	procCreateEventW                = modkernel32.NewProc("CreateEventW")
+	procGetOverlappedResult         = modkernel32.NewProc("GetOverlappedResult")
+
+	procConvertStringSecurityDescriptorToSecurityDescriptorW = modadvapi32.NewProc("ConvertStringSecurityDescriptorToSecurityDescriptorW")
+)
+
+const (
+	pipeAccessDuplex          = 0x3
+	fileFlagFirstPipeInstance = 0x80000
+	fileFlagOverlapped        = 0x40000000
+	pipeRejectRemoteClients   = 0x8
+	pipeUnlimitedInstances    = 255
+	pipeBufferSize            = 65536
+	sddlRevision1             = 1
+
+	processQueryLimitedInformation = 0x1000
+	seGroupLogonID                 = 0xC0000000
+
+	errorNoData           syscall.Errno = 232
+	errorPipeNotConnected syscall.Errno = 233
+	errorPipeConnected    syscall.Errno = 535
+)
+
+// pipeSecurityDescriptor allows only the administrators and the system to
+// connect to the named pipe.
+const pipeSecurityDescriptor = "D:P(A;;GA;;;BA)(A;;GA;;;SY)"
+
+// errPipeClosed matches the error of closed network connections the API
+// server ignores when its listeners are closed.
+var errPipeClosed = errors.New("use of closed network connection")
+
+// pipeTimeoutError is returned by the operations of a pipe connection whose
+// deadline expired.
+type pipeTimeoutError struct{}
+
+func (pipeTimeoutError) Error() string   { return "i/o timeout" }
+func (pipeTimeoutError) Timeout() bool   { return true }
+func (pipeTimeoutError) Temporary() bool { return true }
+
+// pipeAcceptError is returned by Accept when a client could not be accepted.
+// It is temporary, so that the server keeps accepting the next clients.
+type pipeAcceptError struct {
+	err error
+}
+
+func (e pipeAcceptError) Error() string   { return e.err.Error() }
+func (e pipeAcceptError) Timeout() bool   { return false }
+func (e pipeAcceptError) Temporary() bool { return true }
+
+// pipeAddr is the address of a named pipe, e.g. //./pipe/docker_engine.
+type pipeAddr string
+
+func (a pipeAddr) Network() string { return "npipe" }
+func (a pipeAddr) String() string  { return string(a) }
+
+func createEvent() (syscall.Handle, error) {
+	// manual reset event, as the pipe operations reset it when they start
+	r, _, err := procCreateEventW.Call(0, 1, 0, 0)
+	if r == 0 {
+		return 0, err
+	}
+	return syscall.Handle(r), nil
+}
+
+func getOverlappedResult(h syscall.Handle, o *syscall.Overlapped, n *uint32) error {
+	r, _, err := procGetOverlappedResult.Call(uintptr(h), uintptr(unsafe.Pointer(o)), uintptr(unsafe.Pointer(n)), 1)
+	if r == 0 {
+		return err
+	}
+	return nil
+}
+
+// pipeListener is a net.Listener accepting the clients of a named pipe. It
+// always keeps an instance of the pipe waiting for the next client.
+type pipeListener struct {
+	path string
+	sa   *syscall.SecurityAttributes
+
+	mu      sync.Mutex
+	cond    *sync.Cond
+	handle  syscall.Handle
+	event   syscall.Handle
+	pending bool
+	closed  bool
+}
+
+// newPipeListener creates the named pipe at path. It fails if the pipe
+// already exists, so that the pipe cannot be created beforehand by another
+// process to impersonate the daemon.
+func newPipeListener(path string) (*pipeListener, error) {
+	sddl, err := syscall.UTF16PtrFromString(pipeSecurityDescriptor)
+	if err != nil {
+		return nil, err
+	}
+	var sd uintptr
+	if r, _, err := procConvertStringSecurityDescriptorToSecurityDescriptorW.Call(uintptr(unsafe.Pointer(sddl)), sddlRevision1, uintptr(unsafe.Pointer(&sd)), 0); r == 0 {
+		return nil, fmt.Errorf("can't create the security descriptor of named pipe %s: %v", path, err)
+	}
+
+	l := &pipeListener{
+		path: path,
+		sa: &syscall.SecurityAttributes{
+			Length:             uint32(unsafe.Sizeof(syscall.SecurityAttributes{})),
+			SecurityDescriptor: sd,
+		},
+	}
+	l.cond = sync.NewCond(&l.mu)
+
+	if l.event, err = createEvent(); err != nil {
+		syscall.LocalFree(syscall.Handle(sd))
+		return nil, err
+	}
+	if l.handle, err = l.createInstance(true); err != nil {
+		syscall.CloseHandle(l.event)
+		syscall.LocalFree(syscall.Handle(sd))
+		return nil, fmt.Errorf("can't create named pipe %s: %v", path, err)
+	}
+	return l, nil
+}
+
+// createInstance creates a new instance of the pipe for the next client.
+func (l *pipeListener) createInstance(first bool) (syscall.Handle, error) {
+	name, err := syscall.UTF16PtrFromString(l.path)
+	if err != nil {
+		return syscall.InvalidHandle, err
+	}
+	mode := uint32(pipeAccessDuplex | fileFlagOverlapped)
+	if first {
+		mode |= fileFlagFirstPipeInstance
+	}
+	r, _, err := procCreateNamedPipeW.Call(uintptr(unsafe.Pointer(name)), uintptr(mode), pipeRejectRemoteClients, pipeUnlimitedInstances, pipeBufferSize, pipeBufferSize, 0, uintptr(unsafe.Pointer(l.sa)))
+	if syscall.Handle(r) == syscall.InvalidHandle {
+		return syscall.InvalidHandle, err
+	}
+	return syscall.Handle(r), nil
+}
+
+// Accept waits for a client to connect to the waiting instance of the pipe,
+// and creates a new instance for the next client.
+func (l *pipeListener) Accept() (net.Conn, error) {
+	l.mu.Lock()
+	if l.closed {
+		l.mu.Unlock()
+		return nil, errPipeClosed
+	}
+	h := l.handle
+	o := &syscall.Overlapped{HEvent: l.event}
+	r, _, err := procConnectNamedPipe.Call(uintptr(h), uintptr(unsafe.Pointer(o)))
+	if r != 0 || err == errorPipeConnected {
+		err = nil
+	} else if err == syscall.ERROR_IO_PENDING {
+		l.pending = true
+		l.mu.Unlock()
+
+		var n uint32
+		err = getOverlappedResult(h, o, &n)
+
+		l.mu.Lock()
+		l.pending = false
+		l.cond.Broadcast()
+	}
+	if l.closed {
+		l.mu.Unlock()
+		return nil, errPipeClosed
+	}
+
+	// the waiting instance is replaced whether the client was accepted or
+	// not, as a failed instance cannot be reused
+	next, nerr := l.createInstance(false)
+	if nerr != nil {
+		l.mu.Unlock()
+		syscall.CloseHandle(h)
+		return nil, pipeAcceptError{fmt.Errorf("can't create named pipe %s: %v", l.path, nerr)}
+	}
+	l.handle = next
+	l.mu.Unlock()
+
+	if err != nil {
+		syscall.CloseHandle(h)
+		return nil, pipeAcceptError{fmt.Errorf("can't accept a client on named pipe %s: %v", l.path, err)}
+	}
+
+	c, err := newPipeConn(h, l.path)
+	if err != nil {
+		syscall.CloseHandle(h)
+		return nil, pipeAcceptError{err}
+	}
+	return c, nil
+}
+
+// Close stops accepting clients. The connections already accepted are not
+// closed.
+func (l *pipeListener) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.closed {
+		return nil
+	}
+	l.closed = true
+	syscall.CancelIoEx(l.handle, nil)
+	for l.pending {
+		l.cond.Wait()
+	}
+	syscall.CloseHandle(l.handle)
+	syscall.CloseHandle(l.event)
+	syscall.LocalFree(syscall.Handle(l.sa.SecurityDescriptor))
+	return nil
+}
+
+func (l *pipeListener) Addr() net.Addr {
+	return pipeAddr(l.path)
+}
+
+// pipeIO is the state of the reads or the writes of a pipe connection.
+type pipeIO struct {
+	event syscall.Handle
+	// o is the pending operation, nil if there is none.
+	o       *syscall.Overlapped
+	timer   *time.Timer
+	expired bool
+}
+
+// pipeConn is a connection of a client to a named pipe. Its operations are
+// overlapped, so that they can be cancelled when the connection is closed or
+// a deadline expires.
+type pipeConn struct {
+	handle syscall.Handle
+	path   string
+
+	mu      sync.Mutex
+	cond    *sync.Cond
+	pending int
+	closed  bool
+	read    pipeIO
+	write   pipeIO
+}
+
+func newPipeConn(h syscall.Handle, path string) (*pipeConn, error) {
+	c := &pipeConn{handle: h, path: path}
+	c.cond = sync.NewCond(&c.mu)
+	var err error
+	if c.read.event, err = createEvent(); err != nil {
+		return nil, err
+	}
+	if c.write.event, err = createEvent(); err != nil {
+		syscall.CloseHandle(c.read.event)
+		return nil, err
+	}
+	return c, nil
+}
+
+// do runs the overlapped operation op and waits for its completion. The
+// operation is started with the lock held, so that Close cancels every
+// operation which has been started.
+func (c *pipeConn) do(pio *pipeIO, op func(o *syscall.Overlapped, n *uint32) error) (int, error) {
+	var n uint32
+	o := &syscall.Overlapped{HEvent: pio.event}
+
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return 0, errPipeClosed
+	}
+	if pio.expired {
+		c.mu.Unlock()
+		return 0, pipeTimeoutError{}
+	}
+	err := op(o, &n)
+	if err == syscall.ERROR_IO_PENDING {
+		pio.o = o
+		c.pending++
+		c.mu.Unlock()
+
+		err = getOverlappedResult(c.handle, o, &n)
+
+		c.mu.Lock()
+		pio.o = nil
+		c.pending--
+		c.cond.Broadcast()
+	}
+	closed, expired := c.closed, pio.expired
+	c.mu.Unlock()
+
+	if err == syscall.ERROR_OPERATION_ABORTED {
+		if closed {
+			return int(n), errPipeClosed
+		}
+		if expired {
+			return int(n), pipeTimeoutError{}
+		}
+	}
+	return int(n), err
+}
+
+func (c *pipeConn) Read(b []byte) (int, error) {
+	if len(b) == 0 {
+		return 0, nil
+	}
+	n, err := c.do(&c.read, func(o *syscall.Overlapped, n *uint32) error {
+		return syscall.ReadFile(c.handle, b, n, o)
+	})
+	switch err {
+	case syscall.ERROR_BROKEN_PIPE, errorPipeNotConnected, errorNoData:
+		return n, io.EOF
+	}
+	return n, err
+}
+
+func (c *pipeConn) Write(b []byte) (int, error) {
+	written := 0
+	for written < len(b) {
+		n, err := c.do(&c.write, func(o *syscall.Overlapped, n *uint32) error {
+			return syscall.WriteFile(c.handle, b[written:], n, o)
+		})
+		written += n
+		if err != nil {
+			return written, err
+		}
+	}
+	return written, nil
+}
+
+// Close cancels the pending operations and closes the connection. The data
+// already written stays readable by the client.
+func (c *pipeConn) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return nil
+	}
+	c.closed = true
+	for _, pio := range []*pipeIO{&c.read, &c.write} {
+		if pio.timer != nil {
+			pio.timer.Stop()
+		}
+	}
+	syscall.CancelIoEx(c.handle, nil)
+	for c.pending > 0 {
+		c.cond.Wait()
+	}
+	syscall.CloseHandle(c.read.event)
+	syscall.CloseHandle(c.write.event)
+	return syscall.CloseHandle(c.handle)
+}
+
+func (c *pipeConn) LocalAddr() net.Addr {
+	return pipeAddr(c.path)
+}
+
+func (c *pipeConn) RemoteAddr() net.Addr {
+	return pipeAddr(c.path)
+}
+
+func (c *pipeConn) SetDeadline(t time.Time) error {
+	c.SetReadDeadline(t)
+	c.SetWriteDeadline(t)
+	return nil
+}
+
+func (c *pipeConn) SetReadDeadline(t time.Time) error {
+	c.setDeadline(&c.read, t)
+	return nil
+}
+
+func (c *pipeConn) SetWriteDeadline(t time.Time) error {
+	c.setDeadline(&c.write, t)
+	return nil
+}
+
+// setDeadline makes the operations of pio fail once t is passed, cancelling
+// the pending one. A zero t clears the deadline.
+func (c *pipeConn) setDeadline(pio *pipeIO, t time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if pio.timer != nil {
+		pio.timer.Stop()
+		pio.timer = nil
+	}
+	pio.expired = false
+	if t.IsZero() || c.closed {
+		return
+	}
+
+	expire := func() {
+		pio.expired = true
+		if pio.o != nil {
+			syscall.CancelIoEx(c.handle, pio.o)
+		}
+	}
+	d := t.Sub(time.Now())
+	if d <= 0 {
+		expire()
+		return
+	}
+	pio.timer = time.AfterFunc(d, func() {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		if !c.closed {
+			expire()
+		}
+	})
+}
+
+// identifyPipeConn returns the identity of the client process of a named
+// pipe connection from its access token.
+func identifyPipeConn(nc net.Conn) (*peerIdentity, error) {
+	c, ok := nc.(*pipeConn)
+	if !ok {
+		return nil, fmt.Errorf("not a named pipe connection: %T", nc)
+	}
+
+	var pid uint32
+	if r, _, err := procGetNamedPipeClientProcessID.Call(uintptr(c.handle), uintptr(unsafe.Pointer(&pid))); r == 0 {
+		return nil, err
+	}
+	p, err := syscall.OpenProcess(processQueryLimitedInformation, false, pid)
+	if err != nil {
+		return nil, err
+	}
+	defer syscall.CloseHandle(p)
+
+	var token syscall.Token
+	if err := syscall.OpenProcessToken(p, syscall.TOKEN_QUERY, &token); err != nil {
+		return nil, err
+	}
+	defer token.Close()
+
+	user, err := token.GetTokenUser()
+	if err != nil {
+		return nil, err
+	}
+	userSID, err := user.User.Sid.String()
+	if err != nil {
+		return nil, err
+	}
+	logonSID, err := tokenLogonSID(token)
+	if err != nil {
+		return nil, err
+	}
+
+	return &peerIdentity{
+		User:        userSID,
+		Logon:       logonSID,
+		Pid:         int(pid),
+		AuthNMethod: "npipe",
+	}, nil
+}
+
+// tokenGroups is the TOKEN_GROUPS structure, with a variable number of
+// groups.
+type tokenGroups struct {
+	GroupCount uint32
+	Groups     [1]syscall.SIDAndAttributes
+}
+
+// tokenLogonSID returns the logon SID of the access token, identifying the
+// logon session of the process.
+func tokenLogonSID(token syscall.Token) (string, error) {
+	var size uint32
+	syscall.GetTokenInformation(token, syscall.TokenGroups, nil, 0, &size)
+	if size == 0 {
+		return "", fmt.Errorf("can't get the groups of the access token")
+	}
+	buf := make([]byte, size)
+	if err := syscall.GetTokenInformation(token, syscall.TokenGroups, &buf[0], size, &size); err != nil {
+		return "", err
+	}
+
+	groups := (*tokenGroups)(unsafe.Pointer(&buf[0]))
+	all := (*[1 << 20]syscall.SIDAndAttributes)(unsafe.Pointer(&groups.Groups[0]))[:groups.GroupCount:groups.GroupCount]
+	for _, g := range all {
+		if g.Attributes&seGroupLogonID == seGroupLogonID {
+			return g.Sid.String()
+		}
+	}
+	return "", fmt.Errorf("no logon SID in the access token")
+}
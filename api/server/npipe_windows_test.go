@@ -0,0 +1,197 @@
+// +build windows
+
+package server
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func testPipePath() string {
+	return fmt.Sprintf("//./pipe/docker-test-%d", time.Now().UnixNano())
+}
+
+// dialPipe opens a client handle to the named pipe at path.
+func dialPipe(t *testing.T, path string) *os.File {
+	name, err := syscall.UTF16PtrFromString(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h, err := syscall.CreateFile(name, syscall.GENERIC_READ|syscall.GENERIC_WRITE, 0, nil, syscall.OPEN_EXISTING, 0, 0)
+	if err != nil {
+		t.Fatalf("Error connecting to %s: %v", path, err)
+	}
+	return os.NewFile(uintptr(h), path)
+}
+
+func TestPipeListenerAccept(t *testing.T) {
+	path := testPipePath()
+	l, err := newPipeListener(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	if l.Addr().Network() != "npipe" || l.Addr().String() != path {
+		t.Fatalf("Unexpected address %s %s", l.Addr().Network(), l.Addr())
+	}
+
+	client := dialPipe(t, path)
+	defer client.Close()
+
+	c, err := l.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	if _, err := client.Write([]byte("ping")); err != nil {
+		t.Fatal(err)
+	}
+	b := make([]byte, 4)
+	if _, err := io.ReadFull(c, b); err != nil || string(b) != "ping" {
+		t.Fatalf("Expected to read ping, got %q %v", b, err)
+	}
+	if _, err := c.Write([]byte("pong")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := io.ReadFull(client, b); err != nil || string(b) != "pong" {
+		t.Fatalf("Expected to read pong, got %q %v", b, err)
+	}
+
+	// the listener keeps an instance waiting for the next client
+	next := dialPipe(t, path)
+	defer next.Close()
+	c2, err := l.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+	c2.Close()
+}
+
+func TestPipeListenerExisting(t *testing.T) {
+	path := testPipePath()
+	l, err := newPipeListener(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	if l2, err := newPipeListener(path); err == nil {
+		l2.Close()
+		t.Fatal("Expected creating a listener on an existing pipe to fail")
+	}
+}
+
+func TestPipeListenerClose(t *testing.T) {
+	l, err := newPipeListener(testPipePath())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		_, err := l.Accept()
+		errCh <- err
+	}()
+	time.Sleep(100 * time.Millisecond)
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case err := <-errCh:
+		if err != errPipeClosed {
+			t.Fatalf("Expected %v, got %v", errPipeClosed, err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Accept was not interrupted by Close")
+	}
+
+	if _, err := l.Accept(); err != errPipeClosed {
+		t.Fatalf("Expected %v, got %v", errPipeClosed, err)
+	}
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPipeConnClose(t *testing.T) {
+	path := testPipePath()
+	l, err := newPipeListener(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	client := dialPipe(t, path)
+	defer client.Close()
+	c, err := l.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := c.Write([]byte("bye")); err != nil {
+		t.Fatal(err)
+	}
+	errCh := make(chan error, 1)
+	go func() {
+		_, err := c.Read(make([]byte, 1))
+		errCh <- err
+	}()
+	time.Sleep(100 * time.Millisecond)
+	if err := c.Close(); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case err := <-errCh:
+		if err != errPipeClosed {
+			t.Fatalf("Expected %v, got %v", errPipeClosed, err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Read was not interrupted by Close")
+	}
+
+	b := make([]byte, 3)
+	if _, err := io.ReadFull(client, b); err != nil || string(b) != "bye" {
+		t.Fatalf("Expected the data written before Close to be readable, got %q %v", b, err)
+	}
+	if _, err := client.Read(b); err != io.EOF {
+		t.Fatalf("Expected EOF, got %v", err)
+	}
+}
+
+func TestPipeConnDeadline(t *testing.T) {
+	path := testPipePath()
+	l, err := newPipeListener(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	client := dialPipe(t, path)
+	defer client.Close()
+	c, err := l.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	c.SetReadDeadline(time.Now().Add(100 * time.Millisecond))
+	_, err = c.Read(make([]byte, 1))
+	if nerr, ok := err.(net.Error); !ok || !nerr.Timeout() {
+		t.Fatalf("Expected a timeout error, got %v", err)
+	}
+
+	// clearing the deadline makes the reads succeed again
+	c.SetReadDeadline(time.Time{})
+	if _, err := client.Write([]byte("x")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Read(make([]byte, 1)); err != nil {
+		t.Fatal(err)
+	}
+}
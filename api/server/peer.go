@@ -0,0 +1,144 @@
+package server
+
+import (
+	"fmt"
+	"net"
+	"sync"
+
+	"github.com/Sirupsen/logrus"
+)
+
+// peerIdentity is the identity of the local process at the other end of a
+// connection to a unix socket or a named pipe of the API, as reported by the
+// operating system.
+type peerIdentity struct {
+	// User is the uid of the process on Unix, and the SID of its user on
+	// Windows.
+	User string
+	// Logon is the logon SID of the process on Windows.
+	Logon string
+	Pid   int
+	// AuthNMethod is how the identity was obtained, passed to the
+	// authorization plugins along with the user.
+	AuthNMethod string
+}
+
+func (id *peerIdentity) String() string {
+	if id.Logon != "" {
+		return fmt.Sprintf("user=%s logon=%s pid=%d", id.User, id.Logon, id.Pid)
+	}
+	return fmt.Sprintf("user=%s pid=%d", id.User, id.Pid)
+}
+
+// peerConns keeps the identity of the clients of the open connections, by the
+// remote address of the connections.
+type peerConns struct {
+	mu   sync.Mutex
+	next uint64
+	ids  map[string]*peerIdentity
+}
+
+func newPeerConns() *peerConns {
+	return &peerConns{ids: make(map[string]*peerIdentity)}
+}
+
+// add registers the identity of a new connection to listener and returns the
+// unique remote address of the connection.
+func (p *peerConns) add(listener net.Addr, id *peerIdentity) string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.next++
+	addr := fmt.Sprintf("%s#%d", listener, p.next)
+	p.ids[addr] = id
+	return addr
+}
+
+func (p *peerConns) remove(addr string) {
+	p.mu.Lock()
+	delete(p.ids, addr)
+	p.mu.Unlock()
+}
+
+// get returns the identity of the client of the connection with the given
+// remote address, nil if the client is not known.
+func (p *peerConns) get(addr string) *peerIdentity {
+	if p == nil {
+		return nil
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.ids[addr]
+}
+
+// peerListener identifies the client of every connection it accepts. The
+// remote address of the connections is replaced by a unique address the
+// identity is registered with in peers, so that the identity of the client of
+// a request can be found from the remote address of the request.
+type peerListener struct {
+	net.Listener
+	identify func(net.Conn) (*peerIdentity, error)
+	peers    *peerConns
+}
+
+// newPeerListener wraps l to identify the clients of its connections with
+// identify. It returns l unchanged if identify is nil.
+func (s *Server) newPeerListener(l net.Listener, identify func(net.Conn) (*peerIdentity, error)) net.Listener {
+	if identify == nil {
+		return l
+	}
+	return &peerListener{Listener: l, identify: identify, peers: s.peers}
+}
+
+// Accept waits for the next connection whose client can be identified.
+// Connections whose client cannot be identified are closed.
+func (l *peerListener) Accept() (net.Conn, error) {
+	for {
+		c, err := l.Listener.Accept()
+		if err != nil {
+			return nil, err
+		}
+		id, err := l.identify(c)
+		if err != nil {
+			logrus.Warnf("Rejecting connection on %s: failed to identify the client: %v", l.Addr(), err)
+			c.Close()
+			continue
+		}
+		if id == nil {
+			return c, nil
+		}
+		addr := l.peers.add(l.Addr(), id)
+		logrus.Debugf("Accepted connection on %s from %s", l.Addr(), id)
+		return &peerConn{Conn: c, addr: peerAddr{network: c.RemoteAddr().Network(), addr: addr}, peers: l.peers}, nil
+	}
+}
+
+// peerConn is a connection whose client identity is registered in peers.
+type peerConn struct {
+	net.Conn
+	addr  peerAddr
+	peers *peerConns
+	once  sync.Once
+}
+
+func (c *peerConn) RemoteAddr() net.Addr {
+	return c.addr
+}
+
+func (c *peerConn) Close() error {
+	c.once.Do(func() { c.peers.remove(c.addr.addr) })
+	return c.Conn.Close()
+}
+
+// peerAddr is the unique remote address of a peerConn.
+type peerAddr struct {
+	network string
+	addr    string
+}
+
+func (a peerAddr) Network() string {
+	return a.network
+}
+
+func (a peerAddr) String() string {
+	return a.addr
+}
@@ -0,0 +1,9 @@
+package server
+
+import "net"
+
+// identifyUnixConn does not identify the clients of unix socket connections
+// on FreeBSD.
+func identifyUnixConn(c net.Conn) (*peerIdentity, error) {
+	return nil, nil
+}
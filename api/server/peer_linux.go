@@ -0,0 +1,38 @@
+package server
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"syscall"
+)
+
+// identifyUnixConn returns the identity of the client of a unix socket
+// connection from its SO_PEERCRED credentials.
+func identifyUnixConn(c net.Conn) (*peerIdentity, error) {
+	uc, ok := c.(*net.UnixConn)
+	if !ok {
+		return nil, fmt.Errorf("not a unix socket connection: %T", c)
+	}
+	// File returns a dup of the socket, put in blocking mode. The mode is
+	// shared with the socket of the connection, and restored before the
+	// dup is closed.
+	f, err := uc.File()
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	fd := int(f.Fd())
+	defer syscall.SetNonblock(fd, true)
+
+	cred, err := syscall.GetsockoptUcred(fd, syscall.SOL_SOCKET, syscall.SO_PEERCRED)
+	if err != nil {
+		return nil, err
+	}
+
+	return &peerIdentity{
+		User:        strconv.FormatUint(uint64(cred.Uid), 10),
+		Pid:         int(cred.Pid),
+		AuthNMethod: "SO_PEERCRED",
+	}, nil
+}
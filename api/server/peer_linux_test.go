@@ -0,0 +1,54 @@
+package server
+
+import (
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+)
+
+func TestPeerListenerUnixSocket(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "docker-peer-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+
+	path := filepath.Join(tmp, "docker.sock")
+	ul, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &Server{cfg: &Config{}, peers: newPeerConns()}
+	l := s.newPeerListener(ul, identifyUnixConn)
+	defer l.Close()
+
+	client, err := net.Dial("unix", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	c, err := l.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	addr := c.RemoteAddr().String()
+	id := s.peers.get(addr)
+	if id == nil {
+		t.Fatalf("Expected the client identity to be registered for %s", addr)
+	}
+	if id.User != strconv.Itoa(os.Getuid()) || id.Pid != os.Getpid() || id.AuthNMethod != "SO_PEERCRED" {
+		t.Fatalf("Unexpected client identity %+v", id)
+	}
+
+	if err := c.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if s.peers.get(addr) != nil {
+		t.Fatal("Expected the client identity to be removed when the connection is closed")
+	}
+}
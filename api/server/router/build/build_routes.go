@@ -10,6 +10,7 @@ import (
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/Sirupsen/logrus"
 	"github.com/docker/docker/api/server/httputils"
@@ -135,6 +136,8 @@ func (br *buildRouter) postBuild(ctx context.Context, w http.ResponseWriter, r *
 	buildConfig.CPUSetCpus = r.FormValue("cpusetcpus")
 	buildConfig.CPUSetMems = r.FormValue("cpusetmems")
 	buildConfig.CgroupParent = r.FormValue("cgroupparent")
+	buildConfig.NetworkMode = r.FormValue("networkmode")
+	buildConfig.DNS = r.Form["dns"]
 
 	if r.Form.Get("shmsize") != "" {
 		shmSize, err := strconv.ParseInt(r.Form.Get("shmsize"), 10, 64)
@@ -169,8 +172,34 @@ func (br *buildRouter) postBuild(ctx context.Context, w http.ResponseWriter, r *
 		buildConfig.BuildArgs = buildArgs
 	}
 
+	var secretBuildArgs = []string{}
+	secretBuildArgsJSON := r.FormValue("secretbuildargs")
+	if secretBuildArgsJSON != "" {
+		if err := json.NewDecoder(strings.NewReader(secretBuildArgsJSON)).Decode(&secretBuildArgs); err != nil {
+			return errf(err)
+		}
+		buildConfig.SecretBuildArgs = make(map[string]bool, len(secretBuildArgs))
+		for _, name := range secretBuildArgs {
+			buildConfig.SecretBuildArgs[name] = true
+		}
+	}
+
+	// The build writes containers and images, so it fails early while the
+	// storage of the daemon is degraded
+	if err := br.backend.CheckStorage(); err != nil {
+		return errf(err)
+	}
+
 	remoteURL := r.FormValue("remote")
 
+	if br.backend.BuildSourceLabels() {
+		source := r.FormValue("vcsurl")
+		if source == "" {
+			source = remoteURL
+		}
+		buildConfig.Labels = sourceLabels(r.FormValue("vcsref"), source)
+	}
+
 	// Currently, only used if context is from a remote url.
 	// Look at code in DetectContextFromRemoteURL for more information.
 	createProgressReader := func(in io.ReadCloser) io.ReadCloser {
@@ -185,7 +214,11 @@ func (br *buildRouter) postBuild(ctx context.Context, w http.ResponseWriter, r *
 		context        builder.ModifiableContext
 		dockerfileName string
 	)
-	context, dockerfileName, err = daemonbuilder.DetectContextFromRemoteURL(r.Body, remoteURL, createProgressReader)
+	contextAuth, err := br.backend.BuildContextAuth(remoteURL)
+	if err != nil {
+		return errf(err)
+	}
+	context, dockerfileName, err = daemonbuilder.DetectContextFromRemoteURL(r.Body, remoteURL, contextAuth, createProgressReader)
 	if err != nil {
 		return errf(err)
 	}
@@ -259,3 +292,18 @@ func (br *buildRouter) postBuild(ctx context.Context, w http.ResponseWriter, r *
 
 	return nil
 }
+
+// sourceLabels returns the labels describing the source of a build: the
+// build time, and the revision and location of the sources when known.
+func sourceLabels(vcsRef, source string) map[string]string {
+	labels := map[string]string{
+		"org.opencontainers.image.created": time.Now().UTC().Format(time.RFC3339),
+	}
+	if vcsRef != "" {
+		labels["org.opencontainers.image.revision"] = vcsRef
+	}
+	if source != "" {
+		labels["org.opencontainers.image.source"] = source
+	}
+	return labels
+}
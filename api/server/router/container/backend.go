@@ -6,10 +6,12 @@ import (
 
 	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/filters"
 	"github.com/docker/docker/daemon"
 	"github.com/docker/docker/daemon/exec"
 	"github.com/docker/docker/pkg/archive"
 	"github.com/docker/docker/pkg/version"
+	"golang.org/x/net/context"
 )
 
 // execBackend includes functions to implement to provide exec functionality.
@@ -23,11 +25,13 @@ type execBackend interface {
 
 // copyBackend includes functions to implement to provide container copy functionality.
 type copyBackend interface {
-	ContainerArchivePath(name string, path string) (content io.ReadCloser, stat *types.ContainerPathStat, err error)
-	ContainerCopy(name string, res string) (io.ReadCloser, error)
-	ContainerExport(name string, out io.Writer) error
-	ContainerExtractToDir(name, path string, noOverwriteDirNonDir bool, content io.Reader) error
-	ContainerStatPath(name string, path string) (stat *types.ContainerPathStat, err error)
+	ContainerArchivePath(ctx context.Context, name string, path string) (content io.ReadCloser, stat *types.ContainerPathStat, err error)
+	ContainerCopy(ctx context.Context, name string, res string) (io.ReadCloser, error)
+	ContainerDu(ctx context.Context, name, path string, depth int) (*types.ContainerPathUsage, error)
+	ContainerExport(ctx context.Context, name string, out io.Writer) error
+	ContainerExtractToDir(ctx context.Context, name, path string, noOverwriteDirNonDir bool, content io.Reader) error
+	ContainerRWLayerExport(name string, pause bool, out io.Writer) error
+	ContainerStatPath(ctx context.Context, name string, path string) (stat *types.ContainerPathStat, err error)
 }
 
 // stateBackend includes functions to implement to provide container state lifecycle functionality.
@@ -35,15 +39,19 @@ type stateBackend interface {
 	ContainerCreate(types.ContainerCreateConfig) (types.ContainerCreateResponse, error)
 	ContainerKill(name string, sig uint64) error
 	ContainerPause(name string) error
+	ContainerRecover(name string) error
 	ContainerRename(oldName, newName string) error
 	ContainerResize(name string, height, width int) error
-	ContainerRestart(name string, seconds int) error
+	ContainerRestart(ctx context.Context, name string, seconds *int) error
 	ContainerRm(name string, config *types.ContainerRmConfig) error
-	ContainerStart(name string, hostConfig *container.HostConfig) error
-	ContainerStop(name string, seconds int) error
+	ContainerSendInput(name string, data []byte) error
+	ContainersPrune(pruneFilters filters.Args) (*types.ContainersPruneReport, error)
+	ReindexContainers() (*types.ContainersReindexReport, error)
+	ContainerStart(ctx context.Context, name string, hostConfig *container.HostConfig, checkpoint string) error
+	ContainerStop(ctx context.Context, name string, seconds *int) error
 	ContainerUnpause(name string) error
-	ContainerUpdate(name string, hostConfig *container.HostConfig) ([]string, error)
-	ContainerWait(name string, timeout time.Duration) (int, error)
+	ContainerUpdate(name string, updateConfig *container.UpdateConfig) ([]string, error)
+	ContainerWait(ctx context.Context, name string, timeout time.Duration) (int, error)
 	Exists(id string) bool
 }
 
@@ -52,10 +60,12 @@ type monitorBackend interface {
 	ContainerChanges(name string) ([]archive.Change, error)
 	ContainerInspect(name string, size bool, version version.Version) (interface{}, error)
 	ContainerLogs(name string, config *daemon.ContainerLogsConfig) error
+	ContainerPortCheck(name, port string) (*types.ContainerPortCheck, error)
 	ContainerStats(name string, config *daemon.ContainerStatsConfig) error
 	ContainerTop(name string, psArgs string) (*types.ContainerProcessList, error)
 
 	Containers(config *daemon.ContainersConfig) ([]*types.Container, error)
+	ContainerDrift(name string, desired types.ContainerCreateConfig) (*types.ContainerDrift, error)
 }
 
 // attachBackend includes function to implement to provide container attaching functionality.
@@ -64,6 +74,20 @@ type attachBackend interface {
 	ContainerWsAttachWithLogs(name string, c *daemon.ContainerWsAttachWithLogsConfig) error
 }
 
+// checkpointBackend includes functions to implement to provide container checkpointing functionality.
+type checkpointBackend interface {
+	CheckpointCreate(name string, config types.CheckpointCreateOptions) error
+	CheckpointDelete(name, checkpointID string) error
+	CheckpointList(name string) ([]types.Checkpoint, error)
+}
+
+// captureBackend includes functions to implement to provide container traffic capture functionality.
+type captureBackend interface {
+	ContainerCaptureExport(name string, out io.Writer) error
+	ContainerCaptureStart(name string, options types.ContainerCaptureOptions) (*types.ContainerCapture, error)
+	ContainerCaptureStop(name string) error
+}
+
 // Backend is all the methods that need to be implemented to provide container specific functionality.
 type Backend interface {
 	execBackend
@@ -71,4 +95,6 @@ type Backend interface {
 	stateBackend
 	monitorBackend
 	attachBackend
+	checkpointBackend
+	captureBackend
 }
@@ -0,0 +1,51 @@
+package container
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/docker/docker/api/server/httputils"
+	"github.com/docker/docker/api/types"
+	"golang.org/x/net/context"
+)
+
+func (s *containerRouter) postContainerCapture(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	if err := httputils.ParseForm(r); err != nil {
+		return err
+	}
+	if err := httputils.CheckForJSON(r); err != nil {
+		return err
+	}
+
+	var options types.ContainerCaptureOptions
+	if err := json.NewDecoder(r.Body).Decode(&options); err != nil {
+		return err
+	}
+
+	capture, err := s.backend.ContainerCaptureStart(vars["name"], options)
+	if err != nil {
+		return err
+	}
+	return httputils.WriteJSON(w, http.StatusCreated, capture)
+}
+
+func (s *containerRouter) getContainerCapture(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	if err := httputils.ParseForm(r); err != nil {
+		return err
+	}
+
+	w.Header().Set("Content-Type", "application/vnd.tcpdump.pcap")
+	return s.backend.ContainerCaptureExport(vars["name"], w)
+}
+
+func (s *containerRouter) deleteContainerCapture(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	if err := httputils.ParseForm(r); err != nil {
+		return err
+	}
+
+	if err := s.backend.ContainerCaptureStop(vars["name"]); err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
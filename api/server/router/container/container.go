@@ -33,6 +33,7 @@ func (r *containerRouter) initRoutes() {
 		// GET
 		local.NewGetRoute("/containers/json", r.getContainersJSON),
 		local.NewGetRoute("/containers/{name:.*}/export", r.getContainersExport),
+		local.NewGetRoute("/containers/{name:.*}/rwlayer", r.getContainersRWLayer),
 		local.NewGetRoute("/containers/{name:.*}/changes", r.getContainersChanges),
 		local.NewGetRoute("/containers/{name:.*}/json", r.getContainersByName),
 		local.NewGetRoute("/containers/{name:.*}/top", r.getContainersTop),
@@ -41,8 +42,14 @@ func (r *containerRouter) initRoutes() {
 		local.NewGetRoute("/containers/{name:.*}/attach/ws", r.wsContainersAttach),
 		local.NewGetRoute("/exec/{id:.*}/json", r.getExecByID),
 		local.NewGetRoute("/containers/{name:.*}/archive", r.getContainersArchive),
+		local.NewGetRoute("/containers/{name:.*}/du", r.getContainersDu),
+		local.NewGetRoute("/containers/{name:.*}/portcheck", r.getContainersPortCheck),
+		local.NewGetRoute("/containers/{name:.*}/checkpoints", r.getContainerCheckpoints),
+		local.NewGetRoute("/containers/{name:.*}/capture", r.getContainerCapture),
 		// POST
 		local.NewPostRoute("/containers/create", r.postContainersCreate),
+		local.NewPostRoute("/containers/prune", r.postContainersPrune),
+		local.NewPostRoute("/containers/reindex", r.postContainersReindex),
 		local.NewPostRoute("/containers/{name:.*}/kill", r.postContainersKill),
 		local.NewPostRoute("/containers/{name:.*}/pause", r.postContainersPause),
 		local.NewPostRoute("/containers/{name:.*}/unpause", r.postContainersUnpause),
@@ -51,6 +58,7 @@ func (r *containerRouter) initRoutes() {
 		local.NewPostRoute("/containers/{name:.*}/stop", r.postContainersStop),
 		local.NewPostRoute("/containers/{name:.*}/wait", r.postContainersWait),
 		local.NewPostRoute("/containers/{name:.*}/resize", r.postContainersResize),
+		local.NewPostRoute("/containers/{name:.*}/input", r.postContainersInput),
 		local.NewPostRoute("/containers/{name:.*}/attach", r.postContainersAttach),
 		local.NewPostRoute("/containers/{name:.*}/copy", r.postContainersCopy),
 		local.NewPostRoute("/containers/{name:.*}/exec", r.postContainerExecCreate),
@@ -58,9 +66,15 @@ func (r *containerRouter) initRoutes() {
 		local.NewPostRoute("/exec/{name:.*}/resize", r.postContainerExecResize),
 		local.NewPostRoute("/containers/{name:.*}/rename", r.postContainerRename),
 		local.NewPostRoute("/containers/{name:.*}/update", r.postContainerUpdate),
+		local.NewPostRoute("/containers/{name:.*}/recover", r.postContainersRecover),
+		local.NewPostRoute("/containers/{name:.*}/checkpoints", r.postContainerCheckpoint),
+		local.NewPostRoute("/containers/{name:.*}/capture", r.postContainerCapture),
+		local.NewPostRoute("/containers/{name:.*}/drift", r.postContainerDrift),
 		// PUT
 		local.NewPutRoute("/containers/{name:.*}/archive", r.putContainersArchive),
 		// DELETE
+		local.NewDeleteRoute("/containers/{name:.*}/checkpoints/{checkpoint}", r.deleteContainerCheckpoint),
+		local.NewDeleteRoute("/containers/{name:.*}/capture", r.deleteContainerCapture),
 		local.NewDeleteRoute("/containers/{name:.*}", r.deleteContainers),
 	}
 }
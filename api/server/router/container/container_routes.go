@@ -1,8 +1,10 @@
 package container
 
 import (
+	"encoding/json"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"net/http"
 	"strconv"
 	"strings"
@@ -14,6 +16,7 @@ import (
 	"github.com/docker/docker/api/server/httputils"
 	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/filters"
 	timetypes "github.com/docker/docker/api/types/time"
 	"github.com/docker/docker/daemon"
 	derr "github.com/docker/docker/errors"
@@ -47,6 +50,14 @@ func (s *containerRouter) getContainersJSON(ctx context.Context, w http.Response
 		config.Limit = limit
 	}
 
+	if tmpOffset := r.Form.Get("offset"); tmpOffset != "" {
+		offset, err := strconv.Atoi(tmpOffset)
+		if err != nil {
+			return err
+		}
+		config.Offset = offset
+	}
+
 	containers, err := s.backend.Containers(config)
 	if err != nil {
 		return err
@@ -154,10 +165,27 @@ func (s *containerRouter) getContainersLogs(ctx context.Context, w http.Response
 }
 
 func (s *containerRouter) getContainersExport(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
-	return s.backend.ContainerExport(vars["name"], w)
+	return s.backend.ContainerExport(ctx, vars["name"], w)
+}
+
+func (s *containerRouter) getContainersRWLayer(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	if err := httputils.ParseForm(r); err != nil {
+		return err
+	}
+
+	pause := httputils.BoolValue(r, "pause")
+	if r.FormValue("pause") == "" {
+		pause = true
+	}
+
+	return s.backend.ContainerRWLayerExport(vars["name"], pause, w)
 }
 
 func (s *containerRouter) postContainersStart(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	if err := httputils.ParseForm(r); err != nil {
+		return err
+	}
+
 	// If contentLength is -1, we can assumed chunked encoding
 	// or more technically that the length is unknown
 	// https://golang.org/src/pkg/net/http/request.go#L139
@@ -178,7 +206,7 @@ func (s *containerRouter) postContainersStart(ctx context.Context, w http.Respon
 		hostConfig = c
 	}
 
-	if err := s.backend.ContainerStart(vars["name"], hostConfig); err != nil {
+	if err := s.backend.ContainerStart(ctx, vars["name"], hostConfig, r.Form.Get("checkpoint")); err != nil {
 		return err
 	}
 	w.WriteHeader(http.StatusNoContent)
@@ -190,9 +218,12 @@ func (s *containerRouter) postContainersStop(ctx context.Context, w http.Respons
 		return err
 	}
 
-	seconds, _ := strconv.Atoi(r.Form.Get("t"))
+	seconds, err := stopTimeoutValue(ctx, r)
+	if err != nil {
+		return err
+	}
 
-	if err := s.backend.ContainerStop(vars["name"], seconds); err != nil {
+	if err := s.backend.ContainerStop(ctx, vars["name"], seconds); err != nil {
 		return err
 	}
 	w.WriteHeader(http.StatusNoContent)
@@ -200,6 +231,26 @@ func (s *containerRouter) postContainersStop(ctx context.Context, w http.Respons
 	return nil
 }
 
+// stopTimeoutValue returns the number of seconds to wait for a container to
+// stop given in the "t" parameter of the request, or nil to use the stop
+// timeout of the container. Before API 1.22, a missing parameter means no
+// wait.
+func stopTimeoutValue(ctx context.Context, r *http.Request) (*int, error) {
+	t := r.Form.Get("t")
+	if t == "" {
+		if httputils.VersionFromContext(ctx).LessThan("1.22") {
+			seconds := 0
+			return &seconds, nil
+		}
+		return nil, nil
+	}
+	seconds, err := strconv.Atoi(t)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid timeout %q: %v", t, err)
+	}
+	return &seconds, nil
+}
+
 func (s *containerRouter) postContainersKill(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
 	if err := httputils.ParseForm(r); err != nil {
 		return err
@@ -238,9 +289,12 @@ func (s *containerRouter) postContainersRestart(ctx context.Context, w http.Resp
 		return err
 	}
 
-	timeout, _ := strconv.Atoi(r.Form.Get("t"))
+	timeout, err := stopTimeoutValue(ctx, r)
+	if err != nil {
+		return err
+	}
 
-	if err := s.backend.ContainerRestart(vars["name"], timeout); err != nil {
+	if err := s.backend.ContainerRestart(ctx, vars["name"], timeout); err != nil {
 		return err
 	}
 
@@ -263,6 +317,20 @@ func (s *containerRouter) postContainersPause(ctx context.Context, w http.Respon
 	return nil
 }
 
+func (s *containerRouter) postContainersRecover(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	if err := httputils.ParseForm(r); err != nil {
+		return err
+	}
+
+	if err := s.backend.ContainerRecover(vars["name"]); err != nil {
+		return err
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+
+	return nil
+}
+
 func (s *containerRouter) postContainersUnpause(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
 	if err := httputils.ParseForm(r); err != nil {
 		return err
@@ -278,7 +346,21 @@ func (s *containerRouter) postContainersUnpause(ctx context.Context, w http.Resp
 }
 
 func (s *containerRouter) postContainersWait(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
-	status, err := s.backend.ContainerWait(vars["name"], -1*time.Second)
+	// Stop waiting for the container if the client goes away.
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	if notifier, ok := w.(http.CloseNotifier); ok {
+		closeNotifier := notifier.CloseNotify()
+		go func() {
+			select {
+			case <-closeNotifier:
+				cancel()
+			case <-ctx.Done():
+			}
+		}()
+	}
+
+	status, err := s.backend.ContainerWait(ctx, vars["name"], -1*time.Second)
 	if err != nil {
 		return err
 	}
@@ -310,6 +392,49 @@ func (s *containerRouter) getContainersTop(ctx context.Context, w http.ResponseW
 	return httputils.WriteJSON(w, http.StatusOK, procList)
 }
 
+func (s *containerRouter) getContainersDu(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	if err := httputils.ParseForm(r); err != nil {
+		return err
+	}
+
+	path := r.Form.Get("path")
+	if path == "" {
+		path = "/"
+	}
+	depth := 1
+	if d := r.Form.Get("depth"); d != "" {
+		var err error
+		if depth, err = strconv.Atoi(d); err != nil {
+			return fmt.Errorf("Invalid depth %q: %v", d, err)
+		}
+	}
+
+	usage, err := s.backend.ContainerDu(ctx, vars["name"], path, depth)
+	if err != nil {
+		return err
+	}
+
+	return httputils.WriteJSON(w, http.StatusOK, usage)
+}
+
+func (s *containerRouter) getContainersPortCheck(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	if err := httputils.ParseForm(r); err != nil {
+		return err
+	}
+
+	port := r.Form.Get("port")
+	if port == "" {
+		return derr.ErrorCodeInvalidPortCheck.WithArgs("missing port")
+	}
+
+	check, err := s.backend.ContainerPortCheck(vars["name"], port)
+	if err != nil {
+		return err
+	}
+
+	return httputils.WriteJSON(w, http.StatusOK, check)
+}
+
 func (s *containerRouter) postContainerRename(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
 	if err := httputils.ParseForm(r); err != nil {
 		return err
@@ -332,13 +457,13 @@ func (s *containerRouter) postContainerUpdate(ctx context.Context, w http.Respon
 		return err
 	}
 
-	_, hostConfig, err := runconfig.DecodeContainerConfig(r.Body)
-	if err != nil {
+	var updateConfig container.UpdateConfig
+	if err := json.NewDecoder(r.Body).Decode(&updateConfig); err != nil {
 		return err
 	}
 
 	name := vars["name"]
-	warnings, err := s.backend.ContainerUpdate(name, hostConfig)
+	warnings, err := s.backend.ContainerUpdate(name, &updateConfig)
 	if err != nil {
 		return err
 	}
@@ -358,7 +483,7 @@ func (s *containerRouter) postContainersCreate(ctx context.Context, w http.Respo
 
 	name := r.Form.Get("name")
 
-	config, hostConfig, err := runconfig.DecodeContainerConfig(r.Body)
+	config, hostConfig, networkingConfig, err := runconfig.DecodeContainerConfig(r.Body)
 	if err != nil {
 		return err
 	}
@@ -366,10 +491,11 @@ func (s *containerRouter) postContainersCreate(ctx context.Context, w http.Respo
 	adjustCPUShares := version.LessThan("1.19")
 
 	ccr, err := s.backend.ContainerCreate(types.ContainerCreateConfig{
-		Name:            name,
-		Config:          config,
-		HostConfig:      hostConfig,
-		AdjustCPUShares: adjustCPUShares,
+		Name:             name,
+		Config:           config,
+		HostConfig:       hostConfig,
+		NetworkingConfig: networkingConfig,
+		AdjustCPUShares:  adjustCPUShares,
 	})
 	if err != nil {
 		return err
@@ -378,6 +504,30 @@ func (s *containerRouter) postContainersCreate(ctx context.Context, w http.Respo
 	return httputils.WriteJSON(w, http.StatusCreated, ccr)
 }
 
+func (s *containerRouter) postContainerDrift(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	if err := httputils.ParseForm(r); err != nil {
+		return err
+	}
+	if err := httputils.CheckForJSON(r); err != nil {
+		return err
+	}
+
+	config, hostConfig, networkingConfig, err := runconfig.DecodeContainerConfig(r.Body)
+	if err != nil {
+		return err
+	}
+
+	drift, err := s.backend.ContainerDrift(vars["name"], types.ContainerCreateConfig{
+		Config:           config,
+		HostConfig:       hostConfig,
+		NetworkingConfig: networkingConfig,
+	})
+	if err != nil {
+		return err
+	}
+	return httputils.WriteJSON(w, http.StatusOK, drift)
+}
+
 func (s *containerRouter) deleteContainers(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
 	if err := httputils.ParseForm(r); err != nil {
 		return err
@@ -403,6 +553,31 @@ func (s *containerRouter) deleteContainers(ctx context.Context, w http.ResponseW
 	return nil
 }
 
+func (s *containerRouter) postContainersPrune(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	if err := httputils.ParseForm(r); err != nil {
+		return err
+	}
+
+	pruneFilters, err := filters.FromParam(r.Form.Get("filters"))
+	if err != nil {
+		return err
+	}
+
+	pruneReport, err := s.backend.ContainersPrune(pruneFilters)
+	if err != nil {
+		return err
+	}
+	return httputils.WriteJSON(w, http.StatusOK, pruneReport)
+}
+
+func (s *containerRouter) postContainersReindex(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	report, err := s.backend.ReindexContainers()
+	if err != nil {
+		return err
+	}
+	return httputils.WriteJSON(w, http.StatusOK, report)
+}
+
 func (s *containerRouter) postContainersResize(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
 	if err := httputils.ParseForm(r); err != nil {
 		return err
@@ -420,6 +595,21 @@ func (s *containerRouter) postContainersResize(ctx context.Context, w http.Respo
 	return s.backend.ContainerResize(vars["name"], height, width)
 }
 
+func (s *containerRouter) postContainersInput(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	// Read one byte past the limit, so that the daemon rejects a larger input
+	data, err := ioutil.ReadAll(io.LimitReader(r.Body, daemon.MaxInputSize+1))
+	if err != nil {
+		return err
+	}
+
+	if err := s.backend.ContainerSendInput(vars["name"], data); err != nil {
+		return err
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
 func (s *containerRouter) postContainersAttach(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
 	err := httputils.ParseForm(r)
 	if err != nil {
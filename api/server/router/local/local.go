@@ -95,10 +95,13 @@ func (r *router) initRoutes() {
 		NewGetRoute("/images/{name:.*}/get", r.getImagesGet),
 		NewGetRoute("/images/{name:.*}/history", r.getImagesHistory),
 		NewGetRoute("/images/{name:.*}/json", r.getImagesByName),
+		NewGetRoute("/images/{name:.*}/raw", r.getImagesRaw),
+		NewGetRoute("/distribution/{name:.*}/json", r.getDistributionInfo),
 		// POST
 		NewPostRoute("/commit", r.postCommit),
 		NewPostRoute("/images/create", r.postImagesCreate),
 		NewPostRoute("/images/load", r.postImagesLoad),
+		NewPostRoute("/images/prune", r.postImagesPrune),
 		NewPostRoute("/images/{name:.*}/push", r.postImagesPush),
 		NewPostRoute("/images/{name:.*}/tag", r.postImagesTag),
 		// DELETE
@@ -14,6 +14,7 @@ import (
 	"github.com/docker/docker/daemon"
 	"github.com/docker/docker/runconfig"
 	"github.com/docker/libnetwork"
+	"github.com/docker/libnetwork/netlabel"
 )
 
 func (n *networkRouter) getNetworksList(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
@@ -92,7 +93,7 @@ func (n *networkRouter) postNetworkCreate(ctx context.Context, w http.ResponseWr
 		warning = fmt.Sprintf("Network with name %s (id : %s) already exists", nw.Name(), nw.ID())
 	}
 
-	nw, err = n.backend.CreateNetwork(create.Name, create.Driver, create.IPAM, create.Options)
+	nw, err = n.backend.CreateNetwork(create.Name, create.Driver, create.IPAM, create.Options, create.Internal)
 	if err != nil {
 		return err
 	}
@@ -161,7 +162,18 @@ func buildNetworkResource(nw libnetwork.Network) *types.NetworkResource {
 	r.ID = nw.ID()
 	r.Scope = nw.Info().Scope()
 	r.Driver = nw.Type()
+	// Internal is not part of the NetworkInfo interface of the vendored
+	// libnetwork yet, and the daemon marks the internal bridge networks in
+	// their options.
+	if info, ok := nw.Info().(interface {
+		Internal() bool
+	}); ok {
+		r.Internal = info.Internal()
+	}
 	r.Options = nw.Info().DriverOptions()
+	if r.Options[netlabel.Internal] == "true" {
+		r.Internal = true
+	}
 	r.Containers = make(map[string]types.EndpointResource)
 	buildIpamResources(r, nw)
 
@@ -11,6 +11,10 @@ import (
 type Backend interface {
 	SystemInfo() (*types.Info, error)
 	SystemVersion() types.Version
+	SystemDiskUsage() (*types.DiskUsage, error)
+	TrustKey() (*types.TrustKey, error)
+	RotateTrustKey() (*types.TrustKey, error)
+	MigrateStorage(targetDriver string, options []string) (*types.StorageMigration, error)
 	SubscribeToEvents(since, sinceNano int64, ef filters.Args) ([]events.Message, chan interface{})
 	UnsubscribeFromEvents(chan interface{})
 	AuthenticateToRegistry(authConfig *types.AuthConfig) (string, error)
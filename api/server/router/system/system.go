@@ -25,7 +25,11 @@ func NewRouter(b Backend) router.Router {
 		local.NewGetRoute("/events", r.getEvents),
 		local.NewGetRoute("/info", r.getInfo),
 		local.NewGetRoute("/version", r.getVersion),
+		local.NewGetRoute("/system/df", r.getDiskUsage),
+		local.NewGetRoute("/system/trustkey", r.getTrustKey),
 		local.NewPostRoute("/auth", r.postAuth),
+		local.NewPostRoute("/system/trustkey/rotate", r.postTrustKeyRotate),
+		local.NewPostRoute("/system/storage/migrate", r.postStorageMigrate),
 	}
 
 	return r
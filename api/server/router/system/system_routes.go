@@ -42,6 +42,45 @@ func (s *systemRouter) getVersion(ctx context.Context, w http.ResponseWriter, r
 	return httputils.WriteJSON(w, http.StatusOK, info)
 }
 
+func (s *systemRouter) getDiskUsage(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	du, err := s.backend.SystemDiskUsage()
+	if err != nil {
+		return err
+	}
+
+	return httputils.WriteJSON(w, http.StatusOK, du)
+}
+
+func (s *systemRouter) getTrustKey(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	key, err := s.backend.TrustKey()
+	if err != nil {
+		return err
+	}
+
+	return httputils.WriteJSON(w, http.StatusOK, key)
+}
+
+func (s *systemRouter) postTrustKeyRotate(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	key, err := s.backend.RotateTrustKey()
+	if err != nil {
+		return err
+	}
+
+	return httputils.WriteJSON(w, http.StatusOK, key)
+}
+
+func (s *systemRouter) postStorageMigrate(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	if err := httputils.ParseForm(r); err != nil {
+		return err
+	}
+	migration, err := s.backend.MigrateStorage(r.Form.Get("driver"), r.Form["opt"])
+	if err != nil {
+		return err
+	}
+
+	return httputils.WriteJSON(w, http.StatusOK, migration)
+}
+
 func (s *systemRouter) getEvents(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
 	if err := httputils.ParseForm(r); err != nil {
 		return err
@@ -3,6 +3,7 @@ package volume
 import (
 	// TODO return types need to be refactored into pkg
 	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/filters"
 )
 
 // Backend is the methods that need to be implemented to provide
@@ -11,6 +12,7 @@ type Backend interface {
 	Volumes(filter string) ([]*types.Volume, error)
 	VolumeInspect(name string) (*types.Volume, error)
 	VolumeCreate(name, driverName string,
-		opts map[string]string) (*types.Volume, error)
+		opts, labels map[string]string) (*types.Volume, error)
 	VolumeRm(name string) error
+	VolumesPrune(pruneFilters filters.Args, dryRun bool) (*types.VolumesPruneReport, error)
 }
@@ -46,6 +46,10 @@ type Server struct {
 	servers      []*HTTPServer
 	routers      []router.Router
 	authZPlugins []authorization.Plugin
+	peers        *peerConns
+	// restoring tells whether the daemon is restoring its containers in
+	// the background.
+	restoring func() bool
 }
 
 // Addr contains string representation of address and its protocol (tcp, unix...).
@@ -58,7 +62,8 @@ type Addr struct {
 // It allocates resources which will be needed for ServeAPI(ports, unix-sockets).
 func New(cfg *Config) (*Server, error) {
 	s := &Server{
-		cfg: cfg,
+		cfg:   cfg,
+		peers: newPeerConns(),
 	}
 	for _, addr := range cfg.Addrs {
 		srv, err := s.newServer(addr.Proto, addr.Addr)
@@ -146,8 +151,13 @@ func (s *Server) initTCPSocket(addr string) (l net.Listener, err error) {
 
 func (s *Server) makeHTTPHandler(handler httputils.APIFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		// log the handler call
-		logrus.Debugf("Calling %s %s", r.Method, r.URL.Path)
+		// log the handler call, with the identity of the client on local
+		// sockets
+		if id := s.peers.get(r.RemoteAddr); id != nil {
+			logrus.Debugf("Calling %s %s from %s", r.Method, r.URL.Path, id)
+		} else {
+			logrus.Debugf("Calling %s %s", r.Method, r.URL.Path)
+		}
 
 		// Define the context that we'll pass around to share info
 		// like the docker-request-id.
@@ -155,8 +165,20 @@ func (s *Server) makeHTTPHandler(handler httputils.APIFunc) http.HandlerFunc {
 		// The 'context' will be used for global data that should
 		// apply to all requests. Data that is specific to the
 		// immediate function being called should still be passed
-		// as 'args' on the function call.
-		ctx := context.Background()
+		// as 'args' on the function call. It is cancelled when the
+		// client goes away before the request is done.
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		if notifier, ok := w.(http.CloseNotifier); ok {
+			closed := notifier.CloseNotify()
+			go func() {
+				select {
+				case <-closed:
+					cancel()
+				case <-ctx.Done():
+				}
+			}()
+		}
 		handlerFunc := s.handleWithGlobalMiddlewares(handler)
 
 		vars := mux.Vars(r)
@@ -173,6 +195,7 @@ func (s *Server) makeHTTPHandler(handler httputils.APIFunc) http.HandlerFunc {
 
 // InitRouters initializes a list of routers for the server.
 func (s *Server) InitRouters(d *daemon.Daemon) {
+	s.restoring = d.IsRestoring
 	s.addRouter(container.NewRouter(d))
 	s.addRouter(local.NewRouter(d))
 	s.addRouter(network.NewRouter(d))
@@ -40,7 +40,7 @@ func (s *Server) newServer(proto, addr string) ([]*HTTPServer, error) {
 		if err != nil {
 			return nil, fmt.Errorf("can't create unix socket %s: %v", addr, err)
 		}
-		ls = append(ls, l)
+		ls = append(ls, s.newPeerListener(l, identifyUnixConn))
 	default:
 		return nil, fmt.Errorf("Invalid protocol format: %q", proto)
 	}
@@ -20,9 +20,15 @@ func (s *Server) newServer(proto, addr string) ([]*HTTPServer, error) {
 			return nil, err
 		}
 		ls = append(ls, l)
+	case "npipe":
+		l, err := newPipeListener(addr)
+		if err != nil {
+			return nil, err
+		}
+		ls = append(ls, s.newPeerListener(l, identifyPipeConn))
 
 	default:
-		return nil, errors.New("Invalid protocol format. Windows only supports tcp.")
+		return nil, errors.New("Invalid protocol format. Windows only supports tcp and npipe.")
 	}
 
 	var res []*HTTPServer
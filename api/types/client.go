@@ -49,6 +49,7 @@ type ContainerListOptions struct {
 	Since  string
 	Before string
 	Limit  int
+	Offset int
 	Filter filters.Args
 }
 
@@ -136,12 +137,22 @@ type ImageBuildOptions struct {
 	Memory         int64
 	MemorySwap     int64
 	CgroupParent   string
+	NetworkMode    string
+	DNS            []string
 	ShmSize        string
 	Dockerfile     string
 	Ulimits        []*units.Ulimit
 	BuildArgs      []string
 	AuthConfigs    map[string]AuthConfig
 	Context        io.Reader
+
+	// SecretBuildArgs are build args whose values are kept out of the
+	// image history and of the build cache key.
+	SecretBuildArgs []string
+	// VCSRef and VCSURL are the revision and location of the sources of
+	// the build, used to label the image.
+	VCSRef string
+	VCSURL string
 }
 
 // ImageBuildResponse holds information
@@ -22,6 +22,40 @@ func (i IsolationLevel) IsDefault() bool {
 	return strings.ToLower(string(i)) == "default" || string(i) == ""
 }
 
+// PrivilegeProfile is a named set of extended privileges given to a
+// container, short of the full privileges of the privileged mode.
+type PrivilegeProfile string
+
+const (
+	// PrivilegeProfileDevices gives access to all the devices of the host,
+	// with the default capabilities and security confinement.
+	PrivilegeProfileDevices PrivilegeProfile = "devices"
+	// PrivilegeProfileCapabilities gives all the capabilities, with the
+	// default devices and security confinement.
+	PrivilegeProfileCapabilities PrivilegeProfile = "capabilities"
+)
+
+// Valid indicates whether the privilege profile is a known profile, or
+// empty for the default privileges.
+func (p PrivilegeProfile) Valid() bool {
+	switch p {
+	case "", PrivilegeProfileDevices, PrivilegeProfileCapabilities:
+		return true
+	}
+	return false
+}
+
+// AllDevices indicates whether the profile gives access to all the devices
+// of the host.
+func (p PrivilegeProfile) AllDevices() bool {
+	return p == PrivilegeProfileDevices
+}
+
+// AllCapabilities indicates whether the profile gives all the capabilities.
+func (p PrivilegeProfile) AllCapabilities() bool {
+	return p == PrivilegeProfileCapabilities
+}
+
 // IpcMode represents the container ipc stack.
 type IpcMode string
 
@@ -89,6 +123,30 @@ func (n UTSMode) Valid() bool {
 	return true
 }
 
+// UsernsMode represents the user namespace of the container.
+type UsernsMode string
+
+// IsHost indicates whether the container uses the host's user namespace.
+func (n UsernsMode) IsHost() bool {
+	return n == "host"
+}
+
+// IsPrivate indicates whether the container uses the remapped user namespace
+// of the daemon, if any.
+func (n UsernsMode) IsPrivate() bool {
+	return !(n.IsHost())
+}
+
+// Valid indicates whether the user namespace is valid.
+func (n UsernsMode) Valid() bool {
+	switch n {
+	case "", "host":
+	default:
+		return false
+	}
+	return true
+}
+
 // PidMode represents the pid stack of the container.
 type PidMode string
 
@@ -151,6 +209,11 @@ func (rp *RestartPolicy) IsUnlessStopped() bool {
 	return rp.Name == "unless-stopped"
 }
 
+// IsSame compares two RestartPolicy to see if they are the same
+func (rp *RestartPolicy) IsSame(tp *RestartPolicy) bool {
+	return rp.Name == tp.Name && rp.MaximumRetryCount == tp.MaximumRetryCount
+}
+
 // LogConfig represents the logging configuration of the container.
 type LogConfig struct {
 	Type   string
@@ -184,6 +247,14 @@ type Resources struct {
 	Ulimits              []*units.Ulimit // List of ulimits to be set in the container
 }
 
+// UpdateConfig holds the mutable attributes of a Container.
+// Those attributes can be updated at runtime.
+type UpdateConfig struct {
+	// Contains container's resources (cgroups, ulimits)
+	Resources
+	RestartPolicy RestartPolicy
+}
+
 // HostConfig the non-portable Config structure of a container.
 // Here, "non-portable" means "dependent of the host we are running on".
 // Portable information *should* appear in Config.
@@ -199,24 +270,35 @@ type HostConfig struct {
 	VolumesFrom     []string      // List of volumes to take from other container
 
 	// Applicable to UNIX platforms
-	CapAdd          *strslice.StrSlice // List of kernel capabilities to add to the container
-	CapDrop         *strslice.StrSlice // List of kernel capabilities to remove from the container
-	DNS             []string           `json:"Dns"`        // List of DNS server to lookup
-	DNSOptions      []string           `json:"DnsOptions"` // List of DNSOption to look for
-	DNSSearch       []string           `json:"DnsSearch"`  // List of DNSSearch to look for
-	ExtraHosts      []string           // List of extra hosts
-	GroupAdd        []string           // List of additional groups that the container process will run as
-	IpcMode         IpcMode            // IPC namespace to use for the container
-	Links           []string           // List of links (in the name:alias form)
-	OomScoreAdj     int                // Container preference for OOM-killing
-	PidMode         PidMode            // PID namespace to use for the container
-	Privileged      bool               // Is the container in privileged mode
-	PublishAllPorts bool               // Should docker publish all exposed port for the container
-	ReadonlyRootfs  bool               // Is the container root filesystem in read-only
-	SecurityOpt     []string           // List of string values to customize labels for MLS systems, such as SELinux.
-	Tmpfs           map[string]string  `json:",omitempty"` // List of tmpfs (mounts) used for the container
-	UTSMode         UTSMode            // UTS namespace to use for the container
-	ShmSize         *int64             // Total shm memory usage
+	CapAdd            *strslice.StrSlice // List of kernel capabilities to add to the container
+	CapDrop           *strslice.StrSlice // List of kernel capabilities to remove from the container
+	CPUIsolationGroup string             `json:"CpuIsolationGroup"` // CPU isolation group of the container, sharing exclusive physical cores
+	CPUIsolationCores int                `json:"CpuIsolationCores"` // Number of physical cores of the CPU isolation group
+	DNS               []string           `json:"Dns"`               // List of DNS server to lookup
+	DNSOptions        []string           `json:"DnsOptions"`        // List of DNSOption to look for
+	DNSSearch         []string           `json:"DnsSearch"`         // List of DNSSearch to look for
+	ExtraHosts        []string           // List of extra hosts
+	GroupAdd          []string           // List of additional groups that the container process will run as
+	IccAllow          []string           `json:",omitempty"` // Containers allowed to communicate with the container on networks with ICC disabled
+	IccDeny           []string           `json:",omitempty"` // Containers denied to communicate with the container on networks with ICC enabled
+	IpcMode           IpcMode            // IPC namespace to use for the container
+	KernelModules     []string           `json:",omitempty"` // Kernel modules required by the container, verified before it starts
+	Links             []string           // List of links (in the name:alias form)
+	LinkEnv           *bool              `json:",omitempty"` // Inject the environment variables of links on user-defined networks, the daemon default if nil
+	NoBaselineMounts  bool               // Do not bind mount the baseline mounts of the daemon
+	OomScoreAdj       int                // Container preference for OOM-killing
+	PidMode           PidMode            // PID namespace to use for the container
+	Privileged        bool               // Is the container in privileged mode
+	PrivilegeProfile  PrivilegeProfile   `json:",omitempty"` // Extended privileges given to the container, short of the privileged mode
+	PublishAllPorts   bool               // Should docker publish all exposed port for the container
+	ReadonlyRootfs    bool               // Is the container root filesystem in read-only
+	SecurityOpt       []string           // List of string values to customize labels for MLS systems, such as SELinux.
+	StorageOpt        map[string]string  `json:",omitempty"` // Storage driver options of the read-write layer of the container, such as its size
+	Timezone          string             // Timezone of the container, materialized as /etc/localtime and TZ
+	Tmpfs             map[string]string  `json:",omitempty"` // List of tmpfs (mounts) used for the container
+	UTSMode           UTSMode            // UTS namespace to use for the container
+	UsernsMode        UsernsMode         // The user namespace to use for the container
+	ShmSize           *int64             // Total shm memory usage
 
 	// Applicable to Windows
 	ConsoleSize [2]int         // Initial console size
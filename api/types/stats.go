@@ -102,4 +102,17 @@ type StatsJSON struct {
 
 	// Networks request version >=1.21
 	Networks map[string]NetworkStats `json:"networks,omitempty"`
+
+	// Pids are the pids of the processes running in the container
+	Pids []int `json:"pids,omitempty"`
+	// Subcgroups is the usage of the cgroups created inside the container,
+	// by path relative to the cgroup of the container
+	Subcgroups map[string]SubcgroupStats `json:"subcgroups,omitempty"`
+}
+
+// SubcgroupStats aggregates the stats of a cgroup created inside a container
+type SubcgroupStats struct {
+	CPUStats    CPUStats    `json:"cpu_stats,omitempty"`
+	MemoryStats MemoryStats `json:"memory_stats,omitempty"`
+	BlkioStats  BlkioStats  `json:"blkio_stats,omitempty"`
 }
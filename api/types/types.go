@@ -87,6 +87,7 @@ type Image struct {
 	RepoTags    []string
 	RepoDigests []string
 	Created     int64
+	LastUsed    int64
 	Size        int64
 	VirtualSize int64
 	Labels      map[string]string
@@ -120,6 +121,36 @@ type ImageInspect struct {
 	GraphDriver     GraphDriverData
 }
 
+// ImageRaw contains the configuration of an image and the manifests it was
+// pulled with, byte for byte: GET "/images/{name:.*}/raw"
+type ImageRaw struct {
+	ID        string             // ID is the digest of Config
+	Config    []byte             // Config is the configuration of the image, as stored by the daemon
+	Manifests []ImageRawManifest // Manifests are the manifests the image was pulled with, the most recent last
+}
+
+// ImageRawManifest is a manifest an image was pulled with, as received from
+// the registry
+type ImageRawManifest struct {
+	Digest   string
+	Manifest []byte
+}
+
+// RemoteImageInspect contains the configuration of an image in a registry,
+// fetched without pulling the image: GET "/distribution/{name:.*}/json"
+type RemoteImageInspect struct {
+	Name          string // Name is the reference of the image, with its tag
+	Digest        string // Digest is the digest of the manifest of the image
+	Created       string
+	Comment       string
+	DockerVersion string
+	Author        string
+	Config        *container.Config
+	Architecture  string
+	Os            string
+	Layers        []string // Layers are the digests of the layers of the image, from the base layer
+}
+
 // Port stores open ports info of container
 // e.g. {"PrivatePort": 8080, "PublicPort": 80, "Type": "tcp"}
 type Port struct {
@@ -166,6 +197,37 @@ type ContainerPathStat struct {
 	LinkTarget string      `json:"linkTarget"`
 }
 
+// ContainerPathUsage contains response of Remote API:
+// GET "/containers/{name:.*}/du"
+// "Size" is the size in bytes of the files under the path, and "Children"
+// the usage of its entries down to the requested depth, largest first.
+type ContainerPathUsage struct {
+	Name     string
+	Size     int64
+	Children []ContainerPathUsage `json:",omitempty"`
+}
+
+// ContainerPortCheck contains response of Remote API:
+// GET "/containers/{name:.*}/portcheck"
+// "Listening" tells whether a process listens on the port in the network
+// namespace of the container, on the "ListenAddresses", and "Bindings" are
+// the probes of the host ports the port is published on.
+type ContainerPortCheck struct {
+	Port            string
+	Listening       bool
+	ListenAddresses []string           `json:",omitempty"`
+	Bindings        []PortBindingCheck `json:",omitempty"`
+}
+
+// PortBindingCheck is the probe of a host port a container port is
+// published on. "Error" tells why the port is not "Reachable".
+type PortBindingCheck struct {
+	HostIP    string
+	HostPort  string
+	Reachable bool
+	Error     string `json:",omitempty"`
+}
+
 // ContainerProcessList contains response of Remote API:
 // GET "/containers/{name:.*}/top"
 type ContainerProcessList struct {
@@ -233,6 +295,42 @@ type Info struct {
 	ServerVersion      string
 	ClusterStore       string
 	ClusterAdvertise   string
+	RestoreStatus      *RestoreStatus `json:",omitempty"`
+	StorageStatus      *StorageStatus `json:",omitempty"`
+}
+
+// StorageStatus is the state of the storage of the daemon when it is
+// degraded, because no space is left on it or it is read-only. It is part of
+// the Info struct.
+type StorageStatus struct {
+	Degraded bool
+	// Error is the error the storage was degraded by
+	Error string
+	// Since is the time the storage was degraded at
+	Since time.Time
+}
+
+// StorageMigration is the result of the migration of the storage of the
+// daemon to another storage driver.
+type StorageMigration struct {
+	// Driver is the storage driver the storage was migrated from
+	Driver string
+	// TargetDriver is the storage driver the daemon uses after its restart
+	TargetDriver string
+	Images       int
+	Layers       int
+	Containers   int
+}
+
+// RestoreStatus is the progress of the restore of the containers when the
+// daemon restores them in the background. It is part of the Info struct.
+type RestoreStatus struct {
+	// InProgress is true until the containers are loaded and restarted
+	InProgress bool
+	// Loaded is the number of containers loaded so far
+	Loaded int
+	// Total is the number of containers to load
+	Total int
 }
 
 // PluginsInfo is temp struct holds Plugins name
@@ -264,11 +362,75 @@ type ContainerState struct {
 	Restarting bool
 	OOMKilled  bool
 	Dead       bool
+	Broken     bool
 	Pid        int
 	ExitCode   int
+	ExitSignal int    `json:",omitempty"` // ExitSignal is the signal which terminated the process
+	ExitReason string `json:",omitempty"` // ExitReason describes why the process terminated
 	Error      string
+	LastError  *MountError `json:",omitempty"`
 	StartedAt  string
 	FinishedAt string
+	Health     *Health `json:",omitempty"`
+	// DesiredState is the state the container is converged to, "running" or
+	// "stopped", if it was ever started or stopped by a request
+	DesiredState string `json:",omitempty"`
+	// Transitions are the last changes of Status, oldest first
+	Transitions []*StateTransition `json:",omitempty"`
+	// RestartHistory are the last runs of the process, oldest first
+	RestartHistory []*ContainerExit `json:",omitempty"`
+}
+
+// ContainerExit records a run of the process of a container
+type ContainerExit struct {
+	Start    time.Time // Start is the time the process was started
+	End      time.Time // End is the time the process exited
+	ExitCode int       // ExitCode is the exit code of the process
+	Reason   string    // Reason is one of "exited", "oom", "stopped" or "error"
+	Error    string    `json:",omitempty"` // Error is the error running the process, with the "error" reason
+}
+
+// StateTransition records a change of the status of a container
+type StateTransition struct {
+	From   string    // From is the status of the container before the transition
+	To     string    // To is the status of the container after the transition
+	Time   time.Time // Time is the time of the transition
+	Reason string    // Reason is the operation or event which caused the transition
+}
+
+// MountError describes the last failure of the graph driver to mount the
+// read-write layer of a container.
+type MountError struct {
+	Time       time.Time
+	Driver     string   // name of the graph driver
+	Error      string   // error returned by the graph driver
+	MountID    string   // ID of the read-write layer in the graph driver
+	MountLabel string   // label the layer was mounted with
+	Options    []string // storage options of the graph driver
+	Layers     []string // chain IDs of the layers of the image, from the top
+}
+
+// Health states
+const (
+	NoHealthcheck = "none"      // Indicates there is no healthcheck
+	Starting      = "starting"  // Starting indicates that the container is not yet ready
+	Healthy       = "healthy"   // Healthy indicates that the container is running correctly
+	Unhealthy     = "unhealthy" // Unhealthy indicates that the container has a problem
+)
+
+// Health stores information about the container's healthcheck results
+type Health struct {
+	Status        string               // Status is one of Starting, Healthy or Unhealthy
+	FailingStreak int                  // FailingStreak is the number of consecutive failures
+	Log           []*HealthcheckResult // Log contains the last few results (oldest first)
+}
+
+// HealthcheckResult stores information about a single run of a healthcheck probe
+type HealthcheckResult struct {
+	Start    time.Time // Start is the time this check started
+	End      time.Time // End is the time this check ended
+	ExitCode int       // ExitCode meanings: 0=healthy, 1=unhealthy, others=unhealthy
+	Output   string    // Output from last check
 }
 
 // ContainerJSONBase contains response of Remote API:
@@ -293,6 +455,7 @@ type ContainerJSONBase struct {
 	ExecIDs         []string
 	HostConfig      *container.HostConfig
 	GraphDriver     GraphDriverData
+	IsolatedCpus    string `json:",omitempty"`
 	SizeRw          *int64 `json:",omitempty"`
 	SizeRootFs      *int64 `json:",omitempty"`
 }
@@ -303,6 +466,18 @@ type ContainerJSON struct {
 	Mounts          []MountPoint
 	Config          *container.Config
 	NetworkSettings *NetworkSettings
+	EffectiveEnv    []ContainerEnvVar `json:",omitempty"`
+}
+
+// ContainerEnvVar is a variable of the environment of a container, with the
+// origin of its value: "image" when it is inherited from the image, or
+// "container" when it is set when the container is created. ImageValue is
+// the value of the image overridden by the container, if any.
+type ContainerEnvVar struct {
+	Name       string
+	Value      string
+	Source     string
+	ImageValue string `json:",omitempty"`
 }
 
 // NetworkSettings exposes the network settings in the api
@@ -347,6 +522,7 @@ type DefaultNetworkSettings struct {
 
 // MountPoint represents a mount point configuration inside the container.
 type MountPoint struct {
+	Type        string `json:",omitempty"` // Type is one of "bind", "volume" or "tmpfs"
 	Name        string `json:",omitempty"`
 	Source      string
 	Destination string
@@ -356,11 +532,19 @@ type MountPoint struct {
 	Propagation string
 }
 
+// The types of the mounts of a container
+const (
+	MountTypeBind   = "bind"
+	MountTypeVolume = "volume"
+	MountTypeTmpfs  = "tmpfs"
+)
+
 // Volume represents the configuration of a volume for the remote API
 type Volume struct {
-	Name       string // Name is the name of the volume
-	Driver     string // Driver is the Driver name used to create the volume
-	Mountpoint string // Mountpoint is the location on disk of the volume
+	Name       string            // Name is the name of the volume
+	Driver     string            // Driver is the Driver name used to create the volume
+	Mountpoint string            // Mountpoint is the location on disk of the volume
+	Labels     map[string]string // Labels holds the labels set on the volume at creation time
 }
 
 // VolumesListResponse contains the response for the remote API:
@@ -375,6 +559,129 @@ type VolumeCreateRequest struct {
 	Name       string            // Name is the requested name of the volume
 	Driver     string            // Driver is the name of the driver that should be used to create the volume
 	DriverOpts map[string]string // DriverOpts holds the driver specific options to use for when creating the volume.
+	Labels     map[string]string // Labels holds metadata specific to the volume being created.
+}
+
+// VolumePruneItem describes a volume removed by the remote API:
+// POST "/volumes/prune"
+type VolumePruneItem struct {
+	Name           string
+	SpaceReclaimed uint64
+}
+
+// VolumesPruneReport contains the response for the remote API:
+// POST "/volumes/prune"
+type VolumesPruneReport struct {
+	VolumesDeleted []VolumePruneItem
+	SpaceReclaimed uint64
+	DryRun         bool
+}
+
+// DiskUsageSummary summarizes the disk usage of a class of objects.
+type DiskUsageSummary struct {
+	TotalCount  int   // TotalCount is the number of objects of the class
+	Active      int   // Active is the number of objects in use
+	Size        int64 // Size is the disk space used by the objects, in bytes
+	Reclaimable int64 // Reclaimable is the disk space used by the objects not in use, in bytes
+}
+
+// ImageDiskUsage reports the disk usage of an image.
+type ImageDiskUsage struct {
+	ID         string `json:"Id"`
+	RepoTags   []string
+	Size       int64 // Size is the total size of the layers of the image
+	SharedSize int64 // SharedSize is the size of the layers shared with other images
+	Containers int   // Containers is the number of containers using the image
+}
+
+// ContainerDiskUsage reports the disk usage of a container.
+type ContainerDiskUsage struct {
+	ID         string `json:"Id"`
+	Name       string
+	Image      string
+	State      string
+	SizeRw     int64
+	SizeRootFs int64
+}
+
+// VolumeDiskUsage reports the disk usage of a volume.
+type VolumeDiskUsage struct {
+	Name     string
+	Driver   string
+	Size     int64 // Size is -1 when it cannot be computed
+	RefCount uint  // RefCount is the number of containers using the volume
+}
+
+// DiskUsage contains the response for the remote API:
+// GET "/system/df"
+type DiskUsage struct {
+	Images           DiskUsageSummary
+	Containers       DiskUsageSummary
+	Volumes          DiskUsageSummary
+	ImageDetails     []ImageDiskUsage
+	ContainerDetails []ContainerDiskUsage
+	VolumeDetails    []VolumeDiskUsage
+}
+
+// TrustKey contains the response for the remote API:
+// GET "/system/trustkey" and POST "/system/trustkey/rotate"
+type TrustKey struct {
+	ID        string // ID is the libtrust key ID of the trust key
+	PublicKey string // PublicKey is the PEM encoded public key
+}
+
+// CheckpointCreateOptions holds the parameters to create a checkpoint
+// of a container: POST "/containers/{name:.*}/checkpoints"
+type CheckpointCreateOptions struct {
+	CheckpointID   string // CheckpointID is the name of the checkpoint
+	Exit           bool   // Exit stops the container once the checkpoint is created
+	TCPEstablished bool   // TCPEstablished checkpoints established TCP connections
+}
+
+// Checkpoint represents the details of a checkpoint of a container
+type Checkpoint struct {
+	Name           string
+	Created        time.Time
+	TCPEstablished bool
+}
+
+// ContainerCaptureOptions holds the parameters to capture the traffic of a
+// container: POST "/containers/{name:.*}/capture"
+type ContainerCaptureOptions struct {
+	Network   string // Network is the network of the container to capture the traffic on
+	Interface string // Interface is the host interface to mirror the traffic to, the traffic is written to a pcap file if empty
+	Duration  int    // Duration is the number of seconds after which the capture is stopped
+	MaxBytes  int64  // MaxBytes is the maximum size of the pcap file
+}
+
+// ContainerCapture represents a running capture of the traffic of a container
+type ContainerCapture struct {
+	Network       string
+	HostInterface string // HostInterface is the host end of the veth pair of the container
+	Interface     string `json:",omitempty"`
+	Path          string `json:",omitempty"`
+	Started       time.Time
+	Expires       time.Time
+}
+
+// ContainerDrift contains the differences between the configuration of a
+// container and a desired configuration: POST "/containers/{name:.*}/drift"
+type ContainerDrift struct {
+	Drifted bool          // Drifted is true if the container must be recreated to match the desired configuration
+	Image   *DriftChange  `json:",omitempty"`
+	Env     []DriftChange // Env are the changed environment variables, by name
+	Ports   []DriftChange // Ports are the changed port bindings, by container port
+	Mounts  []DriftChange // Mounts are the changed mounts, by destination
+}
+
+// DriftChange is a setting of a container which differs from the desired
+// configuration. Kind is "add" if only the desired configuration has it,
+// "remove" if only the container has it, or "change".
+type DriftChange struct {
+	Kind    string
+	Key     string
+	Current string `json:",omitempty"`
+	Desired string `json:",omitempty"`
 }
 
 // NetworkResource is the body of the "get network" http response message
@@ -384,6 +691,7 @@ type NetworkResource struct {
 	Scope      string
 	Driver     string
 	IPAM       network.IPAM
+	Internal   bool
 	Containers map[string]EndpointResource
 	Options    map[string]string
 }
@@ -403,6 +711,7 @@ type NetworkCreate struct {
 	CheckDuplicate bool
 	Driver         string
 	IPAM           network.IPAM
+	Internal       bool
 	Options        map[string]string
 }
 
@@ -421,3 +730,57 @@ type NetworkConnect struct {
 type NetworkDisconnect struct {
 	Container string
 }
+
+// ContainersPruneReport contains the response for the remote API:
+// POST "/containers/prune"
+type ContainersPruneReport struct {
+	ContainersDeleted []string
+	SpaceReclaimed    uint64
+}
+
+// ContainerNameChange is a container renamed by a reindex because another
+// container had the same name.
+type ContainerNameChange struct {
+	ID      string `json:"Id"`
+	OldName string
+	NewName string
+}
+
+// ContainersReindexReport contains the response for the remote API:
+// POST "/containers/reindex"
+type ContainersReindexReport struct {
+	IDsAdded          []string              // IDsAdded are the IDs missing from the ID index
+	IDsRemoved        []string              // IDsRemoved are the IDs of the index without container
+	NamesAdded        []string              // NamesAdded are the names missing from the name graph
+	NamesRemoved      []string              // NamesRemoved are the dangling or stale names of the graph
+	NamesChanged      []ContainerNameChange // NamesChanged are the containers renamed to fix duplicate names
+	ContainersRemoved []string              // ContainersRemoved are the containers without directory on disk
+	ContainersLoaded  []string              // ContainersLoaded are the containers on disk which were not loaded
+}
+
+// ContainerRWLayerManifest describes the read-write layer of a container
+// exported by the remote API GET "/containers/{name:.*}/rwlayer". It is
+// stored as manifest.json next to the layer tar in the export.
+type ContainerRWLayerManifest struct {
+	ContainerID string
+	// Image is the ID of the image of the container, the digest of its
+	// configuration.
+	Image string
+	// ConfigDigest is the digest of the configuration and the host
+	// configuration of the container.
+	ConfigDigest string
+	// Layer is the name of the layer tar in the export.
+	Layer       string
+	LayerDigest string
+	LayerSize   int64
+	// Paused is set if the container was paused while its layer was read.
+	Paused  bool
+	Created string
+}
+
+// ImagesPruneReport contains the response for the remote API:
+// POST "/images/prune"
+type ImagesPruneReport struct {
+	ImagesDeleted  []ImageDelete
+	SpaceReclaimed uint64
+}
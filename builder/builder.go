@@ -114,6 +114,9 @@ type Backend interface {
 	ContainerKill(containerID string, sig uint64) error
 	// Start starts a new container
 	ContainerStart(containerID string, hostConfig *container.HostConfig) error
+	// ContainerStartWithEnv starts a new container, adding env to the
+	// environment of its process without storing it in its config.
+	ContainerStartWithEnv(containerID string, env []string) error
 	// ContainerWait stops processing until the given container is stopped.
 	ContainerWait(containerID string, timeout time.Duration) (int, error)
 
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"io"
 	"io/ioutil"
+	"net"
 	"os"
 	"strings"
 	"sync"
@@ -67,6 +68,19 @@ type Config struct {
 	CPUSetMems   string
 	CgroupParent string
 	Ulimits      []*units.Ulimit
+
+	// NetworkMode is the network the intermediate containers are connected
+	// to: "none", "default" or the name of a user-defined network.
+	NetworkMode string
+	// DNS overrides the DNS servers of the intermediate containers.
+	DNS []string
+
+	// SecretBuildArgs are the names of the build args whose values are
+	// only passed to the environment of 'run', and are kept out of the
+	// image history and of the build cache key.
+	SecretBuildArgs map[string]bool
+	// Labels are added to the image once the Dockerfile is processed.
+	Labels map[string]string
 }
 
 // Builder is a Dockerfile builder
@@ -108,6 +122,9 @@ func NewBuilder(config *Config, docker builder.Backend, context builder.Context,
 	if config.BuildArgs == nil {
 		config.BuildArgs = make(map[string]string)
 	}
+	if err := validateNetworkConfig(config); err != nil {
+		return nil, err
+	}
 	b = &Builder{
 		Config:           config,
 		Stdout:           os.Stdout,
@@ -130,6 +147,21 @@ func NewBuilder(config *Config, docker builder.Backend, context builder.Context,
 	return b, nil
 }
 
+// validateNetworkConfig checks that the intermediate containers of a build
+// are only given their own network stack, so that the network of a build
+// cannot be shared with the host or with another container.
+func validateNetworkConfig(config *Config) error {
+	if config.NetworkMode == "host" || strings.HasPrefix(config.NetworkMode, "container:") {
+		return fmt.Errorf("Network mode %q is not supported for builds", config.NetworkMode)
+	}
+	for _, dns := range config.DNS {
+		if net.ParseIP(dns) == nil {
+			return fmt.Errorf("%s is not a valid DNS server address", dns)
+		}
+	}
+	return nil
+}
+
 // Build runs the Dockerfile builder from a context and a docker object that allows to make calls
 // to Docker.
 //
@@ -190,6 +222,20 @@ func (b *Builder) Build() (string, error) {
 		return "", fmt.Errorf("No image was generated. Is your Dockerfile empty?")
 	}
 
+	if len(b.Labels) > 0 {
+		if err := b.addLabels(b.Labels); err != nil {
+			if b.ForceRemove {
+				b.clearTmp()
+			}
+			return "", err
+		}
+		if b.Remove {
+			b.clearTmp()
+		}
+		shortImgID = stringid.TruncateID(b.image)
+		fmt.Fprintf(b.Stdout, " ---> %s\n", shortImgID)
+	}
+
 	fmt.Fprintf(b.Stdout, "Successfully built %s\n", shortImgID)
 	return b.image, nil
 }
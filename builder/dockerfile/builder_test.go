@@ -0,0 +1,143 @@
+package dockerfile
+
+import (
+	"io"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/builder"
+	"github.com/docker/docker/builder/dockerfile/parser"
+)
+
+func TestValidateNetworkConfig(t *testing.T) {
+	valid := []*Config{
+		{},
+		{NetworkMode: "none"},
+		{NetworkMode: "default", DNS: []string{"10.0.0.53"}},
+		{NetworkMode: "mirror", DNS: []string{"10.0.0.53", "fd00::53"}},
+	}
+	for _, config := range valid {
+		if err := validateNetworkConfig(config); err != nil {
+			t.Fatalf("Expected %+v to be valid, got %v", config, err)
+		}
+	}
+
+	invalid := []*Config{
+		{NetworkMode: "host"},
+		{NetworkMode: "container:builder"},
+		{NetworkMode: "mirror", DNS: []string{"mirror.local"}},
+	}
+	for _, config := range invalid {
+		if err := validateNetworkConfig(config); err == nil {
+			t.Fatalf("Expected %+v to be invalid", config)
+		}
+	}
+}
+
+func TestSecretBuildArgsNotExpanded(t *testing.T) {
+	config := &Config{
+		BuildArgs:       map[string]string{"TOKEN": "s3cr3t", "VERSION": "1.0"},
+		SecretBuildArgs: map[string]bool{"TOKEN": true},
+	}
+	b, err := NewBuilder(config, nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b.Stdout = ioutil.Discard
+	b.disableCommit = true
+
+	node, err := parser.Parse(strings.NewReader("ARG TOKEN\nARG VERSION\nENV AUTH=x$TOKEN V=$VERSION\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, n := range node.Children {
+		if err := b.dispatch(i, n); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	env := strings.Join(b.runConfig.Env, " ")
+	if env != "AUTH=x V=1.0" {
+		t.Fatalf("Expected the secret build arg not to be expanded, got %q", env)
+	}
+}
+
+// fakeRunBackend runs the RUN instructions without a daemon. The image it
+// commits records the config the container was created with as its
+// ContainerConfig, like the daemon does.
+type fakeRunBackend struct {
+	builder.Backend
+	created         *container.Config
+	startEnv        []string
+	containerConfig *container.Config
+}
+
+func (d *fakeRunBackend) ContainerCreate(config types.ContainerCreateConfig) (types.ContainerCreateResponse, error) {
+	created := *config.Config
+	created.Env = append([]string{}, config.Config.Env...)
+	d.created = &created
+	return types.ContainerCreateResponse{ID: "build"}, nil
+}
+
+func (d *fakeRunBackend) ContainerUpdateCmd(containerID string, cmd []string) error {
+	return nil
+}
+
+func (d *fakeRunBackend) ContainerAttach(cID string, stdin io.ReadCloser, stdout, stderr io.Writer, stream bool) error {
+	return nil
+}
+
+func (d *fakeRunBackend) ContainerStartWithEnv(containerID string, env []string) error {
+	d.startEnv = env
+	return nil
+}
+
+func (d *fakeRunBackend) ContainerWait(containerID string, timeout time.Duration) (int, error) {
+	return 0, nil
+}
+
+func (d *fakeRunBackend) Commit(containerID string, config *types.ContainerCommitConfig) (string, error) {
+	d.containerConfig = d.created
+	return "sha256:built", nil
+}
+
+func TestSecretBuildArgsNotCommitted(t *testing.T) {
+	config := &Config{
+		BuildArgs:       map[string]string{"TOKEN": "s3cr3t", "VERSION": "1.0"},
+		SecretBuildArgs: map[string]bool{"TOKEN": true},
+	}
+	docker := &fakeRunBackend{}
+	b, err := NewBuilder(config, docker, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b.Stdout = ioutil.Discard
+	b.image = "sha256:base"
+
+	node, err := parser.Parse(strings.NewReader("ARG TOKEN\nARG VERSION\nRUN fetch\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, n := range node.Children {
+		if err := b.dispatch(i, n); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if docker.containerConfig == nil {
+		t.Fatal("Expected the RUN container to be committed")
+	}
+	if env := strings.Join(docker.containerConfig.Env, " "); env != "VERSION=1.0" {
+		t.Fatalf("Expected the ContainerConfig of the image to hold the build args which are not secret only, got %q", env)
+	}
+	if env := strings.Join(docker.startEnv, " "); env != "TOKEN=s3cr3t" {
+		t.Fatalf("Expected the secret build args to be given to the RUN process, got %q", env)
+	}
+	if cmd := strings.Join(docker.containerConfig.Cmd.Slice(), " "); strings.Contains(cmd, "s3cr3t") {
+		t.Fatalf("Expected the secret build arg not to be in the command, got %q", cmd)
+	}
+}
@@ -338,6 +338,8 @@ func run(b *Builder, args []string, attributes map[string]bool, original string)
 	// of RUN, without leaking it to the final image. It also aids cache
 	// lookup for same image built with same build time environment.
 	cmdBuildEnv := []string{}
+	secretBuildEnv := []string{}
+	historyBuildEnv := []string{}
 	configEnv := runconfigopts.ConvertKVStringsToMap(b.runConfig.Env)
 	for key, val := range b.BuildArgs {
 		if !b.isBuildArgAllowed(key) {
@@ -349,7 +351,17 @@ func run(b *Builder, args []string, attributes map[string]bool, original string)
 			continue
 		}
 		if _, ok := configEnv[key]; !ok {
-			cmdBuildEnv = append(cmdBuildEnv, fmt.Sprintf("%s=%s", key, val))
+			if b.SecretBuildArgs[key] {
+				// only record the name of secret build-args, their value
+				// must neither be committed nor change the cache key. They
+				// are given to the process of the RUN only, never to the
+				// config of the container which is committed.
+				secretBuildEnv = append(secretBuildEnv, fmt.Sprintf("%s=%s", key, val))
+				historyBuildEnv = append(historyBuildEnv, key)
+			} else {
+				cmdBuildEnv = append(cmdBuildEnv, fmt.Sprintf("%s=%s", key, val))
+				historyBuildEnv = append(historyBuildEnv, fmt.Sprintf("%s=%s", key, val))
+			}
 		}
 	}
 
@@ -361,9 +373,9 @@ func run(b *Builder, args []string, attributes map[string]bool, original string)
 	// help ensure proper cache matches. We don't want a RUN command
 	// that starts with "foo=abc" to be considered part of a build-time env var.
 	saveCmd := config.Cmd
-	if len(cmdBuildEnv) > 0 {
-		sort.Strings(cmdBuildEnv)
-		tmpEnv := append([]string{fmt.Sprintf("|%d", len(cmdBuildEnv))}, cmdBuildEnv...)
+	if len(historyBuildEnv) > 0 {
+		sort.Strings(historyBuildEnv)
+		tmpEnv := append([]string{fmt.Sprintf("|%d", len(historyBuildEnv))}, historyBuildEnv...)
 		saveCmd = strslice.New(append(tmpEnv, saveCmd.Slice()...)...)
 	}
 
@@ -390,7 +402,7 @@ func run(b *Builder, args []string, attributes map[string]bool, original string)
 		return err
 	}
 
-	if err := b.run(cID); err != nil {
+	if err := b.run(cID, secretBuildEnv); err != nil {
 		return err
 	}
 
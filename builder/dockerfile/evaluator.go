@@ -157,6 +157,11 @@ func (b *Builder) dispatch(stepN int, ast *parser.Node) error {
 			// the entire file (see 'leftoverArgs' processing in evaluator.go )
 			continue
 		}
+		if b.SecretBuildArgs[key] {
+			// secret build-args are only passed to 'run', expanding them
+			// would persist them in the image.
+			continue
+		}
 		envs = append(envs, fmt.Sprintf("%s=%s", key, val))
 	}
 	for ast.Next != nil {
@@ -490,9 +490,11 @@ func (b *Builder) create() (string, error) {
 
 	// TODO: why not embed a hostconfig in builder?
 	hostConfig := &container.HostConfig{
-		Isolation: b.Isolation,
-		ShmSize:   b.ShmSize,
-		Resources: resources,
+		Isolation:   b.Isolation,
+		ShmSize:     b.ShmSize,
+		Resources:   resources,
+		NetworkMode: container.NetworkMode(b.NetworkMode),
+		DNS:         b.DNS,
 	}
 
 	config := *b.runConfig
@@ -522,7 +524,9 @@ func (b *Builder) create() (string, error) {
 	return c.ID, nil
 }
 
-func (b *Builder) run(cID string) (err error) {
+// run starts the container cID, with env added to the environment of its
+// process only, and waits for it to exit.
+func (b *Builder) run(cID string, env []string) (err error) {
 	errCh := make(chan error)
 	go func() {
 		errCh <- b.docker.ContainerAttach(cID, nil, b.Stdout, b.Stderr, true)
@@ -540,7 +544,7 @@ func (b *Builder) run(cID string) (err error) {
 		}
 	}()
 
-	if err := b.docker.ContainerStart(cID, nil); err != nil {
+	if err := b.docker.ContainerStartWithEnv(cID, env); err != nil {
 		return err
 	}
 
@@ -634,6 +638,23 @@ func (b *Builder) readDockerfile() error {
 	return nil
 }
 
+// addLabels commits the labels to the image, as a LABEL instruction at the
+// end of the Dockerfile would.
+func (b *Builder) addLabels(labels map[string]string) error {
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	args := make([]string, 0, 2*len(keys))
+	for _, k := range keys {
+		args = append(args, k, labels[k])
+	}
+	b.flags = NewBFlags()
+	return label(b, args, nil, "")
+}
+
 // determine if build arg is part of built-in args or user
 // defined args in Dockerfile at any point in time.
 func (b *Builder) isBuildArgAllowed(arg string) bool {
@@ -3,13 +3,19 @@ package builder
 import (
 	"os"
 
+	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/pkg/archive"
 	"github.com/docker/docker/pkg/gitutils"
 )
 
 // MakeGitContext returns a Context from gitURL that is cloned in a temporary directory.
-func MakeGitContext(gitURL string) (ModifiableContext, error) {
-	root, err := gitutils.Clone(gitURL)
+// The repository is cloned with the credentials of auth, unless it is nil.
+func MakeGitContext(gitURL string, auth *types.AuthConfig) (ModifiableContext, error) {
+	var username, password string
+	if auth != nil {
+		username, password = auth.Username, auth.Password
+	}
+	root, err := gitutils.CloneWithBasicAuth(gitURL, username, password)
 	if err != nil {
 		return nil, err
 	}
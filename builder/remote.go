@@ -8,6 +8,7 @@ import (
 	"io/ioutil"
 	"regexp"
 
+	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/pkg/httputils"
 )
 
@@ -19,7 +20,8 @@ const acceptableRemoteMIME = `(?:application/(?:(?:x\-)?tar|octet\-stream|((?:x\
 
 var mimeRe = regexp.MustCompile(acceptableRemoteMIME)
 
-// MakeRemoteContext downloads a context from remoteURL and returns it.
+// MakeRemoteContext downloads a context from remoteURL and returns it. The
+// context is downloaded with the credentials of auth, unless it is nil.
 //
 // If contentTypeHandlers is non-nil, then the Content-Type header is read along with a maximum of
 // maxPreambleLength bytes from the body to help detecting the MIME type.
@@ -28,8 +30,12 @@ var mimeRe = regexp.MustCompile(acceptableRemoteMIME)
 // If a match is found, then the body is sent to the contentType handler and a (potentially compressed) tar stream is expected
 // to be returned. If no match is found, it is assumed the body is a tar stream (compressed or not).
 // In either case, an (assumed) tar stream is passed to MakeTarSumContext whose result is returned.
-func MakeRemoteContext(remoteURL string, contentTypeHandlers map[string]func(io.ReadCloser) (io.ReadCloser, error)) (ModifiableContext, error) {
-	f, err := httputils.Download(remoteURL)
+func MakeRemoteContext(remoteURL string, auth *types.AuthConfig, contentTypeHandlers map[string]func(io.ReadCloser) (io.ReadCloser, error)) (ModifiableContext, error) {
+	var username, password string
+	if auth != nil {
+		username, password = auth.Username, auth.Password
+	}
+	f, err := httputils.DownloadWithBasicAuth(remoteURL, username, password)
 	if err != nil {
 		return nil, fmt.Errorf("Error downloading remote context %s: %v", remoteURL, err)
 	}
@@ -0,0 +1,99 @@
+package container
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/docker/distribution/digest"
+	derr "github.com/docker/docker/errors"
+)
+
+// IsConfigFile returns true if the base name of the given path is one of
+// the files the container configuration is persisted to.
+func IsConfigFile(pth string) bool {
+	name := filepath.Base(pth)
+	return name == configFileName || name == hostConfigFileName
+}
+
+// ConfigModifiedOnDisk returns true if the container configuration
+// files differ from what was last read or written by the daemon.
+func (container *Container) ConfigModifiedOnDisk() (bool, error) {
+	pth, err := container.ConfigPath()
+	if err != nil {
+		return false, err
+	}
+	if modified, err := modifiedOnDisk(pth, container.configDigest); err != nil || modified {
+		return modified, err
+	}
+
+	pth, err = container.HostConfigPath()
+	if err != nil {
+		return false, err
+	}
+	return modifiedOnDisk(pth, container.hostConfigDigest)
+}
+
+// ReloadConfig replaces the in-memory configuration of the container with
+// the one stored on disk. Only the user supplied configuration is reloaded,
+// the runtime state of the container is kept as is.
+func (container *Container) ReloadConfig() error {
+	fresh := NewBaseContainer(container.ID, container.Root)
+	if err := fresh.FromDisk(); err != nil {
+		return err
+	}
+	if fresh.ID != container.ID {
+		return derr.ErrorCodeConfigReloadID.WithArgs(container.ID, fresh.ID)
+	}
+
+	container.Path = fresh.Path
+	container.Args = fresh.Args
+	container.Config = fresh.Config
+	container.HostConfig = fresh.HostConfig
+	container.configDigest = fresh.configDigest
+	container.hostConfigDigest = fresh.hostConfigDigest
+	container.configConflict = false
+	return nil
+}
+
+// SetConfigConflict flags the container configuration as modified outside
+// of the daemon. Saving the container to disk fails while it is running
+// and the external modifications are reloaded once it stops.
+func (container *Container) SetConfigConflict() {
+	container.configConflict = true
+}
+
+// HasConfigConflict returns true if the container configuration was
+// modified outside of the daemon and has not been reloaded yet.
+func (container *Container) HasConfigConflict() bool {
+	return container.configConflict
+}
+
+// resolveConfigConflict reloads the externally modified configuration of
+// a stopped container, so that it is not overwritten by the in-memory one.
+func (container *Container) resolveConfigConflict() error {
+	if !container.configConflict {
+		return nil
+	}
+	if container.Running || container.Restarting {
+		return derr.ErrorCodeConfigConflict.WithArgs(container.ID)
+	}
+	return container.ReloadConfig()
+}
+
+// modifiedOnDisk returns true if the content of the file at pth does not
+// match dgst. A missing file only matches an empty digest.
+func modifiedOnDisk(pth string, dgst digest.Digest) (bool, error) {
+	b, err := ioutil.ReadFile(pth)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return dgst != "", nil
+		}
+		return false, err
+	}
+	current, err := digest.FromBytes(b)
+	if err != nil {
+		return false, err
+	}
+	return current != dgst, nil
+}
@@ -0,0 +1,107 @@
+package container
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	containertypes "github.com/docker/docker/api/types/container"
+)
+
+func newReloadTestContainer(t *testing.T) (*Container, string) {
+	root, err := ioutil.TempDir("", "docker-container-reload-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := NewBaseContainer("reload", root)
+	c.Config = &containertypes.Config{Image: "busybox"}
+	c.HostConfig = &containertypes.HostConfig{}
+	if err := c.ToDisk(); err != nil {
+		os.RemoveAll(root)
+		t.Fatal(err)
+	}
+	return c, root
+}
+
+func TestConfigModifiedOnDisk(t *testing.T) {
+	c, root := newReloadTestContainer(t)
+	defer os.RemoveAll(root)
+
+	modified, err := c.ConfigModifiedOnDisk()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if modified {
+		t.Fatal("expected configuration written by the daemon to be unmodified")
+	}
+
+	edited := NewBaseContainer(c.ID, root)
+	if err := edited.FromDisk(); err != nil {
+		t.Fatal(err)
+	}
+	edited.Config.Image = "debian"
+	if err := edited.ToDisk(); err != nil {
+		t.Fatal(err)
+	}
+
+	modified, err = c.ConfigModifiedOnDisk()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !modified {
+		t.Fatal("expected externally edited configuration to be detected")
+	}
+}
+
+func TestReloadConfig(t *testing.T) {
+	c, root := newReloadTestContainer(t)
+	defer os.RemoveAll(root)
+
+	edited := NewBaseContainer(c.ID, root)
+	if err := edited.FromDisk(); err != nil {
+		t.Fatal(err)
+	}
+	edited.Config.Image = "debian"
+	if err := edited.ToDisk(); err != nil {
+		t.Fatal(err)
+	}
+
+	c.SetConfigConflict()
+	c.RestartCount = 3
+	if err := c.ToDisk(); err != nil {
+		t.Fatal(err)
+	}
+	if c.HasConfigConflict() {
+		t.Fatal("expected conflict to be resolved when saving a stopped container")
+	}
+	if c.Config.Image != "debian" {
+		t.Fatalf("expected external modification to be reloaded, got image %q", c.Config.Image)
+	}
+	if c.RestartCount != 3 {
+		t.Fatalf("expected runtime state to be kept, got restart count %d", c.RestartCount)
+	}
+}
+
+func TestConfigConflictRunning(t *testing.T) {
+	c, root := newReloadTestContainer(t)
+	defer os.RemoveAll(root)
+
+	c.SetConfigConflict()
+	c.Running = true
+	if err := c.ToDisk(); err == nil {
+		t.Fatal("expected saving a running container with a config conflict to fail")
+	}
+}
+
+func TestIsConfigFile(t *testing.T) {
+	for pth, expected := range map[string]bool{
+		filepath.Join("/var/lib/docker/containers/abc", configFileName):     true,
+		filepath.Join("/var/lib/docker/containers/abc", hostConfigFileName): true,
+		"/var/lib/docker/containers/abc/resolv.conf":                        false,
+	} {
+		if IsConfigFile(pth) != expected {
+			t.Fatalf("expected IsConfigFile(%q) to be %v", pth, expected)
+		}
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"os"
 	"path/filepath"
 	"sync"
@@ -11,6 +12,7 @@ import (
 	"time"
 
 	"github.com/Sirupsen/logrus"
+	"github.com/docker/distribution/digest"
 	containertypes "github.com/docker/docker/api/types/container"
 	"github.com/docker/docker/daemon/exec"
 	"github.com/docker/docker/daemon/execdriver"
@@ -29,7 +31,15 @@ import (
 	"github.com/opencontainers/runc/libcontainer/label"
 )
 
-const configFileName = "config.v2.json"
+const (
+	configFileName     = "config.v2.json"
+	hostConfigFileName = "hostconfig.json"
+
+	// defaultReadCacheMaxSize and defaultReadCacheMaxFile bound the read
+	// cache of the logs of the drivers which cannot read them back.
+	defaultReadCacheMaxSize = "20m"
+	defaultReadCacheMaxFile = "5"
+)
 
 // CommonContainer holds the fields for a container which are
 // applicable across all platforms supported by the daemon.
@@ -55,7 +65,8 @@ type CommonContainer struct {
 	ProcessLabel           string
 	RestartCount           int
 	HasBeenStartedBefore   bool
-	HasBeenManuallyStopped bool // used for unless-stopped restart policy
+	HasBeenManuallyStopped bool          // used for unless-stopped restart policy
+	RestartDelay           time.Duration // current delay between restarts by the restart policy
 	MountPoints            map[string]*volume.MountPoint
 	HostConfig             *containertypes.HostConfig `json:"-"` // do not serialize the host config in the json, otherwise we'll make the container unportable
 	Command                *execdriver.Command        `json:"-"`
@@ -64,6 +75,22 @@ type CommonContainer struct {
 	// logDriver for closing
 	LogDriver logger.Logger  `json:"-"`
 	LogCopier *logger.Copier `json:"-"`
+	// configDigest and hostConfigDigest are the digests of the config
+	// files as they were last read or written by the daemon.
+	configDigest     digest.Digest
+	hostConfigDigest digest.Digest
+	// configConflict is set when the config files were modified outside
+	// of the daemon while the container could not be reloaded.
+	configConflict bool
+	// desiredState is the state the daemon converges the container to,
+	// persisted apart from the actual state.
+	desiredState string
+	// ProcessEnv is added to the environment of the process on its next
+	// start only, it is neither saved nor committed.
+	ProcessEnv []string `json:"-"`
+	// restartTimer starts the container once its restart delay passes,
+	// when the daemon restarted while the container was waiting for it.
+	restartTimer *time.Timer
 }
 
 // NewBaseContainer creates a new container with its
@@ -88,27 +115,34 @@ func (container *Container) FromDisk() error {
 		return err
 	}
 
-	jsonSource, err := os.Open(pth)
+	jsonSource, err := ioutil.ReadFile(pth)
 	if err != nil {
 		return err
 	}
-	defer jsonSource.Close()
-
-	dec := json.NewDecoder(jsonSource)
 
 	// Load container settings
-	if err := dec.Decode(container); err != nil {
+	if err := json.Unmarshal(jsonSource, container); err != nil {
+		return err
+	}
+	if container.configDigest, err = digest.FromBytes(jsonSource); err != nil {
 		return err
 	}
 
 	if err := label.ReserveLabel(container.ProcessLabel); err != nil {
 		return err
 	}
+	if err := container.readDesiredState(); err != nil {
+		return err
+	}
 	return container.readHostConfig()
 }
 
 // ToDisk saves the container configuration on disk.
 func (container *Container) ToDisk() error {
+	if err := container.resolveConfigConflict(); err != nil {
+		return err
+	}
+
 	pth, err := container.ConfigPath()
 	if err != nil {
 		return err
@@ -120,12 +154,14 @@ func (container *Container) ToDisk() error {
 	}
 	defer jsonSource.Close()
 
-	enc := json.NewEncoder(jsonSource)
+	digester := digest.Canonical.New()
+	enc := json.NewEncoder(io.MultiWriter(jsonSource, digester.Hash()))
 
 	// Save container settings
 	if err := enc.Encode(container); err != nil {
 		return err
 	}
+	container.configDigest = digester.Digest()
 
 	return container.WriteHostConfig()
 }
@@ -141,6 +177,7 @@ func (container *Container) ToDiskLocking() error {
 // readHostConfig reads the host configuration from disk for the container.
 func (container *Container) readHostConfig() error {
 	container.HostConfig = &containertypes.HostConfig{}
+	container.hostConfigDigest = ""
 	// If the hostconfig file does not exist, do not read it.
 	// (We still have to initialize container.HostConfig,
 	// but that's OK, since we just did that above.)
@@ -149,16 +186,18 @@ func (container *Container) readHostConfig() error {
 		return err
 	}
 
-	f, err := os.Open(pth)
+	f, err := ioutil.ReadFile(pth)
 	if err != nil {
 		if os.IsNotExist(err) {
 			return nil
 		}
 		return err
 	}
-	defer f.Close()
 
-	if err := json.NewDecoder(f).Decode(&container.HostConfig); err != nil {
+	if err := json.Unmarshal(f, &container.HostConfig); err != nil {
+		return err
+	}
+	if container.hostConfigDigest, err = digest.FromBytes(f); err != nil {
 		return err
 	}
 
@@ -180,7 +219,12 @@ func (container *Container) WriteHostConfig() error {
 	}
 	defer f.Close()
 
-	return json.NewEncoder(f).Encode(&container.HostConfig)
+	digester := digest.Canonical.New()
+	if err := json.NewEncoder(io.MultiWriter(f, digester.Hash())).Encode(&container.HostConfig); err != nil {
+		return err
+	}
+	container.hostConfigDigest = digester.Digest()
+	return nil
 }
 
 // GetResourcePath evaluates `path` in the scope of the container's BaseFS, with proper path
@@ -224,9 +268,34 @@ func (container *Container) GetRootResourcePath(path string) (string, error) {
 }
 
 // ExitOnNext signals to the monitor that it should not restart the container
-// after we send the kill signal.
-func (container *Container) ExitOnNext() {
-	container.monitor.ExitOnNext()
+// after we send the kill signal. It returns false if the monitor was already
+// signaled.
+func (container *Container) ExitOnNext() bool {
+	return container.monitor.ExitOnNext()
+}
+
+// SetRestartTimer records the timer which restarts the container after its
+// restart delay, so that it can be cancelled.
+func (container *Container) SetRestartTimer(t *time.Timer) {
+	container.Lock()
+	container.restartTimer = t
+	container.Unlock()
+}
+
+// CancelRestartTimer stops the timer which restarts the container after its
+// restart delay, if any.
+func (container *Container) CancelRestartTimer() {
+	container.Lock()
+	if container.restartTimer != nil {
+		container.restartTimer.Stop()
+		container.restartTimer = nil
+	}
+	container.Unlock()
+}
+
+// CancelExitOnNext reverts ExitOnNext when the container was not stopped.
+func (container *Container) CancelExitOnNext() {
+	container.monitor.CancelExitOnNext()
 }
 
 // Resize changes the TTY of the process running inside the container
@@ -240,7 +309,7 @@ func (container *Container) Resize(h, w int) error {
 
 // HostConfigPath returns the path to the container's JSON hostconfig
 func (container *Container) HostConfigPath() (string, error) {
-	return container.GetRootResourcePath("hostconfig.json")
+	return container.GetRootResourcePath(hostConfigFileName)
 }
 
 // ConfigPath returns the path to the container's JSON config
@@ -248,6 +317,11 @@ func (container *Container) ConfigPath() (string, error) {
 	return container.GetRootResourcePath(configFileName)
 }
 
+// CheckpointDir returns the directory holding the checkpoints of the container
+func (container *Container) CheckpointDir() string {
+	return filepath.Join(container.Root, "checkpoints")
+}
+
 func validateID(id string) error {
 	if id == "" {
 		return derr.ErrorCodeEmptyID
@@ -280,8 +354,9 @@ func (container *Container) StartLogger(cfg containertypes.LogConfig) (logger.Lo
 	if err != nil {
 		return nil, derr.ErrorCodeLoggingFactory.WithArgs(err)
 	}
+	driverCfg, cacheCfg := logger.SplitReadCacheOpts(cfg.Config)
 	ctx := logger.Context{
-		Config:              cfg.Config,
+		Config:              driverCfg,
 		ContainerID:         container.ID,
 		ContainerName:       container.Name,
 		ContainerEntrypoint: container.Path,
@@ -300,7 +375,40 @@ func (container *Container) StartLogger(cfg containertypes.LogConfig) (logger.Lo
 			return nil, err
 		}
 	}
-	return c(ctx)
+	l, err := c(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := l.(logger.LogReader); ok || !logger.ReadCacheEnabled(cacheCfg) {
+		return l, nil
+	}
+	return container.startReadCache(l, ctx, cacheCfg)
+}
+
+// startReadCache keeps a copy of the logs sent to a log driver which cannot
+// read them back in a json-file log, so that they can still be read.
+func (container *Container) startReadCache(l logger.Logger, ctx logger.Context, cacheCfg map[string]string) (logger.Logger, error) {
+	ctx.Config = map[string]string{
+		"max-size": defaultReadCacheMaxSize,
+		"max-file": defaultReadCacheMaxFile,
+	}
+	for k, v := range cacheCfg {
+		if k != "enabled" {
+			ctx.Config[k] = v
+		}
+	}
+	var err error
+	ctx.LogPath, err = container.GetRootResourcePath(fmt.Sprintf("%s-cache.log", container.ID))
+	if err != nil {
+		l.Close()
+		return nil, err
+	}
+	cache, err := jsonfilelog.New(ctx)
+	if err != nil {
+		l.Close()
+		return nil, err
+	}
+	return logger.NewReadCachingLogger(l, cache), nil
 }
 
 // GetProcessLabel returns the process label for the container.
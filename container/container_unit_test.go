@@ -1,7 +1,9 @@
 package container
 
 import (
+	"os"
 	"testing"
+	"time"
 
 	"github.com/docker/docker/api/types/container"
 	"github.com/docker/docker/pkg/signal"
@@ -34,3 +36,133 @@ func TestContainerStopSignal(t *testing.T) {
 		t.Fatalf("Expected 9, got %v", s)
 	}
 }
+
+func TestContainerUpdateMonitor(t *testing.T) {
+	c := NewBaseContainer("id", "root")
+	// A container without a monitor is left alone.
+	c.UpdateMonitor(container.RestartPolicy{Name: "always"})
+
+	c.monitor = &containerMonitor{
+		restartPolicy: container.RestartPolicy{Name: "no"},
+	}
+
+	c.UpdateMonitor(container.RestartPolicy{})
+	if !c.monitor.restartPolicy.IsNone() {
+		t.Fatalf("Expected an empty policy to keep the current one, got %v", c.monitor.restartPolicy)
+	}
+
+	c.UpdateMonitor(container.RestartPolicy{Name: "on-failure", MaximumRetryCount: 3})
+	if !c.monitor.restartPolicy.IsOnFailure() || c.monitor.restartPolicy.MaximumRetryCount != 3 {
+		t.Fatalf("Expected on-failure:3, got %v", c.monitor.restartPolicy)
+	}
+}
+
+func TestContainerShouldRestartUnlessStopped(t *testing.T) {
+	c := &Container{
+		CommonContainer: CommonContainer{
+			HostConfig: &container.HostConfig{
+				RestartPolicy: container.RestartPolicy{Name: "unless-stopped"},
+			},
+		},
+	}
+	if !c.ShouldRestart() {
+		t.Fatal("Expected a container with the unless-stopped policy to be restarted")
+	}
+
+	c.HasBeenManuallyStopped = true
+	if c.ShouldRestart() {
+		t.Fatal("Expected a manually stopped container with the unless-stopped policy not to be restarted")
+	}
+}
+
+func TestRestartBackoff(t *testing.T) {
+	b := RestartBackoff{
+		InitialDelay: 100 * time.Millisecond,
+		MaxDelay:     time.Second,
+		Factor:       3,
+	}
+	expected := []time.Duration{
+		100 * time.Millisecond,
+		300 * time.Millisecond,
+		900 * time.Millisecond,
+		time.Second,
+		time.Second,
+	}
+	var delay time.Duration
+	for i, e := range expected {
+		if delay = b.next(delay); delay != e {
+			t.Fatalf("Expected delay %d to be %s, got %s", i, e, delay)
+		}
+	}
+
+	b.Jitter = 0.5
+	for i := 0; i < 100; i++ {
+		if d := b.jitter(time.Second); d < 500*time.Millisecond || d > 1500*time.Millisecond {
+			t.Fatalf("Expected a delay between 500ms and 1.5s, got %s", d)
+		}
+	}
+}
+
+func TestContainerDesiredState(t *testing.T) {
+	c, root := newReloadTestContainer(t)
+	defer os.RemoveAll(root)
+
+	if err := c.SetDesiredState("paused"); err == nil {
+		t.Fatal("Expected an error for an invalid desired state")
+	}
+	if err := c.SetDesiredState(DesiredRunning); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded := NewBaseContainer(c.ID, root)
+	if err := loaded.FromDisk(); err != nil {
+		t.Fatal(err)
+	}
+	if state := loaded.DesiredState(); state != DesiredRunning {
+		t.Fatalf("Expected the desired state %s to be persisted, got %q", DesiredRunning, state)
+	}
+	// The desired state is kept apart from the configuration
+	if modified, err := c.ConfigModifiedOnDisk(); err != nil || modified {
+		t.Fatalf("Expected the configuration not to be modified, got %v (%v)", modified, err)
+	}
+}
+
+func TestCancelExitOnNext(t *testing.T) {
+	c := &Container{monitor: &containerMonitor{stopChan: make(chan struct{})}}
+	if !c.ExitOnNext() {
+		t.Fatal("Expected the monitor to be signaled")
+	}
+	if c.ExitOnNext() {
+		t.Fatal("Expected the monitor to be already signaled")
+	}
+	c.CancelExitOnNext()
+	if c.monitor.shouldStop {
+		t.Fatal("Expected the signal to be cancelled")
+	}
+	select {
+	case <-c.monitor.stopChan:
+		t.Fatal("Expected the monitor to wait again for the next restart")
+	default:
+	}
+	if !c.ExitOnNext() {
+		t.Fatal("Expected the monitor to be signaled again")
+	}
+}
+
+func TestCancelRestartTimer(t *testing.T) {
+	c := NewBaseContainer("id", "root")
+	// cancelling without a pending restart is a no-op
+	c.CancelRestartTimer()
+
+	fired := make(chan struct{})
+	c.SetRestartTimer(time.AfterFunc(100*time.Millisecond, func() { close(fired) }))
+	c.CancelRestartTimer()
+	select {
+	case <-fired:
+		t.Fatal("Expected the restart to be cancelled")
+	case <-time.After(300 * time.Millisecond):
+	}
+	if c.restartTimer != nil {
+		t.Fatal("Expected the timer to be cleared")
+	}
+}
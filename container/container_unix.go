@@ -42,6 +42,8 @@ type Container struct {
 	AppArmorProfile string
 	HostnamePath    string
 	HostsPath       string
+	IsolatedCpus    string
+	LocaltimePath   string
 	ShmPath         string
 	MqueuePath      string
 	ResolvConfPath  string
@@ -261,6 +263,12 @@ func (container *Container) BuildCreateEndpointOptions(n libnetwork.Network) ([]
 		createOptions = append(createOptions, libnetwork.CreateOptionAnonymous())
 	}
 
+	if settings := container.NetworkSettings.Networks[n.Name()]; settings != nil && settings.IPAMConfig != nil {
+		if ip := net.ParseIP(settings.IPAMConfig.IPv4Address); ip != nil {
+			createOptions = append(createOptions, libnetwork.CreateOptionIpam(ip, nil))
+		}
+	}
+
 	// Other configs are applicable only for the endpoint in the network
 	// to which container was connected to on docker run.
 	if n.Name() != container.HostConfig.NetworkMode.NetworkName() &&
@@ -438,6 +446,25 @@ func (container *Container) NetworkMounts() []execdriver.Mount {
 	return mounts
 }
 
+// LocaltimeMounts returns the mount of the /etc/localtime file generated for
+// the timezone of the container, if any.
+func (container *Container) LocaltimeMounts() []execdriver.Mount {
+	if container.LocaltimePath == "" {
+		return nil
+	}
+	if _, err := os.Stat(container.LocaltimePath); err != nil {
+		logrus.Warnf("LocaltimePath set to %q, but can't stat this filename (err = %v); skipping", container.LocaltimePath, err)
+		return nil
+	}
+	label.Relabel(container.LocaltimePath, container.MountLabel, false)
+	return []execdriver.Mount{{
+		Source:      container.LocaltimePath,
+		Destination: "/etc/localtime",
+		Writable:    false,
+		Propagation: volume.DefaultPropagationMode,
+	}}
+}
+
 // CopyImagePathContent copies files in destination to the volume.
 func (container *Container) CopyImagePathContent(v volume.Volume, destination string) error {
 	rootfs, err := symlink.FollowSymlinkInScope(filepath.Join(container.BaseFS, destination), container.BaseFS)
@@ -593,6 +620,11 @@ func (container *Container) UpdateContainer(hostConfig *container.HostConfig) er
 	if resources.KernelMemory != 0 {
 		cResources.KernelMemory = resources.KernelMemory
 	}
+
+	// update HostConfig of container
+	if hostConfig.RestartPolicy.Name != "" {
+		container.HostConfig.RestartPolicy = hostConfig.RestartPolicy
+	}
 	container.Unlock()
 
 	// If container is not running, update hostConfig struct is enough,
@@ -48,11 +48,26 @@ func (container *Container) TmpfsMounts() []execdriver.Mount {
 	return nil
 }
 
-// UpdateContainer updates resources of a container
-func (container *Container) UpdateContainer(hostConfig *container.HostConfig) error {
+// LocaltimeMounts returns the mount of the /etc/localtime file of the
+// container. Timezones are not supported on Windows.
+func (container *Container) LocaltimeMounts() []execdriver.Mount {
 	return nil
 }
 
+// UpdateContainer updates the restart policy of a container. Resources
+// cannot be updated on Windows.
+func (container *Container) UpdateContainer(hostConfig *container.HostConfig) error {
+	if hostConfig.RestartPolicy.Name == "" {
+		return nil
+	}
+
+	container.Lock()
+	container.HostConfig.RestartPolicy = hostConfig.RestartPolicy
+	container.Unlock()
+
+	return container.ToDiskLocking()
+}
+
 // appendNetworkMounts appends any network mounts to the array of mount points passed in.
 // Windows does not support network mounts (not to be confused with SMB network mounts), so
 // this is a no-op.
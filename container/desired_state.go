@@ -0,0 +1,84 @@
+package container
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// The desired states of a container, which the daemon converges the actual
+// state of the container to.
+const (
+	DesiredRunning = "running"
+	DesiredStopped = "stopped"
+)
+
+// desiredStateFileName is the file the desired state of a container is
+// persisted to, apart from its actual state.
+const desiredStateFileName = "desired-state.json"
+
+// desiredState is the desired state of a container as persisted on disk.
+type desiredState struct {
+	State   string
+	Updated time.Time
+}
+
+// DesiredState returns the desired state of the container, or "" if it was
+// never set. The container lock must be held.
+func (container *Container) DesiredState() string {
+	return container.desiredState
+}
+
+// SetDesiredState sets and persists the desired state of the container. The
+// container lock must be held.
+func (container *Container) SetDesiredState(state string) error {
+	if state != DesiredRunning && state != DesiredStopped {
+		return fmt.Errorf("Invalid desired state %q", state)
+	}
+	if state == container.desiredState {
+		return nil
+	}
+	pth, err := container.GetRootResourcePath(desiredStateFileName)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(&desiredState{State: state, Updated: time.Now().UTC()})
+	if err != nil {
+		return err
+	}
+	tmp := filepath.Join(filepath.Dir(pth), ".tmp-"+desiredStateFileName)
+	if err := ioutil.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, pth); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	container.desiredState = state
+	return nil
+}
+
+// readDesiredState reads the desired state of the container from disk, if
+// it was ever set.
+func (container *Container) readDesiredState() error {
+	pth, err := container.GetRootResourcePath(desiredStateFileName)
+	if err != nil {
+		return err
+	}
+	data, err := ioutil.ReadFile(pth)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	var s desiredState
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	container.desiredState = s.State
+	return nil
+}
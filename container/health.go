@@ -0,0 +1,47 @@
+package container
+
+import (
+	"sync"
+
+	"github.com/docker/docker/api/types"
+)
+
+// Health holds the current container health-check state
+type Health struct {
+	types.Health
+
+	mu   sync.Mutex
+	stop chan struct{} // closed to stop the monitor
+}
+
+// String returns a human-readable description of the health-check state
+func (s *Health) String() string {
+	if s.Status == types.Starting {
+		return "health: starting"
+	}
+	return s.Status
+}
+
+// OpenMonitorChannel creates and returns a new monitor channel. If there
+// already is one, it returns nil.
+func (s *Health) OpenMonitorChannel() chan struct{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.stop == nil {
+		s.stop = make(chan struct{})
+		return s.stop
+	}
+	return nil
+}
+
+// CloseMonitorChannel closes any existing monitor channel.
+func (s *Health) CloseMonitorChannel() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.stop != nil {
+		close(s.stop)
+		s.stop = nil
+	}
+}
@@ -0,0 +1,80 @@
+package container
+
+import (
+	"time"
+
+	"github.com/docker/docker/api/types"
+	derr "github.com/docker/docker/errors"
+)
+
+// Status is a step of the lifecycle of a container.
+type Status string
+
+// The statuses of a container. They match the strings returned by
+// StateString, plus StatusRemoving while the container is being removed.
+const (
+	StatusCreated    Status = "created"
+	StatusRunning    Status = "running"
+	StatusPaused     Status = "paused"
+	StatusRestarting Status = "restarting"
+	StatusExited     Status = "exited"
+	StatusBroken     Status = "broken"
+	StatusRemoving   Status = "removing"
+	StatusDead       Status = "dead"
+)
+
+// maxTransitions is the number of transitions kept in the history of the
+// state of a container.
+const maxTransitions = 20
+
+// transitions are the statuses which can be requested from each status.
+// The transitions which are not requested but observed, like the exit of
+// the container process, are recorded without being checked.
+var transitions = map[Status][]Status{
+	StatusCreated:    {StatusRunning, StatusRemoving},
+	StatusRunning:    {StatusPaused, StatusRestarting, StatusExited, StatusRemoving},
+	StatusPaused:     {StatusRunning, StatusExited, StatusRemoving},
+	StatusRestarting: {StatusRunning, StatusExited, StatusRemoving},
+	StatusExited:     {StatusRunning, StatusRestarting, StatusRemoving},
+	StatusBroken:     {StatusRemoving},
+	StatusRemoving:   {StatusExited, StatusDead},
+	StatusDead:       {StatusRemoving},
+}
+
+// Status returns the current step of the lifecycle of the container.
+func (s *State) Status() Status {
+	if s.RemovalInProgress {
+		return StatusRemoving
+	}
+	return Status(s.StateString())
+}
+
+// CheckTransition returns an error if the container cannot move from its
+// current status to the given one.
+func (s *State) CheckTransition(to Status) error {
+	from := s.Status()
+	for _, t := range transitions[from] {
+		if t == to {
+			return nil
+		}
+	}
+	return derr.ErrorCodeIllegalStateTransition.WithArgs(from, to)
+}
+
+// recordTransition adds a transition to the history of the state if the
+// status changed from the given one.
+func (s *State) recordTransition(from Status, reason string) {
+	to := s.Status()
+	if to == from {
+		return
+	}
+	s.Transitions = append(s.Transitions, &types.StateTransition{
+		From:   string(from),
+		To:     string(to),
+		Time:   time.Now().UTC(),
+		Reason: reason,
+	})
+	if len(s.Transitions) > maxTransitions {
+		s.Transitions = s.Transitions[len(s.Transitions)-maxTransitions:]
+	}
+}
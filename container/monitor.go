@@ -2,7 +2,10 @@ package container
 
 import (
 	"io"
+	"math/rand"
+	"os"
 	"os/exec"
+	"strconv"
 	"strings"
 	"sync"
 	"syscall"
@@ -18,22 +21,75 @@ import (
 )
 
 const (
-	defaultTimeIncrement = 100
-	loggerCloseTimeout   = 10 * time.Second
+	loggerCloseTimeout = 10 * time.Second
 )
 
+// RestartBackoff configures the delay between the restarts of a container by
+// its restart policy. The delay starts at InitialDelay and is multiplied by
+// Factor after each restart of a container which ran for less than 10
+// seconds, up to MaxDelay.
+type RestartBackoff struct {
+	// InitialDelay is the delay before the first restart.
+	InitialDelay time.Duration
+	// MaxDelay caps the delay. A zero value leaves the delay unbounded.
+	MaxDelay time.Duration
+	// Factor multiplies the delay after each restart.
+	Factor float64
+	// Jitter is the fraction of the delay, between 0 and 1, which is
+	// randomized so that restarted containers do not restart in lockstep.
+	Jitter float64
+}
+
+// next returns the delay following the given delay.
+func (b RestartBackoff) next(delay time.Duration) time.Duration {
+	if delay <= 0 {
+		return b.InitialDelay
+	}
+	delay = time.Duration(float64(delay) * b.Factor)
+	if b.MaxDelay > 0 && delay > b.MaxDelay {
+		delay = b.MaxDelay
+	}
+	return delay
+}
+
+// jitter randomizes the given delay by up to the jitter fraction, in either
+// direction.
+func (b RestartBackoff) jitter(delay time.Duration) time.Duration {
+	if b.Jitter <= 0 {
+		return delay
+	}
+	return delay + time.Duration((rand.Float64()*2-1)*b.Jitter*float64(delay))
+}
+
 // supervisor defines the interface that a supervisor must implement
 type supervisor interface {
 	// LogContainerEvent generates events related to a given container
 	LogContainerEvent(*Container, string)
+	// LogContainerEventWithAttributes generates events related to a given
+	// container, with extra attributes
+	LogContainerEventWithAttributes(*Container, string, map[string]string)
 	// Cleanup ensures that the container is properly unmounted
 	Cleanup(*Container)
+	// HoldOnFailure keeps the resources of a container whose process failed
+	// for a while instead of cleaning them up, and tells whether it does
+	HoldOnFailure(*Container) bool
 	// StartLogging starts the logging driver for the container
 	StartLogging(*Container) error
 	// Run starts a container
 	Run(c *Container, pipes *execdriver.Pipes, startCallback execdriver.DriverCallback) (execdriver.ExitStatus, error)
 	// IsShuttingDown tells whether the supervisor is shutting down or not
 	IsShuttingDown() bool
+	// StartTimeout returns how long to wait for the container's process to
+	// start before it is considered hung, and whether a hung start should be
+	// abandoned. A zero timeout disables the watchdog.
+	StartTimeout() (time.Duration, bool)
+	// RestartBackoff returns the delay between the restarts of containers
+	// by their restart policy.
+	RestartBackoff() RestartBackoff
+	// CrashLoop returns the number of failures of a container within the
+	// window which make it crash looping. Zero failures disable the
+	// detection.
+	CrashLoop() (int, time.Duration)
 }
 
 // containerMonitor monitors the execution of a container's main process.
@@ -65,16 +121,24 @@ type containerMonitor struct {
 	startSignal chan struct{}
 
 	// stopChan is used to signal to the monitor whenever there is a wait for the
-	// next restart so that the timeIncrement is not honored and the user is not
+	// next restart so that the restart delay is not honored and the user is not
 	// left waiting for nothing to happen during this time
 	stopChan chan struct{}
 
-	// timeIncrement is the amount of time to wait between restarts
-	// this is in milliseconds
-	timeIncrement int
+	// backoff configures the delay between restarts, the current delay being
+	// kept in the container so that it survives restarts of the daemon
+	backoff RestartBackoff
 
 	// lastStartTime is the time which the monitor last exec'd the container's process
 	lastStartTime time.Time
+
+	// startAbandoned is set when the initial start of the container's process
+	// timed out and was abandoned by the start watchdog
+	startAbandoned bool
+
+	// failed is set when the container's process exited for good with a
+	// non-zero code without being asked to stop
+	failed bool
 }
 
 // StartMonitor initializes a containerMonitor for this container with the provided supervisor and restart policy
@@ -84,7 +148,7 @@ func (container *Container) StartMonitor(s supervisor, policy container.RestartP
 		supervisor:    s,
 		container:     container,
 		restartPolicy: policy,
-		timeIncrement: defaultTimeIncrement,
+		backoff:       s.RestartBackoff(),
 		stopChan:      make(chan struct{}),
 		startSignal:   make(chan struct{}),
 	}
@@ -92,39 +156,106 @@ func (container *Container) StartMonitor(s supervisor, policy container.RestartP
 	return container.monitor.wait()
 }
 
+// UpdateMonitor updates the restart policy applied by the monitor of a
+// running container. Containers without a monitor pick up the policy from
+// their HostConfig the next time they are started.
+func (container *Container) UpdateMonitor(restartPolicy container.RestartPolicy) {
+	container.Lock()
+	monitor := container.monitor
+	container.Unlock()
+	if monitor == nil {
+		return
+	}
+
+	monitor.mux.Lock()
+	if restartPolicy.Name != "" && !monitor.restartPolicy.IsSame(&restartPolicy) {
+		monitor.restartPolicy = restartPolicy
+	}
+	monitor.mux.Unlock()
+}
+
 // wait starts the container and wait until
 // we either receive an error from the initial start of the container's
 // process or until the process is running in the container
 func (m *containerMonitor) wait() error {
-	select {
-	case <-m.startSignal:
-	case err := <-promise.Go(m.start):
-		return err
+	startErr := promise.Go(m.start)
+
+	var deadline <-chan time.Time
+	timeout, abandon := m.supervisor.StartTimeout()
+	if timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		deadline = timer.C
 	}
 
-	return nil
+	for {
+		select {
+		case <-m.startSignal:
+			return nil
+		case err := <-startErr:
+			return err
+		case <-deadline:
+			logrus.Warnf("Container %s did not start within %s", m.container.ID, timeout)
+			m.logEvent("start-timeout")
+			if abandon {
+				m.abandonStart()
+				return derr.ErrorCodeStartTimeout.WithArgs(m.container.ID, timeout)
+			}
+			deadline = nil
+		}
+	}
 }
 
-// Stop signals to the container monitor that it should stop monitoring the container
-// for exits the next time the process dies
-func (m *containerMonitor) ExitOnNext() {
+// abandonStart gives up on the initial start of the container's process. The
+// process is killed if the exec driver starts it later on, and it is never
+// restarted.
+func (m *containerMonitor) abandonStart() {
 	m.mux.Lock()
+	defer m.mux.Unlock()
 
-	// we need to protect having a double close of the channel when stop is called
-	// twice or else we will get a panic
+	m.startAbandoned = true
 	if !m.shouldStop {
 		m.shouldStop = true
 		close(m.stopChan)
 	}
+}
 
-	m.mux.Unlock()
+// Stop signals to the container monitor that it should stop monitoring the container
+// for exits the next time the process dies. It returns false if the monitor
+// was already signaled.
+func (m *containerMonitor) ExitOnNext() bool {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+
+	// we need to protect having a double close of the channel when stop is called
+	// twice or else we will get a panic
+	if m.shouldStop {
+		return false
+	}
+	m.shouldStop = true
+	close(m.stopChan)
+	return true
+}
+
+// CancelExitOnNext reverts ExitOnNext when the process was not stopped after
+// all, so that the restart policy applies again.
+func (m *containerMonitor) CancelExitOnNext() {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+
+	if m.shouldStop && !m.startAbandoned {
+		m.shouldStop = false
+		m.stopChan = make(chan struct{})
+	}
 }
 
 // Close closes the container's resources such as networking allocations and
 // unmounts the container's root filesystem
 func (m *containerMonitor) Close() error {
-	// Cleanup networking and mounts
-	m.supervisor.Cleanup(m.container)
+	// Cleanup networking and mounts, unless they are held for debugging
+	if !m.failed || !m.supervisor.HoldOnFailure(m.container) {
+		m.supervisor.Cleanup(m.container)
+	}
 
 	// FIXME: here is race condition between two RUN instructions in Dockerfile
 	// because they share same runconfig and change image. Must be fixed
@@ -215,11 +346,12 @@ func (m *containerMonitor) start() error {
 		// here container.Lock is already lost
 		afterRun = true
 
+		m.recordExit(&exitStatus, err)
 		m.resetMonitor(err == nil && exitStatus.ExitCode == 0)
 
 		if m.shouldRestart(exitStatus.ExitCode) {
 			m.container.SetRestarting(&exitStatus)
-			m.logEvent("die")
+			m.supervisor.LogContainerEventWithAttributes(m.container, "die", exitAttributes(&exitStatus))
 			m.resetContainer(true)
 
 			// sleep with a small time increment between each restart to help avoid issues cased by quickly
@@ -234,7 +366,8 @@ func (m *containerMonitor) start() error {
 			continue
 		}
 
-		m.logEvent("die")
+		m.failed = !m.shouldStop && (err != nil || exitStatus.ExitCode != 0)
+		m.supervisor.LogContainerEventWithAttributes(m.container, "die", exitAttributes(&exitStatus))
 		m.resetContainer(true)
 		return err
 	}
@@ -242,16 +375,16 @@ func (m *containerMonitor) start() error {
 
 // resetMonitor resets the stateful fields on the containerMonitor based on the
 // previous runs success or failure.  Regardless of success, if the container had
-// an execution time of more than 10s then reset the timer back to the default
+// an execution time of more than 10s then reset the delay back to the initial delay
 func (m *containerMonitor) resetMonitor(successful bool) {
 	executionTime := time.Now().Sub(m.lastStartTime).Seconds()
 
 	if executionTime > 10 {
-		m.timeIncrement = defaultTimeIncrement
+		m.container.RestartDelay = m.backoff.InitialDelay
 	} else {
-		// otherwise we need to increment the amount of time we wait before restarting
-		// the process.  We will build up by multiplying the increment by 2
-		m.timeIncrement *= 2
+		// otherwise we need to increase the amount of time we wait before
+		// restarting the process, up to the maximum delay
+		m.container.RestartDelay = m.backoff.next(m.container.RestartDelay)
 	}
 
 	// the container exited successfully so we need to reset the failure counter
@@ -262,12 +395,44 @@ func (m *containerMonitor) resetMonitor(successful bool) {
 	}
 }
 
-// waitForNextRestart waits with the default time increment to restart the container unless
+// recordExit adds the exit of the container's process to its restart
+// history, and logs a crash-loop event when the container failed as many
+// times as the crash loop threshold within the crash loop window. The event
+// is logged once until the failures fall under the threshold.
+func (m *containerMonitor) recordExit(exitStatus *execdriver.ExitStatus, runErr error) {
+	m.mux.Lock()
+	stopped := m.shouldStop
+	m.mux.Unlock()
+
+	maxFailures, window := m.supervisor.CrashLoop()
+	m.container.Lock()
+	failures := m.container.addExit(newContainerExit(m.lastStartTime, exitStatus, runErr, stopped), window)
+	m.container.Unlock()
+
+	if maxFailures > 0 && failures == maxFailures {
+		logrus.Warnf("Container %s failed %d times within %s", stringid.TruncateID(m.container.ID), failures, window)
+		m.supervisor.LogContainerEventWithAttributes(m.container, "crash-loop", map[string]string{
+			"failures": strconv.Itoa(failures),
+			"window":   window.String(),
+		})
+	}
+}
+
+// waitForNextRestart waits for the restart delay to restart the container unless
 // a user or docker asks for the container to be stopped
 func (m *containerMonitor) waitForNextRestart() {
+	// persist the delay so that a restart of the daemon does not restart the
+	// container right away
+	if err := m.container.ToDisk(); err != nil {
+		logrus.Errorf("Error dumping container %s state to disk: %s", m.container.ID, err)
+	}
+
+	m.mux.Lock()
+	stopChan := m.stopChan
+	m.mux.Unlock()
 	select {
-	case <-time.After(time.Duration(m.timeIncrement) * time.Millisecond):
-	case <-m.stopChan:
+	case <-time.After(m.backoff.jitter(m.container.RestartDelay)):
+	case <-stopChan:
 	}
 }
 
@@ -319,6 +484,17 @@ func (m *containerMonitor) callback(processConfig *execdriver.ProcessConfig, pid
 		}
 	}
 
+	m.mux.Lock()
+	abandoned := m.startAbandoned
+	m.mux.Unlock()
+	if abandoned {
+		logrus.Warnf("Killing process %d of container %s started after the start deadline", pid, m.container.ID)
+		if p, err := os.FindProcess(pid); err == nil {
+			p.Kill()
+		}
+		return nil
+	}
+
 	m.container.SetRunning(pid)
 
 	// signal that the process has started
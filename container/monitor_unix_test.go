@@ -0,0 +1,121 @@
+// +build linux freebsd
+
+package container
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"sync"
+	"testing"
+	"time"
+
+	containertypes "github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/daemon/execdriver"
+	derr "github.com/docker/docker/errors"
+)
+
+// hungSupervisor is a supervisor whose exec driver only calls the start
+// callback once started is closed.
+type hungSupervisor struct {
+	mu      sync.Mutex
+	events  []string
+	started chan struct{}
+	process *exec.Cmd
+	timeout time.Duration
+	abandon bool
+}
+
+func (s *hungSupervisor) LogContainerEvent(c *Container, action string) {
+	s.mu.Lock()
+	s.events = append(s.events, action)
+	s.mu.Unlock()
+}
+
+func (s *hungSupervisor) LogContainerEventWithAttributes(c *Container, action string, attributes map[string]string) {
+	s.LogContainerEvent(c, action)
+}
+
+func (s *hungSupervisor) hasEvent(action string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, e := range s.events {
+		if e == action {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *hungSupervisor) Cleanup(c *Container) {}
+
+func (s *hungSupervisor) HoldOnFailure(c *Container) bool { return false }
+
+func (s *hungSupervisor) StartLogging(c *Container) error { return nil }
+
+func (s *hungSupervisor) Run(c *Container, pipes *execdriver.Pipes, startCallback execdriver.DriverCallback) (execdriver.ExitStatus, error) {
+	<-s.started
+	if err := s.process.Start(); err != nil {
+		return execdriver.ExitStatus{ExitCode: -1}, err
+	}
+	startCallback(&execdriver.ProcessConfig{}, s.process.Process.Pid, nil)
+	s.process.Wait()
+	return execdriver.ExitStatus{ExitCode: 137}, nil
+}
+
+func (s *hungSupervisor) IsShuttingDown() bool { return false }
+
+func (s *hungSupervisor) StartTimeout() (time.Duration, bool) {
+	return s.timeout, s.abandon
+}
+
+func (s *hungSupervisor) RestartBackoff() RestartBackoff {
+	return RestartBackoff{InitialDelay: 100 * time.Millisecond, Factor: 2}
+}
+
+func (s *hungSupervisor) CrashLoop() (int, time.Duration) {
+	return 0, 0
+}
+
+func TestStartMonitorAbandonsHungStart(t *testing.T) {
+	root, err := ioutil.TempDir("", "docker-container-monitor-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	c := NewBaseContainer("hung", root)
+	c.Config = &containertypes.Config{Image: "busybox"}
+	c.HostConfig = &containertypes.HostConfig{}
+	c.Command = &execdriver.Command{}
+
+	s := &hungSupervisor{
+		started: make(chan struct{}),
+		process: exec.Command("sleep", "60"),
+		timeout: 50 * time.Millisecond,
+		abandon: true,
+	}
+	err = c.StartMonitor(s, containertypes.RestartPolicy{Name: "always"})
+	if err == nil || err.Error() != derr.ErrorCodeStartTimeout.WithArgs(c.ID, s.timeout).Error() {
+		t.Fatalf("Expected start timeout error, got %v", err)
+	}
+	if !s.hasEvent("start-timeout") {
+		t.Fatalf("Expected a start-timeout event, got %v", s.events)
+	}
+
+	// Let the exec driver start the process after the deadline, it must be
+	// killed and the container must not be marked as running.
+	close(s.started)
+	for i := 0; !s.hasEvent("die"); i++ {
+		if i == 100 {
+			t.Fatal("Timed out waiting for the monitor to exit")
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	if c.IsRunning() {
+		t.Fatal("Expected the container not to be running")
+	}
+	if s.process.ProcessState == nil || s.process.ProcessState.Success() {
+		t.Fatalf("Expected the late process to be killed, got %v", s.process.ProcessState)
+	}
+}
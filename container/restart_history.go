@@ -0,0 +1,85 @@
+package container
+
+import (
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/daemon/execdriver"
+	"github.com/docker/docker/utils"
+)
+
+// MaxRestartHistory is the number of runs kept in the restart history of a
+// container.
+const MaxRestartHistory = 20
+
+// The reasons of the exits of the process of a container.
+const (
+	exitReasonExited  = "exited"
+	exitReasonOOM     = "oom"
+	exitReasonStopped = "stopped"
+	exitReasonError   = "error"
+)
+
+// newContainerExit describes the exit of a process started at start. stopped
+// tells whether the user or the daemon asked for the container to stop.
+func newContainerExit(start time.Time, exitStatus *execdriver.ExitStatus, runErr error, stopped bool) *types.ContainerExit {
+	exit := &types.ContainerExit{
+		Start:    start.UTC(),
+		End:      time.Now().UTC(),
+		ExitCode: exitStatus.ExitCode,
+		Reason:   exitReasonExited,
+	}
+	switch {
+	case runErr != nil:
+		exit.Reason = exitReasonError
+		exit.Error = utils.GetErrorMessage(runErr)
+	case stopped:
+		exit.Reason = exitReasonStopped
+	case oomKilled(exitStatus):
+		exit.Reason = exitReasonOOM
+	}
+	return exit
+}
+
+// isFailure tells whether the exit of a process is a failure, the exits
+// requested by the user or the daemon not being failures.
+func isFailure(exit *types.ContainerExit) bool {
+	if exit.Reason == exitReasonStopped {
+		return false
+	}
+	return exit.Reason == exitReasonError || exit.ExitCode != 0
+}
+
+// KilledExternally tells whether the last process of the container was
+// terminated by a signal which neither the user nor the daemon sent.
+func (s *State) KilledExternally() bool {
+	if len(s.RestartHistory) == 0 || s.ExitSignal == 0 {
+		return false
+	}
+	return s.RestartHistory[len(s.RestartHistory)-1].Reason == exitReasonExited
+}
+
+// addExit adds an exit to the restart history and returns the number of
+// failures which ended within window of it, including it, or 0 if it is not
+// a failure.
+func (s *State) addExit(exit *types.ContainerExit, window time.Duration) int {
+	s.RestartHistory = append(s.RestartHistory, exit)
+	if len(s.RestartHistory) > MaxRestartHistory {
+		s.RestartHistory = s.RestartHistory[len(s.RestartHistory)-MaxRestartHistory:]
+	}
+	if !isFailure(exit) {
+		return 0
+	}
+
+	failures := 0
+	for i := len(s.RestartHistory) - 1; i >= 0; i-- {
+		e := s.RestartHistory[i]
+		if exit.End.Sub(e.End) > window {
+			break
+		}
+		if isFailure(e) {
+			failures++
+		}
+	}
+	return failures
+}
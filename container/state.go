@@ -5,9 +5,11 @@ import (
 	"sync"
 	"time"
 
+	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/daemon/execdriver"
 	derr "github.com/docker/docker/errors"
 	"github.com/docker/go-units"
+	"golang.org/x/net/context"
 )
 
 // State holds the current container state, and has methods to get and
@@ -23,11 +25,18 @@ type State struct {
 	OOMKilled         bool
 	RemovalInProgress bool // Not need for this to be persistent on disk.
 	Dead              bool
+	Broken            bool // the read-write layer could not be loaded, Error holds the reason
 	Pid               int
 	ExitCode          int
-	Error             string // contains last known error when starting the container
+	ExitSignal        int               // the signal which terminated the process, 0 if it exited
+	ExitReason        string            // why the process terminated, e.g. "out of memory"
+	Error             string            // contains last known error when starting the container
+	LastError         *types.MountError // last failure to mount the read-write layer
 	StartedAt         time.Time
 	FinishedAt        time.Time
+	Health            *Health
+	Transitions       []*types.StateTransition // last changes of the status, oldest first
+	RestartHistory    []*types.ContainerExit   // last runs of the process, oldest first
 	waitChan          chan struct{}
 }
 
@@ -48,6 +57,9 @@ func (s *State) String() string {
 			return fmt.Sprintf("Restarting (%d) %s ago", s.ExitCode, units.HumanDuration(time.Now().UTC().Sub(s.FinishedAt)))
 		}
 
+		if h := s.Health; h != nil {
+			return fmt.Sprintf("Up %s (%s)", units.HumanDuration(time.Now().UTC().Sub(s.StartedAt)), h.String())
+		}
 		return fmt.Sprintf("Up %s", units.HumanDuration(time.Now().UTC().Sub(s.StartedAt)))
 	}
 
@@ -59,6 +71,10 @@ func (s *State) String() string {
 		return "Dead"
 	}
 
+	if s.Broken {
+		return "Broken"
+	}
+
 	if s.StartedAt.IsZero() {
 		return "Created"
 	}
@@ -86,6 +102,10 @@ func (s *State) StateString() string {
 		return "dead"
 	}
 
+	if s.Broken {
+		return "broken"
+	}
+
 	if s.StartedAt.IsZero() {
 		return "created"
 	}
@@ -99,6 +119,7 @@ func IsValidStateString(s string) bool {
 		s != "restarting" &&
 		s != "running" &&
 		s != "dead" &&
+		s != "broken" &&
 		s != "created" &&
 		s != "exited" {
 		return false
@@ -156,6 +177,26 @@ func (s *State) WaitStop(timeout time.Duration) (int, error) {
 	return s.getExitCode(), nil
 }
 
+// WaitStopWithContext waits until state is stopped or the context is
+// cancelled. If state already stopped it returns immediately. Returns the
+// exit code, that was passed to SetStoppedLocking, or the context error.
+func (s *State) WaitStopWithContext(ctx context.Context) (int, error) {
+	s.Lock()
+	if !s.Running {
+		exitCode := s.ExitCode
+		s.Unlock()
+		return exitCode, nil
+	}
+	waitChan := s.waitChan
+	s.Unlock()
+	select {
+	case <-ctx.Done():
+		return -1, ctx.Err()
+	case <-waitChan:
+		return s.getExitCode(), nil
+	}
+}
+
 // IsRunning returns whether the running flag is set. Used by Container to check whether a container is running.
 func (s *State) IsRunning() bool {
 	s.Lock()
@@ -181,11 +222,15 @@ func (s *State) getExitCode() int {
 
 // SetRunning sets the state of the container to "running".
 func (s *State) SetRunning(pid int) {
+	defer s.recordTransition(s.Status(), "start")
 	s.Error = ""
+	s.LastError = nil
 	s.Running = true
 	s.Paused = false
 	s.Restarting = false
 	s.ExitCode = 0
+	s.ExitSignal = 0
+	s.ExitReason = ""
 	s.Pid = pid
 	s.StartedAt = time.Now().UTC()
 	close(s.waitChan) // fire waiters for start
@@ -201,6 +246,7 @@ func (s *State) SetStoppedLocking(exitStatus *execdriver.ExitStatus) {
 
 // SetStopped sets the container state to "stopped" without locking.
 func (s *State) SetStopped(exitStatus *execdriver.ExitStatus) {
+	defer s.recordTransition(s.Status(), fmt.Sprintf("exit code %d", exitStatus.ExitCode))
 	s.Running = false
 	s.Restarting = false
 	s.Pid = 0
@@ -223,6 +269,7 @@ func (s *State) SetRestartingLocking(exitStatus *execdriver.ExitStatus) {
 func (s *State) SetRestarting(exitStatus *execdriver.ExitStatus) {
 	// we should consider the container running when it is restarting because of
 	// all the checks in docker around rm/stop/etc
+	defer s.recordTransition(s.Status(), fmt.Sprintf("restart after exit code %d", exitStatus.ExitCode))
 	s.Running = true
 	s.Restarting = true
 	s.Pid = 0
@@ -254,6 +301,10 @@ func (s *State) SetRemovalInProgress() error {
 	if s.RemovalInProgress {
 		return derr.ErrorCodeAlreadyRemoving
 	}
+	if err := s.CheckTransition(StatusRemoving); err != nil {
+		return err
+	}
+	defer s.recordTransition(s.Status(), "remove")
 	s.RemovalInProgress = true
 	return nil
 }
@@ -261,13 +312,37 @@ func (s *State) SetRemovalInProgress() error {
 // ResetRemovalInProgress make the RemovalInProgress state to false.
 func (s *State) ResetRemovalInProgress() {
 	s.Lock()
+	defer s.Unlock()
+	defer s.recordTransition(s.Status(), "removal ended")
 	s.RemovalInProgress = false
-	s.Unlock()
 }
 
 // SetDead sets the container state to "dead"
 func (s *State) SetDead() {
 	s.Lock()
+	defer s.Unlock()
+	defer s.recordTransition(s.Status(), "removal")
 	s.Dead = true
-	s.Unlock()
+}
+
+// SetPaused sets the container state to "paused" without locking.
+func (s *State) SetPaused() {
+	defer s.recordTransition(s.Status(), "pause")
+	s.Paused = true
+}
+
+// SetUnpaused sets the container state back to "running" without locking.
+func (s *State) SetUnpaused() {
+	defer s.recordTransition(s.Status(), "unpause")
+	s.Paused = false
+}
+
+// CanStart returns an error if the container cannot be started.
+func (s *State) CanStart() error {
+	return s.CheckTransition(StatusRunning)
+}
+
+// CanPause returns an error if the container cannot be paused.
+func (s *State) CanPause() error {
+	return s.CheckTransition(StatusPaused)
 }
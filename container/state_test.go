@@ -5,7 +5,9 @@ import (
 	"testing"
 	"time"
 
+	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/daemon/execdriver"
+	"golang.org/x/net/context"
 )
 
 func TestStateRunStop(t *testing.T) {
@@ -109,3 +111,116 @@ func TestStateTimeoutWait(t *testing.T) {
 	}
 
 }
+
+func TestStateWaitStopWithContext(t *testing.T) {
+	s := NewState()
+	s.Lock()
+	s.SetRunning(100)
+	s.Unlock()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := s.WaitStopWithContext(ctx); err != context.Canceled {
+		t.Fatalf("WaitStopWithContext returned err: %v, expected %v", err, context.Canceled)
+	}
+
+	stopped := make(chan struct{})
+	var exit int64
+	go func() {
+		exitCode, _ := s.WaitStopWithContext(context.Background())
+		atomic.StoreInt64(&exit, int64(exitCode))
+		close(stopped)
+	}()
+	s.SetStoppedLocking(&execdriver.ExitStatus{ExitCode: 2})
+	select {
+	case <-time.After(100 * time.Millisecond):
+		t.Fatal("Stop callback doesn't fire in 100 milliseconds")
+	case <-stopped:
+	}
+	if exitCode := int(atomic.LoadInt64(&exit)); exitCode != 2 {
+		t.Fatalf("ExitCode %v, expected 2", exitCode)
+	}
+}
+
+func TestStateTransitions(t *testing.T) {
+	s := NewState()
+	if err := s.CanPause(); err == nil {
+		t.Fatal("Expected an error pausing a created container")
+	}
+
+	s.SetRunning(100)
+	s.SetPaused()
+	s.SetUnpaused()
+	s.SetRestarting(&execdriver.ExitStatus{ExitCode: 1})
+	if err := s.CanPause(); err == nil {
+		t.Fatal("Expected an error pausing a restarting container")
+	}
+	s.SetRunning(101)
+	s.SetStopped(&execdriver.ExitStatus{ExitCode: 2})
+	if err := s.SetRemovalInProgress(); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.CanStart(); err == nil {
+		t.Fatal("Expected an error starting a container being removed")
+	}
+	s.SetDead()
+	s.ResetRemovalInProgress()
+	if err := s.CanStart(); err == nil {
+		t.Fatal("Expected an error starting a dead container")
+	}
+
+	expected := []struct{ from, to, reason string }{
+		{"created", "running", "start"},
+		{"running", "paused", "pause"},
+		{"paused", "running", "unpause"},
+		{"running", "restarting", "restart after exit code 1"},
+		{"restarting", "running", "start"},
+		{"running", "exited", "exit code 2"},
+		{"exited", "removing", "remove"},
+		{"removing", "dead", "removal ended"},
+	}
+	if len(s.Transitions) != len(expected) {
+		t.Fatalf("Expected %d transitions, got %d", len(expected), len(s.Transitions))
+	}
+	for i, e := range expected {
+		tr := s.Transitions[i]
+		if tr.From != e.from || tr.To != e.to || tr.Reason != e.reason {
+			t.Fatalf("Expected transition %d from %s to %s (%s), got from %s to %s (%s)", i, e.from, e.to, e.reason, tr.From, tr.To, tr.Reason)
+		}
+	}
+
+	for i := 0; i < maxTransitions; i++ {
+		s.recordTransition(StatusCreated, "test")
+	}
+	if len(s.Transitions) != maxTransitions {
+		t.Fatalf("Expected the history to be capped to %d transitions, got %d", maxTransitions, len(s.Transitions))
+	}
+}
+
+func TestStateAddExit(t *testing.T) {
+	s := NewState()
+	start := time.Now()
+	failure := func(end time.Time) *types.ContainerExit {
+		return &types.ContainerExit{Start: start, End: end, ExitCode: 1, Reason: exitReasonExited}
+	}
+
+	if n := s.addExit(failure(start), time.Minute); n != 1 {
+		t.Fatalf("Expected 1 failure, got %d", n)
+	}
+	if n := s.addExit(&types.ContainerExit{End: start.Add(time.Second), ExitCode: 137, Reason: exitReasonStopped}, time.Minute); n != 0 {
+		t.Fatalf("Expected a stopped container not to be a failure, got %d failures", n)
+	}
+	if n := s.addExit(failure(start.Add(30*time.Second)), time.Minute); n != 2 {
+		t.Fatalf("Expected 2 failures, got %d", n)
+	}
+	if n := s.addExit(failure(start.Add(2*time.Minute)), time.Minute); n != 1 {
+		t.Fatalf("Expected the failures out of the window not to be counted, got %d failures", n)
+	}
+
+	for i := 0; i < MaxRestartHistory; i++ {
+		s.addExit(failure(start.Add(3*time.Minute)), time.Minute)
+	}
+	if len(s.RestartHistory) != MaxRestartHistory {
+		t.Fatalf("Expected the history to be capped to %d runs, got %d", MaxRestartHistory, len(s.RestartHistory))
+	}
+}
@@ -2,11 +2,41 @@
 
 package container
 
-import "github.com/docker/docker/daemon/execdriver"
+import (
+	"strconv"
+
+	"github.com/docker/docker/daemon/execdriver"
+)
 
 // setFromExitStatus is a platform specific helper function to set the state
 // based on the ExitStatus structure.
 func (s *State) setFromExitStatus(exitStatus *execdriver.ExitStatus) {
 	s.ExitCode = exitStatus.ExitCode
 	s.OOMKilled = exitStatus.OOMKilled
+	s.ExitSignal = exitStatus.Signal
+	s.ExitReason = exitStatus.Reason
+}
+
+// oomKilled tells whether the process of the container was killed because
+// it ran out of memory.
+func oomKilled(exitStatus *execdriver.ExitStatus) bool {
+	return exitStatus.OOMKilled
+}
+
+// exitAttributes returns the attributes of the "die" event describing the
+// exit of the process of the container.
+func exitAttributes(exitStatus *execdriver.ExitStatus) map[string]string {
+	attributes := map[string]string{
+		"exitCode": strconv.Itoa(exitStatus.ExitCode),
+	}
+	if exitStatus.OOMKilled {
+		attributes["oomKilled"] = "true"
+	}
+	if exitStatus.Signal != 0 {
+		attributes["signal"] = strconv.Itoa(exitStatus.Signal)
+	}
+	if exitStatus.Reason != "" {
+		attributes["reason"] = exitStatus.Reason
+	}
+	return attributes
 }
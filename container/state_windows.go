@@ -1,9 +1,25 @@
 package container
 
-import "github.com/docker/docker/daemon/execdriver"
+import (
+	"strconv"
+
+	"github.com/docker/docker/daemon/execdriver"
+)
 
 // setFromExitStatus is a platform specific helper function to set the state
 // based on the ExitStatus structure.
 func (s *State) setFromExitStatus(exitStatus *execdriver.ExitStatus) {
 	s.ExitCode = exitStatus.ExitCode
 }
+
+// oomKilled tells whether the process of the container was killed because
+// it ran out of memory.
+func oomKilled(exitStatus *execdriver.ExitStatus) bool {
+	return false
+}
+
+// exitAttributes returns the attributes of the "die" event describing the
+// exit of the process of the container.
+func exitAttributes(exitStatus *execdriver.ExitStatus) map[string]string {
+	return map[string]string{"exitCode": strconv.Itoa(exitStatus.ExitCode)}
+}
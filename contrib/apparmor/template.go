@@ -30,9 +30,11 @@ profile /usr/bin/docker (attach_disconnected, complain) {
   owner /** rw,
   @{DOCKER_GRAPH_PATH}/** rwl,
   @{DOCKER_GRAPH_PATH}/linkgraph.db k,
+  @{DOCKER_GRAPH_PATH}/links.db k,
   @{DOCKER_GRAPH_PATH}/network/files/boltdb.db k,
   @{DOCKER_GRAPH_PATH}/network/files/local-kv.db k,
   @{DOCKER_GRAPH_PATH}/[0-9]*.[0-9]*/linkgraph.db k,
+  @{DOCKER_GRAPH_PATH}/[0-9]*.[0-9]*/links.db k,
 
   # For non-root client use:
   /dev/urandom r,
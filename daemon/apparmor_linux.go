@@ -0,0 +1,69 @@
+// +build linux
+
+package daemon
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/docker/docker/container"
+	"github.com/opencontainers/runc/libcontainer/apparmor"
+)
+
+const (
+	// appArmorProfilesPath lists the AppArmor profiles loaded in the kernel.
+	appArmorProfilesPath = "/sys/kernel/security/apparmor/profiles"
+	// defaultAppArmorProfile is the profile the native driver applies to
+	// the unprivileged containers.
+	defaultAppArmorProfile = "docker-default"
+)
+
+// validateAppArmorProfile checks that the AppArmor profile name is loaded on
+// the host, so that a container does not start with a missing profile.
+func validateAppArmorProfile(name string) error {
+	if name == "" || name == "unconfined" || !apparmor.IsEnabled() {
+		return nil
+	}
+	loaded, err := appArmorProfileLoaded(appArmorProfilesPath, name)
+	if err != nil {
+		return fmt.Errorf("Reading AppArmor profiles failed: %v", err)
+	}
+	if !loaded {
+		return fmt.Errorf("AppArmor profile %s is not loaded", name)
+	}
+	return nil
+}
+
+// appArmorProfileLoaded tells whether the profiles file at path, in the
+// format of the kernel, lists the profile name.
+func appArmorProfileLoaded(path, name string) (bool, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		// Each line is "<name> (<mode>)".
+		if fields := strings.Fields(s.Text()); len(fields) > 0 && fields[0] == name {
+			return true, nil
+		}
+	}
+	return false, s.Err()
+}
+
+// appArmorProfile returns the AppArmor profile the container runs with: the
+// profile set with --security-opt, or the one the native driver applies by
+// default when AppArmor is enabled on the host.
+func appArmorProfile(c *container.Container) string {
+	if c.AppArmorProfile != "" || !apparmor.IsEnabled() {
+		return c.AppArmorProfile
+	}
+	if c.HostConfig.Privileged {
+		return "unconfined"
+	}
+	return defaultAppArmorProfile
+}
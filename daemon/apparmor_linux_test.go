@@ -0,0 +1,42 @@
+// +build linux
+
+package daemon
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func TestAppArmorProfileLoaded(t *testing.T) {
+	f, err := ioutil.TempFile("", "docker-apparmor-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.WriteString("docker-default (enforce)\n/usr/sbin/ntpd (complain)\n"); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	for name, expected := range map[string]bool{
+		"docker-default":  true,
+		"/usr/sbin/ntpd":  true,
+		"docker":          false,
+		"custom-profile":  false,
+		"(enforce)":       false,
+		"docker-default ": false,
+	} {
+		loaded, err := appArmorProfileLoaded(f.Name(), name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if loaded != expected {
+			t.Fatalf("Expected profile %q loaded to be %v, got %v", name, expected, loaded)
+		}
+	}
+
+	if _, err := appArmorProfileLoaded(f.Name()+".missing", "docker-default"); err == nil {
+		t.Fatal("Expected an error reading a missing profiles file")
+	}
+}
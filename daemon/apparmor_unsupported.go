@@ -0,0 +1,13 @@
+// +build !linux,!windows
+
+package daemon
+
+import "github.com/docker/docker/container"
+
+func validateAppArmorProfile(name string) error {
+	return nil
+}
+
+func appArmorProfile(c *container.Container) string {
+	return c.AppArmorProfile
+}
@@ -12,6 +12,7 @@ import (
 	"github.com/docker/docker/pkg/archive"
 	"github.com/docker/docker/pkg/chrootarchive"
 	"github.com/docker/docker/pkg/ioutils"
+	"golang.org/x/net/context"
 )
 
 // ErrExtractPointNotDirectory is used to convey that the operation to extract
@@ -21,8 +22,8 @@ var ErrExtractPointNotDirectory = errors.New("extraction point is not a director
 
 // ContainerCopy performs a deprecated operation of archiving the resource at
 // the specified path in the container identified by the given name.
-func (daemon *Daemon) ContainerCopy(name string, res string) (io.ReadCloser, error) {
-	container, err := daemon.GetContainer(name)
+func (daemon *Daemon) ContainerCopy(ctx context.Context, name string, res string) (io.ReadCloser, error) {
+	container, err := daemon.getContainerWithContext(ctx, name)
 	if err != nil {
 		return nil, err
 	}
@@ -31,30 +32,30 @@ func (daemon *Daemon) ContainerCopy(name string, res string) (io.ReadCloser, err
 		res = res[1:]
 	}
 
-	return daemon.containerCopy(container, res)
+	return daemon.containerCopy(ctx, container, res)
 }
 
 // ContainerStatPath stats the filesystem resource at the specified path in the
 // container identified by the given name.
-func (daemon *Daemon) ContainerStatPath(name string, path string) (stat *types.ContainerPathStat, err error) {
-	container, err := daemon.GetContainer(name)
+func (daemon *Daemon) ContainerStatPath(ctx context.Context, name string, path string) (stat *types.ContainerPathStat, err error) {
+	container, err := daemon.getContainerWithContext(ctx, name)
 	if err != nil {
 		return nil, err
 	}
 
-	return daemon.containerStatPath(container, path)
+	return daemon.containerStatPath(ctx, container, path)
 }
 
 // ContainerArchivePath creates an archive of the filesystem resource at the
 // specified path in the container identified by the given name. Returns a
 // tar archive of the resource and whether it was a directory or a single file.
-func (daemon *Daemon) ContainerArchivePath(name string, path string) (content io.ReadCloser, stat *types.ContainerPathStat, err error) {
-	container, err := daemon.GetContainer(name)
+func (daemon *Daemon) ContainerArchivePath(ctx context.Context, name string, path string) (content io.ReadCloser, stat *types.ContainerPathStat, err error) {
+	container, err := daemon.getContainerWithContext(ctx, name)
 	if err != nil {
 		return nil, nil, err
 	}
 
-	return daemon.containerArchivePath(container, path)
+	return daemon.containerArchivePath(ctx, container, path)
 }
 
 // ContainerExtractToDir extracts the given archive to the specified location
@@ -63,22 +64,28 @@ func (daemon *Daemon) ContainerArchivePath(name string, path string) (content io
 // be ErrExtractPointNotDirectory. If noOverwriteDirNonDir is true then it will
 // be an error if unpacking the given content would cause an existing directory
 // to be replaced with a non-directory and vice versa.
-func (daemon *Daemon) ContainerExtractToDir(name, path string, noOverwriteDirNonDir bool, content io.Reader) error {
-	container, err := daemon.GetContainer(name)
+func (daemon *Daemon) ContainerExtractToDir(ctx context.Context, name, path string, noOverwriteDirNonDir bool, content io.Reader) error {
+	done, err := daemon.beginStorageWrite()
 	if err != nil {
 		return err
 	}
+	defer done()
 
-	return daemon.containerExtractToDir(container, path, noOverwriteDirNonDir, content)
+	container, err := daemon.getContainerWithContext(ctx, name)
+	if err != nil {
+		return err
+	}
+
+	return daemon.containerExtractToDir(ctx, container, path, noOverwriteDirNonDir, content)
 }
 
 // containerStatPath stats the filesystem resource at the specified path in this
 // container. Returns stat info about the resource.
-func (daemon *Daemon) containerStatPath(container *container.Container, path string) (stat *types.ContainerPathStat, err error) {
+func (daemon *Daemon) containerStatPath(ctx context.Context, container *container.Container, path string) (stat *types.ContainerPathStat, err error) {
 	container.Lock()
 	defer container.Unlock()
 
-	if err = daemon.Mount(container); err != nil {
+	if err = daemon.Mount(ctx, container); err != nil {
 		return nil, err
 	}
 	defer daemon.Unmount(container)
@@ -100,7 +107,7 @@ func (daemon *Daemon) containerStatPath(container *container.Container, path str
 // containerArchivePath creates an archive of the filesystem resource at the specified
 // path in this container. Returns a tar archive of the resource and stat info
 // about the resource.
-func (daemon *Daemon) containerArchivePath(container *container.Container, path string) (content io.ReadCloser, stat *types.ContainerPathStat, err error) {
+func (daemon *Daemon) containerArchivePath(ctx context.Context, container *container.Container, path string) (content io.ReadCloser, stat *types.ContainerPathStat, err error) {
 	container.Lock()
 
 	defer func() {
@@ -112,7 +119,7 @@ func (daemon *Daemon) containerArchivePath(container *container.Container, path
 		}
 	}()
 
-	if err = daemon.Mount(container); err != nil {
+	if err = daemon.Mount(ctx, container); err != nil {
 		return nil, nil, err
 	}
 
@@ -147,7 +154,8 @@ func (daemon *Daemon) containerArchivePath(container *container.Container, path
 	// also catches the case when the root directory of the container is
 	// requested: we want the archive entries to start with "/" and not the
 	// container ID.
-	data, err := archive.TarResourceRebase(resolvedPath, filepath.Base(absPath))
+	uidMaps, gidMaps := daemon.containerUIDGIDMaps(container.HostConfig)
+	data, err := archive.TarResourceRebaseWithMaps(resolvedPath, filepath.Base(absPath), uidMaps, gidMaps)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -171,11 +179,11 @@ func (daemon *Daemon) containerArchivePath(container *container.Container, path
 // noOverwriteDirNonDir is true then it will be an error if unpacking the
 // given content would cause an existing directory to be replaced with a non-
 // directory and vice versa.
-func (daemon *Daemon) containerExtractToDir(container *container.Container, path string, noOverwriteDirNonDir bool, content io.Reader) (err error) {
+func (daemon *Daemon) containerExtractToDir(ctx context.Context, container *container.Container, path string, noOverwriteDirNonDir bool, content io.Reader) (err error) {
 	container.Lock()
 	defer container.Unlock()
 
-	if err = daemon.Mount(container); err != nil {
+	if err = daemon.Mount(ctx, container); err != nil {
 		return err
 	}
 	defer daemon.Unmount(container)
@@ -248,9 +256,12 @@ func (daemon *Daemon) containerExtractToDir(container *container.Container, path
 		return ErrRootFSReadOnly
 	}
 
+	// The extracted files are owned by the root user of the container,
+	// which is the remapped root when user namespaces are in use.
+	uid, gid := daemon.containerRemappedUIDGID(container.HostConfig)
 	options := &archive.TarOptions{
 		ChownOpts: &archive.TarChownOptions{
-			UID: 0, GID: 0, // TODO: use config.User? Remap to userns root?
+			UID: uid, GID: gid,
 		},
 		NoOverwriteDirNonDir: noOverwriteDirNonDir,
 	}
@@ -264,7 +275,7 @@ func (daemon *Daemon) containerExtractToDir(container *container.Container, path
 	return nil
 }
 
-func (daemon *Daemon) containerCopy(container *container.Container, resource string) (rc io.ReadCloser, err error) {
+func (daemon *Daemon) containerCopy(ctx context.Context, container *container.Container, resource string) (rc io.ReadCloser, err error) {
 	container.Lock()
 
 	defer func() {
@@ -276,7 +287,7 @@ func (daemon *Daemon) containerCopy(container *container.Container, resource str
 		}
 	}()
 
-	if err := daemon.Mount(container); err != nil {
+	if err := daemon.Mount(ctx, container); err != nil {
 		return nil, err
 	}
 
@@ -310,9 +321,12 @@ func (daemon *Daemon) containerCopy(container *container.Container, resource str
 		filter = []string{filepath.Base(basePath)}
 		basePath = filepath.Dir(basePath)
 	}
+	uidMaps, gidMaps := daemon.containerUIDGIDMaps(container.HostConfig)
 	archive, err := archive.TarWithOptions(basePath, &archive.TarOptions{
 		Compression:  archive.Uncompressed,
 		IncludeFiles: filter,
+		UIDMaps:      uidMaps,
+		GIDMaps:      gidMaps,
 	})
 	if err != nil {
 		return nil, err
@@ -102,12 +102,15 @@ func (daemon *Daemon) ContainerWsAttachWithLogs(prefixOrName string, c *Containe
 
 func (daemon *Daemon) attachWithLogs(container *container.Container, stdin io.ReadCloser, stdout, stderr io.Writer, logs, stream bool, keys []byte) error {
 	if logs {
-		logDriver, err := daemon.getLogger(container)
+		logDriver, created, err := daemon.getLogger(container)
 		if err != nil {
 			return err
 		}
 		cLog, ok := logDriver.(logger.LogReader)
 		if !ok {
+			if created {
+				closeLogger(container, logDriver)
+			}
 			return logger.ErrReadLogsNotSupported
 		}
 		logs := cLog.ReadLogs(logger.ReadConfig{Tail: -1})
@@ -130,6 +133,9 @@ func (daemon *Daemon) attachWithLogs(container *container.Container, stdin io.Re
 				break LogLoop
 			}
 		}
+		if created {
+			closeLogger(container, logDriver)
+		}
 	}
 
 	daemon.LogContainerEvent(container, "attach")
@@ -0,0 +1,86 @@
+// +build !windows
+
+package daemon
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	derr "github.com/docker/docker/errors"
+)
+
+// bindPolicy restricts the host paths the containers can bind mount. The
+// rule with the longest path containing the host path applies, a deny rule
+// winning over an allow rule for the same path. The host paths matching no
+// rule are allowed only if there is no allow rule.
+type bindPolicy struct {
+	allow []string
+	deny  []string
+}
+
+// newBindPolicy returns the policy configured with --bind-mount-allow and
+// --bind-mount-deny, or nil if there is none.
+func newBindPolicy(config *Config) (*bindPolicy, error) {
+	if len(config.BindMountAllow) == 0 && len(config.BindMountDeny) == 0 {
+		return nil, nil
+	}
+	p := &bindPolicy{}
+	for _, rule := range []struct {
+		paths []string
+		dest  *[]string
+	}{
+		{config.BindMountAllow, &p.allow},
+		{config.BindMountDeny, &p.deny},
+	} {
+		for _, path := range rule.paths {
+			if !filepath.IsAbs(path) {
+				return nil, fmt.Errorf("Invalid bind mount policy path %q, paths must be absolute", path)
+			}
+			*rule.dest = append(*rule.dest, filepath.Clean(path))
+		}
+	}
+	return p, nil
+}
+
+// hasPathPrefix tells whether path is prefix or under it.
+func hasPathPrefix(path, prefix string) bool {
+	if prefix == "/" || path == prefix {
+		return true
+	}
+	return strings.HasPrefix(path, prefix+"/")
+}
+
+// longestPrefix returns the length of the longest of the prefixes path has,
+// or -1 if it has none.
+func longestPrefix(path string, prefixes []string) int {
+	longest := -1
+	for _, prefix := range prefixes {
+		if hasPathPrefix(path, prefix) && len(prefix) > longest {
+			longest = len(prefix)
+		}
+	}
+	return longest
+}
+
+// check returns an error if the host path source cannot be bind mounted.
+// The symbolic links of source are resolved first, so that a link does not
+// give access to a forbidden path.
+func (p *bindPolicy) check(source string) error {
+	if p == nil {
+		return nil
+	}
+	path := filepath.Clean(source)
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		path = resolved
+	}
+
+	allowed, denied := longestPrefix(path, p.allow), longestPrefix(path, p.deny)
+	switch {
+	case denied >= 0 && denied >= allowed:
+		return derr.ErrorCodeBindMountDenied.WithArgs(source, path)
+	case allowed < 0 && len(p.allow) > 0:
+		return derr.ErrorCodeBindMountNotAllowed.WithArgs(source)
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+// +build !windows
+
+package daemon
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/docker/distribution/registry/api/errcode"
+	derr "github.com/docker/docker/errors"
+)
+
+func TestBindPolicy(t *testing.T) {
+	if p, err := newBindPolicy(&Config{}); err != nil || p != nil {
+		t.Fatalf("Expected no policy, got %v (%v)", p, err)
+	}
+	if _, err := newBindPolicy(&Config{BindMountDeny: []string{"etc"}}); err == nil {
+		t.Fatal("Expected an error for a relative path")
+	}
+
+	tmp, err := ioutil.TempDir("", "docker-bind-policy")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+	tmp, err = filepath.EvalSymlinks(tmp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("/etc", filepath.Join(tmp, "etc")); err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := newBindPolicy(&Config{
+		BindMountAllow: []string{tmp, "/var/lib/docker/volumes/"},
+		BindMountDeny:  []string{"/", "/etc", "/var/lib/docker"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, source := range []string{tmp, filepath.Join(tmp, "data"), "/var/lib/docker/volumes/data"} {
+		if err := p.check(source); err != nil {
+			t.Fatalf("Expected %s to be allowed, got %v", source, err)
+		}
+	}
+	// The link to /etc is denied as /etc
+	for _, source := range []string{"/", "/etc/passwd", "/var/lib/docker", "/var/lib/dockerfile", filepath.Join(tmp, "etc")} {
+		if e, ok := p.check(source).(errcode.Error); !ok || e.ErrorCode() != derr.ErrorCodeBindMountDenied {
+			t.Fatalf("Expected %s to be denied, got %v", source, e)
+		}
+	}
+
+	p, err = newBindPolicy(&Config{BindMountAllow: []string{tmp}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e, ok := p.check("/srv").(errcode.Error); !ok || e.ErrorCode() != derr.ErrorCodeBindMountNotAllowed {
+		t.Fatalf("Expected a path outside of the allowed paths not to be allowed, got %v", e)
+	}
+}
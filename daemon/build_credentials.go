@@ -0,0 +1,52 @@
+package daemon
+
+import (
+	"net/url"
+	"os"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/cliconfig"
+	"github.com/docker/docker/pkg/urlutil"
+)
+
+// BuildContextAuth returns the credentials of the daemon for the host of the
+// remote build context at remoteURL, or nil if it has none. The credentials
+// file is read on each build so that it can be updated without restarting
+// the daemon.
+func (daemon *Daemon) BuildContextAuth(remoteURL string) (*types.AuthConfig, error) {
+	if daemon.configStore.BuildContextCredentials == "" {
+		return nil, nil
+	}
+	return buildContextAuth(daemon.configStore.BuildContextCredentials, remoteURL)
+}
+
+// buildContextAuth looks up the credentials for the host of remoteURL in
+// credentialsFile, which has the format of the configuration file of the
+// client. Only https URLs are given credentials, so that they are never sent
+// in clear.
+func buildContextAuth(credentialsFile, remoteURL string) (*types.AuthConfig, error) {
+	// git URLs without transport are cloned over https, see gitutils.Clone.
+	if urlutil.IsGitURL(remoteURL) && !urlutil.IsGitTransport(remoteURL) {
+		remoteURL = "https://" + remoteURL
+	}
+	u, err := url.Parse(remoteURL)
+	if err != nil || u.Scheme != "https" {
+		return nil, nil
+	}
+
+	f, err := os.Open(credentialsFile)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	configFile, err := cliconfig.LoadFromReader(f)
+	if err != nil {
+		return nil, err
+	}
+	for _, key := range []string{u.Host, "https://" + u.Host} {
+		if auth, ok := configFile.AuthConfigs[key]; ok {
+			return &auth, nil
+		}
+	}
+	return nil, nil
+}
@@ -0,0 +1,52 @@
+package daemon
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func TestBuildContextAuth(t *testing.T) {
+	f, err := ioutil.TempFile("", "build-credentials")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	// "dXNlcjpzZWNyZXQ=" is "user:secret" and "Y2k6dG9rZW4=" is "ci:token".
+	if _, err := f.WriteString(`{"auths": {"github.com": {"auth": "dXNlcjpzZWNyZXQ="}, "https://files.example.com": {"auth": "Y2k6dG9rZW4="}}}`); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	cases := []struct {
+		url      string
+		username string
+		password string
+	}{
+		{"https://github.com/docker/docker.git#master:docs", "user", "secret"},
+		{"github.com/docker/docker", "user", "secret"},
+		{"https://files.example.com/context.tar.gz", "ci", "token"},
+		{"http://github.com/docker/docker.git", "", ""},
+		{"git@github.com:docker/docker.git", "", ""},
+		{"https://example.com/context.tar", "", ""},
+	}
+	for _, c := range cases {
+		auth, err := buildContextAuth(f.Name(), c.url)
+		if err != nil {
+			t.Fatalf("%s: %v", c.url, err)
+		}
+		if c.username == "" {
+			if auth != nil {
+				t.Fatalf("%s: expected no credentials, got %v", c.url, auth.Username)
+			}
+			continue
+		}
+		if auth == nil || auth.Username != c.username || auth.Password != c.password {
+			t.Fatalf("%s: expected the credentials of %s, got %v", c.url, c.username, auth)
+		}
+	}
+
+	if _, err := buildContextAuth(f.Name()+"-missing", "https://github.com/docker/docker"); err == nil {
+		t.Fatal("Expected an error with a missing credentials file")
+	}
+}
@@ -0,0 +1,252 @@
+package daemon
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/container"
+	derr "github.com/docker/docker/errors"
+)
+
+const (
+	// captureFileName is the name of the pcap file written in the root
+	// directory of the container when no host interface is given.
+	captureFileName = "capture.pcap"
+
+	defaultCaptureDuration = time.Minute
+	maxCaptureDuration     = time.Hour
+
+	defaultCaptureMaxBytes int64 = 10 * 1024 * 1024
+	maxCaptureMaxBytes     int64 = 1024 * 1024 * 1024
+
+	// captureSnapLen is the maximum length of the packets written to the
+	// pcap file.
+	captureSnapLen = 65535
+)
+
+// errCaptureFull is returned by pcapWriter when a packet does not fit in the
+// maximum size of the file.
+var errCaptureFull = errors.New("capture file is full")
+
+// captureSession is a capture running on a container.
+type captureSession struct {
+	container *container.Container
+	info      types.ContainerCapture
+	timer     *time.Timer
+	// teardown removes the mirroring rules, or stops writing the pcap file.
+	teardown func() error
+}
+
+// captureStore holds the running captures by container ID.
+type captureStore struct {
+	sync.Mutex
+	sessions map[string]*captureSession
+}
+
+func newCaptureStore() *captureStore {
+	return &captureStore{sessions: make(map[string]*captureSession)}
+}
+
+// ContainerCaptureStart starts capturing the traffic of a running container
+// on one of its networks. The capture is stopped after options.Duration.
+func (daemon *Daemon) ContainerCaptureStart(name string, options types.ContainerCaptureOptions) (*types.ContainerCapture, error) {
+	container, err := daemon.GetContainer(name)
+	if err != nil {
+		return nil, err
+	}
+	duration, err := validateCaptureOptions(&options)
+	if err != nil {
+		return nil, err
+	}
+
+	container.Lock()
+	defer container.Unlock()
+	if !container.Running {
+		return nil, derr.ErrorCodeNotRunning.WithArgs(container.ID)
+	}
+	if options.Network == "" {
+		options.Network = container.HostConfig.NetworkMode.NetworkName()
+	}
+	if _, ok := container.NetworkSettings.Networks[options.Network]; !ok {
+		return nil, derr.ErrorCodeInvalidCapture.WithArgs(fmt.Sprintf("container %s is not connected to the network %s", container.ID, options.Network))
+	}
+
+	daemon.captures.Lock()
+	defer daemon.captures.Unlock()
+	if _, ok := daemon.captures.sessions[container.ID]; ok {
+		return nil, derr.ErrorCodeCaptureRunning.WithArgs(container.ID)
+	}
+
+	s := &captureSession{
+		container: container,
+		info: types.ContainerCapture{
+			Network:   options.Network,
+			Interface: options.Interface,
+			Started:   time.Now().UTC(),
+		},
+	}
+	s.info.Expires = s.info.Started.Add(duration)
+	if options.Interface == "" {
+		s.info.Path = filepath.Join(container.Root, captureFileName)
+	}
+	onDone := func(reason string) { daemon.stopCapture(container.ID, s, reason) }
+	hostIface, teardown, err := startCapture(container, options, s.info.Path, onDone)
+	if err != nil {
+		return nil, derr.ErrorCodeCantCapture.WithArgs(container.ID, err)
+	}
+	s.info.HostInterface = hostIface
+	s.teardown = teardown
+	s.timer = time.AfterFunc(duration, func() { daemon.stopCapture(container.ID, s, "expired") })
+	daemon.captures.sessions[container.ID] = s
+
+	daemon.LogContainerEventWithAttributes(container, "capture_start", captureAttributes(s.info))
+	info := s.info
+	return &info, nil
+}
+
+// ContainerCaptureStop stops the capture running on a container.
+func (daemon *Daemon) ContainerCaptureStop(name string) error {
+	container, err := daemon.GetContainer(name)
+	if err != nil {
+		return err
+	}
+	if !daemon.stopCapture(container.ID, nil, "stopped") {
+		return derr.ErrorCodeNoCapture.WithArgs(container.ID)
+	}
+	return nil
+}
+
+// ContainerCaptureExport writes the pcap file of the last capture of a
+// container to out.
+func (daemon *Daemon) ContainerCaptureExport(name string, out io.Writer) error {
+	container, err := daemon.GetContainer(name)
+	if err != nil {
+		return err
+	}
+	f, err := os.Open(filepath.Join(container.Root, captureFileName))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return derr.ErrorCodeNoCaptureFile.WithArgs(container.ID)
+		}
+		return err
+	}
+	defer f.Close()
+	_, err = io.Copy(out, f)
+	return err
+}
+
+// stopCapture stops the capture running on the container with the given id,
+// if it is s or s is nil, and tells whether one was stopped.
+func (daemon *Daemon) stopCapture(id string, s *captureSession, reason string) bool {
+	daemon.captures.Lock()
+	running, ok := daemon.captures.sessions[id]
+	if !ok || (s != nil && running != s) {
+		daemon.captures.Unlock()
+		return false
+	}
+	delete(daemon.captures.sessions, id)
+	daemon.captures.Unlock()
+
+	running.timer.Stop()
+	if err := running.teardown(); err != nil {
+		logrus.Warnf("Failed to tear down the capture of container %s: %v", id, err)
+	}
+	attributes := captureAttributes(running.info)
+	attributes["reason"] = reason
+	daemon.LogContainerEventWithAttributes(running.container, "capture_stop", attributes)
+	return true
+}
+
+func captureAttributes(info types.ContainerCapture) map[string]string {
+	attributes := map[string]string{
+		"network":       info.Network,
+		"hostInterface": info.HostInterface,
+	}
+	if info.Interface != "" {
+		attributes["interface"] = info.Interface
+	}
+	if info.Path != "" {
+		attributes["path"] = info.Path
+	}
+	return attributes
+}
+
+// validateCaptureOptions checks the options of a capture, setting the default
+// maximum size of the pcap file, and returns its duration.
+func validateCaptureOptions(options *types.ContainerCaptureOptions) (time.Duration, error) {
+	duration := time.Duration(options.Duration) * time.Second
+	switch {
+	case options.Duration < 0 || duration > maxCaptureDuration:
+		return 0, derr.ErrorCodeInvalidCapture.WithArgs(fmt.Sprintf("duration must be between 0 and %d seconds", int(maxCaptureDuration.Seconds())))
+	case options.Duration == 0:
+		duration = defaultCaptureDuration
+	}
+	if options.Interface != "" {
+		if options.MaxBytes != 0 {
+			return 0, derr.ErrorCodeInvalidCapture.WithArgs("the maximum size only applies to captures written to a file")
+		}
+		return duration, nil
+	}
+	switch {
+	case options.MaxBytes < 0 || options.MaxBytes > maxCaptureMaxBytes:
+		return 0, derr.ErrorCodeInvalidCapture.WithArgs(fmt.Sprintf("maximum size must be between 0 and %d bytes", maxCaptureMaxBytes))
+	case options.MaxBytes == 0:
+		options.MaxBytes = defaultCaptureMaxBytes
+	}
+	return duration, nil
+}
+
+// pcapWriter writes packets in the pcap format, up to a maximum size.
+type pcapWriter struct {
+	w       io.Writer
+	size    int64
+	maxSize int64
+}
+
+// newPcapWriter writes the pcap file header of Ethernet captures to w.
+func newPcapWriter(w io.Writer, maxSize int64) (*pcapWriter, error) {
+	header := make([]byte, 24)
+	binary.LittleEndian.PutUint32(header[0:], 0xa1b2c3d4)
+	binary.LittleEndian.PutUint16(header[4:], 2)
+	binary.LittleEndian.PutUint16(header[6:], 4)
+	binary.LittleEndian.PutUint32(header[16:], captureSnapLen)
+	binary.LittleEndian.PutUint32(header[20:], 1)
+	if int64(len(header)) > maxSize {
+		return nil, errCaptureFull
+	}
+	if _, err := w.Write(header); err != nil {
+		return nil, err
+	}
+	return &pcapWriter{w: w, size: int64(len(header)), maxSize: maxSize}, nil
+}
+
+// writePacket writes a packet captured at t, truncated to captureSnapLen. It
+// returns errCaptureFull if the packet does not fit in the file.
+func (p *pcapWriter) writePacket(t time.Time, data []byte) error {
+	length := len(data)
+	if len(data) > captureSnapLen {
+		data = data[:captureSnapLen]
+	}
+	record := make([]byte, 16+len(data))
+	binary.LittleEndian.PutUint32(record[0:], uint32(t.Unix()))
+	binary.LittleEndian.PutUint32(record[4:], uint32(t.Nanosecond()/1000))
+	binary.LittleEndian.PutUint32(record[8:], uint32(len(data)))
+	binary.LittleEndian.PutUint32(record[12:], uint32(length))
+	copy(record[16:], data)
+	if p.size+int64(len(record)) > p.maxSize {
+		return errCaptureFull
+	}
+	if _, err := p.w.Write(record); err != nil {
+		return err
+	}
+	p.size += int64(len(record))
+	return nil
+}
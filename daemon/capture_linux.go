@@ -0,0 +1,208 @@
+package daemon
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/container"
+	"github.com/vishvananda/netlink"
+	"github.com/vishvananda/netns"
+)
+
+// startCapture starts capturing the traffic of the container c on the host
+// end of its veth pair, mirroring it to options.Interface or writing it to
+// the pcap file at path. onDone is called with the reason if the capture of
+// the pcap file ends by itself. It returns the host interface and the
+// function stopping the capture.
+func startCapture(c *container.Container, options types.ContainerCaptureOptions, path string, onDone func(reason string)) (string, func() error, error) {
+	if !c.HostConfig.NetworkMode.IsPrivate() {
+		return "", nil, fmt.Errorf("the container does not have its own network stack")
+	}
+	veth, err := hostVeth(c.NetworkSettings.SandboxKey, c.NetworkSettings.Networks[options.Network].MacAddress)
+	if err != nil {
+		return "", nil, err
+	}
+	if options.Interface != "" {
+		teardown, err := startMirror(veth, options.Interface)
+		return veth.Attrs().Name, teardown, err
+	}
+	teardown, err := startPcapCapture(veth, path, options.MaxBytes, onDone)
+	return veth.Attrs().Name, teardown, err
+}
+
+// hostVeth returns the host end of the veth pair of the interface with the
+// given MAC address in the network namespace at sandboxKey.
+func hostVeth(sandboxKey, macAddress string) (netlink.Link, error) {
+	parentIndex, err := func() (int, error) {
+		runtime.LockOSThread()
+		defer runtime.UnlockOSThread()
+
+		origns, err := netns.Get()
+		if err != nil {
+			return 0, err
+		}
+		defer origns.Close()
+		ns, err := netns.GetFromPath(sandboxKey)
+		if err != nil {
+			return 0, fmt.Errorf("failed to open the network namespace %s: %v", sandboxKey, err)
+		}
+		defer ns.Close()
+		if err := netns.Set(ns); err != nil {
+			return 0, err
+		}
+		defer netns.Set(origns)
+
+		links, err := netlink.LinkList()
+		if err != nil {
+			return 0, err
+		}
+		for _, link := range links {
+			if link.Type() == "veth" && strings.EqualFold(link.Attrs().HardwareAddr.String(), macAddress) {
+				return link.Attrs().ParentIndex, nil
+			}
+		}
+		return 0, fmt.Errorf("no veth interface with the address %s", macAddress)
+	}()
+	if err != nil {
+		return nil, err
+	}
+	return netlink.LinkByIndex(parentIndex)
+}
+
+// startMirror mirrors the traffic in both directions of veth to the host
+// interface iface with tc mirred actions.
+func startMirror(veth netlink.Link, iface string) (func() error, error) {
+	if _, err := netlink.LinkByName(iface); err != nil {
+		return nil, fmt.Errorf("no host interface %s: %v", iface, err)
+	}
+	dev := veth.Attrs().Name
+	teardown := func() error {
+		// Removing the qdiscs removes their filters. The veth may have
+		// been removed with the container already.
+		var errs []string
+		for _, parent := range []string{"ingress", "root"} {
+			if _, err := netlink.LinkByName(dev); err != nil {
+				return nil
+			}
+			if err := tc("qdisc", "del", "dev", dev, parent); err != nil {
+				errs = append(errs, err.Error())
+			}
+		}
+		if len(errs) > 0 {
+			return fmt.Errorf("%s", strings.Join(errs, ", "))
+		}
+		return nil
+	}
+	for _, args := range [][]string{
+		{"qdisc", "add", "dev", dev, "ingress"},
+		{"filter", "add", "dev", dev, "parent", "ffff:", "protocol", "all", "u32", "match", "u32", "0", "0", "action", "mirred", "egress", "mirror", "dev", iface},
+		{"qdisc", "add", "dev", dev, "root", "handle", "1:", "prio"},
+		{"filter", "add", "dev", dev, "parent", "1:", "protocol", "all", "u32", "match", "u32", "0", "0", "action", "mirred", "egress", "mirror", "dev", iface},
+	} {
+		if err := tc(args...); err != nil {
+			teardown()
+			return nil, err
+		}
+	}
+	return teardown, nil
+}
+
+func tc(args ...string) error {
+	if output, err := exec.Command("tc", args...).CombinedOutput(); err != nil {
+		return fmt.Errorf("tc %s failed: %v: %s", strings.Join(args, " "), err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// startPcapCapture writes the traffic of veth to the pcap file at path, up to
+// maxBytes.
+func startPcapCapture(veth netlink.Link, path string, maxBytes int64, onDone func(reason string)) (func() error, error) {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return nil, err
+	}
+	w, err := newPcapWriter(f, maxBytes)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	fd, err := packetSocket(veth.Attrs().Index)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		defer f.Close()
+		defer syscall.Close(fd)
+
+		buf := make([]byte, captureSnapLen)
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			n, _, err := syscall.Recvfrom(fd, buf, 0)
+			if err != nil {
+				if err == syscall.EAGAIN || err == syscall.EINTR {
+					continue
+				}
+				logrus.Warnf("Failed to capture the traffic of %s: %v", veth.Attrs().Name, err)
+				go onDone("failed")
+				return
+			}
+			if err := w.writePacket(time.Now(), buf[:n]); err != nil {
+				if err == errCaptureFull {
+					go onDone("full")
+					return
+				}
+				logrus.Warnf("Failed to write the capture of %s: %v", veth.Attrs().Name, err)
+				go onDone("failed")
+				return
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() error {
+		once.Do(func() { close(stop) })
+		<-done
+		return nil
+	}, nil
+}
+
+// packetSocket opens a packet socket receiving all the traffic of the
+// interface with the given index. Reads time out every second.
+func packetSocket(index int) (int, error) {
+	proto := htons(syscall.ETH_P_ALL)
+	fd, err := syscall.Socket(syscall.AF_PACKET, syscall.SOCK_RAW, int(proto))
+	if err != nil {
+		return -1, err
+	}
+	tv := syscall.Timeval{Sec: 1}
+	if err := syscall.SetsockoptTimeval(fd, syscall.SOL_SOCKET, syscall.SO_RCVTIMEO, &tv); err != nil {
+		syscall.Close(fd)
+		return -1, err
+	}
+	if err := syscall.Bind(fd, &syscall.SockaddrLinklayer{Protocol: proto, Ifindex: index}); err != nil {
+		syscall.Close(fd)
+		return -1, err
+	}
+	return fd, nil
+}
+
+func htons(i uint16) uint16 {
+	return (i<<8)&0xff00 | i>>8
+}
@@ -0,0 +1,77 @@
+package daemon
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types"
+)
+
+func TestValidateCaptureOptions(t *testing.T) {
+	options := types.ContainerCaptureOptions{}
+	duration, err := validateCaptureOptions(&options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if duration != defaultCaptureDuration || options.MaxBytes != defaultCaptureMaxBytes {
+		t.Fatalf("Expected the default duration and size, got %v and %d", duration, options.MaxBytes)
+	}
+
+	options = types.ContainerCaptureOptions{Interface: "dummy0", Duration: 30}
+	if duration, err = validateCaptureOptions(&options); err != nil {
+		t.Fatal(err)
+	}
+	if duration != 30*time.Second || options.MaxBytes != 0 {
+		t.Fatalf("Expected a 30s mirror without a maximum size, got %v and %d", duration, options.MaxBytes)
+	}
+
+	for _, invalid := range []types.ContainerCaptureOptions{
+		{Duration: -1},
+		{Duration: 3601},
+		{MaxBytes: -1},
+		{MaxBytes: maxCaptureMaxBytes + 1},
+		{Interface: "dummy0", MaxBytes: 1024},
+	} {
+		if _, err := validateCaptureOptions(&invalid); err == nil {
+			t.Fatalf("Expected %+v to be invalid", invalid)
+		}
+	}
+}
+
+func TestPcapWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := newPcapWriter(&buf, 24+16+4+16+4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if magic := binary.LittleEndian.Uint32(buf.Bytes()); magic != 0xa1b2c3d4 || buf.Len() != 24 {
+		t.Fatalf("Unexpected pcap header %x", buf.Bytes())
+	}
+
+	ts := time.Unix(1000, 2000)
+	for i := 0; i < 2; i++ {
+		if err := w.writePacket(ts, []byte{1, 2, 3, 4}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	record := buf.Bytes()[24:]
+	if sec, usec := binary.LittleEndian.Uint32(record), binary.LittleEndian.Uint32(record[4:]); sec != 1000 || usec != 2 {
+		t.Fatalf("Unexpected timestamp %d.%06d", sec, usec)
+	}
+	if incl, orig := binary.LittleEndian.Uint32(record[8:]), binary.LittleEndian.Uint32(record[12:]); incl != 4 || orig != 4 {
+		t.Fatalf("Unexpected lengths %d and %d", incl, orig)
+	}
+
+	if err := w.writePacket(ts, []byte{1}); err != errCaptureFull {
+		t.Fatalf("Expected %v, got %v", errCaptureFull, err)
+	}
+	if buf.Len() != 24+2*20 {
+		t.Fatalf("Expected the full packet not to be written, got %d bytes", buf.Len())
+	}
+
+	if _, err := newPcapWriter(&buf, 10); err != errCaptureFull {
+		t.Fatalf("Expected %v, got %v", errCaptureFull, err)
+	}
+}
@@ -0,0 +1,14 @@
+// +build !linux
+
+package daemon
+
+import (
+	"fmt"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/container"
+)
+
+func startCapture(c *container.Container, options types.ContainerCaptureOptions, path string, onDone func(reason string)) (string, func() error, error) {
+	return "", nil, fmt.Errorf("capturing the traffic of containers is not supported on this platform")
+}
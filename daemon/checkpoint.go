@@ -0,0 +1,177 @@
+package daemon
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/container"
+	"github.com/docker/docker/daemon/execdriver"
+	derr "github.com/docker/docker/errors"
+)
+
+// checkpointConfigFileName is the name of the file describing a checkpoint,
+// stored next to the CRIU images. It is written once the checkpoint is
+// complete.
+const checkpointConfigFileName = "checkpoint.json"
+
+// CheckpointCreate checkpoints the processes of a running container. The
+// container keeps running unless config.Exit is set.
+func (daemon *Daemon) CheckpointCreate(name string, config types.CheckpointCreateOptions) error {
+	container, err := daemon.GetContainer(name)
+	if err != nil {
+		return err
+	}
+	return daemon.checkpointCreate(container, config)
+}
+
+func (daemon *Daemon) checkpointCreate(container *container.Container, config types.CheckpointCreateOptions) error {
+	container.Lock()
+	defer container.Unlock()
+
+	if !container.Running {
+		return derr.ErrorCodeNotRunning.WithArgs(container.ID)
+	}
+
+	dir, err := checkpointPath(container, config.CheckpointID)
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(dir); err == nil {
+		return derr.ErrorCodeCheckpointExists.WithArgs(config.CheckpointID, container.ID)
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+
+	opts := &execdriver.CheckpointOpts{
+		ImagesDirectory: dir,
+		WorkDirectory:   dir,
+		LeaveRunning:    !config.Exit,
+		TCPEstablished:  config.TCPEstablished,
+		ShellJob:        container.Config.Tty,
+	}
+	// The processes are killed once they are checkpointed, make sure the
+	// restart policy does not start them again, unless the checkpoint fails
+	// and they keep running.
+	signaled := config.Exit && container.ExitOnNext()
+	if err := daemon.execDriver.Checkpoint(container.Command, opts); err != nil {
+		if signaled {
+			container.CancelExitOnNext()
+		}
+		os.RemoveAll(dir)
+		return derr.ErrorCodeCantCheckpoint.WithArgs(container.ID, err)
+	}
+
+	cp := types.Checkpoint{
+		Name:           config.CheckpointID,
+		Created:        time.Now().UTC(),
+		TCPEstablished: config.TCPEstablished,
+	}
+	b, err := json.Marshal(cp)
+	if err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, checkpointConfigFileName), b, 0600); err != nil {
+		return err
+	}
+
+	daemon.LogContainerEvent(container, "checkpoint")
+	return nil
+}
+
+// CheckpointList returns the checkpoints of a container.
+func (daemon *Daemon) CheckpointList(name string) ([]types.Checkpoint, error) {
+	container, err := daemon.GetContainer(name)
+	if err != nil {
+		return nil, err
+	}
+
+	dirs, err := ioutil.ReadDir(container.CheckpointDir())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []types.Checkpoint{}, nil
+		}
+		return nil, err
+	}
+
+	checkpoints := []types.Checkpoint{}
+	for _, d := range dirs {
+		if !d.IsDir() {
+			continue
+		}
+		cp, err := readCheckpoint(filepath.Join(container.CheckpointDir(), d.Name()))
+		if err != nil {
+			logrus.Warnf("Ignoring checkpoint %s of container %s: %v", d.Name(), container.ID, err)
+			continue
+		}
+		checkpoints = append(checkpoints, cp)
+	}
+	return checkpoints, nil
+}
+
+// CheckpointDelete removes a checkpoint of a container.
+func (daemon *Daemon) CheckpointDelete(name, checkpointID string) error {
+	container, err := daemon.GetContainer(name)
+	if err != nil {
+		return err
+	}
+
+	dir, err := checkpointPath(container, checkpointID)
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(dir); err != nil {
+		if os.IsNotExist(err) {
+			return derr.ErrorCodeNoSuchCheckpoint.WithArgs(checkpointID, container.ID)
+		}
+		return err
+	}
+	return os.RemoveAll(dir)
+}
+
+// checkpointRestoreOpts returns the options to restore the container from
+// the given checkpoint.
+func checkpointRestoreOpts(container *container.Container, checkpointID string) (*execdriver.CheckpointOpts, error) {
+	dir, err := checkpointPath(container, checkpointID)
+	if err != nil {
+		return nil, err
+	}
+	cp, err := readCheckpoint(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, derr.ErrorCodeNoSuchCheckpoint.WithArgs(checkpointID, container.ID)
+		}
+		return nil, err
+	}
+	return &execdriver.CheckpointOpts{
+		ImagesDirectory: dir,
+		WorkDirectory:   dir,
+		TCPEstablished:  cp.TCPEstablished,
+		ShellJob:        container.Config.Tty,
+	}, nil
+}
+
+// checkpointPath returns the directory of the given checkpoint of the
+// container, after validating the name of the checkpoint.
+func checkpointPath(container *container.Container, checkpointID string) (string, error) {
+	if !validContainerNamePattern.MatchString(checkpointID) {
+		return "", derr.ErrorCodeInvalidCheckpointName.WithArgs(checkpointID, validContainerNameChars)
+	}
+	return filepath.Join(container.CheckpointDir(), checkpointID), nil
+}
+
+// readCheckpoint reads the description of the checkpoint stored in dir.
+func readCheckpoint(dir string) (types.Checkpoint, error) {
+	var cp types.Checkpoint
+	b, err := ioutil.ReadFile(filepath.Join(dir, checkpointConfigFileName))
+	if err != nil {
+		return cp, err
+	}
+	err = json.Unmarshal(b, &cp)
+	return cp, err
+}
@@ -0,0 +1,52 @@
+package daemon
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	containertypes "github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/container"
+)
+
+func TestCheckpointRestoreOpts(t *testing.T) {
+	root, err := ioutil.TempDir("", "docker-checkpoint-test-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	c := container.NewBaseContainer("checkpointed", root)
+	c.Config = &containertypes.Config{Tty: true}
+
+	if _, err := checkpointRestoreOpts(c, "missing"); err == nil {
+		t.Fatal("Expected an error for a missing checkpoint")
+	}
+	if _, err := checkpointRestoreOpts(c, "../escape"); err == nil {
+		t.Fatal("Expected an error for an invalid checkpoint name")
+	}
+
+	dir := filepath.Join(c.CheckpointDir(), "cp1")
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		t.Fatal(err)
+	}
+	b, err := json.Marshal(types.Checkpoint{Name: "cp1", Created: time.Now().UTC(), TCPEstablished: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, checkpointConfigFileName), b, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	opts, err := checkpointRestoreOpts(c, "cp1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if opts.ImagesDirectory != dir || !opts.TCPEstablished || !opts.ShellJob || opts.LeaveRunning {
+		t.Fatalf("Unexpected restore options %+v", opts)
+	}
+}
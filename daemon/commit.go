@@ -16,11 +16,18 @@ import (
 	"github.com/docker/docker/pkg/ioutils"
 	"github.com/docker/docker/reference"
 	"github.com/docker/docker/runconfig"
+	"golang.org/x/net/context"
 )
 
 // Commit creates a new filesystem image from the current state of a container.
 // The image can optionally be tagged into a repository.
 func (daemon *Daemon) Commit(name string, c *types.ContainerCommitConfig) (string, error) {
+	done, err := daemon.beginStorageWrite()
+	if err != nil {
+		return "", err
+	}
+	defer done()
+
 	container, err := daemon.GetContainer(name)
 	if err != nil {
 		return "", err
@@ -36,6 +43,10 @@ func (daemon *Daemon) Commit(name string, c *types.ContainerCommitConfig) (strin
 		defer daemon.containerUnpause(container)
 	}
 
+	if err := daemon.checkCommitSize(container); err != nil {
+		return "", err
+	}
+
 	if c.MergeConfigs {
 		if err := runconfig.Merge(c.Config, container.Config); err != nil {
 			return "", err
@@ -66,7 +77,7 @@ func (daemon *Daemon) Commit(name string, c *types.ContainerCommitConfig) (strin
 
 	l, err := daemon.layerStore.Register(rwTar, rootFS.ChainID())
 	if err != nil {
-		return "", err
+		return "", daemon.storageError(err)
 	}
 	defer layer.ReleaseAndLog(daemon.layerStore, l)
 
@@ -135,7 +146,7 @@ func (daemon *Daemon) Commit(name string, c *types.ContainerCommitConfig) (strin
 }
 
 func (daemon *Daemon) exportContainerRw(container *container.Container) (archive.Archive, error) {
-	if err := daemon.Mount(container); err != nil {
+	if err := daemon.Mount(context.Background(), container); err != nil {
 		return nil, err
 	}
 
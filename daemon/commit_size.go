@@ -0,0 +1,49 @@
+package daemon
+
+import (
+	"fmt"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/docker/docker/container"
+	derr "github.com/docker/docker/errors"
+	"github.com/docker/docker/pkg/stringid"
+	"github.com/docker/go-units"
+)
+
+// parseCommitSizeLimit returns the maximum size in bytes of the read-write
+// layer of a committed or exported container, or 0 if it is unlimited.
+func parseCommitSizeLimit(config *Config) (int64, error) {
+	if config.CommitSizeLimit == "" {
+		return 0, nil
+	}
+	limit, err := units.RAMInBytes(config.CommitSizeLimit)
+	if err != nil || limit <= 0 {
+		return 0, fmt.Errorf("Invalid commit size limit: %q", config.CommitSizeLimit)
+	}
+	return limit, nil
+}
+
+// checkCommitSize checks the size of the read-write layer of the container
+// against the commit size limit of the daemon, before the layer is committed
+// or exported. Exceeding the limit is an error, or only logs a warning if the
+// daemon is configured so.
+func (daemon *Daemon) checkCommitSize(container *container.Container) error {
+	if daemon.commitSizeLimit == 0 || container.RWLayer == nil {
+		return nil
+	}
+	size, err := container.RWLayer.Size()
+	if err != nil {
+		return err
+	}
+	if size <= daemon.commitSizeLimit {
+		return nil
+	}
+
+	id := stringid.TruncateID(container.ID)
+	humanSize, humanLimit := units.HumanSize(float64(size)), units.HumanSize(float64(daemon.commitSizeLimit))
+	if daemon.configStore.CommitSizeLimitWarn {
+		logrus.Warnf("The read-write layer of container %s is %s, which exceeds the commit size limit of %s", id, humanSize, humanLimit)
+		return nil
+	}
+	return derr.ErrorCodeCommitSizeLimit.WithArgs(id, humanSize, humanLimit)
+}
@@ -0,0 +1,63 @@
+package daemon
+
+import (
+	"testing"
+
+	"github.com/docker/distribution/registry/api/errcode"
+	"github.com/docker/docker/container"
+	derr "github.com/docker/docker/errors"
+	"github.com/docker/docker/layer"
+)
+
+type sizedRWLayer struct {
+	layer.RWLayer
+	size int64
+}
+
+func (l *sizedRWLayer) Size() (int64, error) {
+	return l.size, nil
+}
+
+func TestParseCommitSizeLimit(t *testing.T) {
+	valid := map[string]int64{
+		"":     0,
+		"1024": 1024,
+		"10g":  10 * 1024 * 1024 * 1024,
+	}
+	for value, expected := range valid {
+		limit, err := parseCommitSizeLimit(&Config{CommonConfig: CommonConfig{CommitSizeLimit: value}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if limit != expected {
+			t.Fatalf("Expected %d for %q, got %d", expected, value, limit)
+		}
+	}
+
+	for _, value := range []string{"0", "-1g", "big"} {
+		if _, err := parseCommitSizeLimit(&Config{CommonConfig: CommonConfig{CommitSizeLimit: value}}); err == nil {
+			t.Fatalf("Expected %q to be invalid", value)
+		}
+	}
+}
+
+func TestCheckCommitSize(t *testing.T) {
+	daemon := &Daemon{configStore: &Config{}, commitSizeLimit: 1000}
+	c := &container.Container{CommonContainer: container.CommonContainer{ID: "5a4ff6a163ad"}}
+
+	c.RWLayer = &sizedRWLayer{size: 1000}
+	if err := daemon.checkCommitSize(c); err != nil {
+		t.Fatal(err)
+	}
+
+	c.RWLayer = &sizedRWLayer{size: 1001}
+	err := daemon.checkCommitSize(c)
+	if e, ok := err.(errcode.Error); !ok || e.ErrorCode() != derr.ErrorCodeCommitSizeLimit {
+		t.Fatalf("Expected a commit size limit error, got %v", err)
+	}
+
+	daemon.configStore.CommitSizeLimitWarn = true
+	if err := daemon.checkCommitSize(c); err != nil {
+		t.Fatalf("Expected only a warning, got %v", err)
+	}
+}
@@ -1,6 +1,8 @@
 package daemon
 
 import (
+	"time"
+
 	"github.com/docker/docker/api/types/container"
 	"github.com/docker/docker/opts"
 	flag "github.com/docker/docker/pkg/mflag"
@@ -34,6 +36,154 @@ type CommonConfig struct {
 	Root          string
 	TrustKeyPath  string
 
+	// ContainerNameTemplate is the text/template used to generate the
+	// names of containers created without an explicit name.
+	ContainerNameTemplate string
+
+	// HostnameTemplate is the text/template used to generate the
+	// hostnames of containers created without an explicit hostname.
+	HostnameTemplate string
+
+	// StartTimeout is how long the daemon waits for the exec driver to
+	// start the process of a container before flagging the start as hung.
+	// A zero value disables the start watchdog.
+	StartTimeout time.Duration
+
+	// StartTimeoutCleanup abandons hung container starts, releasing the
+	// mounts and network endpoints of the container.
+	StartTimeoutCleanup bool
+
+	// StartRetries is the number of times a container start failing with a
+	// transient error is retried.
+	StartRetries int
+
+	// StartRetryDelay is the delay before the first retry of a container
+	// start, doubled after each retry.
+	StartRetryDelay time.Duration
+
+	// RestartDelay is the delay before the first restart of a container by
+	// its restart policy.
+	RestartDelay time.Duration
+
+	// RestartMaxDelay caps the delay between the restarts of a container.
+	// A zero value leaves the delay unbounded.
+	RestartMaxDelay time.Duration
+
+	// RestartBackoffFactor multiplies the delay between the restarts of a
+	// container after each restart following a short run.
+	RestartBackoffFactor float64
+
+	// RestartJitter is the fraction of the restart delay which is
+	// randomized, between 0 and 1.
+	RestartJitter float64
+
+	// HoldOnFailure is how long the mounts and the network sandbox of a
+	// container whose process failed are kept before being released. Zero
+	// releases them right away.
+	HoldOnFailure time.Duration
+
+	// ReconcileInterval is the interval at which the containers are
+	// converged to their desired state, 0 to disable it.
+	ReconcileInterval time.Duration
+
+	// CrashLoopFailures is the number of failures of a container within
+	// CrashLoopWindow which make it crash looping. Zero disables the
+	// detection.
+	CrashLoopFailures int
+
+	// CrashLoopWindow is the period the failures of a container are
+	// counted over to detect a crash loop.
+	CrashLoopWindow time.Duration
+
+	// WatchContainerConfigs enables reloading container configuration
+	// files that were modified outside of the daemon.
+	WatchContainerConfigs bool
+
+	// EventsLogMax is the maximum number of events kept in the events
+	// journal on disk, which lets `since` queries replay the events
+	// logged before a restart of the daemon. Zero disables the journal.
+	EventsLogMax int
+
+	// EventsLogRetention is how long the events are kept in the events
+	// journal. A zero value keeps them until they are discarded by
+	// EventsLogMax.
+	EventsLogRetention time.Duration
+
+	// DefaultStopTimeout is the number of seconds to wait for a container
+	// to stop before killing it, for the containers without a stop timeout.
+	DefaultStopTimeout int
+
+	// StatsInterval is the interval at which the stats of the containers
+	// are collected.
+	StatsInterval time.Duration
+
+	// StatsOnDemand only runs the collection of stats while at least one
+	// client is streaming the stats of a container, instead of waking up
+	// at every interval.
+	StatsOnDemand bool
+
+	// CommitSizeLimit is the maximum size of the read-write layer of a
+	// committed or exported container, such as "10g". An empty value
+	// disables the limit.
+	CommitSizeLimit string
+
+	// CommitSizeLimitWarn only logs a warning when the commit size limit
+	// is exceeded, instead of failing the commit or the export.
+	CommitSizeLimitWarn bool
+
+	// RestoreInBackground serves the API while the containers are being
+	// restored, accepting only read-only requests and container creations
+	// until they are.
+	RestoreInBackground bool
+
+	// PressureLabel selects the low-priority containers, in the form
+	// key[=value], which are paused while the host is under pressure.
+	PressureLabel string
+
+	// PressureMemory is the percentage of the memory of the host in use
+	// above which it is under pressure, 0 to ignore the memory.
+	PressureMemory int
+
+	// PressureLoad is the load average per CPU of the host above which it
+	// is under pressure, 0 to ignore the load.
+	PressureLoad float64
+
+	// PressureInterval is the interval at which the pressure on the host is
+	// checked.
+	PressureInterval time.Duration
+
+	// InhibitShutdown takes a delay lock on the shutdown of the host from
+	// logind, to stop the containers gracefully before it shuts down.
+	InhibitShutdown bool
+
+	// LayerCache is the directory of a cache of layer blobs shared with the
+	// other daemons of the host, consulted before downloading a layer.
+	LayerCache string
+
+	// BuildSourceLabels adds labels describing the build time and the
+	// sources of the images built by the daemon.
+	BuildSourceLabels bool
+
+	// BuildContextCredentials is a file with the credentials used to fetch
+	// the remote build contexts, in the format of the client configuration.
+	BuildContextCredentials string
+
+	// LogRules are the specifications of the rules selecting the log
+	// driver of the containers created without one, by label or image.
+	LogRules []string
+
+	// EventSinks are the specifications of the webhooks, unix sockets and
+	// files the events of the daemon are forwarded to.
+	EventSinks []string
+
+	// ContentTrust verifies the images pulled by tag against their signed
+	// trust metadata, and signs the images pushed.
+	ContentTrust bool
+
+	// ContentTrustServers are the URLs of the trust servers by registry,
+	// overriding the default trust servers.
+	ContentTrustServers map[string]string
+
 	// ClusterStore is the storage backend used for the cluster information. It is used by both
 	// multihost networking (to store networks and endpoints information) and by the node discovery
 	// mechanism.
@@ -43,6 +193,10 @@ type CommonConfig struct {
 	// as TLS configuration settings.
 	ClusterOpts map[string]string
 
+	// ServicePublish is the URL of the key-value store the running
+	// containers are published in for the service discovery.
+	ServicePublish string
+
 	// ClusterAdvertise is the network endpoint that the Engine advertises for the purpose of node
 	// discovery. This should be a 'host:port' combination on which that daemon instance is
 	// reachable by other hosts.
@@ -70,7 +224,43 @@ func (config *Config) InstallCommonFlags(cmd *flag.FlagSet, usageFn func(string)
 	cmd.Var(opts.NewListOptsRef(&config.Labels, opts.ValidateLabel), []string{"-label"}, usageFn("Set key=value labels to the daemon"))
 	cmd.StringVar(&config.LogConfig.Type, []string{"-log-driver"}, "json-file", usageFn("Default driver for container logs"))
 	cmd.Var(opts.NewMapOpts(config.LogConfig.Config, nil), []string{"-log-opt"}, usageFn("Set log driver options"))
+	cmd.Var(opts.NewListOptsRef(&config.LogRules, nil), []string{"-log-rule"}, usageFn("Select the log driver of containers by label or image"))
 	cmd.StringVar(&config.ClusterAdvertise, []string{"-cluster-advertise"}, "", usageFn("Address or interface name to advertise"))
 	cmd.StringVar(&config.ClusterStore, []string{"-cluster-store"}, "", usageFn("Set the cluster store"))
 	cmd.Var(opts.NewMapOpts(config.ClusterOpts, nil), []string{"-cluster-store-opt"}, usageFn("Set cluster store options"))
+	cmd.StringVar(&config.ServicePublish, []string{"-service-publish"}, "", usageFn("Publish the running containers in a key-value store (consul://, etcd:// or zk://)"))
+	cmd.StringVar(&config.ContainerNameTemplate, []string{"-container-name-template"}, "", usageFn("Template for generated container names"))
+	cmd.StringVar(&config.HostnameTemplate, []string{"-hostname-template"}, "", usageFn("Template for generated container hostnames"))
+	cmd.IntVar(&config.EventsLogMax, []string{"-events-log-max"}, 0, usageFn("Maximum number of events kept on disk, 0 disables the events log"))
+	cmd.DurationVar(&config.EventsLogRetention, []string{"-events-log-retention"}, 0, usageFn("Maximum age of the events kept on disk"))
+	cmd.IntVar(&config.DefaultStopTimeout, []string{"-default-stop-timeout"}, 10, usageFn("Default timeout (in seconds) to stop a container"))
+	cmd.DurationVar(&config.StatsInterval, []string{"-stats-interval"}, time.Second, usageFn("Interval at which the stats of the containers are collected"))
+	cmd.BoolVar(&config.StatsOnDemand, []string{"-stats-on-demand"}, false, usageFn("Only collect stats while a client is streaming them"))
+	cmd.StringVar(&config.CommitSizeLimit, []string{"-commit-size-limit"}, "", usageFn("Maximum size of the read-write layer of a committed or exported container"))
+	cmd.BoolVar(&config.CommitSizeLimitWarn, []string{"-commit-size-limit-warn"}, false, usageFn("Only warn when the commit size limit is exceeded"))
+	cmd.BoolVar(&config.RestoreInBackground, []string{"-restore-in-background"}, false, usageFn("Serve read-only requests and container creations while the containers are restored"))
+	cmd.StringVar(&config.PressureLabel, []string{"-pressure-label"}, "", usageFn("Label (key[=value]) of the containers paused while the host is under pressure"))
+	cmd.IntVar(&config.PressureMemory, []string{"-pressure-memory"}, 0, usageFn("Percentage of the host memory in use above which the host is under pressure"))
+	cmd.Float64Var(&config.PressureLoad, []string{"-pressure-load"}, 0, usageFn("Load average per CPU above which the host is under pressure"))
+	cmd.DurationVar(&config.PressureInterval, []string{"-pressure-interval"}, 10*time.Second, usageFn("Interval at which the pressure on the host is checked"))
+	cmd.BoolVar(&config.InhibitShutdown, []string{"-inhibit-shutdown"}, false, usageFn("Delay the shutdown of the host to stop the containers gracefully"))
+	cmd.StringVar(&config.LayerCache, []string{"-layer-cache"}, "", usageFn("Directory of a layer cache shared with other daemons, consulted before downloading layers"))
+	cmd.BoolVar(&config.BuildSourceLabels, []string{"-build-source-labels"}, false, usageFn("Label built images with their build time and source revision"))
+	cmd.StringVar(&config.BuildContextCredentials, []string{"-build-context-credentials"}, "", usageFn("File with the credentials used to fetch remote build contexts"))
+	cmd.Var(opts.NewListOptsRef(&config.EventSinks, nil), []string{"-event-sink"}, usageFn("Forward the events to a webhook, unix socket or file"))
+	cmd.DurationVar(&config.StartTimeout, []string{"-start-timeout"}, 0, usageFn("Time to wait for a container process to start before flagging it as hung"))
+	cmd.BoolVar(&config.StartTimeoutCleanup, []string{"-start-timeout-cleanup"}, false, usageFn("Abandon container starts exceeding the start timeout"))
+	cmd.IntVar(&config.StartRetries, []string{"-start-retries"}, 0, usageFn("Number of retries of container starts failing with a transient error"))
+	cmd.DurationVar(&config.StartRetryDelay, []string{"-start-retry-delay"}, 500*time.Millisecond, usageFn("Delay before the first retry of a container start, doubled after each retry"))
+	cmd.DurationVar(&config.RestartDelay, []string{"-restart-delay"}, 100*time.Millisecond, usageFn("Delay before the first restart of a container by its restart policy"))
+	cmd.DurationVar(&config.RestartMaxDelay, []string{"-restart-max-delay"}, time.Minute, usageFn("Maximum delay between the restarts of a container, 0 for no maximum"))
+	cmd.Float64Var(&config.RestartBackoffFactor, []string{"-restart-backoff-factor"}, 2, usageFn("Factor the restart delay is multiplied by after each restart"))
+	cmd.Float64Var(&config.RestartJitter, []string{"-restart-jitter"}, 0, usageFn("Fraction of the restart delay which is randomized"))
+	cmd.IntVar(&config.CrashLoopFailures, []string{"-crash-loop-failures"}, 5, usageFn("Number of failures of a container within the crash loop window which make it crash looping, 0 to disable"))
+	cmd.DurationVar(&config.CrashLoopWindow, []string{"-crash-loop-window"}, 5*time.Minute, usageFn("Period over which the failures of a container are counted to detect crash loops"))
+	cmd.DurationVar(&config.ReconcileInterval, []string{"-reconcile-interval"}, 0, usageFn("Interval at which the containers are converged to their desired state"))
+	cmd.DurationVar(&config.HoldOnFailure, []string{"-hold-on-failure"}, 0, usageFn("Keep the mounts and network of failed containers for this long"))
+	cmd.BoolVar(&config.ContentTrust, []string{"-content-trust"}, false, usageFn("Only pull signed images and sign the images pushed"))
+	cmd.Var(opts.NewMapOpts(config.ContentTrustServers, nil), []string{"-content-trust-server"}, usageFn("Set the trust server of a registry (registry=URL)"))
+	cmd.BoolVar(&config.WatchContainerConfigs, []string{"-watch-container-configs"}, false, usageFn("Reload container configurations modified on disk"))
 }
@@ -30,6 +30,14 @@ type Config struct {
 	SocketGroup          string
 	CgroupParent         string
 	Ulimits              map[string]*units.Ulimit
+	BaselineMounts       []string
+	BindMountAllow       []string
+	BindMountDeny        []string
+	DefaultTimezone      string
+	IsolatedCpus         string
+	KernelModuleLoad     bool
+	LinkEnv              bool
+	SeccompProfile       string
 }
 
 // bridgeConfig stores all the bridge driver specific
@@ -79,6 +87,14 @@ func (config *Config) InstallFlags(cmd *flag.FlagSet, usageFn func(string) strin
 	cmd.BoolVar(&config.EnableCors, []string{"#api-enable-cors", "#-api-enable-cors"}, false, usageFn("Enable CORS headers in the remote API, this is deprecated by --api-cors-header"))
 	cmd.StringVar(&config.CorsHeaders, []string{"-api-cors-header"}, "", usageFn("Set CORS headers in the remote API"))
 	cmd.StringVar(&config.CgroupParent, []string{"-cgroup-parent"}, "/docker", usageFn("Set parent cgroup for all containers"))
+	cmd.Var(opts.NewListOptsRef(&config.BaselineMounts, nil), []string{"-baseline-mount"}, usageFn("Host path to bind mount read-only into every container (host-path[:container-path])"))
+	cmd.Var(opts.NewListOptsRef(&config.BindMountAllow, nil), []string{"-bind-mount-allow"}, usageFn("Host path containers are allowed to bind mount"))
+	cmd.Var(opts.NewListOptsRef(&config.BindMountDeny, nil), []string{"-bind-mount-deny"}, usageFn("Host path containers are not allowed to bind mount"))
+	cmd.StringVar(&config.DefaultTimezone, []string{"-default-timezone"}, "", usageFn("Default timezone of containers, e.g. Europe/Paris"))
+	cmd.BoolVar(&config.LinkEnv, []string{"-link-env"}, true, usageFn("Inject the environment variables of links on user-defined networks"))
+	cmd.StringVar(&config.SeccompProfile, []string{"-seccomp-profile"}, "", usageFn("Path to the default seccomp profile of containers, or unconfined"))
+	cmd.StringVar(&config.IsolatedCpus, []string{"-isolated-cpus"}, "", usageFn("CPUs reserved for CPU isolation groups (0-3, 0,1)"))
+	cmd.BoolVar(&config.KernelModuleLoad, []string{"-kernel-module-load"}, false, usageFn("Load the kernel modules required by containers with modprobe"))
 
 	config.attachExperimentalFlags(cmd, usageFn)
 }
@@ -0,0 +1,110 @@
+package daemon
+
+import (
+	"path/filepath"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/docker/docker/container"
+	"github.com/docker/docker/pkg/filenotify"
+)
+
+// containerConfigWatcher watches the configuration files of the daemon's
+// containers for modifications made outside of the daemon, e.g. by
+// recovery tooling, so that they are not silently overwritten.
+type containerConfigWatcher struct {
+	watcher filenotify.FileWatcher
+}
+
+// startContainerConfigWatcher creates the config watcher and starts
+// processing its events.
+func (daemon *Daemon) startContainerConfigWatcher() (*containerConfigWatcher, error) {
+	watcher, err := filenotify.NewEventWatcher()
+	if err != nil {
+		return nil, err
+	}
+	w := &containerConfigWatcher{watcher: watcher}
+	go daemon.processConfigEvents(w)
+	return w, nil
+}
+
+// watch starts watching the configuration files of the container.
+func (w *containerConfigWatcher) watch(c *container.Container) {
+	if w == nil {
+		return
+	}
+	if err := w.watcher.Add(c.Root); err != nil {
+		logrus.Warnf("Cannot watch configuration of container %s: %v", c.ID, err)
+	}
+}
+
+// unwatch stops watching the configuration files of the container.
+func (w *containerConfigWatcher) unwatch(c *container.Container) {
+	if w == nil {
+		return
+	}
+	w.watcher.Remove(c.Root)
+}
+
+// close stops the watcher.
+func (w *containerConfigWatcher) close() error {
+	if w == nil {
+		return nil
+	}
+	return w.watcher.Close()
+}
+
+func (daemon *Daemon) processConfigEvents(w *containerConfigWatcher) {
+	for {
+		select {
+		case e, ok := <-w.watcher.Events():
+			if !ok {
+				return
+			}
+			if !container.IsConfigFile(e.Name) {
+				continue
+			}
+			c := daemon.containers.Get(filepath.Base(filepath.Dir(e.Name)))
+			if c == nil {
+				continue
+			}
+			daemon.handleExternalConfigChange(c)
+		case err, ok := <-w.watcher.Errors():
+			if !ok {
+				return
+			}
+			logrus.Errorf("Error watching container configurations: %v", err)
+		}
+	}
+}
+
+// handleExternalConfigChange reloads the configuration of the container if
+// it was modified outside of the daemon. The configuration of a running
+// container cannot be reloaded safely, it is flagged as a conflict instead.
+func (daemon *Daemon) handleExternalConfigChange(c *container.Container) {
+	c.Lock()
+	defer c.Unlock()
+
+	modified, err := c.ConfigModifiedOnDisk()
+	if err != nil {
+		logrus.Errorf("Cannot check configuration of container %s: %v", c.ID, err)
+		return
+	}
+	if !modified {
+		return
+	}
+
+	if c.Running || c.Restarting || c.RemovalInProgress {
+		if !c.HasConfigConflict() {
+			logrus.Warnf("Configuration of container %s was modified on disk while it is running, it will be reloaded when the container stops", c.ID)
+			c.SetConfigConflict()
+		}
+		return
+	}
+
+	if err := c.ReloadConfig(); err != nil {
+		logrus.Errorf("Cannot reload configuration of container %s: %v", c.ID, err)
+		c.SetConfigConflict()
+		return
+	}
+	logrus.Infof("Reloaded configuration of container %s modified on disk", c.ID)
+}
@@ -4,11 +4,14 @@ package daemon
 
 import (
 	"fmt"
+	"io/ioutil"
+	"net"
 	"os"
 	"path"
 	"path/filepath"
 	"strconv"
 	"strings"
+	"sync"
 	"syscall"
 	"time"
 
@@ -27,11 +30,13 @@ import (
 	"github.com/docker/docker/runconfig"
 	"github.com/docker/go-units"
 	"github.com/docker/libnetwork"
+	"github.com/docker/libnetwork/etchosts"
 	"github.com/docker/libnetwork/netlabel"
 	"github.com/docker/libnetwork/options"
 	"github.com/opencontainers/runc/libcontainer/configs"
 	"github.com/opencontainers/runc/libcontainer/devices"
 	"github.com/opencontainers/runc/libcontainer/label"
+	"golang.org/x/net/context"
 )
 
 func (daemon *Daemon) setupLinkedContainers(container *container.Container) ([]string, error) {
@@ -41,8 +46,9 @@ func (daemon *Daemon) setupLinkedContainers(container *container.Container) ([]s
 		return nil, err
 	}
 
-	bridgeSettings := container.NetworkSettings.Networks["bridge"]
-	if bridgeSettings == nil {
+	network := daemon.linkNetwork(container)
+	settings := container.NetworkSettings.Networks[network]
+	if settings == nil {
 		return nil, nil
 	}
 
@@ -52,19 +58,22 @@ func (daemon *Daemon) setupLinkedContainers(container *container.Container) ([]s
 				return nil, derr.ErrorCodeLinkNotRunning.WithArgs(child.Name, linkAlias)
 			}
 
-			childBridgeSettings := child.NetworkSettings.Networks["bridge"]
-			if childBridgeSettings == nil {
-				return nil, fmt.Errorf("container %s not attached to default bridge network", child.ID)
+			childSettings := child.NetworkSettings.Networks[network]
+			if childSettings == nil {
+				return nil, fmt.Errorf("container %s not attached to network %s", child.ID, network)
 			}
 
 			link := links.NewLink(
-				bridgeSettings.IPAddress,
-				childBridgeSettings.IPAddress,
+				settings.IPAddress,
+				childSettings.IPAddress,
 				linkAlias,
 				child.Config.Env,
 				child.Config.ExposedPorts,
 			)
 
+			if !daemon.linkEnvEnabled(container) {
+				continue
+			}
 			for _, envVar := range link.ToEnv() {
 				env = append(env, envVar)
 			}
@@ -73,6 +82,30 @@ func (daemon *Daemon) setupLinkedContainers(container *container.Container) ([]s
 	return env, nil
 }
 
+// seccompProfile returns the seccomp profile of the container: the profile
+// set with --security-opt, or the default profile of the daemon for the
+// unprivileged containers.
+func (daemon *Daemon) seccompProfile(c *container.Container) string {
+	if c.SeccompProfile != "" || c.HostConfig.Privileged {
+		return c.SeccompProfile
+	}
+	return daemon.configStore.SeccompProfile
+}
+
+// linkEnvEnabled tells whether the environment variables of the links of the
+// container are injected in the container. On user-defined networks, links
+// are resolved through DNS and the variables can be disabled by the daemon
+// or the container.
+func (daemon *Daemon) linkEnvEnabled(container *container.Container) bool {
+	if !container.HostConfig.NetworkMode.IsUserDefined() {
+		return true
+	}
+	if container.HostConfig.LinkEnv != nil {
+		return *container.HostConfig.LinkEnv
+	}
+	return daemon.configStore.LinkEnv
+}
+
 func (daemon *Daemon) populateCommand(c *container.Container, env []string) error {
 	var en *execdriver.Network
 	if !c.Config.NetworkDisabled {
@@ -193,6 +226,11 @@ func (daemon *Daemon) populateCommand(c *container.Container, env []string) erro
 		rlimits = append(rlimits, rl)
 	}
 
+	cpusetCpus, err := daemon.containerCpusetCpus(c)
+	if err != nil {
+		return err
+	}
+
 	resources := &execdriver.Resources{
 		CommonResources: execdriver.CommonResources{
 			Memory:            c.HostConfig.Memory,
@@ -202,7 +240,7 @@ func (daemon *Daemon) populateCommand(c *container.Container, env []string) erro
 		},
 		MemorySwap:                   c.HostConfig.MemorySwap,
 		KernelMemory:                 c.HostConfig.KernelMemory,
-		CpusetCpus:                   c.HostConfig.CpusetCpus,
+		CpusetCpus:                   cpusetCpus,
 		CpusetMems:                   c.HostConfig.CpusetMems,
 		CPUPeriod:                    c.HostConfig.CPUPeriod,
 		CPUQuota:                     c.HostConfig.CPUQuota,
@@ -234,12 +272,19 @@ func (daemon *Daemon) populateCommand(c *container.Container, env []string) erro
 	processConfig.Env = env
 
 	remappedRoot := &execdriver.User{}
-	rootUID, rootGID := daemon.GetRemappedUIDGID()
+	rootUID, rootGID := daemon.containerRemappedUIDGID(c.HostConfig)
 	if rootUID != 0 {
 		remappedRoot.UID = rootUID
 		remappedRoot.GID = rootGID
 	}
-	uidMap, gidMap := daemon.GetUIDGIDMaps()
+	uidMap, gidMap := daemon.containerUIDGIDMaps(c.HostConfig)
+
+	// The profile is checked when the container starts, as it may be loaded
+	// or unloaded on the host after the container is created.
+	profile := appArmorProfile(c)
+	if err := validateAppArmorProfile(profile); err != nil {
+		return err
+	}
 
 	c.Command = &execdriver.Command{
 		CommonCommand: execdriver.CommonCommand{
@@ -253,8 +298,10 @@ func (daemon *Daemon) populateCommand(c *container.Container, env []string) erro
 			Resources:     resources,
 			WorkingDir:    c.Config.WorkingDir,
 		},
+		AllCapabilities:    c.HostConfig.PrivilegeProfile.AllCapabilities(),
+		AllDevices:         c.HostConfig.PrivilegeProfile.AllDevices(),
 		AllowedDevices:     allowedDevices,
-		AppArmorProfile:    c.AppArmorProfile,
+		AppArmorProfile:    profile,
 		AutoCreatedDevices: autoCreatedDevices,
 		CapAdd:             c.HostConfig.CapAdd.Slice(),
 		CapDrop:            c.HostConfig.CapDrop.Slice(),
@@ -266,7 +313,7 @@ func (daemon *Daemon) populateCommand(c *container.Container, env []string) erro
 		Pid:                pid,
 		ReadonlyRootfs:     c.HostConfig.ReadonlyRootfs,
 		RemappedRoot:       remappedRoot,
-		SeccompProfile:     c.SeccompProfile,
+		SeccompProfile:     daemon.seccompProfile(c),
 		UIDMapping:         uidMap,
 		UTS:                uts,
 	}
@@ -284,7 +331,7 @@ func (daemon *Daemon) getSize(container *container.Container) (int64, int64) {
 		err                error
 	)
 
-	if err := daemon.Mount(container); err != nil {
+	if err := daemon.Mount(context.Background(), container); err != nil {
 		logrus.Errorf("Failed to compute size of container rootfs %s: %s", container.ID, err)
 		return sizeRw, sizeRootfs
 	}
@@ -391,9 +438,9 @@ func (daemon *Daemon) buildSandboxOptions(container *container.Container, n libn
 		sboxOptions = append(sboxOptions, libnetwork.OptionExtraHost(parts[0], parts[1]))
 	}
 
-	// Link feature is supported only for the default bridge network.
-	// return if this call to build join options is not for default bridge network
-	if n.Name() != "bridge" {
+	// Links are resolved in the network of the network mode of the container,
+	// return if this call to build join options is not for that network
+	if n.Name() != daemon.linkNetwork(container) {
 		return sboxOptions, nil
 	}
 
@@ -410,24 +457,19 @@ func (daemon *Daemon) buildSandboxOptions(container *container.Container, n libn
 	}
 
 	for linkAlias, child := range children {
-		if !isLinkable(child) {
-			return nil, fmt.Errorf("Cannot link to %s, as it does not belong to the default network", child.Name)
+		if !isLinkable(child, n.Name()) {
+			return nil, fmt.Errorf("Cannot link to %s, as it does not belong to network %s", child.Name, n.Name())
 		}
 		_, alias := path.Split(linkAlias)
-		// allow access to the linked container via the alias, real name, and container hostname
-		aliasList := alias + " " + child.Config.Hostname
-		// only add the name if alias isn't equal to the name
-		if alias != child.Name[1:] {
-			aliasList = aliasList + " " + child.Name[1:]
-		}
-		sboxOptions = append(sboxOptions, libnetwork.OptionExtraHost(aliasList, child.NetworkSettings.Networks["bridge"].IPAddress))
+		aliasList := linkAliasList(alias, child.Config.Hostname, child.Name)
+		sboxOptions = append(sboxOptions, libnetwork.OptionExtraHost(aliasList, child.NetworkSettings.Networks[n.Name()].IPAddress))
 		cEndpoint, _ := child.GetEndpointInNetwork(n)
 		if cEndpoint != nil && cEndpoint.ID() != "" {
 			childEndpoints = append(childEndpoints, cEndpoint.ID())
 		}
 	}
 
-	bridgeSettings := container.NetworkSettings.Networks["bridge"]
+	settings := container.NetworkSettings.Networks[n.Name()]
 	refs := daemon.containerGraph().RefPaths(container.ID)
 	for _, ref := range refs {
 		if ref.ParentID == "0" {
@@ -439,9 +481,9 @@ func (daemon *Daemon) buildSandboxOptions(container *container.Container, n libn
 			logrus.Error(err)
 		}
 
-		if c != nil && !daemon.configStore.DisableBridge && container.HostConfig.NetworkMode.IsPrivate() {
-			logrus.Debugf("Update /etc/hosts of %s for alias %s with ip %s", c.ID, ref.Name, bridgeSettings.IPAddress)
-			sboxOptions = append(sboxOptions, libnetwork.OptionParentUpdate(c.ID, ref.Name, bridgeSettings.IPAddress))
+		if c != nil && container.HostConfig.NetworkMode.IsPrivate() && daemon.linkNetwork(c) == n.Name() {
+			logrus.Debugf("Update /etc/hosts of %s for alias %s with ip %s", c.ID, ref.Name, settings.IPAddress)
+			sboxOptions = append(sboxOptions, libnetwork.OptionParentUpdate(c.ID, ref.Name, settings.IPAddress))
 			if ep.ID() != "" {
 				parentEndpoints = append(parentEndpoints, ep.ID())
 			}
@@ -460,6 +502,156 @@ func (daemon *Daemon) buildSandboxOptions(container *container.Container, n libn
 	return sboxOptions, nil
 }
 
+// linkAliasList returns the hosts entry of a linked container named name in
+// the /etc/hosts file of its parent.
+func linkAliasList(alias, hostname, name string) string {
+	// allow access to the linked container via the alias, real name, and container hostname
+	aliasList := alias + " " + hostname
+	// only add the name if alias isn't equal to the name
+	if alias != name[1:] {
+		aliasList = aliasList + " " + name[1:]
+	}
+	return aliasList
+}
+
+// updateParentsHosts rewrites the /etc/hosts entries of the running
+// containers linking to container, after container was renamed from
+// oldName to newName. Failures are logged, a parent whose hosts file cannot
+// be updated keeps resolving the old name until it is restarted.
+// The caller must hold the container lock.
+func (daemon *Daemon) updateParentsHosts(container *container.Container, oldName, newName string) {
+	if !container.Running {
+		return
+	}
+
+	for _, ref := range daemon.containerGraph().RefPaths(container.ID) {
+		if ref.ParentID == "0" {
+			continue
+		}
+
+		parent, err := daemon.GetContainer(ref.ParentID)
+		if err != nil {
+			logrus.Error(err)
+			continue
+		}
+		if !parent.IsRunning() || parent.HostsPath == "" {
+			continue
+		}
+		network := daemon.linkNetwork(parent)
+		if !isLinkable(container, network) {
+			continue
+		}
+		ip := container.NetworkSettings.Networks[network].IPAddress
+
+		oldHosts := linkAliasList(ref.Name, container.Config.Hostname, oldName)
+		newHosts := linkAliasList(ref.Name, container.Config.Hostname, newName)
+		if oldHosts == newHosts {
+			continue
+		}
+
+		logrus.Debugf("Update /etc/hosts of %s for alias %s with name %s", parent.ID, ref.Name, newName)
+		if err := etchosts.Delete(parent.HostsPath, []etchosts.Record{{Hosts: oldHosts}}); err != nil {
+			logrus.Warnf("Failed to update /etc/hosts of %s after renaming %s: %v", parent.ID, container.ID, err)
+			continue
+		}
+		if err := etchosts.Add(parent.HostsPath, []etchosts.Record{{Hosts: newHosts, IP: ip}}); err != nil {
+			logrus.Warnf("Failed to update /etc/hosts of %s after renaming %s: %v", parent.ID, container.ID, err)
+		}
+	}
+}
+
+// networkPeers returns the other containers connected to network n.
+func (daemon *Daemon) networkPeers(c *container.Container, n libnetwork.Network) []*container.Container {
+	var peers []*container.Container
+	n.WalkEndpoints(func(ep libnetwork.Endpoint) bool {
+		epInfo := ep.Info()
+		if epInfo == nil {
+			return false
+		}
+		sb := epInfo.Sandbox()
+		if sb == nil || sb.ContainerID() == c.ID {
+			return false
+		}
+		if peer, err := daemon.GetContainer(sb.ContainerID()); err == nil {
+			peers = append(peers, peer)
+		}
+		return false
+	})
+	return peers
+}
+
+// publishAliases adds the aliases of the container in network n to the
+// /etc/hosts files of the other containers of the network, and their aliases
+// to the /etc/hosts file of the container.
+func (daemon *Daemon) publishAliases(container *container.Container, n libnetwork.Network) {
+	settings := container.NetworkSettings.Networks[n.Name()]
+	if settings == nil {
+		return
+	}
+	for _, peer := range daemon.networkPeers(container, n) {
+		peerSettings := peer.NetworkSettings.Networks[n.Name()]
+		if peerSettings == nil {
+			continue
+		}
+		if len(settings.Aliases) > 0 && peer.HostsPath != "" {
+			rec := etchosts.Record{Hosts: strings.Join(settings.Aliases, " "), IP: settings.IPAddress}
+			if err := etchosts.Add(peer.HostsPath, []etchosts.Record{rec}); err != nil {
+				logrus.Warnf("Failed to add the aliases of %s to /etc/hosts of %s: %v", container.ID, peer.ID, err)
+			}
+		}
+		if len(peerSettings.Aliases) > 0 && container.HostsPath != "" {
+			rec := etchosts.Record{Hosts: strings.Join(peerSettings.Aliases, " "), IP: peerSettings.IPAddress}
+			if err := etchosts.Add(container.HostsPath, []etchosts.Record{rec}); err != nil {
+				logrus.Warnf("Failed to add the aliases of %s to /etc/hosts of %s: %v", peer.ID, container.ID, err)
+			}
+		}
+	}
+}
+
+// unpublishAliases removes the aliases of the container in network n from the
+// /etc/hosts files of the other containers of the network.
+func (daemon *Daemon) unpublishAliases(container *container.Container, n libnetwork.Network) {
+	settings := container.NetworkSettings.Networks[n.Name()]
+	if settings == nil || len(settings.Aliases) == 0 {
+		return
+	}
+	rec := etchosts.Record{Hosts: strings.Join(settings.Aliases, " "), IP: settings.IPAddress}
+	for _, peer := range daemon.networkPeers(container, n) {
+		if peer.HostsPath == "" {
+			continue
+		}
+		if err := deleteHostsRecord(peer.HostsPath, rec); err != nil {
+			logrus.Warnf("Failed to remove the aliases of %s from /etc/hosts of %s: %v", container.ID, peer.ID, err)
+		}
+	}
+}
+
+// hostsLock serializes the deletions of the records from the /etc/hosts
+// files of the containers.
+var hostsLock sync.Mutex
+
+// deleteHostsRecord removes the lines of the /etc/hosts file at path which
+// map the IP of rec to its hosts. Unlike etchosts.Delete, which removes the
+// lines ending with the hosts whatever their IP, it keeps the identical
+// aliases of the other containers.
+func deleteHostsRecord(path string, rec etchosts.Record) error {
+	hostsLock.Lock()
+	defer hostsLock.Unlock()
+
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	line := rec.IP + "\t" + rec.Hosts
+	var kept []string
+	for _, l := range strings.SplitAfter(string(content), "\n") {
+		if strings.TrimSuffix(l, "\n") != line {
+			kept = append(kept, l)
+		}
+	}
+	return ioutil.WriteFile(path, []byte(strings.Join(kept, "")), 0644)
+}
+
 func (daemon *Daemon) updateNetworkSettings(container *container.Container, n libnetwork.Network) error {
 	if container.NetworkSettings == nil {
 		container.NetworkSettings = &network.Settings{Networks: make(map[string]*networktypes.EndpointSettings)}
@@ -516,21 +708,22 @@ func (daemon *Daemon) updateNetwork(container *container.Container) error {
 		return derr.ErrorCodeNoSandbox.WithArgs(sid, err)
 	}
 
-	// Find if container is connected to the default bridge network
+	// Find if container is connected to the network its links are resolved in
 	var n libnetwork.Network
+	network := daemon.linkNetwork(container)
 	for name := range container.NetworkSettings.Networks {
 		sn, err := daemon.FindNetwork(name)
 		if err != nil {
 			continue
 		}
-		if sn.Name() == "bridge" {
+		if sn.Name() == network {
 			n = sn
 			break
 		}
 	}
 
 	if n == nil {
-		// Not connected to the default bridge network; Nothing to do
+		// Not connected to the network of its links; Nothing to do
 		return nil
 	}
 
@@ -546,10 +739,14 @@ func (daemon *Daemon) updateNetwork(container *container.Container) error {
 	return nil
 }
 
-// updateContainerNetworkSettings update the network settings
-func (daemon *Daemon) updateContainerNetworkSettings(container *container.Container) error {
+// updateContainerNetworkSettings update the network settings, adding the
+// endpoints of the networks the container is connected to at creation
+func (daemon *Daemon) updateContainerNetworkSettings(container *container.Container, endpointsConfig map[string]*networktypes.EndpointSettings) error {
 	mode := container.HostConfig.NetworkMode
 	if container.Config.NetworkDisabled || mode.IsContainer() {
+		if len(endpointsConfig) > 0 {
+			return runconfig.ErrConflictSharedNetwork
+		}
 		return nil
 	}
 
@@ -566,6 +763,42 @@ func (daemon *Daemon) updateContainerNetworkSettings(container *container.Contai
 	}
 	container.NetworkSettings.Networks = make(map[string]*networktypes.EndpointSettings)
 	container.NetworkSettings.Networks[networkName] = new(networktypes.EndpointSettings)
+
+	for name, epConfig := range endpointsConfig {
+		n, err := daemon.FindNetwork(name)
+		if err != nil {
+			return err
+		}
+		if err := daemon.updateNetworkSettings(container, n); err != nil {
+			return err
+		}
+		if epConfig == nil {
+			continue
+		}
+		if err := verifyEndpointConfig(n, epConfig); err != nil {
+			return err
+		}
+		settings := container.NetworkSettings.Networks[n.Name()]
+		settings.IPAMConfig = epConfig.IPAMConfig
+		settings.Aliases = epConfig.Aliases
+	}
+	return nil
+}
+
+// verifyEndpointConfig checks the static IP address and the aliases of the
+// endpoint of a container in network n.
+func verifyEndpointConfig(n libnetwork.Network, epConfig *networktypes.EndpointSettings) error {
+	if epConfig.IPAMConfig == nil && len(epConfig.Aliases) == 0 {
+		return nil
+	}
+	if !containertypes.NetworkMode(n.Name()).IsUserDefined() {
+		return fmt.Errorf("User specified IP address and aliases are supported only on user-defined networks, not on network %s", n.Name())
+	}
+	if epConfig.IPAMConfig != nil && epConfig.IPAMConfig.IPv4Address != "" {
+		if ip := net.ParseIP(epConfig.IPAMConfig.IPv4Address); ip == nil || ip.To4() == nil {
+			return fmt.Errorf("Invalid IPv4 address %s for network %s", epConfig.IPAMConfig.IPv4Address, n.Name())
+		}
+	}
 	return nil
 }
 
@@ -583,7 +816,7 @@ func (daemon *Daemon) allocateNetwork(container *container.Container) error {
 			return nil
 		}
 
-		err := daemon.updateContainerNetworkSettings(container)
+		err := daemon.updateContainerNetworkSettings(container, nil)
 		if err != nil {
 			return err
 		}
@@ -702,6 +935,12 @@ func (daemon *Daemon) connectToNetwork(container *container.Container, idOrName
 		return derr.ErrorCodeJoinInfo.WithArgs(err)
 	}
 
+	daemon.publishAliases(container, n)
+
+	if err := daemon.applyICCPolicy(container, n); err != nil {
+		return err
+	}
+
 	daemon.LogNetworkEventWithAttributes(n, "connect", map[string]string{"container": container.ID})
 	return nil
 }
@@ -716,6 +955,9 @@ func (daemon *Daemon) DisconnectFromNetwork(container *container.Container, n li
 		return runconfig.ErrConflictHostNetwork
 	}
 
+	daemon.unpublishAliases(container, n)
+	daemon.releaseICCPolicy(container, n)
+
 	if err := disconnectFromNetwork(container, n); err != nil {
 		return err
 	}
@@ -855,11 +1097,17 @@ func (daemon *Daemon) releaseNetwork(container *container.Container) {
 	sid := container.NetworkSettings.SandboxID
 	settings := container.NetworkSettings.Networks
 	var networks []libnetwork.Network
-	for n := range settings {
+	for n, s := range settings {
 		if nw, err := daemon.FindNetwork(n); err == nil {
+			daemon.unpublishAliases(container, nw)
+			daemon.releaseICCPolicy(container, nw)
 			networks = append(networks, nw)
 		}
-		settings[n] = &networktypes.EndpointSettings{}
+		// keep the configuration of the endpoint for the next start
+		settings[n] = &networktypes.EndpointSettings{
+			IPAMConfig: s.IPAMConfig,
+			Aliases:    s.Aliases,
+		}
 	}
 
 	container.NetworkSettings = &network.Settings{Networks: settings}
@@ -887,7 +1135,7 @@ func (daemon *Daemon) releaseNetwork(container *container.Container) {
 }
 
 func (daemon *Daemon) setupIpcDirs(c *container.Container) error {
-	rootUID, rootGID := daemon.GetRemappedUIDGID()
+	rootUID, rootGID := daemon.containerRemappedUIDGID(c.HostConfig)
 	if !c.HasMountFor("/dev/shm") {
 		shmPath, err := c.ShmResourcePath()
 		if err != nil {
@@ -1041,8 +1289,23 @@ func detachMounted(path string) error {
 	return syscall.Unmount(path, syscall.MNT_DETACH)
 }
 
-func isLinkable(child *container.Container) bool {
-	// A container is linkable only if it belongs to the default network
-	_, ok := child.NetworkSettings.Networks["bridge"]
+func isLinkable(child *container.Container, network string) bool {
+	// A container is linkable only if it belongs to the network of its parent
+	_, ok := child.NetworkSettings.Networks[network]
 	return ok
 }
+
+// linkNetwork returns the name of the network the links of the container are
+// resolved in: the network of its network mode if it is a user-defined
+// network, the default bridge network otherwise.
+func (daemon *Daemon) linkNetwork(container *container.Container) string {
+	mode := container.HostConfig.NetworkMode
+	if !mode.IsUserDefined() {
+		return "bridge"
+	}
+	n, err := daemon.FindNetwork(mode.NetworkName())
+	if err != nil {
+		return mode.NetworkName()
+	}
+	return n.Name()
+}
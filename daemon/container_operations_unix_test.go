@@ -0,0 +1,99 @@
+// +build linux freebsd
+
+package daemon
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	containertypes "github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/container"
+	"github.com/docker/libnetwork/etchosts"
+)
+
+func TestLinkEnvEnabled(t *testing.T) {
+	enabled, disabled := true, false
+	daemon := &Daemon{configStore: &Config{LinkEnv: false}}
+
+	tests := []struct {
+		mode     string
+		linkEnv  *bool
+		expected bool
+	}{
+		{"bridge", nil, true},
+		{"default", &disabled, true},
+		{"appnet", nil, false},
+		{"appnet", &enabled, true},
+	}
+	for _, test := range tests {
+		c := &container.Container{
+			CommonContainer: container.CommonContainer{
+				HostConfig: &containertypes.HostConfig{
+					NetworkMode: containertypes.NetworkMode(test.mode),
+					LinkEnv:     test.linkEnv,
+				},
+			},
+		}
+		if enabled := daemon.linkEnvEnabled(c); enabled != test.expected {
+			t.Fatalf("Expected link env enabled to be %v for network %s, got %v", test.expected, test.mode, enabled)
+		}
+	}
+
+	daemon.configStore.LinkEnv = true
+	c := &container.Container{
+		CommonContainer: container.CommonContainer{
+			HostConfig: &containertypes.HostConfig{NetworkMode: "appnet", LinkEnv: &disabled},
+		},
+	}
+	if daemon.linkEnvEnabled(c) {
+		t.Fatal("Expected the container to disable the link env")
+	}
+}
+
+func TestDeleteHostsRecord(t *testing.T) {
+	dir, err := ioutil.TempDir("", "docker-hosts-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "hosts")
+	content := "127.0.0.1\tlocalhost\n172.20.0.5\tdb cache\n172.20.0.6\tdb cache\n"
+	if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := deleteHostsRecord(path, etchosts.Record{Hosts: "db cache", IP: "172.20.0.5"}); err != nil {
+		t.Fatal(err)
+	}
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := "127.0.0.1\tlocalhost\n172.20.0.6\tdb cache\n"; string(b) != expected {
+		t.Fatalf("Expected %q, got %q", expected, b)
+	}
+}
+
+func TestInternalBridgeOptions(t *testing.T) {
+	daemon := &Daemon{}
+	if _, err := daemon.internalBridgeOptions(nil); err == nil {
+		t.Fatal("Expected internal bridge networks to require iptables")
+	}
+
+	daemon.icc = newICCPolicy(&fakeICCFirewall{rules: make(map[string]bool)})
+	options := map[string]string{"com.docker.network.bridge.name": "internal0"}
+	internalOptions, err := daemon.internalBridgeOptions(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if internalOptions["com.docker.network.bridge.enable_ip_masquerade"] != "false" ||
+		internalOptions["com.docker.network.internal"] != "true" ||
+		internalOptions["com.docker.network.bridge.name"] != "internal0" {
+		t.Fatalf("Unexpected options %v", internalOptions)
+	}
+	if len(options) != 1 {
+		t.Fatalf("Expected the options not to be modified, got %v", options)
+	}
+}
@@ -3,8 +3,10 @@
 package daemon
 
 import (
+	"fmt"
 	"strings"
 
+	networktypes "github.com/docker/docker/api/types/network"
 	"github.com/docker/docker/container"
 	"github.com/docker/docker/daemon/execdriver"
 	"github.com/docker/docker/daemon/execdriver/windows"
@@ -17,8 +19,54 @@ func (daemon *Daemon) setupLinkedContainers(container *container.Container) ([]s
 	return nil, nil
 }
 
+// containerTimezone returns the timezone of the container. Timezones are not
+// supported on Windows.
+func (daemon *Daemon) containerTimezone(container *container.Container) string {
+	return ""
+}
+
+// setupLocaltime is a no-op on Windows as timezones are not supported.
+func (daemon *Daemon) setupLocaltime(container *container.Container) error {
+	return nil
+}
+
+// initCPUIsolation returns the allocator of CPU isolation groups, which are
+// not supported on Windows.
+func initCPUIsolation(config *Config) (*cpuIsolation, error) {
+	return nil, nil
+}
+
+// initICCPolicy returns the policy installing the ICC rules of the
+// containers, which are not supported on Windows.
+func initICCPolicy(config *Config) (*iccPolicy, error) {
+	return nil, nil
+}
+
+// internalBridgeOptions returns the driver options of an internal bridge
+// network. Bridge networks are not supported on Windows.
+func (daemon *Daemon) internalBridgeOptions(options map[string]string) (map[string]string, error) {
+	return nil, fmt.Errorf("Internal bridge networks are not supported on Windows")
+}
+
+// isolateNetwork is a no-op on Windows as bridge networks are not supported.
+func (daemon *Daemon) isolateNetwork(n libnetwork.Network, enable bool) error {
+	return nil
+}
+
+// releaseIsolatedCpus is a no-op on Windows as CPU isolation groups are not
+// supported.
+func (daemon *Daemon) releaseIsolatedCpus(container *container.Container) {
+}
+
+// updateParentsHosts is a no-op on Windows as links are not supported.
+func (daemon *Daemon) updateParentsHosts(container *container.Container, oldName, newName string) {
+}
+
 // updateContainerNetworkSettings update the network settings
-func (daemon *Daemon) updateContainerNetworkSettings(container *container.Container) error {
+func (daemon *Daemon) updateContainerNetworkSettings(container *container.Container, endpointsConfig map[string]*networktypes.EndpointSettings) error {
+	if len(endpointsConfig) > 0 {
+		return fmt.Errorf("Connecting a container to networks at creation is not supported on Windows")
+	}
 	return nil
 }
 
@@ -0,0 +1,168 @@
+package daemon
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/docker/docker/pkg/parsers"
+)
+
+// cpuIsolation allocates the physical cores of the --isolated-cpus pool to
+// CPU isolation groups. A group gets whole cores, with all their SMT
+// siblings, so that containers of different groups never share a core.
+type cpuIsolation struct {
+	mu     sync.Mutex
+	cores  []*isolatedCore
+	groups map[string]*cpuIsolationGroup
+
+	// shared is the cpuset of the containers which are not in a group and
+	// do not set their own cpuset. It excludes the pool.
+	shared string
+}
+
+// isolatedCore is a physical core of the pool.
+type isolatedCore struct {
+	// cpus are the logical CPUs of the core.
+	cpus []int
+	// group is the group the core is allocated to, empty if the core is free.
+	group string
+}
+
+// cpuIsolationGroup is a group of running containers sharing the same cores.
+type cpuIsolationGroup struct {
+	cores   int
+	cpus    string
+	members map[string]struct{}
+}
+
+// newCPUIsolation returns an allocator of the given cores, each core being
+// the list of its logical CPUs. shared is the cpuset of the containers outside
+// of any group.
+func newCPUIsolation(cores [][]int, shared string) *cpuIsolation {
+	ci := &cpuIsolation{
+		groups: make(map[string]*cpuIsolationGroup),
+		shared: shared,
+	}
+	for _, cpus := range cores {
+		ci.cores = append(ci.cores, &isolatedCore{cpus: cpus})
+	}
+	return ci
+}
+
+// join adds the container with the given id to group and returns the cpuset
+// of the group. The first container of a group allocates cores free cores
+// to the group, the others must ask for the same number of cores.
+func (ci *cpuIsolation) join(group string, cores int, id string) (string, error) {
+	if cores == 0 {
+		cores = 1
+	}
+
+	ci.mu.Lock()
+	defer ci.mu.Unlock()
+
+	if g, exists := ci.groups[group]; exists {
+		if g.cores != cores {
+			return "", fmt.Errorf("CPU isolation group %s has %d cores, cannot join it with %d cores", group, g.cores, cores)
+		}
+		g.members[id] = struct{}{}
+		return g.cpus, nil
+	}
+
+	var free []*isolatedCore
+	for _, c := range ci.cores {
+		if c.group == "" {
+			free = append(free, c)
+		}
+	}
+	if len(free) < cores {
+		return "", fmt.Errorf("Not enough free isolated cores for CPU isolation group %s: %d requested, %d available", group, cores, len(free))
+	}
+
+	var cpus []int
+	for _, c := range free[:cores] {
+		c.group = group
+		cpus = append(cpus, c.cpus...)
+	}
+	g := &cpuIsolationGroup{
+		cores:   cores,
+		cpus:    formatCPUList(cpus),
+		members: map[string]struct{}{id: {}},
+	}
+	ci.groups[group] = g
+	return g.cpus, nil
+}
+
+// leave removes the container with the given id from group. The cores of the
+// group are freed when its last container leaves.
+func (ci *cpuIsolation) leave(group, id string) {
+	ci.mu.Lock()
+	defer ci.mu.Unlock()
+
+	g, exists := ci.groups[group]
+	if !exists {
+		return
+	}
+	delete(g.members, id)
+	if len(g.members) > 0 {
+		return
+	}
+	for _, c := range ci.cores {
+		if c.group == group {
+			c.group = ""
+		}
+	}
+	delete(ci.groups, group)
+}
+
+// isolated returns the CPUs of cpus which are in the pool.
+func (ci *cpuIsolation) isolated(cpus map[int]bool) []int {
+	var isolated []int
+	for _, c := range ci.cores {
+		for _, cpu := range c.cpus {
+			if cpus[cpu] {
+				isolated = append(isolated, cpu)
+			}
+		}
+	}
+	return isolated
+}
+
+// verifyCpusetIsolation checks that the cpuset set by a container outside
+// of the CPU isolation groups does not include isolated CPUs.
+func (daemon *Daemon) verifyCpusetIsolation(cpuset string) error {
+	if daemon.cpuIsolation == nil || cpuset == "" {
+		return nil
+	}
+	cpus, err := parsers.ParseUintList(cpuset)
+	if err != nil {
+		return fmt.Errorf("Invalid value %s for cpuset cpus", cpuset)
+	}
+	if isolated := daemon.cpuIsolation.isolated(cpus); len(isolated) > 0 {
+		return fmt.Errorf("Invalid cpuset %s: CPUs %s are reserved for the CPU isolation groups", cpuset, formatCPUList(isolated))
+	}
+	return nil
+}
+
+// formatCPUList formats a list of CPUs in the cpuset list format, e.g. 0-3,8.
+func formatCPUList(cpus []int) string {
+	sorted := append([]int(nil), cpus...)
+	sort.Ints(sorted)
+
+	var ranges []string
+	for i := 0; i < len(sorted); {
+		j := i
+		for j+1 < len(sorted) && sorted[j+1] == sorted[j]+1 {
+			j++
+		}
+		if i == j {
+			ranges = append(ranges, strconv.Itoa(sorted[i]))
+		} else {
+			ranges = append(ranges, strconv.Itoa(sorted[i])+"-"+strconv.Itoa(sorted[j]))
+		}
+		i = j + 1
+	}
+	return strings.Join(ranges, ",")
+}
@@ -0,0 +1,122 @@
+// +build !windows
+
+package daemon
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/docker/docker/container"
+	"github.com/docker/docker/pkg/parsers"
+)
+
+// sysCPUDir is the sysfs directory the CPU topology is read from.
+var sysCPUDir = "/sys/devices/system/cpu"
+
+// readCPUList reads a file of sysfs in the cpuset list format.
+func readCPUList(path string) (map[int]bool, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return parsers.ParseUintList(strings.TrimSpace(string(content)))
+}
+
+// initCPUIsolation groups the CPUs of the --isolated-cpus pool by physical
+// core. Every SMT sibling of a CPU of the pool must be in the pool, so that
+// a core is never shared between the pool and other containers. It returns
+// nil if no pool is configured.
+func initCPUIsolation(config *Config) (*cpuIsolation, error) {
+	if config.IsolatedCpus == "" {
+		return nil, nil
+	}
+	pool, err := parsers.ParseUintList(config.IsolatedCpus)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid --isolated-cpus %q: %v", config.IsolatedCpus, err)
+	}
+	online, err := readCPUList(filepath.Join(sysCPUDir, "online"))
+	if err != nil {
+		return nil, err
+	}
+
+	var cpus []int
+	for cpu := range pool {
+		if !online[cpu] {
+			return nil, fmt.Errorf("Invalid --isolated-cpus %q: CPU %d is not online", config.IsolatedCpus, cpu)
+		}
+		cpus = append(cpus, cpu)
+	}
+	sort.Ints(cpus)
+
+	var cores [][]int
+	seen := map[int]bool{}
+	for _, cpu := range cpus {
+		if seen[cpu] {
+			continue
+		}
+		siblings, err := readCPUList(filepath.Join(sysCPUDir, fmt.Sprintf("cpu%d", cpu), "topology", "thread_siblings_list"))
+		if err != nil {
+			return nil, err
+		}
+		var core []int
+		for sibling := range siblings {
+			if !pool[sibling] {
+				return nil, fmt.Errorf("Invalid --isolated-cpus %q: CPU %d shares a core with CPU %d which is not isolated", config.IsolatedCpus, cpu, sibling)
+			}
+			seen[sibling] = true
+			core = append(core, sibling)
+		}
+		sort.Ints(core)
+		cores = append(cores, core)
+	}
+
+	var shared []int
+	for cpu := range online {
+		if !pool[cpu] {
+			shared = append(shared, cpu)
+		}
+	}
+	if len(shared) == 0 {
+		return nil, fmt.Errorf("Invalid --isolated-cpus %q: no CPU is left for the other containers", config.IsolatedCpus)
+	}
+
+	return newCPUIsolation(cores, formatCPUList(shared)), nil
+}
+
+// containerCpusetCpus returns the cpuset the container runs on. Containers
+// of a CPU isolation group run on the cores allocated to the group, and other
+// containers which do not set their own cpuset stay off the isolated CPUs.
+func (daemon *Daemon) containerCpusetCpus(c *container.Container) (string, error) {
+	group := c.HostConfig.CPUIsolationGroup
+	if daemon.cpuIsolation == nil {
+		if group != "" {
+			return "", fmt.Errorf("CPU isolation groups require the daemon to be started with --isolated-cpus")
+		}
+		return c.HostConfig.CpusetCpus, nil
+	}
+	if group == "" {
+		if c.HostConfig.CpusetCpus != "" {
+			return c.HostConfig.CpusetCpus, nil
+		}
+		return daemon.cpuIsolation.shared, nil
+	}
+
+	cpus, err := daemon.cpuIsolation.join(group, c.HostConfig.CPUIsolationCores, c.ID)
+	if err != nil {
+		return "", err
+	}
+	c.IsolatedCpus = cpus
+	return cpus, nil
+}
+
+// releaseIsolatedCpus removes the container from its CPU isolation group.
+func (daemon *Daemon) releaseIsolatedCpus(c *container.Container) {
+	if daemon.cpuIsolation == nil || c.IsolatedCpus == "" {
+		return
+	}
+	daemon.cpuIsolation.leave(c.HostConfig.CPUIsolationGroup, c.ID)
+	c.IsolatedCpus = ""
+}
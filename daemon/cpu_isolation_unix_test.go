@@ -0,0 +1,119 @@
+// +build !windows
+
+package daemon
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeCPUTopology(t *testing.T, dir, online string, siblings map[int]string) {
+	if err := ioutil.WriteFile(filepath.Join(dir, "online"), []byte(online+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	for cpu, list := range siblings {
+		topology := filepath.Join(dir, "cpu"+formatCPUList([]int{cpu}), "topology")
+		if err := os.MkdirAll(topology, 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(filepath.Join(topology, "thread_siblings_list"), []byte(list+"\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestInitCPUIsolation(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "docker-cpu-isolation-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+
+	defer func(dir string) { sysCPUDir = dir }(sysCPUDir)
+	sysCPUDir = tmp
+	// 4 cores with 2 threads each, the siblings of CPU n being n and n+4.
+	writeCPUTopology(t, tmp, "0-7", map[int]string{
+		0: "0,4", 1: "1,5", 2: "2,6", 3: "3,7",
+		4: "0,4", 5: "1,5", 6: "2,6", 7: "3,7",
+	})
+
+	if ci, err := initCPUIsolation(&Config{}); err != nil || ci != nil {
+		t.Fatalf("Expected no CPU isolation without --isolated-cpus, got %v, %v", ci, err)
+	}
+	for _, cpus := range []string{"2-3", "0-7", "8,9", "a-b"} {
+		if _, err := initCPUIsolation(&Config{IsolatedCpus: cpus}); err == nil {
+			t.Fatalf("Expected an error for --isolated-cpus %s", cpus)
+		}
+	}
+
+	ci, err := initCPUIsolation(&Config{IsolatedCpus: "1-3,5-7"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ci.shared != "0,4" {
+		t.Fatalf("Expected the shared cpuset 0,4, got %s", ci.shared)
+	}
+	if len(ci.cores) != 3 {
+		t.Fatalf("Expected 3 isolated cores, got %d", len(ci.cores))
+	}
+
+	cpus, err := ci.join("db", 2, "c1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cpus != "1-2,5-6" {
+		t.Fatalf("Expected the cpuset 1-2,5-6, got %s", cpus)
+	}
+	if cpus, err := ci.join("db", 2, "c2"); err != nil || cpus != "1-2,5-6" {
+		t.Fatalf("Expected to join the group on the same cores, got %s, %v", cpus, err)
+	}
+	if _, err := ci.join("db", 1, "c3"); err == nil {
+		t.Fatal("Expected an error joining a group with another number of cores")
+	}
+	if _, err := ci.join("web", 2, "c4"); err == nil {
+		t.Fatal("Expected an error allocating more cores than available")
+	}
+	if cpus, err := ci.join("web", 0, "c4"); err != nil || cpus != "3,7" {
+		t.Fatalf("Expected the cpuset 3,7, got %s, %v", cpus, err)
+	}
+
+	ci.leave("db", "c1")
+	if _, err := ci.join("cache", 1, "c5"); err == nil {
+		t.Fatal("Expected the cores of the group to be kept while it has members")
+	}
+	ci.leave("db", "c2")
+	if cpus, err := ci.join("cache", 1, "c5"); err != nil || cpus != "1,5" {
+		t.Fatalf("Expected the cores of the group to be freed, got %s, %v", cpus, err)
+	}
+
+	daemon := &Daemon{cpuIsolation: ci}
+	for _, cpuset := range []string{"", "0", "0,4"} {
+		if err := daemon.verifyCpusetIsolation(cpuset); err != nil {
+			t.Fatalf("Expected the cpuset %q to be allowed, got %v", cpuset, err)
+		}
+	}
+	for _, cpuset := range []string{"0-1", "7", "a"} {
+		if err := daemon.verifyCpusetIsolation(cpuset); err == nil {
+			t.Fatalf("Expected the cpuset %q to be rejected", cpuset)
+		}
+	}
+}
+
+func TestFormatCPUList(t *testing.T) {
+	for _, tc := range []struct {
+		cpus     []int
+		expected string
+	}{
+		{nil, ""},
+		{[]int{3}, "3"},
+		{[]int{0, 1, 2, 3}, "0-3"},
+		{[]int{8, 0, 2, 1}, "0-2,8"},
+		{[]int{1, 3, 5, 6}, "1,3,5-6"},
+	} {
+		if list := formatCPUList(tc.cpus); list != tc.expected {
+			t.Fatalf("Expected %q for %v, got %q", tc.expected, tc.cpus, list)
+		}
+	}
+}
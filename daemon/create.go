@@ -4,6 +4,7 @@ import (
 	"github.com/Sirupsen/logrus"
 	"github.com/docker/docker/api/types"
 	containertypes "github.com/docker/docker/api/types/container"
+	networktypes "github.com/docker/docker/api/types/network"
 	"github.com/docker/docker/container"
 	derr "github.com/docker/docker/errors"
 	"github.com/docker/docker/image"
@@ -19,6 +20,16 @@ func (daemon *Daemon) ContainerCreate(params types.ContainerCreateConfig) (types
 	if params.Config == nil {
 		return types.ContainerCreateResponse{}, derr.ErrorCodeEmptyConfig
 	}
+	done, err := daemon.beginStorageWrite()
+	if err != nil {
+		return types.ContainerCreateResponse{}, err
+	}
+	defer done()
+	daemon.indexLock.RLock()
+	defer daemon.indexLock.RUnlock()
+	if err := daemon.CheckStorage(); err != nil {
+		return types.ContainerCreateResponse{}, err
+	}
 
 	warnings, err := daemon.verifyContainerSettings(params.HostConfig, params.Config)
 	if err != nil {
@@ -35,7 +46,7 @@ func (daemon *Daemon) ContainerCreate(params types.ContainerCreateConfig) (types
 
 	container, err := daemon.create(params)
 	if err != nil {
-		return types.ContainerCreateResponse{Warnings: warnings}, daemon.imageNotExistToErrcode(err)
+		return types.ContainerCreateResponse{Warnings: warnings}, daemon.storageError(daemon.imageNotExistToErrcode(err))
 	}
 
 	return types.ContainerCreateResponse{ID: container.ID, Warnings: warnings}, nil
@@ -61,13 +72,14 @@ func (daemon *Daemon) create(params types.ContainerCreateConfig) (retC *containe
 	if err := daemon.mergeAndVerifyConfig(params.Config, img); err != nil {
 		return nil, err
 	}
+	daemon.applyLogRules(params.Config, params.HostConfig)
 
 	if container, err = daemon.newContainer(params.Name, params.Config, imgID); err != nil {
 		return nil, err
 	}
 	defer func() {
 		if retErr != nil {
-			if err := daemon.ContainerRm(container.ID, &types.ContainerRmConfig{ForceRemove: true}); err != nil {
+			if err := daemon.containerRm(container.ID, &types.ContainerRmConfig{ForceRemove: true, RemoveVolume: true}); err != nil {
 				logrus.Errorf("Clean up Error! Cannot destroy container %s: %v", container.ID, err)
 			}
 		}
@@ -78,14 +90,15 @@ func (daemon *Daemon) create(params types.ContainerCreateConfig) (retC *containe
 	}
 
 	// Set RWLayer for container after mount labels have been set
-	if err := daemon.setRWLayer(container); err != nil {
+	if err := daemon.setRWLayer(container, params.HostConfig); err != nil {
 		return nil, err
 	}
 
 	if err := daemon.Register(container); err != nil {
 		return nil, err
 	}
-	rootUID, rootGID, err := idtools.GetRootUIDGID(daemon.uidMaps, daemon.gidMaps)
+	uidMaps, gidMaps := daemon.containerUIDGIDMaps(params.HostConfig)
+	rootUID, rootGID, err := idtools.GetRootUIDGID(uidMaps, gidMaps)
 	if err != nil {
 		return nil, err
 	}
@@ -96,19 +109,15 @@ func (daemon *Daemon) create(params types.ContainerCreateConfig) (retC *containe
 	if err := daemon.setHostConfig(container, params.HostConfig); err != nil {
 		return nil, err
 	}
-	defer func() {
-		if retErr != nil {
-			if err := daemon.removeMountPoints(container, true); err != nil {
-				logrus.Error(err)
-			}
-		}
-	}()
-
 	if err := daemon.createContainerPlatformSpecificSettings(container, params.Config, params.HostConfig, img); err != nil {
 		return nil, err
 	}
 
-	if err := daemon.updateContainerNetworkSettings(container); err != nil {
+	var endpointsConfig map[string]*networktypes.EndpointSettings
+	if params.NetworkingConfig != nil {
+		endpointsConfig = params.NetworkingConfig.EndpointsConfig
+	}
+	if err := daemon.updateContainerNetworkSettings(container, endpointsConfig); err != nil {
 		return nil, err
 	}
 
@@ -116,6 +125,7 @@ func (daemon *Daemon) create(params types.ContainerCreateConfig) (retC *containe
 		logrus.Errorf("Error saving new container to disk: %v", err)
 		return nil, err
 	}
+	daemon.updateImageLastUsed(container.ImageID)
 	daemon.LogContainerEvent(container, "create")
 	return container, nil
 }
@@ -135,7 +145,7 @@ func (daemon *Daemon) generateSecurityOpt(ipcMode containertypes.IpcMode, pidMod
 	return nil, nil
 }
 
-func (daemon *Daemon) setRWLayer(container *container.Container) error {
+func (daemon *Daemon) setRWLayer(container *container.Container, hostConfig *containertypes.HostConfig) error {
 	var layerID layer.ChainID
 	if container.ImageID != "" {
 		img, err := daemon.imageStore.Get(container.ImageID)
@@ -144,7 +154,7 @@ func (daemon *Daemon) setRWLayer(container *container.Container) error {
 		}
 		layerID = img.RootFS.ChainID()
 	}
-	rwLayer, err := daemon.layerStore.CreateRWLayer(container.ID, layerID, container.MountLabel, daemon.setupInitLayer)
+	rwLayer, err := daemon.layerStore.CreateRWLayer(container.ID, layerID, container.MountLabel, daemon.initLayerFunc(hostConfig), hostConfig.StorageOpt)
 	if err != nil {
 		return err
 	}
@@ -153,14 +163,14 @@ func (daemon *Daemon) setRWLayer(container *container.Container) error {
 	return nil
 }
 
-// VolumeCreate creates a volume with the specified name, driver, and opts
+// VolumeCreate creates a volume with the specified name, driver, opts and labels
 // This is called directly from the remote API
-func (daemon *Daemon) VolumeCreate(name, driverName string, opts map[string]string) (*types.Volume, error) {
+func (daemon *Daemon) VolumeCreate(name, driverName string, opts, labels map[string]string) (*types.Volume, error) {
 	if name == "" {
 		name = stringid.GenerateNonCryptoID()
 	}
 
-	v, err := daemon.volumes.Create(name, driverName, opts)
+	v, err := daemon.volumes.Create(name, driverName, opts, labels)
 	if err != nil {
 		return nil, err
 	}
@@ -174,5 +184,5 @@ func (daemon *Daemon) VolumeCreate(name, driverName string, opts map[string]stri
 		driverName = volume.DefaultDriverName
 	}
 	daemon.LogVolumeEvent(name, "create", map[string]string{"driver": driverName})
-	return volumeToAPIType(v), nil
+	return daemon.volumeToAPIType(v), nil
 }
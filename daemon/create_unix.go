@@ -13,11 +13,12 @@ import (
 	"github.com/docker/docker/pkg/stringid"
 	"github.com/docker/docker/volume"
 	"github.com/opencontainers/runc/libcontainer/label"
+	"golang.org/x/net/context"
 )
 
 // createContainerPlatformSpecificSettings performs platform specific container create functionality
 func (daemon *Daemon) createContainerPlatformSpecificSettings(container *container.Container, config *containertypes.Config, hostConfig *containertypes.HostConfig, img *image.Image) error {
-	if err := daemon.Mount(container); err != nil {
+	if err := daemon.Mount(context.Background(), container); err != nil {
 		return err
 	}
 	defer daemon.Unmount(container)
@@ -15,6 +15,7 @@ import (
 	"runtime"
 	"strings"
 	"sync"
+	"text/template"
 	"time"
 
 	"github.com/Sirupsen/logrus"
@@ -36,6 +37,7 @@ import (
 	"github.com/docker/docker/daemon/logger"
 	"github.com/docker/docker/daemon/network"
 	"github.com/docker/docker/distribution"
+	"github.com/docker/docker/distribution/layercache"
 	dmetadata "github.com/docker/docker/distribution/metadata"
 	"github.com/docker/docker/distribution/xfer"
 	derr "github.com/docker/docker/errors"
@@ -49,7 +51,6 @@ import (
 	"github.com/docker/docker/pkg/graphdb"
 	"github.com/docker/docker/pkg/idtools"
 	"github.com/docker/docker/pkg/mount"
-	"github.com/docker/docker/pkg/namesgenerator"
 	"github.com/docker/docker/pkg/progress"
 	"github.com/docker/docker/pkg/signal"
 	"github.com/docker/docker/pkg/streamformatter"
@@ -61,10 +62,12 @@ import (
 	"github.com/docker/docker/registry"
 	"github.com/docker/docker/runconfig"
 	"github.com/docker/docker/utils"
+	"github.com/docker/docker/volume"
 	volumedrivers "github.com/docker/docker/volume/drivers"
 	"github.com/docker/docker/volume/local"
 	"github.com/docker/docker/volume/store"
 	"github.com/docker/go-connections/nat"
+	"github.com/docker/go-units"
 	"github.com/docker/libnetwork"
 	lntypes "github.com/docker/libnetwork/types"
 	"github.com/docker/libtrust"
@@ -79,6 +82,9 @@ const (
 	// maxUploadConcurrency is the maximum number of uploads that
 	// may take place at a time for each push.
 	maxUploadConcurrency = 5
+	// restoreIndexBatchSize is the number of restored containers whose IDs
+	// are added at once to the index, which finds them by ID prefix.
+	restoreIndexBatchSize = 1000
 )
 
 var (
@@ -99,12 +105,18 @@ func (e ErrImageDoesNotExist) Error() string {
 
 type contStore struct {
 	s map[string]*container.Container
+	// ordered caches the containers sorted by creation date, newest first.
+	// It is dropped when a container is added or deleted and sorted again
+	// by the next List, so that restoring many containers does not sort
+	// them for each of them.
+	ordered History
 	sync.Mutex
 }
 
 func (c *contStore) Add(id string, cont *container.Container) {
 	c.Lock()
 	c.s[id] = cont
+	c.ordered = nil
 	c.Unlock()
 }
 
@@ -117,19 +129,50 @@ func (c *contStore) Get(id string) *container.Container {
 
 func (c *contStore) Delete(id string) {
 	c.Lock()
-	delete(c.s, id)
+	if _, exists := c.s[id]; exists {
+		delete(c.s, id)
+		c.ordered = nil
+	}
 	c.Unlock()
 }
 
+// List returns the containers sorted by creation date, newest first. The
+// returned slice is shared with the other callers until the store changes,
+// so it must be copied before being modified.
 func (c *contStore) List() []*container.Container {
-	containers := new(History)
 	c.Lock()
-	for _, cont := range c.s {
-		containers.Add(cont)
+	defer c.Unlock()
+	if c.ordered == nil {
+		ordered := make(History, 0, len(c.s))
+		for _, cont := range c.s {
+			ordered.Add(cont)
+		}
+		ordered.sort()
+		c.ordered = ordered
 	}
-	c.Unlock()
-	containers.sort()
-	return *containers
+	return c.ordered
+}
+
+// Find returns the containers for which filter returns true, newest first,
+// skipping the first offset of them and returning at most limit of them if
+// limit is positive. filter is called without the store lock held, so that it
+// can lock the containers.
+func (c *contStore) Find(filter func(*container.Container) bool, offset, limit int) []*container.Container {
+	var found []*container.Container
+	for _, cont := range c.List() {
+		if limit > 0 && len(found) == limit {
+			break
+		}
+		if !filter(cont) {
+			continue
+		}
+		if offset > 0 {
+			offset--
+			continue
+		}
+		found = append(found, cont)
+	}
+	return found
 }
 
 // Daemon holds information about the Docker daemon.
@@ -143,12 +186,15 @@ type Daemon struct {
 	uploadManager             *xfer.LayerUploadManager
 	distributionMetadataStore dmetadata.Store
 	trustKey                  libtrust.PrivateKey
+	trustKeyLock              sync.Mutex
 	idIndex                   *truncindex.TruncIndex
 	configStore               *Config
 	containerGraphDB          *graphdb.Database
 	execDriver                execdriver.Driver
 	statsCollector            *statsCollector
 	defaultLogConfig          containertypes.LogConfig
+	logRules                  []*logRule
+	held                      heldContainers
 	RegistryService           *registry.Service
 	EventsService             *events.Events
 	netController             libnetwork.NetworkController
@@ -160,6 +206,33 @@ type Daemon struct {
 	gidMaps                   []idtools.IDMap
 	layerStore                layer.Store
 	imageStore                image.Store
+	imageIndex                *imageIndex
+	configWatcher             *containerConfigWatcher
+	nameTemplate              *template.Template
+	hostnameTemplate          *template.Template
+	baselineMounts            []*volume.MountPoint
+	bindPolicy                *bindPolicy
+	cpuIsolation              *cpuIsolation
+	restoreStatus             *restoreStatus
+	storageStatus             storageStatus
+	pressure                  *pressurePolicy
+	reconciler                *reconciler
+	pullSessions              *pullSessions
+	publisher                 *servicePublisher
+	inhibitor                 *shutdownInhibitor
+	layerCache                *layercache.Cache
+	trustService              *distribution.TrustService
+	icc                       *iccPolicy
+	captures                  *captureStore
+	remoteInspect             *remoteInspectCache
+	commitSizeLimit           int64
+	// indexLock is held for reading by the changes to the indexes of the
+	// containers, and for writing while they are rebuilt.
+	indexLock                 sync.RWMutex
+	migrationMu               sync.Mutex
+	migrating                 bool
+	migratedDriver            string
+	storageWriters            sync.WaitGroup
 }
 
 // GetContainer looks for a container using the provided information, which could be
@@ -168,8 +241,14 @@ type Daemon struct {
 //  - A container name, which will only exact match via the GetByName() function
 //  - A partial container ID prefix (e.g. short ID) of any length that is
 //    unique enough to only return a single container object
+//  - A label selector (e.g. "label:app=web,env=prod") matching a single
+//    container
 //  If none of these searches succeed, an error is returned
 func (daemon *Daemon) GetContainer(prefixOrName string) (*container.Container, error) {
+	if isLabelSelector(prefixOrName) {
+		return daemon.getSelectedContainer(prefixOrName)
+	}
+
 	if containerByID := daemon.containers.Get(prefixOrName); containerByID != nil {
 		// prefix is an exact match to a full container ID
 		return containerByID, nil
@@ -192,6 +271,16 @@ func (daemon *Daemon) GetContainer(prefixOrName string) (*container.Container, e
 	return daemon.containers.Get(containerID), nil
 }
 
+// getContainerWithContext looks for a container like GetContainer, unless
+// ctx is cancelled, so that the requests given up by their clients stop
+// there.
+func (daemon *Daemon) getContainerWithContext(ctx context.Context, prefixOrName string) (*container.Container, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return daemon.GetContainer(prefixOrName)
+}
+
 // Exists returns a true if a container of the specified ID or name exists,
 // false otherwise.
 func (daemon *Daemon) Exists(id string) bool {
@@ -233,7 +322,7 @@ func (daemon *Daemon) registerName(container *container.Container) error {
 		return err
 	}
 	if container.Name == "" {
-		name, err := daemon.generateNewName(container.ID)
+		name, err := daemon.generateNewName(container.ID, container.Config.Image)
 		if err != nil {
 			return err
 		}
@@ -249,6 +338,14 @@ func (daemon *Daemon) registerName(container *container.Container) error {
 
 // Register makes a container object usable by the daemon as <container.ID>
 func (daemon *Daemon) Register(container *container.Container) error {
+	err := daemon.register(container)
+	daemon.idIndex.Add(container.ID)
+	return err
+}
+
+// register registers the container without adding its ID to the index, for
+// restore to add the IDs of the containers in batches.
+func (daemon *Daemon) register(container *container.Container) error {
 	// Attach to stdout and stderr
 	if container.Config.OpenStdin {
 		container.NewInputPipes()
@@ -257,10 +354,6 @@ func (daemon *Daemon) Register(container *container.Container) error {
 	}
 	daemon.containers.Add(container.ID, container)
 
-	// don't update the Suffixarray if we're starting up
-	// we'll waste time if we update it for every container
-	daemon.idIndex.Add(container.ID)
-
 	if container.IsRunning() {
 		logrus.Debugf("killing old running container %s", container.ID)
 		// Set exit code to 128 + SIGKILL (9) to properly represent unsuccessful exit
@@ -285,20 +378,28 @@ func (daemon *Daemon) Register(container *container.Container) error {
 		return err
 	}
 
+	daemon.configWatcher.watch(container)
+
 	return nil
 }
 
-func (daemon *Daemon) restore() error {
-	type cr struct {
-		container  *container.Container
-		registered bool
+// addIDs adds the IDs of the restored containers to the index.
+func (daemon *Daemon) addIDs(ids []string) {
+	if err := daemon.idIndex.AddBatch(ids); err != nil {
+		// a batch is added entirely or not at all, so add them one by one
+		for _, id := range ids {
+			daemon.idIndex.Add(id)
+		}
 	}
+}
 
+func (daemon *Daemon) restore() error {
 	var (
 		debug         = os.Getenv("DEBUG") != ""
 		currentDriver = daemon.GraphDriverName()
-		containers    = make(map[string]*cr)
+		registered    = make(map[string]bool)
 	)
+	defer daemon.restoreDone()
 
 	if !debug {
 		logrus.Info("Loading containers: start.")
@@ -307,72 +408,82 @@ func (daemon *Daemon) restore() error {
 	if err != nil {
 		return err
 	}
+	daemon.restoreStarted(len(dir))
 
+	if entities := daemon.containerGraphDB.List("/", -1); entities != nil {
+		for _, p := range entities.Paths() {
+			registered[entities[p].ID()] = true
+		}
+	}
+
+	// The containers are registered as soon as they are loaded, so that
+	// they are listed while the others are loaded when restoring in the
+	// background.
+	restartContainers := make(map[*container.Container]chan struct{})
+	// don't update the index for every container, as adding a batch of IDs
+	// to it costs as much as adding one of them
+	var ids []string
+	daemon.indexLock.RLock()
 	for _, v := range dir {
 		id := v.Name()
 		container, err := daemon.load(id)
 		if !debug && logrus.GetLevel() == logrus.InfoLevel {
 			fmt.Print(".")
 		}
+		daemon.containerLoaded()
 		if err != nil {
 			logrus.Errorf("Failed to load container %v: %v", id, err)
 			continue
 		}
 
-		rwlayer, err := daemon.layerStore.GetRWLayer(container.ID)
-		if err != nil {
-			logrus.Errorf("Failed to load container mount %v: %v", id, err)
-			continue
-		}
-		container.RWLayer = rwlayer
-
 		// Ignore the container if it does not support the current driver being used by the graph
-		if (container.Driver == "" && currentDriver == "aufs") || container.Driver == currentDriver {
-			logrus.Debugf("Loaded container %v", container.ID)
-
-			containers[container.ID] = &cr{container: container}
-		} else {
+		if (container.Driver != "" || currentDriver != "aufs") && container.Driver != currentDriver {
 			logrus.Debugf("Cannot load container %s because it was created with another graph driver.", container.ID)
+			continue
 		}
-	}
 
-	if entities := daemon.containerGraphDB.List("/", -1); entities != nil {
-		for _, p := range entities.Paths() {
-			if !debug && logrus.GetLevel() == logrus.InfoLevel {
-				fmt.Print(".")
-			}
-
-			e := entities[p]
-
-			if c, ok := containers[e.ID()]; ok {
-				c.registered = true
-			}
+		// Register the container as broken if its mount cannot be loaded,
+		// so that it keeps its name and can be recovered or removed.
+		if err := daemon.loadRWLayer(container); err != nil {
+			logrus.Errorf("Failed to load container mount %v: %v", id, err)
+		} else {
+			logrus.Debugf("Loaded container %v", container.ID)
 		}
-	}
 
-	restartContainers := make(map[*container.Container]chan struct{})
-	for _, c := range containers {
-		if !c.registered {
+		if !registered[container.ID] {
 			// Try to set the default name for a container if it exists prior to links
-			c.container.Name, err = daemon.generateNewName(c.container.ID)
+			container.Name, err = daemon.generateNewName(container.ID, container.Config.Image)
 			if err != nil {
 				logrus.Debugf("Setting default id - %s", err)
 			}
-			if err := daemon.registerName(c.container); err != nil {
-				logrus.Errorf("Failed to register container %s: %s", c.container.ID, err)
+			if err := daemon.registerName(container); err != nil {
+				logrus.Errorf("Failed to register container %s: %s", container.ID, err)
 				continue
 			}
 		}
 
-		if err := daemon.Register(c.container); err != nil {
-			logrus.Errorf("Failed to register container %s: %s", c.container.ID, err)
+		err = daemon.register(container)
+		if ids = append(ids, container.ID); len(ids) == restoreIndexBatchSize {
+			daemon.addIDs(ids)
+			ids = nil
+		}
+		if err != nil {
+			logrus.Errorf("Failed to register container %s: %s", container.ID, err)
 			continue
 		}
 		// get list of containers we need to restart
-		if daemon.configStore.AutoRestart && c.container.ShouldRestart() {
-			restartContainers[c.container] = make(chan struct{})
+		if daemon.configStore.AutoRestart && !container.Broken && container.ShouldRestart() {
+			// containers which were waiting to be restarted by their
+			// restart policy keep waiting for the rest of their delay
+			if wait := container.RestartDelay - time.Now().Sub(container.FinishedAt); wait > 0 {
+				daemon.delayRestart(container, wait)
+				continue
+			}
+			restartContainers[container] = make(chan struct{})
 		}
 	}
+	daemon.addIDs(ids)
+	daemon.indexLock.RUnlock()
 
 	group := sync.WaitGroup{}
 	for c, notifier := range restartContainers {
@@ -396,7 +507,13 @@ func (daemon *Daemon) restore() error {
 					}
 				}
 			}
-			if err := daemon.containerStart(container); err != nil {
+			// the daemon may be shut down while it restores the
+			// containers in the background
+			if daemon.IsShuttingDown() {
+				close(chNotify)
+				return
+			}
+			if err := daemon.containerStartWithRetries(context.Background(), container, ""); err != nil {
 				logrus.Errorf("Failed to start container %s: %s", container.ID, err)
 			}
 			close(chNotify)
@@ -414,6 +531,24 @@ func (daemon *Daemon) restore() error {
 	return nil
 }
 
+// delayRestart starts the container after the given delay, capped by the
+// maximum restart delay, unless it should no longer be restarted by then. The
+// delay is cancelled when the container is stopped or removed.
+func (daemon *Daemon) delayRestart(c *container.Container, wait time.Duration) {
+	if max := daemon.configStore.RestartMaxDelay; max > 0 && wait > max {
+		wait = max
+	}
+	logrus.Debugf("Starting container %s in %s", c.ID, wait)
+	c.SetRestartTimer(time.AfterFunc(wait, func() {
+		if daemon.IsShuttingDown() || !c.ShouldRestart() || daemon.containers.Get(c.ID) != c {
+			return
+		}
+		if err := daemon.containerStart(context.Background(), c, ""); err != nil {
+			logrus.Errorf("Failed to start container %s: %s", c.ID, err)
+		}
+	}))
+}
+
 func (daemon *Daemon) mergeAndVerifyConfig(config *containertypes.Config, img *image.Image) error {
 	if img != nil && img.Config != nil {
 		if err := runconfig.Merge(config, img.Config); err != nil {
@@ -426,14 +561,14 @@ func (daemon *Daemon) mergeAndVerifyConfig(config *containertypes.Config, img *i
 	return nil
 }
 
-func (daemon *Daemon) generateIDAndName(name string) (string, string, error) {
+func (daemon *Daemon) generateIDAndName(name, image string) (string, string, error) {
 	var (
 		err error
 		id  = stringid.GenerateNonCryptoID()
 	)
 
 	if name == "" {
-		if name, err = daemon.generateNewName(id); err != nil {
+		if name, err = daemon.generateNewName(id, image); err != nil {
 			return "", "", err
 		}
 		return id, name, nil
@@ -472,10 +607,13 @@ func (daemon *Daemon) reserveName(id, name string) (string, error) {
 	return name, nil
 }
 
-func (daemon *Daemon) generateNewName(id string) (string, error) {
+func (daemon *Daemon) generateNewName(id, image string) (string, error) {
 	var name string
 	for i := 0; i < 6; i++ {
-		name = namesgenerator.GetRandomName(i)
+		var err error
+		if name, err = daemon.generateName(i, id, image); err != nil {
+			return "", err
+		}
 		if name[0] != '/' {
 			name = "/" + name
 		}
@@ -496,13 +634,6 @@ func (daemon *Daemon) generateNewName(id string) (string, error) {
 	return name, nil
 }
 
-func (daemon *Daemon) generateHostname(id string, config *containertypes.Config) {
-	// Generate default hostname
-	if config.Hostname == "" {
-		config.Hostname = id[:12]
-	}
-}
-
 func (daemon *Daemon) getEntrypointAndArgs(configEntrypoint *strslice.StrSlice, configCmd *strslice.StrSlice) (string, []string) {
 	cmdSlice := configCmd.Slice()
 	if configEntrypoint.Len() != 0 {
@@ -518,12 +649,14 @@ func (daemon *Daemon) newContainer(name string, config *containertypes.Config, i
 		err            error
 		noExplicitName = name == ""
 	)
-	id, name, err = daemon.generateIDAndName(name)
+	id, name, err = daemon.generateIDAndName(name, config.Image)
 	if err != nil {
 		return nil, err
 	}
 
-	daemon.generateHostname(id, config)
+	if err := daemon.generateHostname(id, name, config); err != nil {
+		return nil, err
+	}
 	entrypoint, args := daemon.getEntrypointAndArgs(config.Entrypoint, config.Cmd)
 
 	base := daemon.newBaseContainer(id)
@@ -707,6 +840,10 @@ func NewDaemon(config *Config, registryService *registry.Service) (daemon *Daemo
 		}
 	}
 	logrus.Debugf("Using default logging driver %s", config.LogConfig.Type)
+	logRules, err := parseLogRules(config)
+	if err != nil {
+		return nil, err
+	}
 
 	daemonRepo := filepath.Join(config.Root, "containers")
 	if err := idtools.MkdirAllAs(daemonRepo, 0700, rootUID, rootGID); err != nil && !os.IsExist(err) {
@@ -717,11 +854,22 @@ func NewDaemon(config *Config, registryService *registry.Service) (daemon *Daemo
 	if driverName == "" {
 		driverName = config.GraphDriver
 	}
+	driverOptions := config.GraphOptions
+	if migrated, migratedOptions := migratedDriver(config.Root); migrated != "" {
+		if driverName == "" {
+			driverName = migrated
+			if len(driverOptions) == 0 {
+				driverOptions = migratedOptions
+			}
+		} else if driverName != migrated {
+			return nil, fmt.Errorf("the storage was migrated to the storage driver %s, but the storage driver %s is set. Set the storage driver to %s, or remove %s to roll the migration back", migrated, driverName, migrated, filepath.Join(config.Root, migratedDriverFile))
+		}
+	}
 	d.layerStore, err = layer.NewStoreFromOptions(layer.StoreOptions{
 		StorePath:                 config.Root,
 		MetadataStorePathTemplate: filepath.Join(config.Root, "image", "%s", "layerdb"),
 		GraphDriver:               driverName,
-		GraphDriverOptions:        config.GraphOptions,
+		GraphDriverOptions:        driverOptions,
 		UIDMaps:                   uidMaps,
 		GIDMaps:                   gidMaps,
 	})
@@ -738,6 +886,7 @@ func NewDaemon(config *Config, registryService *registry.Service) (daemon *Daemo
 	}
 
 	d.downloadManager = xfer.NewLayerDownloadManager(d.layerStore, maxDownloadConcurrency)
+	d.pullSessions = newPullSessions()
 	d.uploadManager = xfer.NewLayerUploadManager(maxUploadConcurrency)
 
 	ifs, err := image.NewFSStoreBackend(filepath.Join(imageRoot, "imagedb"))
@@ -745,10 +894,12 @@ func NewDaemon(config *Config, registryService *registry.Service) (daemon *Daemo
 		return nil, err
 	}
 
-	d.imageStore, err = image.NewImageStore(ifs, d.layerStore)
+	imageStore, err := image.NewImageStore(ifs, d.layerStore)
 	if err != nil {
 		return nil, err
 	}
+	d.imageIndex = newImageIndex()
+	d.imageStore = wrapImageStore(imageStore, d.imageIndex)
 
 	// Configure the volumes driver
 	volStore, err := configureVolumes(config, rootUID, rootGID)
@@ -772,12 +923,47 @@ func NewDaemon(config *Config, registryService *registry.Service) (daemon *Daemo
 		return nil, err
 	}
 
+	if err := verifyRestartBackoff(config); err != nil {
+		return nil, err
+	}
+
+	if config.StatsInterval <= 0 {
+		return nil, fmt.Errorf("Invalid stats interval %s, the interval must be positive", config.StatsInterval)
+	}
+
+	if config.DefaultStopTimeout < 0 {
+		return nil, fmt.Errorf("Invalid default stop timeout %d, the timeout must not be negative", config.DefaultStopTimeout)
+	}
+
 	eventsService := events.New()
+	if config.EventsLogMax > 0 {
+		journal, err := events.NewJournal(filepath.Join(config.Root, "events.log"), config.EventsLogMax, config.EventsLogRetention)
+		if err != nil {
+			return nil, fmt.Errorf("Couldn't open the events journal: %v", err)
+		}
+		if eventsService, err = events.NewWithJournal(journal); err != nil {
+			journal.Close()
+			return nil, fmt.Errorf("Couldn't read the events journal: %v", err)
+		}
+	}
+	for _, spec := range config.EventSinks {
+		sinkConfig, err := events.ParseSinkConfig(spec)
+		if err != nil {
+			eventsService.Close()
+			return nil, err
+		}
+		if err := eventsService.AddSink(sinkConfig); err != nil {
+			eventsService.Close()
+			return nil, err
+		}
+	}
 
-	referenceStore, err := reference.NewReferenceStore(filepath.Join(imageRoot, "repositories.json"))
+	rs, err := reference.NewReferenceStore(filepath.Join(imageRoot, "repositories.json"))
 	if err != nil {
 		return nil, fmt.Errorf("Couldn't create Tag store repositories: %s", err)
 	}
+	d.imageIndex.load(imageStore, rs)
+	referenceStore := wrapReferenceStore(rs, d.imageIndex)
 
 	if err := restoreCustomImage(d.imageStore, d.layerStore, referenceStore); err != nil {
 		return nil, fmt.Errorf("Couldn't restore custom images: %s", err)
@@ -808,9 +994,10 @@ func NewDaemon(config *Config, registryService *registry.Service) (daemon *Daemo
 	if err != nil {
 		return nil, fmt.Errorf("Error initializing network controller: %v", err)
 	}
+	// Activating the plugins may be retried, do not block the daemon start.
+	go d.discoverPlugins()
 
-	graphdbPath := filepath.Join(config.Root, "linkgraph.db")
-	graph, err := graphdb.NewSqliteConn(graphdbPath)
+	graph, err := openContainerGraph(config.Root)
 	if err != nil {
 		return nil, err
 	}
@@ -833,31 +1020,113 @@ func NewDaemon(config *Config, registryService *registry.Service) (daemon *Daemo
 	d.repository = daemonRepo
 	d.containers = &contStore{s: make(map[string]*container.Container)}
 	d.execCommands = exec.NewStore()
+	d.captures = newCaptureStore()
+	d.remoteInspect = newRemoteInspectCache(remoteInspectTTL)
 	d.referenceStore = referenceStore
 	d.distributionMetadataStore = distributionMetadataStore
 	d.trustKey = trustKey
+	if config.LayerCache != "" {
+		if d.layerCache, err = layercache.New(config.LayerCache, d.ID); err != nil {
+			return nil, fmt.Errorf("failed to open the layer cache %s: %v", config.LayerCache, err)
+		}
+	}
 	d.idIndex = truncindex.NewTruncIndex([]string{})
 	d.configStore = config
 	d.execDriver = ed
-	d.statsCollector = d.newStatsCollector(1 * time.Second)
+	d.statsCollector = d.newStatsCollector(config.StatsInterval, config.StatsOnDemand)
 	d.defaultLogConfig = config.LogConfig
+	d.logRules = logRules
 	d.RegistryService = registryService
+	if config.ContentTrust {
+		if d.trustService, err = distribution.NewTrustService(filepath.Join(config.Root, "trust"), config.ContentTrustServers, registryService); err != nil {
+			return nil, err
+		}
+	}
 	d.EventsService = eventsService
 	d.volumes = volStore
 	d.root = config.Root
 	d.uidMaps = uidMaps
 	d.gidMaps = gidMaps
 
+	if config.ContainerNameTemplate != "" {
+		if d.nameTemplate, err = parseNameTemplate(config.ContainerNameTemplate); err != nil {
+			return nil, err
+		}
+	}
+	if config.HostnameTemplate != "" {
+		if d.hostnameTemplate, err = parseHostnameTemplate(config.HostnameTemplate); err != nil {
+			return nil, err
+		}
+	}
+	if d.baselineMounts, err = parseBaselineMounts(config); err != nil {
+		return nil, err
+	}
+	if d.bindPolicy, err = newBindPolicy(config); err != nil {
+		return nil, err
+	}
+	if d.cpuIsolation, err = initCPUIsolation(config); err != nil {
+		return nil, err
+	}
+	if d.icc, err = initICCPolicy(config); err != nil {
+		return nil, err
+	}
+	// The isolation rules of the internal networks were removed with the
+	// ICC chain
+	if d.netController != nil {
+		for _, n := range d.GetAllNetworks() {
+			if err := d.isolateNetwork(n, true); err != nil {
+				return nil, fmt.Errorf("Failed to isolate the internal network %s: %v", n.Name(), err)
+			}
+		}
+	}
+	if d.commitSizeLimit, err = parseCommitSizeLimit(config); err != nil {
+		return nil, err
+	}
+
 	if err := d.cleanupMounts(); err != nil {
 		return nil, err
 	}
 
 	go d.execCommandGC()
 
-	if err := d.restore(); err != nil {
+	if config.WatchContainerConfigs {
+		if d.configWatcher, err = d.startContainerConfigWatcher(); err != nil {
+			return nil, fmt.Errorf("Error watching container configurations: %v", err)
+		}
+	}
+
+	if d.pressure, err = newPressurePolicy(config); err != nil {
 		return nil, err
 	}
 
+	if d.publisher, err = newServicePublisher(config); err != nil {
+		return nil, fmt.Errorf("Error initializing the service publication: %v", err)
+	}
+
+	if config.RestoreInBackground {
+		d.restoreInBackground()
+	} else if err := d.restore(); err != nil {
+		return nil, err
+	}
+
+	if d.pressure != nil {
+		go d.watchPressure(d.pressure)
+	}
+
+	if d.reconciler = newReconciler(config); d.reconciler != nil {
+		go d.reconcileContainers(d.reconciler)
+	}
+
+	if d.publisher != nil {
+		go d.runServicePublisher(d.publisher)
+	}
+
+	if config.InhibitShutdown {
+		if err := d.startShutdownInhibitor(); err != nil {
+			return nil, fmt.Errorf("Error inhibiting the shutdown of the host: %v", err)
+		}
+	}
+
 	return d, nil
 }
 
@@ -891,8 +1160,8 @@ func (daemon *Daemon) shutdownContainer(c *container.Container) error {
 			return err
 		}
 	}
-	// If container failed to exit in 10 seconds of SIGTERM, then using the force
-	if err := daemon.containerStop(c, 10); err != nil {
+	// If container failed to exit within its stop timeout of SIGTERM, then using the force
+	if err := daemon.containerStop(context.Background(), c, daemon.stopTimeout(c)); err != nil {
 		return fmt.Errorf("Stop container %s with error: %v", c.ID, err)
 	}
 
@@ -903,6 +1172,9 @@ func (daemon *Daemon) shutdownContainer(c *container.Container) error {
 // Shutdown stops the daemon.
 func (daemon *Daemon) Shutdown() error {
 	daemon.shutdown = true
+	daemon.pressure.close()
+	daemon.reconciler.close()
+	defer daemon.inhibitor.close()
 	if daemon.containers != nil {
 		group := sync.WaitGroup{}
 		logrus.Debug("starting clean shutdown of all containers...")
@@ -923,6 +1195,13 @@ func (daemon *Daemon) Shutdown() error {
 		}
 		group.Wait()
 	}
+	daemon.releaseHolds()
+
+	daemon.publisher.close()
+
+	if err := daemon.configWatcher.close(); err != nil {
+		logrus.Errorf("Error closing container config watcher: %v", err)
+	}
 
 	// trigger libnetwork Stop only if it's initialized
 	if daemon.netController != nil {
@@ -941,6 +1220,12 @@ func (daemon *Daemon) Shutdown() error {
 		}
 	}
 
+	if daemon.EventsService != nil {
+		if err := daemon.EventsService.Close(); err != nil {
+			logrus.Errorf("Error closing the events journal: %v", err)
+		}
+	}
+
 	if err := daemon.cleanupMounts(); err != nil {
 		return err
 	}
@@ -948,11 +1233,51 @@ func (daemon *Daemon) Shutdown() error {
 	return nil
 }
 
+// mountRWLayer mounts the read-write layer of the container, returning the
+// context error if ctx is cancelled before the graph driver mounts it. The
+// layer is then unmounted once mounted.
+func (daemon *Daemon) mountRWLayer(ctx context.Context, container *container.Container) (string, error) {
+	type mountResult struct {
+		dir string
+		err error
+	}
+	mounted := make(chan mountResult, 1)
+	rwlayer, mountLabel := container.RWLayer, container.GetMountLabel()
+	go func() {
+		dir, err := rwlayer.Mount(mountLabel)
+		mounted <- mountResult{dir, err}
+	}()
+	select {
+	case r := <-mounted:
+		return r.dir, r.err
+	case <-ctx.Done():
+		go func() {
+			if r := <-mounted; r.err == nil {
+				if err := rwlayer.Unmount(); err != nil {
+					logrus.Errorf("Error unmounting container %s: %v", container.ID, err)
+				}
+			}
+		}()
+		return "", ctx.Err()
+	}
+}
+
 // Mount sets container.BaseFS
 // (is it not set coming in? why is it unset?)
-func (daemon *Daemon) Mount(container *container.Container) error {
-	dir, err := container.RWLayer.Mount(container.GetMountLabel())
+// The layer is not mounted if ctx is already cancelled, and Mount returns
+// when ctx is cancelled while mounting it.
+func (daemon *Daemon) Mount(ctx context.Context, container *container.Container) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if container.RWLayer == nil {
+		return derr.ErrorCodeContainerBroken.WithArgs(container.ID, container.Error)
+	}
+	dir, err := daemon.mountRWLayer(ctx, container)
 	if err != nil {
+		if mountErr, ok := err.(*layer.MountError); ok {
+			daemon.mountFailed(container, mountErr)
+		}
 		return err
 	}
 	logrus.Debugf("container mounted via layerStore: %v", dir)
@@ -971,8 +1296,42 @@ func (daemon *Daemon) Mount(container *container.Container) error {
 	return nil
 }
 
+// mountFailed records the failure to mount the read-write layer of the
+// container, reported in inspect until the container starts, and logs a
+// mount-failure event.
+func (daemon *Daemon) mountFailed(container *container.Container, mountErr *layer.MountError) {
+	lastError := &types.MountError{
+		Time:       time.Now().UTC(),
+		Driver:     mountErr.Driver,
+		Error:      mountErr.Err.Error(),
+		MountID:    mountErr.MountID,
+		MountLabel: mountErr.MountLabel,
+		Options:    daemon.configStore.GraphOptions,
+	}
+	for _, chainID := range mountErr.Chain {
+		lastError.Layers = append(lastError.Layers, chainID.String())
+	}
+	container.LastError = lastError
+	if err := container.ToDisk(); err != nil {
+		logrus.Warnf("Failed to save the mount failure of container %s: %v", container.ID, err)
+	}
+
+	attributes := map[string]string{
+		"driver":  lastError.Driver,
+		"error":   lastError.Error,
+		"mountID": lastError.MountID,
+	}
+	if len(lastError.Layers) > 0 {
+		attributes["layer"] = lastError.Layers[0]
+	}
+	daemon.LogContainerEventWithAttributes(container, "mount-failure", attributes)
+}
+
 // Unmount unsets the container base filesystem
 func (daemon *Daemon) Unmount(container *container.Container) {
+	if container.RWLayer == nil {
+		return
+	}
 	if err := container.RWLayer.Unmount(); err != nil {
 		logrus.Errorf("Error unmounting container %s: %s", container.ID, err)
 	}
@@ -1006,12 +1365,21 @@ func (daemon *Daemon) unsubscribeToContainerStats(c *container.Container, ch cha
 }
 
 func (daemon *Daemon) changes(container *container.Container) ([]archive.Change, error) {
+	if container.RWLayer == nil {
+		return nil, derr.ErrorCodeContainerBroken.WithArgs(container.ID, container.Error)
+	}
 	return container.RWLayer.Changes()
 }
 
 // TagImage creates a tag in the repository reponame, pointing to the image named
 // imageName.
 func (daemon *Daemon) TagImage(newTag reference.Named, imageName string) error {
+	done, err := daemon.beginStorageWrite()
+	if err != nil {
+		return err
+	}
+	defer done()
+
 	imageID, err := daemon.GetImageID(imageName)
 	if err != nil {
 		return err
@@ -1041,36 +1409,56 @@ func writeDistributionProgress(cancelFunc func(), outStream io.Writer, progressC
 
 // PullImage initiates a pull operation. image is the repository name to pull, and
 // tag may be either empty, or indicate a specific tag to pull.
-func (daemon *Daemon) PullImage(ref reference.Named, metaHeaders map[string][]string, authConfig *types.AuthConfig, outStream io.Writer) error {
+func (daemon *Daemon) PullImage(ctx context.Context, ref reference.Named, metaHeaders map[string][]string, authConfig *types.AuthConfig, outStream io.Writer) error {
+	done, err := daemon.beginStorageWrite()
+	if err != nil {
+		return err
+	}
+	defer done()
+	if err := daemon.CheckStorage(); err != nil {
+		return err
+	}
+
 	// Include a buffer so that slow client connections don't affect
 	// transfer performance.
 	progressChan := make(chan progress.Progress, 100)
 
 	writesDone := make(chan struct{})
 
-	ctx, cancelFunc := context.WithCancel(context.Background())
+	ctx, cancelFunc := context.WithCancel(ctx)
 
 	go func() {
 		writeDistributionProgress(cancelFunc, outStream, progressChan)
 		close(writesDone)
 	}()
 
-	imagePullConfig := &distribution.ImagePullConfig{
-		MetaHeaders:      metaHeaders,
-		AuthConfig:       authConfig,
-		ProgressOutput:   progress.ChanOutput(progressChan),
-		RegistryService:  daemon.RegistryService,
-		ImageEventLogger: daemon.LogImageEvent,
-		MetadataStore:    daemon.distributionMetadataStore,
-		ImageStore:       daemon.imageStore,
-		ReferenceStore:   daemon.referenceStore,
-		DownloadManager:  daemon.downloadManager,
-	}
+	// The clients pulling the same image at the same time share a single
+	// pull, and get its progress.
+	session := daemon.pullSessions.join(pullSessionKey(ref, metaHeaders, authConfig), func(ctx context.Context, progressOutput progress.Output) error {
+		imagePullConfig := &distribution.ImagePullConfig{
+			MetaHeaders:      metaHeaders,
+			AuthConfig:       authConfig,
+			ProgressOutput:   progressOutput,
+			RegistryService:  daemon.RegistryService,
+			ImageEventLogger: daemon.LogImageEvent,
+			EventLogger:      daemon.LogDistributionEvent,
+			MetadataStore:    daemon.distributionMetadataStore,
+			ImageStore:       daemon.imageStore,
+			ReferenceStore:   daemon.referenceStore,
+			DownloadManager:  daemon.downloadManager,
+			LayerCache:       daemon.layerCache,
+			TrustService:     daemon.trustService,
+		}
+		return distribution.Pull(ctx, ref, imagePullConfig)
+	})
 
-	err := distribution.Pull(ctx, ref, imagePullConfig)
+	err = session.watch(ctx, progress.ChanOutput(progressChan))
 	close(progressChan)
 	<-writesDone
-	return err
+	if err != nil {
+		return daemon.storageError(err)
+	}
+	return nil
 }
 
 // ExportImage exports a list of images to the given output stream. The
@@ -1084,14 +1472,14 @@ func (daemon *Daemon) ExportImage(names []string, outStream io.Writer) error {
 }
 
 // PushImage initiates a push operation on the repository named localName.
-func (daemon *Daemon) PushImage(ref reference.Named, metaHeaders map[string][]string, authConfig *types.AuthConfig, outStream io.Writer) error {
+func (daemon *Daemon) PushImage(ctx context.Context, ref reference.Named, metaHeaders map[string][]string, authConfig *types.AuthConfig, outStream io.Writer) error {
 	// Include a buffer so that slow client connections don't affect
 	// transfer performance.
 	progressChan := make(chan progress.Progress, 100)
 
 	writesDone := make(chan struct{})
 
-	ctx, cancelFunc := context.WithCancel(context.Background())
+	ctx, cancelFunc := context.WithCancel(ctx)
 
 	go func() {
 		writeDistributionProgress(cancelFunc, outStream, progressChan)
@@ -1104,12 +1492,14 @@ func (daemon *Daemon) PushImage(ref reference.Named, metaHeaders map[string][]st
 		ProgressOutput:   progress.ChanOutput(progressChan),
 		RegistryService:  daemon.RegistryService,
 		ImageEventLogger: daemon.LogImageEvent,
+		EventLogger:      daemon.LogDistributionEvent,
 		MetadataStore:    daemon.distributionMetadataStore,
 		LayerStore:       daemon.layerStore,
 		ImageStore:       daemon.imageStore,
 		ReferenceStore:   daemon.referenceStore,
-		TrustKey:         daemon.trustKey,
+		TrustKey:         daemon.getTrustKey(),
 		UploadManager:    daemon.uploadManager,
+		TrustService:     daemon.trustService,
 	}
 
 	err := distribution.Push(ctx, ref, imagePushConfig)
@@ -1190,10 +1580,17 @@ func (daemon *Daemon) LookupImage(name string) (*types.ImageInspect, error) {
 
 // LoadImage uploads a set of images into the repository. This is the
 // complement of ImageExport.  The input stream is an uncompressed tar
-// ball containing images and metadata.
-func (daemon *Daemon) LoadImage(inTar io.ReadCloser, outStream io.Writer) error {
+// ball containing images and metadata. Unless quiet is true, the progress of
+// the load is reported to outStream as a JSON stream.
+func (daemon *Daemon) LoadImage(inTar io.ReadCloser, outStream io.Writer, quiet bool) error {
+	done, err := daemon.beginStorageWrite()
+	if err != nil {
+		return err
+	}
+	defer done()
+
 	imageExporter := tarexport.NewTarExporter(daemon.imageStore, daemon.layerStore, daemon.referenceStore)
-	return imageExporter.Load(inTar, outStream)
+	return imageExporter.Load(inTar, outStream, quiet)
 }
 
 // ImageHistory returns a slice of ImageHistory structures for the specified image
@@ -1279,22 +1676,19 @@ func (daemon *Daemon) GetImageID(refOrID string) (image.ID, error) {
 
 	// Treat it as a possible tag or digest reference
 	if ref, err := reference.ParseNamed(refOrID); err == nil {
-		if id, err := daemon.referenceStore.Get(ref); err == nil {
+		if id, ok := daemon.imageIndex.get(ref); ok {
 			return id, nil
 		}
+		// The tag can be an ID prefix of an image of the repository
 		if tagged, ok := ref.(reference.NamedTagged); ok {
-			if id, err := daemon.imageStore.Search(tagged.Tag()); err == nil {
-				for _, namedRef := range daemon.referenceStore.References(id) {
-					if namedRef.Name() == ref.Name() {
-						return id, nil
-					}
-				}
+			if id, err := daemon.imageIndex.searchID(tagged.Tag()); err == nil && daemon.imageIndex.inRepository(ref.Name(), id) {
+				return id, nil
 			}
 		}
 	}
 
 	// Search based on ID
-	if id, err := daemon.imageStore.Search(refOrID); err == nil {
+	if id, err := daemon.imageIndex.searchID(refOrID); err == nil {
 		return id, nil
 	}
 
@@ -1325,6 +1719,53 @@ func (daemon *Daemon) containerGraph() *graphdb.Database {
 	return daemon.containerGraphDB
 }
 
+// openContainerGraph opens the graph database of the container names and
+// links in root. The sqlite linkgraph.db of the previous versions is migrated
+// the first time, and left in place. The migration is written to a temporary
+// database renamed into place once complete, so that an interrupted migration
+// is run again on the next start.
+func openContainerGraph(root string) (*graphdb.Database, error) {
+	graphPath := filepath.Join(root, "links.db")
+	sqlitePath := filepath.Join(root, "linkgraph.db")
+	_, err := os.Stat(graphPath)
+	migrate := os.IsNotExist(err)
+	if _, err := os.Stat(sqlitePath); err != nil {
+		migrate = false
+	}
+
+	if migrate {
+		logrus.Infof("Migrating the container names and links of %s", sqlitePath)
+		if err := migrateContainerGraph(sqlitePath, graphPath); err != nil {
+			return nil, fmt.Errorf("failed to migrate the container names and links of %s: %v", sqlitePath, err)
+		}
+	}
+	return graphdb.NewDatabase(graphPath)
+}
+
+// migrateContainerGraph migrates the sqlite graph database at sqlitePath to
+// a new graph database at graphPath.
+func migrateContainerGraph(sqlitePath, graphPath string) error {
+	tmpPath := graphPath + ".migrating"
+	if err := os.Remove(tmpPath); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	graph, err := graphdb.NewDatabase(tmpPath)
+	if err != nil {
+		return err
+	}
+	err = graphdb.MigrateSqlite(sqlitePath, graph)
+	if cerr := graph.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(tmpPath, graphPath)
+	}
+	if err != nil {
+		os.Remove(tmpPath)
+	}
+	return err
+}
+
 // GetUIDGIDMaps returns the current daemon's user namespace settings
 // for the full uid and gid maps which will be applied to containers
 // started in this instance.
@@ -1340,6 +1781,24 @@ func (daemon *Daemon) GetRemappedUIDGID() (int, int) {
 	return uid, gid
 }
 
+// containerUIDGIDMaps returns the user namespace mappings a container with
+// hostConfig runs with: none if it opts out with --userns=host, the ones of
+// the daemon otherwise.
+func (daemon *Daemon) containerUIDGIDMaps(hostConfig *containertypes.HostConfig) ([]idtools.IDMap, []idtools.IDMap) {
+	if hostConfig != nil && hostConfig.UsernsMode.IsHost() {
+		return nil, nil
+	}
+	return daemon.GetUIDGIDMaps()
+}
+
+// containerRemappedUIDGID returns the uid and gid of root in the user
+// namespace of a container with hostConfig.
+func (daemon *Daemon) containerRemappedUIDGID(hostConfig *containertypes.HostConfig) (int, int) {
+	uidMaps, gidMaps := daemon.containerUIDGIDMaps(hostConfig)
+	uid, gid, _ := idtools.GetRootUIDGID(uidMaps, gidMaps)
+	return uid, gid
+}
+
 // ImageGetCached returns the earliest created image that is a child
 // of the image with imgID, that had the same config when it was
 // created. nil is returned if a child cannot be found. An error is
@@ -1406,9 +1865,14 @@ func (daemon *Daemon) setHostConfig(container *container.Container, hostConfig *
 	return nil
 }
 
-func (daemon *Daemon) setupInitLayer(initPath string) error {
-	rootUID, rootGID := daemon.GetRemappedUIDGID()
-	return setupInitLayer(initPath, rootUID, rootGID)
+// initLayerFunc returns the function populating the init layer of a
+// container with hostConfig, owned by root in the user namespace of the
+// container.
+func (daemon *Daemon) initLayerFunc(hostConfig *containertypes.HostConfig) layer.MountInit {
+	rootUID, rootGID := daemon.containerRemappedUIDGID(hostConfig)
+	return func(initPath string) error {
+		return setupInitLayer(initPath, rootUID, rootGID)
+	}
 }
 
 func setDefaultMtu(config *Config) {
@@ -1457,6 +1921,19 @@ func (daemon *Daemon) verifyContainerSettings(hostConfig *containertypes.HostCon
 		}
 	}
 
+	// The storage driver validates its options when it creates the
+	// read-write layer, check the size now not to fail later on.
+	for key, val := range hostConfig.StorageOpt {
+		if key == "" {
+			return nil, fmt.Errorf("Invalid storage option %q: the key is empty", key+"="+val)
+		}
+		if strings.ToLower(key) == "size" {
+			if size, err := units.RAMInBytes(val); err != nil || size <= 0 {
+				return nil, fmt.Errorf("Invalid storage option size %q: it must be a positive size", val)
+			}
+		}
+	}
+
 	// Now do platform-specific verification
 	return verifyPlatformContainerSettings(daemon, hostConfig, config)
 }
@@ -1468,7 +1945,10 @@ func configureVolumes(config *Config, rootUID, rootGID int) (*store.VolumeStore,
 	}
 
 	volumedrivers.Register(volumesDriver, volumesDriver.Name())
-	s := store.New()
+	s, err := store.New(filepath.Join(config.Root, "volume-metadata"))
+	if err != nil {
+		return nil, err
+	}
 	s.AddAll(volumesDriver.List())
 
 	return s, nil
@@ -1492,6 +1972,58 @@ func (daemon *Daemon) IsShuttingDown() bool {
 	return daemon.shutdown
 }
 
+// StartTimeout returns how long to wait for the process of a container to
+// start, and whether a hung start should be abandoned.
+func (daemon *Daemon) StartTimeout() (time.Duration, bool) {
+	return daemon.configStore.StartTimeout, daemon.configStore.StartTimeoutCleanup
+}
+
+// RestartBackoff returns the delay between the restarts of containers by
+// their restart policy.
+func (daemon *Daemon) RestartBackoff() container.RestartBackoff {
+	return container.RestartBackoff{
+		InitialDelay: daemon.configStore.RestartDelay,
+		MaxDelay:     daemon.configStore.RestartMaxDelay,
+		Factor:       daemon.configStore.RestartBackoffFactor,
+		Jitter:       daemon.configStore.RestartJitter,
+	}
+}
+
+// verifyRestartBackoff validates the restart backoff settings of the daemon.
+func verifyRestartBackoff(config *Config) error {
+	if config.RestartDelay <= 0 {
+		return fmt.Errorf("Invalid restart delay %s, the delay must be positive", config.RestartDelay)
+	}
+	if config.RestartMaxDelay != 0 && config.RestartMaxDelay < config.RestartDelay {
+		return fmt.Errorf("Invalid restart max delay %s, the delay must not be less than the restart delay", config.RestartMaxDelay)
+	}
+	if config.RestartBackoffFactor < 1 {
+		return fmt.Errorf("Invalid restart backoff factor %v, the factor must be at least 1", config.RestartBackoffFactor)
+	}
+	if config.RestartJitter < 0 || config.RestartJitter > 1 {
+		return fmt.Errorf("Invalid restart jitter %v, the jitter must be between 0 and 1", config.RestartJitter)
+	}
+	if config.CrashLoopFailures < 0 || config.CrashLoopFailures > container.MaxRestartHistory {
+		return fmt.Errorf("Invalid crash loop failures %d, the failures must be between 0 and %d", config.CrashLoopFailures, container.MaxRestartHistory)
+	}
+	if config.CrashLoopFailures > 0 && config.CrashLoopWindow <= 0 {
+		return fmt.Errorf("Invalid crash loop window %s, the window must be positive", config.CrashLoopWindow)
+	}
+	return nil
+}
+
+// CrashLoop returns the number of failures of a container within the window
+// which make it crash looping.
+func (daemon *Daemon) CrashLoop() (int, time.Duration) {
+	return daemon.configStore.CrashLoopFailures, daemon.configStore.CrashLoopWindow
+}
+
+// BuildSourceLabels tells whether the source labels are added to the images
+// built by the daemon.
+func (daemon *Daemon) BuildSourceLabels() bool {
+	return daemon.configStore.BuildSourceLabels
+}
+
 // GetContainerStats collects all the stats published by a container
 func (daemon *Daemon) GetContainerStats(container *container.Container) (*execdriver.ResourceStats, error) {
 	stats, err := daemon.stats(container)
@@ -100,8 +100,8 @@ func setupDaemonRoot(config *Config, rootDir string, rootUID, rootGID int) error
 }
 
 func (daemon *Daemon) verifyExperimentalContainerSettings(hostConfig *container.HostConfig, config *container.Config) ([]string, error) {
-	if hostConfig.Privileged && daemon.configStore.RemappedRoot != "" {
-		return nil, fmt.Errorf("Privileged mode is incompatible with user namespace mappings")
+	if hostConfig.Privileged && daemon.configStore.RemappedRoot != "" && !hostConfig.UsernsMode.IsHost() {
+		return nil, fmt.Errorf("Privileged mode is incompatible with user namespace mappings, use --userns=host to run the container in the host user namespace")
 	}
 	return nil, nil
 }
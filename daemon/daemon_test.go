@@ -5,16 +5,21 @@ import (
 	"os"
 	"path"
 	"path/filepath"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
 
 	containertypes "github.com/docker/docker/api/types/container"
 	"github.com/docker/docker/container"
+	"github.com/docker/docker/layer"
 	"github.com/docker/docker/pkg/graphdb"
 	"github.com/docker/docker/pkg/truncindex"
 	"github.com/docker/docker/volume"
 	volumedrivers "github.com/docker/docker/volume/drivers"
 	"github.com/docker/docker/volume/local"
 	"github.com/docker/docker/volume/store"
+	"golang.org/x/net/context"
 )
 
 //
@@ -75,9 +80,9 @@ func TestGetContainer(t *testing.T) {
 	index.Add(c5.ID)
 
 	daemonTestDbPath := path.Join(os.TempDir(), "daemon_test.db")
-	graph, err := graphdb.NewSqliteConn(daemonTestDbPath)
+	graph, err := graphdb.NewDatabase(daemonTestDbPath)
 	if err != nil {
-		t.Fatalf("Failed to create daemon test sqlite database at %s", daemonTestDbPath)
+		t.Fatalf("Failed to create daemon test graph database at %s", daemonTestDbPath)
 	}
 	graph.Set(c1.Name, c1.ID)
 	graph.Set(c2.Name, c2.ID)
@@ -123,11 +128,59 @@ func TestGetContainer(t *testing.T) {
 	os.Remove(daemonTestDbPath)
 }
 
+func TestContStoreList(t *testing.T) {
+	store := &contStore{s: make(map[string]*container.Container)}
+	now := time.Now()
+	for i := 0; i < 5; i++ {
+		c := container.NewBaseContainer(strconv.Itoa(i), "")
+		c.Created = now.Add(time.Duration(i%3) * time.Second)
+		store.Add(c.ID, c)
+	}
+	// Replacing a container moves it
+	replaced := container.NewBaseContainer("0", "")
+	replaced.Created = now.Add(time.Minute)
+	store.Add(replaced.ID, replaced)
+	store.Delete("3")
+	store.Delete("unknown")
+
+	ids := func(list []*container.Container) string {
+		var ids []string
+		for _, c := range list {
+			ids = append(ids, c.ID)
+		}
+		return strings.Join(ids, ",")
+	}
+	if list := ids(store.List()); list != "0,2,1,4" {
+		t.Fatalf("Expected the containers 0,2,1,4 newest first, got %s", list)
+	}
+
+	filter := func(c *container.Container) bool {
+		return c != replaced && c.ID != "2"
+	}
+	for _, c := range []struct {
+		offset, limit int
+		expected      string
+	}{
+		{0, 0, "1,4"},
+		{1, 0, "4"},
+		{0, 1, "1"},
+		{2, 1, ""},
+	} {
+		if found := ids(store.Find(filter, c.offset, c.limit)); found != c.expected {
+			t.Fatalf("Expected %q with offset %d and limit %d, got %q", c.expected, c.offset, c.limit, found)
+		}
+	}
+}
+
 func initDaemonWithVolumeStore(tmp string) (*Daemon, error) {
+	volumes, err := store.New("")
+	if err != nil {
+		return nil, err
+	}
 	daemon := &Daemon{
 		repository: tmp,
 		root:       tmp,
-		volumes:    store.New(),
+		volumes:    volumes,
 	}
 
 	volumesDriver, err := local.New(tmp, 0, 0)
@@ -162,6 +215,14 @@ func TestParseSecurityOpt(t *testing.T) {
 		t.Fatalf("Unexpected AppArmorProfile, expected: %q, got %q", sp, container.SeccompProfile)
 	}
 
+	config.SecurityOpt = []string{"seccomp=unconfined"}
+	if err := parseSecurityOpt(container, config); err != nil {
+		t.Fatalf("Unexpected parseSecurityOpt error: %v", err)
+	}
+	if container.SeccompProfile != "unconfined" {
+		t.Fatalf("Unexpected SeccompProfile, expected: \"unconfined\", got %q", container.SeccompProfile)
+	}
+
 	// test valid label
 	config.SecurityOpt = []string{"label:user:USER"}
 	if err := parseSecurityOpt(container, config); err != nil {
@@ -310,3 +371,92 @@ func TestContainerInitDNS(t *testing.T) {
 		t.Fatal("Expected container DNSOptions to not be nil")
 	}
 }
+
+func TestOpenContainerGraphFailedMigration(t *testing.T) {
+	root, err := ioutil.TempDir("", "docker-graph-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	// a leftover of an interrupted migration, and a database which cannot
+	// be migrated
+	if err := ioutil.WriteFile(filepath.Join(root, "links.db.migrating"), []byte("partial"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(root, "linkgraph.db"), []byte("not a database"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := openContainerGraph(root); err == nil {
+		t.Fatal("Expected the migration to fail")
+	}
+	for _, name := range []string{"links.db", "links.db.migrating"} {
+		if _, err := os.Stat(filepath.Join(root, name)); !os.IsNotExist(err) {
+			t.Fatalf("Expected no %s after a failed migration, got %v", name, err)
+		}
+	}
+
+	// the daemon starts without the names and links once it is removed
+	if err := os.Remove(filepath.Join(root, "linkgraph.db")); err != nil {
+		t.Fatal(err)
+	}
+	graph, err := openContainerGraph(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	graph.Close()
+}
+
+// blockingRWLayer is a read-write layer whose mounts block until released.
+type blockingRWLayer struct {
+	layer.RWLayer
+	started   chan struct{}
+	release   chan struct{}
+	unmounted chan struct{}
+}
+
+func (l *blockingRWLayer) Mount(mountLabel string) (string, error) {
+	close(l.started)
+	<-l.release
+	return "/var/lib/docker/aufs/mnt/web", nil
+}
+
+func (l *blockingRWLayer) Unmount() error {
+	close(l.unmounted)
+	return nil
+}
+
+func TestMountCancelled(t *testing.T) {
+	rwlayer := &blockingRWLayer{started: make(chan struct{}), release: make(chan struct{}), unmounted: make(chan struct{})}
+	c := container.NewBaseContainer("5a4ff6a163ad4533d22d69a2b8960bf7fafdcba06e72d2febdba229008b0bf57", "")
+	c.HostConfig = &containertypes.HostConfig{}
+	c.RWLayer = rwlayer
+	daemon := &Daemon{}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- daemon.Mount(ctx, c)
+	}()
+	<-rwlayer.started
+	cancel()
+	select {
+	case err := <-errCh:
+		if err != context.Canceled {
+			t.Fatalf("Expected the mount to be cancelled, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected the mount to return when cancelled")
+	}
+	if c.BaseFS != "" {
+		t.Fatalf("Expected the container not to be mounted, got %s", c.BaseFS)
+	}
+
+	// The layer is unmounted once the graph driver mounted it
+	close(rwlayer.release)
+	select {
+	case <-rwlayer.unmounted:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected the layer mounted after the cancellation to be unmounted")
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"net"
 	"os"
 	"path/filepath"
+	"regexp"
 	"strconv"
 	"strings"
 	"syscall"
@@ -19,11 +20,13 @@ import (
 	"github.com/docker/docker/image"
 	"github.com/docker/docker/layer"
 	"github.com/docker/docker/pkg/idtools"
+	"github.com/docker/docker/pkg/mount"
 	"github.com/docker/docker/pkg/parsers/kernel"
 	"github.com/docker/docker/pkg/sysinfo"
 	"github.com/docker/docker/reference"
 	"github.com/docker/docker/runconfig"
 	runconfigopts "github.com/docker/docker/runconfig/opts"
+	"github.com/docker/docker/volume"
 	"github.com/docker/libnetwork"
 	nwconfig "github.com/docker/libnetwork/config"
 	"github.com/docker/libnetwork/drivers/bridge"
@@ -33,6 +36,7 @@ import (
 	"github.com/docker/libnetwork/types"
 	blkiodev "github.com/opencontainers/runc/libcontainer/configs"
 	"github.com/opencontainers/runc/libcontainer/label"
+	"golang.org/x/net/context"
 )
 
 const (
@@ -44,6 +48,9 @@ const (
 	linuxMinMemory = 4194304
 )
 
+// validKernelModulePattern matches the names of kernel modules.
+var validKernelModulePattern = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_-]*$`)
+
 func getBlkioWeightDevices(config *containertypes.HostConfig) ([]*blkiodev.WeightDevice, error) {
 	var stat syscall.Stat_t
 	var blkioWeightDevices []*blkiodev.WeightDevice
@@ -59,6 +66,15 @@ func getBlkioWeightDevices(config *containertypes.HostConfig) ([]*blkiodev.Weigh
 	return blkioWeightDevices, nil
 }
 
+// splitSecurityOpt splits a security option in its key and value, separated
+// by "=" or by ":".
+func splitSecurityOpt(opt string) []string {
+	if strings.Contains(opt, "=") {
+		return strings.SplitN(opt, "=", 2)
+	}
+	return strings.SplitN(opt, ":", 2)
+}
+
 func parseSecurityOpt(container *container.Container, config *containertypes.HostConfig) error {
 	var (
 		labelOpts []string
@@ -66,7 +82,7 @@ func parseSecurityOpt(container *container.Container, config *containertypes.Hos
 	)
 
 	for _, opt := range config.SecurityOpt {
-		con := strings.SplitN(opt, ":", 2)
+		con := splitSecurityOpt(opt)
 		if len(con) == 1 {
 			return fmt.Errorf("Invalid --security-opt: %q", opt)
 		}
@@ -372,6 +388,66 @@ func verifyPlatformContainerSettings(daemon *Daemon, hostConfig *containertypes.
 	if hostConfig.OomScoreAdj < -1000 || hostConfig.OomScoreAdj > 1000 {
 		return warnings, fmt.Errorf("Invalid value %d, range for oom score adj is [-1000, 1000].", hostConfig.OomScoreAdj)
 	}
+	if err := verifyTmpfs(hostConfig, config); err != nil {
+		return warnings, err
+	}
+	if hostConfig.CPUIsolationGroup != "" {
+		if daemon.cpuIsolation == nil {
+			return warnings, fmt.Errorf("CPU isolation groups require the daemon to be started with --isolated-cpus")
+		}
+		if !validContainerNamePattern.MatchString(hostConfig.CPUIsolationGroup) {
+			return warnings, fmt.Errorf("Invalid CPU isolation group (%s), only %s are allowed", hostConfig.CPUIsolationGroup, validContainerNameChars)
+		}
+		if hostConfig.CpusetCpus != "" {
+			return warnings, fmt.Errorf("Conflicting options: a CPU isolation group and a cpuset cannot be set together")
+		}
+	} else if err := daemon.verifyCpusetIsolation(hostConfig.CpusetCpus); err != nil {
+		return warnings, err
+	}
+	if hostConfig.CPUIsolationCores < 0 || (hostConfig.CPUIsolationCores > 0 && hostConfig.CPUIsolationGroup == "") {
+		return warnings, fmt.Errorf("Invalid number of CPU isolation cores %d, it must be positive and set with a CPU isolation group", hostConfig.CPUIsolationCores)
+	}
+	for _, rules := range [][]string{hostConfig.IccAllow, hostConfig.IccDeny} {
+		for _, rule := range rules {
+			if err := validateICCRule(rule); err != nil {
+				return warnings, err
+			}
+		}
+	}
+	if (len(hostConfig.IccAllow) > 0 || len(hostConfig.IccDeny) > 0) && !daemon.configStore.Bridge.EnableIPTables {
+		return warnings, fmt.Errorf("--icc-allow and --icc-deny use iptables to function, they cannot be used with --iptables=false")
+	}
+	if !hostConfig.UsernsMode.Valid() {
+		return warnings, fmt.Errorf("Invalid user namespace mode: %q", hostConfig.UsernsMode)
+	}
+	if !hostConfig.PrivilegeProfile.Valid() {
+		return warnings, fmt.Errorf("Invalid privilege profile %q, the profiles are %q and %q", hostConfig.PrivilegeProfile, containertypes.PrivilegeProfileDevices, containertypes.PrivilegeProfileCapabilities)
+	}
+	if hostConfig.PrivilegeProfile != "" && hostConfig.Privileged {
+		return warnings, fmt.Errorf("Conflicting options: a privilege profile and the privileged mode cannot be set together")
+	}
+	if hostConfig.Timezone != "" {
+		if _, err := readZoneinfo(hostConfig.Timezone); err != nil {
+			return warnings, err
+		}
+	}
+	for _, m := range hostConfig.KernelModules {
+		if !validKernelModulePattern.MatchString(m) {
+			return warnings, fmt.Errorf("Invalid kernel module name: %q", m)
+		}
+	}
+	for _, opt := range hostConfig.SecurityOpt {
+		con := splitSecurityOpt(opt)
+		if len(con) != 2 {
+			continue
+		}
+		switch con[0] {
+		case "seccomp":
+			if err := validateSeccompProfile(con[1]); err != nil {
+				return warnings, err
+			}
+		}
+	}
 	if sysInfo.IPv4ForwardingDisabled {
 		warnings = append(warnings, "IPv4 forwarding is disabled. Networking will not work.")
 		logrus.Warnf("IPv4 forwarding is disabled. Networking will not work")
@@ -379,8 +455,50 @@ func verifyPlatformContainerSettings(daemon *Daemon, hostConfig *containertypes.
 	return warnings, nil
 }
 
+// verifyTmpfs checks the destinations and the options of the tmpfs mounts,
+// which cannot be the destination of a volume or of a bind mount too.
+func verifyTmpfs(hostConfig *containertypes.HostConfig, config *containertypes.Config) error {
+	if len(hostConfig.Tmpfs) == 0 {
+		return nil
+	}
+	destinations := make(map[string]string)
+	if config != nil {
+		for dest := range config.Volumes {
+			destinations[filepath.Clean(dest)] = "a volume"
+		}
+	}
+	for _, bind := range hostConfig.Binds {
+		mp, err := volume.ParseMountSpec(bind, hostConfig.VolumeDriver)
+		if err != nil {
+			return err
+		}
+		destinations[filepath.Clean(mp.Destination)] = "a bind mount"
+	}
+
+	for dest, data := range hostConfig.Tmpfs {
+		if !filepath.IsAbs(dest) {
+			return fmt.Errorf("Invalid tmpfs destination %q: it must be an absolute path", dest)
+		}
+		cleaned := filepath.Clean(dest)
+		if cleaned == "/" {
+			return fmt.Errorf("Invalid tmpfs destination %q: a tmpfs cannot be mounted on the root of the container", dest)
+		}
+		if _, _, err := mount.ParseTmpfsOptions(data); err != nil {
+			return fmt.Errorf("Invalid tmpfs options %q for %s: %v", data, dest, err)
+		}
+		if kind, ok := destinations[cleaned]; ok {
+			return fmt.Errorf("Conflicting options: %s is the destination of a tmpfs and of %s", dest, kind)
+		}
+		destinations[cleaned] = "a tmpfs"
+	}
+	return nil
+}
+
 // checkConfigOptions checks for mutually incompatible config options
 func checkConfigOptions(config *Config) error {
+	if err := validateSeccompProfile(config.SeccompProfile); err != nil {
+		return err
+	}
 	// Check for mutually incompatible config options
 	if config.Bridge.Iface != "" && config.Bridge.IP != "" {
 		return fmt.Errorf("You specified -b & --bip, mutually exclusive options. Please specify only one.")
@@ -391,6 +509,11 @@ func checkConfigOptions(config *Config) error {
 	if !config.Bridge.EnableIPTables && config.Bridge.EnableIPMasq {
 		config.Bridge.EnableIPMasq = false
 	}
+	if config.DefaultTimezone != "" {
+		if _, err := readZoneinfo(config.DefaultTimezone); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
@@ -718,8 +841,8 @@ func (daemon *Daemon) registerLinks(container *container.Container, hostConfig *
 
 // conditionalMountOnStart is a platform specific helper function during the
 // container start to call mount.
-func (daemon *Daemon) conditionalMountOnStart(container *container.Container) error {
-	return daemon.Mount(container)
+func (daemon *Daemon) conditionalMountOnStart(ctx context.Context, container *container.Container) error {
+	return daemon.Mount(ctx, container)
 }
 
 // conditionalUnmountOnCleanup is a platform specific helper function called
@@ -85,3 +85,28 @@ func TestAdjustCPUSharesNoAdjustment(t *testing.T) {
 		t.Error("Expected CPUShares to be unchanged")
 	}
 }
+
+func TestVerifyTmpfs(t *testing.T) {
+	valid := &container.HostConfig{
+		Binds: []string{"/var/log:/var/log"},
+		Tmpfs: map[string]string{
+			"/run":     "rw,noexec,nosuid,size=65536k",
+			"/scratch": "",
+		},
+	}
+	if err := verifyTmpfs(valid, &container.Config{Volumes: map[string]struct{}{"/data": {}}}); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, hostConfig := range []*container.HostConfig{
+		{Tmpfs: map[string]string{"run": ""}},
+		{Tmpfs: map[string]string{"/": ""}},
+		{Tmpfs: map[string]string{"/run": "noexec,compress=lz4"}},
+		{Tmpfs: map[string]string{"/var/log/": ""}, Binds: []string{"/var/log:/var/log"}},
+		{Tmpfs: map[string]string{"/data": ""}},
+	} {
+		if err := verifyTmpfs(hostConfig, &container.Config{Volumes: map[string]struct{}{"/data": {}}}); err == nil {
+			t.Fatalf("Expected an error for the tmpfs mounts %v", hostConfig.Tmpfs)
+		}
+	}
+}
@@ -8,19 +8,20 @@ import (
 	"runtime"
 	"strings"
 
+	// register the windows graph driver
 	"github.com/Sirupsen/logrus"
 	containertypes "github.com/docker/docker/api/types/container"
 	"github.com/docker/docker/container"
 	"github.com/docker/docker/daemon/graphdriver"
+	"github.com/docker/docker/daemon/graphdriver/windows"
 	"github.com/docker/docker/dockerversion"
 	"github.com/docker/docker/image"
 	"github.com/docker/docker/layer"
-	"github.com/docker/docker/reference"
-	// register the windows graph driver
-	"github.com/docker/docker/daemon/graphdriver/windows"
 	"github.com/docker/docker/pkg/system"
+	"github.com/docker/docker/reference"
 	"github.com/docker/libnetwork"
 	blkiodev "github.com/opencontainers/runc/libcontainer/configs"
+	"golang.org/x/net/context"
 )
 
 const (
@@ -83,6 +84,9 @@ func (daemon *Daemon) adaptContainerSettings(hostConfig *containertypes.HostConf
 // verifyPlatformContainerSettings performs platform-specific validation of the
 // hostconfig and config structures.
 func verifyPlatformContainerSettings(daemon *Daemon, hostConfig *containertypes.HostConfig, config *containertypes.Config) ([]string, error) {
+	if len(hostConfig.Tmpfs) > 0 {
+		return nil, fmt.Errorf("tmpfs mounts are not supported on Windows")
+	}
 	return nil, nil
 }
 
@@ -137,10 +141,10 @@ func (daemon *Daemon) cleanupMounts() error {
 
 // conditionalMountOnStart is a platform specific helper function during the
 // container start to call mount.
-func (daemon *Daemon) conditionalMountOnStart(container *container.Container) error {
+func (daemon *Daemon) conditionalMountOnStart(ctx context.Context, container *container.Container) error {
 	// We do not mount if a Hyper-V container
 	if !container.HostConfig.Isolation.IsHyperV() {
-		if err := daemon.Mount(container); err != nil {
+		if err := daemon.Mount(ctx, container); err != nil {
 			return err
 		}
 	}
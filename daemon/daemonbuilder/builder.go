@@ -7,6 +7,7 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/Sirupsen/logrus"
 	"github.com/docker/docker/api"
@@ -22,6 +23,7 @@ import (
 	"github.com/docker/docker/pkg/urlutil"
 	"github.com/docker/docker/reference"
 	"github.com/docker/docker/registry"
+	"golang.org/x/net/context"
 )
 
 // Docker implements builder.Backend for the docker Daemon object.
@@ -58,7 +60,7 @@ func (d Docker) Pull(name string) (builder.Image, error) {
 		pullRegistryAuth = &resolvedConfig
 	}
 
-	if err := d.Daemon.PullImage(ref, nil, pullRegistryAuth, ioutils.NopWriteCloser(d.OutOld)); err != nil {
+	if err := d.Daemon.PullImage(context.Background(), ref, nil, pullRegistryAuth, ioutils.NopWriteCloser(d.OutOld)); err != nil {
 		return nil, err
 	}
 	return d.GetImage(name)
@@ -84,6 +86,22 @@ func (d Docker) ContainerUpdateCmd(cID string, cmd []string) error {
 	return nil
 }
 
+// ContainerStart starts the container cID.
+func (d Docker) ContainerStart(cID string, hostConfig *container.HostConfig) error {
+	return d.Daemon.ContainerStart(context.Background(), cID, hostConfig, "")
+}
+
+// ContainerStartWithEnv starts the container cID, adding env to the
+// environment of its process only.
+func (d Docker) ContainerStartWithEnv(cID string, env []string) error {
+	return d.Daemon.ContainerStartWithEnv(context.Background(), cID, env)
+}
+
+// ContainerWait stops processing until the container cID is stopped.
+func (d Docker) ContainerWait(cID string, timeout time.Duration) (int, error) {
+	return d.Daemon.ContainerWait(context.Background(), cID, timeout)
+}
+
 // ContainerAttach attaches streams to the container cID. If stream is true, it streams the output.
 func (d Docker) ContainerAttach(cID string, stdin io.ReadCloser, stdout, stderr io.Writer, stream bool) error {
 	return d.Daemon.ContainerWsAttachWithLogs(cID, &daemon.ContainerWsAttachWithLogsConfig{
@@ -111,7 +129,7 @@ func (d Docker) BuilderCopy(cID string, destPath string, src builder.FileInfo, d
 	if err != nil {
 		return err
 	}
-	err = d.Daemon.Mount(c)
+	err = d.Daemon.Mount(context.Background(), c)
 	if err != nil {
 		return err
 	}
@@ -197,14 +215,15 @@ func (d Docker) GetCachedImage(imgID string, cfg *container.Config) (string, err
 // DetectContextFromRemoteURL returns a context and in certain cases the name of the dockerfile to be used
 // irrespective of user input.
 // progressReader is only used if remoteURL is actually a URL (not empty, and not a Git endpoint).
-func DetectContextFromRemoteURL(r io.ReadCloser, remoteURL string, createProgressReader func(in io.ReadCloser) io.ReadCloser) (context builder.ModifiableContext, dockerfileName string, err error) {
+// The remote contexts are fetched with the credentials of auth, unless it is nil.
+func DetectContextFromRemoteURL(r io.ReadCloser, remoteURL string, auth *types.AuthConfig, createProgressReader func(in io.ReadCloser) io.ReadCloser) (context builder.ModifiableContext, dockerfileName string, err error) {
 	switch {
 	case remoteURL == "":
 		context, err = builder.MakeTarSumContext(r)
 	case urlutil.IsGitURL(remoteURL):
-		context, err = builder.MakeGitContext(remoteURL)
+		context, err = builder.MakeGitContext(remoteURL, auth)
 	case urlutil.IsURL(remoteURL):
-		context, err = builder.MakeRemoteContext(remoteURL, map[string]func(io.ReadCloser) (io.ReadCloser, error){
+		context, err = builder.MakeRemoteContext(remoteURL, auth, map[string]func(io.ReadCloser) (io.ReadCloser, error){
 			httputils.MimeTypes.TextPlain: func(rc io.ReadCloser) (io.ReadCloser, error) {
 				dockerfile, err := ioutil.ReadAll(rc)
 				if err != nil {
@@ -10,13 +10,29 @@ import (
 	derr "github.com/docker/docker/errors"
 	"github.com/docker/docker/layer"
 	volumestore "github.com/docker/docker/volume/store"
+	"golang.org/x/net/context"
 )
 
 // ContainerRm removes the container id from the filesystem. An error
 // is returned if the container is not found, or if the remove
 // fails. If the remove succeeds, the container name is released, and
-// network links are removed.
+// network links are removed. A label selector removes all the containers
+// it matches.
 func (daemon *Daemon) ContainerRm(name string, config *types.ContainerRmConfig) error {
+	if isLabelSelector(name) && !config.RemoveLink {
+		return daemon.forEachSelected(name, func(id string) error {
+			return daemon.ContainerRm(id, config)
+		})
+	}
+
+	daemon.indexLock.RLock()
+	defer daemon.indexLock.RUnlock()
+	return daemon.containerRm(name, config)
+}
+
+// containerRm removes the container name, holding the read lock of the
+// indexes of the containers.
+func (daemon *Daemon) containerRm(name string, config *types.ContainerRmConfig) error {
 	container, err := daemon.GetContainer(name)
 	if err != nil {
 		return err
@@ -41,6 +57,12 @@ func (daemon *Daemon) ContainerRm(name string, config *types.ContainerRmConfig)
 		return daemon.rmLink(name)
 	}
 
+	done, err := daemon.beginStorageWrite()
+	if err != nil {
+		return err
+	}
+	defer done()
+
 	if err := daemon.cleanupContainer(container, config.ForceRemove); err != nil {
 		// return derr.ErrorCodeCantDestroy.WithArgs(name, utils.GetErrorMessage(err))
 		return err
@@ -82,64 +104,150 @@ func (daemon *Daemon) rmLink(name string) error {
 	return nil
 }
 
+// removalStep is a step of the removal of a container. The action of a step
+// must be idempotent, so that a failed removal can be retried from the start.
+type removalStep struct {
+	name string
+	do   func() error
+	// undo compensates the action of the step when a later step fails, or
+	// is nil if the action needs no compensation.
+	undo func()
+}
+
+// removalTransaction runs the steps of the removal of a container in order.
+// If a step fails, the completed steps are compensated in reverse order and
+// the container is left registered, so that the removal can be retried.
+type removalTransaction struct {
+	steps []removalStep
+}
+
+func (t *removalTransaction) add(name string, do func() error, undo func()) {
+	t.steps = append(t.steps, removalStep{name: name, do: do, undo: undo})
+}
+
+func (t *removalTransaction) run() error {
+	for i, step := range t.steps {
+		if err := step.do(); err != nil {
+			for j := i - 1; j >= 0; j-- {
+				if t.steps[j].undo != nil {
+					logrus.Debugf("Compensating removal step %s", t.steps[j].name)
+					t.steps[j].undo()
+				}
+			}
+			return err
+		}
+	}
+	return nil
+}
+
 // cleanupContainer unregisters a container from the daemon, stops stats
 // collection and cleanly removes contents and metadata from the filesystem.
-func (daemon *Daemon) cleanupContainer(container *container.Container, forceRemove bool) (err error) {
-	if container.IsRunning() {
-		if !forceRemove {
-			return derr.ErrorCodeRmRunning
-		}
-		if err := daemon.Kill(container); err != nil {
-			return derr.ErrorCodeRmFailed.WithArgs(err)
+// If the removal fails, the container is left dead but registered, and the
+// removal can be completed by removing the container again.
+func (daemon *Daemon) cleanupContainer(container *container.Container, forceRemove bool) error {
+	t := &removalTransaction{}
+
+	t.add("stop", func() error {
+		container.CancelRestartTimer()
+		if container.IsRunning() {
+			if !forceRemove {
+				return derr.ErrorCodeRmRunning
+			}
+			if err := daemon.Kill(container); err != nil {
+				return derr.ErrorCodeRmFailed.WithArgs(err)
+			}
 		}
-	}
 
-	// stop collection of stats for the container regardless
-	// if stats are currently getting collected.
-	daemon.statsCollector.stopCollection(container)
+		// stop collection of stats for the container regardless
+		// if stats are currently getting collected.
+		daemon.statsCollector.stopCollection(container)
 
-	if err = daemon.containerStop(container, 3); err != nil {
-		return err
-	}
+		container.Lock()
+		daemon.releaseHold(container, "remove")
+		container.Unlock()
 
-	// Mark container dead. We don't want anybody to be restarting it.
-	container.SetDead()
+		return daemon.containerStop(context.Background(), container, 3)
+	}, nil)
 
-	// Save container state to disk. So that if error happens before
-	// container meta file got removed from disk, then a restart of
-	// docker should not make a dead container alive.
-	if err := container.ToDiskLocking(); err != nil {
-		logrus.Errorf("Error saving dying container to disk: %v", err)
-	}
+	t.add("mark dead", func() error {
+		// Mark container dead. We don't want anybody to be restarting it.
+		container.SetDead()
+
+		// Save container state to disk. So that if error happens before
+		// container meta file got removed from disk, then a restart of
+		// docker should not make a dead container alive.
+		if err := container.ToDiskLocking(); err != nil {
+			logrus.Errorf("Error saving dying container to disk: %v", err)
+		}
+		return nil
+	}, nil)
 
-	// If force removal is required, delete container from various
-	// indexes even if removal failed.
-	defer func() {
-		if err == nil || forceRemove {
-			if _, err := daemon.containerGraphDB.Purge(container.ID); err != nil {
-				logrus.Debugf("Unable to remove container from link graph: %s", err)
+	t.add("release network", func() error {
+		daemon.releaseNetwork(container)
+		return nil
+	}, nil)
+
+	// The volumes are dereferenced before the read-write layer is released,
+	// and referenced again if the removal fails, so that they cannot be
+	// removed while they are used by a container which still exists.
+	t.add("dereference volumes", func() error {
+		for _, m := range container.MountPoints {
+			if m.Volume != nil {
+				daemon.volumes.Decrement(m.Volume)
+			}
+		}
+		return nil
+	}, func() {
+		for _, m := range container.MountPoints {
+			if m.Volume != nil {
+				daemon.volumes.Increment(m.Volume)
 			}
-			selinuxFreeLxcContexts(container.ProcessLabel)
-			daemon.idIndex.Delete(container.ID)
-			daemon.containers.Delete(container.ID)
-			daemon.LogContainerEvent(container, "destroy")
 		}
-	}()
+	})
 
-	if err = os.RemoveAll(container.Root); err != nil {
-		return derr.ErrorCodeRmFS.WithArgs(container.ID, err)
-	}
+	t.add("release layer", func() error {
+		// Broken containers, and containers whose layer was released by
+		// a previous removal, have no read-write layer to release
+		if container.RWLayer == nil {
+			return nil
+		}
+		metadata, err := daemon.layerStore.ReleaseRWLayer(container.RWLayer)
+		layer.LogReleaseMetadata(metadata)
+		if err != nil && err != layer.ErrMountDoesNotExist {
+			return derr.ErrorCodeRmDriverFS.WithArgs(daemon.GraphDriverName(), container.ID, err)
+		}
+		container.RWLayer = nil
+		return nil
+	}, nil)
 
-	metadata, err := daemon.layerStore.ReleaseRWLayer(container.RWLayer)
-	layer.LogReleaseMetadata(metadata)
-	if err != nil && err != layer.ErrMountDoesNotExist {
-		return derr.ErrorCodeRmDriverFS.WithArgs(daemon.GraphDriverName(), container.ID, err)
-	}
+	t.add("clean exec driver", func() error {
+		if err := daemon.execDriver.Clean(container.ID); err != nil {
+			return derr.ErrorCodeRmExecDriver.WithArgs(container.ID, err)
+		}
+		return nil
+	}, nil)
+
+	t.add("remove root", func() error {
+		if err := os.RemoveAll(container.Root); err != nil {
+			return derr.ErrorCodeRmFS.WithArgs(container.ID, err)
+		}
+		return nil
+	}, nil)
 
-	if err = daemon.execDriver.Clean(container.ID); err != nil {
-		return derr.ErrorCodeRmExecDriver.WithArgs(container.ID, err)
+	if err := t.run(); err != nil {
+		return err
 	}
 
+	// Everything the container held is released: remove it, with its links
+	// and names, from the indexes of the daemon.
+	if _, err := daemon.containerGraphDB.Purge(container.ID); err != nil {
+		logrus.Debugf("Unable to remove container from link graph: %s", err)
+	}
+	selinuxFreeLxcContexts(container.ProcessLabel)
+	daemon.configWatcher.unwatch(container)
+	daemon.idIndex.Delete(container.ID)
+	daemon.containers.Delete(container.ID)
+	daemon.LogContainerEvent(container, "destroy")
 	return nil
 }
 
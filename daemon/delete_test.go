@@ -1,8 +1,10 @@
 package daemon
 
 import (
+	"fmt"
 	"io/ioutil"
 	"os"
+	"reflect"
 	"testing"
 
 	"github.com/docker/docker/api/types"
@@ -42,3 +44,36 @@ func TestContainerDoubleDelete(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+func TestRemovalTransactionCompensation(t *testing.T) {
+	var calls []string
+	step := func(name string, fail bool) (func() error, func()) {
+		return func() error {
+				calls = append(calls, name)
+				if fail {
+					return fmt.Errorf("%s failed", name)
+				}
+				return nil
+			}, func() {
+				calls = append(calls, "undo "+name)
+			}
+	}
+
+	tx := &removalTransaction{}
+	do, undo := step("first", false)
+	tx.add("first", do, undo)
+	do, _ = step("second", false)
+	tx.add("second", do, nil)
+	do, undo = step("third", true)
+	tx.add("third", do, undo)
+	do, undo = step("fourth", false)
+	tx.add("fourth", do, undo)
+
+	if err := tx.run(); err == nil || err.Error() != "third failed" {
+		t.Fatalf("Expected the third step to fail, got %v", err)
+	}
+	expected := []string{"first", "second", "third", "undo first"}
+	if !reflect.DeepEqual(calls, expected) {
+		t.Fatalf("Expected %v, got %v", expected, calls)
+	}
+}
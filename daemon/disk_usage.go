@@ -0,0 +1,158 @@
+package daemon
+
+import (
+	"strings"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/image"
+	"github.com/docker/docker/layer"
+	"github.com/docker/docker/reference"
+)
+
+// SystemDiskUsage returns the disk space used by the images, the containers
+// and the volumes known to the daemon, along with the space that would be
+// reclaimed by removing the ones that are not in use.
+func (daemon *Daemon) SystemDiskUsage() (*types.DiskUsage, error) {
+	du := &types.DiskUsage{}
+
+	imageContainers := make(map[image.ID]int)
+	du.Containers, du.ContainerDetails = daemon.containersDiskUsage(imageContainers)
+
+	var err error
+	if du.Images, du.ImageDetails, err = daemon.imagesDiskUsage(imageContainers); err != nil {
+		return nil, err
+	}
+
+	du.Volumes, du.VolumeDetails = daemon.volumesDiskUsage()
+	return du, nil
+}
+
+// containersDiskUsage reports the size of the RW layers of the containers.
+// The number of containers using each image is recorded in imageContainers.
+func (daemon *Daemon) containersDiskUsage(imageContainers map[image.ID]int) (types.DiskUsageSummary, []types.ContainerDiskUsage) {
+	var (
+		summary types.DiskUsageSummary
+		details []types.ContainerDiskUsage
+	)
+	for _, c := range daemon.List() {
+		imageContainers[c.ImageID]++
+
+		sizeRw, sizeRootFs := daemon.getSize(c)
+		running := c.IsRunning()
+
+		summary.TotalCount++
+		if sizeRw > 0 {
+			summary.Size += sizeRw
+		}
+		if running {
+			summary.Active++
+		} else if sizeRw > 0 {
+			summary.Reclaimable += sizeRw
+		}
+
+		details = append(details, types.ContainerDiskUsage{
+			ID:         c.ID,
+			Name:       strings.TrimPrefix(c.Name, "/"),
+			Image:      c.Config.Image,
+			State:      c.State.StateString(),
+			SizeRw:     sizeRw,
+			SizeRootFs: sizeRootFs,
+		})
+	}
+	return summary, details
+}
+
+// imagesDiskUsage reports the size of the images. Layers shared between
+// images are only accounted for once in the summary, and are only
+// reclaimable if none of the images using them is used by a container.
+func (daemon *Daemon) imagesDiskUsage(imageContainers map[image.ID]int) (types.DiskUsageSummary, []types.ImageDiskUsage, error) {
+	var (
+		summary types.DiskUsageSummary
+		details []types.ImageDiskUsage
+	)
+
+	allImages := daemon.imageStore.Map()
+	imageLayers := make(map[image.ID]map[layer.ChainID]int64, len(allImages))
+	layerRefs := make(map[layer.ChainID]int)
+	activeLayers := make(map[layer.ChainID]bool)
+	layerSizes := make(map[layer.ChainID]int64)
+
+	for id, img := range allImages {
+		sizes, err := daemon.layerDiffSizes(img.RootFS.ChainID())
+		if err != nil {
+			return summary, nil, err
+		}
+		imageLayers[id] = sizes
+		for chainID, size := range sizes {
+			layerRefs[chainID]++
+			layerSizes[chainID] = size
+			if imageContainers[id] > 0 {
+				activeLayers[chainID] = true
+			}
+		}
+	}
+
+	for chainID, size := range layerSizes {
+		summary.Size += size
+		if !activeLayers[chainID] {
+			summary.Reclaimable += size
+		}
+	}
+
+	for id := range allImages {
+		summary.TotalCount++
+		if imageContainers[id] > 0 {
+			summary.Active++
+		}
+
+		img := types.ImageDiskUsage{
+			ID:         id.String(),
+			Containers: imageContainers[id],
+		}
+		for chainID, size := range imageLayers[id] {
+			img.Size += size
+			if layerRefs[chainID] > 1 {
+				img.SharedSize += size
+			}
+		}
+		for _, ref := range daemon.referenceStore.References(id) {
+			if _, ok := ref.(reference.NamedTagged); ok {
+				img.RepoTags = append(img.RepoTags, ref.String())
+			}
+		}
+		details = append(details, img)
+	}
+	return summary, details, nil
+}
+
+// volumesDiskUsage reports the size of the volumes. The size of volumes
+// which cannot be measured is reported as -1 and left out of the summary.
+func (daemon *Daemon) volumesDiskUsage() (types.DiskUsageSummary, []types.VolumeDiskUsage) {
+	var (
+		summary types.DiskUsageSummary
+		details []types.VolumeDiskUsage
+	)
+	for _, v := range daemon.volumes.List() {
+		refs := daemon.volumes.Count(v)
+		size := localVolumeSize(v)
+
+		summary.TotalCount++
+		if refs > 0 {
+			summary.Active++
+		}
+		if size > 0 {
+			summary.Size += size
+			if refs == 0 {
+				summary.Reclaimable += size
+			}
+		}
+
+		details = append(details, types.VolumeDiskUsage{
+			Name:     v.Name(),
+			Driver:   v.DriverName(),
+			Size:     size,
+			RefCount: refs,
+		})
+	}
+	return summary, details
+}
@@ -0,0 +1,65 @@
+package daemon
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/docker/docker/volume/drivers"
+	"github.com/docker/docker/volume/local"
+	"github.com/docker/docker/volume/store"
+	vt "github.com/docker/docker/volume/testutils"
+)
+
+func TestVolumesDiskUsage(t *testing.T) {
+	root, err := ioutil.TempDir("", "docker-volumes-df")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	localDriver, err := local.New(root, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	volumedrivers.Register(localDriver, localDriver.Name())
+	volumedrivers.Register(vt.FakeDriver{}, "fake")
+	volumes, err := store.New("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	daemon := &Daemon{volumes: volumes}
+
+	unused, err := daemon.volumes.Create("unused", "local", nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(unused.Path(), "data"), make([]byte, 1024), 0644); err != nil {
+		t.Fatal(err)
+	}
+	used, err := daemon.volumes.Create("used", "local", nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(used.Path(), "data"), make([]byte, 512), 0644); err != nil {
+		t.Fatal(err)
+	}
+	daemon.volumes.Increment(used)
+	if _, err := daemon.volumes.Create("remote", "fake", nil, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	summary, details := daemon.volumesDiskUsage()
+	if summary.TotalCount != 3 || summary.Active != 1 {
+		t.Fatalf("Expected 3 volumes with 1 in use, got %+v", summary)
+	}
+	if summary.Size != 1536 || summary.Reclaimable != 1024 {
+		t.Fatalf("Expected 1536 bytes used and 1024 reclaimable, got %+v", summary)
+	}
+	for _, v := range details {
+		if v.Name == "remote" && v.Size != -1 {
+			t.Fatalf("Expected unknown size for the fake volume, got %+v", v)
+		}
+	}
+}
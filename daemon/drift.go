@@ -0,0 +1,169 @@
+package daemon
+
+import (
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/docker/docker/api/types"
+	containertypes "github.com/docker/docker/api/types/container"
+	derr "github.com/docker/docker/errors"
+	"github.com/docker/docker/runconfig"
+	"github.com/docker/docker/volume"
+	"github.com/docker/go-connections/nat"
+)
+
+// ContainerDrift compares the configuration of a container with a desired
+// configuration, given like to create a container. The desired configuration
+// is merged with its image like when the container is created, so that the
+// settings inherited from the image are not reported as changed.
+func (daemon *Daemon) ContainerDrift(name string, desired types.ContainerCreateConfig) (*types.ContainerDrift, error) {
+	container, err := daemon.GetContainer(name)
+	if err != nil {
+		return nil, err
+	}
+	if desired.Config == nil {
+		return nil, derr.ErrorCodeEmptyConfig
+	}
+	hostConfig := desired.HostConfig
+	if hostConfig == nil {
+		hostConfig = &containertypes.HostConfig{}
+	}
+
+	// runconfig.Merge modifies both configurations, work on copies.
+	config := *desired.Config
+	config.Env = append([]string(nil), config.Env...)
+	config.Volumes = make(map[string]struct{}, len(desired.Config.Volumes))
+	for v := range desired.Config.Volumes {
+		config.Volumes[v] = struct{}{}
+	}
+	img, err := daemon.GetImage(config.Image)
+	if err != nil {
+		return nil, daemon.imageNotExistToErrcode(err)
+	}
+	if img.Config != nil {
+		imgConfig := *img.Config
+		imgConfig.Labels = nil
+		if err := runconfig.Merge(&config, &imgConfig); err != nil {
+			return nil, err
+		}
+	}
+	desiredMounts, err := mountSettings(config.Volumes, hostConfig.Binds, hostConfig.VolumeDriver)
+	if err != nil {
+		return nil, err
+	}
+
+	container.Lock()
+	defer container.Unlock()
+	currentMounts, err := mountSettings(container.Config.Volumes, container.HostConfig.Binds, container.HostConfig.VolumeDriver)
+	if err != nil {
+		return nil, err
+	}
+	drift := &types.ContainerDrift{
+		Env:    diffSettings(envSettings(container.Config.Env), envSettings(config.Env)),
+		Ports:  diffSettings(portSettings(container.HostConfig.PortBindings), portSettings(hostConfig.PortBindings)),
+		Mounts: diffSettings(currentMounts, desiredMounts),
+	}
+	if img.ID() != container.ImageID {
+		drift.Image = &types.DriftChange{
+			Kind:    "change",
+			Key:     "Image",
+			Current: container.ImageID.String(),
+			Desired: img.ID().String(),
+		}
+	}
+	drift.Drifted = drift.Image != nil || len(drift.Env) > 0 || len(drift.Ports) > 0 || len(drift.Mounts) > 0
+	return drift, nil
+}
+
+// diffSettings returns the changes from the current settings to the desired
+// ones, sorted by key.
+func diffSettings(current, desired map[string]string) []types.DriftChange {
+	keys := make([]string, 0, len(current)+len(desired))
+	for k := range current {
+		keys = append(keys, k)
+	}
+	for k := range desired {
+		if _, ok := current[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	changes := []types.DriftChange{}
+	for _, k := range keys {
+		c, inCurrent := current[k]
+		d, inDesired := desired[k]
+		switch {
+		case !inCurrent:
+			changes = append(changes, types.DriftChange{Kind: "add", Key: k, Desired: d})
+		case !inDesired:
+			changes = append(changes, types.DriftChange{Kind: "remove", Key: k, Current: c})
+		case c != d:
+			changes = append(changes, types.DriftChange{Kind: "change", Key: k, Current: c, Desired: d})
+		}
+	}
+	return changes
+}
+
+// envSettings returns the values of the environment variables by name.
+func envSettings(env []string) map[string]string {
+	settings := make(map[string]string, len(env))
+	for _, e := range env {
+		parts := strings.SplitN(e, "=", 2)
+		if len(parts) == 2 {
+			settings[parts[0]] = parts[1]
+		} else {
+			settings[parts[0]] = ""
+		}
+	}
+	return settings
+}
+
+// portSettings returns the host addresses bound to each container port, in
+// the form [ip:]port, sorted and separated by commas.
+func portSettings(bindings nat.PortMap) map[string]string {
+	settings := make(map[string]string, len(bindings))
+	for port, portBindings := range bindings {
+		addresses := make([]string, 0, len(portBindings))
+		for _, b := range portBindings {
+			if b.HostIP != "" {
+				addresses = append(addresses, b.HostIP+":"+b.HostPort)
+			} else {
+				addresses = append(addresses, b.HostPort)
+			}
+		}
+		sort.Strings(addresses)
+		settings[string(port)] = strings.Join(addresses, ",")
+	}
+	return settings
+}
+
+// mountSettings returns the mounts of the binds and volumes of a container by
+// destination, in the form source:mode for host directories and name:mode
+// for named volumes, or "anonymous" for the other volumes.
+func mountSettings(volumes map[string]struct{}, binds []string, volumeDriver string) (map[string]string, error) {
+	settings := make(map[string]string, len(volumes)+len(binds))
+	for _, b := range binds {
+		mp, err := volume.ParseMountSpec(b, volumeDriver)
+		if err != nil {
+			return nil, err
+		}
+		source := mp.Source
+		if source == "" {
+			source = mp.Name
+		}
+		mode := mp.Mode
+		if mode == "" {
+			mode = "rw"
+		}
+		settings[mp.Destination] = source + ":" + mode
+	}
+	for v := range volumes {
+		dest := filepath.Clean(v)
+		if _, ok := settings[dest]; !ok {
+			settings[dest] = "anonymous"
+		}
+	}
+	return settings, nil
+}
@@ -0,0 +1,59 @@
+package daemon
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/go-connections/nat"
+)
+
+func TestDiffSettings(t *testing.T) {
+	current := envSettings([]string{"PATH=/bin", "FOO=1", "BAR=2"})
+	desired := envSettings([]string{"PATH=/bin", "FOO=3", "BAZ"})
+	expected := []types.DriftChange{
+		{Kind: "remove", Key: "BAR", Current: "2"},
+		{Kind: "add", Key: "BAZ"},
+		{Kind: "change", Key: "FOO", Current: "1", Desired: "3"},
+	}
+	if changes := diffSettings(current, desired); !reflect.DeepEqual(changes, expected) {
+		t.Fatalf("Expected %+v, got %+v", expected, changes)
+	}
+	if changes := diffSettings(current, current); len(changes) != 0 {
+		t.Fatalf("Expected no changes, got %+v", changes)
+	}
+}
+
+func TestPortSettings(t *testing.T) {
+	settings := portSettings(nat.PortMap{
+		"80/tcp": {{HostPort: "8080"}, {HostIP: "127.0.0.1", HostPort: "80"}},
+		"53/udp": {},
+	})
+	expected := map[string]string{"80/tcp": "127.0.0.1:80,8080", "53/udp": ""}
+	if !reflect.DeepEqual(settings, expected) {
+		t.Fatalf("Expected %v, got %v", expected, settings)
+	}
+}
+
+func TestMountSettings(t *testing.T) {
+	settings, err := mountSettings(
+		map[string]struct{}{"/data": {}, "/cache/": {}},
+		[]string{"/host:/data:ro", "logs:/var/log"},
+		"",
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]string{
+		"/data":    "/host:ro",
+		"/var/log": "logs:rw",
+		"/cache":   "anonymous",
+	}
+	if !reflect.DeepEqual(settings, expected) {
+		t.Fatalf("Expected %v, got %v", expected, settings)
+	}
+
+	if _, err := mountSettings(nil, []string{"relative:path"}, ""); err == nil {
+		t.Fatal("Expected an invalid bind to fail")
+	}
+}
@@ -0,0 +1,165 @@
+package daemon
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/container"
+	"golang.org/x/net/context"
+)
+
+const (
+	// duEntriesPerSecond is the number of files the usage walk stats per
+	// second at most, so that it does not starve the containers of IO.
+	duEntriesPerSecond = 10000
+	// duBurst is the number of files statted between two checks of the rate.
+	duBurst = 500
+)
+
+// ContainerDu returns the disk usage of the filesystem resource at the
+// specified path in the container identified by the given name, with the
+// usage of its entries down to the given depth. The walk covers the
+// read-write layer and the volumes as the container sees them.
+func (daemon *Daemon) ContainerDu(ctx context.Context, name, path string, depth int) (*types.ContainerPathUsage, error) {
+	if depth < 0 {
+		return nil, fmt.Errorf("Invalid depth %d: must be positive", depth)
+	}
+	container, err := daemon.getContainerWithContext(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	return daemon.containerDu(ctx, container, path, depth)
+}
+
+// containerDu returns the disk usage of the filesystem resource at the
+// specified path in this container.
+func (daemon *Daemon) containerDu(ctx context.Context, container *container.Container, path string, depth int) (*types.ContainerPathUsage, error) {
+	container.Lock()
+	defer container.Unlock()
+
+	if err := daemon.Mount(ctx, container); err != nil {
+		return nil, err
+	}
+	defer daemon.Unmount(container)
+
+	err := daemon.mountVolumes(container)
+	defer container.UnmountVolumes(true, daemon.LogVolumeEvent)
+	if err != nil {
+		return nil, err
+	}
+
+	resolvedPath, _, err := container.ResolvePath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	w := newDuWalker(ctx)
+	usage, err := w.walk(resolvedPath, depth)
+	if err != nil {
+		return nil, err
+	}
+	usage.Name = path
+	return usage, nil
+}
+
+// duWalker walks a directory tree to compute its disk usage, at a limited
+// rate. The files hardlinked more than once are counted once.
+type duWalker struct {
+	ctx   context.Context
+	start time.Time
+	count int
+	seen  map[uint64]struct{}
+}
+
+func newDuWalker(ctx context.Context) *duWalker {
+	return &duWalker{
+		ctx:   ctx,
+		start: time.Now(),
+		seen:  make(map[uint64]struct{}),
+	}
+}
+
+// throttle waits for the rate of the walk to be under duEntriesPerSecond,
+// and returns an error if the walk is cancelled.
+func (w *duWalker) throttle() error {
+	w.count++
+	if w.count%duBurst != 0 {
+		return nil
+	}
+	if err := w.ctx.Err(); err != nil {
+		return err
+	}
+	wait := time.Duration(w.count)*time.Second/duEntriesPerSecond - time.Since(w.start)
+	if wait <= 0 {
+		return nil
+	}
+	select {
+	case <-w.ctx.Done():
+		return w.ctx.Err()
+	case <-time.After(wait):
+		return nil
+	}
+}
+
+// walk returns the usage of the path p, with its entries down to depth.
+// The symlinks are not followed, so that the walk stays in the container.
+func (w *duWalker) walk(p string, depth int) (*types.ContainerPathUsage, error) {
+	if err := w.throttle(); err != nil {
+		return nil, err
+	}
+	fi, err := os.Lstat(p)
+	if err != nil {
+		return nil, err
+	}
+
+	usage := &types.ContainerPathUsage{
+		Name: filepath.Base(p),
+		Size: w.fileSize(fi),
+	}
+	if !fi.IsDir() {
+		return usage, nil
+	}
+
+	f, err := os.Open(p)
+	if err != nil {
+		return nil, err
+	}
+	names, err := f.Readdirnames(-1)
+	f.Close()
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range names {
+		child, err := w.walk(filepath.Join(p, name), depth-1)
+		if err != nil {
+			// The files of a running container can be removed during the walk
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, err
+		}
+		usage.Size += child.Size
+		if depth > 0 {
+			usage.Children = append(usage.Children, *child)
+		}
+	}
+	sort.Sort(byUsage(usage.Children))
+	return usage, nil
+}
+
+// byUsage sorts the usages by decreasing size, then by name.
+type byUsage []types.ContainerPathUsage
+
+func (u byUsage) Len() int      { return len(u) }
+func (u byUsage) Swap(i, j int) { u[i], u[j] = u[j], u[i] }
+func (u byUsage) Less(i, j int) bool {
+	if u[i].Size != u[j].Size {
+		return u[i].Size > u[j].Size
+	}
+	return u[i].Name < u[j].Name
+}
@@ -0,0 +1,61 @@
+package daemon
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"golang.org/x/net/context"
+)
+
+func TestDuWalk(t *testing.T) {
+	root, err := ioutil.TempDir("", "du")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	for name, size := range map[string]int{
+		"var/log/app.log":   300,
+		"var/cache/pkg.tar": 500,
+		"etc/hosts":         20,
+	} {
+		p := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(p, make([]byte, size), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if runtime.GOOS != "windows" {
+		// A hardlinked file is counted once
+		if err := os.Link(filepath.Join(root, "var", "log", "app.log"), filepath.Join(root, "var", "log", "app.log.1")); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	usage, err := newDuWalker(context.Background()).walk(root, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if usage.Size != 820 {
+		t.Fatalf("Expected a usage of 820 bytes, got %d", usage.Size)
+	}
+	if len(usage.Children) != 2 || usage.Children[0].Name != "var" || usage.Children[0].Size != 800 || usage.Children[1].Name != "etc" {
+		t.Fatalf("Expected the usage of var then etc, got %+v", usage.Children)
+	}
+	if usage.Children[0].Children != nil {
+		t.Fatalf("Expected no usage below the depth, got %+v", usage.Children[0].Children)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	w := newDuWalker(ctx)
+	w.count = duBurst - 1
+	if _, err := w.walk(root, 0); err != context.Canceled {
+		t.Fatalf("Expected the walk to be cancelled, got %v", err)
+	}
+}
@@ -0,0 +1,25 @@
+// +build linux freebsd
+
+package daemon
+
+import (
+	"os"
+	"syscall"
+)
+
+// fileSize returns the size of the file fi for the usage walk: the
+// directories are not counted, nor the files already counted through
+// another hardlink.
+func (w *duWalker) fileSize(fi os.FileInfo) int64 {
+	if fi.IsDir() || fi.Size() == 0 {
+		return 0
+	}
+	if st, ok := fi.Sys().(*syscall.Stat_t); ok && st.Nlink > 1 {
+		// inode is not a uint64 on all platforms. Cast it to avoid issues.
+		if _, exists := w.seen[uint64(st.Ino)]; exists {
+			return 0
+		}
+		w.seen[uint64(st.Ino)] = struct{}{}
+	}
+	return fi.Size()
+}
@@ -0,0 +1,12 @@
+package daemon
+
+import "os"
+
+// fileSize returns the size of the file fi for the usage walk: the
+// directories are not counted.
+func (w *duWalker) fileSize(fi os.FileInfo) int64 {
+	if fi.IsDir() {
+		return 0
+	}
+	return fi.Size()
+}
@@ -8,9 +8,17 @@ import (
 	"github.com/docker/libnetwork"
 )
 
-// LogContainerEvent generates an event related to a container.
+// LogContainerEvent generates an event related to a container with only the default attributes.
 func (daemon *Daemon) LogContainerEvent(container *container.Container, action string) {
+	daemon.LogContainerEventWithAttributes(container, action, map[string]string{})
+}
+
+// LogContainerEventWithAttributes generates an event related to a container with specific given attributes.
+func (daemon *Daemon) LogContainerEventWithAttributes(container *container.Container, action string, extraAttributes map[string]string) {
 	attributes := copyAttributes(container.Config.Labels)
+	for k, v := range extraAttributes {
+		attributes[k] = v
+	}
 	if container.Config.Image != "" {
 		attributes["image"] = container.Config.Image
 	}
@@ -43,6 +51,17 @@ func (daemon *Daemon) LogImageEvent(imageID, refName, action string) {
 	daemon.EventsService.Log(action, events.ImageEventType, actor)
 }
 
+// LogDistributionEvent generates an event related to the pull or the push
+// of the image referenced by ref.
+func (daemon *Daemon) LogDistributionEvent(ref, action string, attributes map[string]string) {
+	attributes["name"] = ref
+	actor := events.Actor{
+		ID:         ref,
+		Attributes: attributes,
+	}
+	daemon.EventsService.Log(action, events.ImageEventType, actor)
+}
+
 // LogVolumeEvent generates an event related to a volume.
 func (daemon *Daemon) LogVolumeEvent(volumeID, action string, attributes map[string]string) {
 	actor := events.Actor{
@@ -68,6 +87,15 @@ func (daemon *Daemon) LogNetworkEventWithAttributes(nw libnetwork.Network, actio
 	daemon.EventsService.Log(action, events.NetworkEventType, actor)
 }
 
+// LogDaemonEvent generates an event related to the daemon itself.
+func (daemon *Daemon) LogDaemonEvent(action string, attributes map[string]string) {
+	actor := events.Actor{
+		ID:         daemon.ID,
+		Attributes: attributes,
+	}
+	daemon.EventsService.Log(action, events.DaemonEventType, actor)
+}
+
 // copyAttributes guarantees that labels are not mutated by event triggers.
 func copyAttributes(labels map[string]string) map[string]string {
 	attributes := map[string]string{}
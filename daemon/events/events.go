@@ -4,6 +4,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/Sirupsen/logrus"
 	eventtypes "github.com/docker/docker/api/types/events"
 	"github.com/docker/docker/pkg/pubsub"
 )
@@ -18,6 +19,15 @@ type Events struct {
 	mu     sync.Mutex
 	events []eventtypes.Message
 	pub    *pubsub.Publisher
+	sinks  []*sinkForwarder
+
+	// The events are written to the journal by writeJournal, in the order
+	// they are logged, to keep the writes out of the lock. journaled is the
+	// number of events queued to the journal.
+	journal     *Journal
+	journalCh   chan eventtypes.Message
+	journalDone chan struct{}
+	journaled   int64
 }
 
 // New returns new *Events instance
@@ -28,6 +38,37 @@ func New() *Events {
 	}
 }
 
+// NewWithJournal returns new *Events instance which writes the events to the
+// journal, and replays them from it for the subscribers asking for the past
+// events. The stored last events are restored from the journal.
+func NewWithJournal(j *Journal) (*Events, error) {
+	past, err := j.Read(0, 0)
+	if err != nil {
+		return nil, err
+	}
+	if len(past) > eventsLimit {
+		past = past[len(past)-eventsLimit:]
+	}
+	e := New()
+	e.events = append(e.events, past...)
+	e.journal = j
+	e.journalCh = make(chan eventtypes.Message, bufferSize)
+	e.journalDone = make(chan struct{})
+	go e.writeJournal(j, e.journalCh)
+	return e, nil
+}
+
+// writeJournal appends the events queued to the journal until the queue is
+// closed.
+func (e *Events) writeJournal(j *Journal, ch chan eventtypes.Message) {
+	defer close(e.journalDone)
+	for m := range ch {
+		if err := j.Append(m); err != nil {
+			logrus.Errorf("Error writing event to the journal: %v", err)
+		}
+	}
+}
+
 // Subscribe adds new listener to events, returns slice of 64 stored
 // last events, a channel in which you can expect new events (in form
 // of interface{}, so you need type assertion), and a function to call
@@ -50,14 +91,14 @@ func (e *Events) Subscribe() ([]eventtypes.Message, chan interface{}, func()) {
 // of interface{}, so you need type assertion).
 func (e *Events) SubscribeTopic(since, sinceNano int64, ef *Filter) ([]eventtypes.Message, chan interface{}) {
 	e.mu.Lock()
-	defer e.mu.Unlock()
 
 	var buffered []eventtypes.Message
 	topic := func(m interface{}) bool {
 		return ef.Include(m.(eventtypes.Message))
 	}
 
-	if since != -1 {
+	journal, journaled := e.journal, e.journaled
+	if since != -1 && journal == nil {
 		for i := len(e.events) - 1; i >= 0; i-- {
 			ev := e.events[i]
 			if ev.Time < since || ((ev.Time == since) && (ev.TimeNano < sinceNano)) {
@@ -76,6 +117,21 @@ func (e *Events) SubscribeTopic(since, sinceNano int64, ef *Filter) ([]eventtype
 		// Subscribe to all events if there are no filters
 		ch = e.pub.Subscribe()
 	}
+	e.mu.Unlock()
+
+	// The events logged from now on are sent to the channel, the journal
+	// is read without the lock up to the last event queued to it.
+	if since != -1 && journal != nil {
+		past, err := journal.ReadAppended(since, sinceNano, journaled)
+		if err != nil {
+			logrus.Errorf("Error reading the events journal: %v", err)
+		}
+		for _, ev := range past {
+			if ef.filter.Len() == 0 || topic(ev) {
+				buffered = append(buffered, ev)
+			}
+		}
+	}
 
 	return buffered, ch
 }
@@ -116,10 +172,39 @@ func (e *Events) Log(action, eventType string, actor eventtypes.Actor) {
 	} else {
 		e.events = append(e.events, jm)
 	}
+	if e.journal != nil {
+		select {
+		case e.journalCh <- jm:
+			e.journaled++
+		default:
+			logrus.Errorf("The events journal is behind, event %s %s is not written to it", eventType, action)
+		}
+	}
+	for _, f := range e.sinks {
+		f.enqueue(jm)
+	}
 	e.mu.Unlock()
 	e.pub.Publish(jm)
 }
 
+// Close stops forwarding the events to the sinks and closes the journal of
+// the events, if any.
+func (e *Events) Close() error {
+	e.closeSinks()
+	e.mu.Lock()
+	journal := e.journal
+	if journal != nil {
+		e.journal = nil
+		close(e.journalCh)
+	}
+	e.mu.Unlock()
+	if journal == nil {
+		return nil
+	}
+	<-e.journalDone
+	return journal.Close()
+}
+
 // SubscribersCount returns number of event listeners
 func (e *Events) SubscribersCount() int {
 	return e.pub.Len()
@@ -0,0 +1,196 @@
+package events
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	eventtypes "github.com/docker/docker/api/types/events"
+)
+
+// Journal is a bounded log of events on disk, so that the history of events
+// survives the restarts of the daemon. Events are appended to a file, one JSON
+// message per line. Once the file holds half of the maximum number of events,
+// it replaces the previous file and a new file is started, so the journal
+// keeps between half and all of the maximum number of events.
+type Journal struct {
+	mu        sync.Mutex
+	path      string
+	f         *os.File
+	count     int
+	maxEvents int
+	retention time.Duration
+	// written is the number of events appended since the journal was
+	// opened, signaled by appended.
+	written  int64
+	appended *sync.Cond
+	closed   bool
+}
+
+// NewJournal opens the journal at path, keeping at most maxEvents events
+// which are not older than retention. A zero retention keeps events until
+// they are discarded by maxEvents.
+func NewJournal(path string, maxEvents int, retention time.Duration) (*Journal, error) {
+	if maxEvents < 2 {
+		maxEvents = 2
+	}
+	j := &Journal{
+		path:      path,
+		maxEvents: maxEvents,
+		retention: retention,
+	}
+	j.appended = sync.NewCond(&j.mu)
+
+	events, err := readJournalFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	j.count = len(events)
+
+	if j.f, err = os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600); err != nil {
+		return nil, err
+	}
+	return j, nil
+}
+
+// Append writes the event to the journal, rotating the journal file if it is
+// full. An event which fails to be written is still counted as appended.
+func (j *Journal) Append(m eventtypes.Message) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	defer func() {
+		j.written++
+		j.appended.Broadcast()
+	}()
+
+	if j.count >= j.maxEvents/2 {
+		if err := j.rotate(); err != nil {
+			return err
+		}
+	}
+
+	b, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	if _, err := j.f.Write(append(b, '\n')); err != nil {
+		return err
+	}
+	j.count++
+	return nil
+}
+
+func (j *Journal) rotate() error {
+	if err := j.f.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(j.path, j.path+".1"); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(j.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	j.f = f
+	j.count = 0
+	return nil
+}
+
+// Read returns the events of the journal which happened at or after the
+// given time, oldest first, without the events older than the retention.
+func (j *Journal) Read(since, sinceNano int64) ([]eventtypes.Message, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.read(since, sinceNano, 0)
+}
+
+// ReadAppended is like Read, but returns the events up to the n-th event
+// appended since the journal was opened, waiting for it to be written.
+func (j *Journal) ReadAppended(since, sinceNano int64, n int64) ([]eventtypes.Message, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	for j.written < n && !j.closed {
+		j.appended.Wait()
+	}
+	var newer int64
+	if j.written > n {
+		newer = j.written - n
+	}
+	return j.read(since, sinceNano, newer)
+}
+
+// read returns the events of the journal which happened at or after the
+// given time, but the newer last ones.
+func (j *Journal) read(since, sinceNano int64, newer int64) ([]eventtypes.Message, error) {
+	if j.retention > 0 {
+		oldest := time.Now().Add(-j.retention)
+		if since < oldest.Unix() {
+			since, sinceNano = oldest.Unix(), oldest.UnixNano()
+		}
+	}
+
+	var all []eventtypes.Message
+	for _, p := range []string{j.path + ".1", j.path} {
+		fileEvents, err := readJournalFile(p)
+		if err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+		all = append(all, fileEvents...)
+	}
+	if newer >= int64(len(all)) {
+		return nil, nil
+	}
+	all = all[:int64(len(all))-newer]
+
+	var events []eventtypes.Message
+	for _, ev := range all {
+		if ev.Time < since || ((ev.Time == since) && (ev.TimeNano < sinceNano)) {
+			continue
+		}
+		events = append(events, ev)
+	}
+	return events, nil
+}
+
+// Close closes the journal file.
+func (j *Journal) Close() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.closed = true
+	j.appended.Broadcast()
+	return j.f.Close()
+}
+
+// readJournalFile reads the events of a journal file. Lines which cannot be
+// decoded, like a line truncated by a crash of the daemon, are skipped.
+func readJournalFile(path string) ([]eventtypes.Message, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var events []eventtypes.Message
+	r := bufio.NewReader(f)
+	for {
+		line, err := r.ReadBytes('\n')
+		if len(line) > 0 {
+			var ev eventtypes.Message
+			if err := json.Unmarshal(line, &ev); err != nil {
+				logrus.Warnf("Skipping invalid event in %s: %v", path, err)
+			} else {
+				events = append(events, ev)
+			}
+		}
+		if err == io.EOF {
+			return events, nil
+		}
+		if err != nil {
+			return events, err
+		}
+	}
+}
@@ -0,0 +1,178 @@
+package events
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types/events"
+	"github.com/docker/docker/api/types/filters"
+)
+
+func TestJournalReplayAfterRestart(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "docker-events-journal-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+	path := filepath.Join(tmp, "events.log")
+
+	j, err := NewJournal(path, 100, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e, err := NewWithJournal(j)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		e.Log("action", events.ContainerEventType, events.Actor{ID: fmt.Sprintf("cont%d", i)})
+	}
+	if err := e.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// A truncated line, as left by a crash, must not break the journal.
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString(`{"status":"trunc`)
+	f.Close()
+
+	j, err = NewJournal(path, 100, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e, err = NewWithJournal(j)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer e.Close()
+	if len(e.events) != 3 {
+		t.Fatalf("Must restore 3 events, got %d", len(e.events))
+	}
+
+	buffered, l := e.SubscribeTopic(0, 0, NewFilter(filters.NewArgs()))
+	defer e.Evict(l)
+	if len(buffered) != 3 {
+		t.Fatalf("Must replay 3 events, got %d", len(buffered))
+	}
+	for i, ev := range buffered {
+		if expected := fmt.Sprintf("cont%d", i); ev.ID != expected {
+			t.Fatalf("Expected event %d for %s, got %s", i, expected, ev.ID)
+		}
+	}
+}
+
+func TestJournalRotation(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "docker-events-journal-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+
+	j, err := NewJournal(filepath.Join(tmp, "events.log"), 10, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer j.Close()
+	for i := 0; i < 23; i++ {
+		if err := j.Append(events.Message{ID: fmt.Sprintf("%d", i), Time: int64(i)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	all, err := j.Read(0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != 8 {
+		t.Fatalf("Must keep 8 events, got %d", len(all))
+	}
+	if all[0].ID != "15" || all[len(all)-1].ID != "22" {
+		t.Fatalf("Must keep the last events, got %s to %s", all[0].ID, all[len(all)-1].ID)
+	}
+
+	since, err := j.Read(20, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(since) != 3 {
+		t.Fatalf("Must read 3 events since 20, got %d", len(since))
+	}
+}
+
+func TestJournalRetention(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "docker-events-journal-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+
+	j, err := NewJournal(filepath.Join(tmp, "events.log"), 100, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer j.Close()
+
+	old := time.Now().Add(-2 * time.Hour)
+	now := time.Now()
+	j.Append(events.Message{ID: "old", Time: old.Unix(), TimeNano: old.UnixNano()})
+	j.Append(events.Message{ID: "new", Time: now.Unix(), TimeNano: now.UnixNano()})
+
+	evs, err := j.Read(0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(evs) != 1 || evs[0].ID != "new" {
+		t.Fatalf("Must only keep the recent event, got %v", evs)
+	}
+}
+
+func TestJournalReadAppended(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "docker-events-journal-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+
+	j, err := NewJournal(filepath.Join(tmp, "events.log"), 100, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer j.Close()
+
+	// A line longer than the default limit of bufio.Scanner.
+	long := strings.Repeat("a", 100*1024)
+	for i := 0; i < 3; i++ {
+		j.Append(events.Message{ID: fmt.Sprintf("%d", i), Actor: events.Actor{Attributes: map[string]string{"long": long}}})
+	}
+
+	evs, err := j.ReadAppended(0, 0, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(evs) != 2 || evs[1].ID != "1" || evs[1].Actor.Attributes["long"] != long {
+		t.Fatalf("Must read the first 2 events appended, got %d events", len(evs))
+	}
+
+	read := make(chan []events.Message)
+	go func() {
+		evs, _ := j.ReadAppended(0, 0, 4)
+		read <- evs
+	}()
+	select {
+	case <-read:
+		t.Fatal("Must wait for the 4th event to be appended")
+	case <-time.After(100 * time.Millisecond):
+	}
+	j.Append(events.Message{ID: "3"})
+	if evs := <-read; len(evs) != 4 {
+		t.Fatalf("Must read the 4 events appended, got %d", len(evs))
+	}
+}
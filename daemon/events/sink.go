@@ -0,0 +1,304 @@
+package events
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	eventtypes "github.com/docker/docker/api/types/events"
+	"github.com/docker/docker/api/types/filters"
+)
+
+const (
+	sinkTimeout        = 10 * time.Second
+	sinkDefaultRetries = 5
+	sinkMaxBackoff     = 30 * time.Second
+)
+
+// sinkInitialBackoff is the delay before the first retry to send an event
+// to a sink. The delay doubles after each failed retry.
+var sinkInitialBackoff = 500 * time.Millisecond
+
+// Sink receives the events of the daemon.
+type Sink interface {
+	// Send delivers an event to the sink.
+	Send(eventtypes.Message) error
+	// Close releases the resources of the sink.
+	Close() error
+}
+
+// SinkConfig is the configuration of an event sink.
+type SinkConfig struct {
+	// URL is the address of the sink: an http:// or https:// URL for a
+	// webhook, a unix:// socket path or a file:// path.
+	URL string
+	// Filter selects the events sent to the sink.
+	Filter filters.Args
+	// Retries is the number of times the delivery of an event is retried
+	// before the event is dropped.
+	Retries int
+}
+
+// ParseSinkConfig parses the specification of an event sink, made of the URL
+// of the sink optionally followed by comma separated options:
+//
+//	http://hooks.example.com/docker,filter=type=container,filter=event=die,retries=3
+func ParseSinkConfig(spec string) (*SinkConfig, error) {
+	fields := strings.Split(spec, ",")
+	config := &SinkConfig{
+		URL:     fields[0],
+		Filter:  filters.NewArgs(),
+		Retries: sinkDefaultRetries,
+	}
+	for _, field := range fields[1:] {
+		kv := strings.SplitN(field, "=", 2)
+		if len(kv) != 2 {
+			return nil, fmt.Errorf("Invalid event sink option %q", field)
+		}
+		switch kv[0] {
+		case "filter":
+			var err error
+			if config.Filter, err = filters.ParseFlag(kv[1], config.Filter); err != nil {
+				return nil, fmt.Errorf("Invalid event sink filter %q: %v", kv[1], err)
+			}
+		case "retries":
+			retries, err := strconv.Atoi(kv[1])
+			if err != nil || retries < 0 {
+				return nil, fmt.Errorf("Invalid event sink retries %q", kv[1])
+			}
+			config.Retries = retries
+		default:
+			return nil, fmt.Errorf("Unknown event sink option %q", kv[0])
+		}
+	}
+	if _, err := newSink(config.URL); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+// newSink returns the sink at the given URL.
+func newSink(rawurl string) (Sink, error) {
+	u, err := url.Parse(rawurl)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid event sink URL %q: %v", rawurl, err)
+	}
+	switch u.Scheme {
+	case "http", "https":
+		return &httpSink{url: rawurl, client: &http.Client{Timeout: sinkTimeout}}, nil
+	case "unix":
+		if u.Path == "" {
+			return nil, fmt.Errorf("Invalid event sink URL %q: missing socket path", rawurl)
+		}
+		return &unixSink{path: u.Path}, nil
+	case "file":
+		if u.Path == "" {
+			return nil, fmt.Errorf("Invalid event sink URL %q: missing file path", rawurl)
+		}
+		return &fileSink{path: u.Path}, nil
+	default:
+		return nil, fmt.Errorf("Unsupported event sink URL %q", rawurl)
+	}
+}
+
+// httpSink posts each event as JSON to a webhook.
+type httpSink struct {
+	url    string
+	client *http.Client
+}
+
+func (s *httpSink) Send(m eventtypes.Message) error {
+	b, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	resp, err := s.client.Post(s.url, "application/json", bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("%s returned %s", s.url, resp.Status)
+	}
+	return nil
+}
+
+func (s *httpSink) Close() error {
+	return nil
+}
+
+// unixSink writes each event as a line of JSON to a unix socket. The socket
+// is dialed again after a failed write.
+type unixSink struct {
+	path string
+	conn net.Conn
+}
+
+func (s *unixSink) Send(m eventtypes.Message) error {
+	b, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	if s.conn == nil {
+		if s.conn, err = net.DialTimeout("unix", s.path, sinkTimeout); err != nil {
+			return err
+		}
+	}
+	s.conn.SetWriteDeadline(time.Now().Add(sinkTimeout))
+	if _, err := s.conn.Write(append(b, '\n')); err != nil {
+		s.conn.Close()
+		s.conn = nil
+		return err
+	}
+	return nil
+}
+
+func (s *unixSink) Close() error {
+	if s.conn == nil {
+		return nil
+	}
+	return s.conn.Close()
+}
+
+// fileSink appends each event as a line of JSON to a file.
+type fileSink struct {
+	path string
+	f    *os.File
+}
+
+func (s *fileSink) Send(m eventtypes.Message) error {
+	b, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	if s.f == nil {
+		if s.f, err = os.OpenFile(s.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600); err != nil {
+			return err
+		}
+	}
+	_, err = s.f.Write(append(b, '\n'))
+	return err
+}
+
+func (s *fileSink) Close() error {
+	if s.f == nil {
+		return nil
+	}
+	return s.f.Close()
+}
+
+// sinkForwarder forwards the events queued to it to a sink, retrying the
+// failed deliveries with an exponential backoff. Events logged while a
+// delivery is retried are queued, and dropped when the queue is full, so
+// that a slow sink does not hold back the other subscribers.
+type sinkForwarder struct {
+	config *SinkConfig
+	filter *Filter
+	sink   Sink
+	queue  chan eventtypes.Message
+	stop   chan struct{}
+	done   chan struct{}
+}
+
+// enqueue queues the event m to the sink if it is selected by the filter
+// of the sink. It does not block.
+func (f *sinkForwarder) enqueue(m eventtypes.Message) {
+	if !f.filter.Include(m) {
+		return
+	}
+	select {
+	case f.queue <- m:
+	default:
+		logrus.Errorf("Event sink %s is behind, dropping %s %s event", f.config.URL, m.Type, m.Action)
+	}
+}
+
+func (f *sinkForwarder) run() {
+	defer close(f.done)
+	for m := range f.queue {
+		select {
+		case <-f.stop:
+			return
+		default:
+		}
+		f.send(m)
+	}
+}
+
+func (f *sinkForwarder) send(m eventtypes.Message) {
+	backoff := sinkInitialBackoff
+	for attempt := 0; ; attempt++ {
+		err := f.sink.Send(m)
+		if err == nil {
+			return
+		}
+		if attempt >= f.config.Retries {
+			logrus.Errorf("Dropping %s %s event for sink %s: %v", m.Type, m.Action, f.config.URL, err)
+			return
+		}
+		logrus.Debugf("Error sending event to sink %s, retrying in %s: %v", f.config.URL, backoff, err)
+		select {
+		case <-time.After(backoff):
+		case <-f.stop:
+			return
+		}
+		if backoff *= 2; backoff > sinkMaxBackoff {
+			backoff = sinkMaxBackoff
+		}
+	}
+}
+
+// AddSink forwards the events selected by the filter of the sink to the
+// sink, until the events are closed.
+func (e *Events) AddSink(config *SinkConfig) error {
+	sink, err := newSink(config.URL)
+	if err != nil {
+		return err
+	}
+	f := &sinkForwarder{
+		config: config,
+		filter: NewFilter(config.Filter),
+		sink:   sink,
+		queue:  make(chan eventtypes.Message, bufferSize),
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+
+	e.mu.Lock()
+	e.sinks = append(e.sinks, f)
+	e.mu.Unlock()
+
+	go f.run()
+	return nil
+}
+
+// closeSinks stops forwarding events to the sinks and closes them.
+func (e *Events) closeSinks() {
+	e.mu.Lock()
+	sinks := e.sinks
+	e.sinks = nil
+	e.mu.Unlock()
+
+	for _, f := range sinks {
+		// Give the sink some time to receive the queued events, and drop
+		// them if it does not.
+		close(f.queue)
+		select {
+		case <-f.done:
+		case <-time.After(sinkTimeout):
+			close(f.stop)
+			<-f.done
+		}
+		if err := f.sink.Close(); err != nil {
+			logrus.Errorf("Error closing event sink %s: %v", f.config.URL, err)
+		}
+	}
+}
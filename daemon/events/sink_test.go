@@ -0,0 +1,184 @@
+package events
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types/events"
+	"github.com/docker/docker/api/types/filters"
+)
+
+func TestParseSinkConfig(t *testing.T) {
+	config, err := ParseSinkConfig("http://hooks.example.com/docker,filter=type=container,filter=event=die,retries=2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.URL != "http://hooks.example.com/docker" || config.Retries != 2 {
+		t.Fatalf("Unexpected sink config %+v", config)
+	}
+	if !config.Filter.ExactMatch("event", "die") || config.Filter.ExactMatch("event", "start") {
+		t.Fatalf("Unexpected sink filter %v", config.Filter)
+	}
+
+	config, err = ParseSinkConfig("unix:///run/events.sock")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.Retries != sinkDefaultRetries || config.Filter.Len() != 0 {
+		t.Fatalf("Unexpected sink config %+v", config)
+	}
+
+	invalid := []string{
+		"ftp://example.com/events",
+		"unix://",
+		"file:///var/log/events,retries=-1",
+		"file:///var/log/events,filter=event",
+		"file:///var/log/events,timeout=1s",
+	}
+	for _, spec := range invalid {
+		if _, err := ParseSinkConfig(spec); err == nil {
+			t.Fatalf("Expected %q to be invalid", spec)
+		}
+	}
+}
+
+func TestFileSinkFilter(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "docker-events-sink-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+	path := filepath.Join(tmp, "events.json")
+
+	config, err := ParseSinkConfig("file://" + path + ",filter=event=die")
+	if err != nil {
+		t.Fatal(err)
+	}
+	e := New()
+	if err := e.AddSink(config); err != nil {
+		t.Fatal(err)
+	}
+	e.Log("start", events.ContainerEventType, events.Actor{ID: "cont"})
+	e.Log("die", events.ContainerEventType, events.Actor{ID: "cont"})
+	e.Close()
+
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(b)), "\n")
+	if len(lines) != 1 {
+		t.Fatalf("Must write 1 event, got %q", lines)
+	}
+	var m events.Message
+	if err := json.Unmarshal([]byte(lines[0]), &m); err != nil {
+		t.Fatal(err)
+	}
+	if m.Action != "die" || m.Actor.ID != "cont" {
+		t.Fatalf("Unexpected event %+v", m)
+	}
+}
+
+func TestHTTPSinkRetry(t *testing.T) {
+	defer func(backoff time.Duration) { sinkInitialBackoff = backoff }(sinkInitialBackoff)
+	sinkInitialBackoff = time.Millisecond
+
+	received := make(chan events.Message, 1)
+	failures := 2
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if failures > 0 {
+			failures--
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		var m events.Message
+		if err := json.NewDecoder(r.Body).Decode(&m); err != nil {
+			t.Error(err)
+		}
+		received <- m
+	}))
+	defer srv.Close()
+
+	config, err := ParseSinkConfig(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e := New()
+	defer e.Close()
+	if err := e.AddSink(config); err != nil {
+		t.Fatal(err)
+	}
+	e.Log("create", events.ContainerEventType, events.Actor{ID: "cont"})
+
+	select {
+	case m := <-received:
+		if m.Action != "create" {
+			t.Fatalf("Unexpected event %+v", m)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timeout waiting for the event to be delivered")
+	}
+}
+
+// blockingSink blocks the delivery of the events until it is released.
+type blockingSink struct {
+	release chan struct{}
+}
+
+func (s *blockingSink) Send(events.Message) error {
+	<-s.release
+	return nil
+}
+
+func (s *blockingSink) Close() error {
+	return nil
+}
+
+func TestSinkDoesNotBlockLog(t *testing.T) {
+	e := New()
+	sink := &blockingSink{release: make(chan struct{})}
+	f := &sinkForwarder{
+		config: &SinkConfig{URL: "blocking"},
+		filter: NewFilter(filters.NewArgs()),
+		sink:   sink,
+		queue:  make(chan events.Message, 2),
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+	e.sinks = append(e.sinks, f)
+	go f.run()
+
+	_, ch, cancel := e.Subscribe()
+	defer cancel()
+
+	// the events overflowing the queue of the sink are dropped, without
+	// delaying the other subscribers
+	start := time.Now()
+	for i := 0; i < 10; i++ {
+		e.Log("create", events.ContainerEventType, events.Actor{ID: "cont"})
+		<-ch
+	}
+	if d := time.Now().Sub(start); d > time.Second {
+		t.Fatalf("Expected a blocked sink not to delay the events, took %s", d)
+	}
+	if n := len(f.queue); n != cap(f.queue) {
+		t.Fatalf("Expected the queue of the sink to be full, got %d events", n)
+	}
+
+	close(sink.release)
+	if err := e.Close(); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-f.done:
+	default:
+		t.Fatal("Expected the sink to be stopped")
+	}
+}
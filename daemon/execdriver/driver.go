@@ -96,6 +96,20 @@ type Driver interface {
 
 	// SupportsHooks refers to the driver capability to exploit pre/post hook functionality
 	SupportsHooks() bool
+
+	// Checkpoint dumps the state of the processes of a running container
+	// to disk.
+	Checkpoint(c *Command, opts *CheckpointOpts) error
+}
+
+// CheckpointOpts contains the options used to checkpoint a container, or
+// to restore it from a checkpoint.
+type CheckpointOpts struct {
+	ImagesDirectory string // ImagesDirectory is the directory holding the checkpoint images
+	WorkDirectory   string // WorkDirectory is the directory holding the checkpoint logs
+	LeaveRunning    bool   // LeaveRunning keeps the container running after the checkpoint
+	TCPEstablished  bool   // TCPEstablished checkpoints and restores established TCP connections
+	ShellJob        bool   // ShellJob allows to checkpoint and restore processes attached to a terminal
 }
 
 // CommonResources contains the resource configs for a driver that are
@@ -113,6 +127,11 @@ type ResourceStats struct {
 	Read        time.Time `json:"read"`
 	MemoryLimit int64     `json:"memory_limit"`
 	SystemUsage uint64    `json:"system_usage"`
+	// Pids are the pids of the processes of the container.
+	Pids []int `json:"pids,omitempty"`
+	// Subcgroups are the usage of the cgroups created inside the container,
+	// by path relative to the cgroup of the container.
+	Subcgroups map[string]*libcontainer.Stats `json:"subcgroups,omitempty"`
 }
 
 // CommonProcessConfig is the common platform agnostic part of the ProcessConfig
@@ -141,4 +160,8 @@ type CommonCommand struct {
 	Rootfs        string        `json:"rootfs"` // root fs of the container
 	WorkingDir    string        `json:"working_dir"`
 	TmpDir        string        `json:"tmpdir"` // Directory used to store docker tmpdirs.
+
+	// Restore is set to restore the processes of the container from a
+	// checkpoint instead of starting its init process.
+	Restore *CheckpointOpts `json:"-"`
 }
@@ -108,6 +108,8 @@ type Command struct {
 
 	// Fields below here are platform specific
 
+	AllCapabilities    bool              `json:"all_capabilities"` // Give all the capabilities, without the other privileges
+	AllDevices         bool              `json:"all_devices"`      // Give access to all the host devices, without the other privileges
 	AllowedDevices     []*configs.Device `json:"allowed_devices"`
 	AppArmorProfile    string            `json:"apparmor_profile"`
 	AutoCreatedDevices []*configs.Device `json:"autocreated_devices"`
@@ -302,4 +304,10 @@ type ExitStatus struct {
 
 	// Whether the container encountered an OOM.
 	OOMKilled bool
+
+	// The signal which terminated the process, or 0 if it exited.
+	Signal int
+
+	// Reason describes why the process terminated, e.g. "out of memory".
+	Reason string
 }
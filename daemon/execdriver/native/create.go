@@ -73,6 +73,12 @@ func (d *Driver) createContainer(c *execdriver.Command, hooks execdriver.Hooks)
 		if c.SeccompProfile == "" {
 			container.Seccomp = getDefaultSeccompProfile()
 		}
+
+		if c.AllDevices {
+			if err := d.setAllDevices(container); err != nil {
+				return nil, err
+			}
+		}
 	}
 	// add CAP_ prefix to all caps for new libcontainer update to match
 	// the spec format.
@@ -251,6 +257,18 @@ func (d *Driver) setupRemappedRoot(container *configs.Config, c *execdriver.Comm
 
 func (d *Driver) setPrivileged(container *configs.Config) (err error) {
 	container.Capabilities = execdriver.GetAllCapabilities()
+	if err := d.setAllDevices(container); err != nil {
+		return err
+	}
+
+	if apparmor.IsEnabled() {
+		container.AppArmorProfile = "unconfined"
+	}
+	return nil
+}
+
+// setAllDevices gives the container access to all the devices of the host.
+func (d *Driver) setAllDevices(container *configs.Config) error {
 	container.Cgroups.AllowAllDevices = true
 
 	hostDevices, err := devices.HostDevices()
@@ -258,15 +276,15 @@ func (d *Driver) setPrivileged(container *configs.Config) (err error) {
 		return err
 	}
 	container.Devices = hostDevices
-
-	if apparmor.IsEnabled() {
-		container.AppArmorProfile = "unconfined"
-	}
 	return nil
 }
 
 func (d *Driver) setCapabilities(container *configs.Config, c *execdriver.Command) (err error) {
-	container.Capabilities, err = execdriver.TweakCapabilities(container.Capabilities, c.CapAdd, c.CapDrop)
+	adds := c.CapAdd
+	if c.AllCapabilities {
+		adds = append([]string{"all"}, adds...)
+	}
+	container.Capabilities, err = execdriver.TweakCapabilities(container.Capabilities, adds, c.CapDrop)
 	return err
 }
 
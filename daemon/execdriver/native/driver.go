@@ -169,7 +169,11 @@ func (d *Driver) Run(c *execdriver.Command, pipes *execdriver.Pipes, hooks execd
 		d.cleanContainer(c.ID)
 	}()
 
-	if err := cont.Start(p); err != nil {
+	if c.Restore != nil {
+		if err := cont.Restore(p, criuOpts(c.Restore)); err != nil {
+			return execdriver.ExitStatus{ExitCode: -1}, err
+		}
+	} else if err := cont.Start(p); err != nil {
 		return execdriver.ExitStatus{ExitCode: -1}, err
 	}
 
@@ -201,7 +205,28 @@ func (d *Driver) Run(c *execdriver.Command, pipes *execdriver.Pipes, hooks execd
 	cont.Destroy()
 	destroyed = true
 	_, oomKill := <-oom
-	return execdriver.ExitStatus{ExitCode: utils.ExitStatus(ps.Sys().(syscall.WaitStatus)), OOMKilled: oomKill}, nil
+	return newExitStatus(ps.Sys().(syscall.WaitStatus), oomKill), nil
+}
+
+// newExitStatus returns the exit status of a process which terminated with
+// the wait status ws, with the reason it terminated.
+func newExitStatus(ws syscall.WaitStatus, oomKilled bool) execdriver.ExitStatus {
+	exitStatus := execdriver.ExitStatus{
+		ExitCode:  utils.ExitStatus(ws),
+		OOMKilled: oomKilled,
+	}
+	if ws.Signaled() {
+		exitStatus.Signal = int(ws.Signal())
+	}
+	switch {
+	case oomKilled:
+		exitStatus.Reason = "out of memory"
+	case ws.Signaled():
+		exitStatus.Reason = fmt.Sprintf("terminated by signal %d (%s)", exitStatus.Signal, ws.Signal())
+	default:
+		exitStatus.Reason = fmt.Sprintf("exited with code %d", exitStatus.ExitCode)
+	}
+	return exitStatus
 }
 
 // notifyOnOOM returns a channel that signals if the container received an OOM notification
@@ -295,6 +320,29 @@ func (d *Driver) Pause(c *execdriver.Command) error {
 	return active.Pause()
 }
 
+// Checkpoint implements the exec driver Driver interface,
+// it calls libcontainer API to checkpoint a container with CRIU.
+func (d *Driver) Checkpoint(c *execdriver.Command, opts *execdriver.CheckpointOpts) error {
+	d.Lock()
+	active := d.activeContainers[c.ID]
+	d.Unlock()
+	if active == nil {
+		return fmt.Errorf("active container for %s does not exist", c.ID)
+	}
+	return active.Checkpoint(criuOpts(opts))
+}
+
+// criuOpts converts the checkpoint options to the libcontainer CRIU options.
+func criuOpts(opts *execdriver.CheckpointOpts) *libcontainer.CriuOpts {
+	return &libcontainer.CriuOpts{
+		ImagesDirectory: opts.ImagesDirectory,
+		WorkDirectory:   opts.WorkDirectory,
+		LeaveRunning:    opts.LeaveRunning,
+		TcpEstablished:  opts.TCPEstablished,
+		ShellJob:        opts.ShellJob,
+	}
+}
+
 // Unpause implements the exec driver Driver interface,
 // it calls libcontainer API to unpause a container.
 func (d *Driver) Unpause(c *execdriver.Command) error {
@@ -391,10 +439,22 @@ func (d *Driver) Stats(id string) (*execdriver.ResourceStats, error) {
 	if memoryLimit == 0 {
 		memoryLimit = d.machineMemory
 	}
+	pids, err := c.Processes()
+	if err != nil {
+		logrus.Debugf("Failed to list the processes of container %s: %v", id, err)
+	}
+	var subcgroups map[string]*libcontainer.Stats
+	if state, err := c.State(); err == nil {
+		subcgroups = subcgroupStats(state.CgroupPaths)
+	} else {
+		logrus.Debugf("Failed to get the cgroups of container %s: %v", id, err)
+	}
 	return &execdriver.ResourceStats{
 		Stats:       stats,
 		Read:        now,
 		MemoryLimit: memoryLimit,
+		Pids:        pids,
+		Subcgroups:  subcgroups,
 	}, nil
 }
 
@@ -0,0 +1,29 @@
+// +build linux,cgo
+
+package native
+
+import (
+	"syscall"
+	"testing"
+)
+
+func TestNewExitStatus(t *testing.T) {
+	cases := []struct {
+		ws        syscall.WaitStatus
+		oomKilled bool
+		exitCode  int
+		signal    int
+		reason    string
+	}{
+		{ws: 0, exitCode: 0, reason: "exited with code 0"},
+		{ws: 3 << 8, exitCode: 3, reason: "exited with code 3"},
+		{ws: syscall.WaitStatus(syscall.SIGTERM), exitCode: 143, signal: 15, reason: "terminated by signal 15 (terminated)"},
+		{ws: syscall.WaitStatus(syscall.SIGKILL), oomKilled: true, exitCode: 137, signal: 9, reason: "out of memory"},
+	}
+	for _, c := range cases {
+		s := newExitStatus(c.ws, c.oomKilled)
+		if s.ExitCode != c.exitCode || s.Signal != c.signal || s.Reason != c.reason || s.OOMKilled != c.oomKilled {
+			t.Fatalf("Expected exit code %d, signal %d and reason %q for %#x, got %+v", c.exitCode, c.signal, c.reason, uint32(c.ws), s)
+		}
+	}
+}
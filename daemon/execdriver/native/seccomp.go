@@ -16,6 +16,13 @@ func getDefaultSeccompProfile() *configs.Seccomp {
 	return defaultSeccompProfile
 }
 
+// ValidateSeccompProfile checks that the seccomp profile at path can be
+// loaded into a libcontainer configuration.
+func ValidateSeccompProfile(path string) error {
+	_, err := loadSeccompProfile(path)
+	return err
+}
+
 func loadSeccompProfile(path string) (*configs.Seccomp, error) {
 	f, err := ioutil.ReadFile(path)
 	if err != nil {
@@ -0,0 +1,63 @@
+// +build linux,cgo
+
+package native
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/opencontainers/runc/libcontainer"
+	"github.com/opencontainers/runc/libcontainer/cgroups"
+	"github.com/opencontainers/runc/libcontainer/cgroups/fs"
+)
+
+// subcgroupSubsystems are the cgroup subsystems whose usage is reported for
+// the child cgroups created inside a container, by subsystem name.
+var subcgroupSubsystems = []struct {
+	name  string
+	group interface {
+		GetStats(path string, stats *cgroups.Stats) error
+	}
+}{
+	{"cpu", &fs.CpuGroup{}},
+	{"cpuacct", &fs.CpuacctGroup{}},
+	{"memory", &fs.MemoryGroup{}},
+	{"blkio", &fs.BlkioGroup{}},
+}
+
+// subcgroupStats returns the usage of the cgroups created under the cgroups
+// of a container, by path relative to the cgroup of the container. The
+// cgroups removed while they are read are skipped.
+func subcgroupStats(paths map[string]string) map[string]*libcontainer.Stats {
+	stats := make(map[string]*libcontainer.Stats)
+	for _, s := range subcgroupSubsystems {
+		root, ok := paths[s.name]
+		if !ok {
+			continue
+		}
+		filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+			if err != nil || !info.IsDir() || path == root {
+				return nil
+			}
+			rel, err := filepath.Rel(root, path)
+			if err != nil {
+				return nil
+			}
+			st, ok := stats[rel]
+			if !ok {
+				st = &libcontainer.Stats{CgroupStats: cgroups.NewStats()}
+			}
+			if err := s.group.GetStats(path, st.CgroupStats); err != nil {
+				logrus.Debugf("Failed to read the %s stats of cgroup %s: %v", s.name, path, err)
+				return nil
+			}
+			stats[rel] = st
+			return nil
+		})
+	}
+	if len(stats) == 0 {
+		return nil
+	}
+	return stats
+}
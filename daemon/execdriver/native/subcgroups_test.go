@@ -0,0 +1,57 @@
+// +build linux,cgo
+
+package native
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSubcgroupStats(t *testing.T) {
+	root, err := ioutil.TempDir("", "subcgroups")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	cpuacct := filepath.Join(root, "cpuacct")
+	files := map[string]string{
+		"cpuacct.stat":         "user 10\nsystem 20\n",
+		"cpuacct.usage":        "1000\n",
+		"cpuacct.usage_percpu": "600 400\n",
+	}
+	for _, dir := range []string{cpuacct, filepath.Join(cpuacct, "worker"), filepath.Join(cpuacct, "worker", "job")} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		for name, content := range files {
+			if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	// A cgroup whose stats cannot be read is skipped.
+	if err := os.MkdirAll(filepath.Join(cpuacct, "broken"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	stats := subcgroupStats(map[string]string{"cpuacct": cpuacct, "memory": filepath.Join(root, "memory")})
+	if len(stats) != 2 {
+		t.Fatalf("Expected the stats of 2 subcgroups, got %v", stats)
+	}
+	for _, path := range []string{"worker", filepath.Join("worker", "job")} {
+		s, ok := stats[path]
+		if !ok {
+			t.Fatalf("Missing the stats of subcgroup %s", path)
+		}
+		if usage := s.CgroupStats.CpuStats.CpuUsage.TotalUsage; usage != 1000 {
+			t.Fatalf("Expected a cpu usage of 1000 for %s, got %d", path, usage)
+		}
+	}
+
+	if stats := subcgroupStats(map[string]string{"cpuacct": filepath.Join(cpuacct, "worker", "job")}); stats != nil {
+		t.Fatalf("Expected no subcgroup stats for a leaf cgroup, got %v", stats)
+	}
+}
@@ -17,3 +17,8 @@ func (d *Driver) Pause(c *execdriver.Command) error {
 func (d *Driver) Unpause(c *execdriver.Command) error {
 	return fmt.Errorf("Windows: Containers cannot be paused")
 }
+
+// Checkpoint implements the exec driver Driver interface.
+func (d *Driver) Checkpoint(c *execdriver.Command, opts *execdriver.CheckpointOpts) error {
+	return fmt.Errorf("Windows: Containers cannot be checkpointed")
+}
@@ -7,17 +7,22 @@ import (
 	derr "github.com/docker/docker/errors"
 	"github.com/docker/docker/pkg/archive"
 	"github.com/docker/docker/pkg/ioutils"
+	"golang.org/x/net/context"
 )
 
 // ContainerExport writes the contents of the container to the given
 // writer. An error is returned if the container cannot be found.
-func (daemon *Daemon) ContainerExport(name string, out io.Writer) error {
-	container, err := daemon.GetContainer(name)
+func (daemon *Daemon) ContainerExport(ctx context.Context, name string, out io.Writer) error {
+	container, err := daemon.getContainerWithContext(ctx, name)
 	if err != nil {
 		return err
 	}
 
-	data, err := daemon.containerExport(container)
+	if err := daemon.checkCommitSize(container); err != nil {
+		return err
+	}
+
+	data, err := daemon.containerExport(ctx, container)
 	if err != nil {
 		return derr.ErrorCodeExportFailed.WithArgs(name, err)
 	}
@@ -30,12 +35,12 @@ func (daemon *Daemon) ContainerExport(name string, out io.Writer) error {
 	return nil
 }
 
-func (daemon *Daemon) containerExport(container *container.Container) (archive.Archive, error) {
-	if err := daemon.Mount(container); err != nil {
+func (daemon *Daemon) containerExport(ctx context.Context, container *container.Container) (archive.Archive, error) {
+	if err := daemon.Mount(ctx, container); err != nil {
 		return nil, err
 	}
 
-	uidMaps, gidMaps := daemon.GetUIDGIDMaps()
+	uidMaps, gidMaps := daemon.containerUIDGIDMaps(container.HostConfig)
 	archive, err := archive.TarWithOptions(container.BaseFS, &archive.TarOptions{
 		Compression: archive.Uncompressed,
 		UIDMaps:     uidMaps,
@@ -0,0 +1,151 @@
+package daemon
+
+import (
+	"archive/tar"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/docker/distribution/digest"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/container"
+	derr "github.com/docker/docker/errors"
+)
+
+const (
+	rwLayerManifestFile = "manifest.json"
+	rwLayerFile         = "layer.tar"
+)
+
+// ContainerRWLayerExport writes the read-write layer of the container to out,
+// as a tar archive holding the tar of the layer and a manifest describing it.
+// If pause is set, a running container is paused while its layer is read, so
+// that the export is crash-consistent.
+func (daemon *Daemon) ContainerRWLayerExport(name string, pause bool, out io.Writer) error {
+	container, err := daemon.GetContainer(name)
+	if err != nil {
+		return err
+	}
+
+	if err := daemon.checkCommitSize(container); err != nil {
+		return err
+	}
+
+	layer, manifest, err := daemon.snapshotRWLayer(container, pause)
+	if err != nil {
+		return derr.ErrorCodeExportFailed.WithArgs(name, err)
+	}
+	defer func() {
+		layer.Close()
+		os.Remove(layer.Name())
+	}()
+
+	if err := writeRWLayerExport(out, manifest, layer); err != nil {
+		return derr.ErrorCodeExportFailed.WithArgs(name, err)
+	}
+	daemon.LogContainerEvent(container, "export")
+	return nil
+}
+
+// snapshotRWLayer copies the tar of the read-write layer of the container to
+// a temporary file under the root of the daemon, so that the container is
+// only paused while the layer is read and not while it is sent to the client.
+// The caller must close and remove the file.
+func (daemon *Daemon) snapshotRWLayer(container *container.Container, pause bool) (f *os.File, manifest *types.ContainerRWLayerManifest, err error) {
+	configDigest, err := containerConfigDigest(container)
+	if err != nil {
+		return nil, nil, err
+	}
+	manifest = &types.ContainerRWLayerManifest{
+		ContainerID:  container.ID,
+		Image:        container.ImageID.String(),
+		ConfigDigest: configDigest.String(),
+		Layer:        rwLayerFile,
+		Paused:       container.IsPaused(),
+		Created:      time.Now().UTC().Format(time.RFC3339Nano),
+	}
+
+	if pause && container.IsRunning() && !container.IsPaused() {
+		if err := daemon.containerPause(container); err != nil {
+			return nil, nil, err
+		}
+		defer func() {
+			if unpauseErr := daemon.containerUnpause(container); unpauseErr != nil && err == nil {
+				f.Close()
+				os.Remove(f.Name())
+				f, manifest, err = nil, nil, unpauseErr
+			}
+		}()
+		manifest.Paused = true
+	}
+
+	rwTar, err := daemon.exportContainerRw(container)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rwTar.Close()
+
+	tmpDir := filepath.Join(daemon.root, "tmp")
+	if err := os.MkdirAll(tmpDir, 0700); err != nil {
+		return nil, nil, err
+	}
+	f, err = ioutil.TempFile(tmpDir, "docker-rwlayer-")
+	if err != nil {
+		return nil, nil, err
+	}
+	digester := digest.Canonical.New()
+	size, err := io.Copy(io.MultiWriter(f, digester.Hash()), rwTar)
+	if err == nil {
+		_, err = f.Seek(0, 0)
+	}
+	if err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return nil, nil, err
+	}
+
+	manifest.LayerDigest = digester.Digest().String()
+	manifest.LayerSize = size
+	return f, manifest, nil
+}
+
+// containerConfigDigest returns the digest of the configuration and the host
+// configuration of the container.
+func containerConfigDigest(container *container.Container) (digest.Digest, error) {
+	config, err := json.Marshal(struct {
+		Config     interface{}
+		HostConfig interface{}
+	}{container.Config, container.HostConfig})
+	if err != nil {
+		return "", err
+	}
+	return digest.FromBytes(config)
+}
+
+// writeRWLayerExport writes the manifest and the layer tar of an export to
+// out as a tar archive.
+func writeRWLayerExport(out io.Writer, manifest *types.ContainerRWLayerManifest, layer io.Reader) error {
+	manifestJSON, err := json.Marshal(manifest)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	tw := tar.NewWriter(out)
+	if err := tw.WriteHeader(&tar.Header{Name: rwLayerManifestFile, Mode: 0644, Size: int64(len(manifestJSON)), ModTime: now}); err != nil {
+		return err
+	}
+	if _, err := tw.Write(manifestJSON); err != nil {
+		return err
+	}
+	if err := tw.WriteHeader(&tar.Header{Name: rwLayerFile, Mode: 0644, Size: manifest.LayerSize, ModTime: now}); err != nil {
+		return err
+	}
+	if _, err := io.Copy(tw, layer); err != nil {
+		return err
+	}
+	return tw.Close()
+}
@@ -0,0 +1,79 @@
+package daemon
+
+import (
+	"archive/tar"
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"testing"
+
+	"github.com/docker/distribution/digest"
+	"github.com/docker/docker/api/types"
+	containertypes "github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/container"
+)
+
+func TestWriteRWLayerExport(t *testing.T) {
+	layer := []byte("layer content")
+	layerDigest, err := digest.FromBytes(layer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	manifest := &types.ContainerRWLayerManifest{
+		ContainerID: "5a4ff6a163ad",
+		Layer:       rwLayerFile,
+		LayerDigest: layerDigest.String(),
+		LayerSize:   int64(len(layer)),
+	}
+
+	var buf bytes.Buffer
+	if err := writeRWLayerExport(&buf, manifest, bytes.NewReader(layer)); err != nil {
+		t.Fatal(err)
+	}
+
+	tr := tar.NewReader(&buf)
+	hdr, err := tr.Next()
+	if err != nil || hdr.Name != rwLayerManifestFile {
+		t.Fatalf("Expected the manifest first, got %v, %v", hdr, err)
+	}
+	var read types.ContainerRWLayerManifest
+	if err := json.NewDecoder(tr).Decode(&read); err != nil {
+		t.Fatal(err)
+	}
+	if read != *manifest {
+		t.Fatalf("Expected manifest %+v, got %+v", manifest, read)
+	}
+
+	hdr, err = tr.Next()
+	if err != nil || hdr.Name != rwLayerFile {
+		t.Fatalf("Expected the layer tar, got %v, %v", hdr, err)
+	}
+	content, err := ioutil.ReadAll(tr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d, _ := digest.FromBytes(content); d.String() != manifest.LayerDigest {
+		t.Fatalf("Expected the layer to match digest %s, got %s", manifest.LayerDigest, d)
+	}
+}
+
+func TestContainerConfigDigest(t *testing.T) {
+	c := &container.Container{
+		CommonContainer: container.CommonContainer{
+			Config:     &containertypes.Config{Image: "busybox"},
+			HostConfig: &containertypes.HostConfig{},
+		},
+	}
+	d1, err := containerConfigDigest(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d2, _ := containerConfigDigest(c); d1 != d2 {
+		t.Fatalf("Expected the same digest for the same configuration, got %s and %s", d1, d2)
+	}
+
+	c.HostConfig.Privileged = true
+	if d2, _ := containerConfigDigest(c); d1 == d2 {
+		t.Fatal("Expected the digest to change with the host configuration")
+	}
+}
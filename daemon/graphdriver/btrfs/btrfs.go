@@ -15,9 +15,12 @@ import (
 	"os"
 	"path"
 	"path/filepath"
+	"strconv"
+	"sync"
 	"syscall"
 	"unsafe"
 
+	"github.com/Sirupsen/logrus"
 	"github.com/docker/docker/daemon/graphdriver"
 	"github.com/docker/docker/pkg/idtools"
 	"github.com/docker/docker/pkg/mount"
@@ -54,10 +57,16 @@ func Init(home string, options []string, uidMaps, gidMaps []idtools.IDMap) (grap
 		return nil, err
 	}
 
+	quotaEnabled, err := subvolQgroupStatus(home)
+	if err != nil {
+		logrus.Warnf("[btrfs] %v", err)
+	}
+
 	driver := &Driver{
-		home:    home,
-		uidMaps: uidMaps,
-		gidMaps: gidMaps,
+		home:         home,
+		uidMaps:      uidMaps,
+		gidMaps:      gidMaps,
+		quotaEnabled: quotaEnabled,
 	}
 
 	return graphdriver.NewNaiveDiffDriver(driver, uidMaps, gidMaps), nil
@@ -69,6 +78,11 @@ type Driver struct {
 	home    string
 	uidMaps []idtools.IDMap
 	gidMaps []idtools.IDMap
+
+	// quotaEnabled tells whether the quotas are enabled on the filesystem,
+	// which is done for the first container with a size limit.
+	quotaLock    sync.Mutex
+	quotaEnabled bool
 }
 
 // String prints the name of the driver (btrfs).
@@ -87,12 +101,59 @@ func (d *Driver) Status() [][2]string {
 	if lv := btrfsLibVersion(); lv != -1 {
 		status = append(status, [2]string{"Library Version", fmt.Sprintf("%d", lv)})
 	}
+	quota := "disabled"
+	if d.isQuotaEnabled() {
+		quota = "enabled"
+	}
+	status = append(status, [2]string{"Quota", quota})
 	return status
 }
 
-// GetMetadata returns empty metadata for this driver.
+// GetMetadata returns the size limit and the usage in bytes of the
+// subvolume of id, "Quota" and "QuotaUsage", if the quotas are enabled.
 func (d *Driver) GetMetadata(id string) (map[string]string, error) {
-	return nil, nil
+	if !d.isQuotaEnabled() {
+		return nil, nil
+	}
+	dir := d.subvolumesDirID(id)
+	qgroupid, err := subvolLookupQgroup(dir)
+	if err != nil {
+		logrus.Debugf("[btrfs] %v", err)
+		return nil, nil
+	}
+	qu, err := subvolQgroupUsage(d.home, qgroupid)
+	if err != nil {
+		logrus.Debugf("[btrfs] %v", err)
+		return nil, nil
+	}
+
+	metadata := map[string]string{
+		"QuotaUsage": strconv.FormatUint(qu.usage, 10),
+	}
+	if qu.limit != 0 {
+		metadata["Quota"] = strconv.FormatUint(qu.limit, 10)
+	}
+	return metadata, nil
+}
+
+func (d *Driver) isQuotaEnabled() bool {
+	d.quotaLock.Lock()
+	defer d.quotaLock.Unlock()
+	return d.quotaEnabled
+}
+
+// enableQuota enables the quotas on the filesystem if they are not yet.
+func (d *Driver) enableQuota() error {
+	d.quotaLock.Lock()
+	defer d.quotaLock.Unlock()
+	if d.quotaEnabled {
+		return nil
+	}
+	if err := subvolEnableQuota(d.home); err != nil {
+		return err
+	}
+	d.quotaEnabled = true
+	return nil
 }
 
 // Cleanup unmounts the home directory.
@@ -244,6 +305,18 @@ func (d *Driver) subvolumesDirID(id string) string {
 
 // Create the filesystem with given id.
 func (d *Driver) Create(id, parent, mountLabel string) error {
+	return d.CreateReadWrite(id, parent, mountLabel, nil)
+}
+
+// CreateReadWrite creates the filesystem with given id, with the size limit
+// given by the size storage option. The quotas are enabled on the filesystem
+// if they are not yet.
+func (d *Driver) CreateReadWrite(id, parent, mountLabel string, storageOpt map[string]string) error {
+	size, err := parseStorageOpt(storageOpt)
+	if err != nil {
+		return err
+	}
+
 	subvolumes := path.Join(d.home, "subvolumes")
 	rootUID, rootGID, err := idtools.GetRootUIDGID(d.uidMaps, d.gidMaps)
 	if err != nil {
@@ -266,18 +339,46 @@ func (d *Driver) Create(id, parent, mountLabel string) error {
 		}
 	}
 
+	if size != 0 {
+		if err := d.setStorageSize(path.Join(subvolumes, id), size); err != nil {
+			subvolDelete(subvolumes, id)
+			return err
+		}
+	}
+
 	return label.Relabel(path.Join(subvolumes, id), mountLabel, false)
 }
 
-// Remove the filesystem with given id.
+// setStorageSize limits the size of the subvolume at dir.
+func (d *Driver) setStorageSize(dir string, size uint64) error {
+	if err := d.enableQuota(); err != nil {
+		return err
+	}
+	return subvolLimitQgroup(dir, size)
+}
+
+// Remove the filesystem with given id, and its qgroup if the quotas are
+// enabled.
 func (d *Driver) Remove(id string) error {
 	dir := d.subvolumesDirID(id)
 	if _, err := os.Stat(dir); err != nil {
 		return err
 	}
+	var qgroupid uint64
+	if d.isQuotaEnabled() {
+		var err error
+		if qgroupid, err = subvolLookupQgroup(dir); err != nil {
+			logrus.Debugf("[btrfs] %v", err)
+		}
+	}
 	if err := subvolDelete(d.subvolumesDir(), id); err != nil {
 		return err
 	}
+	if qgroupid != 0 {
+		if err := subvolQgroupDestroy(d.subvolumesDir(), qgroupid); err != nil {
+			logrus.Debugf("[btrfs] %v", err)
+		}
+	}
 	if err := os.RemoveAll(dir); err != nil && !os.IsNotExist(err) {
 		return err
 	}
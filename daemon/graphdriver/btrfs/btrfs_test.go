@@ -61,3 +61,21 @@ func TestBtrfsSubvolDelete(t *testing.T) {
 func TestBtrfsTeardown(t *testing.T) {
 	graphtest.PutDriver(t)
 }
+
+func TestParseStorageOpt(t *testing.T) {
+	size, err := parseStorageOpt(map[string]string{"size": "10G"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if size != 10*1024*1024*1024 {
+		t.Fatalf("Expected a size of 10G, got %d", size)
+	}
+	if size, err := parseStorageOpt(nil); err != nil || size != 0 {
+		t.Fatalf("Expected no size, got %d: %v", size, err)
+	}
+	for _, opt := range []map[string]string{{"size": "-1"}, {"size": "huge"}, {"quota": "1G"}} {
+		if _, err := parseStorageOpt(opt); err == nil {
+			t.Fatalf("Expected an error for the storage options %v", opt)
+		}
+	}
+}
@@ -0,0 +1,220 @@
+// +build linux
+
+package btrfs
+
+/*
+#include <stdlib.h>
+#include <dirent.h>
+#include <btrfs/ioctl.h>
+#include <btrfs/ctree.h>
+*/
+import "C"
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"strings"
+	"syscall"
+	"unsafe"
+
+	"github.com/docker/go-units"
+)
+
+// qgroupUsage is the size limit and the usage of the qgroup of a subvolume,
+// in bytes. The limit is 0 if the qgroup has none.
+type qgroupUsage struct {
+	limit uint64
+	usage uint64
+}
+
+// parseStorageOpt returns the size limit in bytes given by the size storage
+// option, or 0 if there is none.
+func parseStorageOpt(storageOpt map[string]string) (uint64, error) {
+	var size uint64
+	for key, val := range storageOpt {
+		switch strings.ToLower(key) {
+		case "size":
+			s, err := units.RAMInBytes(val)
+			if err != nil {
+				return 0, fmt.Errorf("Invalid size %q: %v", val, err)
+			}
+			if s <= 0 {
+				return 0, fmt.Errorf("Invalid size %q: must be positive", val)
+			}
+			size = uint64(s)
+		default:
+			return 0, fmt.Errorf("Unknown option %s", key)
+		}
+	}
+	return size, nil
+}
+
+// subvolQgroupStatus tells whether the quotas are enabled on the filesystem
+// of path, which has a qgroup status item if they are.
+func subvolQgroupStatus(path string) (bool, error) {
+	dir, err := openDir(path)
+	if err != nil {
+		return false, err
+	}
+	defer closeDir(dir)
+
+	var args C.struct_btrfs_ioctl_search_args
+	args.key.tree_id = C.BTRFS_QUOTA_TREE_OBJECTID
+	args.key.min_type = C.BTRFS_QGROUP_STATUS_KEY
+	args.key.max_type = C.BTRFS_QGROUP_STATUS_KEY
+	args.key.max_objectid = C.__u64(math.MaxUint64)
+	args.key.max_offset = C.__u64(math.MaxUint64)
+	args.key.max_transid = C.__u64(math.MaxUint64)
+	args.key.nr_items = 1
+
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, getDirFd(dir), C.BTRFS_IOC_TREE_SEARCH,
+		uintptr(unsafe.Pointer(&args)))
+	if errno == syscall.ENOENT {
+		// There is no quota tree
+		return false, nil
+	}
+	if errno != 0 {
+		return false, fmt.Errorf("Failed to search the btrfs qgroup status for %s: %v", path, errno.Error())
+	}
+	return args.key.nr_items > 0, nil
+}
+
+// subvolEnableQuota enables the quotas on the filesystem of path.
+func subvolEnableQuota(path string) error {
+	dir, err := openDir(path)
+	if err != nil {
+		return err
+	}
+	defer closeDir(dir)
+
+	var args C.struct_btrfs_ioctl_quota_ctl_args
+	args.cmd = C.BTRFS_QUOTA_CTL_ENABLE
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, getDirFd(dir), C.BTRFS_IOC_QUOTA_CTL,
+		uintptr(unsafe.Pointer(&args)))
+	if errno != 0 {
+		return fmt.Errorf("Failed to enable btrfs quota for %s: %v", path, errno.Error())
+	}
+	return nil
+}
+
+// subvolLimitQgroup limits the size of the data referenced by the subvolume
+// at path to size bytes.
+func subvolLimitQgroup(path string, size uint64) error {
+	dir, err := openDir(path)
+	if err != nil {
+		return err
+	}
+	defer closeDir(dir)
+
+	var args C.struct_btrfs_ioctl_qgroup_limit_args
+	args.lim.max_referenced = C.__u64(size)
+	args.lim.flags = C.BTRFS_QGROUP_LIMIT_MAX_RFER
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, getDirFd(dir), C.BTRFS_IOC_QGROUP_LIMIT,
+		uintptr(unsafe.Pointer(&args)))
+	if errno != 0 {
+		return fmt.Errorf("Failed to limit the btrfs qgroup of %s: %v", path, errno.Error())
+	}
+	return nil
+}
+
+// subvolLookupQgroup returns the id of the qgroup of the subvolume at path,
+// which is the id of its tree.
+func subvolLookupQgroup(path string) (uint64, error) {
+	dir, err := openDir(path)
+	if err != nil {
+		return 0, err
+	}
+	defer closeDir(dir)
+
+	var args C.struct_btrfs_ioctl_ino_lookup_args
+	args.objectid = C.BTRFS_FIRST_FREE_OBJECTID
+
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, getDirFd(dir), C.BTRFS_IOC_INO_LOOKUP,
+		uintptr(unsafe.Pointer(&args)))
+	if errno != 0 {
+		return 0, fmt.Errorf("Failed to lookup the btrfs qgroup of %s: %v", path, errno.Error())
+	}
+	if args.treeid == 0 {
+		return 0, fmt.Errorf("Invalid btrfs qgroup id for %s: 0", path)
+	}
+	return uint64(args.treeid), nil
+}
+
+// subvolQgroupUsage returns the size limit and the usage of the qgroup
+// qgroupid, searched from the filesystem of path.
+func subvolQgroupUsage(path string, qgroupid uint64) (*qgroupUsage, error) {
+	dir, err := openDir(path)
+	if err != nil {
+		return nil, err
+	}
+	defer closeDir(dir)
+
+	// The items are in the little endian order of the disk
+	qu := &qgroupUsage{}
+	info, err := searchQgroupItem(dir, C.BTRFS_QGROUP_INFO_KEY, qgroupid)
+	if err != nil {
+		return nil, err
+	}
+	if len(info) >= 16 {
+		// generation, then referenced bytes
+		qu.usage = binary.LittleEndian.Uint64(info[8:16])
+	}
+	limit, err := searchQgroupItem(dir, C.BTRFS_QGROUP_LIMIT_KEY, qgroupid)
+	if err != nil {
+		return nil, err
+	}
+	if len(limit) >= 16 && binary.LittleEndian.Uint64(limit[0:8])&C.BTRFS_QGROUP_LIMIT_MAX_RFER != 0 {
+		// flags, then the maximum referenced bytes
+		qu.limit = binary.LittleEndian.Uint64(limit[8:16])
+	}
+	return qu, nil
+}
+
+// searchQgroupItem returns the item of the type itemType of the qgroup
+// qgroupid, which has the key (0, itemType, qgroupid) in the quota tree, or
+// nil if there is none.
+func searchQgroupItem(dir *C.DIR, itemType uint32, qgroupid uint64) ([]byte, error) {
+	var args C.struct_btrfs_ioctl_search_args
+	args.key.tree_id = C.BTRFS_QUOTA_TREE_OBJECTID
+	args.key.min_type = C.__u32(itemType)
+	args.key.max_type = C.__u32(itemType)
+	args.key.min_offset = C.__u64(qgroupid)
+	args.key.max_offset = C.__u64(qgroupid)
+	args.key.max_transid = C.__u64(math.MaxUint64)
+	args.key.nr_items = 1
+
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, getDirFd(dir), C.BTRFS_IOC_TREE_SEARCH,
+		uintptr(unsafe.Pointer(&args)))
+	if errno != 0 {
+		return nil, fmt.Errorf("Failed to search the btrfs qgroup %d: %v", qgroupid, errno.Error())
+	}
+	if args.key.nr_items == 0 {
+		return nil, nil
+	}
+
+	// The item follows its search header in the buffer
+	sh := (*C.struct_btrfs_ioctl_search_header)(unsafe.Pointer(&args.buf[0]))
+	off := C.int(unsafe.Sizeof(*sh))
+	return C.GoBytes(unsafe.Pointer(&args.buf[off]), C.int(sh.len)), nil
+}
+
+// subvolQgroupDestroy destroys the qgroup qgroupid of a deleted subvolume,
+// from the filesystem of path.
+func subvolQgroupDestroy(path string, qgroupid uint64) error {
+	dir, err := openDir(path)
+	if err != nil {
+		return err
+	}
+	defer closeDir(dir)
+
+	var args C.struct_btrfs_ioctl_qgroup_create_args
+	args.create = 0
+	args.qgroupid = C.__u64(qgroupid)
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, getDirFd(dir), C.BTRFS_IOC_QGROUP_CREATE,
+		uintptr(unsafe.Pointer(&args)))
+	if errno != 0 {
+		return fmt.Errorf("Failed to destroy the btrfs qgroup %d: %v", qgroupid, errno.Error())
+	}
+	return nil
+}
@@ -807,7 +807,7 @@ func (devices *DeviceSet) createRegisterDevice(hash string) (*devInfo, error) {
 	return info, nil
 }
 
-func (devices *DeviceSet) createRegisterSnapDevice(hash string, baseInfo *devInfo) error {
+func (devices *DeviceSet) createRegisterSnapDevice(hash string, baseInfo *devInfo, size uint64) error {
 	deviceID, err := devices.getNextFreeDeviceID()
 	if err != nil {
 		return err
@@ -842,7 +842,7 @@ func (devices *DeviceSet) createRegisterSnapDevice(hash string, baseInfo *devInf
 		break
 	}
 
-	if _, err := devices.registerDevice(deviceID, hash, baseInfo.Size, devices.OpenTransactionID); err != nil {
+	if _, err := devices.registerDevice(deviceID, hash, size, devices.OpenTransactionID); err != nil {
 		devicemapper.DeleteDevice(devices.getPoolDevName(), deviceID)
 		devices.markDeviceIDFree(deviceID)
 		logrus.Debugf("devmapper: Error registering device: %s", err)
@@ -1717,8 +1717,9 @@ func (devices *DeviceSet) initDevmapper(doInit bool) error {
 	return nil
 }
 
-// AddDevice adds a device and registers in the hash.
-func (devices *DeviceSet) AddDevice(hash, baseHash string) error {
+// AddDevice adds a device and registers in the hash. The size storage option
+// sets the size of the device, which cannot be smaller than the base device.
+func (devices *DeviceSet) AddDevice(hash, baseHash string, storageOpt map[string]string) error {
 	logrus.Debugf("devmapper: AddDevice(hash=%s basehash=%s)", hash, baseHash)
 	defer logrus.Debugf("devmapper: AddDevice(hash=%s basehash=%s) END", hash, baseHash)
 
@@ -1744,10 +1745,103 @@ func (devices *DeviceSet) AddDevice(hash, baseHash string) error {
 		return fmt.Errorf("devmapper: device %s already exists. Deleted=%v", hash, info.Deleted)
 	}
 
-	if err := devices.createRegisterSnapDevice(hash, baseInfo); err != nil {
+	size, err := parseStorageOpt(storageOpt)
+	if err != nil {
+		return err
+	}
+	if size == 0 {
+		size = baseInfo.Size
+	}
+	if size < baseInfo.Size {
+		return fmt.Errorf("devmapper: Container size cannot be smaller than %s", units.HumanSize(float64(baseInfo.Size)))
+	}
+
+	if err := devices.createRegisterSnapDevice(hash, baseInfo, size); err != nil {
+		return err
+	}
+
+	// Grow the filesystem of the snapshot to the size of the device
+	if size > baseInfo.Size {
+		info, err := devices.lookupDevice(hash)
+		if err != nil {
+			return err
+		}
+		if err := devices.growFS(info); err != nil {
+			if err := devices.deleteDevice(info, true); err != nil {
+				logrus.Errorf("devmapper: Error deleting device %s: %s", hash, err)
+			}
+			return err
+		}
+	}
+
+	return nil
+}
+
+// parseStorageOpt returns the size in bytes given by the size storage
+// option, or 0 if there is none.
+func parseStorageOpt(storageOpt map[string]string) (uint64, error) {
+	var size uint64
+	for key, val := range storageOpt {
+		switch strings.ToLower(key) {
+		case "size":
+			s, err := units.RAMInBytes(val)
+			if err != nil {
+				return 0, fmt.Errorf("devmapper: Invalid size %q: %v", val, err)
+			}
+			if s <= 0 {
+				return 0, fmt.Errorf("devmapper: Invalid size %q: must be positive", val)
+			}
+			size = uint64(s)
+		default:
+			return 0, fmt.Errorf("devmapper: Unknown option %s", key)
+		}
+	}
+	return size, nil
+}
+
+// growFS grows the filesystem of the device to the size of the device,
+// mounting it on a temporary directory.
+func (devices *DeviceSet) growFS(info *devInfo) error {
+	if err := devices.activateDeviceIfNeeded(info, false); err != nil {
+		return fmt.Errorf("devmapper: Error activating devmapper device for '%s': %s", info.Hash, err)
+	}
+	defer devices.deactivateDevice(info)
+
+	fstype, err := ProbeFsType(info.DevName())
+	if err != nil {
 		return err
 	}
 
+	mountPoint, err := ioutil.TempDir(devices.root, "growfs-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(mountPoint)
+
+	options := ""
+	if fstype == "xfs" {
+		// XFS needs nouuid or it can't mount filesystems with the same fs
+		options = joinMountOptions(options, "nouuid")
+	}
+	options = joinMountOptions(options, devices.mountOptions)
+
+	if err := mount.Mount(info.DevName(), mountPoint, fstype, options); err != nil {
+		return fmt.Errorf("devmapper: Error mounting '%s' on '%s': %s", info.DevName(), mountPoint, err)
+	}
+	defer syscall.Unmount(mountPoint, syscall.MNT_DETACH)
+
+	var out []byte
+	switch fstype {
+	case "ext4":
+		out, err = exec.Command("resize2fs", info.DevName()).CombinedOutput()
+	case "xfs":
+		out, err = exec.Command("xfs_growfs", mountPoint).CombinedOutput()
+	default:
+		return fmt.Errorf("devmapper: Unsupported filesystem type %s", fstype)
+	}
+	if err != nil {
+		return fmt.Errorf("devmapper: Failed to grow the filesystem of '%s': %v: %s", info.DevName(), err, out)
+	}
 	return nil
 }
 
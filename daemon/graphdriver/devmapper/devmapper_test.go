@@ -108,3 +108,21 @@ func TestDevmapperLockReleasedDeviceDeletion(t *testing.T) {
 	case <-doneChan:
 	}
 }
+
+func TestParseStorageOpt(t *testing.T) {
+	size, err := parseStorageOpt(map[string]string{"size": "20G"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if size != 20*1024*1024*1024 {
+		t.Fatalf("Expected a size of 20G, got %d", size)
+	}
+	if size, err := parseStorageOpt(nil); err != nil || size != 0 {
+		t.Fatalf("Expected no size, got %d: %v", size, err)
+	}
+	for _, opt := range []map[string]string{{"size": "0"}, {"size": "twenty"}, {"dm.basesize": "20G"}} {
+		if _, err := parseStorageOpt(opt); err == nil {
+			t.Fatalf("Expected an error for the storage options %v", opt)
+		}
+	}
+}
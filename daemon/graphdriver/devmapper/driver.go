@@ -48,6 +48,10 @@ func Init(home string, options []string, uidMaps, gidMaps []idtools.IDMap) (grap
 		gidMaps:   gidMaps,
 	}
 
+	// The changes are produced by comparing the filesystems of the device
+	// and of its parent. Reading them from the thin pool metadata would
+	// require reserving a metadata snapshot and the thin_delta tool, which
+	// the daemon does not depend on.
 	return graphdriver.NewNaiveDiffDriver(d, uidMaps, gidMaps), nil
 }
 
@@ -119,7 +123,13 @@ func (d *Driver) Cleanup() error {
 
 // Create adds a device with a given id and the parent.
 func (d *Driver) Create(id, parent, mountLabel string) error {
-	if err := d.DeviceSet.AddDevice(id, parent); err != nil {
+	return d.CreateReadWrite(id, parent, mountLabel, nil)
+}
+
+// CreateReadWrite adds a device with a given id and the parent, with the size
+// given by the size storage option.
+func (d *Driver) CreateReadWrite(id, parent, mountLabel string, storageOpt map[string]string) error {
+	if err := d.DeviceSet.AddDevice(id, parent, storageOpt); err != nil {
 		return err
 	}
 
@@ -92,6 +92,29 @@ type Driver interface {
 	DiffSize(id, parent string) (size int64, err error)
 }
 
+// StorageOptDriver is implemented by the drivers which can create the
+// read-write layer of a container with per container storage options, such
+// as a size quota.
+type StorageOptDriver interface {
+	// CreateReadWrite creates a new, empty, read-write filesystem layer
+	// with the specified id and parent and mountLabel, applying the
+	// storage options.
+	CreateReadWrite(id, parent, mountLabel string, storageOpt map[string]string) error
+}
+
+// CreateReadWrite creates a read-write layer with the driver, applying the
+// storage options if the driver supports them. An error is returned if
+// storage options are given to a driver which does not support them.
+func CreateReadWrite(driver ProtoDriver, id, parent, mountLabel string, storageOpt map[string]string) error {
+	if d, ok := driver.(StorageOptDriver); ok {
+		return d.CreateReadWrite(id, parent, mountLabel, storageOpt)
+	}
+	if len(storageOpt) != 0 {
+		return fmt.Errorf("--storage-opt is not supported by the %s storage driver", driver)
+	}
+	return driver.Create(id, parent, mountLabel)
+}
+
 func init() {
 	drivers = make(map[string]InitFunc)
 }
@@ -111,10 +134,11 @@ func GetDriver(name, home string, options []string, uidMaps, gidMaps []idtools.I
 	if initFunc, exists := drivers[name]; exists {
 		return initFunc(filepath.Join(home, name), options, uidMaps, gidMaps)
 	}
-	if pluginDriver, err := lookupPlugin(name, home, options); err == nil {
+	pluginDriver, err := lookupPlugin(name, home, options)
+	if err == nil {
 		return pluginDriver, nil
 	}
-	logrus.Errorf("Failed to GetDriver graph %s %s", name, home)
+	logrus.Errorf("Failed to GetDriver graph %s %s: %v", name, home, err)
 	return nil, ErrNotSupported
 }
 
@@ -50,6 +50,7 @@ var (
 		"zfs",
 		"devicemapper",
 		"overlay",
+		"overlay2",
 		"vfs",
 	}
 
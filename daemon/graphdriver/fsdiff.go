@@ -41,6 +41,12 @@ func NewNaiveDiffDriver(driver ProtoDriver, uidMaps, gidMaps []idtools.IDMap) Dr
 		gidMaps: gidMaps}
 }
 
+// CreateReadWrite creates a read-write layer with the wrapped driver,
+// applying the storage options if it supports them.
+func (gdw *NaiveDiffDriver) CreateReadWrite(id, parent, mountLabel string, storageOpt map[string]string) error {
+	return CreateReadWrite(gdw.ProtoDriver, id, parent, mountLabel, storageOpt)
+}
+
 // Diff produces an archive of the changes between the specified
 // layer and its parent layer which may be "".
 func (gdw *NaiveDiffDriver) Diff(id, parent string) (arch archive.Archive, err error) {
@@ -0,0 +1,176 @@
+// +build linux
+
+package overlay
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"syscall"
+
+	"github.com/docker/docker/pkg/archive"
+)
+
+// Changes produces the changes of the layer id with respect to its parent
+// from the upper directory of its overlay, which only holds the files of the
+// layer, instead of comparing the whole merged filesystems. This is possible
+// when the layer is overlaid on the root of its parent, or shares the lower
+// directory of its parent, like a container layer and its -init layer do.
+// ErrChangesFallback is returned otherwise.
+func (d *Driver) Changes(id, parent string) ([]archive.Change, error) {
+	if parent == "" {
+		return nil, ErrChangesFallback
+	}
+	dir := d.dir(id)
+	lowerID, err := ioutil.ReadFile(path.Join(dir, "lower-id"))
+	if err != nil {
+		// The layer has a root
+		return nil, ErrChangesFallback
+	}
+	upperDir := path.Join(dir, "upper")
+	lowerDir := path.Join(d.dir(string(lowerID)), "root")
+
+	if string(lowerID) == parent {
+		return archive.OverlayChanges([]string{lowerDir}, upperDir)
+	}
+
+	parentLowerID, err := ioutil.ReadFile(path.Join(d.dir(parent), "lower-id"))
+	if err != nil || string(parentLowerID) != string(lowerID) {
+		return nil, ErrChangesFallback
+	}
+	parentUpperDir := path.Join(d.dir(parent), "upper")
+	changes, err := archive.OverlayChanges([]string{parentUpperDir, lowerDir}, upperDir)
+	if err != nil {
+		return nil, err
+	}
+	return copiedChanges(changes, upperDir, parentUpperDir)
+}
+
+// copiedChanges adjusts the changes of an upper directory which started as a
+// copy of the upper directory of the parent layer: the files left as they
+// were copied are not changes, and the copied files which are gone are
+// deleted, overlay not leaving whiteouts for the files which are not in the
+// lower directory.
+func copiedChanges(changes []archive.Change, upperDir, parentUpperDir string) ([]archive.Change, error) {
+	var adjusted []archive.Change
+	for _, c := range changes {
+		copied, err := sameFile(filepath.Join(upperDir, c.Path), filepath.Join(parentUpperDir, c.Path))
+		if err != nil {
+			return nil, err
+		}
+		if !copied {
+			adjusted = append(adjusted, c)
+		}
+	}
+
+	err := filepath.Walk(parentUpperDir, func(p string, f os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(parentUpperDir, p)
+		if err != nil || rel == "." {
+			return err
+		}
+		if _, err := os.Lstat(filepath.Join(upperDir, rel)); !os.IsNotExist(err) {
+			return err
+		}
+		adjusted = append(adjusted, archive.Change{Path: filepath.Join("/", rel), Kind: archive.ChangeDelete})
+		if f.IsDir() {
+			return filepath.SkipDir
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Sort(changesByPath(adjusted))
+	return adjusted, nil
+}
+
+// sameFile tells whether the files at the paths a and b both exist and have
+// the same metadata and content, like the copies of the upper directories do.
+// The content is compared as a file can be modified without changing its
+// size nor its modification time, and the upper directories of the -init
+// layers only hold a few small files.
+func sameFile(a, b string) (bool, error) {
+	fa, err := os.Lstat(a)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	fb, err := os.Lstat(b)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	sa, sb := fa.Sys().(*syscall.Stat_t), fb.Sys().(*syscall.Stat_t)
+	if fa.Mode() != fb.Mode() || sa.Uid != sb.Uid || sa.Gid != sb.Gid || sa.Rdev != sb.Rdev || !fa.ModTime().Equal(fb.ModTime()) {
+		return false, nil
+	}
+	switch {
+	case fa.Mode().IsRegular():
+		if fa.Size() != fb.Size() {
+			return false, nil
+		}
+		return sameContent(a, b)
+	case fa.Mode()&os.ModeSymlink != 0:
+		ta, err := os.Readlink(a)
+		if err != nil {
+			return false, err
+		}
+		tb, err := os.Readlink(b)
+		if err != nil {
+			return false, err
+		}
+		return ta == tb, nil
+	}
+	return true, nil
+}
+
+// sameContent tells whether the regular files at the paths a and b have the
+// same content.
+func sameContent(a, b string) (bool, error) {
+	fa, err := os.Open(a)
+	if err != nil {
+		return false, err
+	}
+	defer fa.Close()
+	fb, err := os.Open(b)
+	if err != nil {
+		return false, err
+	}
+	defer fb.Close()
+
+	bufa, bufb := make([]byte, 32*1024), make([]byte, 32*1024)
+	for {
+		na, erra := io.ReadFull(fa, bufa)
+		nb, errb := io.ReadFull(fb, bufb)
+		if !bytes.Equal(bufa[:na], bufb[:nb]) {
+			return false, nil
+		}
+		if erra == io.EOF || erra == io.ErrUnexpectedEOF {
+			return errb == erra, nil
+		}
+		if erra != nil {
+			return false, erra
+		}
+		if errb != nil {
+			return false, errb
+		}
+	}
+}
+
+type changesByPath []archive.Change
+
+func (c changesByPath) Less(i, j int) bool { return c[i].Path < c[j].Path }
+func (c changesByPath) Len() int           { return len(c) }
+func (c changesByPath) Swap(i, j int)      { c[j], c[i] = c[i], c[j] }
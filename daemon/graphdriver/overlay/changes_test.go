@@ -0,0 +1,99 @@
+// +build linux
+
+package overlay
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+
+	"github.com/docker/docker/pkg/archive"
+)
+
+func writeTestFile(t *testing.T, pth, content string) {
+	if err := os.MkdirAll(filepath.Dir(pth), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(pth, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestOverlayChanges(t *testing.T) {
+	if os.Getuid() != 0 {
+		t.Skip("Creating whiteouts requires root")
+	}
+	home, err := ioutil.TempDir("", "overlay-changes")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(home)
+	d := &Driver{home: home}
+
+	// An image layer, the -init layer of a container and the container
+	// layer, copied from the -init layer
+	writeTestFile(t, filepath.Join(home, "base", "root", "etc", "passwd"), "root")
+	writeTestFile(t, filepath.Join(home, "base", "root", "bin", "sh"), "sh")
+	writeTestFile(t, filepath.Join(home, "init", "upper", "etc", "hosts"), "localhost")
+	writeTestFile(t, filepath.Join(home, "init", "upper", ".dockerenv"), "")
+	writeTestFile(t, filepath.Join(home, "init", "lower-id"), "base")
+	writeTestFile(t, filepath.Join(home, "ctr", "lower-id"), "base")
+	if err := copyDir(filepath.Join(home, "init", "upper"), filepath.Join(home, "ctr", "upper"), 0); err != nil {
+		t.Fatal(err)
+	}
+
+	upper := filepath.Join(home, "ctr", "upper")
+	writeTestFile(t, filepath.Join(upper, "app", "data"), "data")
+	writeTestFile(t, filepath.Join(upper, "etc", "passwd"), "root,app")
+	if err := os.Remove(filepath.Join(upper, ".dockerenv")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir(filepath.Join(upper, "bin"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := syscall.Mknod(filepath.Join(upper, "bin", "sh"), syscall.S_IFCHR, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	// A file modified without changing its size nor its modification time
+	hosts := filepath.Join(upper, "etc", "hosts")
+	fi, err := os.Stat(hosts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeTestFile(t, hosts, "localhos2")
+	if err := os.Chtimes(hosts, fi.ModTime(), fi.ModTime()); err != nil {
+		t.Fatal(err)
+	}
+
+	changes, err := d.Changes("ctr", "init")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []archive.Change{
+		{Path: "/.dockerenv", Kind: archive.ChangeDelete},
+		{Path: "/app", Kind: archive.ChangeAdd},
+		{Path: "/app/data", Kind: archive.ChangeAdd},
+		{Path: "/bin", Kind: archive.ChangeModify},
+		{Path: "/bin/sh", Kind: archive.ChangeDelete},
+		{Path: "/etc", Kind: archive.ChangeModify},
+		{Path: "/etc/hosts", Kind: archive.ChangeModify},
+		{Path: "/etc/passwd", Kind: archive.ChangeModify},
+	}
+	if len(changes) != len(expected) {
+		t.Fatalf("Expected the changes %v, got %v", expected, changes)
+	}
+	for i, c := range changes {
+		if c != expected[i] {
+			t.Fatalf("Expected the changes %v, got %v", expected, changes)
+		}
+	}
+
+	for _, layer := range [][2]string{{"base", ""}, {"ctr", "base-other"}} {
+		if _, err := d.Changes(layer[0], layer[1]); err != ErrChangesFallback {
+			t.Fatalf("Expected the changes of %s to fall back, got %v", layer[0], err)
+		}
+	}
+}
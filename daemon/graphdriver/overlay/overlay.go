@@ -28,6 +28,8 @@ import (
 var (
 	// ErrApplyDiffFallback is returned to indicate that a normal ApplyDiff is applied as a fallback from Naive diff writer.
 	ErrApplyDiffFallback = fmt.Errorf("Fall back to normal ApplyDiff")
+	// ErrChangesFallback is returned to indicate that the changes are produced by the Naive diff writer as a fallback.
+	ErrChangesFallback = fmt.Errorf("Fall back to normal Changes")
 )
 
 // ApplyDiffProtoDriver wraps the ProtoDriver by extending the interface with ApplyDiff method.
@@ -51,6 +53,26 @@ func NaiveDiffDriverWithApply(driver ApplyDiffProtoDriver, uidMaps, gidMaps []id
 	}
 }
 
+// changesProtoDriver is implemented by the drivers which can produce the
+// changes of a layer on their own.
+type changesProtoDriver interface {
+	// Changes returns the changes of the layer id with respect to its
+	// parent, or ErrChangesFallback if it cannot produce them.
+	Changes(id, parent string) ([]archive.Change, error)
+}
+
+// Changes produces the changes of a layer natively, or with the
+// NaiveDiffDriver as a fallback.
+func (d *naiveDiffDriverWithApply) Changes(id, parent string) ([]archive.Change, error) {
+	if driver, ok := d.applyDiff.(changesProtoDriver); ok {
+		changes, err := driver.Changes(id, parent)
+		if err != ErrChangesFallback {
+			return changes, err
+		}
+	}
+	return d.Driver.Changes(id, parent)
+}
+
 // ApplyDiff creates a diff layer with either the NaiveDiffDriver or with a fallback.
 func (d *naiveDiffDriverWithApply) ApplyDiff(id, parent string, diff archive.Reader) (int64, error) {
 	b, err := d.applyDiff.ApplyDiff(id, parent, diff)
@@ -0,0 +1,579 @@
+// +build linux
+
+package overlay2
+
+import (
+	"bufio"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+
+	"github.com/Sirupsen/logrus"
+
+	"github.com/docker/docker/daemon/graphdriver"
+	"github.com/docker/docker/daemon/graphdriver/quota"
+	"github.com/docker/docker/pkg/archive"
+	"github.com/docker/docker/pkg/idtools"
+	"github.com/docker/docker/pkg/parsers/kernel"
+	"github.com/docker/docker/pkg/stringid"
+	"github.com/docker/go-units"
+
+	"github.com/opencontainers/runc/libcontainer/label"
+)
+
+// This backend uses the overlay union filesystem with multiple lower
+// directories, which needs a 4.0 kernel, instead of the hardlink farms of
+// the overlay driver.
+
+// Each layer has a "diff" directory holding the files of the layer, and a
+// "link" file with the name of the symlink to the "diff" directory in the
+// "l" directory of the driver home. The short names of the symlinks keep the
+// mount data of the deep layers under the page size.
+
+// The layers having a parent also have a "lower" file, listing the links of
+// the parent and of all its own lower layers, topmost first and separated by
+// colons, as well as the "work" and "merged" directories of the overlay.
+// The base layers are not mounted, their "diff" directory is used as is.
+
+const (
+	linkDir   = "l"
+	lowerFile = "lower"
+	idLength  = 26
+	// maxDepth is the number of lower layers a layer can have, which keeps
+	// the mount data relative to the driver home under the page size.
+	maxDepth = 128
+)
+
+type activeMount struct {
+	count   int
+	path    string
+	mounted bool
+}
+
+// Driver contains information about the home directory and the list of
+// active mounts that are created using this driver.
+type Driver struct {
+	home       string
+	sync.Mutex // Protects concurrent modification to active
+	active     map[string]*activeMount
+	uidMaps    []idtools.IDMap
+	gidMaps    []idtools.IDMap
+	naiveDiff  graphdriver.Driver
+	// quotaCtl limits the size of the layers, over xfs with the pquota
+	// mount option only.
+	quotaCtl *quota.Control
+}
+
+var backingFs = "<unknown>"
+
+func init() {
+	graphdriver.Register("overlay2", Init)
+}
+
+// Init returns the overlay2 driver. If overlay filesystem with multiple
+// lower directories is not supported on the host, graphdriver.ErrNotSupported
+// is returned as error. If a overlay filesystem is not supported over a
+// existing filesystem then error graphdriver.ErrIncompatibleFS is returned.
+func Init(home string, options []string, uidMaps, gidMaps []idtools.IDMap) (graphdriver.Driver, error) {
+	if err := supportsOverlay(); err != nil {
+		return nil, graphdriver.ErrNotSupported
+	}
+
+	v, err := kernel.GetKernelVersion()
+	if err != nil {
+		return nil, err
+	}
+	if kernel.CompareKernelVersion(*v, kernel.VersionInfo{Kernel: 4, Major: 0, Minor: 0}) < 0 {
+		logrus.Errorf("'overlay2' requires kernel 4.0 to use multiple lower directories, the kernel is %s.", v)
+		return nil, graphdriver.ErrNotSupported
+	}
+
+	fsMagic, err := graphdriver.GetFSMagic(home)
+	if err != nil {
+		return nil, err
+	}
+	if fsName, ok := graphdriver.FsNames[fsMagic]; ok {
+		backingFs = fsName
+	}
+
+	// check if they are running over btrfs or aufs
+	switch fsMagic {
+	case graphdriver.FsMagicBtrfs:
+		logrus.Error("'overlay2' is not supported over btrfs.")
+		return nil, graphdriver.ErrIncompatibleFS
+	case graphdriver.FsMagicAufs:
+		logrus.Error("'overlay2' is not supported over aufs.")
+		return nil, graphdriver.ErrIncompatibleFS
+	case graphdriver.FsMagicZfs:
+		logrus.Error("'overlay2' is not supported over zfs.")
+		return nil, graphdriver.ErrIncompatibleFS
+	}
+
+	if err := checkOverlayLayers(home); err != nil {
+		return nil, err
+	}
+	if _, err := os.Stat(filepath.Join(filepath.Dir(home), "overlay")); err == nil {
+		logrus.Warnf("The images and containers of the 'overlay' storage driver in %s are not available with 'overlay2'. Use '-s overlay' to use them.", filepath.Dir(home))
+	}
+
+	rootUID, rootGID, err := idtools.GetRootUIDGID(uidMaps, gidMaps)
+	if err != nil {
+		return nil, err
+	}
+	// Create the driver home dir
+	if err := idtools.MkdirAllAs(path.Join(home, linkDir), 0700, rootUID, rootGID); err != nil && !os.IsExist(err) {
+		return nil, err
+	}
+
+	d := &Driver{
+		home:    home,
+		active:  make(map[string]*activeMount),
+		uidMaps: uidMaps,
+		gidMaps: gidMaps,
+	}
+	d.naiveDiff = graphdriver.NewNaiveDiffDriver(d, uidMaps, gidMaps)
+
+	if fsMagic == graphdriver.FsMagicXfs {
+		if d.quotaCtl, err = quota.NewControl(home); err != nil {
+			logrus.Debugf("'overlay2' cannot limit the size of the layers, the project quotas are not enabled on %s: %v", home, err)
+		}
+	}
+
+	return d, nil
+}
+
+// checkOverlayLayers returns an error if the home directory holds layers
+// of the overlay driver, with a "root" directory or a "lower-id" file, for
+// example when the directory of the overlay driver was renamed to migrate it.
+// Their lower layers are hardlink copies which overlay2 cannot use.
+func checkOverlayLayers(home string) error {
+	dirs, err := ioutil.ReadDir(home)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	for _, dir := range dirs {
+		if !dir.IsDir() || dir.Name() == linkDir {
+			continue
+		}
+		for _, name := range []string{"root", "lower-id"} {
+			if _, err := os.Lstat(path.Join(home, dir.Name(), name)); err == nil {
+				return fmt.Errorf("%s contains the layer %s of the 'overlay' storage driver, which 'overlay2' cannot use. Use '-s overlay', or remove the directory to start over with 'overlay2'", home, dir.Name())
+			}
+		}
+	}
+	return nil
+}
+
+func supportsOverlay() error {
+	// We can try to modprobe overlay first before looking at
+	// proc/filesystems for when overlay is supported
+	exec.Command("modprobe", "overlay").Run()
+
+	f, err := os.Open("/proc/filesystems")
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		if s.Text() == "nodev\toverlay" {
+			return nil
+		}
+	}
+	logrus.Error("'overlay' not found as a supported filesystem on this host. Please ensure kernel is new enough and has overlay support loaded.")
+	return graphdriver.ErrNotSupported
+}
+
+func (d *Driver) String() string {
+	return "overlay2"
+}
+
+// Status returns current driver information in a two dimensional string array.
+// Output contains "Backing Filesystem" used in this implementation.
+func (d *Driver) Status() [][2]string {
+	return [][2]string{
+		{"Backing Filesystem", backingFs},
+		{"Supports Size Limit", fmt.Sprintf("%v", d.quotaCtl != nil)},
+	}
+}
+
+// GetMetadata returns meta data about the overlay driver such as LowerDir,
+// UpperDir, WorkDir and MergeDir used to store data.
+func (d *Driver) GetMetadata(id string) (map[string]string, error) {
+	dir := d.dir(id)
+	if _, err := os.Stat(dir); err != nil {
+		return nil, err
+	}
+
+	metadata := map[string]string{
+		"UpperDir": path.Join(dir, "diff"),
+	}
+
+	lowerDirs, err := d.getLowerDirs(id)
+	if err != nil {
+		return nil, err
+	}
+	if len(lowerDirs) > 0 {
+		metadata["LowerDir"] = strings.Join(lowerDirs, ":")
+		metadata["WorkDir"] = path.Join(dir, "work")
+		metadata["MergedDir"] = path.Join(dir, "merged")
+	}
+
+	return metadata, nil
+}
+
+// Cleanup simply returns nil and do not change the existing filesystem.
+// This is required to satisfy the graphdriver.Driver interface.
+func (d *Driver) Cleanup() error {
+	return nil
+}
+
+// Create is used to create the diff, work and merged directories required
+// for overlay fs for a given id, and the link to its diff directory. The
+// lower file lists the links of the parent and of its lower layers.
+func (d *Driver) Create(id, parent, mountLabel string) error {
+	return d.CreateReadWrite(id, parent, mountLabel, nil)
+}
+
+// CreateReadWrite creates the layer id with the size limit given by the size
+// storage option, which requires xfs with the pquota mount option.
+func (d *Driver) CreateReadWrite(id, parent, mountLabel string, storageOpt map[string]string) (retErr error) {
+	size, err := parseStorageOpt(storageOpt)
+	if err != nil {
+		return err
+	}
+	if size != 0 && d.quotaCtl == nil {
+		return fmt.Errorf("--storage-opt size is only supported by 'overlay2' over xfs with the pquota mount option")
+	}
+
+	dir := d.dir(id)
+
+	rootUID, rootGID, err := idtools.GetRootUIDGID(d.uidMaps, d.gidMaps)
+	if err != nil {
+		return err
+	}
+	if err := idtools.MkdirAllAs(path.Dir(dir), 0700, rootUID, rootGID); err != nil {
+		return err
+	}
+	if err := idtools.MkdirAs(dir, 0700, rootUID, rootGID); err != nil {
+		return err
+	}
+
+	defer func() {
+		// Clean up on failure
+		if retErr != nil {
+			d.Remove(id)
+		}
+	}()
+
+	if size != 0 {
+		if err := d.quotaCtl.SetQuota(dir, size); err != nil {
+			return err
+		}
+	}
+
+	if err := idtools.MkdirAs(path.Join(dir, "diff"), 0755, rootUID, rootGID); err != nil {
+		return err
+	}
+
+	lid, err := d.createLink(id)
+	if err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(path.Join(dir, "link"), []byte(lid), 0644); err != nil {
+		return err
+	}
+
+	// Toplevel images are just a "diff" dir
+	if parent == "" {
+		return nil
+	}
+
+	if err := idtools.MkdirAs(path.Join(dir, "work"), 0700, rootUID, rootGID); err != nil {
+		return err
+	}
+	if err := idtools.MkdirAs(path.Join(dir, "merged"), 0700, rootUID, rootGID); err != nil {
+		return err
+	}
+
+	lower, err := d.getLower(parent)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path.Join(dir, lowerFile), []byte(lower), 0666)
+}
+
+// parseStorageOpt returns the size limit in bytes given by the size storage
+// option, or 0 if there is none.
+func parseStorageOpt(storageOpt map[string]string) (uint64, error) {
+	var size uint64
+	for key, val := range storageOpt {
+		switch strings.ToLower(key) {
+		case "size":
+			s, err := units.RAMInBytes(val)
+			if err != nil {
+				return 0, fmt.Errorf("Invalid size %q: %v", val, err)
+			}
+			if s <= 0 {
+				return 0, fmt.Errorf("Invalid size %q: must be positive", val)
+			}
+			size = uint64(s)
+		default:
+			return 0, fmt.Errorf("Unknown option %s", key)
+		}
+	}
+	return size, nil
+}
+
+// createLink creates the link to the diff directory of the layer id, with a
+// random name, and returns the name.
+func (d *Driver) createLink(id string) (string, error) {
+	for {
+		lid := stringid.GenerateRandomID()[:idLength]
+		err := os.Symlink(path.Join("..", id, "diff"), path.Join(d.home, linkDir, lid))
+		if err == nil {
+			return lid, nil
+		}
+		if !os.IsExist(err) {
+			return "", err
+		}
+	}
+}
+
+// getLower returns the lower file of the children of the layer parent: the
+// link of parent followed by its own lower layers.
+func (d *Driver) getLower(parent string) (string, error) {
+	parentDir := d.dir(parent)
+
+	// Ensure parent exists
+	if _, err := os.Lstat(parentDir); err != nil {
+		return "", err
+	}
+
+	parentLink, err := ioutil.ReadFile(path.Join(parentDir, "link"))
+	if err != nil {
+		return "", err
+	}
+	lowers := []string{path.Join(linkDir, string(parentLink))}
+
+	parentLower, err := ioutil.ReadFile(path.Join(parentDir, lowerFile))
+	if err == nil {
+		parentLowers := strings.Split(string(parentLower), ":")
+		lowers = append(lowers, parentLowers...)
+	} else if !os.IsNotExist(err) {
+		return "", err
+	}
+	if len(lowers) > maxDepth {
+		return "", fmt.Errorf("max depth of %d lower layers exceeded", maxDepth)
+	}
+	return strings.Join(lowers, ":"), nil
+}
+
+// getLowerDirs returns the absolute paths of the links to the lower layers
+// of the layer id, topmost first, or none for a base layer.
+func (d *Driver) getLowerDirs(id string) ([]string, error) {
+	lower, err := ioutil.ReadFile(path.Join(d.dir(id), lowerFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var lowerDirs []string
+	for _, l := range strings.Split(string(lower), ":") {
+		lowerDirs = append(lowerDirs, path.Join(d.home, l))
+	}
+	return lowerDirs, nil
+}
+
+func (d *Driver) dir(id string) string {
+	return path.Join(d.home, id)
+}
+
+// Remove cleans the directories that are created for this id, and its link.
+func (d *Driver) Remove(id string) error {
+	dir := d.dir(id)
+	lid, err := ioutil.ReadFile(path.Join(dir, "link"))
+	if err == nil && len(lid) > 0 {
+		if err := os.RemoveAll(path.Join(d.home, linkDir, string(lid))); err != nil {
+			logrus.Debugf("Failed to remove link: %v", err)
+		}
+	}
+
+	if err := os.RemoveAll(dir); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// Get creates and mounts the required file system for the given id and returns the mount path.
+func (d *Driver) Get(id string, mountLabel string) (string, error) {
+	// Protect the d.active from concurrent access
+	d.Lock()
+	defer d.Unlock()
+
+	mount := d.active[id]
+	if mount != nil {
+		mount.count++
+		return mount.path, nil
+	}
+
+	mount = &activeMount{count: 1}
+
+	dir := d.dir(id)
+	if _, err := os.Stat(dir); err != nil {
+		return "", err
+	}
+
+	diffDir := path.Join(dir, "diff")
+	lower, err := ioutil.ReadFile(path.Join(dir, lowerFile))
+	if err != nil {
+		if !os.IsNotExist(err) {
+			return "", err
+		}
+		// If id has no lower layers, just return its diff directory
+		mount.path = diffDir
+		d.active[id] = mount
+		return mount.path, nil
+	}
+
+	var lowerDirs []string
+	for _, l := range strings.Split(string(lower), ":") {
+		lowerDirs = append(lowerDirs, path.Join(d.home, l))
+	}
+	workDir := path.Join(dir, "work")
+	mergedDir := path.Join(dir, "merged")
+
+	opts := fmt.Sprintf("lowerdir=%s,upperdir=%s,workdir=%s", strings.Join(lowerDirs, ":"), diffDir, workDir)
+	mountData := label.FormatMountLabel(opts, mountLabel)
+	mountTarget := mergedDir
+	mountFunc := syscall.Mount
+
+	// Use the paths relative to the driver home if the mount data is too
+	// long for the kernel, which takes up to a page
+	pageSize := syscall.Getpagesize()
+	if len(mountData) > pageSize {
+		opts = fmt.Sprintf("lowerdir=%s,upperdir=%s,workdir=%s", string(lower), path.Join(id, "diff"), path.Join(id, "work"))
+		mountData = label.FormatMountLabel(opts, mountLabel)
+		if len(mountData) > pageSize {
+			return "", fmt.Errorf("cannot mount layer, mount label too large %d", len(mountData))
+		}
+		mountTarget = path.Join(id, "merged")
+		mountFunc = func(source string, target string, mType string, flags uintptr, label string) error {
+			return mountFrom(d.home, source, target, mType, label)
+		}
+	}
+
+	rootUID, rootGID, err := idtools.GetRootUIDGID(d.uidMaps, d.gidMaps)
+	if err != nil {
+		return "", err
+	}
+	if err := mountFunc("overlay", mountTarget, "overlay", 0, mountData); err != nil {
+		return "", fmt.Errorf("error creating overlay mount to %s: %v", mergedDir, err)
+	}
+	// chown "workdir/work" to the remapped root UID/GID. Overlay fs inside a
+	// user namespace requires this to move a directory from lower to upper.
+	if err := os.Chown(path.Join(workDir, "work"), rootUID, rootGID); err != nil {
+		if err := syscall.Unmount(mergedDir, 0); err != nil {
+			logrus.Warnf("Failed to unmount %s overlay: %v", id, err)
+		}
+		return "", err
+	}
+	mount.path = mergedDir
+	mount.mounted = true
+	d.active[id] = mount
+
+	return mount.path, nil
+}
+
+// Put unmounts the mount path created for the give id.
+func (d *Driver) Put(id string) error {
+	// Protect the d.active from concurrent access
+	d.Lock()
+	defer d.Unlock()
+
+	mount := d.active[id]
+	if mount == nil {
+		logrus.Debugf("Put on a non-mounted device %s", id)
+		// but it might be still here
+		if d.Exists(id) {
+			mergedDir := path.Join(d.dir(id), "merged")
+			err := syscall.Unmount(mergedDir, 0)
+			if err != nil {
+				logrus.Debugf("Failed to unmount %s overlay: %v", id, err)
+			}
+		}
+		return nil
+	}
+
+	mount.count--
+	if mount.count > 0 {
+		return nil
+	}
+
+	defer delete(d.active, id)
+	if mount.mounted {
+		err := syscall.Unmount(mount.path, 0)
+		if err != nil {
+			logrus.Debugf("Failed to unmount %s overlay: %v", id, err)
+		}
+		return err
+	}
+	return nil
+}
+
+// Exists checks to see if the id is already mounted.
+func (d *Driver) Exists(id string) bool {
+	_, err := os.Stat(d.dir(id))
+	return err == nil
+}
+
+// Changes produces the changes of the layer id with respect to its parent
+// from its diff directory, when parent is its topmost lower layer. The
+// changes are produced by comparing the mounted layers otherwise.
+func (d *Driver) Changes(id, parent string) ([]archive.Change, error) {
+	if parent == "" {
+		return d.naiveDiff.Changes(id, parent)
+	}
+	lowerDirs, err := d.getLowerDirs(id)
+	if err != nil {
+		return nil, err
+	}
+	parentLink, err := ioutil.ReadFile(path.Join(d.dir(parent), "link"))
+	if err != nil {
+		return nil, err
+	}
+	if len(lowerDirs) == 0 || lowerDirs[0] != path.Join(d.home, linkDir, string(parentLink)) {
+		return d.naiveDiff.Changes(id, parent)
+	}
+	return archive.OverlayChanges(lowerDirs, path.Join(d.dir(id), "diff"))
+}
+
+// Diff produces an archive of the changes between the specified layer and
+// its parent layer which may be "".
+func (d *Driver) Diff(id, parent string) (archive.Archive, error) {
+	return d.naiveDiff.Diff(id, parent)
+}
+
+// ApplyDiff applies the changes in the new layer, on the mounted overlay so
+// that the deleted files are whiteouts of the lower layers.
+func (d *Driver) ApplyDiff(id, parent string, diff archive.Reader) (int64, error) {
+	return d.naiveDiff.ApplyDiff(id, parent, diff)
+}
+
+// DiffSize calculates the changes between the specified id
+// and its parent and returns the size in bytes of the changes
+// relative to its base filesystem directory.
+func (d *Driver) DiffSize(id, parent string) (int64, error) {
+	return d.naiveDiff.DiffSize(id, parent)
+}
@@ -0,0 +1,147 @@
+// +build linux
+
+package overlay2
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/docker/docker/daemon/graphdriver/graphtest"
+	"github.com/docker/docker/pkg/archive"
+	"github.com/docker/docker/pkg/reexec"
+)
+
+func init() {
+	reexec.Init()
+}
+
+// This avoids creating a new driver for each test if all tests are run
+// Make sure to put new tests between TestOverlaySetup and TestOverlayTeardown
+func TestOverlaySetup(t *testing.T) {
+	graphtest.GetDriver(t, "overlay2")
+}
+
+func TestOverlayCreateEmpty(t *testing.T) {
+	graphtest.DriverTestCreateEmpty(t, "overlay2")
+}
+
+func TestOverlayCreateBase(t *testing.T) {
+	graphtest.DriverTestCreateBase(t, "overlay2")
+}
+
+func TestOverlayCreateSnap(t *testing.T) {
+	graphtest.DriverTestCreateSnap(t, "overlay2")
+}
+
+func TestOverlayTeardown(t *testing.T) {
+	graphtest.PutDriver(t)
+}
+
+func TestOverlayLowerLayers(t *testing.T) {
+	if os.Getuid() != 0 {
+		t.Skip("Creating the layers requires root")
+	}
+	home, err := ioutil.TempDir("", "overlay2-lower")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(home)
+	if err := os.Mkdir(path.Join(home, linkDir), 0700); err != nil {
+		t.Fatal(err)
+	}
+	d := &Driver{home: home}
+
+	for _, layer := range [][2]string{{"base", ""}, {"app", "base"}, {"ctr", "app"}} {
+		if err := d.Create(layer[0], layer[1], ""); err != nil {
+			t.Fatal(err)
+		}
+	}
+	link := func(id string) string {
+		lid, err := ioutil.ReadFile(path.Join(home, id, "link"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return path.Join(home, linkDir, string(lid))
+	}
+	lowerDirs, err := d.getLowerDirs("ctr")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := []string{link("app"), link("base")}; strings.Join(lowerDirs, ":") != strings.Join(expected, ":") {
+		t.Fatalf("Expected the lower layers %v, got %v", expected, lowerDirs)
+	}
+
+	if err := ioutil.WriteFile(path.Join(home, "base", "diff", "base"), []byte("base"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(path.Join(home, "ctr", "diff", "ctr"), []byte("ctr"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(path.Join(home, "ctr", "diff", "base"), []byte("changed"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	changes, err := d.Changes("ctr", "app")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []archive.Change{
+		{Path: "/base", Kind: archive.ChangeModify},
+		{Path: "/ctr", Kind: archive.ChangeAdd},
+	}
+	if len(changes) != len(expected) || changes[0] != expected[0] || changes[1] != expected[1] {
+		t.Fatalf("Expected the changes %v, got %v", expected, changes)
+	}
+
+	if err := d.Remove("app"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Lstat(lowerDirs[0]); !os.IsNotExist(err) {
+		t.Fatalf("Expected the link of the removed layer to be removed, got %v", err)
+	}
+}
+
+func TestCheckOverlayLayers(t *testing.T) {
+	home, err := ioutil.TempDir("", "overlay2-check")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(home)
+
+	if err := os.MkdirAll(path.Join(home, linkDir), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(path.Join(home, "layer", "diff"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := checkOverlayLayers(home); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.MkdirAll(path.Join(home, "image", "root"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := checkOverlayLayers(home); err == nil {
+		t.Fatal("Expected an error for the layer of the overlay driver")
+	}
+}
+
+func TestParseStorageOpt(t *testing.T) {
+	size, err := parseStorageOpt(map[string]string{"size": "512M"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if size != 512*1024*1024 {
+		t.Fatalf("Expected a size of 512M, got %d", size)
+	}
+	if size, err := parseStorageOpt(nil); err != nil || size != 0 {
+		t.Fatalf("Expected no size, got %d: %v", size, err)
+	}
+	for _, opt := range []map[string]string{{"size": "-512M"}, {"size": "half"}, {"inodes": "1000"}} {
+		if _, err := parseStorageOpt(opt); err == nil {
+			t.Fatalf("Expected an error for the storage options %v", opt)
+		}
+	}
+}
@@ -1,7 +0,0 @@
-// +build !experimental
-
-package graphdriver
-
-func lookupPlugin(name, home string, opts []string) (Driver, error) {
-	return nil, ErrNotSupported
-}
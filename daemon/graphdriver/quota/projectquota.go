@@ -0,0 +1,255 @@
+// +build linux
+
+// Package quota sets the size limits of the directories of a graph driver
+// with the project quotas of xfs. Each directory is assigned a project ID,
+// inherited by its files and subdirectories, and the limit is set on the
+// project. The filesystem must be mounted with the pquota option.
+package quota
+
+/*
+#include <stdlib.h>
+#include <sys/quota.h>
+#include <linux/fs.h>
+#include <linux/dqblk_xfs.h>
+
+#ifndef FS_XFLAG_PROJINHERIT
+struct fsxattr {
+	__u32		fsx_xflags;
+	__u32		fsx_extsize;
+	__u32		fsx_nextents;
+	__u32		fsx_projid;
+	unsigned char	fsx_unused[12];
+};
+#define FS_XFLAG_PROJINHERIT	0x00000200
+#endif
+#ifndef FS_IOC_FSGETXATTR
+#define FS_IOC_FSGETXATTR		_IOR ('X', 31, struct fsxattr)
+#endif
+#ifndef FS_IOC_FSSETXATTR
+#define FS_IOC_FSSETXATTR		_IOW ('X', 32, struct fsxattr)
+#endif
+
+#ifndef PRJQUOTA
+#define PRJQUOTA	2
+#endif
+#ifndef XFS_PROJ_QUOTA
+#define XFS_PROJ_QUOTA	2
+#endif
+#ifndef Q_XSETPQLIM
+#define Q_XSETPQLIM QCMD(Q_XSETQLIM, PRJQUOTA)
+#endif
+#ifndef Q_XGETPQUOTA
+#define Q_XGETPQUOTA QCMD(Q_XGETQUOTA, PRJQUOTA)
+#endif
+*/
+import "C"
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"sync"
+	"syscall"
+	"unsafe"
+)
+
+// backingFsBlockDev is the name of the block device node of the filesystem,
+// created in the base directory, which quotactl requires.
+const backingFsBlockDev = "backingFsBlockDev"
+
+// Control sets the size limits of the directories under a base directory.
+type Control struct {
+	sync.Mutex
+	blockDev      string
+	nextProjectID uint32
+	projectIDs    map[string]uint32
+}
+
+// NewControl returns a Control for the directories under basePath, or an
+// error if the filesystem does not support the project quotas.
+//
+// The project IDs above the one of basePath are used, so that several base
+// directories can share a filesystem if they have distinct project IDs, and
+// the IDs of the existing directories are not reused.
+func NewControl(basePath string) (*Control, error) {
+	minProjectID, err := getProjectID(basePath)
+	if err != nil {
+		return nil, err
+	}
+	minProjectID++
+
+	blockDev, err := makeBackingFsDev(basePath)
+	if err != nil {
+		return nil, err
+	}
+
+	// Check that the filesystem supports the project quotas by setting an
+	// unlimited quota on the first project ID
+	if err := setProjectQuota(blockDev, minProjectID, 0); err != nil {
+		return nil, err
+	}
+
+	q := &Control{
+		blockDev:      blockDev,
+		nextProjectID: minProjectID + 1,
+		projectIDs:    make(map[string]uint32),
+	}
+	if err := q.loadProjectIDs(basePath); err != nil {
+		return nil, err
+	}
+	return q, nil
+}
+
+// SetQuota limits the size of the directory targetPath to size bytes,
+// assigning it a project ID if it has none.
+func (q *Control) SetQuota(targetPath string, size uint64) error {
+	q.Lock()
+	projectID, ok := q.projectIDs[targetPath]
+	if !ok {
+		projectID = q.nextProjectID
+		if err := setProjectID(targetPath, projectID); err != nil {
+			q.Unlock()
+			return err
+		}
+		q.projectIDs[targetPath] = projectID
+		q.nextProjectID++
+	}
+	q.Unlock()
+
+	return setProjectQuota(q.blockDev, projectID, size)
+}
+
+// GetQuota returns the size limit in bytes of the directory targetPath.
+func (q *Control) GetQuota(targetPath string) (uint64, error) {
+	q.Lock()
+	projectID, ok := q.projectIDs[targetPath]
+	q.Unlock()
+	if !ok {
+		return 0, fmt.Errorf("quota not found for path: %s", targetPath)
+	}
+
+	var d C.fs_disk_quota_t
+	cs := C.CString(q.blockDev)
+	defer C.free(unsafe.Pointer(cs))
+
+	_, _, errno := syscall.Syscall6(syscall.SYS_QUOTACTL, C.Q_XGETPQUOTA,
+		uintptr(unsafe.Pointer(cs)), uintptr(C.__u32(projectID)),
+		uintptr(unsafe.Pointer(&d)), 0, 0)
+	if errno != 0 {
+		return 0, fmt.Errorf("Failed to get the quota of project %d on %s: %v", projectID, q.blockDev, errno.Error())
+	}
+	return uint64(d.d_blk_hardlimit) * 512, nil
+}
+
+// setProjectQuota sets the size limit of the project to size bytes, or no
+// limit if size is 0.
+func setProjectQuota(blockDev string, projectID uint32, size uint64) error {
+	var d C.fs_disk_quota_t
+	d.d_version = C.FS_DQUOT_VERSION
+	d.d_id = C.__u32(projectID)
+	d.d_flags = C.XFS_PROJ_QUOTA
+	d.d_fieldmask = C.FS_DQ_BHARD | C.FS_DQ_BSOFT
+	// The limits are in basic blocks of 512 bytes
+	d.d_blk_hardlimit = C.__u64(size / 512)
+	d.d_blk_softlimit = d.d_blk_hardlimit
+
+	cs := C.CString(blockDev)
+	defer C.free(unsafe.Pointer(cs))
+
+	_, _, errno := syscall.Syscall6(syscall.SYS_QUOTACTL, C.Q_XSETPQLIM,
+		uintptr(unsafe.Pointer(cs)), uintptr(d.d_id),
+		uintptr(unsafe.Pointer(&d)), 0, 0)
+	if errno != 0 {
+		return fmt.Errorf("Failed to set the quota of project %d on %s: %v", projectID, blockDev, errno.Error())
+	}
+	return nil
+}
+
+// getProjectID returns the project ID of the directory targetPath.
+func getProjectID(targetPath string) (uint32, error) {
+	dir, err := os.Open(targetPath)
+	if err != nil {
+		return 0, err
+	}
+	defer dir.Close()
+
+	var fsx C.struct_fsxattr
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, dir.Fd(), C.FS_IOC_FSGETXATTR,
+		uintptr(unsafe.Pointer(&fsx)))
+	if errno != 0 {
+		return 0, fmt.Errorf("Failed to get the project ID of %s: %v", targetPath, errno.Error())
+	}
+	return uint32(fsx.fsx_projid), nil
+}
+
+// setProjectID sets the project ID of the directory targetPath, inherited by
+// the files and directories created in it.
+func setProjectID(targetPath string, projectID uint32) error {
+	dir, err := os.Open(targetPath)
+	if err != nil {
+		return err
+	}
+	defer dir.Close()
+
+	var fsx C.struct_fsxattr
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, dir.Fd(), C.FS_IOC_FSGETXATTR,
+		uintptr(unsafe.Pointer(&fsx)))
+	if errno != 0 {
+		return fmt.Errorf("Failed to get the project ID of %s: %v", targetPath, errno.Error())
+	}
+	fsx.fsx_projid = C.__u32(projectID)
+	fsx.fsx_xflags |= C.FS_XFLAG_PROJINHERIT
+	_, _, errno = syscall.Syscall(syscall.SYS_IOCTL, dir.Fd(), C.FS_IOC_FSSETXATTR,
+		uintptr(unsafe.Pointer(&fsx)))
+	if errno != 0 {
+		return fmt.Errorf("Failed to set the project ID of %s: %v", targetPath, errno.Error())
+	}
+	return nil
+}
+
+// loadProjectIDs records the project IDs of the directories under basePath,
+// and sets the next project ID above them.
+func (q *Control) loadProjectIDs(basePath string) error {
+	files, err := ioutil.ReadDir(basePath)
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		if !file.IsDir() {
+			continue
+		}
+		p := path.Join(basePath, file.Name())
+		projectID, err := getProjectID(p)
+		if err != nil {
+			return err
+		}
+		if projectID == 0 {
+			continue
+		}
+		q.projectIDs[p] = projectID
+		if projectID >= q.nextProjectID {
+			q.nextProjectID = projectID + 1
+		}
+	}
+	return nil
+}
+
+// makeBackingFsDev creates the block device node of the filesystem of home
+// in home, and returns its path.
+func makeBackingFsDev(home string) (string, error) {
+	var stat syscall.Stat_t
+	if err := syscall.Stat(home, &stat); err != nil {
+		return "", err
+	}
+
+	blockDev := path.Join(home, backingFsBlockDev)
+	// Re-create the node, the device of the filesystem can change
+	if err := os.Remove(blockDev); err != nil && !os.IsNotExist(err) {
+		return "", err
+	}
+	if err := syscall.Mknod(blockDev, syscall.S_IFBLK|0600, int(stat.Dev)); err != nil {
+		return "", fmt.Errorf("Failed to create the block device node %s: %v", blockDev, err)
+	}
+	return blockDev, nil
+}
@@ -18,6 +18,7 @@ import (
 	"github.com/docker/docker/pkg/idtools"
 	"github.com/docker/docker/pkg/mount"
 	"github.com/docker/docker/pkg/parsers"
+	"github.com/docker/go-units"
 	zfs "github.com/mistifyio/go-zfs"
 	"github.com/opencontainers/runc/libcontainer/label"
 )
@@ -205,9 +206,20 @@ func (d *Driver) Status() [][2]string {
 	}
 }
 
-// GetMetadata returns image/container metadata related to graph driver
+// GetMetadata returns image/container metadata related to graph driver,
+// the dataset and its quota if it has one.
 func (d *Driver) GetMetadata(id string) (map[string]string, error) {
-	return nil, nil
+	name := d.zfsPath(id)
+	metadata := map[string]string{"Dataset": name}
+
+	dataset := zfs.Dataset{Name: name}
+	quota, err := dataset.GetProperty("quota")
+	if err != nil {
+		logrus.Debugf("[zfs] failed to get the quota of %s: %v", name, err)
+	} else if quota != "" && quota != "0" && quota != "none" {
+		metadata["Quota"] = quota
+	}
+	return metadata, nil
 }
 
 func (d *Driver) cloneFilesystem(name, parentName string) error {
@@ -242,7 +254,19 @@ func (d *Driver) mountPath(id string) string {
 
 // Create prepares the dataset and filesystem for the ZFS driver for the given id under the parent.
 func (d *Driver) Create(id string, parent string, mountLabel string) error {
-	err := d.create(id, parent)
+	return d.CreateReadWrite(id, parent, mountLabel, nil)
+}
+
+// CreateReadWrite prepares the dataset and filesystem for the ZFS driver for
+// the given id under the parent, with the quota given by the size storage
+// option.
+func (d *Driver) CreateReadWrite(id, parent, mountLabel string, storageOpt map[string]string) error {
+	quota, err := parseStorageOpt(storageOpt)
+	if err != nil {
+		return err
+	}
+
+	err = d.create(id, parent, quota)
 	if err == nil {
 		return nil
 	}
@@ -261,10 +285,10 @@ func (d *Driver) Create(id string, parent string, mountLabel string) error {
 	}
 
 	// retry
-	return d.create(id, parent)
+	return d.create(id, parent, quota)
 }
 
-func (d *Driver) create(id, parent string) error {
+func (d *Driver) create(id, parent string, quota int64) error {
 	name := d.zfsPath(id)
 	if parent == "" {
 		mountoptions := map[string]string{"mountpoint": "legacy"}
@@ -274,9 +298,46 @@ func (d *Driver) create(id, parent string) error {
 			d.filesystemsCache[fs.Name] = true
 			d.Unlock()
 		}
+		if err != nil {
+			return err
+		}
+		return setQuota(name, quota)
+	}
+	if err := d.cloneFilesystem(name, d.zfsPath(parent)); err != nil {
 		return err
 	}
-	return d.cloneFilesystem(name, d.zfsPath(parent))
+	return setQuota(name, quota)
+}
+
+// parseStorageOpt returns the quota in bytes given by the size storage
+// option, or 0 if there is none.
+func parseStorageOpt(storageOpt map[string]string) (int64, error) {
+	var quota int64
+	for key, val := range storageOpt {
+		switch strings.ToLower(key) {
+		case "size":
+			size, err := units.RAMInBytes(val)
+			if err != nil {
+				return 0, fmt.Errorf("Invalid size %q: %v", val, err)
+			}
+			if size <= 0 {
+				return 0, fmt.Errorf("Invalid size %q: must be positive", val)
+			}
+			quota = size
+		default:
+			return 0, fmt.Errorf("Unknown option %s", key)
+		}
+	}
+	return quota, nil
+}
+
+// setQuota sets the quota of the dataset name, if quota is not 0.
+func setQuota(name string, quota int64) error {
+	if quota == 0 {
+		return nil
+	}
+	dataset := zfs.Dataset{Name: name}
+	return dataset.SetProperty("quota", strconv.FormatInt(quota, 10))
 }
 
 // Remove deletes the dataset, filesystem and the cache for the given id.
@@ -29,3 +29,21 @@ func TestZfsCreateSnap(t *testing.T) {
 func TestZfsTeardown(t *testing.T) {
 	graphtest.PutDriver(t)
 }
+
+func TestParseStorageOpt(t *testing.T) {
+	quota, err := parseStorageOpt(map[string]string{"size": "1G"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if quota != 1024*1024*1024 {
+		t.Fatalf("Expected a quota of 1G, got %d", quota)
+	}
+	if quota, err := parseStorageOpt(nil); err != nil || quota != 0 {
+		t.Fatalf("Expected no quota, got %d: %v", quota, err)
+	}
+	for _, opt := range []map[string]string{{"size": "big"}, {"size": "0"}, {"inodes": "100"}} {
+		if _, err := parseStorageOpt(opt); err == nil {
+			t.Fatalf("Expected an error for the storage options %v", opt)
+		}
+	}
+}
@@ -0,0 +1,314 @@
+package daemon
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/strslice"
+	"github.com/docker/docker/container"
+	"github.com/docker/docker/daemon/exec"
+	"github.com/docker/docker/daemon/execdriver"
+	"golang.org/x/net/context"
+)
+
+const (
+	// Longest healthcheck probe output message to store. Longer messages will be truncated.
+	maxOutputLen = 4096
+
+	// Default interval between probe runs (from the end of the first to the start of the second).
+	// Also the time before the first probe.
+	defaultProbeInterval = 30 * time.Second
+
+	// The maximum length of time a single probe run should take. If the probe takes longer
+	// than this, the check is considered to have failed.
+	defaultProbeTimeout = 30 * time.Second
+
+	// Default number of consecutive failures of the health check
+	// for the container to be considered unhealthy.
+	defaultProbeRetries = 3
+
+	// Maximum number of entries to record
+	maxLogEntries = 5
+)
+
+const (
+	// Exit status codes that can be returned by the probe command.
+	exitStatusHealthy = 0 // Container is healthy
+)
+
+// probe implementations know how to run a particular type of probe.
+type probe interface {
+	// Perform one run of the check. Returns the exit code and an optional
+	// short diagnostic string.
+	run(context.Context, *Daemon, *container.Container) (*types.HealthcheckResult, error)
+}
+
+// cmdProbe implements the "CMD" probe type.
+type cmdProbe struct {
+	// Run the command with the system's default shell instead of execing it directly.
+	shell bool
+}
+
+// exec the healthcheck command in the container.
+// Returns the exit code and probe output (if any)
+func (p *cmdProbe) run(ctx context.Context, d *Daemon, c *container.Container) (*types.HealthcheckResult, error) {
+	cmdSlice := strslice.New(c.Config.Healthcheck.Test[1:]...)
+	if p.shell {
+		cmdSlice = strslice.New(append(getShell(), c.Config.Healthcheck.Test[1:]...)...)
+	}
+	entrypoint, args := d.getEntrypointAndArgs(strslice.New(), cmdSlice)
+
+	processConfig := &execdriver.ProcessConfig{
+		CommonProcessConfig: execdriver.CommonProcessConfig{
+			Entrypoint: entrypoint,
+			Arguments:  args,
+		},
+	}
+	setPlatformSpecificExecProcessConfig(&types.ExecConfig{}, c, processConfig)
+
+	execConfig := exec.NewConfig()
+	execConfig.OpenStdout = true
+	execConfig.OpenStderr = true
+	execConfig.ProcessConfig = processConfig
+	execConfig.ContainerID = c.ID
+	execConfig.NewNopInputPipe()
+
+	d.registerExecCommand(c, execConfig)
+	defer d.unregisterExecCommand(c, execConfig)
+	d.LogContainerEvent(c, "exec_start: "+entrypoint+" "+strings.Join(args, " "))
+
+	output := &limitedBuffer{}
+	pipes := execdriver.NewPipes(nil, output, output, false)
+
+	started := make(chan int, 1)
+	callback := func(processConfig *execdriver.ProcessConfig, pid int, chOOM <-chan struct{}) error {
+		started <- pid
+		return nil
+	}
+
+	start := time.Now()
+	execErr := make(chan error, 1)
+	go func() {
+		_, err := d.Exec(c, execConfig, pipes, callback)
+		execErr <- err
+	}()
+
+	select {
+	case err := <-execErr:
+		if err != nil {
+			return nil, err
+		}
+	case <-ctx.Done():
+		select {
+		case pid := <-started:
+			if p, err := os.FindProcess(pid); err == nil {
+				p.Kill()
+			}
+		default:
+		}
+		return nil, fmt.Errorf("Health check exceeded timeout (%v)", time.Now().Sub(start))
+	}
+
+	return &types.HealthcheckResult{
+		Start:    start,
+		End:      time.Now(),
+		ExitCode: execConfig.ExitCode,
+		Output:   output.String(),
+	}, nil
+}
+
+// Update the container's Status.Health struct based on the latest probe's result.
+func handleProbeResult(d *Daemon, c *container.Container, result *types.HealthcheckResult) {
+	c.Lock()
+	defer c.Unlock()
+
+	retries := c.Config.Healthcheck.Retries
+	if retries <= 0 {
+		retries = defaultProbeRetries
+	}
+
+	h := c.State.Health
+	oldStatus := h.Status
+
+	if len(h.Log) >= maxLogEntries {
+		h.Log = append(h.Log[len(h.Log)+1-maxLogEntries:], result)
+	} else {
+		h.Log = append(h.Log, result)
+	}
+
+	if result.ExitCode == exitStatusHealthy {
+		h.FailingStreak = 0
+		h.Status = types.Healthy
+	} else {
+		// Failure (including invalid exit code)
+		h.FailingStreak++
+		if h.FailingStreak >= retries {
+			h.Status = types.Unhealthy
+		}
+		// Else we're starting or healthy. Stay in that state.
+	}
+
+	if err := c.ToDisk(); err != nil {
+		logrus.Warnf("Error saving container state to disk: %v", err)
+	}
+
+	if oldStatus != h.Status {
+		d.LogContainerEvent(c, "health_status: "+h.Status)
+	}
+}
+
+// Run the container's monitoring thread until notified via "stop".
+// There is never more than one monitor thread running per container at a time.
+func monitor(d *Daemon, c *container.Container, stop chan struct{}, probe probe) {
+	probeTimeout := timeoutWithDefault(c.Config.Healthcheck.Timeout, defaultProbeTimeout)
+	probeInterval := timeoutWithDefault(c.Config.Healthcheck.Interval, defaultProbeInterval)
+	for {
+		select {
+		case <-stop:
+			logrus.Debugf("Stop healthcheck monitoring for container %s (received while idle)", c.ID)
+			return
+		case <-time.After(probeInterval):
+			logrus.Debugf("Running health check for container %s ...", c.ID)
+			startTime := time.Now()
+			ctx, cancelProbe := context.WithTimeout(context.Background(), probeTimeout)
+			result, err := probe.run(ctx, d, c)
+			cancelProbe()
+			if err != nil {
+				logrus.Warnf("Health check for container %s error: %v", c.ID, err)
+				result = &types.HealthcheckResult{
+					ExitCode: -1,
+					Output:   err.Error(),
+					Start:    startTime,
+					End:      time.Now(),
+				}
+			}
+			select {
+			case <-stop:
+				logrus.Debugf("Stop healthcheck monitoring for container %s (received while probing)", c.ID)
+				return
+			default:
+			}
+			handleProbeResult(d, c, result)
+		}
+	}
+}
+
+// Get a suitable probe implementation for the container's healthcheck configuration.
+// Nil will be returned if no healthcheck was configured or NONE was set.
+func getProbe(c *container.Container) probe {
+	config := c.Config.Healthcheck
+	if config == nil || len(config.Test) == 0 {
+		return nil
+	}
+	switch config.Test[0] {
+	case "CMD":
+		return &cmdProbe{shell: false}
+	case "CMD-SHELL":
+		return &cmdProbe{shell: true}
+	case "NONE":
+		return nil
+	default:
+		logrus.Warnf("Unknown healthcheck type '%s' (expected 'CMD') in container %s", config.Test[0], c.ID)
+		return nil
+	}
+}
+
+// Ensure the health-check monitor is running or not, depending on the current
+// state of the container.
+// Called from containerStart with the container locked.
+func (d *Daemon) initHealthMonitor(c *container.Container) {
+	// If no healthcheck is setup then don't init the monitor
+	probe := getProbe(c)
+	if probe == nil {
+		return
+	}
+
+	// This is needed in case we're auto-restarting
+	d.stopHealthchecks(c)
+
+	if h := c.State.Health; h != nil {
+		h.Status = types.Starting
+		h.FailingStreak = 0
+	} else {
+		h := &container.Health{}
+		h.Status = types.Starting
+		c.State.Health = h
+	}
+
+	if stop := c.State.Health.OpenMonitorChannel(); stop != nil {
+		go monitor(d, c, stop, probe)
+	}
+}
+
+// Called when the container is being stopped (whether because the health check is
+// failing or for any other reason).
+func (d *Daemon) stopHealthchecks(c *container.Container) {
+	if h := c.State.Health; h != nil {
+		h.CloseMonitorChannel()
+	}
+}
+
+// Buffer up to maxOutputLen bytes. Further data is discarded.
+type limitedBuffer struct {
+	buf       bytes.Buffer
+	mu        sync.Mutex
+	truncated bool // indicates that data has been lost
+}
+
+// Append to limitedBuffer while there is room.
+func (b *limitedBuffer) Write(data []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	bufLen := b.buf.Len()
+	dataLen := len(data)
+	keep := min(maxOutputLen-bufLen, dataLen)
+	if keep > 0 {
+		b.buf.Write(data[:keep])
+	}
+	if keep < dataLen {
+		b.truncated = true
+	}
+	return dataLen, nil
+}
+
+// The contents of the buffer, with "..." appended if it overflowed.
+func (b *limitedBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	out := b.buf.String()
+	if b.truncated {
+		out = out + "..."
+	}
+	return out
+}
+
+// If configuredValue is zero, use defaultValue instead.
+func timeoutWithDefault(configuredValue time.Duration, defaultValue time.Duration) time.Duration {
+	if configuredValue == 0 {
+		return defaultValue
+	}
+	return configuredValue
+}
+
+func min(x, y int) int {
+	if x < y {
+		return x
+	}
+	return y
+}
+
+func getShell() []string {
+	if runtime.GOOS == "windows" {
+		return []string{"cmd", "/S", "/C"}
+	}
+	return []string{"/bin/sh", "-c"}
+}
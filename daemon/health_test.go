@@ -0,0 +1,100 @@
+package daemon
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	containertypes "github.com/docker/docker/api/types/container"
+	eventtypes "github.com/docker/docker/api/types/events"
+	"github.com/docker/docker/container"
+	"github.com/docker/docker/daemon/events"
+)
+
+func resetHealth(c *container.Container) {
+	c.State = &container.State{}
+	c.State.Health = &container.Health{}
+	c.State.Health.Status = types.Starting
+}
+
+func TestHealthStates(t *testing.T) {
+	root, err := ioutil.TempDir("", "docker-health-test-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	e := events.New()
+	_, l, _ := e.Subscribe()
+	defer e.Evict(l)
+
+	expect := func(expected string) {
+		select {
+		case event := <-l:
+			ev := event.(eventtypes.Message)
+			if ev.Status != expected {
+				t.Errorf("Expecting event %#v, but got %#v\n", expected, ev.Status)
+			}
+		case <-time.After(1 * time.Second):
+			t.Errorf("Expecting event %#v, but got nothing\n", expected)
+		}
+	}
+
+	c := container.NewBaseContainer("container_id", root)
+	c.Config = &containertypes.Config{
+		Image: "image_name",
+		Healthcheck: &containertypes.HealthConfig{
+			Test:    []string{"CMD", "check"},
+			Retries: 1,
+		},
+	}
+	daemon := &Daemon{EventsService: e}
+
+	handleResult := func(startTime time.Time, exitCode int) {
+		handleProbeResult(daemon, c, &types.HealthcheckResult{
+			Start:    startTime,
+			End:      startTime,
+			ExitCode: exitCode,
+		})
+	}
+
+	resetHealth(c)
+	handleResult(time.Now(), 1)
+	expect("health_status: unhealthy")
+
+	resetHealth(c)
+	handleResult(time.Now(), 0)
+	expect("health_status: healthy")
+	handleResult(time.Now(), 0)
+	handleResult(time.Now(), 1)
+	expect("health_status: unhealthy")
+	handleResult(time.Now(), 0)
+	expect("health_status: healthy")
+
+	c.Config.Healthcheck.Retries = 3
+	for i := 0; i < maxLogEntries+1; i++ {
+		handleResult(time.Now(), 1)
+	}
+	expect("health_status: unhealthy")
+	if n := len(c.State.Health.Log); n != maxLogEntries {
+		t.Errorf("Expecting %d log entries, got %d", maxLogEntries, n)
+	}
+	if c.State.Health.FailingStreak != maxLogEntries+1 {
+		t.Errorf("Expecting FailingStreak=%d, got %d", maxLogEntries+1, c.State.Health.FailingStreak)
+	}
+}
+
+func TestLimitedBuffer(t *testing.T) {
+	b := &limitedBuffer{}
+	b.Write([]byte("hello"))
+	if s := b.String(); s != "hello" {
+		t.Fatalf("Expected hello, got %q", s)
+	}
+	b.Write([]byte(strings.Repeat("x", maxOutputLen)))
+	if s := b.String(); len(s) != maxOutputLen+len("...") || !strings.HasSuffix(s, "...") {
+		t.Fatalf("Expected truncated output of %d bytes, got %d", maxOutputLen+len("..."), len(s))
+	}
+}
@@ -0,0 +1,100 @@
+package daemon
+
+import (
+	"sync"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/docker/docker/container"
+)
+
+// heldContainers holds the resources of the containers which failed, by
+// container ID, until their hold expires.
+type heldContainers struct {
+	sync.Mutex
+	timers map[string]*time.Timer
+}
+
+// HoldOnFailure keeps the mounts and the network sandbox of a container
+// whose process failed for the duration set with --hold-on-failure, so that
+// they can be inspected, and tells whether it does. The container lock must
+// be held.
+func (daemon *Daemon) HoldOnFailure(c *container.Container) bool {
+	if daemon.configStore == nil || daemon.configStore.HoldOnFailure <= 0 || daemon.IsShuttingDown() {
+		return false
+	}
+	hold := daemon.configStore.HoldOnFailure
+
+	daemon.held.Lock()
+	if daemon.held.timers == nil {
+		daemon.held.timers = make(map[string]*time.Timer)
+	}
+	daemon.held.timers[c.ID] = time.AfterFunc(hold, func() {
+		c.Lock()
+		defer c.Unlock()
+		if daemon.releaseHold(c, "expired") {
+			if err := c.ToDisk(); err != nil {
+				logrus.Errorf("Error dumping container %s state to disk: %s", c.ID, err)
+			}
+		}
+	})
+	daemon.held.Unlock()
+
+	attributes := map[string]string{
+		"until":  time.Now().Add(hold).UTC().Format(time.RFC3339),
+		"rootfs": c.BaseFS,
+	}
+	if c.NetworkSettings != nil {
+		attributes["sandboxKey"] = c.NetworkSettings.SandboxKey
+	}
+	daemon.LogContainerEventWithAttributes(c, "hold", attributes)
+	return true
+}
+
+// releaseHold releases the resources held for a container which failed, if
+// any, and tells whether there were. The container lock must be held.
+func (daemon *Daemon) releaseHold(c *container.Container, reason string) bool {
+	daemon.held.Lock()
+	timer, held := daemon.held.timers[c.ID]
+	delete(daemon.held.timers, c.ID)
+	daemon.held.Unlock()
+	if !held {
+		return false
+	}
+	timer.Stop()
+
+	daemon.Cleanup(c)
+	daemon.LogContainerEventWithAttributes(c, "release", map[string]string{
+		"reason": reason,
+	})
+	return true
+}
+
+// releaseHolds releases the resources held for all the containers which
+// failed, on shutdown.
+func (daemon *Daemon) releaseHolds() {
+	daemon.held.Lock()
+	var ids []string
+	for id := range daemon.held.timers {
+		ids = append(ids, id)
+	}
+	daemon.held.Unlock()
+
+	for _, id := range ids {
+		c, err := daemon.GetContainer(id)
+		if err != nil {
+			continue
+		}
+		c.Lock()
+		daemon.releaseHold(c, "shutdown")
+		c.Unlock()
+	}
+}
+
+// isHeld tells whether the resources of the container c are held.
+func (daemon *Daemon) isHeld(c *container.Container) bool {
+	daemon.held.Lock()
+	defer daemon.held.Unlock()
+	_, held := daemon.held.timers[c.ID]
+	return held
+}
@@ -0,0 +1,52 @@
+package daemon
+
+import (
+	"testing"
+	"time"
+
+	containertypes "github.com/docker/docker/api/types/container"
+	eventtypes "github.com/docker/docker/api/types/events"
+	"github.com/docker/docker/container"
+	"github.com/docker/docker/daemon/events"
+	"github.com/docker/docker/daemon/network"
+)
+
+func TestHoldOnFailure(t *testing.T) {
+	e := events.New()
+	_, l, _ := e.Subscribe()
+	defer e.Evict(l)
+	daemon := &Daemon{
+		configStore:   &Config{},
+		EventsService: e,
+	}
+	c := container.NewBaseContainer("held", "")
+	c.Config = &containertypes.Config{}
+	c.NetworkSettings = &network.Settings{SandboxKey: "/var/run/docker/netns/held"}
+
+	if daemon.HoldOnFailure(c) {
+		t.Fatal("Expected no hold without --hold-on-failure")
+	}
+	if daemon.releaseHold(c, "start") {
+		t.Fatal("Expected no hold to release")
+	}
+
+	daemon.configStore.HoldOnFailure = time.Hour
+	if !daemon.HoldOnFailure(c) {
+		t.Fatal("Expected the container to be held")
+	}
+	timer, held := daemon.held.timers[c.ID]
+	if !held {
+		t.Fatal("Expected a timer for the held container")
+	}
+	timer.Stop()
+
+	select {
+	case msg := <-l:
+		m := msg.(eventtypes.Message)
+		if m.Action != "hold" || m.Actor.Attributes["sandboxKey"] != c.NetworkSettings.SandboxKey || m.Actor.Attributes["until"] == "" {
+			t.Fatalf("Unexpected event %+v", m)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected a hold event")
+	}
+}
@@ -0,0 +1,84 @@
+package daemon
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+	"text/template"
+
+	containertypes "github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/pkg/stringid"
+)
+
+// maxHostnameLength is the maximum length of a hostname label (RFC 1123).
+const maxHostnameLength = 63
+
+var (
+	validHostnamePattern = regexp.MustCompile(`^[a-zA-Z0-9]([a-zA-Z0-9-]*[a-zA-Z0-9])?$`)
+	invalidHostnameChars = regexp.MustCompile(`[^a-zA-Z0-9-]`)
+)
+
+// hostnameTemplateData is the data available to the hostname template
+// configured with --hostname-template.
+type hostnameTemplateData struct {
+	// ID is the truncated ID of the container.
+	ID string
+	// Name is the name of the container, with the characters which are
+	// not allowed in hostnames replaced by "-".
+	Name string
+}
+
+// parseHostnameTemplate parses and validates the hostname template.
+func parseHostnameTemplate(tmpl string) (*template.Template, error) {
+	t, err := template.New("hostname").Option("missingkey=error").Parse(tmpl)
+	if err != nil {
+		return nil, fmt.Errorf("invalid hostname template %q: %v", tmpl, err)
+	}
+	if _, err := renderHostname(t, stringid.GenerateNonCryptoID(), "/focused_turing"); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// generateHostname sets the hostname of the container with the given ID
+// and name if none was given in its configuration.
+func (daemon *Daemon) generateHostname(id, name string, config *containertypes.Config) error {
+	if config.Hostname != "" {
+		return nil
+	}
+	if daemon.hostnameTemplate == nil {
+		// Generate default hostname
+		config.Hostname = id[:12]
+		return nil
+	}
+	hostname, err := renderHostname(daemon.hostnameTemplate, id, name)
+	if err != nil {
+		return err
+	}
+	config.Hostname = hostname
+	return nil
+}
+
+func renderHostname(t *template.Template, id, name string) (string, error) {
+	data := hostnameTemplateData{
+		ID:   stringid.TruncateID(id),
+		Name: invalidHostnameChars.ReplaceAllString(strings.TrimPrefix(name, "/"), "-"),
+	}
+
+	var b bytes.Buffer
+	if err := t.Execute(&b, data); err != nil {
+		return "", fmt.Errorf("cannot generate hostname: %v", err)
+	}
+	// long container names are truncated, and the separators left at the
+	// ends are trimmed
+	hostname := b.String()
+	if len(hostname) > maxHostnameLength {
+		hostname = hostname[:maxHostnameLength]
+	}
+	hostname = strings.Trim(hostname, "-")
+	if !validHostnamePattern.MatchString(hostname) {
+		return "", fmt.Errorf("Invalid generated hostname (%s), only [a-zA-Z0-9-] are allowed", b.String())
+	}
+	return hostname, nil
+}
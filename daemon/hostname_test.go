@@ -0,0 +1,68 @@
+package daemon
+
+import (
+	"strings"
+	"testing"
+
+	containertypes "github.com/docker/docker/api/types/container"
+)
+
+func TestGenerateHostnameTemplate(t *testing.T) {
+	tmpl, err := parseHostnameTemplate("web-{{.Name}}-{{.ID}}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	daemon := &Daemon{hostnameTemplate: tmpl}
+
+	id := "5a4ff6a163ad4533d22d69a2b8960bf7fafdcba06e72d2febdba229008b0bf57"
+	config := &containertypes.Config{}
+	if err := daemon.generateHostname(id, "/focused_turing", config); err != nil {
+		t.Fatal(err)
+	}
+	if expected := "web-focused-turing-5a4ff6a163ad"; config.Hostname != expected {
+		t.Fatalf("expected hostname %q, got %q", expected, config.Hostname)
+	}
+
+	config = &containertypes.Config{Hostname: "explicit"}
+	if err := daemon.generateHostname(id, "/focused_turing", config); err != nil {
+		t.Fatal(err)
+	}
+	if config.Hostname != "explicit" {
+		t.Fatalf("expected explicit hostname to be kept, got %q", config.Hostname)
+	}
+
+	// long names are truncated to 63 characters, without a trailing -
+	config = &containertypes.Config{}
+	if err := daemon.generateHostname(id, "/"+strings.Repeat("a", 58), config); err != nil {
+		t.Fatal(err)
+	}
+	if expected := "web-" + strings.Repeat("a", 58); config.Hostname != expected {
+		t.Fatalf("expected hostname %q, got %q", expected, config.Hostname)
+	}
+}
+
+func TestGenerateHostnameTrim(t *testing.T) {
+	tmpl, err := parseHostnameTemplate("{{.Name}}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	daemon := &Daemon{hostnameTemplate: tmpl}
+	config := &containertypes.Config{}
+	if err := daemon.generateHostname("5a4ff6a163ad4533d22d69a2b8960bf7fafdcba06e72d2febdba229008b0bf57", "/_web_", config); err != nil {
+		t.Fatal(err)
+	}
+	if config.Hostname != "web" {
+		t.Fatalf("expected hostname %q, got %q", "web", config.Hostname)
+	}
+	if err := daemon.generateHostname("5a4ff6a163ad4533d22d69a2b8960bf7fafdcba06e72d2febdba229008b0bf57", "/__", &containertypes.Config{}); err == nil {
+		t.Fatal("expected an error for an empty hostname")
+	}
+}
+
+func TestParseInvalidHostnameTemplate(t *testing.T) {
+	for _, tmpl := range []string{"{{.ID", "{{.Image}}", "{{.ID}}.local"} {
+		if _, err := parseHostnameTemplate(tmpl); err == nil {
+			t.Fatalf("expected template %q to be rejected", tmpl)
+		}
+	}
+}
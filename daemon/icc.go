@@ -0,0 +1,161 @@
+package daemon
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/docker/docker/container"
+	"github.com/docker/docker/pkg/stringid"
+)
+
+// iccFirewall programs the rules applied to the traffic between two
+// containers of the same network.
+type iccFirewall interface {
+	// program adds, or removes if enable is false, the rules applying action
+	// to the traffic between the addresses ip1 and ip2, in both directions.
+	program(ip1, ip2, action string, enable bool) error
+	// isolate adds, or removes if enable is false, the rules dropping the
+	// traffic forwarded between the bridge iface and the other interfaces.
+	isolate(iface string, enable bool) error
+}
+
+// iccPolicy installs the --icc-allow and --icc-deny rules of the containers
+// between them and the other containers of their networks.
+type iccPolicy struct {
+	mu sync.Mutex
+	fw iccFirewall
+	// rules are the installed rules by network ID, then by pair of
+	// containers.
+	rules map[string]map[iccPair]iccRule
+}
+
+// iccPair is a pair of container IDs.
+type iccPair struct {
+	a, b string
+}
+
+// iccRule is a rule installed between two addresses.
+type iccRule struct {
+	ip1, ip2 string
+	action   string
+}
+
+// iccEndpoint is a container connected to a network with its address on it.
+type iccEndpoint struct {
+	container *container.Container
+	ip        string
+}
+
+func newICCPolicy(fw iccFirewall) *iccPolicy {
+	return &iccPolicy{
+		fw:    fw,
+		rules: make(map[string]map[iccPair]iccRule),
+	}
+}
+
+// join installs the rules between ep, which joined the network nid, and the
+// peers already connected to it. iccEnabled is the policy of the network.
+func (p *iccPolicy) join(nid string, iccEnabled bool, ep iccEndpoint, peers []iccEndpoint) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for _, peer := range peers {
+		action := iccAction(ep.container, peer.container, iccEnabled)
+		if action == "" || ep.ip == "" || peer.ip == "" {
+			continue
+		}
+		rule := iccRule{ip1: ep.ip, ip2: peer.ip, action: action}
+		if err := p.fw.program(rule.ip1, rule.ip2, rule.action, true); err != nil {
+			p.leaveLocked(nid, ep.container.ID)
+			return fmt.Errorf("Failed to program the ICC rules between %s and %s: %v", ep.container.ID, peer.container.ID, err)
+		}
+		if p.rules[nid] == nil {
+			p.rules[nid] = make(map[iccPair]iccRule)
+		}
+		p.rules[nid][iccPair{ep.container.ID, peer.container.ID}] = rule
+	}
+	return nil
+}
+
+// isolate cuts the bridge iface of an internal network from the outside, or
+// restores its access if enable is false.
+func (p *iccPolicy) isolate(iface string, enable bool) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.fw.isolate(iface, enable)
+}
+
+// leave removes the rules of the container with the given id in the network
+// nid.
+func (p *iccPolicy) leave(nid, id string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.leaveLocked(nid, id)
+}
+
+func (p *iccPolicy) leaveLocked(nid, id string) {
+	for pair, rule := range p.rules[nid] {
+		if pair.a != id && pair.b != id {
+			continue
+		}
+		if err := p.fw.program(rule.ip1, rule.ip2, rule.action, false); err != nil {
+			logrus.Warnf("Failed to remove the ICC rules between %s and %s: %v", pair.a, pair.b, err)
+		}
+		delete(p.rules[nid], pair)
+	}
+	if len(p.rules[nid]) == 0 {
+		delete(p.rules, nid)
+	}
+}
+
+// iccAction returns the action applied to the traffic between the containers
+// a and b of a network: "DROP" if ICC is enabled on the network and one of
+// them denies the other, "ACCEPT" if ICC is disabled and one of them allows
+// the other, or "" to leave the traffic to the policy of the network.
+func iccAction(a, b *container.Container, iccEnabled bool) string {
+	if iccEnabled {
+		if iccRulesMatch(a.HostConfig.IccDeny, b) || iccRulesMatch(b.HostConfig.IccDeny, a) {
+			return "DROP"
+		}
+		return ""
+	}
+	if iccRulesMatch(a.HostConfig.IccAllow, b) || iccRulesMatch(b.HostConfig.IccAllow, a) {
+		return "ACCEPT"
+	}
+	return ""
+}
+
+// iccRulesMatch tells whether one of rules matches the container c.
+func iccRulesMatch(rules []string, c *container.Container) bool {
+	for _, rule := range rules {
+		if iccRuleMatches(rule, c) {
+			return true
+		}
+	}
+	return false
+}
+
+// iccRuleMatches tells whether rule matches the container c. A rule is a
+// container name or ID, or a label in the form label=<key>[=<value>].
+func iccRuleMatches(rule string, c *container.Container) bool {
+	if strings.HasPrefix(rule, "label=") {
+		parts := strings.SplitN(strings.TrimPrefix(rule, "label="), "=", 2)
+		value, ok := c.Config.Labels[parts[0]]
+		if !ok {
+			return false
+		}
+		return len(parts) == 1 || parts[1] == value
+	}
+	return rule == strings.TrimPrefix(c.Name, "/") || rule == c.ID || rule == stringid.TruncateID(c.ID)
+}
+
+// validateICCRule checks that rule is a container name or ID, or a label in
+// the form label=<key>[=<value>].
+func validateICCRule(rule string) error {
+	if rule == "" || rule == "label=" || strings.HasPrefix(rule, "label==") {
+		return fmt.Errorf("Invalid ICC rule %q, it must be a container name or ID, or label=<key>[=<value>]", rule)
+	}
+	return nil
+}
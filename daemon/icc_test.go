@@ -0,0 +1,136 @@
+package daemon
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+
+	containertypes "github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/container"
+)
+
+// fakeICCFirewall records the programmed rules as "ip1 ip2 action".
+type fakeICCFirewall struct {
+	rules map[string]bool
+}
+
+func (fw *fakeICCFirewall) program(ip1, ip2, action string, enable bool) error {
+	key := ip1 + " " + ip2 + " " + action
+	if enable {
+		fw.rules[key] = true
+	} else {
+		delete(fw.rules, key)
+	}
+	return nil
+}
+
+func (fw *fakeICCFirewall) isolate(iface string, enable bool) error {
+	key := "isolate " + iface
+	if enable {
+		fw.rules[key] = true
+	} else {
+		delete(fw.rules, key)
+	}
+	return nil
+}
+
+func (fw *fakeICCFirewall) list() []string {
+	var rules []string
+	for rule := range fw.rules {
+		rules = append(rules, rule)
+	}
+	sort.Strings(rules)
+	return rules
+}
+
+func newICCContainer(id, name string, labels map[string]string, allow, deny []string) *container.Container {
+	return &container.Container{
+		CommonContainer: container.CommonContainer{
+			ID:         id,
+			Name:       "/" + name,
+			Config:     &containertypes.Config{Labels: labels},
+			HostConfig: &containertypes.HostConfig{IccAllow: allow, IccDeny: deny},
+		},
+	}
+}
+
+func TestICCRuleMatches(t *testing.T) {
+	c := newICCContainer("5a4ff6a163ad4533d22d3a1f2e3b7a0cd54d2d8ec3b1b0b6c6d7e0e2f1a2b3c4", "db", map[string]string{"tier": "backend"}, nil, nil)
+	tests := map[string]bool{
+		"db":                   true,
+		"web":                  false,
+		"5a4ff6a163ad":         true,
+		c.ID:                   true,
+		"5a4f":                 false,
+		"label=tier":           true,
+		"label=tier=backend":   true,
+		"label=tier=frontend":  false,
+		"label=zone":           false,
+		"label=tier=backend=x": false,
+	}
+	for rule, expected := range tests {
+		if matches := iccRuleMatches(rule, c); matches != expected {
+			t.Fatalf("Expected rule %q matching to be %v, got %v", rule, expected, matches)
+		}
+	}
+}
+
+func TestValidateICCRule(t *testing.T) {
+	for _, rule := range []string{"db", "label=tier", "label=tier=backend"} {
+		if err := validateICCRule(rule); err != nil {
+			t.Fatalf("Expected %q to be valid, got %v", rule, err)
+		}
+	}
+	for _, rule := range []string{"", "label=", "label==backend"} {
+		if err := validateICCRule(rule); err == nil {
+			t.Fatalf("Expected %q to be invalid", rule)
+		}
+	}
+}
+
+func TestICCPolicy(t *testing.T) {
+	fw := &fakeICCFirewall{rules: make(map[string]bool)}
+	p := newICCPolicy(fw)
+
+	web := iccEndpoint{newICCContainer("web", "web", nil, []string{"db"}, []string{"label=tier=admin"}), "10.0.0.2"}
+	db := iccEndpoint{newICCContainer("db", "db", nil, nil, nil), "10.0.0.3"}
+	admin := iccEndpoint{newICCContainer("admin", "admin", map[string]string{"tier": "admin"}, nil, nil), "10.0.0.4"}
+
+	// ICC enabled: only the deny rules apply.
+	for _, step := range []struct {
+		ep    iccEndpoint
+		peers []iccEndpoint
+	}{
+		{web, nil},
+		{db, []iccEndpoint{web}},
+		{admin, []iccEndpoint{web, db}},
+	} {
+		if err := p.join("open", true, step.ep, step.peers); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if expected := []string{"10.0.0.4 10.0.0.2 DROP"}; !reflect.DeepEqual(fw.list(), expected) {
+		t.Fatalf("Expected rules %v, got %v", expected, fw.list())
+	}
+
+	// ICC disabled: only the allow rules apply.
+	if err := p.join("closed", false, db, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.join("closed", false, web, []iccEndpoint{db}); err != nil {
+		t.Fatal(err)
+	}
+	if expected := []string{"10.0.0.2 10.0.0.3 ACCEPT", "10.0.0.4 10.0.0.2 DROP"}; !reflect.DeepEqual(fw.list(), expected) {
+		t.Fatalf("Expected rules %v, got %v", expected, fw.list())
+	}
+
+	// Leaving a network removes the rules of the container on it only.
+	p.leave("open", "web")
+	if expected := []string{"10.0.0.2 10.0.0.3 ACCEPT"}; !reflect.DeepEqual(fw.list(), expected) {
+		t.Fatalf("Expected rules %v, got %v", expected, fw.list())
+	}
+	p.leave("closed", "db")
+	if len(fw.list()) != 0 || len(p.rules) != 0 {
+		t.Fatalf("Expected no rules left, got %v and %v", fw.list(), p.rules)
+	}
+}
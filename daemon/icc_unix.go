@@ -0,0 +1,188 @@
+// +build !windows
+
+package daemon
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/docker/docker/container"
+	"github.com/docker/libnetwork"
+	"github.com/docker/libnetwork/drivers/bridge"
+	"github.com/docker/libnetwork/iptables"
+	"github.com/docker/libnetwork/netlabel"
+)
+
+// iccChain is the filter chain holding the rules between containers. It is
+// jumped to first from the FORWARD chain so that its rules take precedence
+// over the ICC rules of the bridge networks.
+const iccChain = "DOCKER-ICC"
+
+// initICCPolicy returns the policy installing the ICC rules of the
+// containers, or nil if iptables is disabled.
+func initICCPolicy(config *Config) (*iccPolicy, error) {
+	if !config.Bridge.EnableIPTables {
+		return nil, nil
+	}
+	fw, err := newIptablesICC()
+	if err != nil {
+		return nil, err
+	}
+	return newICCPolicy(fw), nil
+}
+
+// iptablesICC programs the ICC rules in the iccChain chain.
+type iptablesICC struct{}
+
+// newIptablesICC creates the iccChain chain, removing the rules left by a
+// previous daemon.
+func newIptablesICC() (*iptablesICC, error) {
+	for iptables.Exists(iptables.Filter, "FORWARD", "-j", iccChain) {
+		if _, err := iptables.Raw("-D", "FORWARD", "-j", iccChain); err != nil {
+			return nil, fmt.Errorf("Failed to remove the %s chain: %v", iccChain, err)
+		}
+	}
+	if err := iptables.RemoveExistingChain(iccChain, iptables.Filter); err != nil {
+		return nil, fmt.Errorf("Failed to remove the %s chain: %v", iccChain, err)
+	}
+	if _, err := iptables.NewChain(iccChain, iptables.Filter, false); err != nil {
+		return nil, fmt.Errorf("Failed to create the %s chain: %v", iccChain, err)
+	}
+	return &iptablesICC{}, nil
+}
+
+func (fw *iptablesICC) program(ip1, ip2, action string, enable bool) error {
+	return fw.apply([][]string{
+		{"-s", ip1, "-d", ip2, "-j", action},
+		{"-s", ip2, "-d", ip1, "-j", action},
+	}, enable)
+}
+
+func (fw *iptablesICC) isolate(iface string, enable bool) error {
+	return fw.apply([][]string{
+		{"-i", iface, "!", "-o", iface, "-j", "DROP"},
+		{"!", "-i", iface, "-o", iface, "-j", "DROP"},
+	}, enable)
+}
+
+// apply adds the rules to iccChain, or removes them if enable is false.
+func (fw *iptablesICC) apply(rules [][]string, enable bool) error {
+	if enable {
+		if err := fw.ensureJump(); err != nil {
+			return err
+		}
+	}
+	for _, args := range rules {
+		exists := iptables.Exists(iptables.Filter, iccChain, args...)
+		var op string
+		switch {
+		case enable && !exists:
+			op = "-I"
+		case !enable && exists:
+			op = "-D"
+		default:
+			continue
+		}
+		if output, err := iptables.Raw(append([]string{op, iccChain}, args...)...); err != nil {
+			return err
+		} else if len(output) != 0 {
+			return &iptables.ChainError{Chain: iccChain, Output: output}
+		}
+	}
+	return nil
+}
+
+// ensureJump makes the jump to iccChain the first rule of the FORWARD chain,
+// as the bridge networks created after it insert their rules first.
+func (fw *iptablesICC) ensureJump() error {
+	output, err := iptables.Raw("-S", "FORWARD")
+	if err != nil {
+		return err
+	}
+	jump := "-A FORWARD -j " + iccChain
+	var rules []string
+	for _, line := range strings.Split(string(output), "\n") {
+		if strings.HasPrefix(line, "-A FORWARD") {
+			rules = append(rules, strings.TrimSpace(line))
+		}
+	}
+	if len(rules) > 0 && rules[0] == jump {
+		return nil
+	}
+	if _, err := iptables.Raw("-I", "FORWARD", "-j", iccChain); err != nil {
+		return err
+	}
+	// Remove the previous jumps, shifted by the one just inserted, from the
+	// last one so that the positions of the others do not change.
+	for i := len(rules) - 1; i >= 0; i-- {
+		if rules[i] == jump {
+			if _, err := iptables.Raw("-D", "FORWARD", strconv.Itoa(i+2)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// applyICCPolicy installs the ICC rules between the container c, just
+// connected to the network n, and the other containers of the network.
+func (daemon *Daemon) applyICCPolicy(c *container.Container, n libnetwork.Network) error {
+	if daemon.icc == nil || n.Type() != "bridge" {
+		return nil
+	}
+	settings := c.NetworkSettings.Networks[n.Name()]
+	if settings == nil {
+		return nil
+	}
+	var peers []iccEndpoint
+	for _, peer := range daemon.networkPeers(c, n) {
+		if peerSettings := peer.NetworkSettings.Networks[n.Name()]; peerSettings != nil {
+			peers = append(peers, iccEndpoint{container: peer, ip: peerSettings.IPAddress})
+		}
+	}
+	iccEnabled := n.Info().DriverOptions()[bridge.EnableICC] != "false"
+	return daemon.icc.join(n.ID(), iccEnabled, iccEndpoint{container: c, ip: settings.IPAddress}, peers)
+}
+
+// internalBridgeOptions returns the driver options of an internal bridge
+// network: the bridge driver gives it no masquerading, and the daemon drops
+// the traffic forwarded between its bridge and the outside.
+func (daemon *Daemon) internalBridgeOptions(options map[string]string) (map[string]string, error) {
+	if daemon.icc == nil {
+		return nil, fmt.Errorf("Internal bridge networks require --iptables")
+	}
+	internalOptions := make(map[string]string, len(options)+2)
+	for k, v := range options {
+		internalOptions[k] = v
+	}
+	internalOptions[bridge.EnableIPMasquerade] = "false"
+	internalOptions[netlabel.Internal] = "true"
+	return internalOptions, nil
+}
+
+// isolateNetwork cuts the bridge of the network n from the outside if n is an
+// internal bridge network, or restores its access if enable is false.
+func (daemon *Daemon) isolateNetwork(n libnetwork.Network, enable bool) error {
+	if daemon.icc == nil || n.Type() != "bridge" {
+		return nil
+	}
+	options := n.Info().DriverOptions()
+	if options[netlabel.Internal] != "true" {
+		return nil
+	}
+	iface := options[bridge.BridgeName]
+	if iface == "" {
+		// The name the bridge driver gives to the bridges of the networks
+		iface = "br-" + n.ID()[:12]
+	}
+	return daemon.icc.isolate(iface, enable)
+}
+
+// releaseICCPolicy removes the ICC rules of the container c in the network n.
+func (daemon *Daemon) releaseICCPolicy(c *container.Container, n libnetwork.Network) {
+	if daemon.icc == nil {
+		return
+	}
+	daemon.icc.leave(n.ID(), c.ID)
+}
@@ -50,6 +50,12 @@ import (
 // package. This would require that we no longer need the daemon to determine
 // whether images are being used by a stopped or running container.
 func (daemon *Daemon) ImageDelete(imageRef string, force, prune bool) ([]types.ImageDelete, error) {
+	done, err := daemon.beginStorageWrite()
+	if err != nil {
+		return nil, err
+	}
+	defer done()
+
 	records := []types.ImageDelete{}
 
 	imgID, err := daemon.GetImageID(imageRef)
@@ -134,13 +140,13 @@ func isImageIDPrefix(imageID, possiblePrefix string) bool {
 // getContainerUsingImage returns a container that was created using the given
 // imageID. Returns nil if there is no such container.
 func (daemon *Daemon) getContainerUsingImage(imageID image.ID) *container.Container {
-	for _, container := range daemon.List() {
-		if container.ImageID == imageID {
-			return container
-		}
+	found := daemon.containers.Find(func(c *container.Container) bool {
+		return c.ImageID == imageID
+	}, 0, 1)
+	if len(found) == 0 {
+		return nil
 	}
-
-	return nil
+	return found[0]
 }
 
 // removeImageRef attempts to parse and remove the given image reference from
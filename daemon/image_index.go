@@ -0,0 +1,182 @@
+package daemon
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/docker/distribution/digest"
+	"github.com/docker/docker/image"
+	"github.com/docker/docker/layer"
+	"github.com/docker/docker/pkg/truncindex"
+	"github.com/docker/docker/reference"
+)
+
+// imageIndex resolves the references and the ID prefixes of the images
+// without searching the reference and image stores. It is kept up to date by
+// the stores returned by wrapReferenceStore and wrapImageStore, through which
+// the images are tagged, untagged, loaded and removed.
+type imageIndex struct {
+	mu sync.RWMutex
+	// refs maps the references, with their default tag, to image IDs.
+	refs map[string]image.ID
+	// repositories counts the references of each repository to each image.
+	repositories map[string]map[image.ID]int
+	// ids indexes the hex part of the image IDs by prefix.
+	ids *truncindex.TruncIndex
+}
+
+func newImageIndex() *imageIndex {
+	return &imageIndex{
+		refs:         make(map[string]image.ID),
+		repositories: make(map[string]map[image.ID]int),
+		ids:          truncindex.NewTruncIndex(nil),
+	}
+}
+
+// load indexes the images and the references already stored.
+func (idx *imageIndex) load(is image.Store, rs reference.Store) {
+	for id := range is.Map() {
+		idx.addID(id)
+		for _, ref := range rs.References(id) {
+			idx.setRef(ref, id)
+		}
+	}
+}
+
+func (idx *imageIndex) addID(id image.ID) {
+	idx.ids.Add(digest.Digest(id).Hex())
+}
+
+func (idx *imageIndex) deleteID(id image.ID) {
+	idx.ids.Delete(digest.Digest(id).Hex())
+}
+
+// searchID returns the ID of the image whose ID starts with prefix, with or
+// without its algorithm.
+func (idx *imageIndex) searchID(prefix string) (image.ID, error) {
+	hex, err := idx.ids.Get(strings.TrimPrefix(prefix, string(digest.Canonical)+":"))
+	if err != nil {
+		return "", err
+	}
+	return image.ID(digest.NewDigestFromHex(string(digest.Canonical), hex)), nil
+}
+
+func (idx *imageIndex) setRef(ref reference.Named, id image.ID) {
+	ref = reference.WithDefaultTag(ref)
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	if old, exists := idx.refs[ref.String()]; exists {
+		idx.forget(ref.Name(), old)
+	}
+	idx.refs[ref.String()] = id
+	ids := idx.repositories[ref.Name()]
+	if ids == nil {
+		ids = make(map[image.ID]int)
+		idx.repositories[ref.Name()] = ids
+	}
+	ids[id]++
+}
+
+func (idx *imageIndex) deleteRef(ref reference.Named) {
+	ref = reference.WithDefaultTag(ref)
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	if old, exists := idx.refs[ref.String()]; exists {
+		delete(idx.refs, ref.String())
+		idx.forget(ref.Name(), old)
+	}
+}
+
+// forget drops a reference of the repository name to the image id. It must
+// be called with the lock held.
+func (idx *imageIndex) forget(name string, id image.ID) {
+	ids := idx.repositories[name]
+	if ids[id]--; ids[id] > 0 {
+		return
+	}
+	delete(ids, id)
+	if len(ids) == 0 {
+		delete(idx.repositories, name)
+	}
+}
+
+// get returns the ID of the image referred to by ref.
+func (idx *imageIndex) get(ref reference.Named) (image.ID, bool) {
+	ref = reference.WithDefaultTag(ref)
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	id, exists := idx.refs[ref.String()]
+	return id, exists
+}
+
+// inRepository reports whether a reference of the repository name refers to
+// the image id.
+func (idx *imageIndex) inRepository(name string, id image.ID) bool {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	return idx.repositories[name][id] > 0
+}
+
+// indexedReferenceStore updates the index of the images when references are
+// added or deleted.
+type indexedReferenceStore struct {
+	reference.Store
+	index *imageIndex
+}
+
+func wrapReferenceStore(rs reference.Store, index *imageIndex) reference.Store {
+	return &indexedReferenceStore{Store: rs, index: index}
+}
+
+func (s *indexedReferenceStore) AddTag(ref reference.Named, id image.ID, force bool) error {
+	if err := s.Store.AddTag(ref, id, force); err != nil {
+		return err
+	}
+	s.index.setRef(ref, id)
+	return nil
+}
+
+func (s *indexedReferenceStore) AddDigest(ref reference.Canonical, id image.ID, force bool) error {
+	if err := s.Store.AddDigest(ref, id, force); err != nil {
+		return err
+	}
+	s.index.setRef(ref, id)
+	return nil
+}
+
+func (s *indexedReferenceStore) Delete(ref reference.Named) (bool, error) {
+	deleted, err := s.Store.Delete(ref)
+	if deleted {
+		s.index.deleteRef(ref)
+	}
+	return deleted, err
+}
+
+// indexedImageStore updates the index of the images when images are created
+// or deleted.
+type indexedImageStore struct {
+	image.Store
+	index *imageIndex
+}
+
+func wrapImageStore(is image.Store, index *imageIndex) image.Store {
+	return &indexedImageStore{Store: is, index: index}
+}
+
+func (s *indexedImageStore) Create(config []byte) (image.ID, error) {
+	id, err := s.Store.Create(config)
+	if err != nil {
+		return "", err
+	}
+	s.index.addID(id)
+	return id, nil
+}
+
+func (s *indexedImageStore) Delete(id image.ID) ([]layer.Metadata, error) {
+	removed, err := s.Store.Delete(id)
+	if err != nil {
+		return nil, err
+	}
+	s.index.deleteID(id)
+	return removed, nil
+}
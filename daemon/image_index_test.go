@@ -0,0 +1,86 @@
+package daemon
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/docker/docker/image"
+	"github.com/docker/docker/reference"
+)
+
+func TestImageIndexGetImageID(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "docker-image-index")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+	rs, err := reference.NewReferenceStore(filepath.Join(tmp, "repositories.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	index := newImageIndex()
+	rs = wrapReferenceStore(rs, index)
+	daemon := &Daemon{imageIndex: index}
+
+	busybox := image.ID("sha256:8c2e06607696bd4afb3d03b687e361cc43cf8ec1a4a725bc96e39f05ba97dd55")
+	alpine := image.ID("sha256:8c4f5d7e6b2d4aa7a1b0f3bd3a53d3a4d7e0c5f7e0f4d0d7f0a1d3e0b7c1f2a3")
+	web := image.ID("sha256:d5c0f3a92b1c84f8b6d2aa0d9f9f4a5b9c1f2e3d4c5b6a7980f1e2d3c4b5a697")
+	for _, id := range []image.ID{busybox, alpine, web} {
+		index.addID(id)
+	}
+	tag := func(name string, id image.ID) {
+		ref, err := reference.ParseNamed(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := rs.AddTag(ref, id, true); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tag("busybox", busybox)
+	tag("alpine:3.3", alpine)
+	tag("web:d5c0f3", web)
+	tag("web:latest", web)
+	// moving a tag drops the old reference
+	tag("alpine:edge", busybox)
+	tag("alpine:edge", alpine)
+
+	for _, c := range []struct {
+		refOrID  string
+		expected image.ID
+	}{
+		{"busybox", busybox},
+		{"busybox:latest", busybox},
+		{"alpine:3.3", alpine},
+		{"alpine:edge", alpine},
+		{"alpine:8c4f", alpine},
+		{"8c2e", busybox},
+		{"sha256:8c4f", alpine},
+		{"web", web},
+		{string(web), web},
+	} {
+		if id, err := daemon.GetImageID(c.refOrID); err != nil || id != c.expected {
+			t.Fatalf("Expected %s to resolve to %s, got %s %v", c.refOrID, c.expected, id, err)
+		}
+	}
+	for _, refOrID := range []string{"8c", "alpine:8c2e", "alpine", "busybox:edge"} {
+		if id, err := daemon.GetImageID(refOrID); err == nil {
+			t.Fatalf("Expected %s not to resolve, got %s", refOrID, id)
+		}
+	}
+
+	ref, _ := reference.ParseNamed("busybox")
+	if _, err := rs.Delete(ref); err != nil {
+		t.Fatal(err)
+	}
+	if id, err := daemon.GetImageID("busybox"); err == nil {
+		t.Fatalf("Expected the untagged reference not to resolve, got %s", id)
+	}
+	index.deleteID(busybox)
+	if id, err := daemon.GetImageID("8c"); err != nil || id != alpine {
+		t.Fatalf("Expected the prefix to resolve to %s once busybox is removed, got %s %v", alpine, id, err)
+	}
+}
@@ -0,0 +1,47 @@
+package daemon
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/distribution/metadata"
+)
+
+// ImageRaw returns the configuration of an image as stored by the daemon, and
+// the manifests it was pulled with as received from the registries, so that
+// they can be verified against their digests.
+func (daemon *Daemon) ImageRaw(name string) (*types.ImageRaw, error) {
+	img, err := daemon.GetImage(name)
+	if err != nil {
+		return nil, fmt.Errorf("No such image: %s", name)
+	}
+
+	imageRaw := &types.ImageRaw{
+		ID:        img.ID().String(),
+		Config:    img.RawJSON(),
+		Manifests: []types.ImageRawManifest{},
+	}
+
+	manifestService := metadata.NewManifestService(daemon.distributionMetadataStore)
+	digests, err := manifestService.GetDigests(img.ID())
+	if err != nil {
+		if os.IsNotExist(err) {
+			// The image was built, loaded or pulled before the
+			// manifests were kept.
+			return imageRaw, nil
+		}
+		return nil, err
+	}
+	for _, dgst := range digests {
+		manifest, err := manifestService.Get(dgst)
+		if err != nil {
+			return nil, err
+		}
+		imageRaw.Manifests = append(imageRaw.Manifests, types.ImageRawManifest{
+			Digest:   dgst.String(),
+			Manifest: manifest,
+		})
+	}
+	return imageRaw, nil
+}
@@ -4,7 +4,9 @@ import (
 	"fmt"
 	"path"
 	"sort"
+	"time"
 
+	"github.com/Sirupsen/logrus"
 	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/filters"
 	"github.com/docker/docker/image"
@@ -104,6 +106,11 @@ func (daemon *Daemon) Images(filterArgs, filter string, all bool) ([]*types.Imag
 		}
 
 		newImage := newImage(img, size)
+		if lastUsed, err := daemon.imageStore.GetLastUsed(id); err != nil {
+			logrus.Warnf("failed to get the last use of image %s: %v", id, err)
+		} else if !lastUsed.IsZero() {
+			newImage.LastUsed = lastUsed.Unix()
+		}
 
 		for _, ref := range daemon.referenceStore.References(id) {
 			if filter != "" { // filter by tag/repo name
@@ -156,3 +163,14 @@ func newImage(image *image.Image, size int64) *types.Image {
 	}
 	return newImage
 }
+
+// updateImageLastUsed records that the image is being used by a container.
+// Containers created without an image, like on Windows, are ignored.
+func (daemon *Daemon) updateImageLastUsed(id image.ID) {
+	if id == "" {
+		return
+	}
+	if err := daemon.imageStore.SetLastUsed(id, time.Now()); err != nil {
+		logrus.Warnf("failed to record the last use of image %s: %v", id, err)
+	}
+}
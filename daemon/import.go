@@ -23,6 +23,12 @@ import (
 // written to outStream. Repository and tag names can optionally be given in
 // the repo and tag arguments, respectively.
 func (daemon *Daemon) ImportImage(src string, newRef reference.Named, msg string, inConfig io.ReadCloser, outStream io.Writer, config *container.Config) error {
+	done, err := daemon.beginStorageWrite()
+	if err != nil {
+		return err
+	}
+	defer done()
+
 	var (
 		sf      = streamformatter.NewJSONStreamFormatter()
 		archive io.ReadCloser
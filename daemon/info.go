@@ -110,6 +110,11 @@ func (daemon *Daemon) SystemInfo() (*types.Info, error) {
 		v.Name = hostname
 	}
 
+	if daemon.restoreStatus != nil {
+		v.RestoreStatus = daemon.restoreStatus.info()
+	}
+	v.StorageStatus = daemon.storageStatus.info()
+
 	return v, nil
 }
 
@@ -0,0 +1,78 @@
+package daemon
+
+import (
+	"errors"
+	"io"
+	"strconv"
+	"time"
+
+	derr "github.com/docker/docker/errors"
+)
+
+const (
+	// MaxInputSize is the size limit of the input sent to a container
+	// without attaching to it.
+	MaxInputSize = 64 * 1024
+	// inputTimeout is the time to wait for the process of the container to
+	// read the input.
+	inputTimeout = 10 * time.Second
+)
+
+// ContainerSendInput writes data to the standard input of the running
+// container identified by the given name, without attaching to it. The
+// container must be created with OpenStdin, and data is written whole
+// between the writes of the attached clients. An event is logged with the
+// size of the input, not with the input itself, which can be a password.
+func (daemon *Daemon) ContainerSendInput(name string, data []byte) error {
+	if len(data) > MaxInputSize {
+		return derr.ErrorCodeInputTooLarge.WithArgs(MaxInputSize)
+	}
+	container, err := daemon.GetContainer(name)
+	if err != nil {
+		return err
+	}
+	if !container.IsRunning() {
+		return derr.ErrorCodeNotRunning.WithArgs(name)
+	}
+	stdin := container.StdinPipe()
+	if !container.Config.OpenStdin || stdin == nil {
+		return derr.ErrorCodeStdinNotOpen.WithArgs(name)
+	}
+
+	err = writeInput(stdin, data, inputTimeout)
+	switch err {
+	case io.ErrClosedPipe:
+		err = derr.ErrorCodeStdinNotOpen.WithArgs(name)
+	case errInputTimeout:
+		err = derr.ErrorCodeInputTimeout.WithArgs(name, inputTimeout)
+	}
+	attributes := map[string]string{
+		"size": strconv.Itoa(len(data)),
+	}
+	if err != nil {
+		attributes["error"] = err.Error()
+	}
+	daemon.LogContainerEventWithAttributes(container, "send_input", attributes)
+	return err
+}
+
+// errInputTimeout is returned by writeInput when the input is not read in
+// time.
+var errInputTimeout = errors.New("input timeout")
+
+// writeInput writes data to w within the timeout. The write cannot be
+// cancelled, so on a timeout it goes on in the background until the input
+// is read or the pipe is closed.
+func writeInput(w io.Writer, data []byte, timeout time.Duration) error {
+	done := make(chan error, 1)
+	go func() {
+		_, err := w.Write(data)
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-time.After(timeout):
+		return errInputTimeout
+	}
+}
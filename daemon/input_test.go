@@ -0,0 +1,38 @@
+package daemon
+
+import (
+	"io"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/docker/distribution/registry/api/errcode"
+	derr "github.com/docker/docker/errors"
+)
+
+func TestWriteInput(t *testing.T) {
+	r, w := io.Pipe()
+	go ioutil.ReadAll(r)
+	if err := writeInput(w, []byte("password\n"), time.Second); err != nil {
+		t.Fatal(err)
+	}
+
+	// Nothing reads the input
+	r, w = io.Pipe()
+	if err := writeInput(w, []byte("password\n"), 10*time.Millisecond); err != errInputTimeout {
+		t.Fatalf("Expected a timeout, got %v", err)
+	}
+	r.Close()
+
+	if err := writeInput(w, []byte("password\n"), time.Second); err != io.ErrClosedPipe {
+		t.Fatalf("Expected the pipe to be closed, got %v", err)
+	}
+}
+
+func TestContainerSendInputTooLarge(t *testing.T) {
+	daemon := &Daemon{}
+	err := daemon.ContainerSendInput("container", make([]byte, MaxInputSize+1))
+	if e, ok := err.(errcode.Error); !ok || e.ErrorCode() != derr.ErrorCodeInputTooLarge {
+		t.Fatalf("Expected an input too large error, got %v", err)
+	}
+}
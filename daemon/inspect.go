@@ -2,8 +2,10 @@ package daemon
 
 import (
 	"fmt"
+	"strings"
 	"time"
 
+	"github.com/Sirupsen/logrus"
 	"github.com/docker/docker/api/types"
 	networktypes "github.com/docker/docker/api/types/network"
 	"github.com/docker/docker/api/types/versions/v1p20"
@@ -11,6 +13,8 @@ import (
 	"github.com/docker/docker/daemon/exec"
 	"github.com/docker/docker/daemon/network"
 	"github.com/docker/docker/pkg/version"
+	runconfigopts "github.com/docker/docker/runconfig/opts"
+	"github.com/docker/docker/volume"
 )
 
 // ContainerInspect returns low-level information about a
@@ -62,9 +66,55 @@ func (daemon *Daemon) containerInspectCurrent(name string, size bool) (*types.Co
 		Mounts:            mountPoints,
 		Config:            container.Config,
 		NetworkSettings:   networkSettings,
+		EffectiveEnv:      daemon.effectiveEnv(container),
 	}, nil
 }
 
+// effectiveEnv returns the environment of the container, marking the
+// variables inherited from the image and the variables set when the
+// container was created.
+func (daemon *Daemon) effectiveEnv(container *container.Container) []types.ContainerEnvVar {
+	if container.Config == nil || len(container.Config.Env) == 0 {
+		return nil
+	}
+	img, err := daemon.imageStore.Get(container.ImageID)
+	if err != nil {
+		logrus.Debugf("Couldn't get the image of container %s: %v", container.ID, err)
+		return nil
+	}
+	var imageEnv []string
+	if img.Config != nil {
+		imageEnv = img.Config.Env
+	}
+	return mergeEnvProvenance(container.Config.Env, imageEnv)
+}
+
+// mergeEnvProvenance compares the environment of a container, which is the
+// environment of its image merged with the environment of the create request,
+// to the environment of the image to tell where each variable comes from.
+func mergeEnvProvenance(containerEnv, imageEnv []string) []types.ContainerEnvVar {
+	imageValues := runconfigopts.ConvertKVStringsToMap(imageEnv)
+
+	env := make([]types.ContainerEnvVar, 0, len(containerEnv))
+	for _, kv := range containerEnv {
+		v := types.ContainerEnvVar{Source: "container"}
+		parts := strings.SplitN(kv, "=", 2)
+		v.Name = parts[0]
+		if len(parts) == 2 {
+			v.Value = parts[1]
+		}
+		if imageValue, ok := imageValues[v.Name]; ok {
+			if imageValue == v.Value {
+				v.Source = "image"
+			} else {
+				v.ImageValue = imageValue
+			}
+		}
+		env = append(env, v)
+	}
+	return env
+}
+
 // containerInspect120 serializes the master version of a container into a json type.
 func (daemon *Daemon) containerInspect120(name string) (*v1p20.ContainerJSON, error) {
 	container, err := daemon.GetContainer(name)
@@ -124,11 +174,30 @@ func (daemon *Daemon) getInspectData(container *container.Container, size bool)
 		Restarting: container.State.Restarting,
 		OOMKilled:  container.State.OOMKilled,
 		Dead:       container.State.Dead,
+		Broken:     container.State.Broken,
 		Pid:        container.State.Pid,
 		ExitCode:   container.State.ExitCode,
+		ExitSignal: container.State.ExitSignal,
+		ExitReason: container.State.ExitReason,
 		Error:      container.State.Error,
+		LastError:  container.State.LastError,
 		StartedAt:  container.State.StartedAt.Format(time.RFC3339Nano),
 		FinishedAt: container.State.FinishedAt.Format(time.RFC3339Nano),
+
+		DesiredState: container.DesiredState(),
+	}
+	if len(container.State.Transitions) > 0 {
+		containerState.Transitions = append([]*types.StateTransition{}, container.State.Transitions...)
+	}
+	if len(container.State.RestartHistory) > 0 {
+		containerState.RestartHistory = append([]*types.ContainerExit{}, container.State.RestartHistory...)
+	}
+	if h := container.State.Health; h != nil {
+		containerState.Health = &types.Health{
+			Status:        h.Status,
+			FailingStreak: h.FailingStreak,
+			Log:           append([]*types.HealthcheckResult{}, h.Log...),
+		}
 	}
 
 	contJSONBase := &types.ContainerJSONBase{
@@ -163,11 +232,14 @@ func (daemon *Daemon) getInspectData(container *container.Container, size bool)
 
 	contJSONBase.GraphDriver.Name = container.Driver
 
-	graphDriverData, err := container.RWLayer.Metadata()
-	if err != nil {
-		return nil, err
+	// Broken containers have no read-write layer to describe
+	if container.RWLayer != nil {
+		graphDriverData, err := container.RWLayer.Metadata()
+		if err != nil {
+			return nil, err
+		}
+		contJSONBase.GraphDriver.Data = graphDriverData
 	}
-	contJSONBase.GraphDriver.Data = graphDriverData
 
 	return contJSONBase, nil
 }
@@ -189,7 +261,7 @@ func (daemon *Daemon) VolumeInspect(name string) (*types.Volume, error) {
 	if err != nil {
 		return nil, err
 	}
-	return volumeToAPIType(v), nil
+	return daemon.volumeToAPIType(v), nil
 }
 
 func (daemon *Daemon) getBackwardsCompatibleNetworkSettings(settings *network.Settings) *v1p20.NetworkSettings {
@@ -228,3 +300,18 @@ func (daemon *Daemon) getDefaultNetworkSettings(networks map[string]*networktype
 	}
 	return settings
 }
+
+// mountPointType returns the type of the mount point m, the volumes having
+// a name unlike the bind mounts.
+func mountPointType(m *volume.MountPoint) string {
+	if m.Name != "" {
+		return types.MountTypeVolume
+	}
+	return types.MountTypeBind
+}
+
+type mountPointsByDestination []types.MountPoint
+
+func (m mountPointsByDestination) Len() int           { return len(m) }
+func (m mountPointsByDestination) Less(i, j int) bool { return m[i].Destination < m[j].Destination }
+func (m mountPointsByDestination) Swap(i, j int)      { m[i], m[j] = m[j], m[i] }
@@ -0,0 +1,24 @@
+package daemon
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/docker/docker/api/types"
+)
+
+func TestMergeEnvProvenance(t *testing.T) {
+	imageEnv := []string{"PATH=/usr/local/bin:/usr/bin", "LANG=C.UTF-8", "HOME=/root"}
+	containerEnv := []string{"PATH=/opt/app/bin:/usr/bin", "LANG=C.UTF-8", "HOME=/root", "DEBUG=1", "EMPTY"}
+
+	expected := []types.ContainerEnvVar{
+		{Name: "PATH", Value: "/opt/app/bin:/usr/bin", Source: "container", ImageValue: "/usr/local/bin:/usr/bin"},
+		{Name: "LANG", Value: "C.UTF-8", Source: "image"},
+		{Name: "HOME", Value: "/root", Source: "image"},
+		{Name: "DEBUG", Value: "1", Source: "container"},
+		{Name: "EMPTY", Value: "", Source: "container"},
+	}
+	if env := mergeEnvProvenance(containerEnv, imageEnv); !reflect.DeepEqual(env, expected) {
+		t.Fatalf("Expected %+v, got %+v", expected, env)
+	}
+}
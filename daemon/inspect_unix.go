@@ -3,17 +3,22 @@
 package daemon
 
 import (
+	"sort"
+	"strings"
+
 	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/versions/v1p19"
 	"github.com/docker/docker/container"
+	"github.com/docker/docker/daemon/execdriver"
 )
 
 // This sets platform-specific fields
 func setPlatformSpecificContainerFields(container *container.Container, contJSONBase *types.ContainerJSONBase) *types.ContainerJSONBase {
-	contJSONBase.AppArmorProfile = container.AppArmorProfile
+	contJSONBase.AppArmorProfile = appArmorProfile(container)
 	contJSONBase.ResolvConfPath = container.ResolvConfPath
 	contJSONBase.HostnamePath = container.HostnamePath
 	contJSONBase.HostsPath = container.HostsPath
+	contJSONBase.IsolatedCpus = container.IsolatedCpus
 
 	return contJSONBase
 }
@@ -62,10 +67,22 @@ func (daemon *Daemon) containerInspectPre120(name string) (*v1p19.ContainerJSON,
 	}, nil
 }
 
+// addMountPoints returns the mounts of the container sorted by destination.
+// While the container runs, the sources and flags are the ones it was
+// started with, a volume driver possibly mounting a volume elsewhere than
+// when it was inspected before.
 func addMountPoints(container *container.Container) []types.MountPoint {
-	mountPoints := make([]types.MountPoint, 0, len(container.MountPoints))
+	mounted := make(map[string]execdriver.Mount)
+	if container.Running && container.Command != nil {
+		for _, m := range container.Command.Mounts {
+			mounted[m.Destination] = m
+		}
+	}
+
+	mountPoints := make([]types.MountPoint, 0, len(container.MountPoints)+len(container.HostConfig.Tmpfs))
 	for _, m := range container.MountPoints {
-		mountPoints = append(mountPoints, types.MountPoint{
+		mp := types.MountPoint{
+			Type:        mountPointType(m),
 			Name:        m.Name,
 			Source:      m.Path(),
 			Destination: m.Destination,
@@ -73,7 +90,33 @@ func addMountPoints(container *container.Container) []types.MountPoint {
 			Mode:        m.Mode,
 			RW:          m.RW,
 			Propagation: m.Propagation,
+		}
+		if actual, ok := mounted[m.Destination]; ok {
+			mp.Source = actual.Source
+			mp.RW = actual.Writable
+			mp.Propagation = actual.Propagation
+		}
+		mountPoints = append(mountPoints, mp)
+	}
+	for dest, data := range container.HostConfig.Tmpfs {
+		mountPoints = append(mountPoints, types.MountPoint{
+			Type:        types.MountTypeTmpfs,
+			Destination: dest,
+			Mode:        data,
+			RW:          !tmpfsReadOnly(data),
 		})
 	}
+	sort.Sort(mountPointsByDestination(mountPoints))
 	return mountPoints
 }
+
+// tmpfsReadOnly tells whether the options of a tmpfs mount make it
+// read-only.
+func tmpfsReadOnly(data string) bool {
+	for _, o := range strings.Split(data, ",") {
+		if o == "ro" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,42 @@
+// +build !windows
+
+package daemon
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/docker/docker/api/types"
+	containertypes "github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/container"
+	"github.com/docker/docker/daemon/execdriver"
+	"github.com/docker/docker/volume"
+)
+
+func TestAddMountPoints(t *testing.T) {
+	c := container.NewBaseContainer("mounts", "")
+	c.HostConfig = &containertypes.HostConfig{
+		Tmpfs: map[string]string{"/run": "ro,size=64m"},
+	}
+	c.MountPoints["/srv"] = &volume.MountPoint{Source: "/srv/app", Destination: "/srv", RW: true, Propagation: "rprivate"}
+	c.MountPoints["/data"] = &volume.MountPoint{Name: "data", Driver: "local", Source: "/var/lib/docker/volumes/data/_data", Destination: "/data", RW: true}
+
+	expected := []types.MountPoint{
+		{Type: types.MountTypeVolume, Name: "data", Driver: "local", Source: "/var/lib/docker/volumes/data/_data", Destination: "/data", RW: true},
+		{Type: types.MountTypeTmpfs, Destination: "/run", Mode: "ro,size=64m"},
+		{Type: types.MountTypeBind, Source: "/srv/app", Destination: "/srv", RW: true, Propagation: "rprivate"},
+	}
+	if mountPoints := addMountPoints(c); !reflect.DeepEqual(mountPoints, expected) {
+		t.Fatalf("Expected the mounts %+v, got %+v", expected, mountPoints)
+	}
+
+	// The mounts of a running container are the ones it was started with
+	c.Running = true
+	c.Command = &execdriver.Command{
+		Mounts: []execdriver.Mount{{Source: "/mnt/data", Destination: "/data", Propagation: "rslave"}},
+	}
+	expected[0].Source, expected[0].RW, expected[0].Propagation = "/mnt/data", false, "rslave"
+	if mountPoints := addMountPoints(c); !reflect.DeepEqual(mountPoints, expected) {
+		t.Fatalf("Expected the mounts %+v, got %+v", expected, mountPoints)
+	}
+}
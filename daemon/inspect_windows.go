@@ -1,6 +1,8 @@
 package daemon
 
 import (
+	"sort"
+
 	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/container"
 )
@@ -14,6 +16,7 @@ func addMountPoints(container *container.Container) []types.MountPoint {
 	mountPoints := make([]types.MountPoint, 0, len(container.MountPoints))
 	for _, m := range container.MountPoints {
 		mountPoints = append(mountPoints, types.MountPoint{
+			Type:        mountPointType(m),
 			Name:        m.Name,
 			Source:      m.Path(),
 			Destination: m.Destination,
@@ -21,6 +24,7 @@ func addMountPoints(container *container.Container) []types.MountPoint {
 			RW:          m.RW,
 		})
 	}
+	sort.Sort(mountPointsByDestination(mountPoints))
 	return mountPoints
 }
 
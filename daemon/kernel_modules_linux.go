@@ -0,0 +1,77 @@
+package daemon
+
+import (
+	"bufio"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/docker/docker/container"
+	derr "github.com/docker/docker/errors"
+)
+
+// checkKernelModules verifies that the kernel modules required by the
+// container are loaded. The missing modules are loaded with modprobe if the
+// daemon was started with --kernel-module-load.
+func (daemon *Daemon) checkKernelModules(c *container.Container) error {
+	var missing []string
+	for _, m := range c.HostConfig.KernelModules {
+		if kernelModuleLoaded(m) {
+			continue
+		}
+		if daemon.configStore.KernelModuleLoad {
+			out, err := exec.Command("modprobe", m).CombinedOutput()
+			if err == nil {
+				logrus.Infof("Loaded kernel module %s required by container %s", m, c.ID)
+				continue
+			}
+			logrus.Warnf("Failed to load kernel module %s required by container %s: %v (%s)", m, c.ID, err, strings.TrimSpace(string(out)))
+		}
+		missing = append(missing, m)
+	}
+	if len(missing) > 0 {
+		return derr.ErrorCodeMissingKernelModules.WithArgs(c.ID, strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// kernelModuleLoaded reports whether the kernel module is loaded or built
+// into the kernel.
+func kernelModuleLoaded(name string) bool {
+	// Dashes and underscores are interchangeable in module names, the
+	// kernel uses underscores.
+	name = strings.Replace(name, "-", "_", -1)
+	if _, err := os.Stat(filepath.Join("/sys/module", name)); err == nil {
+		return true
+	}
+
+	// Built-in modules without parameters are not in /sys/module.
+	release, err := ioutil.ReadFile("/proc/sys/kernel/osrelease")
+	if err != nil {
+		return false
+	}
+	f, err := os.Open(filepath.Join("/lib/modules", strings.TrimSpace(string(release)), "modules.builtin"))
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+	return builtinKernelModule(f, name)
+}
+
+// builtinKernelModule reports whether the module is listed in modules.builtin,
+// which gives the path of each built-in module, like
+// kernel/net/netfilter/ipvs/ip_vs.ko.
+func builtinKernelModule(builtin io.Reader, name string) bool {
+	s := bufio.NewScanner(builtin)
+	for s.Scan() {
+		module := strings.TrimSuffix(filepath.Base(strings.TrimSpace(s.Text())), ".ko")
+		if strings.Replace(module, "-", "_", -1) == name {
+			return true
+		}
+	}
+	return false
+}
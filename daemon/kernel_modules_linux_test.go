@@ -0,0 +1,24 @@
+package daemon
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestBuiltinKernelModule(t *testing.T) {
+	builtin := `kernel/drivers/block/nbd.ko
+kernel/net/netfilter/ipvs/ip_vs.ko
+kernel/drivers/md/dm-mod.ko
+`
+	for name, expected := range map[string]bool{
+		"nbd":    true,
+		"ip_vs":  true,
+		"dm_mod": true,
+		"ip":     false,
+		"loop":   false,
+	} {
+		if actual := builtinKernelModule(strings.NewReader(builtin), name); actual != expected {
+			t.Fatalf("expected builtin module %s to be %v, got %v", name, expected, actual)
+		}
+	}
+}
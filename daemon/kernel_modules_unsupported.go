@@ -0,0 +1,18 @@
+// +build !linux
+
+package daemon
+
+import (
+	"fmt"
+
+	"github.com/docker/docker/container"
+)
+
+// checkKernelModules fails if the container requires kernel modules, which
+// are only supported on Linux.
+func (daemon *Daemon) checkKernelModules(c *container.Container) error {
+	if len(c.HostConfig.KernelModules) > 0 {
+		return fmt.Errorf("Kernel modules requirements are not supported on this platform")
+	}
+	return nil
+}
@@ -16,7 +16,14 @@ import (
 // If no signal is given (sig 0), then Kill with SIGKILL and wait
 // for the container to exit.
 // If a signal is given, then just send it to the container and return.
+// A label selector kills all the running containers it matches.
 func (daemon *Daemon) ContainerKill(name string, sig uint64) error {
+	if isLabelSelector(name) {
+		return daemon.forEachSelected(name, func(id string) error {
+			return daemon.ContainerKill(id, sig)
+		}, derr.ErrorCodeNotRunning)
+	}
+
 	container, err := daemon.GetContainer(name)
 	if err != nil {
 		return err
@@ -28,6 +35,7 @@ func (daemon *Daemon) ContainerKill(name string, sig uint64) error {
 
 	// If no signal is passed, or SIGKILL, perform regular Kill (SIGKILL + wait())
 	if sig == 0 || syscall.Signal(sig) == syscall.SIGKILL {
+		daemon.setDesiredState(container, desiredStopped)
 		return daemon.Kill(container)
 	}
 	return daemon.killWithSignal(container, int(sig))
@@ -49,6 +49,8 @@ type ContainersConfig struct {
 	Before string
 	// number of containers to return at most
 	Limit int
+	// number of matching containers to skip before returning any
+	Offset int
 	// if true include the sizes of the containers
 	Size bool
 	// return only containers that match filters
@@ -70,12 +72,19 @@ type listContext struct {
 	filters filters.Args
 	// exitAllowed is a list of exit codes allowed to filter with
 	exitAllowed []int
+	// networks are the names of the networks to filter with
+	networks map[string]bool
+	// publish and expose are the ports to filter with
+	publish map[nat.Port]bool
+	expose  map[nat.Port]bool
 	// beforeFilter is a filter to ignore containers that appear before the one given
 	// this is used for --filter=before= and --before=, the latter is deprecated.
 	beforeFilter *container.Container
 	// sinceFilter is a filter to stop the filtering when the iterator arrive to the given container
 	// this is used for --filter=since= and --since=, the latter is deprecated.
 	sinceFilter *container.Container
+	// skipped is the number of matching containers skipped for the offset
+	skipped int
 	// ContainersConfig is the filters set by the user
 	*ContainersConfig
 }
@@ -94,7 +103,16 @@ func (daemon *Daemon) reduceContainers(config *ContainersConfig, reducer contain
 		return nil, err
 	}
 
-	for _, container := range daemon.List() {
+	// The IDs do not change, so they are matched without locking the
+	// containers while walking the store
+	candidates := daemon.List()
+	if ctx.filters.Include("id") {
+		candidates = daemon.containers.Find(func(c *container.Container) bool {
+			return ctx.filters.Match("id", c.ID)
+		}, 0, 0)
+	}
+
+	for _, container := range candidates {
 		t, err := daemon.reducePsContainer(container, ctx, reducer)
 		if err != nil {
 			if err != errStopIteration {
@@ -160,6 +178,39 @@ func (daemon *Daemon) foldFilter(config *ContainersConfig) (*listContext, error)
 		return nil, err
 	}
 
+	err = psFilters.WalkValues("health", func(value string) error {
+		switch value {
+		case types.NoHealthcheck, types.Starting, types.Healthy, types.Unhealthy:
+			return nil
+		}
+		return fmt.Errorf("Unrecognised filter value for health: %s", value)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	networks := make(map[string]bool)
+	psFilters.WalkValues("network", func(value string) error {
+		// The networks are matched by name, the names of the containers'
+		// endpoints being the names of their networks
+		if daemon.netController != nil {
+			if n, err := daemon.FindNetwork(value); err == nil {
+				value = n.Name()
+			}
+		}
+		networks[value] = true
+		return nil
+	})
+
+	publish, err := portFilter(psFilters, "publish")
+	if err != nil {
+		return nil, err
+	}
+	expose, err := portFilter(psFilters, "expose")
+	if err != nil {
+		return nil, err
+	}
+
 	var beforeContFilter, sinceContFilter *container.Container
 	err = psFilters.WalkValues("before", func(value string) error {
 		beforeContFilter, err = daemon.GetContainer(value)
@@ -223,6 +274,9 @@ func (daemon *Daemon) foldFilter(config *ContainersConfig) (*listContext, error)
 		names:            names,
 		images:           imagesFilter,
 		exitAllowed:      filtExited,
+		networks:         networks,
+		publish:          publish,
+		expose:           expose,
 		beforeFilter:     beforeContFilter,
 		sinceFilter:      sinceContFilter,
 		ContainersConfig: config,
@@ -306,9 +360,115 @@ func includeContainerInList(container *container.Container, ctx *listContext) it
 		}
 	}
 
+	// Do not include container if its health status doesn't match the filter
+	if ctx.filters.Include("health") {
+		status := types.NoHealthcheck
+		if container.State.Health != nil {
+			status = container.State.Health.Status
+		}
+		if !ctx.filters.ExactMatch("health", status) {
+			return excludeContainer
+		}
+	}
+
+	// Do not include container if it is not connected to any of the networks
+	if len(ctx.networks) > 0 {
+		shouldSkip := true
+		if container.NetworkSettings != nil {
+			for name := range container.NetworkSettings.Networks {
+				if ctx.networks[name] {
+					shouldSkip = false
+					break
+				}
+			}
+		}
+		if shouldSkip {
+			return excludeContainer
+		}
+	}
+
+	// Do not include container if none of the volumes is mounted in it, the
+	// volumes being matched by name, host path or path in the container
+	if ctx.filters.Include("volume") {
+		shouldSkip := true
+		for _, m := range container.MountPoints {
+			source := m.Name
+			if source == "" {
+				source = m.Source
+			}
+			if ctx.filters.ExactMatch("volume", source) || ctx.filters.ExactMatch("volume", m.Destination) {
+				shouldSkip = false
+				break
+			}
+		}
+		if shouldSkip {
+			return excludeContainer
+		}
+	}
+
+	// Do not include container if none of the ports is published
+	if len(ctx.publish) > 0 {
+		shouldSkip := true
+		for port := range ctx.publish {
+			_, bound := container.HostConfig.PortBindings[port]
+			if bound || (container.NetworkSettings != nil && len(container.NetworkSettings.Ports[port]) > 0) {
+				shouldSkip = false
+				break
+			}
+		}
+		if shouldSkip {
+			return excludeContainer
+		}
+	}
+
+	// Do not include container if none of the ports is exposed
+	if len(ctx.expose) > 0 {
+		shouldSkip := true
+		for port := range ctx.expose {
+			if _, exposed := container.Config.ExposedPorts[port]; exposed {
+				shouldSkip = false
+				break
+			}
+		}
+		if shouldSkip {
+			return excludeContainer
+		}
+	}
+
+	// Do not include the matching containers before the offset
+	if ctx.skipped < ctx.Offset {
+		ctx.skipped++
+		return excludeContainer
+	}
+
 	return includeContainer
 }
 
+// portFilter returns the ports of the values of the given port filter, each
+// a port or a range of ports with an optional protocol, tcp by default.
+func portFilter(psFilters filters.Args, field string) (map[nat.Port]bool, error) {
+	ports := make(map[nat.Port]bool)
+	err := psFilters.WalkValues(field, func(value string) error {
+		proto, portRange := nat.SplitProtoPort(value)
+		start, end, err := nat.ParsePortRangeToInt(portRange)
+		if err != nil || start == 0 {
+			return fmt.Errorf("Invalid filter value for %s: %s", field, value)
+		}
+		for port := start; port <= end; port++ {
+			p, err := nat.NewPort(proto, strconv.Itoa(port))
+			if err != nil {
+				return err
+			}
+			ports[p] = true
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ports, nil
+}
+
 // transformContainer generates the container type expected by the docker ps command.
 func (daemon *Daemon) transformContainer(container *container.Container, ctx *listContext) (*types.Container, error) {
 	newC := &types.Container{
@@ -407,7 +567,7 @@ func (daemon *Daemon) Volumes(filter string) ([]*types.Volume, error) {
 		if filterUsed && daemon.volumes.Count(v) > 0 {
 			continue
 		}
-		volumesOut = append(volumesOut, volumeToAPIType(v))
+		volumesOut = append(volumesOut, daemon.volumeToAPIType(v))
 	}
 	return volumesOut, nil
 }
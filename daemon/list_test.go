@@ -0,0 +1,90 @@
+package daemon
+
+import (
+	"testing"
+
+	"github.com/docker/docker/api/types"
+	containertypes "github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/filters"
+	networktypes "github.com/docker/docker/api/types/network"
+	"github.com/docker/docker/container"
+	"github.com/docker/docker/daemon/network"
+	"github.com/docker/docker/volume"
+	"github.com/docker/go-connections/nat"
+)
+
+func TestPortFilter(t *testing.T) {
+	args := filters.NewArgs()
+	args.Add("publish", "80")
+	args.Add("publish", "8000-8001/udp")
+	ports, err := portFilter(args, "publish")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ports) != 3 || !ports["80/tcp"] || !ports["8000/udp"] || !ports["8001/udp"] {
+		t.Fatalf("Expected the ports 80/tcp, 8000/udp and 8001/udp, got %v", ports)
+	}
+
+	for _, value := range []string{"", "http", "80-70"} {
+		args := filters.NewArgs()
+		args.Add("expose", value)
+		if _, err := portFilter(args, "expose"); err == nil {
+			t.Fatalf("Expected an error for the port %q", value)
+		}
+	}
+}
+
+func TestIncludeContainerInListFilters(t *testing.T) {
+	c := container.NewBaseContainer("web", "")
+	c.Running = true
+	c.Config = &containertypes.Config{
+		ExposedPorts: nat.PortSet{"80/tcp": {}, "443/tcp": {}},
+	}
+	c.HostConfig = &containertypes.HostConfig{
+		PortBindings: nat.PortMap{"80/tcp": {{HostPort: "8080"}}},
+	}
+	c.NetworkSettings = &network.Settings{
+		Networks: map[string]*networktypes.EndpointSettings{"frontend": {}},
+	}
+	c.MountPoints["/data"] = &volume.MountPoint{Name: "webdata", Destination: "/data"}
+	c.MountPoints["/etc/web"] = &volume.MountPoint{Source: "/srv/web", Destination: "/etc/web"}
+
+	for _, tc := range []struct {
+		field, value string
+		include      bool
+	}{
+		{"health", types.NoHealthcheck, true},
+		{"health", types.Healthy, false},
+		{"network", "frontend", true},
+		{"network", "backend", false},
+		{"volume", "webdata", true},
+		{"volume", "/srv/web", true},
+		{"volume", "/data", true},
+		{"volume", "otherdata", false},
+		{"publish", "80", true},
+		{"publish", "443", false},
+		{"expose", "443", true},
+		{"expose", "80/udp", false},
+	} {
+		args := filters.NewArgs()
+		args.Add(tc.field, tc.value)
+		ctx := &listContext{
+			filters:          args,
+			networks:         map[string]bool{},
+			ContainersConfig: &ContainersConfig{},
+		}
+		if tc.field == "network" {
+			ctx.networks[tc.value] = true
+		}
+		var err error
+		if ctx.publish, err = portFilter(args, "publish"); err != nil {
+			t.Fatal(err)
+		}
+		if ctx.expose, err = portFilter(args, "expose"); err != nil {
+			t.Fatal(err)
+		}
+		if action := includeContainerInList(c, ctx); (action == includeContainer) != tc.include {
+			t.Fatalf("Expected the filter %s=%s to include the container: %v", tc.field, tc.value, tc.include)
+		}
+	}
+}
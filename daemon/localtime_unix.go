@@ -0,0 +1,69 @@
+// +build !windows
+
+package daemon
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"github.com/docker/docker/container"
+)
+
+// zoneinfoDir is the directory of the host timezone database the
+// /etc/localtime file of containers is generated from.
+var zoneinfoDir = "/usr/share/zoneinfo"
+
+// readZoneinfo returns the content of the host zoneinfo file of the timezone
+// tz, e.g. Europe/Paris.
+func readZoneinfo(tz string) ([]byte, error) {
+	if filepath.IsAbs(tz) || filepath.Clean(tz) != tz || strings.HasPrefix(tz, "..") {
+		return nil, fmt.Errorf("Invalid timezone %q", tz)
+	}
+	content, err := ioutil.ReadFile(filepath.Join(zoneinfoDir, tz))
+	if err != nil || !bytes.HasPrefix(content, []byte("TZif")) {
+		return nil, fmt.Errorf("Unknown timezone %q", tz)
+	}
+	return content, nil
+}
+
+// containerTimezone returns the timezone of the container, or the default
+// timezone of the daemon if the container does not set one.
+func (daemon *Daemon) containerTimezone(container *container.Container) string {
+	if container.HostConfig.Timezone != "" {
+		return container.HostConfig.Timezone
+	}
+	return daemon.configStore.DefaultTimezone
+}
+
+// setupLocaltime generates the /etc/localtime file of the container from
+// the host timezone database, so the timezone applies whatever the content
+// of the image. The file is not generated when /etc/localtime is a mount
+// point of the container.
+func (daemon *Daemon) setupLocaltime(container *container.Container) error {
+	container.LocaltimePath = ""
+
+	tz := daemon.containerTimezone(container)
+	if tz == "" {
+		return nil
+	}
+	if _, exists := container.MountPoints["/etc/localtime"]; exists {
+		return nil
+	}
+
+	content, err := readZoneinfo(tz)
+	if err != nil {
+		return err
+	}
+	pth, err := container.GetRootResourcePath("localtime")
+	if err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(pth, content, 0644); err != nil {
+		return err
+	}
+	container.LocaltimePath = pth
+	return nil
+}
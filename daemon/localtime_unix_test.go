@@ -0,0 +1,78 @@
+// +build !windows
+
+package daemon
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	containertypes "github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/container"
+	"github.com/docker/docker/volume"
+)
+
+func TestSetupLocaltime(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "docker-localtime-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+
+	defer func(dir string) { zoneinfoDir = dir }(zoneinfoDir)
+	zoneinfoDir = filepath.Join(tmp, "zoneinfo")
+	if err := os.MkdirAll(filepath.Join(zoneinfoDir, "Europe"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	paris := []byte("TZif2 Europe/Paris")
+	if err := ioutil.WriteFile(filepath.Join(zoneinfoDir, "Europe", "Paris"), paris, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(zoneinfoDir, "UTC"), []byte("TZif2 UTC"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(zoneinfoDir, "zone.tab"), []byte("# zones"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tz := range []string{"", "Europe/Berlin", "zone.tab", "Europe", "/Europe/Paris", "../zoneinfo/UTC", "Europe/../UTC"} {
+		if _, err := readZoneinfo(tz); err == nil {
+			t.Fatalf("Expected an error for timezone %q", tz)
+		}
+	}
+
+	daemon := &Daemon{configStore: &Config{DefaultTimezone: "UTC"}}
+	c := container.NewBaseContainer("localtime", filepath.Join(tmp, "container"))
+	c.HostConfig = &containertypes.HostConfig{Timezone: "Europe/Paris"}
+	if err := os.MkdirAll(c.Root, 0700); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := daemon.setupLocaltime(c); err != nil {
+		t.Fatal(err)
+	}
+	content, err := ioutil.ReadFile(c.LocaltimePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != string(paris) {
+		t.Fatalf("Expected /etc/localtime for Europe/Paris, got %q", content)
+	}
+	if m := c.LocaltimeMounts(); len(m) != 1 || m[0].Destination != "/etc/localtime" || m[0].Writable {
+		t.Fatalf("Expected a read-only /etc/localtime mount, got %+v", m)
+	}
+
+	c.HostConfig.Timezone = ""
+	if tz := daemon.containerTimezone(c); tz != "UTC" {
+		t.Fatalf("Expected the daemon default timezone, got %q", tz)
+	}
+
+	c.MountPoints["/etc/localtime"] = &volume.MountPoint{Source: "/etc/localtime", Destination: "/etc/localtime"}
+	if err := daemon.setupLocaltime(c); err != nil {
+		t.Fatal(err)
+	}
+	if c.LocaltimePath != "" || c.LocaltimeMounts() != nil {
+		t.Fatal("Expected no /etc/localtime to be generated over a mount point")
+	}
+}
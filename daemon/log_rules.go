@@ -0,0 +1,125 @@
+package daemon
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	containertypes "github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/daemon/logger"
+	"github.com/docker/docker/reference"
+)
+
+// logRule selects the log driver of the containers created without one,
+// by their labels and the name of their image.
+type logRule struct {
+	labels    map[string]string // labels are the labels the container must have, with any value if empty
+	image     string            // image is a path.Match pattern of the repository name of the image
+	logConfig containertypes.LogConfig
+}
+
+// parseLogRule parses the specification of a log rule, made of the log
+// driver optionally followed by comma separated options:
+//
+//	fluentd,label=team=payments,opt=fluentd-address=10.0.0.1:24224
+func parseLogRule(spec string) (*logRule, error) {
+	fields := strings.Split(spec, ",")
+	r := &logRule{
+		labels: make(map[string]string),
+		logConfig: containertypes.LogConfig{
+			Type:   fields[0],
+			Config: make(map[string]string),
+		},
+	}
+	for _, field := range fields[1:] {
+		kv := strings.SplitN(field, "=", 2)
+		if len(kv) != 2 {
+			return nil, fmt.Errorf("Invalid log rule option %q", field)
+		}
+		switch kv[0] {
+		case "label":
+			label := strings.SplitN(kv[1], "=", 2)
+			r.labels[label[0]] = ""
+			if len(label) == 2 {
+				r.labels[label[0]] = label[1]
+			}
+		case "image":
+			if _, err := path.Match(kv[1], ""); err != nil {
+				return nil, fmt.Errorf("Invalid log rule image pattern %q: %v", kv[1], err)
+			}
+			r.image = kv[1]
+		case "opt":
+			opt := strings.SplitN(kv[1], "=", 2)
+			if len(opt) != 2 {
+				return nil, fmt.Errorf("Invalid log rule driver option %q", kv[1])
+			}
+			r.logConfig.Config[opt[0]] = opt[1]
+		default:
+			return nil, fmt.Errorf("Unknown log rule option %q", kv[0])
+		}
+	}
+	if len(r.labels) == 0 && r.image == "" {
+		return nil, fmt.Errorf("Invalid log rule %q: a label or an image is required", spec)
+	}
+	if r.logConfig.Type != "none" {
+		if _, err := logger.GetLogDriver(r.logConfig.Type); err != nil {
+			return nil, fmt.Errorf("Invalid log rule %q: %v", spec, err)
+		}
+	}
+	if err := logger.ValidateLogOpts(r.logConfig.Type, r.logConfig.Config); err != nil {
+		return nil, fmt.Errorf("Invalid log rule %q: %v", spec, err)
+	}
+	return r, nil
+}
+
+// parseLogRules returns the log rules configured with --log-rule, in order.
+func parseLogRules(config *Config) ([]*logRule, error) {
+	var rules []*logRule
+	for _, spec := range config.LogRules {
+		r, err := parseLogRule(spec)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, r)
+	}
+	return rules, nil
+}
+
+// matches tells whether the rule applies to a container with config.
+func (r *logRule) matches(config *containertypes.Config) bool {
+	for k, v := range r.labels {
+		value, ok := config.Labels[k]
+		if !ok || (v != "" && v != value) {
+			return false
+		}
+	}
+	if r.image != "" {
+		name := config.Image
+		if ref, err := reference.ParseNamed(config.Image); err == nil {
+			name = ref.Name()
+		}
+		if ok, _ := path.Match(r.image, name); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// applyLogRules sets the log configuration of the first log rule matching
+// the container, if it was created without a log driver. The containers
+// matching no rule use the default log configuration of the daemon.
+func (daemon *Daemon) applyLogRules(config *containertypes.Config, hostConfig *containertypes.HostConfig) {
+	if hostConfig.LogConfig.Type != "" || len(hostConfig.LogConfig.Config) > 0 {
+		return
+	}
+	for _, r := range daemon.logRules {
+		if r.matches(config) {
+			hostConfig.LogConfig.Type = r.logConfig.Type
+			hostConfig.LogConfig.Config = make(map[string]string)
+			for k, v := range r.logConfig.Config {
+				hostConfig.LogConfig.Config[k] = v
+			}
+			return
+		}
+	}
+}
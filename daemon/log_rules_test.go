@@ -0,0 +1,66 @@
+package daemon
+
+import (
+	"testing"
+
+	containertypes "github.com/docker/docker/api/types/container"
+)
+
+func TestParseLogRules(t *testing.T) {
+	invalid := []string{
+		"json-file",
+		"json-file,team=payments",
+		"json-file,image=[",
+		"json-file,label=team,opt=max-size",
+		"json-file,label=team,opt=unknown=1",
+		"unknown,label=team",
+	}
+	for _, spec := range invalid {
+		if _, err := parseLogRules(&Config{LogRules: []string{spec}}); err == nil {
+			t.Fatalf("Expected an error for %s", spec)
+		}
+	}
+}
+
+func TestApplyLogRules(t *testing.T) {
+	rules, err := parseLogRules(&Config{LogRules: []string{
+		"syslog,label=team=payments,opt=tag=payments",
+		"none,image=myorg/batch-*",
+		"json-file,label=debug,opt=max-size=10m",
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	daemon := &Daemon{logRules: rules}
+
+	cases := []struct {
+		config    *containertypes.Config
+		logConfig containertypes.LogConfig
+		logType   string
+	}{
+		{config: &containertypes.Config{Image: "ubuntu", Labels: map[string]string{"team": "payments"}}, logType: "syslog"},
+		{config: &containertypes.Config{Image: "myorg/batch-nightly:1.2", Labels: map[string]string{"team": "search"}}, logType: "none"},
+		{config: &containertypes.Config{Image: "docker.io/myorg/batch-nightly", Labels: map[string]string{"debug": ""}}, logType: "none"},
+		{config: &containertypes.Config{Image: "myorg/api", Labels: map[string]string{"debug": "1"}}, logType: "json-file"},
+		{config: &containertypes.Config{Image: "myorg/api"}, logType: ""},
+		// The log driver given at create time is kept
+		{config: &containertypes.Config{Image: "ubuntu", Labels: map[string]string{"team": "payments"}}, logConfig: containertypes.LogConfig{Type: "gelf"}, logType: "gelf"},
+	}
+	for _, c := range cases {
+		hostConfig := &containertypes.HostConfig{LogConfig: c.logConfig}
+		daemon.applyLogRules(c.config, hostConfig)
+		if hostConfig.LogConfig.Type != c.logType {
+			t.Fatalf("Expected the log driver %q for %+v, got %q", c.logType, c.config, hostConfig.LogConfig.Type)
+		}
+	}
+
+	hostConfig := &containertypes.HostConfig{}
+	daemon.applyLogRules(&containertypes.Config{Labels: map[string]string{"team": "payments"}}, hostConfig)
+	if hostConfig.LogConfig.Config["tag"] != "payments" {
+		t.Fatalf("Expected the options of the rule, got %v", hostConfig.LogConfig.Config)
+	}
+	hostConfig.LogConfig.Config["tag"] = "changed"
+	if rules[0].logConfig.Config["tag"] != "payments" {
+		t.Fatal("Expected the options of the rule to be copied")
+	}
+}
@@ -79,11 +79,16 @@ func GetLogDriver(name string) (Creator, error) {
 }
 
 // ValidateLogOpts checks the options for the given log driver. The
-// options supported are specific to the LogDriver implementation.
+// options supported are specific to the LogDriver implementation, except
+// for the options of the read cache.
 func ValidateLogOpts(name string, cfg map[string]string) error {
+	driverCfg, cacheCfg := SplitReadCacheOpts(cfg)
+	if err := validateReadCacheOpts(cacheCfg); err != nil {
+		return err
+	}
 	l := factory.getLogOptValidator(name)
 	if l != nil {
-		return l(cfg)
+		return l(driverCfg)
 	}
 	return nil
 }
@@ -8,10 +8,12 @@ import (
 	"net"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/Sirupsen/logrus"
 	"github.com/docker/docker/daemon/logger"
 	"github.com/docker/docker/daemon/logger/loggerutils"
+	"github.com/docker/go-units"
 	"github.com/fluent/fluent-logger-golang/fluent"
 )
 
@@ -19,7 +21,7 @@ type fluentd struct {
 	tag           string
 	containerID   string
 	containerName string
-	writer        *fluent.Fluent
+	writer        *writer
 	extra         map[string]string
 }
 
@@ -28,6 +30,10 @@ const (
 	defaultHostName  = "localhost"
 	defaultPort      = 24224
 	defaultTagPrefix = "docker"
+
+	defaultBufferLimit = 8 * 1024 * 1024
+	defaultRetryWait   = time.Second
+	defaultMaxRetries  = math.MaxInt32
 )
 
 func init() {
@@ -41,12 +47,17 @@ func init() {
 
 // New creates a fluentd logger using the configuration passed in on
 // the context. Supported context configuration variables are
-// fluentd-address & fluentd-tag.
+// fluentd-address, fluentd-buffer-limit, fluentd-retry-wait,
+// fluentd-max-retries, fluentd-async-connect & fluentd-tag.
 func New(ctx logger.Context) (logger.Logger, error) {
 	host, port, err := parseAddress(ctx.Config["fluentd-address"])
 	if err != nil {
 		return nil, err
 	}
+	opts, err := parseOptions(ctx.Config)
+	if err != nil {
+		return nil, err
+	}
 
 	tag, err := loggerutils.ParseLogTag(ctx, "docker.{{.ID}}")
 	if err != nil {
@@ -54,9 +65,7 @@ func New(ctx logger.Context) (logger.Logger, error) {
 	}
 	extra := ctx.ExtraAttributes(nil)
 	logrus.Debugf("logging driver fluentd configured for container:%s, host:%s, port:%d, tag:%s, extra:%v.", ctx.ContainerID, host, port, tag, extra)
-	// logger tries to reconnect 2**32 - 1 times
-	// failed (and panic) after 204 years [ 1.5 ** (2**32 - 1) - 1 seconds]
-	log, err := fluent.New(fluent.Config{FluentPort: port, FluentHost: host, RetryWait: 1000, MaxRetry: math.MaxInt32})
+	log, err := newWriter(net.JoinHostPort(host, strconv.Itoa(port)), opts.bufferLimit, opts.retryWait, opts.maxRetries, opts.async)
 	if err != nil {
 		return nil, err
 	}
@@ -70,7 +79,7 @@ func New(ctx logger.Context) (logger.Logger, error) {
 }
 
 func (f *fluentd) Log(msg *logger.Message) error {
-	data := map[string]string{
+	data := map[string]interface{}{
 		"container_id":   f.containerID,
 		"container_name": f.containerName,
 		"source":         msg.Source,
@@ -79,24 +88,36 @@ func (f *fluentd) Log(msg *logger.Message) error {
 	for k, v := range f.extra {
 		data[k] = v
 	}
-	// fluent-logger-golang buffers logs from failures and disconnections,
-	// and these are transferred again automatically.
-	return f.writer.PostWithTime(f.tag, msg.Timestamp, data)
+	record := &fluent.Message{Tag: f.tag, Time: msg.Timestamp.Unix(), Record: data}
+	b, err := record.MarshalMsg(nil)
+	if err != nil {
+		return fmt.Errorf("fluentd: cannot encode log message: %v", err)
+	}
+	// The writer buffers the records while fluentd is unreachable, and
+	// sends them once it is reachable again.
+	f.writer.post(b)
+	return nil
 }
 
 func (f *fluentd) Close() error {
-	return f.writer.Close()
+	return f.writer.close()
 }
 
 func (f *fluentd) Name() string {
 	return name
 }
 
-// ValidateLogOpt looks for fluentd specific log options fluentd-address & fluentd-tag.
+// ValidateLogOpt looks for fluentd specific log options fluentd-address,
+// fluentd-buffer-limit, fluentd-retry-wait, fluentd-max-retries,
+// fluentd-async-connect & fluentd-tag.
 func ValidateLogOpt(cfg map[string]string) error {
 	for key := range cfg {
 		switch key {
 		case "fluentd-address":
+		case "fluentd-buffer-limit":
+		case "fluentd-retry-wait":
+		case "fluentd-max-retries":
+		case "fluentd-async-connect":
 		case "fluentd-tag":
 		case "tag":
 		case "labels":
@@ -109,10 +130,57 @@ func ValidateLogOpt(cfg map[string]string) error {
 	if _, _, err := parseAddress(cfg["fluentd-address"]); err != nil {
 		return err
 	}
+	if _, err := parseOptions(cfg); err != nil {
+		return err
+	}
 
 	return nil
 }
 
+type options struct {
+	bufferLimit int
+	retryWait   time.Duration
+	maxRetries  int
+	async       bool
+}
+
+func parseOptions(cfg map[string]string) (options, error) {
+	opts := options{
+		bufferLimit: defaultBufferLimit,
+		retryWait:   defaultRetryWait,
+		maxRetries:  defaultMaxRetries,
+	}
+	if s, ok := cfg["fluentd-buffer-limit"]; ok {
+		limit, err := units.RAMInBytes(s)
+		if err != nil || limit <= 0 || limit > math.MaxInt32 {
+			return opts, fmt.Errorf("invalid fluentd-buffer-limit %s", s)
+		}
+		opts.bufferLimit = int(limit)
+	}
+	if s, ok := cfg["fluentd-retry-wait"]; ok {
+		wait, err := time.ParseDuration(s)
+		if err != nil || wait <= 0 {
+			return opts, fmt.Errorf("invalid fluentd-retry-wait %s", s)
+		}
+		opts.retryWait = wait
+	}
+	if s, ok := cfg["fluentd-max-retries"]; ok {
+		retries, err := strconv.Atoi(s)
+		if err != nil || retries < 0 {
+			return opts, fmt.Errorf("invalid fluentd-max-retries %s", s)
+		}
+		opts.maxRetries = retries
+	}
+	if s, ok := cfg["fluentd-async-connect"]; ok {
+		async, err := strconv.ParseBool(s)
+		if err != nil {
+			return opts, fmt.Errorf("invalid fluentd-async-connect %s", s)
+		}
+		opts.async = async
+	}
+	return opts, nil
+}
+
 func parseAddress(address string) (string, int, error) {
 	if address == "" {
 		return defaultHostName, defaultPort, nil
@@ -0,0 +1,175 @@
+package fluentd
+
+import (
+	"net"
+	"sync"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+)
+
+const (
+	// dialTimeout is the timeout to connect to fluentd.
+	dialTimeout = 3 * time.Second
+	// maxRetryWait is the maximum wait between two connection attempts.
+	maxRetryWait = time.Minute
+)
+
+// writer sends the encoded records to fluentd from a goroutine, so that the
+// containers are not blocked while fluentd is unreachable. The records are
+// buffered up to bufferLimit bytes meanwhile, the oldest ones being dropped
+// once the buffer is full. After maxRetries failed connection attempts, the
+// records being sent are dropped.
+type writer struct {
+	address     string
+	bufferLimit int
+	retryWait   time.Duration
+	maxRetries  int
+
+	mu      sync.Mutex
+	cond    *sync.Cond
+	pending [][]byte
+	size    int
+	dropped int
+	closed  bool
+	conn    net.Conn
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// newWriter returns a writer sending the records to address. The writer is
+// connected to fluentd unless async is true, in which case it connects in
+// the background.
+func newWriter(address string, bufferLimit int, retryWait time.Duration, maxRetries int, async bool) (*writer, error) {
+	w := &writer{
+		address:     address,
+		bufferLimit: bufferLimit,
+		retryWait:   retryWait,
+		maxRetries:  maxRetries,
+		stop:        make(chan struct{}),
+		done:        make(chan struct{}),
+	}
+	w.cond = sync.NewCond(&w.mu)
+	if !async {
+		conn, err := net.DialTimeout("tcp", address, dialTimeout)
+		if err != nil {
+			return nil, err
+		}
+		w.conn = conn
+	}
+	go w.run()
+	return w, nil
+}
+
+// post buffers a record to send it to fluentd.
+func (w *writer) post(record []byte) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return
+	}
+	for len(w.pending) > 0 && w.size+len(record) > w.bufferLimit {
+		w.size -= len(w.pending[0])
+		w.pending = w.pending[1:]
+		w.dropped++
+	}
+	w.pending = append(w.pending, record)
+	w.size += len(record)
+	w.cond.Signal()
+}
+
+// run sends the buffered records until the writer is closed, then tries to
+// send the remaining ones once.
+func (w *writer) run() {
+	defer close(w.done)
+	for {
+		w.mu.Lock()
+		for len(w.pending) == 0 && !w.closed {
+			w.cond.Wait()
+		}
+		batch, closed, dropped := w.pending, w.closed, w.dropped
+		w.pending, w.size, w.dropped = nil, 0, 0
+		w.mu.Unlock()
+
+		if dropped > 0 {
+			logrus.Warnf("fluentd buffer full, dropped %d log messages for %s", dropped, w.address)
+		}
+		if closed {
+			if len(batch) > 0 {
+				if unsent, err := w.send(batch); err != nil {
+					logrus.Errorf("Failed to send %d log messages to fluentd at %s: %v", len(unsent), w.address, err)
+				}
+			}
+			if w.conn != nil {
+				w.conn.Close()
+			}
+			return
+		}
+		w.sendWithRetries(batch)
+	}
+}
+
+// sendWithRetries sends the batch, reconnecting up to maxRetries times.
+func (w *writer) sendWithRetries(batch [][]byte) {
+	wait := w.retryWait
+	for retry := 0; ; retry++ {
+		var err error
+		if batch, err = w.send(batch); err == nil {
+			return
+		}
+		if retry >= w.maxRetries {
+			logrus.Errorf("Failed to send %d log messages to fluentd at %s after %d retries, dropping them: %v", len(batch), w.address, retry, err)
+			return
+		}
+		logrus.Debugf("Failed to send log messages to fluentd at %s, retrying in %v: %v", w.address, wait, err)
+		select {
+		case <-w.stop:
+			if batch, err = w.send(batch); err != nil {
+				logrus.Errorf("Failed to send %d log messages to fluentd at %s before closing: %v", len(batch), w.address, err)
+			}
+			return
+		case <-time.After(wait):
+		}
+		if wait *= 2; wait > maxRetryWait {
+			wait = maxRetryWait
+		}
+	}
+}
+
+// send writes the records to the connection, connecting first if needed,
+// and returns the records which could not be written. The connection is
+// closed if a write fails.
+func (w *writer) send(batch [][]byte) ([][]byte, error) {
+	if w.conn == nil {
+		conn, err := net.DialTimeout("tcp", w.address, dialTimeout)
+		if err != nil {
+			return batch, err
+		}
+		w.conn = conn
+	}
+	for len(batch) > 0 {
+		if _, err := w.conn.Write(batch[0]); err != nil {
+			w.conn.Close()
+			w.conn = nil
+			return batch, err
+		}
+		batch = batch[1:]
+	}
+	return nil, nil
+}
+
+// close stops the writer, trying to send the buffered records once.
+func (w *writer) close() error {
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return nil
+	}
+	w.closed = true
+	close(w.stop)
+	w.cond.Signal()
+	w.mu.Unlock()
+	<-w.done
+	return nil
+}
@@ -0,0 +1,106 @@
+package fluentd
+
+import (
+	"io/ioutil"
+	"net"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestWriterBufferLimit(t *testing.T) {
+	w := &writer{bufferLimit: 10}
+	w.cond = sync.NewCond(&w.mu)
+	for _, record := range []string{"aaaa", "bbbb", "cccc"} {
+		w.post([]byte(record))
+	}
+	if len(w.pending) != 2 || string(w.pending[0]) != "bbbb" || w.size != 8 || w.dropped != 1 {
+		t.Fatalf("Expected the oldest record to be dropped, got %q", w.pending)
+	}
+}
+
+func TestWriterSyncConnect(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := l.Addr().String()
+	l.Close()
+
+	if _, err := newWriter(addr, defaultBufferLimit, time.Millisecond, 0, false); err == nil {
+		t.Fatal("Expected the connection to fluentd to fail")
+	}
+}
+
+func TestWriterAsyncConnect(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := l.Addr().String()
+	l.Close()
+
+	w, err := newWriter(addr, defaultBufferLimit, 10*time.Millisecond, defaultMaxRetries, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.post([]byte("first "))
+	w.post([]byte("second"))
+	time.Sleep(50 * time.Millisecond)
+
+	// fluentd becomes reachable after the first attempts failed.
+	l, err = net.Listen("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	received := make(chan string)
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		b, _ := ioutil.ReadAll(conn)
+		received <- string(b)
+	}()
+
+	time.Sleep(200 * time.Millisecond)
+	if err := w.close(); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case b := <-received:
+		if b != "first second" {
+			t.Fatalf("Expected the buffered records, got %q", b)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timeout waiting for the records")
+	}
+}
+
+func TestParseOptions(t *testing.T) {
+	opts, err := parseOptions(map[string]string{
+		"fluentd-buffer-limit":  "1m",
+		"fluentd-retry-wait":    "500ms",
+		"fluentd-max-retries":   "5",
+		"fluentd-async-connect": "true",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := options{bufferLimit: 1024 * 1024, retryWait: 500 * time.Millisecond, maxRetries: 5, async: true}
+	if opts != expected {
+		t.Fatalf("Expected %+v, got %+v", expected, opts)
+	}
+
+	for _, cfg := range []map[string]string{
+		{"fluentd-buffer-limit": "0"},
+		{"fluentd-retry-wait": "soon"},
+		{"fluentd-max-retries": "-1"},
+		{"fluentd-async-connect": "maybe"},
+	} {
+		if _, err := parseOptions(cfg); err == nil {
+			t.Fatalf("Expected %v to be invalid", cfg)
+		}
+	}
+}
@@ -6,9 +6,11 @@ package gelf
 
 import (
 	"bytes"
+	"compress/flate"
 	"fmt"
 	"net"
 	"net/url"
+	"strconv"
 	"time"
 
 	"github.com/Graylog2/go-gelf/gelf"
@@ -20,8 +22,14 @@ import (
 
 const name = "gelf"
 
+// gelfWriter sends GELF messages to an endpoint.
+type gelfWriter interface {
+	WriteMessage(*gelf.Message) error
+	Close() error
+}
+
 type gelfLogger struct {
-	writer   *gelf.Writer
+	writer   gelfWriter
 	ctx      logger.Context
 	hostname string
 	extra    map[string]interface{}
@@ -37,8 +45,8 @@ func init() {
 }
 
 // New creates a gelf logger using the configuration passed in on the
-// context. Supported context configuration variables are
-// gelf-address, & gelf-tag.
+// context. Supported context configuration variables are gelf-address,
+// gelf-compression-type, gelf-compression-level & gelf-tag.
 func New(ctx logger.Context) (logger.Logger, error) {
 	// parse gelf address
 	address, err := parseAddress(ctx.Config["gelf-address"])
@@ -82,13 +90,29 @@ func New(ctx logger.Context) (logger.Logger, error) {
 	}
 
 	// create new gelfWriter
-	gelfWriter, err := gelf.NewWriter(address)
-	if err != nil {
-		return nil, fmt.Errorf("gelf: cannot connect to GELF endpoint: %s %v", address, err)
+	var writer gelfWriter
+	switch address.Scheme {
+	case "tcp":
+		writer, err = newTCPWriter(address.Host)
+		if err != nil {
+			return nil, fmt.Errorf("gelf: cannot connect to GELF endpoint: %s %v", address.Host, err)
+		}
+	default:
+		compressionType, compressionLevel, err := parseCompression(ctx.Config)
+		if err != nil {
+			return nil, err
+		}
+		udpWriter, err := gelf.NewWriter(address.Host)
+		if err != nil {
+			return nil, fmt.Errorf("gelf: cannot connect to GELF endpoint: %s %v", address.Host, err)
+		}
+		udpWriter.CompressionType = compressionType
+		udpWriter.CompressionLevel = compressionLevel
+		writer = udpWriter
 	}
 
 	return &gelfLogger{
-		writer:   gelfWriter,
+		writer:   writer,
 		ctx:      ctx,
 		hostname: hostname,
 		extra:    extra,
@@ -124,12 +148,14 @@ func (s *gelfLogger) Name() string {
 	return name
 }
 
-// ValidateLogOpt looks for gelf specific log options gelf-address, &
-// gelf-tag.
+// ValidateLogOpt looks for gelf specific log options gelf-address,
+// gelf-compression-type, gelf-compression-level & gelf-tag.
 func ValidateLogOpt(cfg map[string]string) error {
 	for key := range cfg {
 		switch key {
 		case "gelf-address":
+		case "gelf-compression-type":
+		case "gelf-compression-level":
 		case "gelf-tag":
 		case "tag":
 		case "labels":
@@ -139,34 +165,64 @@ func ValidateLogOpt(cfg map[string]string) error {
 		}
 	}
 
-	if _, err := parseAddress(cfg["gelf-address"]); err != nil {
+	address, err := parseAddress(cfg["gelf-address"])
+	if err != nil {
 		return err
 	}
+	if _, _, err := parseCompression(cfg); err != nil {
+		return err
+	}
+	if address.Scheme == "tcp" && (cfg["gelf-compression-type"] != "" || cfg["gelf-compression-level"] != "") {
+		return fmt.Errorf("gelf: compression is not supported with TCP endpoints")
+	}
 
 	return nil
 }
 
-func parseAddress(address string) (string, error) {
+func parseAddress(address string) (*url.URL, error) {
 	if address == "" {
-		return "", nil
+		return &url.URL{}, nil
 	}
 	if !urlutil.IsTransportURL(address) {
-		return "", fmt.Errorf("gelf-address should be in form proto://address, got %v", address)
+		return nil, fmt.Errorf("gelf-address should be in form proto://address, got %v", address)
 	}
 	url, err := url.Parse(address)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
-	// we support only udp
-	if url.Scheme != "udp" {
-		return "", fmt.Errorf("gelf: endpoint needs to be UDP")
+	// we support only udp and tcp
+	if url.Scheme != "udp" && url.Scheme != "tcp" {
+		return nil, fmt.Errorf("gelf: endpoint needs to be UDP or TCP")
 	}
 
 	// get host and port
 	if _, _, err = net.SplitHostPort(url.Host); err != nil {
-		return "", fmt.Errorf("gelf: please provide gelf-address as udp://host:port")
+		return nil, fmt.Errorf("gelf: please provide gelf-address as %s://host:port", url.Scheme)
 	}
 
-	return url.Host, nil
+	return url, nil
+}
+
+// parseCompression returns the compression type and level of the messages
+// sent over UDP, gzip with the best speed by default.
+func parseCompression(cfg map[string]string) (gelf.CompressType, int, error) {
+	compressionType := gelf.CompressGzip
+	switch cfg["gelf-compression-type"] {
+	case "", "gzip":
+	case "zlib":
+		compressionType = gelf.CompressZlib
+	default:
+		return 0, 0, fmt.Errorf("gelf: unknown compression type %q, it must be gzip or zlib", cfg["gelf-compression-type"])
+	}
+
+	compressionLevel := flate.BestSpeed
+	if s, ok := cfg["gelf-compression-level"]; ok {
+		level, err := strconv.Atoi(s)
+		if err != nil || level < flate.DefaultCompression || level > flate.BestCompression {
+			return 0, 0, fmt.Errorf("gelf: invalid compression level %q, it must be between %d and %d", s, flate.DefaultCompression, flate.BestCompression)
+		}
+		compressionLevel = level
+	}
+	return compressionType, compressionLevel, nil
 }
@@ -0,0 +1,85 @@
+// +build linux
+
+package gelf
+
+import (
+	"bufio"
+	"encoding/json"
+	"net"
+	"testing"
+
+	"github.com/Graylog2/go-gelf/gelf"
+)
+
+func TestValidateLogOpt(t *testing.T) {
+	for _, cfg := range []map[string]string{
+		{"gelf-address": "udp://127.0.0.1:12201"},
+		{"gelf-address": "tcp://127.0.0.1:12201"},
+		{"gelf-address": "udp://127.0.0.1:12201", "gelf-compression-type": "zlib", "gelf-compression-level": "9"},
+		{"gelf-address": "udp://127.0.0.1:12201", "gelf-compression-level": "-1"},
+	} {
+		if err := ValidateLogOpt(cfg); err != nil {
+			t.Fatalf("Expected %v to be valid, got %v", cfg, err)
+		}
+	}
+
+	for _, cfg := range []map[string]string{
+		{"gelf-address": "http://127.0.0.1:12201"},
+		{"gelf-address": "tcp://127.0.0.1"},
+		{"gelf-address": "udp://127.0.0.1:12201", "gelf-compression-type": "lz4"},
+		{"gelf-address": "udp://127.0.0.1:12201", "gelf-compression-level": "10"},
+		{"gelf-address": "tcp://127.0.0.1:12201", "gelf-compression-type": "gzip"},
+		{"gelf-unknown": "value"},
+	} {
+		if err := ValidateLogOpt(cfg); err == nil {
+			t.Fatalf("Expected %v to be invalid", cfg)
+		}
+	}
+}
+
+func TestTCPWriter(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	received := make(chan map[string]interface{}, 2)
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+		for {
+			b, err := r.ReadBytes(0)
+			if err != nil {
+				return
+			}
+			var m map[string]interface{}
+			if err := json.Unmarshal(b[:len(b)-1], &m); err != nil {
+				t.Error(err)
+				return
+			}
+			received <- m
+		}
+	}()
+
+	w, err := newTCPWriter(l.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	for _, short := range []string{"first", "second"} {
+		if err := w.WriteMessage(&gelf.Message{Version: "1.1", Short: short, Extra: map[string]interface{}{"_container_id": "abc"}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, short := range []string{"first", "second"} {
+		m := <-received
+		if m["short_message"] != short || m["_container_id"] != "abc" {
+			t.Fatalf("Unexpected message %v", m)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+// +build linux
+
+package gelf
+
+import (
+	"encoding/json"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/Graylog2/go-gelf/gelf"
+)
+
+// tcpDialTimeout is the timeout to connect to a GELF endpoint over TCP.
+const tcpDialTimeout = 10 * time.Second
+
+// tcpWriter sends GELF messages over TCP. The messages are not compressed
+// and are terminated by a null byte, as expected by the GELF TCP inputs.
+// The connection is reopened once if a message cannot be sent.
+type tcpWriter struct {
+	mu   sync.Mutex
+	addr string
+	conn net.Conn
+}
+
+func newTCPWriter(addr string) (*tcpWriter, error) {
+	conn, err := net.DialTimeout("tcp", addr, tcpDialTimeout)
+	if err != nil {
+		return nil, err
+	}
+	return &tcpWriter{addr: addr, conn: conn}, nil
+}
+
+func (w *tcpWriter) WriteMessage(m *gelf.Message) error {
+	b, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	b = append(b, 0)
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.conn != nil {
+		if _, err = w.conn.Write(b); err == nil {
+			return nil
+		}
+		w.conn.Close()
+		w.conn = nil
+	}
+	conn, err := net.DialTimeout("tcp", w.addr, tcpDialTimeout)
+	if err != nil {
+		return err
+	}
+	w.conn = conn
+	_, err = w.conn.Write(b)
+	return err
+}
+
+func (w *tcpWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.conn == nil {
+		return nil
+	}
+	err := w.conn.Close()
+	w.conn = nil
+	return err
+}
@@ -53,6 +53,12 @@ func New(ctx logger.Context) (logger.Logger, error) {
 		"CONTAINER_ID_FULL": ctx.ContainerID,
 		"CONTAINER_NAME":    name,
 	}
+	if ctx.ContainerImageName != "" {
+		vars["CONTAINER_IMAGE"] = ctx.ContainerImageName
+	}
+	if ctx.ContainerImageID != "" {
+		vars["CONTAINER_IMAGE_ID"] = ctx.ContainerImageID
+	}
 	extraAttrs := ctx.ExtraAttributes(strings.ToTitle)
 	for k, v := range extraAttrs {
 		vars[k] = v
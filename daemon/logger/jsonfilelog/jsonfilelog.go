@@ -42,24 +42,9 @@ func init() {
 // New creates new JSONFileLogger which writes to filename passed in
 // on given context.
 func New(ctx logger.Context) (logger.Logger, error) {
-	var capval int64 = -1
-	if capacity, ok := ctx.Config["max-size"]; ok {
-		var err error
-		capval, err = units.FromHumanSize(capacity)
-		if err != nil {
-			return nil, err
-		}
-	}
-	var maxFiles = 1
-	if maxFileString, ok := ctx.Config["max-file"]; ok {
-		var err error
-		maxFiles, err = strconv.Atoi(maxFileString)
-		if err != nil {
-			return nil, err
-		}
-		if maxFiles < 1 {
-			return nil, fmt.Errorf("max-file cannot be less than 1")
-		}
+	capval, maxFiles, err := parseRotateOpts(ctx.Config)
+	if err != nil {
+		return nil, err
 	}
 
 	writer, err := loggerutils.NewRotateFileWriter(ctx.LogPath, capval, maxFiles)
@@ -107,6 +92,35 @@ func (l *JSONFileLogger) Log(msg *logger.Message) error {
 	return err
 }
 
+// parseRotateOpts returns the maximum size of the log files given by the
+// max-size log option, -1 if the logs are not rotated, and their maximum
+// number given by max-file.
+func parseRotateOpts(cfg map[string]string) (int64, int, error) {
+	var capval int64 = -1
+	if capacity, ok := cfg["max-size"]; ok {
+		var err error
+		capval, err = units.FromHumanSize(capacity)
+		if err != nil {
+			return 0, 0, err
+		}
+		if capval <= 0 {
+			return 0, 0, fmt.Errorf("max-size must be a positive number")
+		}
+	}
+	var maxFiles = 1
+	if maxFileString, ok := cfg["max-file"]; ok {
+		var err error
+		maxFiles, err = strconv.Atoi(maxFileString)
+		if err != nil {
+			return 0, 0, err
+		}
+		if maxFiles < 1 {
+			return 0, 0, fmt.Errorf("max-file cannot be less than 1")
+		}
+	}
+	return capval, maxFiles, nil
+}
+
 // ValidateLogOpt looks for json specific log options max-file & max-size.
 func ValidateLogOpt(cfg map[string]string) error {
 	for key := range cfg {
@@ -119,7 +133,8 @@ func ValidateLogOpt(cfg map[string]string) error {
 			return fmt.Errorf("unknown log opt '%s' for json-file log driver", key)
 		}
 	}
-	return nil
+	_, _, err := parseRotateOpts(cfg)
+	return err
 }
 
 // LogPath returns the location the given json logger logs to.
@@ -152,6 +152,66 @@ func TestJSONFileLoggerWithOpts(t *testing.T) {
 
 }
 
+func TestJSONFileLoggerReadRotated(t *testing.T) {
+	cid := "a7317399f3f857173c6179d44823594f8294678dea9999662e5c625b5a1c7657"
+	tmp, err := ioutil.TempDir("", "docker-logger-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+	filename := filepath.Join(tmp, "container.log")
+	config := map[string]string{"max-file": "3", "max-size": "1k"}
+	l, err := New(logger.Context{
+		ContainerID: cid,
+		LogPath:     filename,
+		Config:      config,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	for i := 0; i < 40; i++ {
+		if err := l.Log(&logger.Message{ContainerID: cid, Line: []byte("line" + strconv.Itoa(i)), Source: "src1"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := os.Stat(filename + ".2"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filename + ".tmp"); !os.IsNotExist(err) {
+		t.Fatalf("expected no temporary log file, got %v", err)
+	}
+
+	watcher := l.(logger.LogReader).ReadLogs(logger.ReadConfig{Tail: -1})
+	var i int
+	for msg := range watcher.Msg {
+		if expected := "line" + strconv.Itoa(i) + "\n"; string(msg.Line) != expected {
+			t.Fatalf("Wrong log line: %q, expected %q", msg.Line, expected)
+		}
+		i++
+	}
+	if i != 40 {
+		t.Fatalf("Expected 40 log lines from the rotated files, got %d", i)
+	}
+}
+
+func TestValidateLogOpt(t *testing.T) {
+	if err := ValidateLogOpt(map[string]string{"max-file": "3", "max-size": "10m"}); err != nil {
+		t.Fatal(err)
+	}
+	for _, cfg := range []map[string]string{
+		{"max-size": "ten"},
+		{"max-size": "0"},
+		{"max-file": "0"},
+		{"max-file": "three"},
+		{"max-files": "3"},
+	} {
+		if err := ValidateLogOpt(cfg); err == nil {
+			t.Fatalf("Expected error for %v", cfg)
+		}
+	}
+}
+
 func TestJSONFileLoggerWithLabelsEnv(t *testing.T) {
 	cid := "a7317399f3f857173c6179d44823594f8294678dea9999662e5c625b5a1c7657"
 	tmp, err := ioutil.TempDir("", "docker-logger-")
@@ -199,3 +259,73 @@ func TestJSONFileLoggerWithLabelsEnv(t *testing.T) {
 		t.Fatalf("Wrong log attrs: %q, expected %q", extra, expected)
 	}
 }
+
+// nopLogger is a log driver which cannot read its logs back.
+type nopLogger struct{}
+
+func (nopLogger) Log(*logger.Message) error { return nil }
+func (nopLogger) Name() string              { return "nop" }
+func (nopLogger) Close() error              { return nil }
+
+func TestJSONFileLoggerAsReadCache(t *testing.T) {
+	cid := "a7317399f3f857173c6179d44823594f8294678dea9999662e5c625b5a1c7657"
+	tmp, err := ioutil.TempDir("", "docker-logger-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+	cache, err := New(logger.Context{
+		ContainerID: cid,
+		LogPath:     filepath.Join(tmp, "container-cache.log"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	l := logger.NewReadCachingLogger(nopLogger{}, cache)
+	defer l.Close()
+
+	start := time.Now().Add(-time.Minute)
+	for i := 0; i < 5; i++ {
+		msg := &logger.Message{ContainerID: cid, Line: []byte("line" + strconv.Itoa(i)), Source: "stdout", Timestamp: start.Add(time.Duration(i) * time.Second)}
+		if err := l.Log(msg); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	read := func(config logger.ReadConfig) []string {
+		var lines []string
+		for m := range l.(logger.LogReader).ReadLogs(config).Msg {
+			lines = append(lines, string(m.Line))
+		}
+		return lines
+	}
+	for _, c := range []struct {
+		config   logger.ReadConfig
+		expected []string
+	}{
+		{logger.ReadConfig{Tail: -1}, []string{"line0\n", "line1\n", "line2\n", "line3\n", "line4\n"}},
+		{logger.ReadConfig{Tail: 2}, []string{"line3\n", "line4\n"}},
+		{logger.ReadConfig{Tail: -1, Since: start.Add(2500 * time.Millisecond)}, []string{"line3\n", "line4\n"}},
+	} {
+		if lines := read(c.config); !reflect.DeepEqual(lines, c.expected) {
+			t.Fatalf("Expected %v with %+v, got %v", c.expected, c.config, lines)
+		}
+	}
+
+	watcher := l.(logger.LogReader).ReadLogs(logger.ReadConfig{Tail: 1, Follow: true})
+	defer watcher.Close()
+	if m := <-watcher.Msg; string(m.Line) != "line4\n" {
+		t.Fatalf("Expected the last line, got %q", m.Line)
+	}
+	// let the watcher reach the end of the log before logging
+	time.Sleep(100 * time.Millisecond)
+	go l.Log(&logger.Message{ContainerID: cid, Line: []byte("line5"), Source: "stdout", Timestamp: time.Now()})
+	select {
+	case m := <-watcher.Msg:
+		if string(m.Line) != "line5\n" {
+			t.Fatalf("Expected the followed line, got %q", m.Line)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected the line logged after to be followed")
+	}
+}
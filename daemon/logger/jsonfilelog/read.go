@@ -3,7 +3,6 @@ package jsonfilelog
 import (
 	"bytes"
 	"encoding/json"
-	"fmt"
 	"io"
 	"os"
 	"time"
@@ -43,29 +42,18 @@ func (l *JSONFileLogger) ReadLogs(config logger.ReadConfig) *logger.LogWatcher {
 func (l *JSONFileLogger) readLogs(logWatcher *logger.LogWatcher, config logger.ReadConfig) {
 	defer close(logWatcher.Msg)
 
-	pth := l.writer.LogPath()
-	var files []io.ReadSeeker
-	for i := l.writer.MaxFiles(); i > 1; i-- {
-		f, err := os.Open(fmt.Sprintf("%s.%d", pth, i-1))
-		if err != nil {
-			if !os.IsNotExist(err) {
-				logWatcher.Err <- err
-				break
-			}
-			continue
-		}
-		defer f.Close()
-		files = append(files, f)
-	}
-
-	latestFile, err := os.Open(pth)
+	logFiles, err := l.writer.OpenFiles()
 	if err != nil {
 		logWatcher.Err <- err
 		return
 	}
-	defer latestFile.Close()
+	files := make([]io.ReadSeeker, 0, len(logFiles))
+	for _, f := range logFiles {
+		defer f.Close()
+		files = append(files, f)
+	}
+	latestFile := logFiles[len(logFiles)-1]
 
-	files = append(files, latestFile)
 	tailer := ioutils.MultiReadSeeker(files...)
 
 	if config.Tail != 0 {
@@ -180,11 +168,30 @@ func followLogs(f *os.File, logWatcher *logger.LogWatcher, notifyRotate chan int
 				fileWatcher.Remove(f.Name())
 				return
 			case <-notifyRotate:
+				// Send the messages written to the rotated file before it
+				// was swapped, then follow the new log file.
+				for {
+					msg, err := decodeLogLine(dec, l)
+					if err != nil {
+						break
+					}
+					if !since.IsZero() && msg.Timestamp.Before(since) {
+						continue
+					}
+					select {
+					case logWatcher.Msg <- msg:
+					case <-logWatcher.WatchClose():
+						fileWatcher.Remove(f.Name())
+						return
+					}
+				}
+				rotated := f
 				f, err = os.Open(f.Name())
 				if err != nil {
 					logWatcher.Err <- err
 					return
 				}
+				rotated.Close()
 
 				dec = json.NewDecoder(f)
 				fileWatcher.Remove(f.Name())
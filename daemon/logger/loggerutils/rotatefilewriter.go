@@ -11,6 +11,7 @@ import (
 // RotateFileWriter is Logger implementation for default Docker logging.
 type RotateFileWriter struct {
 	f            *os.File // store for closing
+	name         string   // path of the log file
 	mu           sync.Mutex
 	capacity     int64 //maximum size of each file
 	maxFiles     int   //maximum number of files
@@ -26,6 +27,7 @@ func NewRotateFileWriter(logPath string, capacity int64, maxFiles int) (*RotateF
 
 	return &RotateFileWriter{
 		f:            log,
+		name:         logPath,
 		capacity:     capacity,
 		maxFiles:     maxFiles,
 		notifyRotate: pubsub.NewPublisher(0, 1),
@@ -54,17 +56,11 @@ func (w *RotateFileWriter) checkCapacityAndRotate() error {
 	}
 
 	if meta.Size() >= w.capacity {
-		name := w.f.Name()
-		if err := w.f.Close(); err != nil {
-			return err
-		}
-		if err := rotate(name, w.maxFiles); err != nil {
-			return err
-		}
-		file, err := os.OpenFile(name, os.O_WRONLY|os.O_TRUNC|os.O_CREATE, 06400)
+		file, err := rotate(w.name, w.maxFiles)
 		if err != nil {
 			return err
 		}
+		w.f.Close()
 		w.f = file
 		w.notifyRotate.Publish(struct{}{})
 	}
@@ -72,43 +68,71 @@ func (w *RotateFileWriter) checkCapacityAndRotate() error {
 	return nil
 }
 
-func rotate(name string, maxFiles int) error {
-	if maxFiles < 2 {
-		return nil
-	}
-	for i := maxFiles - 1; i > 1; i-- {
-		toPath := name + "." + strconv.Itoa(i)
-		fromPath := name + "." + strconv.Itoa(i-1)
-		if err := backup(fromPath, toPath); err != nil && !os.IsNotExist(err) {
-			return err
+// rotate shifts the rotated files and swaps the log file for an empty one,
+// which is returned. The log file is hard-linked as the first rotated file
+// and replaced by renaming the new one over it, so that it never goes
+// missing for the readers.
+func rotate(name string, maxFiles int) (*os.File, error) {
+	if maxFiles > 1 {
+		for i := maxFiles - 1; i > 1; i-- {
+			toPath := name + "." + strconv.Itoa(i)
+			fromPath := name + "." + strconv.Itoa(i-1)
+			if err := os.Rename(fromPath, toPath); err != nil && !os.IsNotExist(err) {
+				return nil, err
+			}
+		}
+		if err := os.Remove(name + ".1"); err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+		if err := os.Link(name, name+".1"); err != nil {
+			return nil, err
 		}
 	}
 
-	if err := backup(name, name+".1"); err != nil {
-		return err
+	tmpPath := name + ".tmp"
+	file, err := os.OpenFile(tmpPath, os.O_WRONLY|os.O_APPEND|os.O_CREATE|os.O_TRUNC, 0640)
+	if err != nil {
+		return nil, err
 	}
-	return nil
+	if err := os.Rename(tmpPath, name); err != nil {
+		file.Close()
+		os.Remove(tmpPath)
+		return nil, err
+	}
+	return file, nil
 }
 
-// backup renames a file from fromPath to toPath
-func backup(fromPath, toPath string) error {
-	if _, err := os.Stat(fromPath); os.IsNotExist(err) {
-		return err
-	}
+// OpenFiles opens the rotated files, oldest first, followed by the log file.
+// The files are not rotated meanwhile, so that they hold the full history of
+// the log without gaps.
+func (w *RotateFileWriter) OpenFiles() ([]*os.File, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
 
-	if _, err := os.Stat(toPath); !os.IsNotExist(err) {
-		err := os.Remove(toPath)
+	var files []*os.File
+	for i := w.maxFiles - 1; i >= 0; i-- {
+		pth := w.name
+		if i > 0 {
+			pth = w.name + "." + strconv.Itoa(i)
+		}
+		f, err := os.Open(pth)
 		if err != nil {
-			return err
+			if i > 0 && os.IsNotExist(err) {
+				continue
+			}
+			for _, f := range files {
+				f.Close()
+			}
+			return nil, err
 		}
+		files = append(files, f)
 	}
-
-	return os.Rename(fromPath, toPath)
+	return files, nil
 }
 
 // LogPath returns the location the given writer logs to.
 func (w *RotateFileWriter) LogPath() string {
-	return w.f.Name()
+	return w.name
 }
 
 // MaxFiles return maximum number of files
@@ -0,0 +1,97 @@
+package logger
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/docker/go-units"
+)
+
+// readCacheOptPrefix prefixes the log options of the read cache, which keeps
+// a local copy of the logs sent to the drivers which cannot read them back.
+const readCacheOptPrefix = "cache-"
+
+// SplitReadCacheOpts separates the options of the read cache from the options
+// of the log driver. The options of the read cache are returned without
+// their prefix.
+func SplitReadCacheOpts(cfg map[string]string) (driverCfg, cacheCfg map[string]string) {
+	driverCfg = make(map[string]string, len(cfg))
+	cacheCfg = make(map[string]string)
+	for k, v := range cfg {
+		if strings.HasPrefix(k, readCacheOptPrefix) {
+			cacheCfg[strings.TrimPrefix(k, readCacheOptPrefix)] = v
+		} else {
+			driverCfg[k] = v
+		}
+	}
+	return driverCfg, cacheCfg
+}
+
+// ReadCacheEnabled reports whether the read cache is enabled by its options.
+// It is enabled by default.
+func ReadCacheEnabled(cacheCfg map[string]string) bool {
+	v, ok := cacheCfg["enabled"]
+	if !ok {
+		return true
+	}
+	enabled, _ := strconv.ParseBool(v)
+	return enabled
+}
+
+// validateReadCacheOpts checks the options of the read cache.
+func validateReadCacheOpts(cacheCfg map[string]string) error {
+	for k, v := range cacheCfg {
+		switch k {
+		case "enabled":
+			if _, err := strconv.ParseBool(v); err != nil {
+				return fmt.Errorf("invalid value for log opt '%s%s': %s", readCacheOptPrefix, k, v)
+			}
+		case "max-size":
+			if size, err := units.FromHumanSize(v); err != nil || size <= 0 {
+				return fmt.Errorf("invalid value for log opt '%s%s': %s", readCacheOptPrefix, k, v)
+			}
+		case "max-file":
+			if n, err := strconv.Atoi(v); err != nil || n < 1 {
+				return fmt.Errorf("invalid value for log opt '%s%s': %s", readCacheOptPrefix, k, v)
+			}
+		default:
+			return fmt.Errorf("unknown log opt '%s%s'", readCacheOptPrefix, k)
+		}
+	}
+	return nil
+}
+
+// readCachingLogger sends the messages to a log driver which cannot read
+// them back, and to a local cache from which they are read.
+type readCachingLogger struct {
+	Logger
+	cache Logger
+}
+
+// NewReadCachingLogger returns a logger sending the messages to l and to
+// cache, which must implement LogReader to read them back.
+func NewReadCachingLogger(l Logger, cache Logger) Logger {
+	return &readCachingLogger{Logger: l, cache: cache}
+}
+
+func (l *readCachingLogger) Log(msg *Message) error {
+	err := l.Logger.Log(msg)
+	if cacheErr := l.cache.Log(msg); cacheErr != nil {
+		logrus.Warnf("Failed to cache the log message of container %s: %v", msg.ContainerID, cacheErr)
+	}
+	return err
+}
+
+func (l *readCachingLogger) ReadLogs(config ReadConfig) *LogWatcher {
+	return l.cache.(LogReader).ReadLogs(config)
+}
+
+func (l *readCachingLogger) Close() error {
+	err := l.Logger.Close()
+	if cacheErr := l.cache.Close(); cacheErr != nil && err == nil {
+		err = cacheErr
+	}
+	return err
+}
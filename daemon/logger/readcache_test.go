@@ -0,0 +1,106 @@
+package logger
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+type testReadLogger struct {
+	messages []*Message
+	closed   bool
+}
+
+func (l *testReadLogger) Log(m *Message) error {
+	l.messages = append(l.messages, m)
+	return nil
+}
+
+func (l *testReadLogger) Close() error {
+	l.closed = true
+	return nil
+}
+
+func (l *testReadLogger) Name() string { return "test" }
+
+func (l *testReadLogger) ReadLogs(config ReadConfig) *LogWatcher {
+	w := NewLogWatcher()
+	for _, m := range l.messages {
+		w.Msg <- m
+	}
+	close(w.Msg)
+	return w
+}
+
+func TestSplitReadCacheOpts(t *testing.T) {
+	driverCfg, cacheCfg := SplitReadCacheOpts(map[string]string{
+		"tag":            "web",
+		"cache-max-size": "10m",
+		"cache-enabled":  "true",
+	})
+	if expected := map[string]string{"tag": "web"}; !reflect.DeepEqual(driverCfg, expected) {
+		t.Fatalf("expected driver options %v, got %v", expected, driverCfg)
+	}
+	if expected := map[string]string{"max-size": "10m", "enabled": "true"}; !reflect.DeepEqual(cacheCfg, expected) {
+		t.Fatalf("expected cache options %v, got %v", expected, cacheCfg)
+	}
+	if !ReadCacheEnabled(cacheCfg) {
+		t.Fatal("expected read cache to be enabled")
+	}
+	if !ReadCacheEnabled(map[string]string{}) {
+		t.Fatal("expected read cache to be enabled by default")
+	}
+	if ReadCacheEnabled(map[string]string{"enabled": "false"}) {
+		t.Fatal("expected read cache to be disabled")
+	}
+}
+
+func TestValidateReadCacheOpts(t *testing.T) {
+	if err := validateReadCacheOpts(map[string]string{"enabled": "true", "max-size": "1m", "max-file": "2"}); err != nil {
+		t.Fatal(err)
+	}
+	for _, cfg := range []map[string]string{
+		{"enabled": "maybe"},
+		{"max-size": "0"},
+		{"max-file": "0"},
+		{"size": "1m"},
+	} {
+		if err := validateReadCacheOpts(cfg); err == nil {
+			t.Fatalf("expected error for %v", cfg)
+		}
+	}
+}
+
+func TestReadCachingLogger(t *testing.T) {
+	var buf bytes.Buffer
+	cache := &testReadLogger{}
+	l := NewReadCachingLogger(&TestLoggerText{Buffer: &buf}, cache)
+	if err := l.Log(&Message{ContainerID: "c", Source: "stdout", Line: []byte("line")}); err != nil {
+		t.Fatal(err)
+	}
+	if expected := "c stdout line\n"; buf.String() != expected {
+		t.Fatalf("expected %q to be logged, got %q", expected, buf.String())
+	}
+	if l.Name() != "text" {
+		t.Fatalf("expected the name of the log driver, got %s", l.Name())
+	}
+
+	reader, ok := l.(LogReader)
+	if !ok {
+		t.Fatal("expected read caching logger to read logs")
+	}
+	var lines []string
+	for m := range reader.ReadLogs(ReadConfig{Tail: -1}).Msg {
+		lines = append(lines, string(m.Line))
+	}
+	if !reflect.DeepEqual(lines, []string{"line"}) {
+		t.Fatalf("expected cached line, got %v", lines)
+	}
+
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if !cache.closed {
+		t.Fatal("expected cache to be closed")
+	}
+}
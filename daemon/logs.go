@@ -50,10 +50,13 @@ func (daemon *Daemon) ContainerLogs(containerName string, config *ContainerLogsC
 	}
 	config.OutStream = outStream
 
-	cLog, err := daemon.getLogger(container)
+	cLog, created, err := daemon.getLogger(container)
 	if err != nil {
 		return err
 	}
+	if created {
+		defer closeLogger(container, cLog)
+	}
 	logReader, ok := cLog.(logger.LogReader)
 	if !ok {
 		return logger.ErrReadLogsNotSupported
@@ -100,15 +103,29 @@ func (daemon *Daemon) ContainerLogs(containerName string, config *ContainerLogsC
 	}
 }
 
-func (daemon *Daemon) getLogger(container *container.Container) (logger.Logger, error) {
+// getLogger returns the logger of the running container, or a new logger
+// reading the logs of the stopped container, in which case created is true
+// and the caller closes it with closeLogger once the logs are read.
+func (daemon *Daemon) getLogger(container *container.Container) (l logger.Logger, created bool, err error) {
 	if container.LogDriver != nil && container.IsRunning() {
-		return container.LogDriver, nil
+		return container.LogDriver, false, nil
 	}
 	cfg := container.GetLogConfig(daemon.defaultLogConfig)
 	if err := logger.ValidateLogOpts(cfg.Type, cfg.Config); err != nil {
-		return nil, err
+		return nil, false, err
+	}
+	l, err = container.StartLogger(cfg)
+	if err != nil {
+		return nil, false, err
+	}
+	return l, true, nil
+}
+
+// closeLogger closes a logger created by getLogger.
+func closeLogger(container *container.Container, l logger.Logger) {
+	if err := l.Close(); err != nil {
+		logrus.Errorf("Error closing the logger of container %s: %v", container.ID, err)
 	}
-	return container.StartLogger(cfg)
 }
 
 // StartLogging initializes and starts the container logging stream.
@@ -0,0 +1,346 @@
+package daemon
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/container"
+	derr "github.com/docker/docker/errors"
+	"github.com/docker/docker/image"
+	"github.com/docker/docker/layer"
+	"github.com/docker/docker/pkg/archive"
+	"github.com/docker/docker/pkg/chrootarchive"
+	"github.com/docker/docker/reference"
+)
+
+// migratedDriverFile is the file, in the root of the daemon, recording the
+// storage driver the storage was migrated to, on its first line, and its
+// options, one per line.
+const migratedDriverFile = "storage-driver"
+
+// beginStorageWrite registers an operation changing the images or the
+// containers in the storage, and returns the function ending it. It fails
+// immediately during a migration of the storage, and once the storage is
+// migrated, as the changes would not be migrated.
+func (daemon *Daemon) beginStorageWrite() (func(), error) {
+	daemon.migrationMu.Lock()
+	defer daemon.migrationMu.Unlock()
+	if daemon.migrating {
+		return nil, derr.ErrorCodeMigrateInProgress
+	}
+	if daemon.migratedDriver != "" {
+		return nil, derr.ErrorCodeMigrated.WithArgs(daemon.migratedDriver)
+	}
+	daemon.storageWriters.Add(1)
+	return daemon.storageWriters.Done, nil
+}
+
+// MigrateStorage re-creates the layers of all the images and of the
+// stopped containers under the storage driver targetDriver, configured with
+// options, and makes it the storage driver of the daemon from its next
+// start. The layers are streamed from the current driver, which is left
+// untouched: removing the record of the migration before the next start
+// rolls it back. The migration waits for the operations changing the
+// storage in progress, and those started during and after the migration
+// fail until the daemon is restarted.
+func (daemon *Daemon) MigrateStorage(targetDriver string, options []string) (*types.StorageMigration, error) {
+	current := daemon.GraphDriverName()
+	if targetDriver == "" || targetDriver == current {
+		return nil, derr.ErrorCodeMigrateSameDriver.WithArgs(current)
+	}
+	daemon.migrationMu.Lock()
+	if daemon.migrating {
+		daemon.migrationMu.Unlock()
+		return nil, derr.ErrorCodeMigrateInProgress
+	}
+	if daemon.migratedDriver != "" {
+		daemon.migrationMu.Unlock()
+		return nil, derr.ErrorCodeMigrated.WithArgs(daemon.migratedDriver)
+	}
+	daemon.migrating = true
+	daemon.migrationMu.Unlock()
+	defer func() {
+		daemon.migrationMu.Lock()
+		daemon.migrating = false
+		daemon.migrationMu.Unlock()
+	}()
+	daemon.storageWriters.Wait()
+
+	for _, c := range daemon.List() {
+		if c.IsRunning() {
+			return nil, derr.ErrorCodeMigrateRunning.WithArgs(c.ID)
+		}
+	}
+
+	root := daemon.configStore.Root
+	ls, err := layer.NewStoreFromOptions(layer.StoreOptions{
+		StorePath:                 root,
+		MetadataStorePathTemplate: filepath.Join(root, "image", "%s", "layerdb"),
+		GraphDriver:               targetDriver,
+		GraphDriverOptions:        options,
+		UIDMaps:                   daemon.uidMaps,
+		GIDMaps:                   daemon.gidMaps,
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := ls.Cleanup(); err != nil {
+			logrus.Errorf("Error cleaning up the storage driver %s: %v", targetDriver, err)
+		}
+	}()
+
+	migration := &types.StorageMigration{
+		Driver:       current,
+		TargetDriver: ls.DriverName(),
+	}
+	daemon.LogDaemonEvent("storage_migration_start", map[string]string{
+		"driver":       migration.Driver,
+		"targetDriver": migration.TargetDriver,
+	})
+	if err := daemon.migrateStorage(migration, ls); err != nil {
+		daemon.LogDaemonEvent("storage_migration_fail", map[string]string{
+			"driver":       migration.Driver,
+			"targetDriver": migration.TargetDriver,
+			"error":        err.Error(),
+		})
+		return nil, err
+	}
+	record := strings.Join(append([]string{migration.TargetDriver}, options...), "\n") + "\n"
+	if err := ioutil.WriteFile(filepath.Join(root, migratedDriverFile), []byte(record), 0600); err != nil {
+		return nil, err
+	}
+	daemon.migrationMu.Lock()
+	daemon.migratedDriver = migration.TargetDriver
+	daemon.migrationMu.Unlock()
+
+	daemon.LogDaemonEvent("storage_migration_end", map[string]string{
+		"driver":       migration.Driver,
+		"targetDriver": migration.TargetDriver,
+		"images":       strconv.Itoa(migration.Images),
+		"layers":       strconv.Itoa(migration.Layers),
+		"containers":   strconv.Itoa(migration.Containers),
+	})
+	logrus.Infof("Migrated the storage from %s to %s, restart the daemon to use it", migration.Driver, migration.TargetDriver)
+	return migration, nil
+}
+
+// migrateStorage copies the images, their references and distribution
+// metadata, and the containers of the daemon to the layer store ls.
+func (daemon *Daemon) migrateStorage(migration *types.StorageMigration, ls layer.Store) error {
+	imageRoot := filepath.Join(daemon.configStore.Root, "image", ls.DriverName())
+	ifs, err := image.NewFSStoreBackend(filepath.Join(imageRoot, "imagedb"))
+	if err != nil {
+		return err
+	}
+	is, err := image.NewImageStore(ifs, ls)
+	if err != nil {
+		return err
+	}
+	rs, err := reference.NewReferenceStore(filepath.Join(imageRoot, "repositories.json"))
+	if err != nil {
+		return err
+	}
+
+	images := daemon.imageStore.Map()
+	for id, img := range images {
+		top, migrated, err := migrateLayers(daemon.layerStore, ls, img.RootFS.DiffIDs)
+		if err != nil {
+			return fmt.Errorf("failed to migrate the layers of image %s: %v", id, err)
+		}
+		_, err = is.Create(img.RawJSON())
+		if top != nil {
+			layer.ReleaseAndLog(ls, top)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to migrate image %s: %v", id, err)
+		}
+		migration.Images++
+		migration.Layers += migrated
+	}
+	for id := range images {
+		if parent, err := daemon.imageStore.GetParent(id); err == nil {
+			if err := is.SetParent(id, parent); err != nil {
+				return err
+			}
+		}
+		for _, ref := range daemon.referenceStore.References(id) {
+			if canonical, ok := ref.(reference.Canonical); ok {
+				err = rs.AddDigest(canonical, id, true)
+			} else {
+				err = rs.AddTag(ref, id, true)
+			}
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	distributionRoot := filepath.Join(daemon.configStore.Root, "image", daemon.GraphDriverName(), "distribution")
+	if _, err := os.Stat(distributionRoot); err == nil {
+		if err := archive.CopyWithTar(distributionRoot, filepath.Join(imageRoot, "distribution")); err != nil {
+			return err
+		}
+	}
+
+	for _, c := range daemon.List() {
+		migrated, err := daemon.migrateContainer(c, ls)
+		if err != nil {
+			return fmt.Errorf("failed to migrate container %s: %v", c.ID, err)
+		}
+		if migrated {
+			migration.Containers++
+		}
+	}
+	return nil
+}
+
+// migrateLayers registers the layer chain of diffIDs in the layer store to,
+// streaming the layers missing from it from the layer store from. It
+// returns the top layer of the chain, which the caller must release, and
+// the number of layers registered.
+func migrateLayers(from, to layer.Store, diffIDs []layer.DiffID) (layer.Layer, int, error) {
+	var (
+		top      layer.Layer
+		parent   layer.ChainID
+		migrated int
+	)
+	for i := range diffIDs {
+		chainID := layer.CreateChainID(diffIDs[:i+1])
+		l, err := to.Get(chainID)
+		if err == layer.ErrLayerDoesNotExist {
+			l, err = migrateLayer(from, to, chainID, parent)
+			migrated++
+		}
+		if err != nil {
+			if top != nil {
+				layer.ReleaseAndLog(to, top)
+			}
+			return nil, 0, err
+		}
+		// The layer holds a reference to its parent, the reference
+		// taken on the parent is released.
+		if top != nil {
+			layer.ReleaseAndLog(to, top)
+		}
+		top = l
+		parent = chainID
+	}
+	return top, migrated, nil
+}
+
+// migrateLayer streams the diff of the layer chainID from the layer store
+// from into the layer store to, on top of its parent.
+func migrateLayer(from, to layer.Store, chainID, parent layer.ChainID) (layer.Layer, error) {
+	src, err := from.Get(chainID)
+	if err != nil {
+		return nil, err
+	}
+	defer layer.ReleaseAndLog(from, src)
+
+	arch, err := src.TarStream()
+	if err != nil {
+		return nil, err
+	}
+	defer arch.Close()
+
+	l, err := to.Register(arch, parent)
+	if err != nil {
+		return nil, err
+	}
+	if l.ChainID() != chainID {
+		layer.ReleaseAndLog(to, l)
+		return nil, fmt.Errorf("layer %s was migrated as %s", chainID, l.ChainID())
+	}
+	return l, nil
+}
+
+// migrateContainer re-creates the read-write layer of the container in the
+// layer store ls, and applies the changes of the container to it. The
+// read-write layer left by a previous migration is replaced, its content
+// is stale.
+func (daemon *Daemon) migrateContainer(c *container.Container, ls layer.Store) (bool, error) {
+	c.Lock()
+	defer c.Unlock()
+
+	if c.IsRunning() {
+		return false, derr.ErrorCodeMigrateRunning.WithArgs(c.ID)
+	}
+	if c.RWLayer == nil {
+		logrus.Warnf("Not migrating container %s, it has no read-write layer", c.ID)
+		return false, nil
+	}
+
+	var parent layer.ChainID
+	if c.ImageID != "" {
+		img, err := daemon.imageStore.Get(c.ImageID)
+		if err != nil {
+			return false, err
+		}
+		parent = img.RootFS.ChainID()
+	}
+
+	if stale, err := ls.GetRWLayer(c.ID); err == nil {
+		if _, err := ls.ReleaseRWLayer(stale); err != nil {
+			return false, err
+		}
+	}
+	rwLayer, err := ls.CreateRWLayer(c.ID, parent, c.MountLabel, daemon.initLayerFunc(c.HostConfig), c.HostConfig.StorageOpt)
+	if err != nil {
+		return false, err
+	}
+	if err := daemon.copyRWLayer(c, rwLayer); err != nil {
+		if _, err := ls.ReleaseRWLayer(rwLayer); err != nil {
+			logrus.Errorf("Error removing the migrated read-write layer of container %s: %v", c.ID, err)
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// copyRWLayer applies the changes of the read-write layer of the container
+// to rwLayer.
+func (daemon *Daemon) copyRWLayer(c *container.Container, rwLayer layer.RWLayer) error {
+	arch, err := c.RWLayer.TarStream()
+	if err != nil {
+		return err
+	}
+	defer arch.Close()
+
+	dir, err := rwLayer.Mount(c.MountLabel)
+	if err != nil {
+		return err
+	}
+	defer rwLayer.Unmount()
+
+	_, err = chrootarchive.ApplyUncompressedLayer(dir, arch, &archive.TarOptions{
+		UIDMaps: daemon.uidMaps,
+		GIDMaps: daemon.gidMaps,
+	})
+	return err
+}
+
+// migratedDriver returns the storage driver the storage in root was
+// migrated to and its options, or an empty string if it was not migrated.
+func migratedDriver(root string) (string, []string) {
+	b, err := ioutil.ReadFile(filepath.Join(root, migratedDriverFile))
+	if err != nil {
+		return "", nil
+	}
+	var lines []string
+	for _, line := range strings.Split(string(b), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	if len(lines) == 0 {
+		return "", nil
+	}
+	return lines[0], lines[1:]
+}
@@ -0,0 +1,220 @@
+package daemon
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/docker/distribution/digest"
+	"github.com/docker/distribution/registry/api/errcode"
+	containertypes "github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/container"
+	derr "github.com/docker/docker/errors"
+	"github.com/docker/docker/layer"
+)
+
+// fakeMigrateStore is a layer store which registers layers whose diff ID is
+// the digest of their tar stream.
+type fakeMigrateStore struct {
+	layer.Store
+	driver string
+	layers map[layer.ChainID]*fakeMigrateLayer
+}
+
+func newFakeMigrateStore(driver string) *fakeMigrateStore {
+	return &fakeMigrateStore{driver: driver, layers: map[layer.ChainID]*fakeMigrateLayer{}}
+}
+
+func (s *fakeMigrateStore) DriverName() string {
+	return s.driver
+}
+
+func (s *fakeMigrateStore) Get(chainID layer.ChainID) (layer.Layer, error) {
+	l, ok := s.layers[chainID]
+	if !ok {
+		return nil, layer.ErrLayerDoesNotExist
+	}
+	l.references++
+	return l, nil
+}
+
+func (s *fakeMigrateStore) Release(l layer.Layer) ([]layer.Metadata, error) {
+	l.(*fakeMigrateLayer).references--
+	return nil, nil
+}
+
+func (s *fakeMigrateStore) Register(r io.Reader, parent layer.ChainID) (layer.Layer, error) {
+	content, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	var diffIDs []layer.DiffID
+	if parent != "" {
+		diffIDs = append(diffIDs, s.layers[parent].diffIDs...)
+	}
+	dgst, err := digest.FromBytes(content)
+	if err != nil {
+		return nil, err
+	}
+	l := &fakeMigrateLayer{
+		diffIDs:    append(diffIDs, layer.DiffID(dgst)),
+		content:    content,
+		references: 1,
+	}
+	s.layers[l.ChainID()] = l
+	return l, nil
+}
+
+type fakeMigrateLayer struct {
+	layer.Layer
+	diffIDs    []layer.DiffID
+	content    []byte
+	references int
+}
+
+func (l *fakeMigrateLayer) ChainID() layer.ChainID {
+	return layer.CreateChainID(l.diffIDs)
+}
+
+func (l *fakeMigrateLayer) TarStream() (io.ReadCloser, error) {
+	return ioutil.NopCloser(bytes.NewReader(l.content)), nil
+}
+
+func TestMigrateLayers(t *testing.T) {
+	from := newFakeMigrateStore("aufs")
+	base, err := from.Register(bytes.NewReader([]byte("base")), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	top, err := from.Register(bytes.NewReader([]byte("top")), base.ChainID())
+	if err != nil {
+		t.Fatal(err)
+	}
+	diffIDs := top.(*fakeMigrateLayer).diffIDs
+
+	to := newFakeMigrateStore("overlay")
+	l, migrated, err := migrateLayers(from, to, diffIDs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if migrated != 2 || len(to.layers) != 2 {
+		t.Fatalf("Expected 2 layers to be migrated, got %d in a store of %d", migrated, len(to.layers))
+	}
+	if l.ChainID() != top.ChainID() {
+		t.Fatalf("Expected the chain %s to be migrated, got %s", top.ChainID(), l.ChainID())
+	}
+	if n := to.layers[base.ChainID()].references; n != 0 {
+		t.Fatalf("Expected the reference on the base layer to be released, got %d references", n)
+	}
+	if n := to.layers[top.ChainID()].references; n != 1 {
+		t.Fatalf("Expected the top layer to be returned referenced, got %d references", n)
+	}
+	if n := from.layers[top.ChainID()].references; n != 1 {
+		t.Fatalf("Expected the references on the source layers to be released, got %d references", n)
+	}
+	to.Release(l)
+
+	if _, migrated, err = migrateLayers(from, to, diffIDs); err != nil {
+		t.Fatal(err)
+	}
+	if migrated != 0 {
+		t.Fatalf("Expected the layers already migrated to be skipped, got %d migrated", migrated)
+	}
+
+	// A layer whose stream does not match its diff ID fails the migration.
+	from.layers[top.ChainID()].content = []byte("changed")
+	if _, _, err := migrateLayers(from, newFakeMigrateStore("overlay"), diffIDs); err == nil {
+		t.Fatal("Expected a layer migrated under another chain ID to fail")
+	}
+}
+
+func TestMigrateStorageRejected(t *testing.T) {
+	c := &container.Container{
+		CommonContainer: container.CommonContainer{
+			ID:         "5a4ff6a163ad4533d22d69a2b8960bf7fafdcba06e72d2febdba229008b0bf57",
+			State:      container.NewState(),
+			Config:     &containertypes.Config{},
+			HostConfig: &containertypes.HostConfig{},
+		},
+	}
+	containers := &contStore{s: map[string]*container.Container{}}
+	containers.Add(c.ID, c)
+	daemon := &Daemon{
+		containers: containers,
+		layerStore: newFakeMigrateStore("aufs"),
+	}
+
+	for _, target := range []string{"", "aufs"} {
+		_, err := daemon.MigrateStorage(target, nil)
+		if err == nil || err.(errcode.Error).ErrorCode() != derr.ErrorCodeMigrateSameDriver {
+			t.Fatalf("Expected migrating to %q to be rejected, got %v", target, err)
+		}
+	}
+
+	c.Running = true
+	_, err := daemon.MigrateStorage("overlay", nil)
+	if err == nil || err.(errcode.Error).ErrorCode() != derr.ErrorCodeMigrateRunning {
+		t.Fatalf("Expected migrating with a running container to be rejected, got %v", err)
+	}
+
+	daemon.migrating = true
+	_, err = daemon.MigrateStorage("overlay", nil)
+	if err != derr.ErrorCodeMigrateInProgress {
+		t.Fatalf("Expected migrating during another migration to be rejected, got %v", err)
+	}
+}
+
+func TestBeginStorageWrite(t *testing.T) {
+	daemon := &Daemon{layerStore: newFakeMigrateStore("aufs")}
+
+	done, err := daemon.beginStorageWrite()
+	if err != nil {
+		t.Fatal(err)
+	}
+	done()
+
+	daemon.migrating = true
+	if _, err := daemon.beginStorageWrite(); err != derr.ErrorCodeMigrateInProgress {
+		t.Fatalf("Expected changing the storage during a migration to be rejected, got %v", err)
+	}
+
+	daemon.migrating = false
+	daemon.migratedDriver = "overlay"
+	_, err = daemon.beginStorageWrite()
+	if err == nil || err.(errcode.Error).ErrorCode() != derr.ErrorCodeMigrated {
+		t.Fatalf("Expected changing the storage after a migration to be rejected, got %v", err)
+	}
+	_, err = daemon.MigrateStorage("devicemapper", nil)
+	if err == nil || err.(errcode.Error).ErrorCode() != derr.ErrorCodeMigrated {
+		t.Fatalf("Expected migrating again before a restart to be rejected, got %v", err)
+	}
+}
+
+func TestMigratedDriver(t *testing.T) {
+	root, err := ioutil.TempDir("", "docker-migrate")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	if driver, options := migratedDriver(root); driver != "" || options != nil {
+		t.Fatalf("Expected no migrated driver, got %s %v", driver, options)
+	}
+	if err := ioutil.WriteFile(filepath.Join(root, migratedDriverFile), []byte("overlay\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if driver, options := migratedDriver(root); driver != "overlay" || len(options) != 0 {
+		t.Fatalf("Expected overlay without options, got %s %v", driver, options)
+	}
+	if err := ioutil.WriteFile(filepath.Join(root, migratedDriverFile), []byte("devicemapper\ndm.basesize=20G\ndm.fs=xfs\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	driver, options := migratedDriver(root)
+	if expected := []string{"dm.basesize=20G", "dm.fs=xfs"}; driver != "devicemapper" || !reflect.DeepEqual(options, expected) {
+		t.Fatalf("Expected devicemapper with %v, got %s %v", expected, driver, options)
+	}
+}
@@ -0,0 +1,165 @@
+// +build !windows
+
+package daemon
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+
+	"github.com/Sirupsen/logrus"
+	containertypes "github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/container"
+	derr "github.com/docker/docker/errors"
+	"github.com/docker/docker/pkg/idtools"
+	"github.com/docker/docker/volume"
+	"github.com/opencontainers/runc/libcontainer/user"
+	"golang.org/x/net/context"
+)
+
+// chownProgressInterval is the number of files changed between two progress
+// messages while changing the ownership of the source of a mount.
+const chownProgressInterval = 10000
+
+var (
+	// chownDeniedTrees are the host paths whose ownership, and the ownership
+	// of their content, is never changed.
+	chownDeniedTrees = []string{"/bin", "/boot", "/dev", "/etc", "/lib", "/lib32", "/lib64", "/proc", "/run", "/sbin", "/sys", "/usr", "/var/run"}
+	// chownDeniedPaths are the host paths whose ownership is never changed,
+	// though the ownership of the paths under them can be.
+	chownDeniedPaths = []string{"/", "/home", "/media", "/mnt", "/opt", "/root", "/srv", "/tmp", "/var", "/var/lib"}
+)
+
+// chownMount changes the ownership of the source of the mount to the user of
+// the container, mapped to the host with the user namespace mappings of the
+// container, if the mount has the U mode. The files already owned by the
+// user are left as is, so that the ownership of a tree that is already
+// correct is not changed again.
+func (daemon *Daemon) chownMount(c *container.Container, hostConfig *containertypes.HostConfig, m *volume.MountPoint) error {
+	if !volume.ChownNeeded(m.Mode) {
+		return nil
+	}
+	source, err := checkChownSource(m, daemon.chownDeniedTrees())
+	if err != nil {
+		return err
+	}
+	uid, gid, err := daemon.containerUser(c)
+	if err != nil {
+		return err
+	}
+	uidMaps, gidMaps := daemon.containerUIDGIDMaps(hostConfig)
+	if uid, err = idtools.ToHost(uid, uidMaps); err != nil {
+		return err
+	}
+	if gid, err = idtools.ToHost(gid, gidMaps); err != nil {
+		return err
+	}
+	return chownTree(source, uid, gid)
+}
+
+// chownDeniedTrees returns the host trees whose ownership is never changed:
+// the system trees, and the root and exec root of the daemon.
+func (daemon *Daemon) chownDeniedTrees() []string {
+	trees := append([]string{}, chownDeniedTrees...)
+	if daemon.configStore == nil {
+		return trees
+	}
+	for _, root := range []string{daemon.configStore.Root, daemon.configStore.ExecRoot} {
+		if root == "" {
+			continue
+		}
+		if resolved, err := filepath.EvalSymlinks(root); err == nil {
+			root = resolved
+		}
+		trees = append(trees, filepath.Clean(root))
+	}
+	return trees
+}
+
+// checkChownSource returns the source of the mount with its symbolic links
+// resolved, or an error if its ownership must not be changed: the mount is
+// read-only, or the resolved source is a system path or is in one of the
+// denied trees. The resolved source is the one to change, so that a link
+// changed after the check cannot redirect the change.
+func checkChownSource(m *volume.MountPoint, deniedTrees []string) (string, error) {
+	if !m.RW {
+		return "", derr.ErrorCodeChownDenied.WithArgs(m.Source, "the mount is read-only")
+	}
+	path, err := filepath.EvalSymlinks(m.Source)
+	if err != nil {
+		return "", err
+	}
+	path = filepath.Clean(path)
+	for _, p := range chownDeniedPaths {
+		if path == p {
+			return "", derr.ErrorCodeChownDenied.WithArgs(m.Source, "it is a system path")
+		}
+	}
+	if longestPrefix(path, deniedTrees) >= 0 {
+		return "", derr.ErrorCodeChownDenied.WithArgs(m.Source, "it is a system path")
+	}
+	return path, nil
+}
+
+// containerUser returns the uid and gid of the user of the container in its
+// user namespace. The user is looked up in the root filesystem of the
+// container unless it is given as uid:gid.
+func (daemon *Daemon) containerUser(c *container.Container) (int, int, error) {
+	if c.Config == nil || c.Config.User == "" {
+		return 0, 0, nil
+	}
+	// The primary group of a user given by uid only is in /etc/passwd too
+	if strings.Contains(c.Config.User, ":") {
+		if u, err := user.GetExecUser(c.Config.User, nil, nil, nil); err == nil {
+			return u.Uid, u.Gid, nil
+		}
+	}
+
+	if err := daemon.Mount(context.Background(), c); err != nil {
+		return 0, 0, err
+	}
+	defer daemon.Unmount(c)
+	passwdPath, err := c.GetResourcePath("/etc/passwd")
+	if err != nil {
+		return 0, 0, err
+	}
+	groupPath, err := c.GetResourcePath("/etc/group")
+	if err != nil {
+		return 0, 0, err
+	}
+	u, err := user.GetExecUserPath(c.Config.User, nil, passwdPath, groupPath)
+	if err != nil {
+		return 0, 0, fmt.Errorf("Cannot change the ownership of the mounts to the user %s: %v", c.Config.User, err)
+	}
+	return u.Uid, u.Gid, nil
+}
+
+// chownTree changes the ownership of the files of the tree at root not owned
+// by uid and gid yet, without following symbolic links. The progress is
+// logged for large trees.
+func chownTree(root string, uid, gid int) error {
+	changed := 0
+	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if st, ok := info.Sys().(*syscall.Stat_t); ok && int(st.Uid) == uid && int(st.Gid) == gid {
+			return nil
+		}
+		if err := os.Lchown(path, uid, gid); err != nil {
+			return err
+		}
+		changed++
+		if changed%chownProgressInterval == 0 {
+			logrus.Infof("Changing the ownership of %s to %d:%d: %d files changed", root, uid, gid, changed)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	logrus.Debugf("Changed the ownership of %d files of %s to %d:%d", changed, root, uid, gid)
+	return nil
+}
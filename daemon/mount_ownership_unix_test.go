@@ -0,0 +1,119 @@
+// +build !windows
+
+package daemon
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+
+	containertypes "github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/container"
+	"github.com/docker/docker/volume"
+)
+
+func TestChownMount(t *testing.T) {
+	if os.Getuid() != 0 {
+		t.Skip("Changing the ownership of files requires root")
+	}
+	tmp, err := ioutil.TempDir("", "docker-chown-mount")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+	if err := os.MkdirAll(filepath.Join(tmp, "data", "sub"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(tmp, "data", "sub", "file"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("/etc/passwd", filepath.Join(tmp, "data", "link")); err != nil {
+		t.Fatal(err)
+	}
+
+	daemon := &Daemon{}
+	c := container.NewBaseContainer("chown", "")
+	c.Config = &containertypes.Config{User: "1000:1001"}
+	hostConfig := &containertypes.HostConfig{Binds: []string{filepath.Join(tmp, "data") + ":/data:U"}}
+	if err := daemon.registerMountPoints(c, hostConfig); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, path := range []string{"data", "data/sub", "data/sub/file", "data/link"} {
+		fi, err := os.Lstat(filepath.Join(tmp, path))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if st := fi.Sys().(*syscall.Stat_t); st.Uid != 1000 || st.Gid != 1001 {
+			t.Fatalf("Expected %s to be owned by 1000:1001, got %d:%d", path, st.Uid, st.Gid)
+		}
+	}
+	fi, err := os.Stat("/etc/passwd")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st := fi.Sys().(*syscall.Stat_t); st.Uid == 1000 {
+		t.Fatal("Expected the target of the symbolic link to be left as is")
+	}
+}
+
+func TestCheckChownSource(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "docker-chown-source")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+	tmp, err = filepath.EvalSymlinks(tmp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, dir := range []string{"data", "root/volumes", "exec"} {
+		if err := os.MkdirAll(filepath.Join(tmp, dir), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for link, target := range map[string]string{"etc": "/etc", "run": "/run", "link": filepath.Join(tmp, "data")} {
+		if err := os.Symlink(target, filepath.Join(tmp, link)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	daemon := &Daemon{configStore: &Config{}}
+	daemon.configStore.Root = filepath.Join(tmp, "root")
+	daemon.configStore.ExecRoot = filepath.Join(tmp, "exec")
+	denied := daemon.chownDeniedTrees()
+
+	for source, expected := range map[string]string{
+		tmp:                         tmp,
+		filepath.Join(tmp, "data"):  filepath.Join(tmp, "data"),
+		filepath.Join(tmp, "link"):  filepath.Join(tmp, "data"),
+		filepath.Join(tmp, "data/"): filepath.Join(tmp, "data"),
+	} {
+		resolved, err := checkChownSource(&volume.MountPoint{Source: source, RW: true}, denied)
+		if err != nil {
+			t.Fatalf("Expected the ownership of %s to be changed, got %v", source, err)
+		}
+		if resolved != expected {
+			t.Fatalf("Expected %s to resolve to %s, got %s", source, expected, resolved)
+		}
+	}
+	for _, m := range []*volume.MountPoint{
+		{Source: tmp, RW: false},
+		{Source: "/", RW: true},
+		{Source: "/var/", RW: true},
+		{Source: "/etc", RW: true},
+		{Source: "/usr/local", RW: true},
+		{Source: "/var/run", RW: true},
+		{Source: filepath.Join(tmp, "etc"), RW: true},
+		{Source: filepath.Join(tmp, "run"), RW: true},
+		{Source: filepath.Join(tmp, "root"), RW: true},
+		{Source: filepath.Join(tmp, "root/volumes"), RW: true},
+		{Source: filepath.Join(tmp, "exec"), RW: true},
+		{Source: filepath.Join(tmp, "missing"), RW: true},
+	} {
+		if _, err := checkChownSource(m, denied); err == nil {
+			t.Fatalf("Expected the ownership of %s (rw %v) not to be changed", m.Source, m.RW)
+		}
+	}
+}
@@ -21,13 +21,14 @@ func (daemon *Daemon) prepareMountPoints(container *container.Container) error {
 	return nil
 }
 
+// removeMountPoints removes the volumes of a removed container if rm is set.
+// The volumes were already dereferenced by the removal of the container.
 func (daemon *Daemon) removeMountPoints(container *container.Container, rm bool) error {
 	var rmErrors []string
 	for _, m := range container.MountPoints {
 		if m.Volume == nil {
 			continue
 		}
-		daemon.volumes.Decrement(m.Volume)
 		if rm {
 			err := daemon.volumes.Remove(m.Volume)
 			// ErrVolumeInUse is ignored because having this
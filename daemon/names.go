@@ -0,0 +1,83 @@
+package daemon
+
+import (
+	"bytes"
+	"fmt"
+	"path"
+	"text/template"
+
+	"github.com/docker/distribution/digest"
+	"github.com/docker/docker/pkg/namesgenerator"
+	"github.com/docker/docker/pkg/stringid"
+	"github.com/docker/docker/reference"
+)
+
+// nameTemplateData is the data available to the container name template
+// configured with --container-name-template.
+type nameTemplateData struct {
+	// ID is the truncated ID of the container.
+	ID string
+	// Image is the name of the image the container is created from,
+	// without registry, namespace, tag or digest.
+	Image string
+	// Name is a random name from the default generator, e.g. focused_turing.
+	Name string
+	// Rand4 is a random string of 4 hexadecimal characters.
+	Rand4 string
+}
+
+// parseNameTemplate parses and validates the container name template.
+func parseNameTemplate(tmpl string) (*template.Template, error) {
+	t, err := template.New("container-name").Option("missingkey=error").Parse(tmpl)
+	if err != nil {
+		return nil, fmt.Errorf("invalid container name template %q: %v", tmpl, err)
+	}
+	if _, err := renderName(t, 0, stringid.GenerateNonCryptoID(), "busybox"); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// generateName returns a candidate name for the container with the given
+// ID and image. retry is the number of previous attempts which collided
+// with existing names.
+func (daemon *Daemon) generateName(retry int, id, image string) (string, error) {
+	if daemon.nameTemplate == nil {
+		return namesgenerator.GetRandomName(retry), nil
+	}
+	return renderName(daemon.nameTemplate, retry, id, image)
+}
+
+func renderName(t *template.Template, retry int, id, image string) (string, error) {
+	data := nameTemplateData{
+		ID:    stringid.TruncateID(id),
+		Image: imageNameForTemplate(image),
+		Name:  namesgenerator.GetRandomName(retry),
+		Rand4: stringid.GenerateNonCryptoID()[:4],
+	}
+
+	var b bytes.Buffer
+	if err := t.Execute(&b, data); err != nil {
+		return "", fmt.Errorf("cannot generate container name: %v", err)
+	}
+	name := b.String()
+	if !validContainerNamePattern.MatchString(name) {
+		return "", fmt.Errorf("Invalid generated container name (%s), only %s are allowed", name, validContainerNameChars)
+	}
+	return name, nil
+}
+
+// imageNameForTemplate returns the short name of the image, e.g. "redis"
+// for "docker.io/library/redis:3". Image IDs are truncated.
+func imageNameForTemplate(image string) string {
+	if image == "" {
+		return ""
+	}
+	if _, err := digest.ParseDigest(image); err == nil {
+		return stringid.TruncateID(image)
+	}
+	if ref, err := reference.ParseNamed(image); err == nil {
+		return path.Base(ref.Name())
+	}
+	return stringid.TruncateID(image)
+}
@@ -0,0 +1,42 @@
+package daemon
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRenderNameTemplate(t *testing.T) {
+	tmpl, err := parseNameTemplate("{{.Image}}-{{.Rand4}}")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	id := "5a4ff6a163ad4533d22d69a2b8960bf7fafdcba06e72d2febdba229008b0bf57"
+	name, err := renderName(tmpl, 0, id, "docker.io/library/redis:3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(name, "redis-") || len(name) != len("redis-")+4 {
+		t.Fatalf("unexpected generated name %q", name)
+	}
+
+	tmpl, err = parseNameTemplate("{{.Image}}_{{.ID}}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	name, err = renderName(tmpl, 0, id, "sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := "0123456789ab_5a4ff6a163ad"; name != expected {
+		t.Fatalf("expected generated name %q, got %q", expected, name)
+	}
+}
+
+func TestParseInvalidNameTemplate(t *testing.T) {
+	for _, tmpl := range []string{"{{.Image", "{{.Unknown}}", "{{.Image}} {{.ID}}"} {
+		if _, err := parseNameTemplate(tmpl); err == nil {
+			t.Fatalf("expected template %q to be rejected", tmpl)
+		}
+	}
+}
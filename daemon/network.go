@@ -6,6 +6,7 @@ import (
 	"net"
 	"strings"
 
+	"github.com/Sirupsen/logrus"
 	"github.com/docker/docker/api/types/network"
 	derr "github.com/docker/docker/errors"
 	"github.com/docker/docker/runconfig"
@@ -100,14 +101,27 @@ func (daemon *Daemon) GetAllNetworks() []libnetwork.Network {
 	return list
 }
 
-// CreateNetwork creates a network with the given name, driver and other optional parameters
-func (daemon *Daemon) CreateNetwork(name, driver string, ipam network.IPAM, options map[string]string) (libnetwork.Network, error) {
+// CreateNetwork creates a network with the given name, driver and other optional parameters.
+// The containers of an internal network can only reach each other.
+func (daemon *Daemon) CreateNetwork(name, driver string, ipam network.IPAM, options map[string]string, internal bool) (libnetwork.Network, error) {
 	c := daemon.netController
 	if driver == "" {
 		driver = c.Config().Daemon.DefaultDriver
 	}
 
 	nwOptions := []libnetwork.NetworkOption{}
+	if internal {
+		// The bridge driver of libnetwork does not implement internal
+		// networks, the daemon isolates their bridges itself.
+		if driver == "bridge" {
+			var err error
+			if options, err = daemon.internalBridgeOptions(options); err != nil {
+				return nil, err
+			}
+		} else {
+			nwOptions = append(nwOptions, libnetwork.NetworkOptionInternalNetwork())
+		}
+	}
 
 	v4Conf, v6Conf, err := getIpamConfig(ipam.Config)
 	if err != nil {
@@ -120,6 +134,12 @@ func (daemon *Daemon) CreateNetwork(name, driver string, ipam network.IPAM, opti
 	if err != nil {
 		return nil, err
 	}
+	if err := daemon.isolateNetwork(n, true); err != nil {
+		if err := n.Delete(); err != nil {
+			logrus.Warnf("Failed to remove the network %s: %v", name, err)
+		}
+		return nil, fmt.Errorf("Failed to isolate the internal network %s: %v", name, err)
+	}
 
 	daemon.LogNetworkEvent(n, "create")
 	return n, nil
@@ -201,6 +221,9 @@ func (daemon *Daemon) DeleteNetwork(networkID string) error {
 	if err := nw.Delete(); err != nil {
 		return err
 	}
+	if err := daemon.isolateNetwork(nw, false); err != nil {
+		logrus.Warnf("Failed to remove the isolation rules of the network %s: %v", nw.Name(), err)
+	}
 	daemon.LogNetworkEvent(nw, "destroy")
 	return nil
 }
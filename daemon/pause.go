@@ -35,10 +35,14 @@ func (daemon *Daemon) containerPause(container *container.Container) error {
 		return derr.ErrorCodeAlreadyPaused.WithArgs(container.ID)
 	}
 
+	if err := container.CanPause(); err != nil {
+		return err
+	}
+
 	if err := daemon.execDriver.Pause(container.Command); err != nil {
 		return err
 	}
-	container.Paused = true
+	container.SetPaused()
 	daemon.LogContainerEvent(container, "pause")
 	return nil
 }
@@ -0,0 +1,32 @@
+package daemon
+
+import (
+	"github.com/Sirupsen/logrus"
+	"github.com/docker/docker/pkg/plugins"
+	volumedrivers "github.com/docker/docker/volume/drivers"
+	"github.com/docker/libnetwork/driverapi"
+	"github.com/docker/libnetwork/ipamapi"
+)
+
+// discoverPlugins activates the volume, network and IPAM plugins found in the
+// plugin directories. Activating a plugin registers it with the subsystems it
+// implements, so this runs once the network controller is initialized for
+// the plugins implementing several of them.
+func (daemon *Daemon) discoverPlugins() {
+	if err := volumedrivers.Discover(); err != nil {
+		logrus.Warn(err)
+	}
+	if !daemon.NetworkControllerEnabled() {
+		return
+	}
+	for _, imp := range []string{driverapi.NetworkPluginEndpointType, ipamapi.PluginEndpointType} {
+		pls, err := plugins.GetAll(imp)
+		if err != nil {
+			logrus.Warnf("Error discovering %s plugins: %v", imp, err)
+			continue
+		}
+		for _, pl := range pls {
+			logrus.Infof("Registered %s plugin %s", imp, pl.Name)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package daemon
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	derr "github.com/docker/docker/errors"
+	"github.com/docker/go-connections/nat"
+)
+
+const (
+	// portProbeTimeout is the time to wait for the connection to a
+	// published port.
+	portProbeTimeout = 2 * time.Second
+	// portProbeGrace is the time to wait for the connection to a published
+	// port to be closed by the peer, which the userland proxy does when the
+	// container does not accept it.
+	portProbeGrace = 500 * time.Millisecond
+)
+
+// ContainerPortCheck checks whether a process of the container identified
+// by the given name listens on the port, in the format port[/proto], in the
+// network namespace of the container, and probes the host ports the port is
+// published on. Only the TCP host ports can be probed.
+func (daemon *Daemon) ContainerPortCheck(name, port string) (*types.ContainerPortCheck, error) {
+	proto, p := nat.SplitProtoPort(port)
+	natPort, err := nat.NewPort(proto, p)
+	if err != nil {
+		return nil, derr.ErrorCodeInvalidPortCheck.WithArgs(err)
+	}
+	if proto != "tcp" && proto != "udp" {
+		return nil, derr.ErrorCodeInvalidPortCheck.WithArgs(fmt.Sprintf("invalid protocol %q, must be tcp or udp", proto))
+	}
+	portNum, err := nat.ParsePort(natPort.Port())
+	if err != nil || portNum == 0 {
+		return nil, derr.ErrorCodeInvalidPortCheck.WithArgs(fmt.Sprintf("invalid port %q", port))
+	}
+
+	container, err := daemon.GetContainer(name)
+	if err != nil {
+		return nil, err
+	}
+	container.Lock()
+	if !container.IsRunning() {
+		container.Unlock()
+		return nil, derr.ErrorCodeNotRunning.WithArgs(name)
+	}
+	pid := container.GetPID()
+	var bindings []nat.PortBinding
+	if container.NetworkSettings != nil {
+		bindings = append(bindings, container.NetworkSettings.Ports[natPort]...)
+	}
+	container.Unlock()
+
+	addrs, err := listenAddresses(pid, proto, portNum)
+	if err != nil {
+		return nil, err
+	}
+	check := &types.ContainerPortCheck{
+		Port:            string(natPort),
+		Listening:       len(addrs) > 0,
+		ListenAddresses: addrs,
+	}
+	for _, b := range bindings {
+		bc := types.PortBindingCheck{
+			HostIP:   b.HostIP,
+			HostPort: b.HostPort,
+		}
+		if proto != "tcp" {
+			bc.Error = "UDP ports cannot be probed"
+		} else if err := probePort(b.HostIP, b.HostPort); err != nil {
+			bc.Error = err.Error()
+		} else {
+			bc.Reachable = true
+		}
+		check.Bindings = append(check.Bindings, bc)
+	}
+	return check, nil
+}
+
+// probePort connects to the TCP host port, on the loopback address if it is
+// published on all the addresses. The userland proxy accepts the connection
+// even if the container does not, and closes it right away then, so the
+// port is reachable only if the connection stays open for a moment or
+// receives data.
+func probePort(hostIP, hostPort string) error {
+	switch hostIP {
+	case "", "0.0.0.0":
+		hostIP = "127.0.0.1"
+	case "::":
+		hostIP = "::1"
+	}
+	conn, err := net.DialTimeout("tcp", net.JoinHostPort(hostIP, hostPort), portProbeTimeout)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	conn.SetReadDeadline(time.Now().Add(portProbeGrace))
+	if _, err := conn.Read(make([]byte, 1)); err != nil {
+		if nerr, ok := err.(net.Error); ok && nerr.Timeout() {
+			return nil
+		}
+		if err == io.EOF {
+			return fmt.Errorf("Connection to %s closed by the peer", conn.RemoteAddr())
+		}
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package daemon
+
+import (
+	"bufio"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/docker/docker/pkg/stringutils"
+)
+
+// tcpListen is the state of the listening sockets in /proc/net/tcp.
+const tcpListen = "0A"
+
+// listenAddresses returns the addresses a socket is bound to on the port in
+// the network namespace of the process pid, from its /proc/net tables. The
+// TCP sockets must be listening, the UDP sockets only bound.
+func listenAddresses(pid int, proto string, port int) ([]string, error) {
+	var addrs []string
+	for _, table := range []string{proto, proto + "6"} {
+		f, err := os.Open(fmt.Sprintf("/proc/%d/net/%s", pid, table))
+		if err != nil {
+			// IPv6 can be disabled
+			if os.IsNotExist(err) && table != proto {
+				continue
+			}
+			return nil, err
+		}
+		a, err := parseListenAddresses(f, port, proto == "tcp")
+		f.Close()
+		if err != nil {
+			return nil, err
+		}
+		addrs = append(addrs, a...)
+	}
+	return addrs, nil
+}
+
+// parseListenAddresses returns the local addresses of the sockets bound to
+// the port in a /proc/net table, of the listening ones if listenOnly.
+func parseListenAddresses(r io.Reader, port int, listenOnly bool) ([]string, error) {
+	var addrs []string
+	s := bufio.NewScanner(r)
+	// Skip the header
+	s.Scan()
+	for s.Scan() {
+		fields := strings.Fields(s.Text())
+		if len(fields) < 4 {
+			continue
+		}
+		if listenOnly && fields[3] != tcpListen {
+			continue
+		}
+		parts := strings.Split(fields[1], ":")
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("Invalid socket address %q", fields[1])
+		}
+		p, err := strconv.ParseUint(parts[1], 16, 16)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid socket address %q: %v", fields[1], err)
+		}
+		if int(p) != port {
+			continue
+		}
+		ip, err := parseProcIP(parts[0])
+		if err != nil {
+			return nil, err
+		}
+		addr := ip.String()
+		if !stringutils.InSlice(addrs, addr) {
+			addrs = append(addrs, addr)
+		}
+	}
+	return addrs, s.Err()
+}
+
+// parseProcIP decodes an address of a /proc/net table, which is in groups
+// of 4 bytes in the byte order of the host, little endian in practice.
+func parseProcIP(s string) (net.IP, error) {
+	b, err := hex.DecodeString(s)
+	if err != nil || (len(b) != net.IPv4len && len(b) != net.IPv6len) {
+		return nil, fmt.Errorf("Invalid socket address %q", s)
+	}
+	for i := 0; i < len(b); i += 4 {
+		b[i], b[i+1], b[i+2], b[i+3] = b[i+3], b[i+2], b[i+1], b[i]
+	}
+	return net.IP(b), nil
+}
@@ -0,0 +1,43 @@
+package daemon
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseListenAddresses(t *testing.T) {
+	tcp := `  sl  local_address rem_address   st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode
+   0: 0100007F:0050 00000000:0000 0A 00000000:00000000 00:00000000 00000000     0        0 14551 1 0000000000000000 100 0 0 10 0
+   1: 00000000:1F90 00000000:0000 0A 00000000:00000000 00:00000000 00000000     0        0 14552 1 0000000000000000 100 0 0 10 0
+   2: 0200000A:0050 0100000A:D2F0 01 00000000:00000000 00:00000000 00000000     0        0 14553 1 0000000000000000 20 4 30 10 -1
+`
+	addrs, err := parseListenAddresses(strings.NewReader(tcp), 80, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(addrs) != 1 || addrs[0] != "127.0.0.1" {
+		t.Fatalf("Expected to listen on 127.0.0.1, got %v", addrs)
+	}
+	addrs, err = parseListenAddresses(strings.NewReader(tcp), 80, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(addrs) != 2 || addrs[1] != "10.0.0.2" {
+		t.Fatalf("Expected to be bound on 127.0.0.1 and 10.0.0.2, got %v", addrs)
+	}
+	if addrs, err := parseListenAddresses(strings.NewReader(tcp), 443, true); err != nil || len(addrs) != 0 {
+		t.Fatalf("Expected no address, got %v: %v", addrs, err)
+	}
+
+	tcp6 := `  sl  local_address                         remote_address                        st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode
+   0: 00000000000000000000000000000000:1F90 00000000000000000000000000000000:0000 0A 00000000:00000000 00:00000000 00000000     0        0 14554 1 0000000000000000 100 0 0 10 0
+   1: 00000000000000000000000001000000:1F90 00000000000000000000000000000000:0000 0A 00000000:00000000 00:00000000 00000000     0        0 14555 1 0000000000000000 100 0 0 10 0
+`
+	addrs, err = parseListenAddresses(strings.NewReader(tcp6), 8080, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(addrs) != 2 || addrs[0] != "::" || addrs[1] != "::1" {
+		t.Fatalf("Expected to listen on :: and ::1, got %v", addrs)
+	}
+}
@@ -0,0 +1,61 @@
+package daemon
+
+import (
+	"net"
+	"testing"
+
+	"github.com/docker/distribution/registry/api/errcode"
+	derr "github.com/docker/docker/errors"
+)
+
+func TestProbePort(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	closeConns := make(chan bool, 1)
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			// Close the connection like the userland proxy does when the
+			// container refuses it
+			if <-closeConns {
+				conn.Close()
+			} else {
+				defer conn.Close()
+			}
+		}
+	}()
+	_, port, err := net.SplitHostPort(l.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	closeConns <- false
+	if err := probePort("0.0.0.0", port); err != nil {
+		t.Fatalf("Expected the port to be reachable, got %v", err)
+	}
+	closeConns <- true
+	if err := probePort("127.0.0.1", port); err == nil {
+		t.Fatal("Expected an error for a connection closed by the peer")
+	}
+
+	l.Close()
+	if err := probePort("", port); err == nil {
+		t.Fatal("Expected an error for a closed port")
+	}
+}
+
+func TestContainerPortCheckInvalid(t *testing.T) {
+	daemon := &Daemon{}
+	for _, port := range []string{"http", "80/sctp", "0"} {
+		_, err := daemon.ContainerPortCheck("c", port)
+		if e, ok := err.(errcode.Error); !ok || e.ErrorCode() != derr.ErrorCodeInvalidPortCheck {
+			t.Fatalf("Expected the port %q to be invalid, got %v", port, err)
+		}
+	}
+}
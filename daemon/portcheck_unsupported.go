@@ -0,0 +1,9 @@
+// +build !linux
+
+package daemon
+
+import "fmt"
+
+func listenAddresses(pid int, proto string, port int) ([]string, error) {
+	return nil, fmt.Errorf("port checks are not supported on this platform")
+}
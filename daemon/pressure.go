@@ -0,0 +1,190 @@
+package daemon
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/docker/docker/container"
+)
+
+// pressureRelief is the fraction of the thresholds under which the pressure
+// on the host subsides, so that the containers are not paused and unpaused
+// repeatedly around the thresholds.
+const pressureRelief = 0.9
+
+// hostPressure is a sample of the usage of the host.
+type hostPressure struct {
+	// memory is the percentage of the memory in use.
+	memory float64
+	// load is the load average over one minute per CPU.
+	load float64
+}
+
+// pressurePolicy pauses the low-priority containers, selected by a label,
+// while the memory usage or the load of the host is above a threshold, and
+// unpauses them once the pressure subsides.
+type pressurePolicy struct {
+	mu         sync.Mutex
+	labelKey   string
+	labelValue string
+	hasValue   bool
+	// memory and load are the thresholds, 0 if disabled.
+	memory   float64
+	load     float64
+	interval time.Duration
+	// underPressure is true from the sample above a threshold to the one
+	// below all the relief thresholds.
+	underPressure bool
+	// paused are the IDs of the containers paused by the policy.
+	paused    map[string]struct{}
+	stop      chan struct{}
+	closeOnce sync.Once
+}
+
+// newPressurePolicy returns the policy configured by the --pressure options,
+// or nil if it is disabled.
+func newPressurePolicy(config *Config) (*pressurePolicy, error) {
+	if config.PressureLabel == "" {
+		if config.PressureMemory != 0 || config.PressureLoad != 0 {
+			return nil, fmt.Errorf("--pressure-memory and --pressure-load require --pressure-label")
+		}
+		return nil, nil
+	}
+	if config.PressureMemory < 0 || config.PressureMemory > 100 {
+		return nil, fmt.Errorf("Invalid --pressure-memory %d, it must be a percentage", config.PressureMemory)
+	}
+	if config.PressureLoad < 0 {
+		return nil, fmt.Errorf("Invalid --pressure-load %v, it must be positive", config.PressureLoad)
+	}
+	if config.PressureMemory == 0 && config.PressureLoad == 0 {
+		return nil, fmt.Errorf("--pressure-label requires --pressure-memory or --pressure-load")
+	}
+	if config.PressureInterval <= 0 {
+		return nil, fmt.Errorf("Invalid --pressure-interval %v, it must be positive", config.PressureInterval)
+	}
+	parts := strings.SplitN(config.PressureLabel, "=", 2)
+	if parts[0] == "" {
+		return nil, fmt.Errorf("Invalid --pressure-label %q, it must be in the form key[=value]", config.PressureLabel)
+	}
+	if _, err := readHostPressure(); err != nil {
+		return nil, fmt.Errorf("Cannot monitor the pressure on the host: %v", err)
+	}
+
+	p := &pressurePolicy{
+		labelKey: parts[0],
+		memory:   float64(config.PressureMemory),
+		load:     config.PressureLoad,
+		interval: config.PressureInterval,
+		paused:   make(map[string]struct{}),
+		stop:     make(chan struct{}),
+	}
+	if len(parts) == 2 {
+		p.labelValue, p.hasValue = parts[1], true
+	}
+	return p, nil
+}
+
+// update records a sample of the usage of the host, and tells whether the
+// host went under pressure or out of it.
+func (p *pressurePolicy) update(s hostPressure) bool {
+	if !p.underPressure {
+		if (p.memory > 0 && s.memory >= p.memory) || (p.load > 0 && s.load >= p.load) {
+			p.underPressure = true
+			return true
+		}
+		return false
+	}
+	if (p.memory == 0 || s.memory < p.memory*pressureRelief) && (p.load == 0 || s.load < p.load*pressureRelief) {
+		p.underPressure = false
+		return true
+	}
+	return false
+}
+
+// matches tells whether the container c has the label of the low-priority
+// containers.
+func (p *pressurePolicy) matches(c *container.Container) bool {
+	value, ok := c.Config.Labels[p.labelKey]
+	return ok && (!p.hasValue || value == p.labelValue)
+}
+
+// close stops the policy. It can be called more than once.
+func (p *pressurePolicy) close() {
+	if p == nil {
+		return
+	}
+	p.closeOnce.Do(func() { close(p.stop) })
+}
+
+// watchPressure samples the usage of the host at every interval of the
+// policy, pausing and unpausing the low-priority containers, until the
+// policy is closed.
+func (daemon *Daemon) watchPressure(p *pressurePolicy) {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-p.stop:
+			return
+		case <-ticker.C:
+		}
+		s, err := readHostPressure()
+		if err != nil {
+			logrus.Errorf("Error reading the pressure on the host: %v", err)
+			continue
+		}
+		daemon.applyPressure(p, s)
+	}
+}
+
+// applyPressure pauses the low-priority containers while the host is under
+// pressure, including the ones started meanwhile, and unpauses them once it
+// is not anymore.
+func (daemon *Daemon) applyPressure(p *pressurePolicy, s hostPressure) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	changed := p.update(s)
+	attributes := map[string]string{
+		"memory": strconv.FormatFloat(s.memory, 'f', 1, 64),
+		"load":   strconv.FormatFloat(s.load, 'f', 2, 64),
+	}
+	if changed && p.underPressure {
+		logrus.Warnf("Host under pressure (memory %s%%, load %s), pausing the containers labeled %s", attributes["memory"], attributes["load"], p.labelKey)
+		daemon.LogDaemonEvent("pressure_start", attributes)
+	}
+
+	if p.underPressure {
+		for _, c := range daemon.List() {
+			if !p.matches(c) || !c.IsRunning() || c.IsPaused() {
+				continue
+			}
+			if err := daemon.containerPause(c); err != nil {
+				logrus.Errorf("Failed to pause container %s under pressure: %v", c.ID, err)
+				continue
+			}
+			p.paused[c.ID] = struct{}{}
+		}
+		return
+	}
+	if !changed {
+		return
+	}
+
+	for id := range p.paused {
+		delete(p.paused, id)
+		c, err := daemon.GetContainer(id)
+		if err != nil || !c.IsPaused() {
+			continue
+		}
+		if err := daemon.containerUnpause(c); err != nil {
+			logrus.Errorf("Failed to unpause container %s after pressure: %v", c.ID, err)
+		}
+	}
+	logrus.Infof("Host pressure subsided (memory %s%%, load %s), unpaused the containers labeled %s", attributes["memory"], attributes["load"], p.labelKey)
+	daemon.LogDaemonEvent("pressure_end", attributes)
+}
@@ -0,0 +1,71 @@
+package daemon
+
+import (
+	"bufio"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// readHostPressure reads the memory usage of the host from /proc/meminfo and
+// its load average from /proc/loadavg.
+func readHostPressure() (hostPressure, error) {
+	memory, err := readMemoryUsage("/proc/meminfo")
+	if err != nil {
+		return hostPressure{}, err
+	}
+	content, err := ioutil.ReadFile("/proc/loadavg")
+	if err != nil {
+		return hostPressure{}, err
+	}
+	fields := strings.Fields(string(content))
+	if len(fields) == 0 {
+		return hostPressure{}, fmt.Errorf("unexpected content in /proc/loadavg: %q", content)
+	}
+	load, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return hostPressure{}, err
+	}
+	return hostPressure{memory: memory, load: load / float64(runtime.NumCPU())}, nil
+}
+
+// readMemoryUsage returns the percentage of the memory in use, from the
+// memory available to start new applications without swapping, or its
+// estimation on kernels not reporting it.
+func readMemoryUsage(path string) (float64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	values := make(map[string]int64)
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		fields := strings.Fields(s.Text())
+		if len(fields) < 2 {
+			continue
+		}
+		v, err := strconv.ParseInt(fields[1], 10, 64)
+		if err != nil {
+			continue
+		}
+		values[strings.TrimSuffix(fields[0], ":")] = v
+	}
+	if err := s.Err(); err != nil {
+		return 0, err
+	}
+
+	total := values["MemTotal"]
+	if total == 0 {
+		return 0, fmt.Errorf("no MemTotal in %s", path)
+	}
+	available, ok := values["MemAvailable"]
+	if !ok {
+		available = values["MemFree"] + values["Buffers"] + values["Cached"]
+	}
+	return float64(total-available) * 100 / float64(total), nil
+}
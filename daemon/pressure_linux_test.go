@@ -0,0 +1,36 @@
+package daemon
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func TestReadMemoryUsage(t *testing.T) {
+	tests := []struct {
+		meminfo string
+		usage   float64
+	}{
+		{"MemTotal: 1000 kB\nMemFree: 100 kB\nMemAvailable: 250 kB\nCached: 300 kB\n", 75},
+		{"MemTotal: 1000 kB\nMemFree: 100 kB\nBuffers: 100 kB\nCached: 300 kB\n", 50},
+	}
+	for _, test := range tests {
+		f, err := ioutil.TempFile("", "meminfo")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.Remove(f.Name())
+		if _, err := f.WriteString(test.meminfo); err != nil {
+			t.Fatal(err)
+		}
+		f.Close()
+
+		usage, err := readMemoryUsage(f.Name())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if usage != test.usage {
+			t.Fatalf("Expected a memory usage of %v%%, got %v%%", test.usage, usage)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package daemon
+
+import (
+	"testing"
+	"time"
+
+	containertypes "github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/container"
+)
+
+func TestNewPressurePolicy(t *testing.T) {
+	p, err := newPressurePolicy(&Config{})
+	if err != nil || p != nil {
+		t.Fatalf("Expected no policy, got %v and %v", p, err)
+	}
+
+	for _, config := range []Config{
+		{PressureMemory: 90},
+		{PressureLabel: "priority=low"},
+		{PressureLabel: "priority=low", PressureMemory: 101, PressureInterval: time.Second},
+		{PressureLabel: "priority=low", PressureLoad: -1, PressureInterval: time.Second},
+		{PressureLabel: "=low", PressureMemory: 90, PressureInterval: time.Second},
+		{PressureLabel: "priority=low", PressureMemory: 90},
+	} {
+		if _, err := newPressurePolicy(&config); err == nil {
+			t.Fatalf("Expected %+v to be invalid", config)
+		}
+	}
+}
+
+func TestPressurePolicyUpdate(t *testing.T) {
+	p := &pressurePolicy{memory: 90, load: 2}
+	for _, step := range []struct {
+		sample        hostPressure
+		changed       bool
+		underPressure bool
+	}{
+		{hostPressure{memory: 50, load: 1}, false, false},
+		{hostPressure{memory: 95, load: 1}, true, true},
+		{hostPressure{memory: 85, load: 1}, false, true},
+		{hostPressure{memory: 70, load: 1.9}, false, true},
+		{hostPressure{memory: 70, load: 1}, true, false},
+		{hostPressure{memory: 50, load: 2.5}, true, true},
+	} {
+		if changed := p.update(step.sample); changed != step.changed || p.underPressure != step.underPressure {
+			t.Fatalf("Expected sample %+v to change the pressure %v to %v, got %v to %v", step.sample, step.changed, step.underPressure, changed, p.underPressure)
+		}
+	}
+
+	// A disabled threshold is ignored.
+	p = &pressurePolicy{load: 2}
+	if p.update(hostPressure{memory: 100, load: 1}) {
+		t.Fatal("Expected the memory to be ignored")
+	}
+}
+
+func TestPressurePolicyMatches(t *testing.T) {
+	c := &container.Container{
+		CommonContainer: container.CommonContainer{
+			Config: &containertypes.Config{Labels: map[string]string{"priority": "low"}},
+		},
+	}
+	tests := []struct {
+		policy  *pressurePolicy
+		matches bool
+	}{
+		{&pressurePolicy{labelKey: "priority"}, true},
+		{&pressurePolicy{labelKey: "priority", labelValue: "low", hasValue: true}, true},
+		{&pressurePolicy{labelKey: "priority", labelValue: "high", hasValue: true}, false},
+		{&pressurePolicy{labelKey: "batch"}, false},
+	}
+	for _, test := range tests {
+		if matches := test.policy.matches(c); matches != test.matches {
+			t.Fatalf("Expected %+v to match %v, got %v", test.policy, test.matches, matches)
+		}
+	}
+}
@@ -0,0 +1,9 @@
+// +build !linux
+
+package daemon
+
+import "fmt"
+
+func readHostPressure() (hostPressure, error) {
+	return hostPressure{}, fmt.Errorf("pressure monitoring is not supported on this platform")
+}
@@ -0,0 +1,303 @@
+package daemon
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/filters"
+	timetypes "github.com/docker/docker/api/types/time"
+	"github.com/docker/docker/container"
+	"github.com/docker/docker/image"
+	"github.com/docker/docker/layer"
+)
+
+// errMultipleUntilFilters is returned when more than one "until" filter is given.
+var errMultipleUntilFilters = errors.New("more than one until filter specified")
+
+// errMultipleUnusedForFilters is returned when more than one "unused-for"
+// filter is given.
+var errMultipleUnusedForFilters = errors.New("more than one unused-for filter specified")
+
+// acceptedContainersPruneFilters is the list of filters accepted by ContainersPrune.
+var acceptedContainersPruneFilters = map[string]bool{
+	"until": true,
+	"label": true,
+}
+
+// acceptedImagesPruneFilters is the list of filters accepted by ImagesPrune.
+var acceptedImagesPruneFilters = map[string]bool{
+	"dangling":   true,
+	"until":      true,
+	"unused-for": true,
+	"label":      true,
+}
+
+// acceptedVolumesPruneFilters is the list of filters accepted by VolumesPrune.
+var acceptedVolumesPruneFilters = map[string]bool{
+	"label": true,
+}
+
+// ContainersPrune removes all the stopped containers matching the given
+// filters. The space reclaimed from the removed containers' RW layers is
+// reported along with the IDs of the containers that were removed.
+func (daemon *Daemon) ContainersPrune(pruneFilters filters.Args) (*types.ContainersPruneReport, error) {
+	if err := pruneFilters.Validate(acceptedContainersPruneFilters); err != nil {
+		return nil, err
+	}
+
+	until, err := getUntilFromPruneFilters(pruneFilters)
+	if err != nil {
+		return nil, err
+	}
+
+	rep := &types.ContainersPruneReport{}
+	for _, c := range daemon.List() {
+		if !shouldPruneContainer(c, pruneFilters, until) {
+			continue
+		}
+
+		sizeRw, _ := daemon.getSize(c)
+		if err := daemon.ContainerRm(c.ID, &types.ContainerRmConfig{}); err != nil {
+			logrus.Warnf("failed to prune container %s: %v", c.ID, err)
+			continue
+		}
+		if sizeRw > 0 {
+			rep.SpaceReclaimed += uint64(sizeRw)
+		}
+		rep.ContainersDeleted = append(rep.ContainersDeleted, c.ID)
+	}
+
+	return rep, nil
+}
+
+// shouldPruneContainer reports whether the container c is stopped and
+// matches the prune filters.
+func shouldPruneContainer(c *container.Container, pruneFilters filters.Args, until time.Time) bool {
+	c.Lock()
+	defer c.Unlock()
+
+	if c.Running || c.Paused || c.Restarting || c.RemovalInProgress || c.Dead {
+		return false
+	}
+	if !until.IsZero() && !c.Created.Before(until) {
+		return false
+	}
+	return pruneFilters.MatchKVList("label", c.Config.Labels)
+}
+
+// ImagesPrune removes the dangling images matching the given filters. When
+// the "dangling" filter is set to false, all the images that are not used by
+// any container are removed, including tagged ones. The space reclaimed from
+// the layers released by the removed images is reported along with the list
+// of untagged and deleted images.
+func (daemon *Daemon) ImagesPrune(pruneFilters filters.Args) (*types.ImagesPruneReport, error) {
+	if err := pruneFilters.Validate(acceptedImagesPruneFilters); err != nil {
+		return nil, err
+	}
+
+	danglingOnly := true
+	if pruneFilters.Include("dangling") {
+		if pruneFilters.ExactMatch("dangling", "false") || pruneFilters.ExactMatch("dangling", "0") {
+			danglingOnly = false
+		} else if !pruneFilters.ExactMatch("dangling", "true") && !pruneFilters.ExactMatch("dangling", "1") {
+			return nil, fmt.Errorf("Invalid filter 'dangling=%s'", pruneFilters.Get("dangling"))
+		}
+	}
+
+	until, err := getUntilFromPruneFilters(pruneFilters)
+	if err != nil {
+		return nil, err
+	}
+	unusedSince, err := getUnusedSinceFromPruneFilters(pruneFilters)
+	if err != nil {
+		return nil, err
+	}
+
+	var allImages map[image.ID]*image.Image
+	if danglingOnly {
+		allImages = daemon.imageStore.Heads()
+	} else {
+		allImages = daemon.imageStore.Map()
+	}
+
+	usedImages := make(map[image.ID]bool)
+	for _, c := range daemon.List() {
+		usedImages[c.ImageID] = true
+	}
+
+	rep := &types.ImagesPruneReport{}
+	for id, img := range allImages {
+		if usedImages[id] || len(daemon.imageStore.Children(id)) > 0 {
+			continue
+		}
+		if !until.IsZero() && !img.Created.Before(until) {
+			continue
+		}
+		if !unusedSince.IsZero() && !daemon.imageLastUsed(id, img).Before(unusedSince) {
+			continue
+		}
+		if pruneFilters.Include("label") {
+			if img.Config == nil || !pruneFilters.MatchKVList("label", img.Config.Labels) {
+				continue
+			}
+		}
+
+		refs := daemon.referenceStore.References(id)
+		if danglingOnly && len(refs) > 0 {
+			continue
+		}
+
+		diffSizes, err := daemon.layerDiffSizes(img.RootFS.ChainID())
+		if err != nil {
+			logrus.Warnf("failed to compute layer sizes of image %s: %v", id, err)
+		}
+
+		var deleted []types.ImageDelete
+		for _, ref := range refs {
+			records, err := daemon.ImageDelete(ref.String(), false, true)
+			if err != nil {
+				logrus.Warnf("failed to prune image reference %s: %v", ref, err)
+				continue
+			}
+			deleted = append(deleted, records...)
+		}
+		if _, err := daemon.imageStore.Get(id); err == nil {
+			records, err := daemon.ImageDelete(id.String(), false, true)
+			if err != nil {
+				logrus.Warnf("failed to prune image %s: %v", id, err)
+			}
+			deleted = append(deleted, records...)
+		}
+
+		for _, record := range deleted {
+			if size, ok := diffSizes[layer.ChainID(record.Deleted)]; ok && size > 0 {
+				rep.SpaceReclaimed += uint64(size)
+			}
+		}
+		rep.ImagesDeleted = append(rep.ImagesDeleted, deleted...)
+	}
+
+	return rep, nil
+}
+
+// VolumesPrune removes all the volumes that are not referenced by any
+// container and match the given filters. The space reclaimed is only
+// computed for volumes of the local driver. When dryRun is set, the volumes
+// that would be removed are reported but left in place.
+func (daemon *Daemon) VolumesPrune(pruneFilters filters.Args, dryRun bool) (*types.VolumesPruneReport, error) {
+	if err := pruneFilters.Validate(acceptedVolumesPruneFilters); err != nil {
+		return nil, err
+	}
+
+	rep := &types.VolumesPruneReport{DryRun: dryRun}
+	for _, v := range daemon.volumes.List() {
+		if daemon.volumes.Count(v) > 0 {
+			continue
+		}
+		if !pruneFilters.MatchKVList("label", daemon.volumes.Labels(v)) {
+			continue
+		}
+
+		var size uint64
+		if s := localVolumeSize(v); s > 0 {
+			size = uint64(s)
+		}
+
+		if !dryRun {
+			if err := daemon.volumes.Remove(v); err != nil {
+				logrus.Warnf("failed to prune volume %s: %v", v.Name(), err)
+				continue
+			}
+			daemon.LogVolumeEvent(v.Name(), "destroy", map[string]string{"driver": v.DriverName()})
+		}
+		rep.SpaceReclaimed += size
+		rep.VolumesDeleted = append(rep.VolumesDeleted, types.VolumePruneItem{
+			Name:           v.Name(),
+			SpaceReclaimed: size,
+		})
+	}
+
+	return rep, nil
+}
+
+// imageLastUsed returns the last time the image was used by a container, or
+// its creation time if it was never used.
+func (daemon *Daemon) imageLastUsed(id image.ID, img *image.Image) time.Time {
+	lastUsed, err := daemon.imageStore.GetLastUsed(id)
+	if err != nil {
+		logrus.Warnf("failed to get the last use of image %s: %v", id, err)
+	}
+	if lastUsed.IsZero() {
+		return img.Created
+	}
+	return lastUsed
+}
+
+// layerDiffSizes returns the size of each layer in the chain of the given
+// layer, indexed by chain ID.
+func (daemon *Daemon) layerDiffSizes(chainID layer.ChainID) (map[layer.ChainID]int64, error) {
+	sizes := make(map[layer.ChainID]int64)
+	if chainID == "" {
+		return sizes, nil
+	}
+	l, err := daemon.layerStore.Get(chainID)
+	if err != nil {
+		return sizes, err
+	}
+	defer layer.ReleaseAndLog(daemon.layerStore, l)
+
+	for p := l; p != nil; p = p.Parent() {
+		size, err := p.DiffSize()
+		if err != nil {
+			return sizes, err
+		}
+		sizes[p.ChainID()] = size
+	}
+	return sizes, nil
+}
+
+// getUntilFromPruneFilters returns the time given by the "until" prune
+// filter, or the zero time if the filter was not set.
+func getUntilFromPruneFilters(pruneFilters filters.Args) (time.Time, error) {
+	until := time.Time{}
+	if !pruneFilters.Include("until") {
+		return until, nil
+	}
+	untilFilters := pruneFilters.Get("until")
+	if len(untilFilters) > 1 {
+		return until, errMultipleUntilFilters
+	}
+	ts, err := timetypes.GetTimestamp(untilFilters[0], time.Now())
+	if err != nil {
+		return until, err
+	}
+	seconds, nanoseconds, err := timetypes.ParseTimestamps(ts, 0)
+	if err != nil {
+		return until, err
+	}
+	return time.Unix(seconds, nanoseconds), nil
+}
+
+// getUnusedSinceFromPruneFilters returns the time since which the images must
+// not have been used, given as a duration by the "unused-for" prune filter,
+// or the zero time if the filter was not set.
+func getUnusedSinceFromPruneFilters(pruneFilters filters.Args) (time.Time, error) {
+	if !pruneFilters.Include("unused-for") {
+		return time.Time{}, nil
+	}
+	unusedForFilters := pruneFilters.Get("unused-for")
+	if len(unusedForFilters) > 1 {
+		return time.Time{}, errMultipleUnusedForFilters
+	}
+	unusedFor, err := time.ParseDuration(unusedForFilters[0])
+	if err != nil {
+		return time.Time{}, fmt.Errorf("Invalid filter 'unused-for=%s': %v", unusedForFilters[0], err)
+	}
+	if unusedFor < 0 {
+		return time.Time{}, fmt.Errorf("Invalid filter 'unused-for=%s': duration must not be negative", unusedForFilters[0])
+	}
+	return time.Now().Add(-unusedFor), nil
+}
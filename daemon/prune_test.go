@@ -0,0 +1,98 @@
+package daemon
+
+import (
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/daemon/events"
+	"github.com/docker/docker/volume/drivers"
+	"github.com/docker/docker/volume/store"
+	vt "github.com/docker/docker/volume/testutils"
+)
+
+func TestVolumesPrune(t *testing.T) {
+	volumedrivers.Register(vt.FakeDriver{}, "fake")
+	volumes, err := store.New("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	daemon := &Daemon{
+		volumes:       volumes,
+		EventsService: events.New(),
+	}
+
+	if _, err := daemon.volumes.Create("cache", "fake", nil, map[string]string{"role": "cache"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := daemon.volumes.Create("data", "fake", nil, map[string]string{"role": "data"}); err != nil {
+		t.Fatal(err)
+	}
+	used, err := daemon.volumes.Create("used", "fake", nil, map[string]string{"role": "cache"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	daemon.volumes.Increment(used)
+
+	pruneFilters := filters.NewArgs()
+	pruneFilters.Add("label", "role=cache")
+
+	rep, err := daemon.VolumesPrune(pruneFilters, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !rep.DryRun || len(rep.VolumesDeleted) != 1 || rep.VolumesDeleted[0].Name != "cache" {
+		t.Fatalf("Expected dry run to report the cache volume, got %+v", rep)
+	}
+	if l := daemon.volumes.List(); len(l) != 3 {
+		t.Fatalf("Expected dry run to keep 3 volumes, got %v", l)
+	}
+
+	rep, err = daemon.VolumesPrune(filters.NewArgs(), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rep.DryRun || len(rep.VolumesDeleted) != 2 {
+		t.Fatalf("Expected the cache and data volumes to be pruned, got %+v", rep)
+	}
+	if l := daemon.volumes.List(); len(l) != 1 || l[0].Name() != "used" {
+		t.Fatalf("Expected only the used volume to remain, got %v", l)
+	}
+
+	pruneFilters = filters.NewArgs()
+	pruneFilters.Add("driver", "fake")
+	if _, err := daemon.VolumesPrune(pruneFilters, false); err == nil {
+		t.Fatal("Expected an error for an unsupported filter")
+	}
+}
+
+func TestGetUnusedSinceFromPruneFilters(t *testing.T) {
+	since, err := getUnusedSinceFromPruneFilters(filters.NewArgs())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !since.IsZero() {
+		t.Fatalf("expected no time without unused-for filter, got %v", since)
+	}
+
+	args := filters.NewArgs()
+	args.Add("unused-for", "720h")
+	before := time.Now().Add(-720 * time.Hour)
+	since, err = getUnusedSinceFromPruneFilters(args)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if since.Before(before) || since.After(time.Now().Add(-720*time.Hour)) {
+		t.Fatalf("expected time 720h ago, got %v", since)
+	}
+
+	for _, invalid := range [][]string{{"30d"}, {"-1h"}, {"1h", "2h"}} {
+		args := filters.NewArgs()
+		for _, v := range invalid {
+			args.Add("unused-for", v)
+		}
+		if _, err := getUnusedSinceFromPruneFilters(args); err == nil {
+			t.Fatalf("expected error for unused-for=%v", invalid)
+		}
+	}
+}
@@ -0,0 +1,181 @@
+package daemon
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sync"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/pkg/progress"
+	"github.com/docker/docker/reference"
+	"golang.org/x/net/context"
+)
+
+// pullSessionKey identifies the pulls which can share a session: the pulls
+// of the same reference from the same registry, with the same credentials and
+// headers so that a client does not get an image it could not pull itself.
+// The credentials are hashed so that they are not kept in the key.
+func pullSessionKey(ref reference.Named, metaHeaders map[string][]string, authConfig *types.AuthConfig) string {
+	// json sorts the keys of the maps, so that the same headers give the
+	// same key
+	b, _ := json.Marshal(struct {
+		AuthConfig  *types.AuthConfig
+		MetaHeaders map[string][]string
+	}{authConfig, metaHeaders})
+	sum := sha256.Sum256(b)
+	return ref.Hostname() + "\x00" + ref.String() + "\x00" + hex.EncodeToString(sum[:])
+}
+
+// pullSessions are the pulls in progress, by key.
+type pullSessions struct {
+	mu       sync.Mutex
+	sessions map[string]*pullSession
+}
+
+func newPullSessions() *pullSessions {
+	return &pullSessions{sessions: make(map[string]*pullSession)}
+}
+
+// join returns the session of the pull in progress with the given key, or
+// starts pull in a new session. The caller must watch the session.
+func (ps *pullSessions) join(key string, pull func(context.Context, progress.Output) error) *pullSession {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	if s, ok := ps.sessions[key]; ok && s.attach() {
+		return s
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s := &pullSession{
+		byID:     make(map[string]int),
+		updated:  make(chan struct{}),
+		cancel:   cancel,
+		watchers: 1,
+	}
+	ps.sessions[key] = s
+	go func() {
+		err := pull(ctx, s)
+		ps.mu.Lock()
+		if ps.sessions[key] == s {
+			delete(ps.sessions, key)
+		}
+		ps.mu.Unlock()
+		s.finish(err)
+	}()
+	return s
+}
+
+// sessionProgress is a progress of a session, with the sequence number of
+// its last update.
+type sessionProgress struct {
+	progress.Progress
+	seq int
+}
+
+// pullSession is a pull in progress, shared by the clients pulling the same
+// image. It keeps the progress of the pull so that the clients attaching
+// late get the current state of the pull before its next updates. The pull
+// is cancelled once all the clients are gone.
+type pullSession struct {
+	mu sync.Mutex
+	// progress is the last progress of each ID, in the order the IDs first
+	// appeared, and all the progress without ID.
+	progress []sessionProgress
+	byID     map[string]int
+	seq      int
+	// updated is closed, then replaced, at each update of the session.
+	updated  chan struct{}
+	done     bool
+	err      error
+	watchers int
+	cancel   context.CancelFunc
+}
+
+// WriteProgress records the progress of the pull.
+func (s *pullSession) WriteProgress(p progress.Progress) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.seq++
+	if i, ok := s.byID[p.ID]; ok && p.ID != "" {
+		s.progress[i] = sessionProgress{p, s.seq}
+	} else {
+		if p.ID != "" {
+			s.byID[p.ID] = len(s.progress)
+		}
+		s.progress = append(s.progress, sessionProgress{p, s.seq})
+	}
+	s.notify()
+	return nil
+}
+
+func (s *pullSession) notify() {
+	close(s.updated)
+	s.updated = make(chan struct{})
+}
+
+// attach adds a watcher to the session. It returns false if the pull was
+// cancelled, all its watchers being gone.
+func (s *pullSession) attach() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.watchers == 0 {
+		return false
+	}
+	s.watchers++
+	return true
+}
+
+// detach cancels the pull once the last watcher detached from it.
+func (s *pullSession) detach() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.watchers--
+	if s.watchers == 0 && !s.done {
+		s.cancel()
+	}
+}
+
+func (s *pullSession) finish(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.done = true
+	s.err = err
+	s.cancel()
+	s.notify()
+}
+
+// watch writes the progress of the pull to out until it finishes, and
+// returns its result. It detaches from the session early if ctx is
+// cancelled.
+func (s *pullSession) watch(ctx context.Context, out progress.Output) error {
+	defer s.detach()
+
+	var last int
+	for {
+		s.mu.Lock()
+		var updates []progress.Progress
+		for _, p := range s.progress {
+			if p.seq > last {
+				updates = append(updates, p.Progress)
+			}
+		}
+		last = s.seq
+		updated, done, err := s.updated, s.done, s.err
+		s.mu.Unlock()
+
+		for _, p := range updates {
+			out.WriteProgress(p)
+		}
+		if done {
+			return err
+		}
+
+		select {
+		case <-updated:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
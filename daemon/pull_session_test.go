@@ -0,0 +1,137 @@
+package daemon
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/pkg/progress"
+	"github.com/docker/docker/reference"
+	"golang.org/x/net/context"
+)
+
+type recordedProgress struct {
+	mu       sync.Mutex
+	progress []progress.Progress
+}
+
+func (r *recordedProgress) WriteProgress(p progress.Progress) error {
+	r.mu.Lock()
+	r.progress = append(r.progress, p)
+	r.mu.Unlock()
+	return nil
+}
+
+func (r *recordedProgress) last() map[string]string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	last := make(map[string]string)
+	for _, p := range r.progress {
+		last[p.ID] = p.Action + p.Message
+	}
+	return last
+}
+
+func TestPullSessionsShareAPull(t *testing.T) {
+	ps := newPullSessions()
+	pulls := 0
+	started := make(chan struct{})
+	release := make(chan struct{})
+	pullErr := errors.New("pull failed")
+	pull := func(ctx context.Context, out progress.Output) error {
+		pulls++
+		progress.Update(out, "layer1", "Downloading")
+		close(started)
+		<-release
+		progress.Update(out, "layer1", "Pull complete")
+		progress.Message(out, "", "Status: Downloaded newer image")
+		return pullErr
+	}
+
+	first := ps.join("busybox", pull)
+	<-started
+	second := ps.join("busybox", pull)
+	if first != second {
+		t.Fatal("Expected the second pull to join the first one")
+	}
+
+	var wg sync.WaitGroup
+	outputs := []*recordedProgress{{}, {}}
+	for i, s := range []*pullSession{first, second} {
+		wg.Add(1)
+		go func(s *pullSession, out *recordedProgress) {
+			defer wg.Done()
+			if err := s.watch(context.Background(), out); err != pullErr {
+				t.Errorf("Expected the error of the pull, got %v", err)
+			}
+		}(s, outputs[i])
+	}
+	close(release)
+	wg.Wait()
+
+	if pulls != 1 {
+		t.Fatalf("Expected a single pull, got %d", pulls)
+	}
+	for _, out := range outputs {
+		last := out.last()
+		if last["layer1"] != "Pull complete" || last[""] != "Status: Downloaded newer image" {
+			t.Fatalf("Unexpected progress %v", last)
+		}
+	}
+}
+
+func TestPullSessionCancelledWithoutWatchers(t *testing.T) {
+	ps := newPullSessions()
+	cancelled := make(chan struct{})
+	s := ps.join("busybox", func(ctx context.Context, out progress.Output) error {
+		<-ctx.Done()
+		close(cancelled)
+		return ctx.Err()
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := s.watch(ctx, &recordedProgress{}); err != context.Canceled {
+		t.Fatalf("Expected the watch to be cancelled, got %v", err)
+	}
+	select {
+	case <-cancelled:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected the pull to be cancelled once its last watcher is gone")
+	}
+
+	// A new pull does not join the cancelled one.
+	if ps.join("busybox", func(context.Context, progress.Output) error { return nil }) == s {
+		t.Fatal("Expected a new session")
+	}
+}
+
+func TestPullSessionKey(t *testing.T) {
+	ref, err := reference.ParseNamed("busybox")
+	if err != nil {
+		t.Fatal(err)
+	}
+	auth := &types.AuthConfig{Username: "user", Password: "secret"}
+	headers := map[string][]string{"X-Meta-Foo": {"bar"}, "X-Meta-Baz": {"qux"}}
+	key := pullSessionKey(ref, headers, auth)
+
+	if k := pullSessionKey(ref, map[string][]string{"X-Meta-Baz": {"qux"}, "X-Meta-Foo": {"bar"}}, &types.AuthConfig{Username: "user", Password: "secret"}); k != key {
+		t.Fatalf("Expected the same credentials and headers to give the same key, got %q and %q", key, k)
+	}
+	for _, k := range []string{
+		pullSessionKey(ref, headers, &types.AuthConfig{Username: "user", Password: "wrong"}),
+		pullSessionKey(ref, headers, &types.AuthConfig{Username: "user", Password: "secret", RegistryToken: "token"}),
+		pullSessionKey(ref, headers, nil),
+		pullSessionKey(ref, map[string][]string{"X-Meta-Foo": {"bar"}}, auth),
+	} {
+		if k == key {
+			t.Fatal("Expected different credentials or headers to give another key")
+		}
+	}
+	if strings.Contains(key, "secret") {
+		t.Fatalf("Expected the key not to contain the password, got %q", key)
+	}
+}
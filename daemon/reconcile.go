@@ -0,0 +1,141 @@
+package daemon
+
+import (
+	"sync"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/docker/docker/container"
+	"golang.org/x/net/context"
+)
+
+// The desired states of the containers, for the functions whose container
+// variable shadows the container package.
+const (
+	desiredRunning = container.DesiredRunning
+	desiredStopped = container.DesiredStopped
+)
+
+// The actions converging a container to its desired state.
+const (
+	reconcileStart = "start"
+	reconcileStop  = "stop"
+)
+
+// reconciler periodically converges the actual state of the containers to
+// their desired state, which is set by the start, restart, stop and kill
+// requests.
+type reconciler struct {
+	interval  time.Duration
+	stop      chan struct{}
+	closeOnce sync.Once
+}
+
+// newReconciler returns the reconciler configured with --reconcile-interval,
+// or nil if it is disabled.
+func newReconciler(config *Config) *reconciler {
+	if config.ReconcileInterval <= 0 {
+		return nil
+	}
+	return &reconciler{
+		interval: config.ReconcileInterval,
+		stop:     make(chan struct{}),
+	}
+}
+
+// close stops the reconciler. It can be called more than once.
+func (r *reconciler) close() {
+	if r == nil {
+		return
+	}
+	r.closeOnce.Do(func() { close(r.stop) })
+}
+
+// setDesiredState records the state the container should be converged to.
+// The errors are logged only, so that the request changing the actual state
+// does not fail because of the desired state.
+func (daemon *Daemon) setDesiredState(c *container.Container, state string) {
+	c.Lock()
+	defer c.Unlock()
+	if err := c.SetDesiredState(state); err != nil {
+		logrus.Errorf("Error saving the desired state of container %s: %v", c.ID, err)
+	}
+}
+
+// reconcileAction returns the action converging the container c to its
+// desired state, or "" if there is none:
+//   - a container which should run is started again if its process was
+//     killed by a signal the daemon did not send, but not if it exited on
+//     its own, which is left to its restart policy,
+//   - a container which should not run is stopped if it was started without
+//     a request, for example by its restart policy when the daemon started.
+//
+// The containers being restarted, paused, removed or held are left alone.
+// The container lock must be held.
+func (daemon *Daemon) reconcileAction(c *container.Container) string {
+	if c.Restarting || c.Paused || c.RemovalInProgress || c.Dead {
+		return ""
+	}
+	switch c.DesiredState() {
+	case desiredRunning:
+		if c.Running || !c.KilledExternally() || daemon.isHeld(c) {
+			return ""
+		}
+		return reconcileStart
+	case desiredStopped:
+		if c.Running {
+			return reconcileStop
+		}
+	}
+	return ""
+}
+
+// reconcileContainers converges the containers to their desired state at
+// every interval of the reconciler, until it is closed.
+func (daemon *Daemon) reconcileContainers(r *reconciler) {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-r.stop:
+			return
+		case <-ticker.C:
+		}
+		if daemon.IsRestoring() {
+			continue
+		}
+		for _, c := range daemon.List() {
+			if daemon.IsShuttingDown() {
+				return
+			}
+			daemon.reconcile(c)
+		}
+	}
+}
+
+// reconcile converges the container c to its desired state.
+func (daemon *Daemon) reconcile(c *container.Container) {
+	c.Lock()
+	action := daemon.reconcileAction(c)
+	desired := c.DesiredState()
+	c.Unlock()
+	if action == "" {
+		return
+	}
+
+	logrus.Infof("Reconciling container %s to its desired state %s", c.ID, desired)
+	daemon.LogContainerEventWithAttributes(c, "reconcile", map[string]string{
+		"desiredState": desired,
+		"action":       action,
+	})
+	var err error
+	switch action {
+	case reconcileStart:
+		err = daemon.containerStart(context.Background(), c, "")
+	case reconcileStop:
+		err = daemon.containerStop(context.Background(), c, daemon.stopTimeout(c))
+	}
+	if err != nil {
+		logrus.Errorf("Failed to %s container %s to reconcile it: %v", action, c.ID, err)
+	}
+}
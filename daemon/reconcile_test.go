@@ -0,0 +1,65 @@
+package daemon
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/container"
+)
+
+func TestReconcileAction(t *testing.T) {
+	root, err := ioutil.TempDir("", "docker-reconcile")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	daemon := &Daemon{}
+	exited := func(c *container.Container, signal int, reason string) {
+		c.ExitSignal = signal
+		c.RestartHistory = append(c.RestartHistory, &types.ContainerExit{End: time.Now(), ExitCode: 128 + signal, Reason: reason})
+	}
+
+	for _, tc := range []struct {
+		desc   string
+		setup  func(*container.Container)
+		action string
+	}{
+		{"no desired state", func(c *container.Container) { exited(c, 9, "exited") }, ""},
+		{"killed externally", func(c *container.Container) {
+			c.SetDesiredState(desiredRunning)
+			exited(c, 9, "exited")
+		}, reconcileStart},
+		{"exited on its own", func(c *container.Container) {
+			c.SetDesiredState(desiredRunning)
+			exited(c, 0, "exited")
+		}, ""},
+		{"stopped by the daemon", func(c *container.Container) {
+			c.SetDesiredState(desiredRunning)
+			exited(c, 15, "stopped")
+		}, ""},
+		{"started out of band", func(c *container.Container) {
+			c.SetDesiredState(desiredStopped)
+			c.Running = true
+		}, reconcileStop},
+		{"paused", func(c *container.Container) {
+			c.SetDesiredState(desiredStopped)
+			c.Running = true
+			c.Paused = true
+		}, ""},
+		{"restarting", func(c *container.Container) {
+			c.SetDesiredState(desiredRunning)
+			exited(c, 9, "exited")
+			c.Restarting = true
+		}, ""},
+	} {
+		c := container.NewBaseContainer("reconcile", root)
+		tc.setup(c)
+		if action := daemon.reconcileAction(c); action != tc.action {
+			t.Fatalf("Expected the action %q for a container %s, got %q", tc.action, tc.desc, action)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package daemon
+
+import (
+	"github.com/Sirupsen/logrus"
+	"github.com/docker/docker/container"
+	derr "github.com/docker/docker/errors"
+)
+
+// ContainerRecover retries loading the read-write layer of a container
+// which could not be loaded when the daemon started. Once recovered, the
+// container can be used again.
+func (daemon *Daemon) ContainerRecover(name string) error {
+	container, err := daemon.GetContainer(name)
+	if err != nil {
+		return err
+	}
+
+	container.Lock()
+	defer container.Unlock()
+
+	if !container.Broken {
+		return derr.ErrorCodeContainerNotBroken.WithArgs(container.ID)
+	}
+	if err := daemon.loadRWLayer(container); err != nil {
+		if err := container.ToDisk(); err != nil {
+			logrus.Errorf("Error saving container state to disk: %v", err)
+		}
+		return derr.ErrorCodeCantRecover.WithArgs(container.ID, err)
+	}
+	if err := container.ToDisk(); err != nil {
+		return err
+	}
+
+	daemon.LogContainerEvent(container, "recover")
+	return nil
+}
+
+// loadRWLayer gets the read-write layer of the container from the layer
+// store. If the layer cannot be loaded the container is marked as broken,
+// and the error is recorded in its state.
+func (daemon *Daemon) loadRWLayer(container *container.Container) error {
+	rwlayer, err := daemon.layerStore.GetRWLayer(container.ID)
+	if err != nil {
+		container.Broken = true
+		container.Error = err.Error()
+		return err
+	}
+	container.RWLayer = rwlayer
+	if container.Broken {
+		container.Broken = false
+		container.Error = ""
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+package daemon
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	containertypes "github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/container"
+	"github.com/docker/docker/daemon/events"
+	derr "github.com/docker/docker/errors"
+	"github.com/docker/docker/layer"
+	"github.com/docker/docker/pkg/truncindex"
+	"golang.org/x/net/context"
+)
+
+// fakeRWLayerStore is a layer store which only knows how to get the
+// read-write layers it holds.
+type fakeRWLayerStore struct {
+	layer.Store
+	rwLayers map[string]layer.RWLayer
+}
+
+func (s *fakeRWLayerStore) GetRWLayer(id string) (layer.RWLayer, error) {
+	if l, ok := s.rwLayers[id]; ok {
+		return l, nil
+	}
+	return nil, layer.ErrMountDoesNotExist
+}
+
+type fakeRWLayer struct {
+	layer.RWLayer
+}
+
+func TestContainerRecover(t *testing.T) {
+	root, err := ioutil.TempDir("", "docker-recover-test-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	id := "5a4ff6a163ad4533d22d69a2b8960bf7fafdcba06e72d2febdba229008b0bf57"
+	c := container.NewBaseContainer(id, root)
+	c.Config = &containertypes.Config{Image: "busybox"}
+	c.HostConfig = &containertypes.HostConfig{}
+
+	ls := &fakeRWLayerStore{rwLayers: map[string]layer.RWLayer{}}
+	daemon := &Daemon{
+		containers:    &contStore{s: map[string]*container.Container{id: c}},
+		idIndex:       truncindex.NewTruncIndex([]string{id}),
+		layerStore:    ls,
+		EventsService: events.New(),
+	}
+
+	if err := daemon.ContainerRecover(id); err == nil || err.Error() != derr.ErrorCodeContainerNotBroken.WithArgs(id).Error() {
+		t.Fatalf("Expected a not broken error, got %v", err)
+	}
+
+	if err := daemon.loadRWLayer(c); err == nil {
+		t.Fatal("Expected the read-write layer not to be found")
+	}
+	if !c.Broken || c.Error != layer.ErrMountDoesNotExist.Error() || c.State.StateString() != "broken" {
+		t.Fatalf("Expected the container to be broken, got %+v", c.State)
+	}
+	expected := derr.ErrorCodeContainerBroken.WithArgs(id, c.Error).Error()
+	if err := daemon.Mount(context.Background(), c); err == nil || err.Error() != expected {
+		t.Fatalf("Expected mounting a broken container to fail, got %v", err)
+	}
+	if err := daemon.containerStart(context.Background(), c, ""); err == nil || err.Error() != expected {
+		t.Fatalf("Expected starting a broken container to fail, got %v", err)
+	}
+
+	if err := daemon.ContainerRecover(id); err == nil {
+		t.Fatal("Expected recovery to fail while the read-write layer is missing")
+	}
+	if !c.Broken {
+		t.Fatal("Expected the container to still be broken")
+	}
+
+	rwLayer := &fakeRWLayer{}
+	ls.rwLayers[id] = rwLayer
+	if err := daemon.ContainerRecover(id); err != nil {
+		t.Fatal(err)
+	}
+	if c.Broken || c.Error != "" || c.RWLayer != rwLayer {
+		t.Fatalf("Expected the container to be recovered, got %+v", c.State)
+	}
+	saved := container.NewBaseContainer(id, root)
+	if err := saved.FromDisk(); err != nil {
+		t.Fatal(err)
+	}
+	if saved.Broken {
+		t.Fatal("Expected the recovered state to be saved to disk")
+	}
+}
@@ -0,0 +1,187 @@
+package daemon
+
+import (
+	"io/ioutil"
+	"sort"
+	"strings"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/container"
+)
+
+// ReindexContainers rebuilds the ID index and the name graph of the
+// containers from the containers stored on disk, while the daemon runs, to
+// recover from a partial corruption of the indexes without a restart:
+//   - the containers stored on disk which are not loaded are loaded,
+//   - the containers whose directory is gone are forgotten, unless running,
+//   - the ID index gets exactly the IDs of the containers left,
+//   - the names of the graph which refer to no container, or to a container
+//     with another name, are removed, links excepted,
+//   - the missing names of the containers are added back to the graph,
+//   - the containers having the name of another container are renamed.
+//
+// The containers cannot be created, removed or renamed during the reindex.
+func (daemon *Daemon) ReindexContainers() (*types.ContainersReindexReport, error) {
+	daemon.indexLock.Lock()
+	defer daemon.indexLock.Unlock()
+
+	report := &types.ContainersReindexReport{}
+
+	dir, err := ioutil.ReadDir(daemon.repository)
+	if err != nil {
+		return nil, err
+	}
+	onDisk := make(map[string]bool)
+	for _, v := range dir {
+		if !v.IsDir() {
+			continue
+		}
+		onDisk[v.Name()] = true
+		if daemon.containers.Get(v.Name()) != nil {
+			continue
+		}
+		loaded, err := daemon.loadMissingContainer(v.Name())
+		if err != nil {
+			logrus.Errorf("Failed to load container %s: %v", v.Name(), err)
+			continue
+		}
+		if loaded {
+			report.ContainersLoaded = append(report.ContainersLoaded, v.Name())
+		}
+	}
+
+	// The oldest containers come first, so that they keep their names.
+	containers := append([]*container.Container(nil), daemon.List()...)
+	sort.Sort(sort.Reverse((*History)(&containers)))
+	byID := make(map[string]*container.Container)
+	for _, c := range containers {
+		if !onDisk[c.ID] {
+			if c.IsRunning() {
+				logrus.Warnf("Container %s is running but has no directory on disk", c.ID)
+			} else {
+				daemon.containers.Delete(c.ID)
+				report.ContainersRemoved = append(report.ContainersRemoved, c.ID)
+				continue
+			}
+		}
+		byID[c.ID] = c
+	}
+
+	daemon.reindexIDs(byID, report)
+	if err := daemon.reindexNames(containers, byID, report); err != nil {
+		return report, err
+	}
+
+	logrus.Infof("Reindexed the containers: %d IDs added, %d IDs removed, %d names added, %d names removed, %d names changed, %d containers removed, %d containers loaded",
+		len(report.IDsAdded), len(report.IDsRemoved), len(report.NamesAdded), len(report.NamesRemoved), len(report.NamesChanged), len(report.ContainersRemoved), len(report.ContainersLoaded))
+	return report, nil
+}
+
+// loadMissingContainer loads and registers the container id stored on disk,
+// as restore does. It returns false if the container belongs to another
+// storage driver and is left unloaded.
+func (daemon *Daemon) loadMissingContainer(id string) (bool, error) {
+	c, err := daemon.load(id)
+	if err != nil {
+		return false, err
+	}
+	if currentDriver := daemon.GraphDriverName(); (c.Driver != "" || currentDriver != "aufs") && c.Driver != currentDriver {
+		return false, nil
+	}
+	if err := daemon.loadRWLayer(c); err != nil {
+		logrus.Errorf("Failed to load container mount %s: %v", id, err)
+	}
+	if err := daemon.registerName(c); err != nil {
+		return false, err
+	}
+	if err := daemon.Register(c); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// reindexIDs makes the ID index hold exactly the IDs of the containers.
+func (daemon *Daemon) reindexIDs(byID map[string]*container.Container, report *types.ContainersReindexReport) {
+	indexed := make(map[string]bool)
+	daemon.idIndex.Iterate(func(id string) {
+		indexed[id] = true
+	})
+	for id := range indexed {
+		if byID[id] == nil {
+			daemon.idIndex.Delete(id)
+			report.IDsRemoved = append(report.IDsRemoved, id)
+		}
+	}
+	for id := range byID {
+		if !indexed[id] {
+			daemon.idIndex.Add(id)
+			report.IDsAdded = append(report.IDsAdded, id)
+		}
+	}
+	sort.Strings(report.IDsRemoved)
+	sort.Strings(report.IDsAdded)
+}
+
+// reindexNames makes the name graph map the name of each container, and
+// only those, to the container. The links between containers are kept as
+// long as both containers exist.
+func (daemon *Daemon) reindexNames(containers []*container.Container, byID map[string]*container.Container, report *types.ContainersReindexReport) error {
+	graph := daemon.containerGraph()
+
+	entities := graph.List("/", -1)
+	purged := make(map[string]bool)
+	for _, p := range entities.Paths() {
+		id := entities[p].ID()
+		c := byID[id]
+		if c == nil {
+			if purged[id] {
+				continue
+			}
+			purged[id] = true
+			if _, err := graph.Purge(id); err != nil {
+				return err
+			}
+			report.NamesRemoved = append(report.NamesRemoved, p)
+			continue
+		}
+		// Paths deeper than a name are the links of the parent container
+		if strings.Count(p, "/") == 1 && p != c.Name {
+			if err := graph.Delete(p); err != nil {
+				return err
+			}
+			report.NamesRemoved = append(report.NamesRemoved, p)
+		}
+	}
+
+	for _, c := range containers {
+		if byID[c.ID] == nil || c.Name == "" {
+			continue
+		}
+		e := graph.Get(c.Name)
+		if e == nil {
+			if _, err := graph.Set(c.Name, c.ID); err != nil {
+				return err
+			}
+			report.NamesAdded = append(report.NamesAdded, c.Name)
+			continue
+		}
+		if e.ID() == c.ID {
+			continue
+		}
+
+		// Another container has the name
+		name, err := daemon.generateNewName(c.ID, c.Config.Image)
+		if err != nil {
+			return err
+		}
+		report.NamesChanged = append(report.NamesChanged, types.ContainerNameChange{ID: c.ID, OldName: c.Name, NewName: name})
+		c.Lock()
+		c.Name = name
+		if err := c.ToDisk(); err != nil {
+			logrus.Errorf("Error saving container name to disk: %v", err)
+		}
+		c.Unlock()
+	}
+	return nil
+}
@@ -0,0 +1,141 @@
+package daemon
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	containertypes "github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/container"
+	"github.com/docker/docker/layer"
+	"github.com/docker/docker/pkg/graphdb"
+	"github.com/docker/docker/pkg/truncindex"
+)
+
+func TestReindexContainers(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "docker-reindex")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+
+	graph, err := graphdb.NewDatabase(filepath.Join(tmp, "links.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer graph.Close()
+
+	store := &contStore{s: make(map[string]*container.Container)}
+	newContainer := func(id, name string, created time.Time, onDisk bool) *container.Container {
+		c := &container.Container{
+			CommonContainer: container.CommonContainer{
+				ID:      id,
+				Name:    name,
+				Created: created,
+				Root:    filepath.Join(tmp, id),
+				State:   container.NewState(),
+				Config:  &containertypes.Config{Image: "busybox"},
+			},
+		}
+		if onDisk {
+			if err := os.Mkdir(c.Root, 0700); err != nil {
+				t.Fatal(err)
+			}
+		}
+		store.Add(id, c)
+		return c
+	}
+	now := time.Now()
+	web := newContainer("5a4ff6a163ad4533d22d69a2b8960bf7fafdcba06e72d2febdba229008b0bf57", "/web", now, true)
+	db := newContainer("3cdbd1aa394fd68559fd1441d6eff2ab7c1e6363582c82febfaa8045df3bd8de", "/db", now.Add(time.Second), true)
+	dup := newContainer("75fb0b800922abdbef2d27e60abcdfaf7fb0698b2a96d22d3354da361a6ff4a5", "/web", now.Add(2*time.Second), true)
+	gone := newContainer("d22d69a2b8960bf7fafdcba06e72d2febdba960bf7fafdcba06e72d2f9008b060b", "/gone", now, false)
+
+	// missing is stored on disk but was not loaded
+	missing := container.NewBaseContainer("4f1f3cb5bd5c5ab2a5d9d0a9b46bd9d1d7e2c1f7c5c86a39b6d6f9f1b6e3d3a1", filepath.Join(tmp, "4f1f3cb5bd5c5ab2a5d9d0a9b46bd9d1d7e2c1f7c5c86a39b6d6f9f1b6e3d3a1"))
+	missing.Name = "/missing"
+	missing.Driver = "aufs"
+	missing.Created = now
+	missing.Config = &containertypes.Config{Image: "busybox"}
+	missing.HostConfig = &containertypes.HostConfig{}
+	if err := os.Mkdir(missing.Root, 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := missing.ToDisk(); err != nil {
+		t.Fatal(err)
+	}
+
+	// The index misses db and has a removed container, the graph misses
+	// the name of db, has an old name of web and the name of a removed
+	// container, and web links to db.
+	index := truncindex.NewTruncIndex([]string{web.ID, dup.ID, gone.ID, "e90302cd6ba2"})
+	graph.Set("/web", web.ID)
+	graph.Set("/old_web", web.ID)
+	graph.Set("/web/database", db.ID)
+	graph.Set("/removed", "e90302cd6ba2")
+	graph.Set("/gone", gone.ID)
+
+	daemon := &Daemon{
+		repository:       tmp,
+		containers:       store,
+		idIndex:          index,
+		containerGraphDB: graph,
+		layerStore: &fakeRWLayerStore{
+			Store:    newFakeMigrateStore("aufs"),
+			rwLayers: map[string]layer.RWLayer{missing.ID: &fakeRWLayer{}},
+		},
+	}
+	report, err := daemon.ReindexContainers()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(report.ContainersRemoved) != 1 || report.ContainersRemoved[0] != gone.ID || store.Get(gone.ID) != nil {
+		t.Fatalf("Expected the container without directory to be removed, got %v", report.ContainersRemoved)
+	}
+	if len(report.ContainersLoaded) != 1 || report.ContainersLoaded[0] != missing.ID || store.Get(missing.ID) == nil {
+		t.Fatalf("Expected the container missing from memory to be loaded, got %v", report.ContainersLoaded)
+	}
+	if len(report.IDsAdded) != 1 || report.IDsAdded[0] != db.ID {
+		t.Fatalf("Expected the ID of db to be added, got %v", report.IDsAdded)
+	}
+	if len(report.IDsRemoved) != 2 {
+		t.Fatalf("Expected 2 IDs to be removed, got %v", report.IDsRemoved)
+	}
+	for _, id := range []string{web.ID, db.ID, dup.ID} {
+		if _, err := index.Get(id); err != nil {
+			t.Fatalf("Expected %s to be indexed: %v", id, err)
+		}
+	}
+	if len(report.NamesAdded) != 2 || report.NamesAdded[0] != "/missing" || report.NamesAdded[1] != "/db" {
+		t.Fatalf("Expected the names of missing and db to be added, got %v", report.NamesAdded)
+	}
+	if len(report.NamesRemoved) != 3 {
+		t.Fatalf("Expected 3 names to be removed, got %v", report.NamesRemoved)
+	}
+	if len(report.NamesChanged) != 1 || report.NamesChanged[0].ID != dup.ID || dup.Name == "/web" {
+		t.Fatalf("Expected the newest container named web to be renamed, got %v", report.NamesChanged)
+	}
+
+	for name, id := range map[string]string{"/web": web.ID, "/db": db.ID, "/web/database": db.ID, "/missing": missing.ID, dup.Name: dup.ID} {
+		if e := graph.Get(name); e == nil || e.ID() != id {
+			t.Fatalf("Expected %s to refer to %s, got %v", name, id, e)
+		}
+	}
+	for _, name := range []string{"/old_web", "/removed", "/gone"} {
+		if graph.Exists(name) {
+			t.Fatalf("Expected %s to be removed", name)
+		}
+	}
+
+	// A second reindex finds nothing to repair
+	report, err = daemon.ReindexContainers()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.IDsAdded)+len(report.IDsRemoved)+len(report.NamesAdded)+len(report.NamesRemoved)+len(report.NamesChanged)+len(report.ContainersRemoved)+len(report.ContainersLoaded) != 0 {
+		t.Fatalf("Expected nothing to repair, got %+v", report)
+	}
+}
@@ -0,0 +1,88 @@
+package daemon
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/distribution"
+	"github.com/docker/docker/reference"
+	"golang.org/x/net/context"
+)
+
+// remoteInspectTTL is how long the configurations of the images fetched from
+// the registries are cached.
+const remoteInspectTTL = time.Minute
+
+type remoteInspectEntry struct {
+	inspect *types.RemoteImageInspect
+	expires time.Time
+}
+
+// remoteInspectCache caches the configurations of the images fetched from
+// the registries, by reference and credentials, so that inspecting the same
+// image repeatedly does not hit the registry.
+type remoteInspectCache struct {
+	sync.Mutex
+	ttl     time.Duration
+	entries map[string]remoteInspectEntry
+}
+
+func newRemoteInspectCache(ttl time.Duration) *remoteInspectCache {
+	return &remoteInspectCache{ttl: ttl, entries: make(map[string]remoteInspectEntry)}
+}
+
+func (c *remoteInspectCache) get(key string, now time.Time) (*types.RemoteImageInspect, bool) {
+	c.Lock()
+	defer c.Unlock()
+	e, ok := c.entries[key]
+	if !ok || !now.Before(e.expires) {
+		return nil, false
+	}
+	return e.inspect, true
+}
+
+// set caches inspect under key, removing the expired entries.
+func (c *remoteInspectCache) set(key string, inspect *types.RemoteImageInspect, now time.Time) {
+	c.Lock()
+	defer c.Unlock()
+	for k, e := range c.entries {
+		if !now.Before(e.expires) {
+			delete(c.entries, k)
+		}
+	}
+	c.entries[key] = remoteInspectEntry{inspect: inspect, expires: now.Add(c.ttl)}
+}
+
+// remoteInspectKey returns the cache key of ref fetched with authConfig. The
+// credentials are part of the key so that an image fetched with some
+// credentials is not returned to a client without them.
+func remoteInspectKey(ref reference.Named, authConfig *types.AuthConfig) string {
+	h := sha256.New()
+	json.NewEncoder(h).Encode(authConfig)
+	return reference.WithDefaultTag(ref).String() + " " + hex.EncodeToString(h.Sum(nil))
+}
+
+// RemoteImageInspect returns the configuration of the image referenced by ref
+// in its registry, fetching only its manifest without pulling nor storing
+// the image. The configurations are cached for remoteInspectTTL.
+func (daemon *Daemon) RemoteImageInspect(ctx context.Context, ref reference.Named, metaHeaders map[string][]string, authConfig *types.AuthConfig) (*types.RemoteImageInspect, error) {
+	key := remoteInspectKey(ref, authConfig)
+	if inspect, ok := daemon.remoteInspect.get(key, time.Now()); ok {
+		return inspect, nil
+	}
+
+	inspect, err := distribution.Inspect(ctx, ref, &distribution.ImageInspectConfig{
+		MetaHeaders:     metaHeaders,
+		AuthConfig:      authConfig,
+		RegistryService: daemon.RegistryService,
+	})
+	if err != nil {
+		return nil, err
+	}
+	daemon.remoteInspect.set(key, inspect, time.Now())
+	return inspect, nil
+}
@@ -0,0 +1,49 @@
+package daemon
+
+import (
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/reference"
+)
+
+func TestRemoteInspectCache(t *testing.T) {
+	c := newRemoteInspectCache(time.Minute)
+	now := time.Now()
+	inspect := &types.RemoteImageInspect{Name: "busybox:latest"}
+
+	c.set("busybox", inspect, now)
+	if cached, ok := c.get("busybox", now.Add(59*time.Second)); !ok || cached != inspect {
+		t.Fatalf("Expected the cached inspection, got %v", cached)
+	}
+	if _, ok := c.get("busybox", now.Add(time.Minute)); ok {
+		t.Fatal("Expected the inspection to expire")
+	}
+	if _, ok := c.get("ubuntu", now); ok {
+		t.Fatal("Expected no inspection for another image")
+	}
+
+	c.set("ubuntu", inspect, now.Add(2*time.Minute))
+	if _, ok := c.entries["busybox"]; ok {
+		t.Fatal("Expected the expired entries to be removed")
+	}
+}
+
+func TestRemoteInspectKey(t *testing.T) {
+	name, err := reference.ParseNamed("busybox")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tagged, err := reference.ParseNamed("busybox:latest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	anonymous := &types.AuthConfig{}
+	if remoteInspectKey(name, anonymous) != remoteInspectKey(tagged, anonymous) {
+		t.Fatal("Expected the latest tag to be the default")
+	}
+	if remoteInspectKey(name, anonymous) == remoteInspectKey(name, &types.AuthConfig{Username: "user", Password: "secret"}) {
+		t.Fatal("Expected the credentials to be part of the key")
+	}
+}
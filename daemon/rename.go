@@ -10,7 +10,9 @@ import (
 
 // ContainerRename changes the name of a container, using the oldName
 // to find the container. An error is returned if newName is already
-// reserved.
+// reserved. The graph database, the container config on disk and the
+// network sandbox are all reverted to oldName if any of them fails to
+// be updated.
 func (daemon *Daemon) ContainerRename(oldName, newName string) error {
 	var (
 		sid string
@@ -21,6 +23,9 @@ func (daemon *Daemon) ContainerRename(oldName, newName string) error {
 		return derr.ErrorCodeEmptyRename
 	}
 
+	daemon.indexLock.RLock()
+	defer daemon.indexLock.RUnlock()
+
 	container, err := daemon.GetContainer(oldName)
 	if err != nil {
 		return err
@@ -30,6 +35,11 @@ func (daemon *Daemon) ContainerRename(oldName, newName string) error {
 
 	container.Lock()
 	defer container.Unlock()
+
+	if strings.TrimPrefix(oldName, "/") == strings.TrimPrefix(newName, "/") {
+		return derr.ErrorCodeRenameSame
+	}
+
 	if newName, err = daemon.reserveName(container.ID, newName); err != nil {
 		return derr.ErrorCodeRenameTaken.WithArgs(err)
 	}
@@ -52,8 +62,12 @@ func (daemon *Daemon) ContainerRename(oldName, newName string) error {
 		return err
 	}
 
+	attributes := map[string]string{
+		"oldName": strings.TrimPrefix(oldName, "/"),
+	}
+
 	if !container.Running {
-		daemon.LogContainerEvent(container, "rename")
+		daemon.LogContainerEventWithAttributes(container, "rename", attributes)
 		return nil
 	}
 
@@ -77,6 +91,8 @@ func (daemon *Daemon) ContainerRename(oldName, newName string) error {
 		return err
 	}
 
-	daemon.LogContainerEvent(container, "rename")
+	daemon.updateParentsHosts(container, oldName, newName)
+
+	daemon.LogContainerEventWithAttributes(container, "rename", attributes)
 	return nil
 }
@@ -0,0 +1,161 @@
+// +build linux freebsd
+
+package daemon
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	containertypes "github.com/docker/docker/api/types/container"
+	eventtypes "github.com/docker/docker/api/types/events"
+	networktypes "github.com/docker/docker/api/types/network"
+	"github.com/docker/docker/container"
+	"github.com/docker/docker/daemon/events"
+	"github.com/docker/docker/daemon/network"
+	derr "github.com/docker/docker/errors"
+	"github.com/docker/docker/pkg/graphdb"
+	"github.com/docker/docker/pkg/truncindex"
+)
+
+func newRenameTestDaemon(t *testing.T, root string, containers ...*container.Container) *Daemon {
+	graph, err := graphdb.NewDatabase(filepath.Join(root, "links.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	store := &contStore{s: map[string]*container.Container{}}
+	index := truncindex.NewTruncIndex([]string{})
+	for _, c := range containers {
+		store.Add(c.ID, c)
+		index.Add(c.ID)
+		if _, err := graph.Set(c.Name, c.ID); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return &Daemon{
+		containers:       store,
+		idIndex:          index,
+		containerGraphDB: graph,
+		EventsService:    events.New(),
+	}
+}
+
+func TestContainerRenameStopped(t *testing.T) {
+	root, err := ioutil.TempDir("", "docker-rename-test-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	c1 := container.NewBaseContainer("5a4ff6a163ad4533d22d69a2b8960bf7fafdcba06e72d2febdba229008b0bf57", filepath.Join(root, "c1"))
+	c1.Name = "/web"
+	c2 := container.NewBaseContainer("3cdbd1aa394fd68559fd1441d6eff2ab7c1e6363582c82febfaa8045df3bd8de", filepath.Join(root, "c2"))
+	c2.Name = "/db"
+	for _, c := range []*container.Container{c1, c2} {
+		c.Config = &containertypes.Config{Image: "busybox"}
+		c.HostConfig = &containertypes.HostConfig{}
+		if err := os.MkdirAll(c.Root, 0700); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	daemon := newRenameTestDaemon(t, root, c1, c2)
+	_, l, _ := daemon.EventsService.Subscribe()
+	defer daemon.EventsService.Evict(l)
+
+	if err := daemon.ContainerRename("web", "web"); err == nil || err.Error() != derr.ErrorCodeRenameSame.Error() {
+		t.Fatalf("Expected renaming to the same name to fail, got %v", err)
+	}
+	if err := daemon.ContainerRename("web", "db"); err == nil {
+		t.Fatal("Expected renaming to a taken name to fail")
+	}
+	if c1.Name != "/web" || !daemon.containerGraphDB.Exists("/web") {
+		t.Fatalf("Expected a failed rename to keep the old name, got %s", c1.Name)
+	}
+
+	if err := daemon.ContainerRename("web", "frontend"); err != nil {
+		t.Fatal(err)
+	}
+	if c1.Name != "/frontend" {
+		t.Fatalf("Expected name /frontend, got %s", c1.Name)
+	}
+	if daemon.containerGraphDB.Exists("/web") {
+		t.Fatal("Expected the old name to be released")
+	}
+	if c, err := daemon.GetContainer("frontend"); err != nil || c != c1 {
+		t.Fatalf("Expected to find the container by its new name, got %v", err)
+	}
+
+	onDisk := container.NewBaseContainer(c1.ID, c1.Root)
+	if err := onDisk.FromDisk(); err != nil {
+		t.Fatal(err)
+	}
+	if onDisk.Name != "/frontend" {
+		t.Fatalf("Expected the new name to be saved to disk, got %s", onDisk.Name)
+	}
+
+	select {
+	case ev := <-l:
+		msg := ev.(eventtypes.Message)
+		if msg.Action != "rename" || msg.Actor.Attributes["name"] != "frontend" || msg.Actor.Attributes["oldName"] != "web" {
+			t.Fatalf("Unexpected rename event %+v", msg)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected a rename event")
+	}
+}
+
+func TestUpdateParentsHosts(t *testing.T) {
+	root, err := ioutil.TempDir("", "docker-rename-test-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	child := container.NewBaseContainer("5a4ff6a163ad4533d22d69a2b8960bf7fafdcba06e72d2febdba229008b0bf57", filepath.Join(root, "child"))
+	child.Name = "/db"
+	child.Config = &containertypes.Config{Hostname: "5a4ff6a163ad"}
+	child.HostConfig = &containertypes.HostConfig{NetworkMode: "bridge"}
+	child.NetworkSettings = &network.Settings{
+		Networks: map[string]*networktypes.EndpointSettings{
+			"bridge": {IPAddress: "172.17.0.2"},
+		},
+	}
+	child.Running = true
+
+	parent := container.NewBaseContainer("3cdbd1aa394fd68559fd1441d6eff2ab7c1e6363582c82febfaa8045df3bd8de", filepath.Join(root, "parent"))
+	parent.Name = "/web"
+	parent.HostConfig = &containertypes.HostConfig{NetworkMode: "bridge"}
+	parent.HostsPath = filepath.Join(root, "hosts")
+	parent.Running = true
+	hosts := "127.0.0.1\tlocalhost\n172.17.0.2\tdatabase 5a4ff6a163ad db\n"
+	if err := ioutil.WriteFile(parent.HostsPath, []byte(hosts), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	daemon := newRenameTestDaemon(t, root, child, parent)
+	if err := daemon.registerLink(parent, child, "database"); err != nil {
+		t.Fatal(err)
+	}
+
+	daemon.updateParentsHosts(child, "/db", "/store")
+
+	content, err := ioutil.ReadFile(parent.HostsPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(content), "172.17.0.2\tdatabase 5a4ff6a163ad store\n") {
+		t.Fatalf("Expected the hosts entry to use the new name, got %q", content)
+	}
+	if strings.Contains(string(content), " db\n") {
+		t.Fatalf("Expected the old hosts entry to be removed, got %q", content)
+	}
+	if !strings.Contains(string(content), "127.0.0.1\tlocalhost\n") {
+		t.Fatalf("Expected unrelated entries to be kept, got %q", content)
+	}
+}
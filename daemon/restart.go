@@ -3,20 +3,27 @@ package daemon
 import (
 	"github.com/docker/docker/container"
 	derr "github.com/docker/docker/errors"
+	"golang.org/x/net/context"
 )
 
 // ContainerRestart stops and starts a container. It attempts to
 // gracefully stop the container within the given timeout, forcefully
-// stopping it if the timeout is exceeded. If given a negative
+// stopping it if the timeout is exceeded. If the timeout is nil, the
+// stop timeout of the container is used. If given a negative
 // timeout, ContainerRestart will wait forever until a graceful
 // stop. Returns an error if the container cannot be found, or if
 // there is an underlying error at any stage of the restart.
-func (daemon *Daemon) ContainerRestart(name string, seconds int) error {
-	container, err := daemon.GetContainer(name)
+func (daemon *Daemon) ContainerRestart(ctx context.Context, name string, seconds *int) error {
+	container, err := daemon.getContainerWithContext(ctx, name)
 	if err != nil {
 		return err
 	}
-	if err := daemon.containerRestart(container, seconds); err != nil {
+	timeout := daemon.stopTimeout(container)
+	if seconds != nil {
+		timeout = *seconds
+	}
+	daemon.setDesiredState(container, desiredRunning)
+	if err := daemon.containerRestart(ctx, container, timeout); err != nil {
 		return derr.ErrorCodeCantRestart.WithArgs(name, err)
 	}
 	return nil
@@ -26,19 +33,19 @@ func (daemon *Daemon) ContainerRestart(name string, seconds int) error {
 // container. When stopping, wait for the given duration in seconds to
 // gracefully stop, before forcefully terminating the container. If
 // given a negative duration, wait forever for a graceful stop.
-func (daemon *Daemon) containerRestart(container *container.Container, seconds int) error {
+func (daemon *Daemon) containerRestart(ctx context.Context, container *container.Container, seconds int) error {
 	// Avoid unnecessarily unmounting and then directly mounting
 	// the container when the container stops and then starts
 	// again
-	if err := daemon.Mount(container); err == nil {
+	if err := daemon.Mount(ctx, container); err == nil {
 		defer daemon.Unmount(container)
 	}
 
-	if err := daemon.containerStop(container, seconds); err != nil {
+	if err := daemon.containerStop(ctx, container, seconds); err != nil {
 		return err
 	}
 
-	if err := daemon.containerStart(container); err != nil {
+	if err := daemon.containerStartWithRetries(ctx, container, ""); err != nil {
 		return err
 	}
 
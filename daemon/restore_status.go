@@ -0,0 +1,118 @@
+package daemon
+
+import (
+	"strconv"
+	"sync"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/docker/docker/api/types"
+)
+
+// restoreProgressSteps is the number of restore_progress events logged while
+// the containers are loaded.
+const restoreProgressSteps = 10
+
+// restoreStatus tracks the restore of the containers when the daemon restores
+// them in the background.
+type restoreStatus struct {
+	sync.Mutex
+	inProgress bool
+	loaded     int
+	total      int
+	step       int
+}
+
+// start records the number of containers to load.
+func (s *restoreStatus) start(total int) {
+	s.Lock()
+	defer s.Unlock()
+	s.total = total
+}
+
+// load records that a container was loaded, and tells whether the progress
+// reached a new step.
+func (s *restoreStatus) load() bool {
+	s.Lock()
+	defer s.Unlock()
+	s.loaded++
+	if s.total == 0 {
+		return false
+	}
+	step := s.loaded * restoreProgressSteps / s.total
+	if step == s.step {
+		return false
+	}
+	s.step = step
+	return true
+}
+
+func (s *restoreStatus) done() {
+	s.Lock()
+	defer s.Unlock()
+	s.inProgress = false
+}
+
+func (s *restoreStatus) info() *types.RestoreStatus {
+	s.Lock()
+	defer s.Unlock()
+	return &types.RestoreStatus{
+		InProgress: s.inProgress,
+		Loaded:     s.loaded,
+		Total:      s.total,
+	}
+}
+
+// IsRestoring tells whether the daemon is restoring its containers in the
+// background.
+func (daemon *Daemon) IsRestoring() bool {
+	if daemon.restoreStatus == nil {
+		return false
+	}
+	return daemon.restoreStatus.info().InProgress
+}
+
+// restoreInBackground restores the containers while the daemon serves the
+// API, logging its progress as events.
+func (daemon *Daemon) restoreInBackground() {
+	daemon.restoreStatus = &restoreStatus{inProgress: true}
+	go func() {
+		if err := daemon.restore(); err != nil {
+			logrus.Errorf("Error restoring containers: %v", err)
+		}
+	}()
+}
+
+// restoreStarted is called by restore with the number of containers to load.
+func (daemon *Daemon) restoreStarted(total int) {
+	if daemon.restoreStatus == nil {
+		return
+	}
+	daemon.restoreStatus.start(total)
+	daemon.LogDaemonEvent("restore_start", map[string]string{"total": strconv.Itoa(total)})
+}
+
+// containerLoaded is called by restore after each container is loaded,
+// whether it could be or not.
+func (daemon *Daemon) containerLoaded() {
+	if daemon.restoreStatus == nil || !daemon.restoreStatus.load() {
+		return
+	}
+	daemon.LogDaemonEvent("restore_progress", restoreAttributes(daemon.restoreStatus.info()))
+}
+
+// restoreDone is called by restore once the containers are loaded and
+// restarted.
+func (daemon *Daemon) restoreDone() {
+	if daemon.restoreStatus == nil {
+		return
+	}
+	daemon.restoreStatus.done()
+	daemon.LogDaemonEvent("restore_done", restoreAttributes(daemon.restoreStatus.info()))
+}
+
+func restoreAttributes(status *types.RestoreStatus) map[string]string {
+	return map[string]string{
+		"loaded": strconv.Itoa(status.Loaded),
+		"total":  strconv.Itoa(status.Total),
+	}
+}
@@ -0,0 +1,33 @@
+package daemon
+
+import "testing"
+
+func TestRestoreStatus(t *testing.T) {
+	s := &restoreStatus{inProgress: true}
+	s.start(25)
+
+	var steps int
+	for i := 0; i < 25; i++ {
+		if s.load() {
+			steps++
+		}
+	}
+	if steps != restoreProgressSteps {
+		t.Fatalf("Expected %d progress steps, got %d", restoreProgressSteps, steps)
+	}
+
+	info := s.info()
+	if !info.InProgress || info.Loaded != 25 || info.Total != 25 {
+		t.Fatalf("Unexpected status %+v", info)
+	}
+	s.done()
+	if s.info().InProgress {
+		t.Fatal("Expected the restore to be done")
+	}
+
+	empty := &restoreStatus{inProgress: true}
+	empty.start(0)
+	if empty.load() {
+		t.Fatal("Expected no progress step without containers")
+	}
+}
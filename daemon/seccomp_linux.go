@@ -0,0 +1,15 @@
+// +build linux
+
+package daemon
+
+import "github.com/docker/docker/daemon/execdriver/native"
+
+// validateSeccompProfile checks that the seccomp profile at path is loaded by
+// the native driver, so that an invalid profile is reported when a container
+// is created rather than when it is started.
+func validateSeccompProfile(path string) error {
+	if path == "" || path == "unconfined" {
+		return nil
+	}
+	return native.ValidateSeccompProfile(path)
+}
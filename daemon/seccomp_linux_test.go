@@ -0,0 +1,43 @@
+// +build linux
+
+package daemon
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestValidateSeccompProfile(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "docker-seccomp-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+
+	valid := filepath.Join(tmp, "valid.json")
+	if err := ioutil.WriteFile(valid, []byte(`{"defaultAction": "SCMP_ACT_ERRNO", "syscalls": [{"name": "read", "action": "SCMP_ACT_ALLOW"}]}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	invalid := filepath.Join(tmp, "invalid.json")
+	if err := ioutil.WriteFile(invalid, []byte(`{"defaultAction": `), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, path := range []string{"", "unconfined", valid} {
+		if err := validateSeccompProfile(path); err != nil {
+			t.Fatalf("Expected %q to be valid, got %v", path, err)
+		}
+	}
+	unknownAction := filepath.Join(tmp, "unknown-action.json")
+	if err := ioutil.WriteFile(unknownAction, []byte(`{"defaultAction": "SCMP_ACT_ERRNO", "syscalls": [{"name": "read", "action": "SCMP_ACT_NOPE"}]}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, path := range []string{invalid, unknownAction, filepath.Join(tmp, "missing.json")} {
+		if err := validateSeccompProfile(path); err == nil {
+			t.Fatalf("Expected %q to be invalid", path)
+		}
+	}
+}
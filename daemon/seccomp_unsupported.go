@@ -0,0 +1,12 @@
+// +build !linux
+
+package daemon
+
+import "fmt"
+
+func validateSeccompProfile(path string) error {
+	if path == "" || path == "unconfined" {
+		return nil
+	}
+	return fmt.Errorf("Seccomp profiles are only supported on Linux")
+}
@@ -0,0 +1,107 @@
+package daemon
+
+import (
+	"strings"
+
+	"github.com/docker/distribution/registry/api/errcode"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/container"
+	derr "github.com/docker/docker/errors"
+)
+
+// labelSelectorPrefix is the prefix of the container references selecting
+// the containers by their labels, for example "label:app=web,env=prod".
+// Container names cannot contain a colon, so the references cannot clash.
+const labelSelectorPrefix = "label:"
+
+// isLabelSelector tells whether the container reference is a label selector.
+func isLabelSelector(ref string) bool {
+	return strings.HasPrefix(ref, labelSelectorPrefix)
+}
+
+// parseLabelSelector returns the label filters of the selector, a comma
+// separated list of key or key=value pairs which must all match.
+func parseLabelSelector(selector string) (filters.Args, error) {
+	args := filters.NewArgs()
+	value := strings.TrimPrefix(selector, labelSelectorPrefix)
+	if value == "" {
+		return args, derr.ErrorCodeInvalidSelector.WithArgs(selector)
+	}
+	for _, label := range strings.Split(value, ",") {
+		if label == "" || strings.HasPrefix(label, "=") {
+			return args, derr.ErrorCodeInvalidSelector.WithArgs(selector)
+		}
+		args.Add("label", label)
+	}
+	return args, nil
+}
+
+// selectContainers returns the containers whose labels match the selector,
+// newest first.
+func (daemon *Daemon) selectContainers(selector string) ([]*container.Container, error) {
+	args, err := parseLabelSelector(selector)
+	if err != nil {
+		return nil, err
+	}
+	var selected []*container.Container
+	for _, c := range daemon.List() {
+		if c.Config != nil && args.MatchKVList("label", c.Config.Labels) {
+			selected = append(selected, c)
+		}
+	}
+	return selected, nil
+}
+
+// getSelectedContainer returns the single container matched by the selector.
+func (daemon *Daemon) getSelectedContainer(selector string) (*container.Container, error) {
+	selected, err := daemon.selectContainers(selector)
+	if err != nil {
+		return nil, err
+	}
+	switch len(selected) {
+	case 0:
+		return nil, derr.ErrorCodeNoSuchContainer.WithArgs(selector)
+	case 1:
+		return selected[0], nil
+	}
+	return nil, derr.ErrorCodeAmbiguousSelector.WithArgs(selector, len(selected))
+}
+
+// forEachSelected calls fn with the ID of every container matched by the
+// selector, and returns the errors of all the calls together. The errors
+// ignored by the caller, for example the containers already stopped, are
+// skipped.
+func (daemon *Daemon) forEachSelected(selector string, fn func(id string) error, ignore ...errcode.ErrorCode) error {
+	selected, err := daemon.selectContainers(selector)
+	if err != nil {
+		return err
+	}
+	if len(selected) == 0 {
+		return derr.ErrorCodeNoSuchContainer.WithArgs(selector)
+	}
+
+	var errs []string
+	for _, c := range selected {
+		if err := fn(c.ID); err != nil && !isErrorCode(err, ignore) {
+			errs = append(errs, c.ID+": "+err.Error())
+		}
+	}
+	if len(errs) > 0 {
+		return derr.ErrorCodeSelectorFailed.WithArgs(selector, strings.Join(errs, "\n"))
+	}
+	return nil
+}
+
+// isErrorCode tells whether err has one of the error codes.
+func isErrorCode(err error, codes []errcode.ErrorCode) bool {
+	coder, ok := err.(errcode.ErrorCoder)
+	if !ok {
+		return false
+	}
+	for _, code := range codes {
+		if coder.ErrorCode() == code {
+			return true
+		}
+	}
+	return false
+}
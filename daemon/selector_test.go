@@ -0,0 +1,73 @@
+package daemon
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/docker/distribution/registry/api/errcode"
+	containertypes "github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/container"
+	derr "github.com/docker/docker/errors"
+)
+
+func TestParseLabelSelector(t *testing.T) {
+	args, err := parseLabelSelector("label:app=web,env")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if labels := args.Get("label"); len(labels) != 2 {
+		t.Fatalf("Expected the labels app=web and env, got %v", labels)
+	}
+
+	for _, selector := range []string{"label:", "label:app=web,", "label:=web"} {
+		if _, err := parseLabelSelector(selector); err == nil {
+			t.Fatalf("Expected an error for the selector %q", selector)
+		}
+	}
+}
+
+func TestSelectContainers(t *testing.T) {
+	store := &contStore{s: make(map[string]*container.Container)}
+	for id, labels := range map[string]map[string]string{
+		"web1": {"app": "web", "env": "prod"},
+		"web2": {"app": "web", "env": "dev"},
+		"db":   {"app": "db", "env": "prod"},
+	} {
+		c := container.NewBaseContainer(id, "")
+		c.Config = &containertypes.Config{Labels: labels}
+		store.Add(id, c)
+	}
+	daemon := &Daemon{containers: store}
+
+	c, err := daemon.GetContainer("label:app=web,env=prod")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.ID != "web1" {
+		t.Fatalf("Expected the container web1, got %s", c.ID)
+	}
+	if _, err := daemon.GetContainer("label:app=web"); err == nil || err.(errcode.ErrorCoder).ErrorCode() != derr.ErrorCodeAmbiguousSelector {
+		t.Fatalf("Expected the selector to be ambiguous, got %v", err)
+	}
+	if _, err := daemon.GetContainer("label:app=cache"); err == nil || err.(errcode.ErrorCoder).ErrorCode() != derr.ErrorCodeNoSuchContainer {
+		t.Fatalf("Expected no such container, got %v", err)
+	}
+
+	var ids []string
+	err = daemon.forEachSelected("label:env=prod", func(id string) error {
+		ids = append(ids, id)
+		if id == "db" {
+			return derr.ErrorCodeStopped
+		}
+		return fmt.Errorf("failed")
+	}, derr.ErrorCodeStopped)
+	sort.Strings(ids)
+	if strings.Join(ids, ",") != "db,web1" {
+		t.Fatalf("Expected the containers db and web1, got %v", ids)
+	}
+	if err == nil || !strings.Contains(err.Error(), "web1: failed") || strings.Contains(err.Error(), "db:") {
+		t.Fatalf("Expected the error of web1 only, got %v", err)
+	}
+}
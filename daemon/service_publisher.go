@@ -0,0 +1,235 @@
+package daemon
+
+import (
+	"encoding/json"
+	"os"
+	"path"
+	"sync"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/docker/docker/api/types"
+	eventtypes "github.com/docker/docker/api/types/events"
+	"github.com/docker/docker/container"
+	"github.com/docker/docker/pkg/discovery/kv"
+	"github.com/docker/go-connections/nat"
+	"github.com/docker/libkv/store"
+)
+
+// servicesPath is the directory of the key-value store the containers are
+// published in, under the prefix of the store URL.
+const servicesPath = "docker/containers"
+
+// serviceRecord is the description of a running container published in the
+// key-value store.
+type serviceRecord struct {
+	ID     string
+	Name   string
+	Host   string
+	Labels map[string]string
+	// IPs are the addresses of the container by network name.
+	IPs   map[string]string
+	Ports []types.Port
+}
+
+// newServiceRecord describes the container c for the service discovery. c
+// must be locked.
+func newServiceRecord(c *container.Container, host string) *serviceRecord {
+	r := &serviceRecord{
+		ID:     c.ID,
+		Name:   c.Name[1:],
+		Host:   host,
+		Labels: c.Config.Labels,
+		IPs:    make(map[string]string),
+		Ports:  []types.Port{},
+	}
+	for name, settings := range c.NetworkSettings.Networks {
+		if settings.IPAddress != "" {
+			r.IPs[name] = settings.IPAddress
+		}
+	}
+	for port, bindings := range c.NetworkSettings.Ports {
+		private, err := nat.ParsePort(port.Port())
+		if err != nil {
+			continue
+		}
+		for _, binding := range bindings {
+			public, err := nat.ParsePort(binding.HostPort)
+			if err != nil {
+				continue
+			}
+			r.Ports = append(r.Ports, types.Port{
+				PrivatePort: private,
+				PublicPort:  public,
+				Type:        port.Proto(),
+				IP:          binding.HostIP,
+			})
+		}
+	}
+	return r
+}
+
+// servicePublisher registers the running containers in a key-value store,
+// so that they can be discovered without running a registration sidecar.
+// The records expire after a TTL unless they are refreshed, so that the
+// records of a daemon which went away disappear.
+type servicePublisher struct {
+	mu        sync.Mutex
+	store     store.Store
+	path      string
+	host      string
+	heartbeat time.Duration
+	ttl       time.Duration
+	// records are the published records by container ID.
+	records   map[string][]byte
+	stop      chan struct{}
+	closeOnce sync.Once
+}
+
+// newServicePublisher returns the publisher configured by --service-publish,
+// or nil if it is disabled. The TLS and TTL options of the store are the
+// ones of the cluster store.
+func newServicePublisher(config *Config) (*servicePublisher, error) {
+	if config.ServicePublish == "" {
+		return nil, nil
+	}
+	heartbeat, ttl, err := discoveryOpts(config.ClusterOpts)
+	if err != nil {
+		return nil, err
+	}
+	d, err := kv.New(config.ServicePublish, heartbeat, ttl, config.ClusterOpts)
+	if err != nil {
+		return nil, err
+	}
+	host, _ := os.Hostname()
+	return &servicePublisher{
+		store:     d.Store(),
+		path:      path.Join(d.Prefix(), servicesPath),
+		host:      host,
+		heartbeat: heartbeat,
+		ttl:       ttl,
+		records:   make(map[string][]byte),
+		stop:      make(chan struct{}),
+	}, nil
+}
+
+// publish registers the container c, or refreshes its record.
+func (p *servicePublisher) publish(c *container.Container) error {
+	c.Lock()
+	r := newServiceRecord(c, p.host)
+	c.Unlock()
+	value, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	select {
+	case <-p.stop:
+		// The containers are not published anymore once closed
+		return nil
+	default:
+	}
+	if err := p.store.Put(path.Join(p.path, r.ID), value, &store.WriteOptions{TTL: p.ttl}); err != nil {
+		return err
+	}
+	p.records[r.ID] = value
+	return nil
+}
+
+// unpublish deregisters the container with the given ID, if it was
+// published.
+func (p *servicePublisher) unpublish(id string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if _, ok := p.records[id]; !ok {
+		return nil
+	}
+	delete(p.records, id)
+	if err := p.store.Delete(path.Join(p.path, id)); err != nil && err != store.ErrKeyNotFound {
+		return err
+	}
+	return nil
+}
+
+// refresh writes the published records again before their TTL expires.
+func (p *servicePublisher) refresh() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for id, value := range p.records {
+		if err := p.store.Put(path.Join(p.path, id), value, &store.WriteOptions{TTL: p.ttl}); err != nil {
+			logrus.Warnf("Failed to refresh the record of container %s in the service discovery: %v", id, err)
+		}
+	}
+}
+
+// close stops the publisher and deregisters the containers still
+// published. It can be called more than once.
+func (p *servicePublisher) close() {
+	if p == nil {
+		return
+	}
+	p.closeOnce.Do(func() {
+		close(p.stop)
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		for id := range p.records {
+			if err := p.store.Delete(path.Join(p.path, id)); err != nil && err != store.ErrKeyNotFound {
+				logrus.Warnf("Failed to deregister container %s from the service discovery: %v", id, err)
+			}
+			delete(p.records, id)
+		}
+	})
+}
+
+// runServicePublisher publishes the running containers, then the containers
+// as they start, and deregisters them when they die or are destroyed, until
+// the publisher is closed.
+func (daemon *Daemon) runServicePublisher(p *servicePublisher) {
+	_, l, cancel := daemon.EventsService.Subscribe()
+	defer cancel()
+
+	for _, c := range daemon.List() {
+		if c.IsRunning() {
+			daemon.publishContainer(p, c.ID)
+		}
+	}
+
+	ticker := time.NewTicker(p.heartbeat)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-p.stop:
+			return
+		case <-ticker.C:
+			p.refresh()
+		case ev, ok := <-l:
+			if !ok {
+				return
+			}
+			msg, ok := ev.(eventtypes.Message)
+			if !ok || msg.Type != eventtypes.ContainerEventType {
+				continue
+			}
+			switch msg.Action {
+			case "start", "rename":
+				daemon.publishContainer(p, msg.Actor.ID)
+			case "die", "destroy":
+				if err := p.unpublish(msg.Actor.ID); err != nil {
+					logrus.Warnf("Failed to deregister container %s from the service discovery: %v", msg.Actor.ID, err)
+				}
+			}
+		}
+	}
+}
+
+func (daemon *Daemon) publishContainer(p *servicePublisher, id string) {
+	c, err := daemon.GetContainer(id)
+	if err != nil || !c.IsRunning() {
+		return
+	}
+	if err := p.publish(c); err != nil {
+		logrus.Warnf("Failed to publish container %s in the service discovery: %v", id, err)
+	}
+}
@@ -0,0 +1,112 @@
+package daemon
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	containertypes "github.com/docker/docker/api/types/container"
+	networktypes "github.com/docker/docker/api/types/network"
+	"github.com/docker/docker/container"
+	"github.com/docker/docker/daemon/network"
+	"github.com/docker/go-connections/nat"
+	"github.com/docker/libkv/store"
+)
+
+// memoryStore is a key-value store keeping the values in memory.
+type memoryStore struct {
+	store.Store
+	values map[string][]byte
+	ttls   map[string]time.Duration
+}
+
+func (s *memoryStore) Put(key string, value []byte, options *store.WriteOptions) error {
+	s.values[key] = value
+	s.ttls[key] = options.TTL
+	return nil
+}
+
+func (s *memoryStore) Delete(key string) error {
+	if _, ok := s.values[key]; !ok {
+		return store.ErrKeyNotFound
+	}
+	delete(s.values, key)
+	return nil
+}
+
+func TestServicePublisher(t *testing.T) {
+	s := &memoryStore{values: make(map[string][]byte), ttls: make(map[string]time.Duration)}
+	p := &servicePublisher{
+		store:   s,
+		path:    "prefix/docker/containers",
+		host:    "node-1",
+		ttl:     time.Minute,
+		records: make(map[string][]byte),
+		stop:    make(chan struct{}),
+	}
+
+	c := &container.Container{
+		CommonContainer: container.CommonContainer{
+			ID:     "5a4ff6a163ad",
+			Name:   "/web",
+			State:  container.NewState(),
+			Config: &containertypes.Config{Labels: map[string]string{"service": "web"}},
+			NetworkSettings: &network.Settings{
+				Networks: map[string]*networktypes.EndpointSettings{
+					"bridge": {IPAddress: "172.17.0.2"},
+					"none":   {},
+				},
+				Ports: nat.PortMap{
+					"80/tcp":  {{HostIP: "0.0.0.0", HostPort: "8080"}},
+					"443/tcp": {},
+				},
+			},
+		},
+	}
+	if err := p.publish(c); err != nil {
+		t.Fatal(err)
+	}
+
+	key := "prefix/docker/containers/5a4ff6a163ad"
+	var r serviceRecord
+	if err := json.Unmarshal(s.values[key], &r); err != nil {
+		t.Fatal(err)
+	}
+	if r.Name != "web" || r.Host != "node-1" || r.Labels["service"] != "web" {
+		t.Fatalf("Unexpected record %+v", r)
+	}
+	if len(r.IPs) != 1 || r.IPs["bridge"] != "172.17.0.2" {
+		t.Fatalf("Expected the bridge address only, got %v", r.IPs)
+	}
+	if len(r.Ports) != 1 || r.Ports[0].PrivatePort != 80 || r.Ports[0].PublicPort != 8080 || r.Ports[0].Type != "tcp" {
+		t.Fatalf("Expected the published port only, got %+v", r.Ports)
+	}
+	if s.ttls[key] != time.Minute {
+		t.Fatalf("Expected the record to expire after a minute, got %s", s.ttls[key])
+	}
+
+	// The store lost the record, which is written again by the refresh.
+	delete(s.values, key)
+	p.refresh()
+	if _, ok := s.values[key]; !ok {
+		t.Fatal("Expected the record to be refreshed")
+	}
+
+	if err := p.unpublish(c.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := s.values[key]; ok {
+		t.Fatal("Expected the record to be deleted")
+	}
+
+	if err := p.publish(c); err != nil {
+		t.Fatal(err)
+	}
+	p.close()
+	if len(s.values) != 0 {
+		t.Fatalf("Expected the records to be deleted on close, got %v", s.values)
+	}
+	if err := p.publish(c); err != nil || len(s.values) != 0 {
+		t.Fatalf("Expected no publication once closed, got %v and %v", s.values, err)
+	}
+}
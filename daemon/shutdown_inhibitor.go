@@ -0,0 +1,70 @@
+package daemon
+
+import (
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/docker/docker/container"
+	"golang.org/x/net/context"
+)
+
+// hostShutdownMargin is the part of the delay granted by the host to its
+// shutdown which is kept to kill the containers that did not stop.
+const hostShutdownMargin = time.Second
+
+// hostShutdownStopTimeout returns the number of seconds to wait for a
+// container with the given stop timeout to stop before killing it, so that
+// it is stopped within the delay granted by the host to its shutdown.
+func hostShutdownStopTimeout(budget time.Duration, timeout int) int {
+	available := int((budget - hostShutdownMargin) / time.Second)
+	if available < 0 {
+		available = 0
+	}
+	if timeout < 0 || timeout > available {
+		return available
+	}
+	return timeout
+}
+
+// stopForHostShutdown stops the running containers before the host shuts
+// down, within the given budget. The daemon is marked as shutting down, so
+// that the containers are neither restarted nor recorded as stopped by the
+// user.
+func (daemon *Daemon) stopForHostShutdown(budget time.Duration) {
+	daemon.shutdown = true
+	daemon.pressure.close()
+
+	var running []*container.Container
+	for _, c := range daemon.List() {
+		if c.IsRunning() {
+			running = append(running, c)
+		}
+	}
+	logrus.Infof("Host shutting down, stopping %d containers within %v", len(running), budget)
+	daemon.LogDaemonEvent("host_shutdown", map[string]string{
+		"containers": strconv.Itoa(len(running)),
+		"budget":     budget.String(),
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), budget)
+	defer cancel()
+	group := sync.WaitGroup{}
+	for _, c := range running {
+		group.Add(1)
+		go func(c *container.Container) {
+			defer group.Done()
+			if c.IsPaused() {
+				if err := daemon.containerUnpause(c); err != nil {
+					logrus.Errorf("Failed to unpause container %s before the host shutdown: %v", c.ID, err)
+				}
+			}
+			timeout := hostShutdownStopTimeout(budget, daemon.stopTimeout(c))
+			if err := daemon.containerStop(ctx, c, timeout); err != nil {
+				logrus.Errorf("Failed to stop container %s before the host shutdown: %v", c.ID, err)
+			}
+		}(c)
+	}
+	group.Wait()
+}
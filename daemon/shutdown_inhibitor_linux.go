@@ -0,0 +1,147 @@
+package daemon
+
+import (
+	"fmt"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/godbus/dbus"
+)
+
+const (
+	logindDest      = "org.freedesktop.login1"
+	logindPath      = dbus.ObjectPath("/org/freedesktop/login1")
+	logindInterface = "org.freedesktop.login1.Manager"
+
+	// defaultHostShutdownBudget is the delay used when logind does not
+	// tell the maximum delay of its inhibitors, which is 5 seconds by
+	// default.
+	defaultHostShutdownBudget = 5 * time.Second
+)
+
+// shutdownInhibitor holds a delay inhibitor lock on the shutdown of the host
+// from logind, so that the daemon can stop the containers gracefully before
+// the host shuts down.
+type shutdownInhibitor struct {
+	mu      sync.Mutex
+	conn    *dbus.Conn
+	manager dbus.BusObject
+	// fd is the file descriptor of the lock, -1 if it is not held.
+	fd      int
+	signals chan *dbus.Signal
+}
+
+// newShutdownInhibitor connects to logind and takes the lock.
+func newShutdownInhibitor() (*shutdownInhibitor, error) {
+	conn, err := dbus.SystemBusPrivate()
+	if err != nil {
+		return nil, err
+	}
+	if err := conn.Auth(nil); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if err := conn.Hello(); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	i := &shutdownInhibitor{
+		conn:    conn,
+		manager: conn.Object(logindDest, logindPath),
+		fd:      -1,
+		signals: make(chan *dbus.Signal, 10),
+	}
+	rule := fmt.Sprintf("type='signal',interface='%s',member='PrepareForShutdown'", logindInterface)
+	if err := conn.BusObject().Call("org.freedesktop.DBus.AddMatch", 0, rule).Store(); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	conn.Signal(i.signals)
+	if err := i.lock(); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return i, nil
+}
+
+// lock takes the lock if it is not held.
+func (i *shutdownInhibitor) lock() error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	if i.fd != -1 {
+		return nil
+	}
+	var fd dbus.UnixFD
+	if err := i.manager.Call(logindInterface+".Inhibit", 0, "shutdown", "Docker", "Stopping the containers", "delay").Store(&fd); err != nil {
+		return fmt.Errorf("failed to take the shutdown inhibitor lock: %v", err)
+	}
+	i.fd = int(fd)
+	return nil
+}
+
+// release releases the lock if it is held.
+func (i *shutdownInhibitor) release() {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	if i.fd == -1 {
+		return
+	}
+	syscall.Close(i.fd)
+	i.fd = -1
+}
+
+// budget returns the maximum delay granted by logind to the shutdown.
+func (i *shutdownInhibitor) budget() time.Duration {
+	v, err := i.manager.GetProperty(logindInterface + ".InhibitDelayMaxUSec")
+	if err != nil {
+		logrus.Warnf("Failed to read the maximum delay of the shutdown inhibitors: %v", err)
+		return defaultHostShutdownBudget
+	}
+	usec, ok := v.Value().(uint64)
+	if !ok {
+		return defaultHostShutdownBudget
+	}
+	return time.Duration(usec) * time.Microsecond
+}
+
+// close releases the lock and disconnects from logind.
+func (i *shutdownInhibitor) close() {
+	if i == nil {
+		return
+	}
+	i.release()
+	i.conn.Close()
+}
+
+// startShutdownInhibitor takes the shutdown inhibitor lock, and stops the
+// containers before releasing it once the host begins shutting down.
+func (daemon *Daemon) startShutdownInhibitor() error {
+	i, err := newShutdownInhibitor()
+	if err != nil {
+		return err
+	}
+	daemon.inhibitor = i
+	go func() {
+		for sig := range i.signals {
+			if sig.Name != logindInterface+".PrepareForShutdown" || len(sig.Body) != 1 {
+				continue
+			}
+			if start, _ := sig.Body[0].(bool); !start {
+				// The shutdown was cancelled, the containers stopped
+				// meanwhile are left stopped.
+				logrus.Info("Host shutdown cancelled")
+				daemon.shutdown = false
+				if err := i.lock(); err != nil {
+					logrus.Error(err)
+				}
+				continue
+			}
+			daemon.stopForHostShutdown(i.budget())
+			i.release()
+		}
+	}()
+	return nil
+}
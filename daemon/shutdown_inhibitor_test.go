@@ -0,0 +1,25 @@
+package daemon
+
+import (
+	"testing"
+	"time"
+)
+
+func TestHostShutdownStopTimeout(t *testing.T) {
+	for _, c := range []struct {
+		budget   time.Duration
+		timeout  int
+		expected int
+	}{
+		{5 * time.Second, 2, 2},
+		{5 * time.Second, 10, 4},
+		{5 * time.Second, -1, 4},
+		{30 * time.Second, 10, 10},
+		{1500 * time.Millisecond, 10, 0},
+		{0, 10, 0},
+	} {
+		if timeout := hostShutdownStopTimeout(c.budget, c.timeout); timeout != c.expected {
+			t.Fatalf("Expected %d seconds with a budget of %v and a stop timeout of %d, got %d", c.expected, c.budget, c.timeout, timeout)
+		}
+	}
+}
@@ -0,0 +1,14 @@
+// +build !linux
+
+package daemon
+
+import "fmt"
+
+// shutdownInhibitor is only supported on Linux, with logind.
+type shutdownInhibitor struct{}
+
+func (i *shutdownInhibitor) close() {}
+
+func (daemon *Daemon) startShutdownInhibitor() error {
+	return fmt.Errorf("Inhibiting the shutdown of the host is only supported on Linux")
+}
@@ -8,11 +8,14 @@ import (
 	"github.com/docker/docker/container"
 	derr "github.com/docker/docker/errors"
 	"github.com/docker/docker/runconfig"
+	"github.com/docker/docker/utils"
+	"golang.org/x/net/context"
 )
 
-// ContainerStart starts a container.
-func (daemon *Daemon) ContainerStart(name string, hostConfig *containertypes.HostConfig) error {
-	container, err := daemon.GetContainer(name)
+// ContainerStart starts a container. If checkpoint is set, the processes
+// of the container are restored from this checkpoint.
+func (daemon *Daemon) ContainerStart(ctx context.Context, name string, hostConfig *containertypes.HostConfig, checkpoint string) error {
+	container, err := daemon.getContainerWithContext(ctx, name)
 	if err != nil {
 		return err
 	}
@@ -56,19 +59,50 @@ func (daemon *Daemon) ContainerStart(name string, hostConfig *containertypes.Hos
 		return err
 	}
 
-	return daemon.containerStart(container)
+	daemon.setDesiredState(container, desiredRunning)
+	return daemon.containerStartWithRetries(ctx, container, checkpoint)
+}
+
+// ContainerStartWithEnv starts a container like ContainerStart, adding env
+// to the environment of its process. env is not stored in the config of the
+// container, so it is never saved nor committed, and it is not given to the
+// processes of the later starts of the container.
+func (daemon *Daemon) ContainerStartWithEnv(ctx context.Context, name string, env []string) error {
+	container, err := daemon.getContainerWithContext(ctx, name)
+	if err != nil {
+		return err
+	}
+
+	container.Lock()
+	container.ProcessEnv = env
+	container.Unlock()
+	defer func() {
+		container.Lock()
+		container.ProcessEnv = nil
+		container.Unlock()
+	}()
+
+	return daemon.ContainerStart(ctx, name, nil, "")
 }
 
 // Start starts a container
 func (daemon *Daemon) Start(container *container.Container) error {
-	return daemon.containerStart(container)
+	return daemon.containerStart(context.Background(), container, "")
 }
 
 // containerStart prepares the container to run by setting up everything the
 // container needs, such as storage and networking, as well as links
 // between containers. The container is left waiting for a signal to
-// begin running.
-func (daemon *Daemon) containerStart(container *container.Container) (err error) {
+// begin running. The start is aborted if ctx is cancelled before the
+// container process is launched. If checkpoint is set, the processes
+// are restored from this checkpoint instead of being started.
+func (daemon *Daemon) containerStart(ctx context.Context, container *container.Container, checkpoint string) (err error) {
+	done, err := daemon.beginStorageWrite()
+	if err != nil {
+		return err
+	}
+	defer done()
+
 	container.Lock()
 	defer container.Unlock()
 
@@ -76,10 +110,20 @@ func (daemon *Daemon) containerStart(container *container.Container) (err error)
 		return nil
 	}
 
+	daemon.releaseHold(container, "start")
+
 	if container.RemovalInProgress || container.Dead {
 		return derr.ErrorCodeContainerBeingRemoved
 	}
 
+	if container.Broken {
+		return derr.ErrorCodeContainerBroken.WithArgs(container.ID, container.Error)
+	}
+
+	if err := container.CanStart(); err != nil {
+		return err
+	}
+
 	// if we encounter an error during start we need to ensure that any other
 	// setup has been cleaned up properly
 	defer func() {
@@ -95,7 +139,11 @@ func (daemon *Daemon) containerStart(container *container.Container) (err error)
 		}
 	}()
 
-	if err := daemon.conditionalMountOnStart(container); err != nil {
+	if err := daemon.checkKernelModules(container); err != nil {
+		return err
+	}
+
+	if err := daemon.conditionalMountOnStart(ctx, container); err != nil {
 		return err
 	}
 
@@ -113,7 +161,17 @@ func (daemon *Daemon) containerStart(container *container.Container) (err error)
 	if err := container.SetupWorkingDirectory(); err != nil {
 		return err
 	}
+	if err := daemon.setupLocaltime(container); err != nil {
+		return err
+	}
 	env := container.CreateDaemonEnvironment(linkedEnv)
+	if tz := daemon.containerTimezone(container); tz != "" {
+		// the timezone of the container takes precedence over the image
+		env = utils.ReplaceOrAppendEnvValues(env, []string{"TZ=" + tz})
+	}
+	if len(container.ProcessEnv) > 0 {
+		env = utils.ReplaceOrAppendEnvValues(env, container.ProcessEnv)
+	}
 	if err := daemon.populateCommand(container, env); err != nil {
 		return err
 	}
@@ -130,11 +188,24 @@ func (daemon *Daemon) containerStart(container *container.Container) (err error)
 	}
 	mounts = append(mounts, container.IpcMounts()...)
 	mounts = append(mounts, container.TmpfsMounts()...)
+	mounts = append(mounts, container.LocaltimeMounts()...)
 
 	container.Command.Mounts = mounts
+	if checkpoint != "" {
+		if container.Command.Restore, err = checkpointRestoreOpts(container, checkpoint); err != nil {
+			return err
+		}
+		// Restarts of the container by its restart policy start new processes
+		defer func() { container.Command.Restore = nil }()
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
 	if err := daemon.waitForStart(container); err != nil {
 		return err
 	}
+	daemon.initHealthMonitor(container)
+	daemon.updateImageLastUsed(container.ImageID)
 	container.HasBeenStartedBefore = true
 	return nil
 }
@@ -146,8 +217,14 @@ func (daemon *Daemon) waitForStart(container *container.Container) error {
 // Cleanup releases any network resources allocated to the container along with any rules
 // around how containers are linked together.  It also unmounts the container's root filesystem.
 func (daemon *Daemon) Cleanup(container *container.Container) {
+	daemon.stopHealthchecks(container)
+
+	daemon.stopCapture(container.ID, nil, "container stopped")
+
 	daemon.releaseNetwork(container)
 
+	daemon.releaseIsolatedCpus(container)
+
 	container.UnmountIpcMounts(detachMounted)
 
 	daemon.conditionalUnmountOnCleanup(container)
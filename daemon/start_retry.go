@@ -0,0 +1,70 @@
+package daemon
+
+import (
+	"net"
+	"strings"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/docker/docker/container"
+	"golang.org/x/net/context"
+)
+
+// startRetryMaxDelay caps the delay between the retries of a container
+// start.
+const startRetryMaxDelay = 10 * time.Second
+
+// transientStartErrors are the messages of the errors which may not happen
+// again when a container start is retried: busy mounts, addresses still in
+// use and timeouts of plugins.
+var transientStartErrors = []string{
+	"device or resource busy",
+	"address already in use",
+	"resource temporarily unavailable",
+	"timed out",
+	"timeout exceeded",
+	"i/o timeout",
+}
+
+// containerStartWithRetries starts the container, retrying the start up to
+// the --start-retries times if it fails with a transient error. The delay
+// between the retries starts at --start-retry-delay and doubles after each.
+func (daemon *Daemon) containerStartWithRetries(ctx context.Context, c *container.Container, checkpoint string) error {
+	wait := daemon.configStore.StartRetryDelay
+	for retry := 0; ; retry++ {
+		err := daemon.containerStart(ctx, c, checkpoint)
+		if err == nil || retry >= daemon.configStore.StartRetries || !isTransientStartError(err) {
+			return err
+		}
+		logrus.Warnf("Failed to start container %s with a transient error, retrying in %v (%d/%d): %v", c.ID, wait, retry+1, daemon.configStore.StartRetries, err)
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(wait):
+		}
+		if daemon.IsShuttingDown() {
+			return err
+		}
+		if wait *= 2; wait > startRetryMaxDelay {
+			wait = startRetryMaxDelay
+		}
+	}
+}
+
+// isTransientStartError reports whether a container start failed with an
+// error which may not happen again if the start is retried.
+func isTransientStartError(err error) bool {
+	if err == context.Canceled || err == context.DeadlineExceeded {
+		return false
+	}
+	if netErr, ok := err.(net.Error); ok && (netErr.Timeout() || netErr.Temporary()) {
+		return true
+	}
+	msg := strings.ToLower(err.Error())
+	for _, transient := range transientStartErrors {
+		if strings.Contains(msg, transient) {
+			return true
+		}
+	}
+	return false
+}
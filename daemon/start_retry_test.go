@@ -0,0 +1,35 @@
+package daemon
+
+import (
+	"errors"
+	"net"
+	"testing"
+
+	"golang.org/x/net/context"
+)
+
+func TestIsTransientStartError(t *testing.T) {
+	transient := []error{
+		errors.New("error creating overlay mount: device or resource busy"),
+		errors.New("Error starting userland proxy: listen tcp 0.0.0.0:80: bind: address already in use"),
+		errors.New("Plugin Error: NetworkDriver.CreateEndpoint, Post http://%2Frun%2Fdocker%2Fplugins%2Fweave.sock/NetworkDriver.CreateEndpoint: net/http: request canceled (Client.Timeout exceeded while awaiting headers)"),
+		&net.OpError{Op: "dial", Net: "unix", Err: errors.New("i/o timeout")},
+	}
+	for _, err := range transient {
+		if !isTransientStartError(err) {
+			t.Fatalf("expected %q to be transient", err)
+		}
+	}
+
+	permanent := []error{
+		errors.New("no such file or directory"),
+		errors.New("Container e90e34656806 did not start within 10s"),
+		context.Canceled,
+		context.DeadlineExceeded,
+	}
+	for _, err := range permanent {
+		if isTransientStartError(err) {
+			t.Fatalf("expected %q not to be transient", err)
+		}
+	}
+}
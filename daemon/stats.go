@@ -47,6 +47,18 @@ func (daemon *Daemon) ContainerStats(prefixOrName string, config *ContainerStats
 		ss.MemoryStats.Limit = uint64(update.MemoryLimit)
 		ss.Read = update.Read
 		ss.CPUStats.SystemUsage = update.SystemUsage
+		ss.Pids = update.Pids
+		if len(update.Subcgroups) > 0 {
+			ss.Subcgroups = make(map[string]types.SubcgroupStats, len(update.Subcgroups))
+			for path, stats := range update.Subcgroups {
+				sub := convertStatsToAPITypes(stats)
+				ss.Subcgroups[path] = types.SubcgroupStats{
+					CPUStats:    sub.CPUStats,
+					MemoryStats: sub.MemoryStats,
+					BlkioStats:  sub.BlkioStats,
+				}
+			}
+		}
 		preCPUStats = ss.CPUStats
 		return ss
 	}
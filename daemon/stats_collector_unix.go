@@ -26,16 +26,21 @@ type statsSupervisor interface {
 // newStatsCollector returns a new statsCollector that collections
 // network and cgroup stats for a registered container at the specified
 // interval.  The collector allows non-running containers to be added
-// and will start processing stats when they are started.
-func (daemon *Daemon) newStatsCollector(interval time.Duration) *statsCollector {
+// and will start processing stats when they are started. With onDemand,
+// the collection loop only runs while a container is registered.
+func (daemon *Daemon) newStatsCollector(interval time.Duration, onDemand bool) *statsCollector {
 	s := &statsCollector{
 		interval:            interval,
+		onDemand:            onDemand,
 		supervisor:          daemon,
 		publishers:          make(map[*container.Container]*pubsub.Publisher),
 		clockTicksPerSecond: uint64(system.GetClockTicks()),
 		bufReader:           bufio.NewReaderSize(nil, 128),
 	}
-	go s.run()
+	if !onDemand {
+		s.running = true
+		go s.run()
+	}
 	return s
 }
 
@@ -44,6 +49,8 @@ type statsCollector struct {
 	m                   sync.Mutex
 	supervisor          statsSupervisor
 	interval            time.Duration
+	onDemand            bool
+	running             bool
 	clockTicksPerSecond uint64
 	publishers          map[*container.Container]*pubsub.Publisher
 	bufReader           *bufio.Reader
@@ -60,6 +67,10 @@ func (s *statsCollector) collect(c *container.Container) chan interface{} {
 		publisher = pubsub.NewPublisher(100*time.Millisecond, 1024)
 		s.publishers[c] = publisher
 	}
+	if !s.running {
+		s.running = true
+		go s.run()
+	}
 	return publisher.Subscribe()
 }
 
@@ -96,12 +107,21 @@ func (s *statsCollector) run() {
 	// it will grow enough in first iteration
 	var pairs []publishersPair
 
-	for range time.Tick(s.interval) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
 		// it does not make sense in the first iteration,
 		// but saves allocations in further iterations
 		pairs = pairs[:0]
 
 		s.m.Lock()
+		if s.onDemand && len(s.publishers) == 0 {
+			// stop until a container is registered again
+			s.running = false
+			s.m.Unlock()
+			return
+		}
 		for container, publisher := range s.publishers {
 			// copy pointers here to release the lock ASAP
 			pairs = append(pairs, publishersPair{container, publisher})
@@ -0,0 +1,57 @@
+// +build !windows
+
+package daemon
+
+import (
+	"testing"
+	"time"
+
+	"github.com/docker/docker/container"
+	"github.com/docker/docker/daemon/execdriver"
+)
+
+type fakeStatsSupervisor struct{}
+
+func (fakeStatsSupervisor) GetContainerStats(c *container.Container) (*execdriver.ResourceStats, error) {
+	return &execdriver.ResourceStats{}, nil
+}
+
+func TestStatsCollectorOnDemand(t *testing.T) {
+	daemon := &Daemon{}
+	s := daemon.newStatsCollector(10*time.Millisecond, true)
+	s.supervisor = fakeStatsSupervisor{}
+
+	isRunning := func() bool {
+		s.m.Lock()
+		defer s.m.Unlock()
+		return s.running
+	}
+	if isRunning() {
+		t.Fatal("Expected the collection not to run without subscribers")
+	}
+
+	c := &container.Container{}
+	ch := s.collect(c)
+	select {
+	case <-ch:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timeout waiting for stats")
+	}
+
+	s.unsubscribe(c, ch)
+	deadline := time.Now().Add(5 * time.Second)
+	for isRunning() {
+		if time.Now().After(deadline) {
+			t.Fatal("Expected the collection to stop without subscribers")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	ch = s.collect(c)
+	defer s.unsubscribe(c, ch)
+	select {
+	case <-ch:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timeout waiting for stats after the collection restarted")
+	}
+}
@@ -10,7 +10,7 @@ import (
 // for a registered container at the specified interval. The collector allows
 // non-running containers to be added and will start processing stats when
 // they are started.
-func (daemon *Daemon) newStatsCollector(interval time.Duration) *statsCollector {
+func (daemon *Daemon) newStatsCollector(interval time.Duration, onDemand bool) *statsCollector {
 	return &statsCollector{}
 }
 
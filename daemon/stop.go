@@ -6,23 +6,41 @@ import (
 	"github.com/Sirupsen/logrus"
 	"github.com/docker/docker/container"
 	derr "github.com/docker/docker/errors"
+	"golang.org/x/net/context"
 )
 
 // ContainerStop looks for the given container and terminates it,
 // waiting the given number of seconds before forcefully killing the
-// container. If a negative number of seconds is given, ContainerStop
+// container. If seconds is nil, the stop timeout of the container is used.
+// If a negative number of seconds is given, ContainerStop
 // will wait for a graceful termination. An error is returned if the
 // container is not found, is already stopped, or if there is a
-// problem stopping the container.
-func (daemon *Daemon) ContainerStop(name string, seconds int) error {
-	container, err := daemon.GetContainer(name)
+// problem stopping the container. Waiting for the container to exit
+// is aborted when ctx is cancelled, the container still stopping within
+// its timeout. A
+// label selector stops all the containers it matches, the ones already
+// stopped being skipped.
+func (daemon *Daemon) ContainerStop(ctx context.Context, name string, seconds *int) error {
+	if isLabelSelector(name) {
+		return daemon.forEachSelected(name, func(id string) error {
+			return daemon.ContainerStop(ctx, id, seconds)
+		}, derr.ErrorCodeStopped)
+	}
+
+	container, err := daemon.getContainerWithContext(ctx, name)
 	if err != nil {
 		return err
 	}
+	daemon.setDesiredState(container, desiredStopped)
+	container.CancelRestartTimer()
 	if !container.IsRunning() {
 		return derr.ErrorCodeStopped
 	}
-	if err := daemon.containerStop(container, seconds); err != nil {
+	timeout := daemon.stopTimeout(container)
+	if seconds != nil {
+		timeout = *seconds
+	}
+	if err := daemon.containerStop(ctx, container, timeout); err != nil {
 		return derr.ErrorCodeCantStop.WithArgs(name, err)
 	}
 	return nil
@@ -32,8 +50,9 @@ func (daemon *Daemon) ContainerStop(name string, seconds int) error {
 // duration in seconds, and then calling SIGKILL and waiting for the
 // process to exit. If a negative duration is given, Stop will wait
 // for the initial signal forever. If the container is not running Stop returns
-// immediately.
-func (daemon *Daemon) containerStop(container *container.Container, seconds int) error {
+// immediately. If ctx is cancelled while waiting, the context error is
+// returned and the stop finishes on its own, within its timeout.
+func (daemon *Daemon) containerStop(ctx context.Context, container *container.Container, seconds int) error {
 	if !container.IsRunning() {
 		return nil
 	}
@@ -46,12 +65,38 @@ func (daemon *Daemon) containerStop(container *container.Container, seconds int)
 		}
 	}
 
-	// 2. Wait for the process to exit on its own
-	if _, err := container.WaitStop(time.Duration(seconds) * time.Second); err != nil {
+	// 2. Wait for the process to exit on its own. A cancelled ctx only
+	// abandons the wait, the stop goes on within its timeout.
+	done := make(chan error, 1)
+	go func() {
+		done <- daemon.waitStop(container, seconds)
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		logrus.Infof("Stop of container %v cancelled - no longer waiting for it to exit", container.ID)
+		return ctx.Err()
+	}
+}
+
+// waitStop waits for the given duration in seconds for the container to
+// exit after its stop signal, and then calls SIGKILL and waits for the
+// process to exit. If a negative duration is given, it waits forever.
+func (daemon *Daemon) waitStop(container *container.Container, seconds int) error {
+	waitCtx := context.Background()
+	if seconds >= 0 {
+		var cancel context.CancelFunc
+		waitCtx, cancel = context.WithTimeout(waitCtx, time.Duration(seconds)*time.Second)
+		defer cancel()
+	}
+	if _, err := container.WaitStopWithContext(waitCtx); err != nil {
 		logrus.Infof("Container %v failed to exit within %d seconds of SIGTERM - using the force", container.ID, seconds)
 		// 3. If it doesn't, then send SIGKILL
 		if err := daemon.Kill(container); err != nil {
-			container.WaitStop(-1 * time.Second)
+			if _, err := container.WaitStopWithContext(context.Background()); err != nil {
+				return err
+			}
 			logrus.Warn(err) // Don't return error because we only care that container is stopped, not what function stopped it
 		}
 	}
@@ -59,3 +104,33 @@ func (daemon *Daemon) containerStop(container *container.Container, seconds int)
 	daemon.LogContainerEvent(container, "stop")
 	return nil
 }
+
+// stopTimeout returns the number of seconds to wait for the container to
+// stop before killing it: the stop timeout of the container if it has one,
+// or the default stop timeout of the daemon.
+func (daemon *Daemon) stopTimeout(container *container.Container) int {
+	if container.Config != nil && container.Config.StopTimeout != nil {
+		return *container.Config.StopTimeout
+	}
+	return daemon.configStore.DefaultStopTimeout
+}
+
+// shutdownGracePeriod is the number of seconds the shutdown of the daemon
+// waits for the containers after their stop timeout, for them to be killed.
+const shutdownGracePeriod = 5
+
+// ShutdownTimeout returns the number of seconds to wait for the daemon to
+// shut down: the largest stop timeout of the running containers, or the
+// default stop timeout, plus a grace period to kill them.
+func (daemon *Daemon) ShutdownTimeout() int {
+	timeout := daemon.configStore.DefaultStopTimeout
+	for _, c := range daemon.List() {
+		if !c.IsRunning() {
+			continue
+		}
+		if t := daemon.stopTimeout(c); t > timeout {
+			timeout = t
+		}
+	}
+	return timeout + shutdownGracePeriod
+}
@@ -0,0 +1,36 @@
+package daemon
+
+import (
+	"testing"
+
+	containertypes "github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/container"
+)
+
+func TestShutdownTimeout(t *testing.T) {
+	newContainer := func(id string, running bool, stopTimeout *int) *container.Container {
+		c := &container.Container{
+			CommonContainer: container.CommonContainer{
+				ID:     id,
+				State:  container.NewState(),
+				Config: &containertypes.Config{StopTimeout: stopTimeout},
+			},
+		}
+		c.Running = running
+		return c
+	}
+	daemon := &Daemon{
+		containers:  &contStore{s: map[string]*container.Container{}},
+		configStore: &Config{DefaultStopTimeout: 10},
+	}
+	if timeout := daemon.ShutdownTimeout(); timeout != 15 {
+		t.Fatalf("Expected the default stop timeout with a grace period, got %d", timeout)
+	}
+
+	long, longer := 60, 120
+	daemon.containers.Add("1", newContainer("1", true, &long))
+	daemon.containers.Add("2", newContainer("2", false, &longer))
+	if timeout := daemon.ShutdownTimeout(); timeout != 65 {
+		t.Fatalf("Expected the largest stop timeout of the running containers with a grace period, got %d", timeout)
+	}
+}
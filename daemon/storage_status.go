@@ -0,0 +1,195 @@
+package daemon
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/docker/docker/api/types"
+	derr "github.com/docker/docker/errors"
+)
+
+const (
+	// storageRecheckInterval is the interval at which the daemon checks
+	// whether space was freed on its storage while it is degraded.
+	storageRecheckInterval = 30 * time.Second
+	// storageProbeSize is the size of the file written to check that space
+	// was freed on the storage.
+	storageProbeSize = 1024 * 1024
+	// storageProbeInterval is the minimum interval between two checks of
+	// the storage by the operations rejected while it is degraded.
+	storageProbeInterval = 5 * time.Second
+)
+
+// storageStatus tracks whether the storage of the daemon is degraded, because
+// no space is left on it or it is read-only. While it is, the containers
+// cannot be created, but the existing ones keep running.
+type storageStatus struct {
+	sync.Mutex
+	degraded bool
+	err      string
+	since    time.Time
+	// probed is when the storage was last checked while degraded.
+	probed time.Time
+}
+
+// degrade records that the storage was degraded by err, and tells whether
+// it was not yet.
+func (s *storageStatus) degrade(err error) bool {
+	s.Lock()
+	defer s.Unlock()
+	if s.degraded {
+		return false
+	}
+	s.degraded = true
+	s.err = err.Error()
+	s.since = time.Now().UTC()
+	s.probed = time.Time{}
+	return true
+}
+
+// shouldProbe tells whether the storage can be checked again, at most once
+// per storageProbeInterval, and records that it is checked if so.
+func (s *storageStatus) shouldProbe() bool {
+	s.Lock()
+	defer s.Unlock()
+	now := time.Now()
+	if now.Sub(s.probed) < storageProbeInterval {
+		return false
+	}
+	s.probed = now
+	return true
+}
+
+// clear records that the storage recovered, and tells whether it was
+// degraded.
+func (s *storageStatus) clear() bool {
+	s.Lock()
+	defer s.Unlock()
+	if !s.degraded {
+		return false
+	}
+	s.degraded = false
+	s.err = ""
+	return true
+}
+
+// info returns the state of the storage if it is degraded, nil otherwise.
+func (s *storageStatus) info() *types.StorageStatus {
+	s.Lock()
+	defer s.Unlock()
+	if !s.degraded {
+		return nil
+	}
+	return &types.StorageStatus{
+		Degraded: true,
+		Error:    s.err,
+		Since:    s.since,
+	}
+}
+
+// isStorageFullError tells whether err is caused by no space left on the
+// storage or by the storage being read-only. The errors of the graph drivers
+// are not always wrapped in a typed error, so their message is matched last.
+func isStorageFullError(err error) bool {
+	for err != nil {
+		switch e := err.(type) {
+		case *os.PathError:
+			err = e.Err
+		case *os.LinkError:
+			err = e.Err
+		case *os.SyscallError:
+			err = e.Err
+		case syscall.Errno:
+			return e == syscall.ENOSPC || e == syscall.EROFS
+		default:
+			msg := err.Error()
+			return strings.Contains(msg, syscall.ENOSPC.Error()) || strings.Contains(msg, syscall.EROFS.Error())
+		}
+	}
+	return false
+}
+
+// CheckStorage returns an error if the storage is degraded, after checking
+// whether space was freed on it if it was not checked recently.
+func (daemon *Daemon) CheckStorage() error {
+	status := daemon.storageStatus.info()
+	if status == nil || (daemon.storageStatus.shouldProbe() && daemon.recoverStorage()) {
+		return nil
+	}
+	return derr.ErrorCodeStorageFull.WithArgs(status.Error)
+}
+
+// storageError degrades the storage if err is caused by no space left on it
+// or by it being read-only, returning the storage full error then, and err
+// otherwise.
+func (daemon *Daemon) storageError(err error) error {
+	if !isStorageFullError(err) {
+		return err
+	}
+	if daemon.storageStatus.degrade(err) {
+		logrus.Errorf("The storage of the daemon is degraded, the containers cannot be created nor the images pulled or built until space is freed: %v", err)
+		daemon.LogDaemonEvent("storage_degraded", map[string]string{"error": err.Error()})
+		go daemon.watchStorage()
+	}
+	return derr.ErrorCodeStorageFull.WithArgs(err)
+}
+
+// watchStorage checks at every interval whether space was freed on the
+// storage, until it recovers.
+func (daemon *Daemon) watchStorage() {
+	for {
+		time.Sleep(storageRecheckInterval)
+		if daemon.storageStatus.info() == nil || daemon.recoverStorage() {
+			return
+		}
+	}
+}
+
+// recoverStorage writes a probe file in the storage of the graph driver, and
+// records that the storage recovered if it could be written.
+func (daemon *Daemon) recoverStorage() bool {
+	if err := probeStorage(daemon.storageProbeDir()); err != nil {
+		logrus.Debugf("The storage of the daemon is still degraded: %v", err)
+		return false
+	}
+	if daemon.storageStatus.clear() {
+		logrus.Infof("The storage of the daemon recovered")
+		daemon.LogDaemonEvent("storage_recovered", nil)
+	}
+	return true
+}
+
+// storageProbeDir returns the home directory of the graph driver, where the
+// layers are written, or the root of the daemon if the driver has none, like
+// the graph driver plugins.
+func (daemon *Daemon) storageProbeDir() string {
+	dir := filepath.Join(daemon.root, daemon.GraphDriverName())
+	if fi, err := os.Stat(dir); err != nil || !fi.IsDir() {
+		return daemon.root
+	}
+	return dir
+}
+
+// probeStorage writes and removes a file of storageProbeSize in dir.
+func probeStorage(dir string) error {
+	f, err := ioutil.TempFile(dir, "storage-probe-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.Write(make([]byte, storageProbeSize)); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
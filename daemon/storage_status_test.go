@@ -0,0 +1,96 @@
+package daemon
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/docker/distribution/registry/api/errcode"
+	"github.com/docker/docker/daemon/events"
+	derr "github.com/docker/docker/errors"
+)
+
+func TestIsStorageFullError(t *testing.T) {
+	for _, err := range []error{
+		syscall.ENOSPC,
+		&os.PathError{Op: "write", Path: "/var/lib/docker/layer", Err: syscall.ENOSPC},
+		&os.LinkError{Op: "rename", Old: "a", New: "b", Err: syscall.EROFS},
+		fmt.Errorf("error creating overlay mount: %v", syscall.ENOSPC),
+	} {
+		if !isStorageFullError(err) {
+			t.Fatalf("Expected %v to be a storage full error", err)
+		}
+	}
+	for _, err := range []error{nil, syscall.EACCES, errors.New("no such image")} {
+		if isStorageFullError(err) {
+			t.Fatalf("Expected %v not to be a storage full error", err)
+		}
+	}
+}
+
+func TestStorageStatus(t *testing.T) {
+	root, err := ioutil.TempDir("", "storage-status")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+	home := filepath.Join(root, "vfs")
+	if err := os.Mkdir(home, 0700); err != nil {
+		t.Fatal(err)
+	}
+	daemon := &Daemon{
+		root:          root,
+		layerStore:    &fakeRWLayerStore{Store: newFakeMigrateStore("vfs")},
+		EventsService: events.New(),
+	}
+
+	if err := daemon.CheckStorage(); err != nil {
+		t.Fatal(err)
+	}
+	if err := daemon.storageError(syscall.EACCES); err != syscall.EACCES {
+		t.Fatalf("Expected the error to be returned as is, got %v", err)
+	}
+	if daemon.storageStatus.info() != nil {
+		t.Fatal("Expected the storage not to be degraded")
+	}
+
+	err = daemon.storageError(&os.PathError{Op: "write", Path: "layer.tar", Err: syscall.ENOSPC})
+	if e, ok := err.(errcode.Error); !ok || e.ErrorCode() != derr.ErrorCodeStorageFull {
+		t.Fatalf("Expected a storage full error, got %v", err)
+	}
+	status := daemon.storageStatus.info()
+	if status == nil || !status.Degraded || status.Error != "write layer.tar: no space left on device" {
+		t.Fatalf("Expected the storage to be degraded, got %+v", status)
+	}
+
+	// The probe fails in a missing storage, and is not repeated right away
+	if err := os.RemoveAll(root); err != nil {
+		t.Fatal(err)
+	}
+	if err := daemon.CheckStorage(); err == nil {
+		t.Fatal("Expected the storage to stay degraded")
+	}
+	if err := os.MkdirAll(home, 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := daemon.CheckStorage(); err == nil {
+		t.Fatal("Expected the storage not to be probed again right away")
+	}
+
+	// The storage of the graph driver is writable, so the storage recovers
+	daemon.storageStatus.probed = time.Time{}
+	if err := daemon.CheckStorage(); err != nil {
+		t.Fatal(err)
+	}
+	if daemon.storageStatus.info() != nil {
+		t.Fatal("Expected the storage to recover")
+	}
+	if files, err := ioutil.ReadDir(home); err != nil || len(files) != 0 {
+		t.Fatalf("Expected the probe file to be removed, got %v: %v", files, err)
+	}
+}
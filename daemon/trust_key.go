@@ -0,0 +1,74 @@
+package daemon
+
+import (
+	"encoding/pem"
+	"os"
+	"path/filepath"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/libtrust"
+)
+
+// getTrustKey returns the current trust key of the daemon.
+func (daemon *Daemon) getTrustKey() libtrust.PrivateKey {
+	daemon.trustKeyLock.Lock()
+	defer daemon.trustKeyLock.Unlock()
+	return daemon.trustKey
+}
+
+// TrustKey returns the ID and the public key of the trust key of the daemon.
+func (daemon *Daemon) TrustKey() (*types.TrustKey, error) {
+	return trustKeyInfo(daemon.getTrustKey())
+}
+
+// RotateTrustKey replaces the trust key of the daemon with a new one. The
+// key file is replaced atomically, so that a failure leaves the old key in
+// place. The ID of the daemon is derived from the key it was started with
+// and only changes on restart.
+func (daemon *Daemon) RotateTrustKey() (*types.TrustKey, error) {
+	daemon.trustKeyLock.Lock()
+	defer daemon.trustKeyLock.Unlock()
+
+	oldKey := daemon.trustKey
+	newKey, err := libtrust.GenerateECP256PrivateKey()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := saveTrustKey(daemon.configStore.TrustKeyPath, newKey); err != nil {
+		return nil, err
+	}
+	daemon.trustKey = newKey
+
+	daemon.LogDaemonEvent("trust_key_rotate", map[string]string{
+		"oldKeyID": oldKey.KeyID(),
+		"newKeyID": newKey.KeyID(),
+	})
+	return trustKeyInfo(newKey)
+}
+
+// saveTrustKey writes key to a temporary file next to path, with the same
+// extension so that it is saved in the same format, and renames it to path.
+func saveTrustKey(path string, key libtrust.PrivateKey) error {
+	tmp := filepath.Join(filepath.Dir(path), ".tmp-"+filepath.Base(path))
+	if err := libtrust.SaveKey(tmp, key); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return nil
+}
+
+func trustKeyInfo(key libtrust.PrivateKey) (*types.TrustKey, error) {
+	block, err := key.PublicKey().PEMBlock()
+	if err != nil {
+		return nil, err
+	}
+	return &types.TrustKey{
+		ID:        key.KeyID(),
+		PublicKey: string(pem.EncodeToMemory(block)),
+	}, nil
+}
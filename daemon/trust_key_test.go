@@ -0,0 +1,65 @@
+package daemon
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api"
+	eventtypes "github.com/docker/docker/api/types/events"
+	"github.com/docker/docker/daemon/events"
+)
+
+func TestRotateTrustKey(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "docker-trust-key")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+
+	keyPath := filepath.Join(tmp, "key.json")
+	trustKey, err := api.LoadOrCreateTrustKey(keyPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e := events.New()
+	_, l, _ := e.Subscribe()
+	defer e.Evict(l)
+	daemon := &Daemon{
+		trustKey:      trustKey,
+		configStore:   &Config{CommonConfig: CommonConfig{TrustKeyPath: keyPath}},
+		EventsService: e,
+	}
+
+	key, err := daemon.TrustKey()
+	if err != nil || key.ID != trustKey.KeyID() {
+		t.Fatalf("Expected the trust key %s, got %+v (%v)", trustKey.KeyID(), key, err)
+	}
+	rotated, err := daemon.RotateTrustKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rotated.ID == key.ID || rotated.PublicKey == key.PublicKey {
+		t.Fatalf("Expected a new trust key, got %+v", rotated)
+	}
+
+	saved, err := api.LoadOrCreateTrustKey(keyPath)
+	if err != nil || saved.KeyID() != rotated.ID {
+		t.Fatalf("Expected the key file to hold the key %s, got %v", rotated.ID, err)
+	}
+	if files, _ := ioutil.ReadDir(tmp); len(files) != 1 {
+		t.Fatalf("Expected only the key file, got %d files", len(files))
+	}
+
+	select {
+	case msg := <-l:
+		m := msg.(eventtypes.Message)
+		if m.Action != "trust_key_rotate" || m.Actor.Attributes["oldKeyID"] != key.ID || m.Actor.Attributes["newKeyID"] != rotated.ID {
+			t.Fatalf("Unexpected event %+v", m)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected a trust_key_rotate event")
+	}
+}
@@ -38,7 +38,7 @@ func (daemon *Daemon) containerUnpause(container *container.Container) error {
 		return err
 	}
 
-	container.Paused = false
+	container.SetUnpaused()
 	daemon.LogContainerEvent(container, "unpause")
 	return nil
 }
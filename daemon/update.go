@@ -4,17 +4,27 @@ import (
 	"fmt"
 
 	"github.com/docker/docker/api/types/container"
+	runconfigopts "github.com/docker/docker/runconfig/opts"
 )
 
-// ContainerUpdate updates resources of the container
-func (daemon *Daemon) ContainerUpdate(name string, hostConfig *container.HostConfig) ([]string, error) {
+// ContainerUpdate updates resources and the restart policy of the container
+func (daemon *Daemon) ContainerUpdate(name string, updateConfig *container.UpdateConfig) ([]string, error) {
 	var warnings []string
 
+	hostConfig := &container.HostConfig{
+		Resources:     updateConfig.Resources,
+		RestartPolicy: updateConfig.RestartPolicy,
+	}
+
 	warnings, err := daemon.verifyContainerSettings(hostConfig, nil)
 	if err != nil {
 		return warnings, err
 	}
 
+	if err := runconfigopts.ValidateRestartPolicy(hostConfig.RestartPolicy); err != nil {
+		return warnings, err
+	}
+
 	if err := daemon.update(name, hostConfig); err != nil {
 		return warnings, err
 	}
@@ -36,6 +46,10 @@ func (daemon *Daemon) update(name string, hostConfig *container.HostConfig) erro
 		return fmt.Errorf("Container is marked for removal and cannot be \"update\".")
 	}
 
+	if container.HostConfig.CPUIsolationGroup != "" && hostConfig.CpusetCpus != "" {
+		return fmt.Errorf("Can not update the cpuset of a container in a CPU isolation group.")
+	}
+
 	if container.IsRunning() && hostConfig.KernelMemory != 0 {
 		return fmt.Errorf("Can not update kernel memory to a running container, please stop it first.")
 	}
@@ -49,12 +63,30 @@ func (daemon *Daemon) update(name string, hostConfig *container.HostConfig) erro
 	// If container is running (including paused), we need to update configs
 	// to the real world.
 	if container.IsRunning() {
-		if err := daemon.execDriver.Update(container.Command); err != nil {
-			return err
+		if hasResourceUpdate(hostConfig.Resources) {
+			if err := daemon.execDriver.Update(container.Command); err != nil {
+				return err
+			}
 		}
+		container.UpdateMonitor(hostConfig.RestartPolicy)
 	}
 
 	daemon.LogContainerEvent(container, "update")
 
 	return nil
 }
+
+// hasResourceUpdate returns whether any of the resources that can be changed
+// through the exec driver are set in resources.
+func hasResourceUpdate(resources container.Resources) bool {
+	return resources.BlkioWeight != 0 ||
+		resources.CPUShares != 0 ||
+		resources.CPUPeriod != 0 ||
+		resources.CPUQuota != 0 ||
+		resources.CpusetCpus != "" ||
+		resources.CpusetMems != "" ||
+		resources.Memory != 0 ||
+		resources.MemorySwap != 0 ||
+		resources.MemoryReservation != 0 ||
+		resources.KernelMemory != 0
+}
@@ -6,11 +6,13 @@ import (
 	"path/filepath"
 	"strings"
 
+	"github.com/Sirupsen/logrus"
 	"github.com/docker/docker/api/types"
 	containertypes "github.com/docker/docker/api/types/container"
 	"github.com/docker/docker/container"
 	"github.com/docker/docker/daemon/execdriver"
 	derr "github.com/docker/docker/errors"
+	"github.com/docker/docker/pkg/directory"
 	"github.com/docker/docker/volume"
 	"github.com/opencontainers/runc/libcontainer/label"
 )
@@ -24,17 +26,33 @@ var (
 type mounts []execdriver.Mount
 
 // volumeToAPIType converts a volume.Volume to the type used by the remote API
-func volumeToAPIType(v volume.Volume) *types.Volume {
+func (daemon *Daemon) volumeToAPIType(v volume.Volume) *types.Volume {
 	return &types.Volume{
 		Name:       v.Name(),
 		Driver:     v.DriverName(),
 		Mountpoint: v.Path(),
+		Labels:     daemon.volumes.Labels(v),
 	}
 }
 
+// localVolumeSize returns the size of the volume v, or -1 if it cannot be
+// computed. Only the volumes of the local driver are measured, other drivers
+// may need to mount the volume to report its path.
+func localVolumeSize(v volume.Volume) int64 {
+	if v.DriverName() != volume.DefaultDriverName {
+		return -1
+	}
+	size, err := directory.Size(v.Path())
+	if err != nil {
+		logrus.Warnf("failed to compute size of volume %s: %v", v.Name(), err)
+		return -1
+	}
+	return size
+}
+
 // createVolume creates a volume.
 func (daemon *Daemon) createVolume(name, driverName string, opts map[string]string) (volume.Volume, error) {
-	v, err := daemon.volumes.Create(name, driverName, opts)
+	v, err := daemon.volumes.Create(name, driverName, opts, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -70,7 +88,8 @@ func (m mounts) parts(i int) int {
 // 1. Select the previously configured mount points for the containers, if any.
 // 2. Select the volumes mounted from another containers. Overrides previously configured mount point destination.
 // 3. Select the bind mounts set by the client. Overrides previously configured mount point destinations.
-// 4. Cleanup old volumes that are about to be reassigned.
+// 4. Select the baseline mounts of the daemon, unless the container opts out. Never overrides configured mount point destinations.
+// 5. Cleanup old volumes that are about to be reassigned.
 func (daemon *Daemon) registerMountPoints(container *container.Container, hostConfig *containertypes.HostConfig) error {
 	binds := map[string]bool{}
 	mountPoints := map[string]*volume.MountPoint{}
@@ -137,19 +156,35 @@ func (daemon *Daemon) registerMountPoints(container *container.Container, hostCo
 			// bind.Name is an already existing volume, we need to use that here
 			bind.Driver = v.DriverName()
 			bind = setBindModeIfNull(bind)
+		} else if err := daemon.bindPolicy.check(bind.Source); err != nil {
+			return err
 		}
 		if label.RelabelNeeded(bind.Mode) {
 			if err := label.Relabel(bind.Source, container.MountLabel, label.IsShared(bind.Mode)); err != nil {
 				return err
 			}
 		}
+		if err := daemon.chownMount(container, hostConfig, bind); err != nil {
+			return err
+		}
 		binds[bind.Destination] = true
 		mountPoints[bind.Destination] = bind
 	}
 
+	// 4. Read baseline mounts
+	if !hostConfig.NoBaselineMounts {
+		for _, m := range daemon.baselineMounts {
+			if _, exists := mountPoints[m.Destination]; exists {
+				continue
+			}
+			bind := *m
+			mountPoints[bind.Destination] = &bind
+		}
+	}
+
 	container.Lock()
 
-	// 4. Cleanup old volumes that are about to be reassigned.
+	// 5. Cleanup old volumes that are about to be reassigned.
 	for _, m := range mountPoints {
 		if m.BackwardsCompatible() {
 			if mp, exists := container.MountPoints[m.Destination]; exists && mp.Volume != nil {
@@ -3,12 +3,16 @@
 package daemon
 
 import (
+	"fmt"
 	"os"
+	"path/filepath"
 	"sort"
 	"strconv"
+	"strings"
 
 	"github.com/docker/docker/container"
 	"github.com/docker/docker/daemon/execdriver"
+	derr "github.com/docker/docker/errors"
 	"github.com/docker/docker/volume"
 	volumedrivers "github.com/docker/docker/volume/drivers"
 	"github.com/docker/docker/volume/local"
@@ -20,6 +24,13 @@ import (
 func (daemon *Daemon) setupMounts(container *container.Container) ([]execdriver.Mount, error) {
 	var mounts []execdriver.Mount
 	for _, m := range container.MountPoints {
+		// The bind mounts are checked again, as the policy or the symbolic
+		// links of their host path may have changed since the creation.
+		if m.Volume == nil && !daemon.isBaselineMount(m) {
+			if err := daemon.bindPolicy.check(m.Source); err != nil {
+				return nil, err
+			}
+		}
 		path, err := m.Setup()
 		if err != nil {
 			return nil, err
@@ -50,7 +61,7 @@ func (daemon *Daemon) setupMounts(container *container.Container) ([]execdriver.
 	// if we are going to mount any of the network files from container
 	// metadata, the ownership must be set properly for potential container
 	// remapped root (user namespaces)
-	rootUID, rootGID := daemon.GetRemappedUIDGID()
+	rootUID, rootGID := daemon.containerRemappedUIDGID(container.HostConfig)
 	for _, mount := range netMounts {
 		if err := os.Chown(mount.Source, rootUID, rootGID); err != nil {
 			return nil, err
@@ -123,3 +134,47 @@ func setBindModeIfNull(bind *volume.MountPoint) *volume.MountPoint {
 	}
 	return bind
 }
+
+// isBaselineMount tells whether m is one of the baseline mounts of the daemon.
+func (daemon *Daemon) isBaselineMount(m *volume.MountPoint) bool {
+	for _, b := range daemon.baselineMounts {
+		if m.Source == b.Source && m.Destination == b.Destination && m.RW == b.RW {
+			return true
+		}
+	}
+	return false
+}
+
+// parseBaselineMounts parses the mounts configured with --baseline-mount in
+// the host-path[:container-path] form. They are bind mounted read-only into
+// every container which does not opt out.
+func parseBaselineMounts(config *Config) ([]*volume.MountPoint, error) {
+	var mounts []*volume.MountPoint
+	destinations := map[string]bool{}
+	for _, spec := range config.BaselineMounts {
+		arr := strings.Split(spec, ":")
+		if len(arr) > 2 {
+			return nil, fmt.Errorf("Invalid baseline mount %q, expected host-path[:container-path]", spec)
+		}
+		source, destination := arr[0], arr[len(arr)-1]
+		if !filepath.IsAbs(source) || !filepath.IsAbs(destination) {
+			return nil, fmt.Errorf("Invalid baseline mount %q, paths must be absolute", spec)
+		}
+		if _, err := os.Stat(source); err != nil {
+			return nil, fmt.Errorf("Invalid baseline mount %q: %v", spec, err)
+		}
+		destination = filepath.Clean(destination)
+		if destinations[destination] {
+			return nil, derr.ErrorCodeMountDup.WithArgs(destination)
+		}
+		destinations[destination] = true
+
+		mounts = append(mounts, &volume.MountPoint{
+			Source:      filepath.Clean(source),
+			Destination: destination,
+			Mode:        "ro",
+			Propagation: volume.DefaultPropagationMode,
+		})
+	}
+	return mounts, nil
+}
@@ -0,0 +1,129 @@
+// +build !windows
+
+package daemon
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	containertypes "github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/container"
+	"github.com/docker/docker/volume"
+)
+
+func TestParseBaselineMounts(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "docker-baseline-mounts-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+
+	invalid := []string{
+		"certs",
+		tmp + ":certs",
+		tmp + ":/etc/ssl/certs:rw",
+		filepath.Join(tmp, "missing"),
+	}
+	for _, spec := range invalid {
+		if _, err := parseBaselineMounts(&Config{BaselineMounts: []string{spec}}); err == nil {
+			t.Fatalf("Expected an error for %s", spec)
+		}
+	}
+	if _, err := parseBaselineMounts(&Config{BaselineMounts: []string{tmp + ":/etc/ssl/certs", tmp + ":/etc/ssl/certs/"}}); err == nil {
+		t.Fatal("Expected an error for a duplicate destination")
+	}
+
+	mounts, err := parseBaselineMounts(&Config{BaselineMounts: []string{tmp, tmp + ":/etc/ssl/certs/"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(mounts) != 2 {
+		t.Fatalf("Expected 2 mounts, got %v", mounts)
+	}
+	if mounts[0].Source != tmp || mounts[0].Destination != tmp || mounts[0].RW {
+		t.Fatalf("Expected %s to be mounted read-only at the same path, got %+v", tmp, mounts[0])
+	}
+	if mounts[1].Source != tmp || mounts[1].Destination != "/etc/ssl/certs" || mounts[1].RW {
+		t.Fatalf("Expected %s to be mounted read-only at /etc/ssl/certs, got %+v", tmp, mounts[1])
+	}
+}
+
+func TestRegisterBaselineMounts(t *testing.T) {
+	daemon := &Daemon{
+		baselineMounts: []*volume.MountPoint{
+			{Source: "/etc/pki", Destination: "/etc/ssl/certs", Mode: "ro"},
+			{Source: "/usr/share/zoneinfo", Destination: "/usr/share/zoneinfo", Mode: "ro"},
+		},
+	}
+
+	c := container.NewBaseContainer("baseline", "")
+	hostConfig := &containertypes.HostConfig{Binds: []string{"/srv/certs:/etc/ssl/certs"}}
+	if err := daemon.registerMountPoints(c, hostConfig); err != nil {
+		t.Fatal(err)
+	}
+	if len(c.MountPoints) != 2 {
+		t.Fatalf("Expected 2 mount points, got %v", c.MountPoints)
+	}
+	if m := c.MountPoints["/etc/ssl/certs"]; m.Source != "/srv/certs" || !m.RW {
+		t.Fatalf("Expected the bind mount to override the baseline mount, got %+v", m)
+	}
+	if m := c.MountPoints["/usr/share/zoneinfo"]; m.Source != "/usr/share/zoneinfo" || m.RW {
+		t.Fatalf("Expected the baseline mount to be read-only, got %+v", m)
+	}
+	if m := c.MountPoints["/usr/share/zoneinfo"]; m == daemon.baselineMounts[1] {
+		t.Fatal("Expected the baseline mount to be copied")
+	}
+
+	c = container.NewBaseContainer("opt-out", "")
+	if err := daemon.registerMountPoints(c, &containertypes.HostConfig{NoBaselineMounts: true}); err != nil {
+		t.Fatal(err)
+	}
+	if len(c.MountPoints) != 0 {
+		t.Fatalf("Expected no mount points, got %v", c.MountPoints)
+	}
+}
+
+func TestSetupMountsBindPolicy(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "docker-setup-mounts")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+	tmp, err = filepath.EvalSymlinks(tmp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	allowed, denied := filepath.Join(tmp, "allowed"), filepath.Join(tmp, "denied")
+	for _, dir := range []string{allowed, denied} {
+		if err := os.Mkdir(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	daemon := &Daemon{
+		baselineMounts: []*volume.MountPoint{{Source: denied, Destination: "/baseline", Mode: "ro"}},
+		bindPolicy:     &bindPolicy{allow: []string{allowed}},
+	}
+
+	c := container.NewBaseContainer("bind-policy", "")
+	c.HostConfig = &containertypes.HostConfig{}
+	c.MountPoints = map[string]*volume.MountPoint{
+		"/data":     {Source: allowed, Destination: "/data", RW: true},
+		"/baseline": {Source: denied, Destination: "/baseline", Mode: "ro"},
+	}
+	if _, err := daemon.setupMounts(c); err != nil {
+		t.Fatal(err)
+	}
+
+	// The host path was replaced by a link to a forbidden path
+	if err := os.Remove(allowed); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(denied, allowed); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := daemon.setupMounts(c); err == nil {
+		t.Fatal("Expected the bind mount to be checked against the policy at start")
+	}
+}
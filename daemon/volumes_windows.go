@@ -5,6 +5,7 @@ package daemon
 import (
 	"sort"
 
+	containertypes "github.com/docker/docker/api/types/container"
 	"github.com/docker/docker/container"
 	"github.com/docker/docker/daemon/execdriver"
 	derr "github.com/docker/docker/errors"
@@ -42,3 +43,27 @@ func (daemon *Daemon) setupMounts(container *container.Container) ([]execdriver.
 func setBindModeIfNull(bind *volume.MountPoint) *volume.MountPoint {
 	return bind
 }
+
+// bindPolicy restricts the host paths the containers can bind mount. There
+// is no bind mount policy on Windows.
+type bindPolicy struct{}
+
+func newBindPolicy(config *Config) (*bindPolicy, error) {
+	return nil, nil
+}
+
+func (p *bindPolicy) check(source string) error {
+	return nil
+}
+
+// chownMount changes the ownership of the source of the mount to the user of
+// the container. The U mount mode is not supported on Windows.
+func (daemon *Daemon) chownMount(c *container.Container, hostConfig *containertypes.HostConfig, m *volume.MountPoint) error {
+	return nil
+}
+
+// parseBaselineMounts returns the mounts to add to every container. Baseline
+// mounts are not supported on Windows.
+func parseBaselineMounts(config *Config) ([]*volume.MountPoint, error) {
+	return nil, nil
+}
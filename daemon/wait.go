@@ -1,17 +1,32 @@
 package daemon
 
-import "time"
+import (
+	"time"
+
+	derr "github.com/docker/docker/errors"
+	"golang.org/x/net/context"
+)
 
 // ContainerWait stops processing until the given container is
 // stopped. If the container is not found, an error is returned. On a
 // successful stop, the exit code of the container is returned. On a
-// timeout, an error is returned. If you want to wait forever, supply
-// a negative duration for the timeout.
-func (daemon *Daemon) ContainerWait(name string, timeout time.Duration) (int, error) {
-	container, err := daemon.GetContainer(name)
+// timeout or when ctx is cancelled, an error is returned. If you want
+// to wait forever, supply a negative duration for the timeout.
+func (daemon *Daemon) ContainerWait(ctx context.Context, name string, timeout time.Duration) (int, error) {
+	container, err := daemon.getContainerWithContext(ctx, name)
 	if err != nil {
 		return -1, err
 	}
 
-	return container.WaitStop(timeout)
+	waitCtx := ctx
+	if timeout >= 0 {
+		var cancel context.CancelFunc
+		waitCtx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	exitCode, err := container.WaitStopWithContext(waitCtx)
+	if err == context.DeadlineExceeded && ctx.Err() == nil {
+		return -1, derr.ErrorCodeTimedOut.WithArgs(timeout)
+	}
+	return exitCode, err
 }
@@ -0,0 +1,20 @@
+package distribution
+
+import (
+	"github.com/docker/docker/reference"
+)
+
+// EventLogger notifies the progress of a pull or a push of the image
+// referenced by ref, such as "pull_start" or "push_layer".
+type EventLogger func(ref, action string, attributes map[string]string)
+
+// log notifies an event if the logger is set.
+func (l EventLogger) log(ref reference.Named, action string, attributes map[string]string) {
+	if l == nil {
+		return
+	}
+	if attributes == nil {
+		attributes = map[string]string{}
+	}
+	l(ref.String(), action, attributes)
+}
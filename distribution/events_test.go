@@ -0,0 +1,47 @@
+package distribution
+
+import (
+	"testing"
+
+	"github.com/docker/docker/reference"
+	"github.com/docker/docker/registry"
+	"golang.org/x/net/context"
+)
+
+func TestPullFailedEvent(t *testing.T) {
+	ref, err := reference.ParseNamed("scratch")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var actions []string
+	var reason string
+	config := &ImagePullConfig{
+		RegistryService: registry.NewService(nil),
+		EventLogger: func(name, action string, attributes map[string]string) {
+			if name != "scratch" {
+				t.Fatalf("Unexpected reference %s", name)
+			}
+			actions = append(actions, action)
+			reason = attributes["reason"]
+		},
+	}
+	if err := Pull(context.Background(), ref, config); err == nil {
+		t.Fatal("Expected pulling scratch to fail")
+	}
+	if len(actions) != 2 || actions[0] != "pull_start" || actions[1] != "pull_failed" {
+		t.Fatalf("Expected the pull_start and pull_failed events, got %v", actions)
+	}
+	if reason == "" {
+		t.Fatal("Expected the reason of the failure")
+	}
+}
+
+func TestNilEventLogger(t *testing.T) {
+	ref, err := reference.ParseNamed("busybox")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var l EventLogger
+	l.log(ref, "pull_start", nil)
+}
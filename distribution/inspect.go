@@ -0,0 +1,136 @@
+package distribution
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/image"
+	"github.com/docker/docker/reference"
+	"github.com/docker/docker/registry"
+	"golang.org/x/net/context"
+)
+
+// ImageInspectConfig stores the configuration to inspect an image in a
+// registry.
+type ImageInspectConfig struct {
+	// MetaHeaders stores HTTP headers with metadata about the image
+	// (DockerHeaders with prefix X-Meta- in the request).
+	MetaHeaders map[string][]string
+	// AuthConfig holds authentication credentials for authenticating with
+	// the registry.
+	AuthConfig *types.AuthConfig
+	// RegistryService is the registry service to use for TLS configuration
+	// and endpoint lookup.
+	RegistryService *registry.Service
+}
+
+// Inspect fetches the manifest of the image referenced by ref from a v2
+// registry and returns its configuration, without pulling its layers nor
+// storing anything. The latest tag is inspected if ref has neither a tag nor
+// a digest.
+func Inspect(ctx context.Context, ref reference.Named, config *ImageInspectConfig) (*types.RemoteImageInspect, error) {
+	ref = reference.WithDefaultTag(ref)
+
+	repoInfo, err := config.RegistryService.ResolveRepository(ref)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateRepoName(repoInfo.Name()); err != nil {
+		return nil, err
+	}
+
+	endpoints, err := config.RegistryService.LookupPullEndpoints(repoInfo)
+	if err != nil {
+		return nil, err
+	}
+
+	var errs []string
+	for _, endpoint := range endpoints {
+		// The configuration of the images is only available without
+		// pulling them from v2 registries.
+		if endpoint.Version != registry.APIVersion2 {
+			continue
+		}
+		logrus.Debugf("Trying to inspect %s on %s", ref.String(), endpoint.URL)
+
+		inspect, err := inspectV2(ctx, ref, repoInfo, endpoint, config)
+		if err == nil {
+			return inspect, nil
+		}
+		if fallbackErr, ok := err.(fallbackError); ok {
+			errs = append(errs, fallbackErr.err.Error())
+			continue
+		}
+		return nil, err
+	}
+
+	if len(errs) == 0 {
+		return nil, fmt.Errorf("no v2 endpoints found for %s", ref.String())
+	}
+	return nil, fmt.Errorf("%s", strings.Join(errs, "\n"))
+}
+
+func inspectV2(ctx context.Context, ref reference.Named, repoInfo *registry.RepositoryInfo, endpoint registry.APIEndpoint, config *ImageInspectConfig) (*types.RemoteImageInspect, error) {
+	repo, confirmedV2, err := NewV2Repository(ctx, repoInfo, endpoint, config.MetaHeaders, config.AuthConfig, "pull")
+	if err != nil {
+		return nil, fallbackError{err: err, confirmedV2: confirmedV2}
+	}
+
+	tagOrDigest := ""
+	if tagged, isTagged := ref.(reference.NamedTagged); isTagged {
+		tagOrDigest = tagged.Tag()
+	} else if digested, isCanonical := ref.(reference.Canonical); isCanonical {
+		tagOrDigest = digested.Digest().String()
+	}
+
+	manSvc, err := repo.Manifests(ctx)
+	if err != nil {
+		return nil, err
+	}
+	unverifiedManifest, err := manSvc.GetByTag(tagOrDigest)
+	if err != nil {
+		if registry.ContinueOnError(err) {
+			return nil, fallbackError{err: err, confirmedV2: confirmedV2}
+		}
+		return nil, err
+	}
+	if unverifiedManifest == nil {
+		return nil, fmt.Errorf("image manifest does not exist for tag or digest %q", tagOrDigest)
+	}
+	manifest, err := verifyManifest(unverifiedManifest, ref)
+	if err != nil {
+		return nil, err
+	}
+	manifestDigest, _, err := digestFromManifest(unverifiedManifest, ref)
+	if err != nil {
+		return nil, err
+	}
+
+	// The first entry of the history holds the configuration of the image.
+	var v1Image image.V1Image
+	if err := json.Unmarshal([]byte(manifest.History[0].V1Compatibility), &v1Image); err != nil {
+		return nil, fmt.Errorf("invalid configuration in the manifest of %s: %v", ref.String(), err)
+	}
+
+	layers := make([]string, 0, len(manifest.FSLayers))
+	for i := len(manifest.FSLayers) - 1; i >= 0; i-- {
+		layers = append(layers, manifest.FSLayers[i].BlobSum.String())
+	}
+
+	return &types.RemoteImageInspect{
+		Name:          ref.String(),
+		Digest:        manifestDigest.String(),
+		Created:       v1Image.Created.Format(time.RFC3339Nano),
+		Comment:       v1Image.Comment,
+		DockerVersion: v1Image.DockerVersion,
+		Author:        v1Image.Author,
+		Config:        v1Image.Config,
+		Architecture:  v1Image.Architecture,
+		Os:            v1Image.OS,
+		Layers:        layers,
+	}, nil
+}
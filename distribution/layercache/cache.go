@@ -0,0 +1,187 @@
+// Package layercache implements a cache of layer blobs shared by the daemons
+// of a host, which they consult before downloading the layers of an image.
+package layercache
+
+import (
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/docker/distribution/digest"
+)
+
+// ErrNotCached is returned when a blob is not in the cache.
+var ErrNotCached = errors.New("blob not in the layer cache")
+
+// Cache is a directory of layer blobs shared by several daemons. The blobs
+// are stored compressed, as served by the registry, in
+// blobs/<algorithm>/<hex> and are only read by the daemons.
+//
+// A daemon using a blob holds a reference file refs/<algorithm>/<hex>/<owner>
+// for as long as the blob is linked into its storage. The daemons hold a
+// shared lock on the lock file of the cache while they take a reference, so
+// that a blob without references can be removed from the cache while holding
+// an exclusive lock.
+type Cache struct {
+	root  string
+	owner string
+
+	mu   sync.Mutex
+	refs map[digest.Digest]int
+}
+
+// New returns the cache in the directory root for the daemon owner. The
+// references left by a previous run of the daemon are removed.
+func New(root, owner string) (*Cache, error) {
+	if err := os.MkdirAll(filepath.Join(root, "refs"), 0755); err != nil {
+		return nil, err
+	}
+	c := &Cache{
+		root:  root,
+		owner: owner,
+		refs:  make(map[digest.Digest]int),
+	}
+
+	unlock, err := c.lock(true)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+	stale, err := filepath.Glob(filepath.Join(root, "refs", "*", "*"))
+	if err != nil {
+		return nil, err
+	}
+	for _, dir := range stale {
+		if err := os.Remove(filepath.Join(dir, owner)); err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+// Blob is a blob of the cache linked into the storage of the daemon. The
+// link and the reference of the daemon are removed when it is closed.
+type Blob struct {
+	*os.File
+	cache  *Cache
+	digest digest.Digest
+}
+
+// Close closes the blob and releases it.
+func (b *Blob) Close() error {
+	err := b.File.Close()
+	if err := os.Remove(b.Name()); err != nil {
+		logrus.Warnf("Failed to remove layer cache link %s: %v", b.Name(), err)
+	}
+	b.cache.release(b.digest)
+	return err
+}
+
+// Open returns the blob with the given digest, hard-linked into dir, or
+// copied there if it cannot be linked. If dir is empty, the default
+// directory for temporary files is used. ErrNotCached is returned if the blob
+// is not in the cache.
+func (c *Cache) Open(dgst digest.Digest, dir string) (*Blob, error) {
+	if err := dgst.Validate(); err != nil {
+		return nil, err
+	}
+	blobPath := filepath.Join(c.root, "blobs", string(dgst.Algorithm()), dgst.Hex())
+
+	unlock, err := c.lock(false)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	if _, err := os.Stat(blobPath); err != nil {
+		if os.IsNotExist(err) {
+			return nil, ErrNotCached
+		}
+		return nil, err
+	}
+	if err := c.acquire(dgst); err != nil {
+		return nil, err
+	}
+	f, err := link(blobPath, dir)
+	if err != nil {
+		c.release(dgst)
+		return nil, err
+	}
+	return &Blob{File: f, cache: c, digest: dgst}, nil
+}
+
+// acquire takes a reference on the blob with the given digest.
+func (c *Cache) acquire(dgst digest.Digest) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.refs[dgst] == 0 {
+		refDir := c.refDir(dgst)
+		if err := os.MkdirAll(refDir, 0755); err != nil {
+			return err
+		}
+		if err := ioutil.WriteFile(filepath.Join(refDir, c.owner), nil, 0644); err != nil {
+			return err
+		}
+	}
+	c.refs[dgst]++
+	return nil
+}
+
+// release releases a reference on the blob with the given digest.
+func (c *Cache) release(dgst digest.Digest) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.refs[dgst]--; c.refs[dgst] > 0 {
+		return
+	}
+	delete(c.refs, dgst)
+	if err := os.Remove(filepath.Join(c.refDir(dgst), c.owner)); err != nil && !os.IsNotExist(err) {
+		logrus.Warnf("Failed to release layer cache blob %s: %v", dgst, err)
+	}
+}
+
+func (c *Cache) refDir(dgst digest.Digest) string {
+	return filepath.Join(c.root, "refs", string(dgst.Algorithm()), dgst.Hex())
+}
+
+// link hard-links the file at path into dir, or copies it if it cannot be
+// linked, and opens the link.
+func link(path, dir string) (*os.File, error) {
+	tmp, err := ioutil.TempFile(dir, "LayerCache")
+	if err != nil {
+		return nil, err
+	}
+	name := tmp.Name()
+	tmp.Close()
+	if err := os.Remove(name); err != nil {
+		return nil, err
+	}
+	if err := os.Link(path, name); err == nil {
+		return os.Open(name)
+	}
+
+	src, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer src.Close()
+	dst, err := os.OpenFile(name, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := io.Copy(dst, src); err != nil {
+		dst.Close()
+		os.Remove(name)
+		return nil, err
+	}
+	if _, err := dst.Seek(0, os.SEEK_SET); err != nil {
+		dst.Close()
+		os.Remove(name)
+		return nil, err
+	}
+	return dst, nil
+}
@@ -0,0 +1,89 @@
+package layercache
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/docker/distribution/digest"
+)
+
+func TestOpen(t *testing.T) {
+	root, err := ioutil.TempDir("", "layercache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	content := []byte("layer")
+	dgst, err := digest.FromBytes(content)
+	if err != nil {
+		t.Fatal(err)
+	}
+	blobDir := filepath.Join(root, "blobs", string(dgst.Algorithm()))
+	if err := os.MkdirAll(blobDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(blobDir, dgst.Hex()), content, 0644); err != nil {
+		t.Fatal(err)
+	}
+	stale := filepath.Join(root, "refs", string(dgst.Algorithm()), dgst.Hex(), "daemon1")
+	if err := os.MkdirAll(filepath.Dir(stale), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(stale, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := New(root, "daemon1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(stale); !os.IsNotExist(err) {
+		t.Fatalf("expected stale reference to be removed, got %v", err)
+	}
+
+	other, err := digest.FromBytes([]byte("other"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Open(other, root); err != ErrNotCached {
+		t.Fatalf("expected %v, got %v", ErrNotCached, err)
+	}
+
+	b1, err := c.Open(dgst, root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b2, err := c.Open(dgst, root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := ioutil.ReadAll(b1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != string(content) {
+		t.Fatalf("expected %q, got %q", content, data)
+	}
+	if _, err := os.Stat(stale); err != nil {
+		t.Fatalf("expected reference of the blob: %v", err)
+	}
+
+	if err := b1.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(b1.Name()); !os.IsNotExist(err) {
+		t.Fatalf("expected link to be removed, got %v", err)
+	}
+	if _, err := os.Stat(stale); err != nil {
+		t.Fatalf("expected reference to be kept while the blob is open: %v", err)
+	}
+	if err := b2.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(stale); !os.IsNotExist(err) {
+		t.Fatalf("expected reference to be removed, got %v", err)
+	}
+}
@@ -0,0 +1,30 @@
+// +build !windows
+
+package layercache
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+)
+
+// lock takes a lock on the lock file of the cache, shared unless exclusive
+// is true, and returns the function releasing it.
+func (c *Cache) lock(exclusive bool) (func(), error) {
+	f, err := os.OpenFile(filepath.Join(c.root, "lock"), os.O_RDONLY|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+	how := syscall.LOCK_SH
+	if exclusive {
+		how = syscall.LOCK_EX
+	}
+	if err := syscall.Flock(int(f.Fd()), how); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return func() {
+		syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+		f.Close()
+	}, nil
+}
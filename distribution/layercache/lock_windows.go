@@ -0,0 +1,8 @@
+package layercache
+
+import "errors"
+
+// lock is not implemented on Windows, where the cache is not supported.
+func (c *Cache) lock(exclusive bool) (func(), error) {
+	return nil, errors.New("the layer cache is not supported on Windows")
+}
@@ -0,0 +1,88 @@
+package metadata
+
+import (
+	"encoding/json"
+
+	"github.com/docker/distribution/digest"
+	"github.com/docker/docker/image"
+)
+
+// ManifestService keeps the manifests images were pulled with, as they were
+// received from the registry.
+type ManifestService struct {
+	store Store
+}
+
+// maxManifests is the number of manifests to keep per image.
+const maxManifests = 5
+
+// NewManifestService creates a new manifest storage service.
+func NewManifestService(store Store) *ManifestService {
+	return &ManifestService{
+		store: store,
+	}
+}
+
+func (manserv *ManifestService) manifestNamespace() string {
+	return "manifest-storage"
+}
+
+func (manserv *ManifestService) imageNamespace() string {
+	return "manifest-lookup"
+}
+
+func (manserv *ManifestService) digestKey(dgst digest.Digest) string {
+	return string(dgst.Algorithm()) + "/" + dgst.Hex()
+}
+
+// Get returns the raw manifest with the given digest.
+func (manserv *ManifestService) Get(dgst digest.Digest) ([]byte, error) {
+	return manserv.store.Get(manserv.manifestNamespace(), manserv.digestKey(dgst))
+}
+
+// GetDigests returns the digests of the manifests an image was pulled with,
+// the most recent last.
+func (manserv *ManifestService) GetDigests(imageID image.ID) ([]digest.Digest, error) {
+	jsonBytes, err := manserv.store.Get(manserv.imageNamespace(), manserv.digestKey(digest.Digest(imageID)))
+	if err != nil {
+		return nil, err
+	}
+
+	var digests []digest.Digest
+	if err := json.Unmarshal(jsonBytes, &digests); err != nil {
+		return nil, err
+	}
+
+	return digests, nil
+}
+
+// Add stores a raw manifest by digest and associates it with an image. If too
+// many manifests are associated with the image, the oldest one is dropped.
+func (manserv *ManifestService) Add(imageID image.ID, dgst digest.Digest, raw []byte) error {
+	if err := manserv.store.Set(manserv.manifestNamespace(), manserv.digestKey(dgst), raw); err != nil {
+		return err
+	}
+
+	oldDigests, err := manserv.GetDigests(imageID)
+	if err != nil {
+		oldDigests = nil
+	}
+	newDigests := make([]digest.Digest, 0, len(oldDigests)+1)
+	for _, oldDigest := range oldDigests {
+		if oldDigest != dgst {
+			newDigests = append(newDigests, oldDigest)
+		}
+	}
+	newDigests = append(newDigests, dgst)
+
+	if len(newDigests) > maxManifests {
+		newDigests = newDigests[len(newDigests)-maxManifests:]
+	}
+
+	jsonBytes, err := json.Marshal(newDigests)
+	if err != nil {
+		return err
+	}
+
+	return manserv.store.Set(manserv.imageNamespace(), manserv.digestKey(digest.Digest(imageID)), jsonBytes)
+}
@@ -0,0 +1,64 @@
+package metadata
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/docker/distribution/digest"
+	"github.com/docker/docker/image"
+)
+
+func TestManifestService(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "manifest-storage-service-test")
+	if err != nil {
+		t.Fatalf("could not create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	metadataStore, err := NewFSMetadataStore(tmpDir)
+	if err != nil {
+		t.Fatalf("could not create metadata store: %v", err)
+	}
+	manifestService := NewManifestService(metadataStore)
+
+	imageID := image.ID("sha256:a3ed95caeb02ffe68cdd9fd84406680ae93d633cb16422d00e8a7c22955b46d4")
+	if _, err := manifestService.GetDigests(imageID); err == nil {
+		t.Fatal("expected an error for an image without manifests")
+	}
+
+	var digests []digest.Digest
+	for i := 0; i < maxManifests+2; i++ {
+		raw := []byte(fmt.Sprintf(`{"schemaVersion": 1, "tag": "%d"}`, i))
+		dgst, err := digest.FromBytes(raw)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := manifestService.Add(imageID, dgst, raw); err != nil {
+			t.Fatalf("error calling Add: %v", err)
+		}
+		// Adding the same manifest again moves it last.
+		if err := manifestService.Add(imageID, dgst, raw); err != nil {
+			t.Fatalf("error calling Add: %v", err)
+		}
+		digests = append(digests, dgst)
+
+		stored, err := manifestService.Get(dgst)
+		if err != nil {
+			t.Fatalf("error calling Get: %v", err)
+		}
+		if string(stored) != string(raw) {
+			t.Fatalf("expected manifest %s, got %s", raw, stored)
+		}
+	}
+
+	stored, err := manifestService.GetDigests(imageID)
+	if err != nil {
+		t.Fatalf("error calling GetDigests: %v", err)
+	}
+	if expected := digests[len(digests)-maxManifests:]; !reflect.DeepEqual(stored, expected) {
+		t.Fatalf("expected digests %v, got %v", expected, stored)
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"github.com/Sirupsen/logrus"
 	"github.com/docker/docker/api"
 	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/distribution/layercache"
 	"github.com/docker/docker/distribution/metadata"
 	"github.com/docker/docker/distribution/xfer"
 	"github.com/docker/docker/image"
@@ -33,6 +34,9 @@ type ImagePullConfig struct {
 	RegistryService *registry.Service
 	// ImageEventLogger notifies events for a given image
 	ImageEventLogger func(id, name, action string)
+	// EventLogger notifies the start, the layers and the failure of the
+	// pull. It may be nil.
+	EventLogger EventLogger
 	// MetadataStore is the storage backend for distribution-specific
 	// metadata.
 	MetadataStore metadata.Store
@@ -42,6 +46,12 @@ type ImagePullConfig struct {
 	ReferenceStore reference.Store
 	// DownloadManager manages concurrent pulls.
 	DownloadManager *xfer.LayerDownloadManager
+	// LayerCache is the cache of layer blobs shared with other daemons,
+	// consulted before downloading a layer. It may be nil.
+	LayerCache *layercache.Cache
+	// TrustService verifies the tags pulled against their signed trust
+	// metadata. It may be nil.
+	TrustService *TrustService
 }
 
 // Puller is an interface that abstracts pulling for different API versions.
@@ -61,10 +71,11 @@ func newPuller(endpoint registry.APIEndpoint, repoInfo *registry.RepositoryInfo,
 	switch endpoint.Version {
 	case registry.APIVersion2:
 		return &v2Puller{
-			blobSumService: metadata.NewBlobSumService(imagePullConfig.MetadataStore),
-			endpoint:       endpoint,
-			config:         imagePullConfig,
-			repoInfo:       repoInfo,
+			blobSumService:  metadata.NewBlobSumService(imagePullConfig.MetadataStore),
+			manifestService: metadata.NewManifestService(imagePullConfig.MetadataStore),
+			endpoint:        endpoint,
+			config:          imagePullConfig,
+			repoInfo:        repoInfo,
 		}, nil
 	case registry.APIVersion1:
 		return &v1Puller{
@@ -79,7 +90,24 @@ func newPuller(endpoint registry.APIEndpoint, repoInfo *registry.RepositoryInfo,
 
 // Pull initiates a pull operation. image is the repository name to pull, and
 // tag may be either empty, or indicate a specific tag to pull.
-func Pull(ctx context.Context, ref reference.Named, imagePullConfig *ImagePullConfig) error {
+func Pull(ctx context.Context, ref reference.Named, imagePullConfig *ImagePullConfig) (err error) {
+	imagePullConfig.EventLogger.log(ref, "pull_start", nil)
+	defer func() {
+		if err != nil {
+			imagePullConfig.EventLogger.log(ref, "pull_failed", map[string]string{"reason": err.Error()})
+		}
+	}()
+
+	// The images pulled by digest are verified against their digest.
+	if _, isCanonical := ref.(reference.Canonical); imagePullConfig.TrustService != nil && !isCanonical {
+		return trustedPull(ctx, ref, imagePullConfig)
+	}
+	return pullFromEndpoints(ctx, ref, imagePullConfig)
+}
+
+// pullFromEndpoints pulls ref from the first endpoint of its registry which
+// serves it.
+func pullFromEndpoints(ctx context.Context, ref reference.Named, imagePullConfig *ImagePullConfig) error {
 	// Resolve the Repository name from fqn to RepositoryInfo
 	repoInfo, err := imagePullConfig.RegistryService.ResolveRepository(ref)
 	if err != nil {
@@ -137,6 +165,10 @@ func Pull(ctx context.Context, ref reference.Named, imagePullConfig *ImagePullCo
 					fallback = true
 					confirmedV2 = confirmedV2 || fallbackErr.confirmedV2
 					err = fallbackErr.err
+				} else if endpoint.Mirror {
+					// The upstream registry may have what
+					// the mirror failed to serve.
+					fallback = true
 				}
 			}
 			if fallback {
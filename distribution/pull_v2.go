@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"io"
 	"io/ioutil"
+	"net/http"
 	"os"
 	"runtime"
 
@@ -14,6 +15,8 @@ import (
 	"github.com/docker/distribution/digest"
 	"github.com/docker/distribution/manifest/schema1"
 	"github.com/docker/distribution/registry/api/errcode"
+	"github.com/docker/distribution/registry/api/v2"
+	"github.com/docker/docker/distribution/layercache"
 	"github.com/docker/docker/distribution/metadata"
 	"github.com/docker/docker/distribution/xfer"
 	"github.com/docker/docker/image"
@@ -24,15 +27,23 @@ import (
 	"github.com/docker/docker/pkg/stringid"
 	"github.com/docker/docker/reference"
 	"github.com/docker/docker/registry"
+	"github.com/docker/go-units"
 	"golang.org/x/net/context"
 )
 
+// errRangeNotSupported is returned when the registry does not serve the
+// requested range of a blob.
+var errRangeNotSupported = errors.New("range requests not supported")
+
 type v2Puller struct {
-	blobSumService *metadata.BlobSumService
-	endpoint       registry.APIEndpoint
-	config         *ImagePullConfig
-	repoInfo       *registry.RepositoryInfo
-	repo           distribution.Repository
+	blobSumService  *metadata.BlobSumService
+	manifestService *metadata.ManifestService
+	endpoint        registry.APIEndpoint
+	config          *ImagePullConfig
+	repoInfo        *registry.RepositoryInfo
+	repo            distribution.Repository
+	// transport is the authenticated transport of repo.
+	transport http.RoundTripper
 	// confirmedV2 is set to true if we confirm we're talking to a v2
 	// registry. This is used to limit fallbacks to the v1 protocol.
 	confirmedV2 bool
@@ -40,9 +51,12 @@ type v2Puller struct {
 
 func (p *v2Puller) Pull(ctx context.Context, ref reference.Named) (err error) {
 	// TODO(tiborvass): was ReceiveTimeout
-	p.repo, p.confirmedV2, err = NewV2Repository(ctx, p.repoInfo, p.endpoint, p.config.MetaHeaders, p.config.AuthConfig, "pull")
+	p.repo, p.transport, p.confirmedV2, err = newV2Repository(ctx, p.repoInfo, p.endpoint, p.config.MetaHeaders, p.config.AuthConfig, "pull")
 	if err != nil {
 		logrus.Warnf("Error getting v2 registry: %v", err)
+		if p.endpoint.Mirror {
+			p.config.RegistryService.MarkMirrorUnhealthy(p.endpoint.URL, err)
+		}
 		return fallbackError{err: err, confirmedV2: p.confirmedV2}
 	}
 
@@ -105,6 +119,17 @@ type v2LayerDescriptor struct {
 	digest         digest.Digest
 	repo           distribution.Repository
 	blobSumService *metadata.BlobSumService
+	ref            reference.Named
+	eventLogger    EventLogger
+	layerCache     *layercache.Cache
+	// transport and endpointURL are used to request the rest of the blob
+	// with a Range request when a download is resumed.
+	transport   http.RoundTripper
+	endpointURL string
+	// partial is the file the blob is downloaded to. It is kept between
+	// the attempts of the download, so that a retry resumes from the
+	// bytes already downloaded.
+	partial *os.File
 }
 
 func (ld *v2LayerDescriptor) Key() string {
@@ -122,6 +147,94 @@ func (ld *v2LayerDescriptor) DiffID() (layer.DiffID, error) {
 func (ld *v2LayerDescriptor) Download(ctx context.Context, progressOutput progress.Output) (io.ReadCloser, int64, error) {
 	logrus.Debugf("pulling blob %q", ld.digest)
 
+	if blob, size := ld.openCached(); blob != nil {
+		progress.Update(progressOutput, ld.ID(), "Found in layer cache")
+		return blob, size, nil
+	}
+
+	verifier, err := digest.NewDigestVerifier(ld.digest)
+	if err != nil {
+		return nil, 0, xfer.DoNotRetry{Err: err}
+	}
+
+	if ld.partial == nil {
+		if ld.partial, err = ioutil.TempFile("", "GetImageBlob"); err != nil {
+			return nil, 0, xfer.DoNotRetry{Err: err}
+		}
+	}
+
+	// Hash the bytes downloaded by the previous attempts, and request the
+	// rest of the blob.
+	if _, err := ld.partial.Seek(0, os.SEEK_SET); err != nil {
+		return nil, 0, xfer.DoNotRetry{Err: err}
+	}
+	offset, err := io.Copy(verifier, ld.partial)
+	if err != nil {
+		return nil, 0, xfer.DoNotRetry{Err: err}
+	}
+
+	var (
+		layerDownload io.ReadCloser
+		size          int64
+	)
+	if offset > 0 {
+		layerDownload, size, err = ld.openFrom(offset)
+		if err == errRangeNotSupported {
+			logrus.Debugf("Cannot resume the download of %s: %v", ld.digest, err)
+			if err := ld.truncatePartial(); err != nil {
+				return nil, 0, xfer.DoNotRetry{Err: err}
+			}
+			if verifier, err = digest.NewDigestVerifier(ld.digest); err != nil {
+				return nil, 0, xfer.DoNotRetry{Err: err}
+			}
+			offset = 0
+		} else if err != nil {
+			return nil, 0, retryOnError(err)
+		} else {
+			progress.Updatef(progressOutput, ld.ID(), "Resuming download at %s", units.HumanSize(float64(offset)))
+		}
+	}
+	if offset == 0 {
+		if layerDownload, size, err = ld.open(ctx); err != nil {
+			return nil, 0, err
+		}
+	}
+
+	remaining := size
+	if remaining > 0 {
+		remaining -= offset
+	}
+	reader := progress.NewProgressReader(ioutils.NewCancelReadCloser(ctx, layerDownload), progressOutput, remaining, ld.ID(), "Downloading")
+	defer reader.Close()
+
+	_, err = io.Copy(ld.partial, io.TeeReader(reader, verifier))
+	if err != nil {
+		// The next attempt resumes from the bytes written so far.
+		return nil, 0, retryOnError(err)
+	}
+
+	progress.Update(progressOutput, ld.ID(), "Verifying Checksum")
+
+	if !verifier.Verified() {
+		err = fmt.Errorf("filesystem layer verification failed for digest %s", ld.digest)
+		logrus.Error(err)
+		ld.Cleanup()
+		return nil, 0, xfer.DoNotRetry{Err: err}
+	}
+
+	progress.Update(progressOutput, ld.ID(), "Download complete")
+
+	tmpFile := ld.partial
+	ld.partial = nil
+	logrus.Debugf("Downloaded %s to tempfile %s", ld.ID(), tmpFile.Name())
+
+	tmpFile.Seek(0, 0)
+	return ioutils.NewReadCloserWrapper(tmpFile, tmpFileCloser(tmpFile)), size, nil
+}
+
+// open requests the whole blob of the layer, and returns its size, or 0 if
+// it is unknown.
+func (ld *v2LayerDescriptor) open(ctx context.Context) (io.ReadCloser, int64, error) {
 	blobs := ld.repo.Blobs(ctx)
 
 	layerDownload, err := blobs.Open(ctx, ld.digest)
@@ -143,52 +256,110 @@ func (ld *v2LayerDescriptor) Download(ctx context.Context, progressOutput progre
 		// Restore the seek offset at the beginning of the stream.
 		_, err = layerDownload.Seek(0, os.SEEK_SET)
 		if err != nil {
+			layerDownload.Close()
 			return nil, 0, err
 		}
 	}
+	return layerDownload, size, nil
+}
 
-	reader := progress.NewProgressReader(ioutils.NewCancelReadCloser(ctx, layerDownload), progressOutput, size, ld.ID(), "Downloading")
-	defer reader.Close()
-
-	verifier, err := digest.NewDigestVerifier(ld.digest)
+// openFrom requests the blob of the layer from offset with a Range request,
+// and returns the size of the whole blob, or 0 if it is unknown. It returns
+// errRangeNotSupported if the registry does not serve the range.
+func (ld *v2LayerDescriptor) openFrom(offset int64) (io.ReadCloser, int64, error) {
+	if ld.transport == nil {
+		return nil, 0, errRangeNotSupported
+	}
+	ub, err := v2.NewURLBuilderFromString(ld.endpointURL)
 	if err != nil {
-		return nil, 0, xfer.DoNotRetry{Err: err}
+		return nil, 0, err
 	}
-
-	tmpFile, err := ioutil.TempFile("", "GetImageBlob")
+	blobURL, err := ub.BuildBlobURL(ld.repo.Name(), ld.digest)
 	if err != nil {
-		return nil, 0, xfer.DoNotRetry{Err: err}
+		return nil, 0, err
 	}
-
-	_, err = io.Copy(tmpFile, io.TeeReader(reader, verifier))
+	req, err := http.NewRequest("GET", blobURL, nil)
 	if err != nil {
-		return nil, 0, retryOnError(err)
+		return nil, 0, err
 	}
+	req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
 
-	progress.Update(progressOutput, ld.ID(), "Verifying Checksum")
-
-	if !verifier.Verified() {
-		err = fmt.Errorf("filesystem layer verification failed for digest %s", ld.digest)
-		logrus.Error(err)
-		tmpFile.Close()
-		if err := os.RemoveAll(tmpFile.Name()); err != nil {
-			logrus.Errorf("Failed to remove temp file: %s", tmpFile.Name())
+	resp, err := (&http.Client{Transport: ld.transport}).Do(req)
+	if err != nil {
+		return nil, 0, err
+	}
+	switch resp.StatusCode {
+	case http.StatusPartialContent:
+		var size int64
+		if resp.ContentLength >= 0 {
+			size = offset + resp.ContentLength
 		}
-
-		return nil, 0, xfer.DoNotRetry{Err: err}
+		return resp.Body, size, nil
+	case http.StatusOK, http.StatusRequestedRangeNotSatisfiable:
+		resp.Body.Close()
+		return nil, 0, errRangeNotSupported
 	}
+	resp.Body.Close()
+	return nil, 0, fmt.Errorf("unexpected status resuming the download of %s: %s", ld.digest, resp.Status)
+}
 
-	progress.Update(progressOutput, ld.ID(), "Download complete")
+// truncatePartial discards the bytes downloaded by the previous attempts.
+func (ld *v2LayerDescriptor) truncatePartial() error {
+	if err := ld.partial.Truncate(0); err != nil {
+		return err
+	}
+	_, err := ld.partial.Seek(0, os.SEEK_SET)
+	return err
+}
 
-	logrus.Debugf("Downloaded %s to tempfile %s", ld.ID(), tmpFile.Name())
+// Cleanup removes the partially downloaded blob of the layer once the
+// download is not retried anymore.
+func (ld *v2LayerDescriptor) Cleanup() {
+	if ld.partial == nil {
+		return
+	}
+	tmpFileCloser(ld.partial)()
+	ld.partial = nil
+}
 
-	tmpFile.Seek(0, 0)
-	return ioutils.NewReadCloserWrapper(tmpFile, tmpFileCloser(tmpFile)), size, nil
+// openCached returns the blob of the layer from the layer cache, or nil if
+// it is not cached or does not match its digest.
+func (ld *v2LayerDescriptor) openCached() (io.ReadCloser, int64) {
+	if ld.layerCache == nil {
+		return nil, 0
+	}
+	blob, err := ld.layerCache.Open(ld.digest, "")
+	if err != nil {
+		if err != layercache.ErrNotCached {
+			logrus.Warnf("Failed to open blob %s from the layer cache: %v", ld.digest, err)
+		}
+		return nil, 0
+	}
+	verifier, err := digest.NewDigestVerifier(ld.digest)
+	if err != nil {
+		blob.Close()
+		return nil, 0
+	}
+	size, err := io.Copy(verifier, blob)
+	if err == nil && !verifier.Verified() {
+		err = fmt.Errorf("verification failed for digest %s", ld.digest)
+	}
+	if err == nil {
+		_, err = blob.Seek(0, os.SEEK_SET)
+	}
+	if err != nil {
+		logrus.Warnf("Failed to read blob %s from the layer cache: %v", ld.digest, err)
+		blob.Close()
+		return nil, 0
+	}
+	logrus.Debugf("Using blob %s from the layer cache", ld.digest)
+	return blob, size
 }
 
 func (ld *v2LayerDescriptor) Registered(diffID layer.DiffID) {
 	// Cache mapping from this layer's DiffID to the blobsum
 	ld.blobSumService.Add(diffID, ld.digest)
+	ld.eventLogger.log(ld.ref, "pull_layer", map[string]string{"layer": ld.digest.String()})
 }
 
 func (p *v2Puller) pullV2Tag(ctx context.Context, ref reference.Named) (tagUpdated bool, err error) {
@@ -288,6 +459,11 @@ func (p *v2Puller) pullV2Tag(ctx context.Context, ref reference.Named) (tagUpdat
 			digest:         blobSum,
 			repo:           p.repo,
 			blobSumService: p.blobSumService,
+			ref:            ref,
+			eventLogger:    p.config.EventLogger,
+			layerCache:     p.config.LayerCache,
+			transport:      p.transport,
+			endpointURL:    p.endpoint.URL,
 		}
 
 		descriptors = append(descriptors, layerDescriptor)
@@ -316,6 +492,10 @@ func (p *v2Puller) pullV2Tag(ctx context.Context, ref reference.Named) (tagUpdat
 
 	if manifestDigest != "" {
 		progress.Message(p.config.ProgressOutput, "", "Digest: "+manifestDigest.String())
+
+		if err := p.manifestService.Add(imageID, manifestDigest, unverifiedManifest.Raw); err != nil {
+			logrus.Warnf("Failed to store the manifest %s of %s: %v", manifestDigest, p.repoInfo.FullName(), err)
+		}
 	}
 
 	oldTagImageID, err := p.config.ReferenceStore.Get(ref)
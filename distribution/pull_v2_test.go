@@ -1,15 +1,23 @@
 package distribution
 
 import (
+	"bytes"
 	"encoding/json"
+	"fmt"
 	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
 	"reflect"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/docker/distribution/digest"
 	"github.com/docker/distribution/manifest/schema1"
+	"github.com/docker/distribution/registry/client"
+	"github.com/docker/docker/pkg/progress"
 	"github.com/docker/docker/reference"
+	"golang.org/x/net/context"
 )
 
 // TestFixManifestLayers checks that fixManifestLayers removes a duplicate
@@ -172,3 +180,71 @@ func TestValidateManifest(t *testing.T) {
 		t.Fatal("expected validateManifest to fail with digest error")
 	}
 }
+
+type discardProgress struct{}
+
+func (discardProgress) WriteProgress(progress.Progress) error { return nil }
+
+func TestResumeLayerDownload(t *testing.T) {
+	blob := bytes.Repeat([]byte("layer data "), 10000)
+	dgst, err := digest.FromBytes(blob)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, supportsRange := range []bool{true, false} {
+		var ranges []string
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == "GET" {
+				ranges = append(ranges, r.Header.Get("Range"))
+			}
+			if !supportsRange {
+				r.Header.Del("Range")
+			}
+			http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(blob))
+		}))
+
+		ctx := context.Background()
+		repo, err := client.NewRepository(ctx, "library/busybox", server.URL, http.DefaultTransport)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ld := &v2LayerDescriptor{
+			digest:      dgst,
+			repo:        repo,
+			transport:   http.DefaultTransport,
+			endpointURL: server.URL,
+		}
+
+		// A previous attempt downloaded the first half of the blob.
+		if ld.partial, err = ioutil.TempFile("", "GetImageBlob"); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := ld.partial.Write(blob[:len(blob)/2]); err != nil {
+			t.Fatal(err)
+		}
+
+		rc, size, err := ld.Download(ctx, discardProgress{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := ioutil.ReadAll(rc)
+		rc.Close()
+		server.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(data, blob) || size != int64(len(blob)) {
+			t.Fatalf("Expected the whole blob of %d bytes, got %d bytes and size %d", len(blob), len(data), size)
+		}
+		if expected := fmt.Sprintf("bytes=%d-", len(blob)/2); len(ranges) == 0 || ranges[0] != expected {
+			t.Fatalf("Expected the download to resume with range %s, got %v", expected, ranges)
+		}
+		if !supportsRange && len(ranges) != 2 {
+			t.Fatalf("Expected the download to restart without range support, got %v", ranges)
+		}
+		if ld.partial != nil {
+			t.Fatal("Expected the partial blob to be handed over")
+		}
+	}
+}
@@ -36,6 +36,9 @@ type ImagePushConfig struct {
 	RegistryService *registry.Service
 	// ImageEventLogger notifies events for a given image
 	ImageEventLogger func(id, name, action string)
+	// EventLogger notifies the start, the layers and the failure of the
+	// push. It may be nil.
+	EventLogger EventLogger
 	// MetadataStore is the storage backend for distribution-specific
 	// metadata.
 	MetadataStore metadata.Store
@@ -50,6 +53,8 @@ type ImagePushConfig struct {
 	TrustKey libtrust.PrivateKey
 	// UploadManager dispatches uploads.
 	UploadManager *xfer.LayerUploadManager
+	// TrustService signs the tags pushed. It may be nil.
+	TrustService *TrustService
 }
 
 // Pusher is an interface that abstracts pushing for different API versions.
@@ -94,9 +99,16 @@ func NewPusher(ref reference.Named, endpoint registry.APIEndpoint, repoInfo *reg
 // Push initiates a push operation on the repository named localName.
 // ref is the specific variant of the image to be pushed.
 // If no tag is provided, all tags will be pushed.
-func Push(ctx context.Context, ref reference.Named, imagePushConfig *ImagePushConfig) error {
+func Push(ctx context.Context, ref reference.Named, imagePushConfig *ImagePushConfig) (err error) {
 	// FIXME: Allow to interrupt current push when new push of same image is done.
 
+	imagePushConfig.EventLogger.log(ref, "push_start", nil)
+	defer func() {
+		if err != nil {
+			imagePushConfig.EventLogger.log(ref, "push_failed", map[string]string{"reason": err.Error()})
+		}
+	}()
+
 	// Resolve the Repository name from fqn to RepositoryInfo
 	repoInfo, err := imagePushConfig.RegistryService.ResolveRepository(ref)
 	if err != nil {
@@ -129,6 +141,11 @@ func Push(ctx context.Context, ref reference.Named, imagePushConfig *ImagePushCo
 			logrus.Debugf("Skipping v1 endpoint %s because v2 registry was detected", endpoint.URL)
 			continue
 		}
+		if imagePushConfig.TrustService != nil && endpoint.Version == registry.APIVersion1 {
+			// The pushes to v1 registries cannot be signed
+			lastErr = fmt.Errorf("content trust requires a v2 registry to push %s", repoInfo.FullName())
+			continue
+		}
 
 		logrus.Debugf("Trying to push %s to %s %s", repoInfo.FullName(), endpoint.URL, endpoint.Version)
 
@@ -155,6 +172,12 @@ func Push(ctx context.Context, ref reference.Named, imagePushConfig *ImagePushCo
 			return err
 		}
 
+		if imagePushConfig.TrustService != nil {
+			if err := signPushed(pusher, repoInfo, imagePushConfig); err != nil {
+				return err
+			}
+		}
+
 		imagePushConfig.ImageEventLogger(ref.String(), repoInfo.Name(), "push")
 		return nil
 	}
@@ -165,6 +188,18 @@ func Push(ctx context.Context, ref reference.Named, imagePushConfig *ImagePushCo
 	return lastErr
 }
 
+// signPushed signs the tags pushed by pusher in the trust metadata of the
+// repository.
+func signPushed(pusher Pusher, repoInfo *registry.RepositoryInfo, imagePushConfig *ImagePushConfig) error {
+	v2Pusher := pusher.(*v2Pusher)
+	if len(v2Pusher.pushed) == 0 {
+		progress.Message(imagePushConfig.ProgressOutput, "", "No tags pushed, skipping trust metadata push")
+		return nil
+	}
+	progress.Message(imagePushConfig.ProgressOutput, "", "Signing and pushing trust metadata")
+	return imagePushConfig.TrustService.sign(repoInfo, imagePushConfig.AuthConfig, v2Pusher.pushed, imagePushConfig.ProgressOutput)
+}
+
 // compress returns an io.ReadCloser which will supply a compressed version of
 // the provided Reader. The caller must close the ReadCloser after reading the
 // compressed data.
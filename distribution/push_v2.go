@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"strconv"
 	"sync"
 	"time"
 
@@ -42,6 +43,9 @@ type v2Pusher struct {
 	// This avoids redundant queries when pushing multiple tags that
 	// involve the same layers.
 	layersPushed pushMap
+
+	// pushed are the tags pushed, to be signed.
+	pushed []trustTarget
 }
 
 type pushMap struct {
@@ -127,6 +131,8 @@ func (p *v2Pusher) pushV2Tag(ctx context.Context, association reference.Associat
 		repo:           p.repo,
 		layersPushed:   &p.layersPushed,
 		confirmedV2:    &p.confirmedV2,
+		ref:            ref,
+		eventLogger:    p.config.EventLogger,
 	}
 
 	// Push empty layer if necessary
@@ -184,7 +190,13 @@ func (p *v2Pusher) pushV2Tag(ctx context.Context, association reference.Associat
 	if err != nil {
 		return err
 	}
-	return manSvc.Put(signed)
+	if err := manSvc.Put(signed); err != nil {
+		return err
+	}
+	if tagged, isTagged := ref.(reference.NamedTagged); isTagged && manifestDigest != "" {
+		p.pushed = append(p.pushed, trustTarget{tag: tagged.Tag(), digest: manifestDigest, size: int64(manifestSize)})
+	}
+	return nil
 }
 
 type v2PushDescriptor struct {
@@ -193,6 +205,8 @@ type v2PushDescriptor struct {
 	repo           distribution.Repository
 	layersPushed   *pushMap
 	confirmedV2    *bool
+	ref            reference.Named
+	eventLogger    EventLogger
 }
 
 func (pd *v2PushDescriptor) Key() string {
@@ -222,6 +236,7 @@ func (pd *v2PushDescriptor) Upload(ctx context.Context, progressOutput progress.
 		}
 		if exists {
 			progress.Update(progressOutput, pd.ID(), "Layer already exists")
+			pd.eventLogger.log(pd.ref, "push_layer", map[string]string{"layer": dgst.String(), "exists": "true"})
 			return dgst, nil
 		}
 	}
@@ -279,6 +294,7 @@ func (pd *v2PushDescriptor) Upload(ctx context.Context, progressOutput progress.
 	pd.layersPushed.layersPushed[pushDigest] = true
 	pd.layersPushed.Unlock()
 
+	pd.eventLogger.log(pd.ref, "push_layer", map[string]string{"layer": pushDigest.String(), "size": strconv.FormatInt(nn, 10)})
 	return pushDigest, nil
 }
 
@@ -51,6 +51,14 @@ func (dcs dumbCredentialStore) Basic(*url.URL) (string, string) {
 // providing timeout settings and authentication support, and also verifies the
 // remote API version.
 func NewV2Repository(ctx context.Context, repoInfo *registry.RepositoryInfo, endpoint registry.APIEndpoint, metaHeaders http.Header, authConfig *types.AuthConfig, actions ...string) (repo distribution.Repository, foundVersion bool, err error) {
+	repo, _, foundVersion, err = newV2Repository(ctx, repoInfo, endpoint, metaHeaders, authConfig, actions...)
+	return repo, foundVersion, err
+}
+
+// newV2Repository is NewV2Repository, also returning the authenticated
+// transport of the repository for the requests the repository does not
+// provide.
+func newV2Repository(ctx context.Context, repoInfo *registry.RepositoryInfo, endpoint registry.APIEndpoint, metaHeaders http.Header, authConfig *types.AuthConfig, actions ...string) (repo distribution.Repository, tr http.RoundTripper, foundVersion bool, err error) {
 	repoName := repoInfo.FullName()
 	// If endpoint does not support CanonicalName, use the RemoteName instead
 	if endpoint.TrimHostname {
@@ -80,11 +88,11 @@ func NewV2Repository(ctx context.Context, repoInfo *registry.RepositoryInfo, end
 	endpointStr := strings.TrimRight(endpoint.URL, "/") + "/v2/"
 	req, err := http.NewRequest("GET", endpointStr, nil)
 	if err != nil {
-		return nil, false, err
+		return nil, nil, false, err
 	}
 	resp, err := pingClient.Do(req)
 	if err != nil {
-		return nil, false, err
+		return nil, nil, false, err
 	}
 	defer resp.Body.Close()
 
@@ -107,7 +115,7 @@ func NewV2Repository(ctx context.Context, repoInfo *registry.RepositoryInfo, end
 
 	challengeManager := auth.NewSimpleChallengeManager()
 	if err := challengeManager.AddResponse(resp); err != nil {
-		return nil, foundVersion, err
+		return nil, nil, foundVersion, err
 	}
 
 	if authConfig.RegistryToken != "" {
@@ -119,10 +127,10 @@ func NewV2Repository(ctx context.Context, repoInfo *registry.RepositoryInfo, end
 		basicHandler := auth.NewBasicHandler(creds)
 		modifiers = append(modifiers, auth.NewAuthorizer(challengeManager, tokenHandler, basicHandler))
 	}
-	tr := transport.NewTransport(base, modifiers...)
+	tr = transport.NewTransport(base, modifiers...)
 
 	repo, err = client.NewRepository(ctx, repoName, endpoint.URL, tr)
-	return repo, foundVersion, err
+	return repo, tr, foundVersion, err
 }
 
 func digestFromManifest(m *schema1.SignedManifest, name reference.Named) (digest.Digest, int, error) {
@@ -0,0 +1,341 @@
+package distribution
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/docker/distribution/digest"
+	"github.com/docker/distribution/registry/client/auth"
+	"github.com/docker/distribution/registry/client/transport"
+	"github.com/docker/docker/api/types"
+	registrytypes "github.com/docker/docker/api/types/registry"
+	"github.com/docker/docker/pkg/progress"
+	"github.com/docker/docker/reference"
+	"github.com/docker/docker/registry"
+	"github.com/docker/notary/client"
+	"github.com/docker/notary/passphrase"
+	"github.com/docker/notary/trustmanager"
+	"github.com/docker/notary/tuf/data"
+	"golang.org/x/net/context"
+)
+
+// trustTarget is a tag of a repository signed in the trust metadata.
+type trustTarget struct {
+	tag    string
+	digest digest.Digest
+	size   int64
+}
+
+// TrustService verifies the images pulled by tag against the signed target
+// metadata of a Notary trust server, and signs the tags pushed. The keys of
+// the repositories are kept in the trust directory of the daemon, encrypted
+// with the passphrases of the environment of the daemon.
+type TrustService struct {
+	dir             string
+	servers         map[string]string
+	registryService *registry.Service
+}
+
+// NewTrustService returns a trust service keeping its metadata and keys in
+// dir. servers are the URLs of the trust servers by registry hostname, the
+// default being the Docker Hub Notary server for the official registry, and
+// the registry itself for the others.
+func NewTrustService(dir string, servers map[string]string, registryService *registry.Service) (*TrustService, error) {
+	for index, server := range servers {
+		u, err := url.Parse(server)
+		if err != nil || u.Scheme != "https" {
+			return nil, fmt.Errorf("valid https URL required for the trust server of %s, got %s", index, server)
+		}
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	return &TrustService{
+		dir:             dir,
+		servers:         servers,
+		registryService: registryService,
+	}, nil
+}
+
+func (s *TrustService) server(index *registrytypes.IndexInfo) string {
+	if server, ok := s.servers[index.Name]; ok {
+		return server
+	}
+	if index.Official {
+		return registry.NotaryServer
+	}
+	return "https://" + index.Name
+}
+
+type simpleCredentialStore struct {
+	auth *types.AuthConfig
+}
+
+func (scs simpleCredentialStore) Basic(u *url.URL) (string, string) {
+	if scs.auth == nil {
+		return "", ""
+	}
+	return scs.auth.Username, scs.auth.Password
+}
+
+// repository returns the trust metadata of the repository.
+func (s *TrustService) repository(repoInfo *registry.RepositoryInfo, authConfig *types.AuthConfig) (*client.NotaryRepository, error) {
+	server := s.server(repoInfo.Index)
+	serverURL, err := url.Parse(server)
+	if err != nil {
+		return nil, err
+	}
+	tlsConfig, err := s.registryService.TLSConfig(serverURL.Host)
+	if err != nil {
+		return nil, err
+	}
+
+	base := &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		Dial: (&net.Dialer{
+			Timeout:   30 * time.Second,
+			KeepAlive: 30 * time.Second,
+			DualStack: true,
+		}).Dial,
+		TLSHandshakeTimeout: 10 * time.Second,
+		TLSClientConfig:     tlsConfig,
+		DisableKeepAlives:   true,
+	}
+
+	modifiers := registry.DockerHeaders(http.Header{})
+	authTransport := transport.NewTransport(base, modifiers...)
+	pingClient := &http.Client{
+		Transport: authTransport,
+		Timeout:   5 * time.Second,
+	}
+	endpointStr := server + "/v2/"
+	req, err := http.NewRequest("GET", endpointStr, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	challengeManager := auth.NewSimpleChallengeManager()
+	resp, err := pingClient.Do(req)
+	if err != nil {
+		// Ignore error on ping to operate in offline mode
+		logrus.Debugf("Error pinging notary server %q: %s", endpointStr, err)
+	} else {
+		defer resp.Body.Close()
+		if err := challengeManager.AddResponse(resp); err != nil {
+			return nil, err
+		}
+	}
+
+	creds := simpleCredentialStore{auth: authConfig}
+	tokenHandler := auth.NewTokenHandler(authTransport, creds, repoInfo.FullName(), "push", "pull")
+	basicHandler := auth.NewBasicHandler(creds)
+	modifiers = append(modifiers, transport.RequestModifier(auth.NewAuthorizer(challengeManager, tokenHandler, basicHandler)))
+	tr := transport.NewTransport(base, modifiers...)
+
+	return client.NewNotaryRepository(s.dir, repoInfo.FullName(), server, tr, s.passphraseRetriever())
+}
+
+// passphraseRetriever returns the passphrases of the keys of the trust
+// directory from the environment of the daemon: the root keys are encrypted
+// with DOCKER_CONTENT_TRUST_ROOT_PASSPHRASE, the keys of the repositories
+// with DOCKER_CONTENT_TRUST_REPOSITORY_PASSPHRASE. The keys cannot be
+// created or decrypted without them.
+func (s *TrustService) passphraseRetriever() passphrase.Retriever {
+	return func(keyName, alias string, createNew bool, numAttempts int) (string, bool, error) {
+		v, err := trustPassphrase(alias)
+		if err != nil || numAttempts > 0 {
+			return "", true, nil
+		}
+		return v, false, nil
+	}
+}
+
+// trustPassphrase returns the passphrase of the keys of role from the
+// environment of the daemon.
+func trustPassphrase(role string) (string, error) {
+	env := "DOCKER_CONTENT_TRUST_REPOSITORY_PASSPHRASE"
+	if role == data.CanonicalRootRole {
+		env = "DOCKER_CONTENT_TRUST_ROOT_PASSPHRASE"
+	}
+	if v := os.Getenv(env); v != "" {
+		return v, nil
+	}
+	return "", fmt.Errorf("%s is not set in the environment of the daemon", env)
+}
+
+// rootKeyID returns the ID of the root key of the repositories initialized
+// by the daemon: the first root key of the trust directory, or a new root
+// key generated in the trust directory.
+func (s *TrustService) rootKeyID(repo *client.NotaryRepository) (string, error) {
+	keys := repo.CryptoService.ListKeys(data.CanonicalRootRole)
+	if len(keys) > 0 {
+		sort.Strings(keys)
+		return keys[0], nil
+	}
+
+	if _, err := trustPassphrase(data.CanonicalRootRole); err != nil {
+		return "", err
+	}
+	rootKey, err := repo.CryptoService.Create(data.CanonicalRootRole, data.ECDSAKey)
+	if err != nil {
+		return "", err
+	}
+	return rootKey.ID(), nil
+}
+
+// targets returns the signed target of the tag, or all the signed targets
+// of the repository if tag is empty.
+func (s *TrustService) targets(repoInfo *registry.RepositoryInfo, authConfig *types.AuthConfig, tag string) ([]trustTarget, error) {
+	repo, err := s.repository(repoInfo, authConfig)
+	if err != nil {
+		return nil, fmt.Errorf("error establishing connection to trust repository: %v", err)
+	}
+
+	var targets []*client.Target
+	if tag == "" {
+		if targets, err = repo.ListTargets(); err != nil {
+			return nil, trustError(err)
+		}
+	} else {
+		t, err := repo.GetTargetByName(tag)
+		if err != nil {
+			return nil, trustError(err)
+		}
+		targets = append(targets, t)
+	}
+
+	var trusted []trustTarget
+	for _, t := range targets {
+		h, ok := t.Hashes["sha256"]
+		if !ok {
+			if tag != "" {
+				return nil, errors.New("no valid hash, expecting sha256")
+			}
+			logrus.Debugf("Skipping target %s of %s without sha256 hash", t.Name, repoInfo.FullName())
+			continue
+		}
+		trusted = append(trusted, trustTarget{
+			tag:    t.Name,
+			digest: digest.NewDigestFromHex("sha256", hex.EncodeToString(h)),
+			size:   t.Length,
+		})
+	}
+	return trusted, nil
+}
+
+// sign adds the targets to the trust metadata of the repository and
+// publishes it, initializing the repository if needed.
+func (s *TrustService) sign(repoInfo *registry.RepositoryInfo, authConfig *types.AuthConfig, targets []trustTarget, out progress.Output) error {
+	if _, err := trustPassphrase(data.CanonicalTargetsRole); err != nil {
+		return fmt.Errorf("cannot sign %s: %v", repoInfo.FullName(), err)
+	}
+	repo, err := s.repository(repoInfo, authConfig)
+	if err != nil {
+		return fmt.Errorf("error establishing connection to trust repository: %v", err)
+	}
+
+	for _, target := range targets {
+		h, err := hex.DecodeString(target.digest.Hex())
+		if err != nil {
+			return err
+		}
+		t := &client.Target{
+			Name: target.tag,
+			Hashes: data.Hashes{
+				string(target.digest.Algorithm()): h,
+			},
+			Length: target.size,
+		}
+		if err := repo.AddTarget(t); err != nil {
+			return err
+		}
+	}
+
+	err = repo.Publish()
+	if _, ok := err.(*client.ErrRepoNotInitialized); !ok {
+		return trustError(err)
+	}
+
+	rootKeyID, err := s.rootKeyID(repo)
+	if err != nil {
+		return err
+	}
+	if err := repo.Initialize(rootKeyID); err != nil {
+		return trustError(err)
+	}
+	progress.Messagef(out, "", "Initialized trust metadata of %s", repoInfo.FullName())
+	return trustError(repo.Publish())
+}
+
+func trustError(err error) error {
+	switch err.(type) {
+	case *json.SyntaxError:
+		logrus.Debugf("Notary syntax error: %s", err)
+		return errors.New("no trust data available for remote repository")
+	case client.ErrExpired:
+		return fmt.Errorf("remote repository out-of-date: %v", err)
+	case trustmanager.ErrKeyNotFound:
+		return fmt.Errorf("signing keys not found: %v", err)
+	case *net.OpError:
+		return fmt.Errorf("error contacting notary server: %v", err)
+	}
+	return err
+}
+
+// trustedPull pulls the signed targets of the tag of ref, or all the signed
+// targets of its repository if ref has no tag, by digest, and tags them.
+func trustedPull(ctx context.Context, ref reference.Named, imagePullConfig *ImagePullConfig) error {
+	repoInfo, err := imagePullConfig.RegistryService.ResolveRepository(ref)
+	if err != nil {
+		return err
+	}
+	var tag string
+	if tagged, isTagged := ref.(reference.NamedTagged); isTagged {
+		tag = tagged.Tag()
+	}
+	targets, err := imagePullConfig.TrustService.targets(repoInfo, imagePullConfig.AuthConfig, tag)
+	if err != nil {
+		return err
+	}
+	if len(targets) == 0 {
+		return fmt.Errorf("no signed tags for %s", repoInfo.FullName())
+	}
+
+	name, err := reference.WithName(ref.Name())
+	if err != nil {
+		return err
+	}
+	for i, t := range targets {
+		trustedRef, err := reference.WithDigest(name, t.digest)
+		if err != nil {
+			return err
+		}
+		progress.Messagef(imagePullConfig.ProgressOutput, "", "Pull (%d of %d): %s:%s@%s", i+1, len(targets), name.Name(), t.tag, t.digest)
+		if err := pullFromEndpoints(ctx, trustedRef, imagePullConfig); err != nil {
+			return err
+		}
+
+		tagged, err := reference.WithTag(name, t.tag)
+		if err != nil {
+			return err
+		}
+		imageID, err := imagePullConfig.ReferenceStore.Get(trustedRef)
+		if err != nil {
+			return err
+		}
+		progress.Messagef(imagePullConfig.ProgressOutput, "", "Tagging %s as %s", trustedRef.String(), tagged.String())
+		if err := imagePullConfig.ReferenceStore.AddTag(tagged, imageID, true); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,128 @@
+package distribution
+
+import (
+	"io/ioutil"
+	"net/http"
+	"os"
+	"testing"
+
+	registrytypes "github.com/docker/docker/api/types/registry"
+	"github.com/docker/docker/opts"
+	"github.com/docker/docker/registry"
+	"github.com/docker/notary/client"
+	"github.com/docker/notary/passphrase"
+	"github.com/docker/notary/trustmanager"
+	"github.com/docker/notary/tuf/data"
+)
+
+func newTestTrustService(t *testing.T, servers map[string]string) (*TrustService, func()) {
+	dir, err := ioutil.TempDir("", "docker-trust")
+	if err != nil {
+		t.Fatal(err)
+	}
+	registryService := registry.NewService(&registry.Options{
+		Mirrors:            opts.NewListOpts(nil),
+		InsecureRegistries: opts.NewListOpts(nil),
+	})
+	s, err := NewTrustService(dir, servers, registryService)
+	if err != nil {
+		os.RemoveAll(dir)
+		t.Fatal(err)
+	}
+	return s, func() { os.RemoveAll(dir) }
+}
+
+func TestTrustServer(t *testing.T) {
+	s, cleanup := newTestTrustService(t, map[string]string{"registry.example.com": "https://notary.example.com"})
+	defer cleanup()
+
+	for _, c := range []struct {
+		index  *registrytypes.IndexInfo
+		server string
+	}{
+		{&registrytypes.IndexInfo{Name: "docker.io", Official: true}, registry.NotaryServer},
+		{&registrytypes.IndexInfo{Name: "registry.example.com"}, "https://notary.example.com"},
+		{&registrytypes.IndexInfo{Name: "localhost:5000"}, "https://localhost:5000"},
+	} {
+		if server := s.server(c.index); server != c.server {
+			t.Fatalf("Expected trust server %s for %s, got %s", c.server, c.index.Name, server)
+		}
+	}
+
+	if _, err := NewTrustService(os.TempDir(), map[string]string{"registry.example.com": "http://notary.example.com"}, nil); err == nil {
+		t.Fatal("Expected an error for a trust server without TLS")
+	}
+}
+
+// setTrustPassphrases sets the passphrases of the keys of the trust
+// directory in the environment, and returns a function restoring it.
+func setTrustPassphrases(root, repository string) func() {
+	oldRoot := os.Getenv("DOCKER_CONTENT_TRUST_ROOT_PASSPHRASE")
+	oldRepository := os.Getenv("DOCKER_CONTENT_TRUST_REPOSITORY_PASSPHRASE")
+	os.Setenv("DOCKER_CONTENT_TRUST_ROOT_PASSPHRASE", root)
+	os.Setenv("DOCKER_CONTENT_TRUST_REPOSITORY_PASSPHRASE", repository)
+	return func() {
+		os.Setenv("DOCKER_CONTENT_TRUST_ROOT_PASSPHRASE", oldRoot)
+		os.Setenv("DOCKER_CONTENT_TRUST_REPOSITORY_PASSPHRASE", oldRepository)
+	}
+}
+
+func TestTrustRootKey(t *testing.T) {
+	s, cleanup := newTestTrustService(t, nil)
+	defer cleanup()
+	defer setTrustPassphrases("root passphrase", "repository passphrase")()
+
+	repo, err := client.NewNotaryRepository(s.dir, "docker.io/library/busybox", registry.NotaryServer, http.DefaultTransport, s.passphraseRetriever())
+	if err != nil {
+		t.Fatal(err)
+	}
+	rootKeyID, err := s.rootKeyID(repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The key is encrypted with the root passphrase of the environment, and
+	// used again by the next repositories.
+	repo, err = client.NewNotaryRepository(s.dir, "docker.io/library/redis", registry.NotaryServer, http.DefaultTransport, s.passphraseRetriever())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if keyID, err := s.rootKeyID(repo); err != nil || keyID != rootKeyID {
+		t.Fatalf("Expected the root key %s, got %s (%v)", rootKeyID, keyID, err)
+	}
+	if _, role, err := repo.CryptoService.GetPrivateKey(rootKeyID); err != nil || role != data.CanonicalRootRole {
+		t.Fatalf("Expected to decrypt the root key, got %s (%v)", role, err)
+	}
+	for _, c := range []struct {
+		passphrase string
+		decrypt    bool
+	}{
+		{"repository passphrase", false},
+		{"root passphrase", true},
+	} {
+		store, err := trustmanager.NewKeyFileStore(s.dir, passphrase.ConstantRetriever(c.passphrase))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, _, err := store.GetKey(rootKeyID); (err == nil) != c.decrypt {
+			t.Fatalf("Expected decrypting the root key with %q to be %v, got %v", c.passphrase, c.decrypt, err)
+		}
+	}
+}
+
+func TestTrustRootKeyRequiresPassphrase(t *testing.T) {
+	s, cleanup := newTestTrustService(t, nil)
+	defer cleanup()
+	defer setTrustPassphrases("", "")()
+
+	repo, err := client.NewNotaryRepository(s.dir, "docker.io/library/busybox", registry.NotaryServer, http.DefaultTransport, s.passphraseRetriever())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.rootKeyID(repo); err == nil {
+		t.Fatal("Expected creating a root key without passphrase to fail")
+	}
+	if keys := repo.CryptoService.ListKeys(data.CanonicalRootRole); len(keys) != 0 {
+		t.Fatalf("Expected no root key to be created, got %v", keys)
+	}
+}
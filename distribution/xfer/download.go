@@ -71,6 +71,16 @@ type DownloadDescriptorWithRegistered interface {
 	Registered(diffID layer.DiffID)
 }
 
+// DownloadDescriptorWithCleanup is a DownloadDescriptor which keeps state
+// between the attempts of a download, like a partially downloaded blob the
+// next attempt resumes from. Cleanup is called once the download is not
+// retried anymore, whether it succeeded or not. This method is called if a
+// cast to DownloadDescriptorWithCleanup is successful.
+type DownloadDescriptorWithCleanup interface {
+	DownloadDescriptor
+	Cleanup()
+}
+
 // Download is a blocking function which ensures the requested layers are
 // present in the layer store. It uses the string returned by the Key method to
 // deduplicate downloads. If a given layer is not already known to present in
@@ -229,6 +239,10 @@ func (ldm *LayerDownloadManager) makeDownloadFunc(descriptor DownloadDescriptor,
 				retries        int
 			)
 
+			if c, ok := descriptor.(DownloadDescriptorWithCleanup); ok {
+				defer c.Cleanup()
+			}
+
 			for {
 				downloadReader, size, err = descriptor.Download(d.Transfer.Context(), progressOutput)
 				if err == nil {
@@ -47,6 +47,7 @@ func NewDaemonCli() *DaemonCli {
 	daemonConfig := new(daemon.Config)
 	daemonConfig.LogConfig.Config = make(map[string]string)
 	daemonConfig.ClusterOpts = make(map[string]string)
+	daemonConfig.ContentTrustServers = make(map[string]string)
 	daemonConfig.InstallFlags(daemonFlags, presentInHelp)
 	daemonConfig.InstallFlags(flag.CommandLine, absentFromHelp)
 	registryOptions := new(registry.Options)
@@ -223,6 +224,10 @@ func (cli *DaemonCli) CmdDaemon(args ...string) error {
 	}
 	cli.TrustKeyPath = commonFlags.TrustKey
 
+	// Restoring the containers may take long, keep the host watchdog from
+	// killing the daemon meanwhile.
+	startWatchdog()
+
 	registryService := registry.NewService(cli.registryOptions)
 	d, err := daemon.NewDaemon(cli.Config, registryService)
 	if err != nil {
@@ -259,9 +264,10 @@ func (cli *DaemonCli) CmdDaemon(args ...string) error {
 	}()
 
 	signal.Trap(func() {
+		notifyShutdown()
 		api.Close()
 		<-serveAPIWait
-		shutdownDaemon(d, 15)
+		shutdownDaemon(d, time.Duration(d.ShutdownTimeout()))
 		if pfile != nil {
 			if err := pfile.Remove(); err != nil {
 				logrus.Error(err)
@@ -275,7 +281,8 @@ func (cli *DaemonCli) CmdDaemon(args ...string) error {
 	// Daemon is fully initialized and handling API traffic
 	// Wait for serve API to complete
 	errAPI := <-serveAPIWait
-	shutdownDaemon(d, 15)
+	notifyShutdown()
+	shutdownDaemon(d, time.Duration(d.ShutdownTimeout()))
 	if errAPI != nil {
 		if pfile != nil {
 			if err := pfile.Remove(); err != nil {
@@ -5,3 +5,12 @@ package main
 // notifySystem sends a message to the host when the server is ready to be used
 func notifySystem() {
 }
+
+// notifyShutdown sends a message to the host when the server starts shutting
+// down
+func notifyShutdown() {
+}
+
+// startWatchdog sends keepalives to the host if it watches the daemon
+func startWatchdog() {
+}
@@ -3,11 +3,55 @@
 package main
 
 import (
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/Sirupsen/logrus"
 	systemdDaemon "github.com/coreos/go-systemd/daemon"
 )
 
 // notifySystem sends a message to the host when the server is ready to be used
 func notifySystem() {
 	// Tell the init daemon we are accepting requests
-	go systemdDaemon.SdNotify("READY=1")
+	systemdDaemon.SdNotify("READY=1")
+}
+
+// notifyShutdown sends a message to the host when the server starts shutting
+// down
+func notifyShutdown() {
+	systemdDaemon.SdNotify("STOPPING=1")
+}
+
+// startWatchdog sends keepalives to the host if it watches the daemon, for
+// as long as the daemon runs, including while it restores its containers.
+func startWatchdog() {
+	interval := watchdogInterval(os.Getenv("WATCHDOG_USEC"), os.Getenv("WATCHDOG_PID"), os.Getpid())
+	if interval == 0 {
+		return
+	}
+	logrus.Debugf("Sending watchdog keepalives every %v", interval)
+	go func() {
+		for range time.Tick(interval) {
+			systemdDaemon.SdNotify("WATCHDOG=1")
+		}
+	}()
+}
+
+// watchdogInterval returns the interval between two keepalives, half the
+// timeout of the watchdog, or 0 if the watchdog is not enabled for the
+// process with the given pid.
+func watchdogInterval(usec, watchdogPid string, pid int) time.Duration {
+	if usec == "" {
+		return 0
+	}
+	if watchdogPid != "" && watchdogPid != strconv.Itoa(pid) {
+		return 0
+	}
+	timeout, err := strconv.ParseInt(usec, 10, 64)
+	if err != nil || timeout <= 0 {
+		logrus.Warnf("Ignoring invalid WATCHDOG_USEC %q", usec)
+		return 0
+	}
+	return time.Duration(timeout) * time.Microsecond / 2
 }
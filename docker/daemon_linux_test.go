@@ -0,0 +1,27 @@
+// +build daemon
+
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestWatchdogInterval(t *testing.T) {
+	cases := []struct {
+		usec, pid string
+		expected  time.Duration
+	}{
+		{"", "", 0},
+		{"30000000", "", 15 * time.Second},
+		{"30000000", "42", 15 * time.Second},
+		{"30000000", "43", 0},
+		{"invalid", "", 0},
+		{"0", "", 0},
+	}
+	for _, c := range cases {
+		if interval := watchdogInterval(c.usec, c.pid, 42); interval != c.expected {
+			t.Fatalf("Expected the interval for WATCHDOG_USEC=%q WATCHDOG_PID=%q to be %v, got %v", c.usec, c.pid, c.expected, interval)
+		}
+	}
+}
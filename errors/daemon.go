@@ -19,6 +19,33 @@ var (
 		HTTPStatusCode: http.StatusNotFound,
 	})
 
+	// ErrorCodeInvalidSelector is generated when a label selector used as a
+	// container reference is malformed.
+	ErrorCodeInvalidSelector = errcode.Register(errGroup, errcode.ErrorDescriptor{
+		Value:          "INVALIDSELECTOR",
+		Message:        "Invalid label selector: %s",
+		Description:    "The label selector must be a comma separated list of key or key=value pairs",
+		HTTPStatusCode: http.StatusBadRequest,
+	})
+
+	// ErrorCodeAmbiguousSelector is generated when a label selector matches
+	// more than one container for a request acting on a single container.
+	ErrorCodeAmbiguousSelector = errcode.Register(errGroup, errcode.ErrorDescriptor{
+		Value:          "AMBIGUOUSSELECTOR",
+		Message:        "Label selector %s matches %d containers",
+		Description:    "The label selector matches more than one container, but the request acts on a single container",
+		HTTPStatusCode: http.StatusConflict,
+	})
+
+	// ErrorCodeSelectorFailed is generated when a request acting on the
+	// containers matched by a label selector fails for some of them.
+	ErrorCodeSelectorFailed = errcode.Register(errGroup, errcode.ErrorDescriptor{
+		Value:          "SELECTORFAILED",
+		Message:        "Failed for some of the containers matched by %s:\n%s",
+		Description:    "The request failed for some of the containers matched by the label selector",
+		HTTPStatusCode: http.StatusInternalServerError,
+	})
+
 	// ErrorCodeUnregisteredContainer is generated when we try to load
 	// a storage driver for an unregistered container
 	ErrorCodeUnregisteredContainer = errcode.Register(errGroup, errcode.ErrorDescriptor{
@@ -63,6 +90,15 @@ var (
 		HTTPStatusCode: http.StatusInternalServerError,
 	})
 
+	// ErrorCodeIllegalStateTransition is generated when an operation would
+	// move a container to a status which cannot follow its current one.
+	ErrorCodeIllegalStateTransition = errcode.Register(errGroup, errcode.ErrorDescriptor{
+		Value:          "ILLEGALSTATETRANSITION",
+		Message:        "Cannot move container from %s to %s",
+		Description:    "The operation is not allowed in the current status of the container",
+		HTTPStatusCode: http.StatusConflict,
+	})
+
 	// ErrorCodeNotPaused is generated when we attempt to unpause a
 	// container when its not paused.
 	ErrorCodeNotPaused = errcode.Register(errGroup, errcode.ErrorDescriptor{
@@ -453,6 +489,33 @@ var (
 		HTTPStatusCode: http.StatusInternalServerError,
 	})
 
+	// ErrorCodeBindMountDenied is generated when a container bind mounts a
+	// host path forbidden by the bind mount policy of the daemon.
+	ErrorCodeBindMountDenied = errcode.Register(errGroup, errcode.ErrorDescriptor{
+		Value:          "BINDMOUNTDENIED",
+		Message:        "Cannot bind mount %s: host path %s is forbidden by the daemon",
+		Description:    "The host path of the bind mount is under a path forbidden with --bind-mount-deny",
+		HTTPStatusCode: http.StatusForbidden,
+	})
+
+	// ErrorCodeBindMountNotAllowed is generated when a container bind
+	// mounts a host path outside of the paths allowed by the daemon.
+	ErrorCodeBindMountNotAllowed = errcode.Register(errGroup, errcode.ErrorDescriptor{
+		Value:          "BINDMOUNTNOTALLOWED",
+		Message:        "Cannot bind mount %s: host path is not under a path allowed by the daemon",
+		Description:    "The host path of the bind mount is not under a path allowed with --bind-mount-allow",
+		HTTPStatusCode: http.StatusForbidden,
+	})
+
+	// ErrorCodeChownDenied is generated when a container asks for the
+	// ownership of a read-only mount or of a system path to be changed.
+	ErrorCodeChownDenied = errcode.Register(errGroup, errcode.ErrorDescriptor{
+		Value:          "CHOWNDENIED",
+		Message:        "Cannot change the ownership of %s: %s",
+		Description:    "The ownership of the source of a mount with the U option cannot be changed",
+		HTTPStatusCode: http.StatusForbidden,
+	})
+
 	// ErrorCodeVolumeNoSourceForMount is generated when no source directory
 	// for a volume mount was found. (Windows specific)
 	ErrorCodeVolumeNoSourceForMount = errcode.Register(errGroup, errcode.ErrorDescriptor{
@@ -626,6 +689,15 @@ var (
 		HTTPStatusCode: http.StatusInternalServerError,
 	})
 
+	// ErrorCodeRenameSame is generated when we try to rename a container
+	// to the name it already has.
+	ErrorCodeRenameSame = errcode.Register(errGroup, errcode.ErrorDescriptor{
+		Value:          "RENAMESAME",
+		Message:        "Renaming a container with the same name as its current name",
+		Description:    "An attempt was made to rename a container to the name it already has",
+		HTTPStatusCode: http.StatusConflict,
+	})
+
 	// ErrorCodeRenameDelete is generated when we try to rename but
 	// failed trying to delete the old container.
 	ErrorCodeRenameDelete = errcode.Register(errGroup, errcode.ErrorDescriptor{
@@ -688,6 +760,15 @@ var (
 		HTTPStatusCode: http.StatusInternalServerError,
 	})
 
+	// ErrorCodeCommitSizeLimit is generated when the read-write layer of a
+	// container to commit or export exceeds the commit size limit.
+	ErrorCodeCommitSizeLimit = errcode.Register(errGroup, errcode.ErrorDescriptor{
+		Value:          "COMMITSIZELIMIT",
+		Message:        "The read-write layer of container %s is %s, which exceeds the commit size limit of %s",
+		Description:    "An attempt was made to commit or export a container whose read-write layer exceeds the commit size limit of the daemon",
+		HTTPStatusCode: http.StatusRequestEntityTooLarge,
+	})
+
 	// ErrorCodeExecResize is generated when we try to resize an exec
 	// but its not running.
 	ErrorCodeExecResize = errcode.Register(errGroup, errcode.ErrorDescriptor{
@@ -948,4 +1029,230 @@ var (
 		Description:    "Engine's predefined networks cannot be deleted",
 		HTTPStatusCode: http.StatusForbidden,
 	})
+
+	// ErrorCodeConfigConflict is generated when the configuration of a
+	// running container was modified outside of the daemon.
+	ErrorCodeConfigConflict = errcode.Register(errGroup, errcode.ErrorDescriptor{
+		Value:          "CONFIGCONFLICT",
+		Message:        "Configuration of container %s was modified on disk while it was running, refusing to overwrite it",
+		Description:    "The container configuration files were modified outside of the daemon while the container was running",
+		HTTPStatusCode: http.StatusConflict,
+	})
+
+	// ErrorCodeConfigReloadID is generated when the configuration reloaded
+	// from disk belongs to another container.
+	ErrorCodeConfigReloadID = errcode.Register(errGroup, errcode.ErrorDescriptor{
+		Value:          "CONFIGRELOADID",
+		Message:        "Cannot reload configuration of container %s: configuration on disk belongs to container %s",
+		Description:    "The container configuration reloaded from disk has a different container ID",
+		HTTPStatusCode: http.StatusInternalServerError,
+	})
+
+	// ErrorCodeStartTimeout is generated when the process of a container
+	// was not started by the exec driver before the start deadline.
+	ErrorCodeStartTimeout = errcode.Register(errGroup, errcode.ErrorDescriptor{
+		Value:          "STARTTIMEOUT",
+		Message:        "Container %s did not start within %s",
+		Description:    "The exec driver did not start the container's process before the start deadline",
+		HTTPStatusCode: http.StatusInternalServerError,
+	})
+
+	// ErrorCodeNoSuchCheckpoint is generated when the requested checkpoint
+	// of a container does not exist.
+	ErrorCodeNoSuchCheckpoint = errcode.Register(errGroup, errcode.ErrorDescriptor{
+		Value:          "NOSUCHCHECKPOINT",
+		Message:        "No such checkpoint %s for container %s",
+		Description:    "The specified checkpoint can not be found",
+		HTTPStatusCode: http.StatusNotFound,
+	})
+
+	// ErrorCodeCheckpointExists is generated when a checkpoint is created
+	// with the name of an existing checkpoint of the container.
+	ErrorCodeCheckpointExists = errcode.Register(errGroup, errcode.ErrorDescriptor{
+		Value:          "CHECKPOINTEXISTS",
+		Message:        "Checkpoint %s already exists for container %s",
+		Description:    "A checkpoint with the same name already exists for the container",
+		HTTPStatusCode: http.StatusConflict,
+	})
+
+	// ErrorCodeInvalidCheckpointName is generated when the name of a
+	// checkpoint contains invalid characters.
+	ErrorCodeInvalidCheckpointName = errcode.Register(errGroup, errcode.ErrorDescriptor{
+		Value:          "INVALIDCHECKPOINTNAME",
+		Message:        "Invalid checkpoint name (%s), only %s are allowed",
+		Description:    "The checkpoint name contains invalid characters",
+		HTTPStatusCode: http.StatusBadRequest,
+	})
+
+	// ErrorCodeCantCheckpoint is generated when the exec driver fails to
+	// checkpoint a container.
+	ErrorCodeCantCheckpoint = errcode.Register(errGroup, errcode.ErrorDescriptor{
+		Value:          "CANTCHECKPOINT",
+		Message:        "Cannot checkpoint container %s: %s",
+		Description:    "There was an error while trying to checkpoint a container",
+		HTTPStatusCode: http.StatusInternalServerError,
+	})
+
+	// ErrorCodeContainerBroken is generated when we try to use the
+	// filesystem of a container whose read-write layer could not be loaded
+	// when the daemon started.
+	ErrorCodeContainerBroken = errcode.Register(errGroup, errcode.ErrorDescriptor{
+		Value:          "CONTAINERBROKEN",
+		Message:        "Container %s is broken, its filesystem could not be loaded: %s",
+		Description:    "The read-write layer of the container could not be loaded, recover or remove the container",
+		HTTPStatusCode: http.StatusConflict,
+	})
+
+	// ErrorCodeContainerNotBroken is generated when we try to recover a
+	// container which is not broken.
+	ErrorCodeContainerNotBroken = errcode.Register(errGroup, errcode.ErrorDescriptor{
+		Value:          "CONTAINERNOTBROKEN",
+		Message:        "Container %s is not broken",
+		Description:    "The container does not need to be recovered",
+		HTTPStatusCode: http.StatusConflict,
+	})
+
+	// ErrorCodeCantRecover is generated when the read-write layer of a
+	// broken container still cannot be loaded.
+	ErrorCodeCantRecover = errcode.Register(errGroup, errcode.ErrorDescriptor{
+		Value:          "CANTRECOVER",
+		Message:        "Cannot recover container %s: %s",
+		Description:    "The read-write layer of the container still cannot be loaded",
+		HTTPStatusCode: http.StatusInternalServerError,
+	})
+
+	// ErrorCodeInvalidCapture is generated when the options of a capture
+	// are invalid.
+	ErrorCodeInvalidCapture = errcode.Register(errGroup, errcode.ErrorDescriptor{
+		Value:          "INVALIDCAPTURE",
+		Message:        "Invalid capture options: %s",
+		Description:    "The options of the capture are invalid",
+		HTTPStatusCode: http.StatusBadRequest,
+	})
+
+	// ErrorCodeInvalidPortCheck is generated when the port of a port check
+	// is missing or invalid.
+	ErrorCodeInvalidPortCheck = errcode.Register(errGroup, errcode.ErrorDescriptor{
+		Value:          "INVALIDPORTCHECK",
+		Message:        "Invalid port check: %s",
+		Description:    "The port to check is missing or invalid",
+		HTTPStatusCode: http.StatusBadRequest,
+	})
+
+	// ErrorCodeCaptureRunning is generated when a capture is started on a
+	// container which already has one running.
+	ErrorCodeCaptureRunning = errcode.Register(errGroup, errcode.ErrorDescriptor{
+		Value:          "CAPTURERUNNING",
+		Message:        "A capture is already running on container %s",
+		Description:    "Only one capture can run on a container at a time",
+		HTTPStatusCode: http.StatusConflict,
+	})
+
+	// ErrorCodeNoCapture is generated when we try to stop the capture of a
+	// container which has none running.
+	ErrorCodeNoCapture = errcode.Register(errGroup, errcode.ErrorDescriptor{
+		Value:          "NOCAPTURE",
+		Message:        "No capture is running on container %s",
+		Description:    "The container has no capture running",
+		HTTPStatusCode: http.StatusNotFound,
+	})
+
+	// ErrorCodeNoCaptureFile is generated when we try to download the pcap
+	// file of a container which has none.
+	ErrorCodeNoCaptureFile = errcode.Register(errGroup, errcode.ErrorDescriptor{
+		Value:          "NOCAPTUREFILE",
+		Message:        "No capture file for container %s",
+		Description:    "No capture has been written to a pcap file for the container",
+		HTTPStatusCode: http.StatusNotFound,
+	})
+
+	// ErrorCodeCantCapture is generated when the capture of the traffic of
+	// a container cannot be set up.
+	ErrorCodeCantCapture = errcode.Register(errGroup, errcode.ErrorDescriptor{
+		Value:          "CANTCAPTURE",
+		Message:        "Cannot capture the traffic of container %s: %v",
+		Description:    "The capture of the traffic of the container could not be set up",
+		HTTPStatusCode: http.StatusInternalServerError,
+	})
+
+	// ErrorCodeMissingKernelModules is generated when the kernel modules
+	// required by a container are not loaded when it starts.
+	ErrorCodeMissingKernelModules = errcode.Register(errGroup, errcode.ErrorDescriptor{
+		Value:          "MISSINGKERNELMODULES",
+		Message:        "Cannot start container %s: required kernel modules are not loaded: %s",
+		Description:    "The kernel modules required by the container are not loaded, and the daemon was not allowed to load them",
+		HTTPStatusCode: http.StatusInternalServerError,
+	})
+
+	// ErrorCodeStorageFull is generated when a container or an image is
+	// written while the storage of the daemon is full or read-only.
+	ErrorCodeStorageFull = errcode.Register(errGroup, errcode.ErrorDescriptor{
+		Value:          "STORAGEFULL",
+		Message:        "The storage of the daemon is full or read-only: %s",
+		Description:    "The storage of the daemon is degraded, no space is left on it or it is read-only, until space is freed",
+		HTTPStatusCode: http.StatusServiceUnavailable,
+	})
+
+	// ErrorCodeStdinNotOpen is generated when input is sent to a container
+	// which does not keep its standard input open.
+	ErrorCodeStdinNotOpen = errcode.Register(errGroup, errcode.ErrorDescriptor{
+		Value:          "STDINNOTOPEN",
+		Message:        "Container %s does not keep its standard input open, create it with -i",
+		Description:    "The input cannot be sent to a container created without OpenStdin, or whose standard input was closed",
+		HTTPStatusCode: http.StatusConflict,
+	})
+
+	// ErrorCodeInputTooLarge is generated when the input sent to a
+	// container is larger than the limit.
+	ErrorCodeInputTooLarge = errcode.Register(errGroup, errcode.ErrorDescriptor{
+		Value:          "INPUTTOOLARGE",
+		Message:        "The input is larger than the limit of %d bytes",
+		Description:    "The input sent to a container without attaching to it is limited in size, attach to the container to send more",
+		HTTPStatusCode: http.StatusRequestEntityTooLarge,
+	})
+
+	// ErrorCodeInputTimeout is generated when the process of a container
+	// does not read the input sent to it in time.
+	ErrorCodeInputTimeout = errcode.Register(errGroup, errcode.ErrorDescriptor{
+		Value:          "INPUTTIMEOUT",
+		Message:        "Container %s did not read the input in %s",
+		Description:    "The process of the container did not read its standard input in time, it is busy or paused",
+		HTTPStatusCode: http.StatusInternalServerError,
+	})
+
+	// ErrorCodeMigrateSameDriver is generated when the storage is migrated
+	// to the storage driver the daemon already uses.
+	ErrorCodeMigrateSameDriver = errcode.Register(errGroup, errcode.ErrorDescriptor{
+		Value:          "MIGRATESAMEDRIVER",
+		Message:        "The daemon already uses the storage driver %s, specify another driver to migrate to",
+		Description:    "The storage can only be migrated to a storage driver the daemon does not use",
+		HTTPStatusCode: http.StatusBadRequest,
+	})
+
+	// ErrorCodeMigrateRunning is generated when the storage is migrated
+	// while a container is running.
+	ErrorCodeMigrateRunning = errcode.Register(errGroup, errcode.ErrorDescriptor{
+		Value:          "MIGRATERUNNING",
+		Message:        "Cannot migrate the storage while container %s is running, stop it first",
+		Description:    "The read-write layers of the containers can only be migrated when all the containers are stopped",
+		HTTPStatusCode: http.StatusConflict,
+	})
+
+	// ErrorCodeMigrateInProgress is generated when the storage is migrated
+	// or changed while a migration is in progress.
+	ErrorCodeMigrateInProgress = errcode.Register(errGroup, errcode.ErrorDescriptor{
+		Value:          "MIGRATEINPROGRESS",
+		Message:        "A migration of the storage is in progress",
+		Description:    "The images and the containers cannot be changed, nor the storage migrated again, while the storage is migrated",
+		HTTPStatusCode: http.StatusConflict,
+	})
+
+	// ErrorCodeMigrated is generated when the storage is migrated or changed
+	// after a migration, before the daemon is restarted.
+	ErrorCodeMigrated = errcode.Register(errGroup, errcode.ErrorDescriptor{
+		Value:          "MIGRATED",
+		Message:        "The storage was migrated to the storage driver %s, restart the daemon to use it",
+		Description:    "The changes made to the images and the containers after a migration of the storage would not be migrated, they are rejected until the daemon restarts with the migrated storage",
+		HTTPStatusCode: http.StatusConflict,
+	})
 )
@@ -42,4 +42,31 @@ var (
 		Description:    "The caller didn't provide a connection to hijack",
 		HTTPStatusCode: http.StatusBadRequest,
 	})
+
+	// ErrorCodeAuthZDenied is generated when an authorization plugin denies
+	// a request or its response.
+	ErrorCodeAuthZDenied = errcode.Register(errGroup, errcode.ErrorDescriptor{
+		Value:          "AUTHZDENIED",
+		Message:        "authorization denied by plugin %s: %s",
+		Description:    "An authorization plugin denied the request",
+		HTTPStatusCode: http.StatusForbidden,
+	})
+
+	// ErrorCodeAuthZPluginFailed is generated when an authorization plugin
+	// cannot be reached or reports an error.
+	ErrorCodeAuthZPluginFailed = errcode.Register(errGroup, errcode.ErrorDescriptor{
+		Value:          "AUTHZPLUGINFAILED",
+		Message:        "plugin %s failed with error: %s",
+		Description:    "An authorization plugin failed to authorize the request",
+		HTTPStatusCode: http.StatusInternalServerError,
+	})
+
+	// ErrorCodeRestoring is generated when a request modifying the state of
+	// the daemon is made while it restores its containers in the background.
+	ErrorCodeRestoring = errcode.Register(errGroup, errcode.ErrorDescriptor{
+		Value:          "RESTORING",
+		Message:        "The daemon is restoring its containers, only read-only requests and container creations are accepted",
+		Description:    "The daemon does not accept requests modifying its state until it has restored its containers",
+		HTTPStatusCode: http.StatusServiceUnavailable,
+	})
 )
@@ -4,7 +4,9 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"os"
 	"sync"
+	"time"
 
 	"github.com/Sirupsen/logrus"
 	"github.com/docker/distribution/digest"
@@ -20,6 +22,8 @@ type Store interface {
 	SetParent(id ID, parent ID) error
 	GetParent(id ID) (ID, error)
 	Children(id ID) []ID
+	SetLastUsed(id ID, t time.Time) error
+	GetLastUsed(id ID) (time.Time, error)
 	Map() map[ID]*Image
 	Heads() map[ID]*Image
 }
@@ -243,6 +247,30 @@ func (is *store) GetParent(id ID) (ID, error) {
 	return ID(d), nil // todo: validate?
 }
 
+// SetLastUsed records the last time the image was used by a container.
+func (is *store) SetLastUsed(id ID, t time.Time) error {
+	data, err := t.UTC().MarshalText()
+	if err != nil {
+		return err
+	}
+	return is.fs.SetMetadata(id, "lastUsed", data)
+}
+
+// GetLastUsed returns the last time the image was used by a container, or
+// the zero time if it was never used.
+func (is *store) GetLastUsed(id ID) (time.Time, error) {
+	var t time.Time
+	data, err := is.fs.GetMetadata(id, "lastUsed")
+	if err != nil {
+		if os.IsNotExist(err) {
+			return t, nil
+		}
+		return t, err
+	}
+	err = t.UnmarshalText(data)
+	return t, err
+}
+
 func (is *store) Children(id ID) []ID {
 	is.Lock()
 	defer is.Unlock()
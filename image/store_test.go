@@ -4,6 +4,7 @@ import (
 	"io/ioutil"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/docker/distribution/digest"
 	"github.com/docker/docker/layer"
@@ -233,6 +234,52 @@ func TestSearchAfterDelete(t *testing.T) {
 	}
 }
 
+func TestLastUsed(t *testing.T) {
+	tmpdir, err := ioutil.TempDir("", "images-fs-store")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpdir)
+	fs, err := NewFSStoreBackend(tmpdir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	is, err := NewImageStore(fs, &mockLayerGetReleaser{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	id, err := is.Create([]byte(`{"comment": "abc", "rootfs": {"type": "layers"}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lastUsed, err := is.GetLastUsed(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !lastUsed.IsZero() {
+		t.Fatalf("expected unused image to have no last use, got %v", lastUsed)
+	}
+
+	now := time.Now()
+	if err := is.SetLastUsed(id, now); err != nil {
+		t.Fatal(err)
+	}
+	lastUsed, err = is.GetLastUsed(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !lastUsed.Equal(now) {
+		t.Fatalf("invalid last use of image: expected %v, got %v", now, lastUsed)
+	}
+
+	if err := is.SetLastUsed(ID("sha256:unknown"), now); err == nil {
+		t.Fatal("expected setting the last use of an unknown image to fail")
+	}
+}
+
 type mockLayerGetReleaser struct{}
 
 func (ls *mockLayerGetReleaser) Get(layer.ChainID) (layer.Layer, error) {
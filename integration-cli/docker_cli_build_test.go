@@ -5756,6 +5756,14 @@ func (s *DockerTrustSuite) TestTrustedBuild(c *check.C) {
 	}
 }
 
+func (s *DockerTrustSuite) TestTrustedBuildRemoteContext(c *check.C) {
+	buildCmd := exec.Command(dockerBinary, "build", "--remote-context", "-t", "testtrustedbuildremote", "https://github.com/docker/rootfs.git#container:docker")
+	s.trustedCmd(buildCmd)
+	out, _, err := runCommandWithOutput(buildCmd)
+	c.Assert(err, checker.NotNil, check.Commentf("Expected error on trusted build with a remote context: %s", out))
+	c.Assert(out, checker.Contains, "--remote-context cannot be used with content trust")
+}
+
 func (s *DockerTrustSuite) TestTrustedBuildUntrustedTag(c *check.C) {
 	repoName := fmt.Sprintf("%v/dockercli/build-untrusted-tag:latest", privateRegistryURL)
 	dockerFile := fmt.Sprintf(`
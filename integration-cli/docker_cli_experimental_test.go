@@ -3,6 +3,7 @@
 package main
 
 import (
+	"archive/tar"
 	"fmt"
 	"io/ioutil"
 	"os"
@@ -74,3 +75,37 @@ func (s *DockerDaemonSuite) TestDaemonUserNamespaceRootSetting(c *check.C) {
 	c.Assert(stat.UID(), checker.Equals, uint32(uid), check.Commentf("Touched file not owned by remapped root UID"))
 	c.Assert(stat.GID(), checker.Equals, uint32(gid), check.Commentf("Touched file not owned by remapped root GID"))
 }
+
+// user namespaces test: a container opting out of the remapped root with
+// --userns=host is copied from and exported with the host IDs
+func (s *DockerDaemonSuite) TestDaemonUserNamespaceHostCopyAndExport(c *check.C) {
+	testRequires(c, DaemonIsLinux, SameHostDaemon)
+
+	c.Assert(s.d.StartWithBusybox("--userns-remap", "default"), checker.IsNil)
+
+	out, err := s.d.Cmd("run", "--name", "hostns", "--userns=host", "busybox", "sh", "-c", "echo hello > /hostfile")
+	c.Assert(err, checker.IsNil, check.Commentf("Output: %s", out))
+
+	tmpDir, err := ioutil.TempDir("", "userns-host")
+	c.Assert(err, checker.IsNil)
+	defer os.RemoveAll(tmpDir)
+
+	out, err = s.d.Cmd("cp", "hostns:/hostfile", tmpDir)
+	c.Assert(err, checker.IsNil, check.Commentf("Output: %s", out))
+	content, err := ioutil.ReadFile(filepath.Join(tmpDir, "hostfile"))
+	c.Assert(err, checker.IsNil)
+	c.Assert(string(content), checker.Equals, "hello\n")
+
+	out, err = s.d.Cmd("export", "hostns")
+	c.Assert(err, checker.IsNil, check.Commentf("Could not export the container"))
+	tr := tar.NewReader(strings.NewReader(out))
+	for {
+		hdr, err := tr.Next()
+		c.Assert(err, checker.IsNil, check.Commentf("Expected hostfile in the export"))
+		if strings.TrimPrefix(hdr.Name, "/") == "hostfile" {
+			c.Assert(hdr.Uid, checker.Equals, 0, check.Commentf("Exported file not owned by root"))
+			c.Assert(hdr.Gid, checker.Equals, 0, check.Commentf("Exported file not owned by root"))
+			break
+		}
+	}
+}
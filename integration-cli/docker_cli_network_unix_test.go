@@ -962,3 +962,23 @@ func (s *DockerNetworkSuite) TestDockerNetworkRestartWithMulipleNetworks(c *chec
 	c.Assert(networks, checker.Contains, "bridge", check.Commentf("Should contain 'bridge' network"))
 	c.Assert(networks, checker.Contains, "test", check.Commentf("Should contain 'test' netwokr"))
 }
+
+func (s *DockerNetworkSuite) TestDockerNetworkCreateInternal(c *check.C) {
+	dockerCmd(c, "network", "create", "--internal", "internal")
+	assertNwIsAvailable(c, "internal")
+	nr := getNwResource(c, "internal")
+	c.Assert(nr.Internal, checker.True)
+
+	dockerCmd(c, "run", "-d", "--net=internal", "--name=first", "busybox", "top")
+	c.Assert(waitRun("first"), check.IsNil)
+	dockerCmd(c, "run", "-d", "--net=internal", "--name=second", "busybox", "top")
+	c.Assert(waitRun("second"), check.IsNil)
+
+	// containers on the internal network can reach each other
+	_, _, err := dockerCmdWithError("exec", "first", "ping", "-c", "1", "-W", "1", "second")
+	c.Assert(err, check.IsNil)
+
+	// but not anything outside of it
+	_, _, err = dockerCmdWithError("exec", "first", "ping", "-c", "1", "-W", "1", "8.8.8.8")
+	c.Assert(err, check.NotNil)
+}
@@ -10,6 +10,7 @@ import (
 	"path/filepath"
 	"regexp"
 	"strconv"
+	"strings"
 
 	"github.com/Sirupsen/logrus"
 	"github.com/docker/distribution/digest"
@@ -268,6 +269,29 @@ func (fms *fileMetadataStore) GetMountParent(mount string) (ChainID, error) {
 	return ChainID(dgst), nil
 }
 
+func (fms *fileMetadataStore) SetMountRemoval(mount string, ids []string) error {
+	return ioutil.WriteFile(fms.getMountFilename(mount, "removing"), []byte(strings.Join(ids, "\n")), 0644)
+}
+
+func (fms *fileMetadataStore) GetMountRemoval(mount string) ([]string, error) {
+	content, err := ioutil.ReadFile(fms.getMountFilename(mount, "removing"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var ids []string
+	for _, id := range strings.Split(string(content), "\n") {
+		if !stringIDRegexp.MatchString(id) {
+			return nil, errors.New("invalid removing id value")
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
 func (fms *fileMetadataStore) List() ([]ChainID, []string, error) {
 	var ids []ChainID
 	for _, algorithm := range supportedAlgorithms {
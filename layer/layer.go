@@ -11,6 +11,7 @@ package layer
 
 import (
 	"errors"
+	"fmt"
 	"io"
 
 	"github.com/Sirupsen/logrus"
@@ -51,6 +52,31 @@ var (
 	ErrMaxDepthExceeded = errors.New("max depth exceeded")
 )
 
+// MountError is used when the graph driver fails to mount
+// a read-write layer. It holds the error of the driver.
+type MountError struct {
+	// Driver is the name of the graph driver.
+	Driver string
+
+	// MountID is the ID of the read-write layer in the
+	// graph driver.
+	MountID string
+
+	// MountLabel is the label the layer was mounted with.
+	MountLabel string
+
+	// Chain holds the chain IDs of the layers the read-write
+	// layer was created from, starting with its parent.
+	Chain []ChainID
+
+	// Err is the error returned by the graph driver.
+	Err error
+}
+
+func (e *MountError) Error() string {
+	return fmt.Sprintf("%s failed to mount layer %s: %v", e.Driver, e.MountID, e.Err)
+}
+
 // ChainID is the content-addressable ID of a layer.
 type ChainID digest.Digest
 
@@ -167,7 +193,7 @@ type Store interface {
 	Get(ChainID) (Layer, error)
 	Release(Layer) ([]Metadata, error)
 
-	CreateRWLayer(id string, parent ChainID, mountLabel string, initFunc MountInit) (RWLayer, error)
+	CreateRWLayer(id string, parent ChainID, mountLabel string, initFunc MountInit, storageOpt map[string]string) (RWLayer, error)
 	GetRWLayer(id string) (RWLayer, error)
 	ReleaseRWLayer(RWLayer) ([]Metadata, error)
 
@@ -212,6 +238,11 @@ type MetadataStore interface {
 	GetInitID(string) (string, error)
 	GetMountParent(string) (ChainID, error)
 
+	// SetMountRemoval records the graph IDs of a mount left to remove
+	// by the driver, GetMountRemoval returns them.
+	SetMountRemoval(string, []string) error
+	GetMountRemoval(string) ([]string, error)
+
 	// List returns the full list of referenced
 	// read-only and read-write layers
 	List() ([]ChainID, []string, error)
@@ -33,6 +33,14 @@ type layerStore struct {
 
 	mounts map[string]*mountedLayer
 	mountL sync.Mutex
+
+	// pending are the removals of the read-write layers retried in the
+	// background while retrying, until stop is closed.
+	pending  []*pendingRemoval
+	retrying bool
+	pendingL sync.Mutex
+	stop     chan struct{}
+	stopOnce sync.Once
 }
 
 // StoreOptions are the options used to create a new Store instance
@@ -75,6 +83,7 @@ func NewStoreFromGraphDriver(store MetadataStore, driver graphdriver.Driver) (St
 		driver:   driver,
 		layerMap: map[ChainID]*roLayer{},
 		mounts:   map[string]*mountedLayer{},
+		stop:     make(chan struct{}),
 	}
 
 	ids, mounts, err := store.List()
@@ -169,6 +178,11 @@ func (ls *layerStore) loadMount(mount string) error {
 		return err
 	}
 
+	removal, err := ls.store.GetMountRemoval(mount)
+	if err != nil {
+		return err
+	}
+
 	ml := &mountedLayer{
 		name:       mount,
 		mountID:    mountID,
@@ -187,6 +201,16 @@ func (ls *layerStore) loadMount(mount string) error {
 		p.referenceCount++
 	}
 
+	if len(removal) > 0 {
+		// The removal of the mount was left pending before a restart.
+		ls.addPendingRemoval(&pendingRemoval{
+			name:   mount,
+			ids:    removal,
+			parent: ml.parent,
+		})
+		return nil
+	}
+
 	ls.mounts[ml.name] = ml
 
 	return nil
@@ -413,7 +437,7 @@ func (ls *layerStore) Release(l Layer) ([]Metadata, error) {
 	return ls.releaseLayer(layer)
 }
 
-func (ls *layerStore) CreateRWLayer(name string, parent ChainID, mountLabel string, initFunc MountInit) (RWLayer, error) {
+func (ls *layerStore) CreateRWLayer(name string, parent ChainID, mountLabel string, initFunc MountInit, storageOpt map[string]string) (RWLayer, error) {
 	ls.mountL.Lock()
 	defer ls.mountL.Unlock()
 	m, ok := ls.mounts[name]
@@ -457,7 +481,7 @@ func (ls *layerStore) CreateRWLayer(name string, parent ChainID, mountLabel stri
 		m.initID = pid
 	}
 
-	if err = ls.driver.Create(m.mountID, pid, ""); err != nil {
+	if err = graphdriver.CreateReadWrite(ls.driver, m.mountID, pid, "", storageOpt); err != nil {
 		return nil, err
 	}
 
@@ -495,16 +519,24 @@ func (ls *layerStore) ReleaseRWLayer(l RWLayer) ([]Metadata, error) {
 		return []Metadata{}, nil
 	}
 
-	if err := ls.driver.Remove(m.mountID); err != nil {
-		logrus.Errorf("Error removing mounted layer %s: %s", m.name, err)
-		return nil, err
-	}
-
-	if m.initID != "" {
-		if err := ls.driver.Remove(m.initID); err != nil {
-			logrus.Errorf("Error removing init layer %s: %s", m.name, err)
+	// A layer the driver fails to remove because it is busy does not block
+	// the removal of the container. The graph IDs left to remove are kept
+	// in the mount metadata, and their removal is retried in the
+	// background, also after a restart.
+	pending, err := ls.removeMountIDs(m)
+	if err != nil {
+		if !isBusyError(err) {
+			logrus.Errorf("Error removing mounted layer %s: %s", m.name, err)
 			return nil, err
 		}
+		if err := ls.store.SetMountRemoval(m.name, pending.ids); err != nil {
+			logrus.Errorf("Error saving the pending removal of mounted layer %s: %s", m.name, err)
+			return nil, err
+		}
+		logrus.Warnf("Error removing mounted layer %s, retrying in the background: %s", m.name, err)
+		delete(ls.mounts, m.Name())
+		ls.addPendingRemoval(pending)
+		return []Metadata{}, nil
 	}
 
 	if err := ls.store.RemoveMount(m.name); err != nil {
@@ -613,6 +645,10 @@ func (ls *layerStore) assembleTar(graphID string, metadata io.ReadCloser, size *
 }
 
 func (ls *layerStore) Cleanup() error {
+	ls.stopOnce.Do(func() { close(ls.stop) })
+	if n := ls.pendingRemovals(); n != 0 {
+		logrus.Warnf("%d mounted layers are left to remove", n)
+	}
 	return ls.driver.Cleanup()
 }
 
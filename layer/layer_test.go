@@ -2,6 +2,7 @@ package layer
 
 import (
 	"bytes"
+	"errors"
 	"io"
 	"io/ioutil"
 	"os"
@@ -82,7 +83,7 @@ type layerInit func(root string) error
 
 func createLayer(ls Store, parent ChainID, layerFunc layerInit) (Layer, error) {
 	containerID := stringid.GenerateRandomID()
-	mount, err := ls.CreateRWLayer(containerID, parent, "", nil)
+	mount, err := ls.CreateRWLayer(containerID, parent, "", nil, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -277,7 +278,7 @@ func TestMountAndRegister(t *testing.T) {
 	size, _ := layer.Size()
 	t.Logf("Layer size: %d", size)
 
-	mount2, err := ls.CreateRWLayer("new-test-mount", layer.ChainID(), "", nil)
+	mount2, err := ls.CreateRWLayer("new-test-mount", layer.ChainID(), "", nil, nil)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -377,7 +378,7 @@ func TestStoreRestore(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	m, err := ls.CreateRWLayer("some-mount_name", layer3.ChainID(), "", nil)
+	m, err := ls.CreateRWLayer("some-mount_name", layer3.ChainID(), "", nil, nil)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -411,7 +412,7 @@ func TestStoreRestore(t *testing.T) {
 	assertLayerEqual(t, layer3b, layer3)
 
 	// Create again with same name, should return error
-	if _, err := ls2.CreateRWLayer("some-mount_name", layer3b.ChainID(), "", nil); err == nil {
+	if _, err := ls2.CreateRWLayer("some-mount_name", layer3b.ChainID(), "", nil, nil); err == nil {
 		t.Fatal("Expected error creating mount with same name")
 	} else if err != ErrMountNameConflict {
 		t.Fatal(err)
@@ -771,3 +772,60 @@ func TestLayerSize(t *testing.T) {
 		t.Fatalf("Unexpected size %d, expected %d", layer2Size, expected)
 	}
 }
+
+type failingMountDriver struct {
+	graphdriver.Driver
+	fail bool
+}
+
+func (d *failingMountDriver) Get(id, mountLabel string) (string, error) {
+	if d.fail {
+		return "", errors.New("mount failed")
+	}
+	return d.Driver.Get(id, mountLabel)
+}
+
+func TestMountError(t *testing.T) {
+	td, err := ioutil.TempDir("", "layerstore-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(td)
+	graph, graphcleanup := newTestGraphDriver(t)
+	defer graphcleanup()
+	fms, err := NewFSMetadataStore(td)
+	if err != nil {
+		t.Fatal(err)
+	}
+	driver := &failingMountDriver{Driver: graph}
+	ls, err := NewStoreFromGraphDriver(fms, driver)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	layer1, err := createLayer(ls, "", initWithFiles(newTestFile("testfile.txt", []byte("layer 1"), 0644)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	layer2, err := createLayer(ls, layer1.ChainID(), initWithFiles(newTestFile("testfile.txt", []byte("layer 2"), 0644)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	mount, err := ls.CreateRWLayer("failing-mount", layer2.ChainID(), "", nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	driver.fail = true
+	_, err = mount.Mount("label")
+	mountErr, ok := err.(*MountError)
+	if !ok {
+		t.Fatalf("Expected a mount error, got %v", err)
+	}
+	if mountErr.Driver != "vfs" || mountErr.MountLabel != "label" || mountErr.Err.Error() != "mount failed" {
+		t.Fatalf("Unexpected mount error: %+v", mountErr)
+	}
+	if len(mountErr.Chain) != 2 || mountErr.Chain[0] != layer2.ChainID() || mountErr.Chain[1] != layer1.ChainID() {
+		t.Fatalf("Unexpected layer chain: %v", mountErr.Chain)
+	}
+}
@@ -351,7 +351,7 @@ func TestMountMigration(t *testing.T) {
 
 	assertActivityCount(t, rwLayer1, 1)
 
-	if _, err := ls.CreateRWLayer("migration-mount", layer1.ChainID(), "", nil); err == nil {
+	if _, err := ls.CreateRWLayer("migration-mount", layer1.ChainID(), "", nil, nil); err == nil {
 		t.Fatal("Expected error creating mount with same name")
 	} else if err != ErrMountNameConflict {
 		t.Fatal(err)
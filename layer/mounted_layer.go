@@ -50,7 +50,20 @@ func (ml *mountedLayer) Parent() Layer {
 }
 
 func (ml *mountedLayer) Mount(mountLabel string) (string, error) {
-	return ml.layerStore.driver.Get(ml.mountID, mountLabel)
+	dir, err := ml.layerStore.driver.Get(ml.mountID, mountLabel)
+	if err != nil {
+		mountErr := &MountError{
+			Driver:     ml.layerStore.driver.String(),
+			MountID:    ml.mountID,
+			MountLabel: mountLabel,
+			Err:        err,
+		}
+		for p := ml.parent; p != nil; p = p.parent {
+			mountErr.Chain = append(mountErr.Chain, p.chainID)
+		}
+		return "", mountErr
+	}
+	return dir, nil
 }
 
 func (ml *mountedLayer) Unmount() error {
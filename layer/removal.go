@@ -0,0 +1,142 @@
+package layer
+
+import (
+	"os"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+)
+
+// removalRetryInterval is the interval between the retries of the pending
+// removals.
+var removalRetryInterval = 10 * time.Second
+
+// pendingRemoval is a released read-write layer the graph driver failed to
+// remove because it is busy, retried in the background. The mount metadata
+// is kept, and the parent layer stays referenced, until the layer is
+// removed.
+type pendingRemoval struct {
+	name string
+	// ids are the graph IDs left to remove, the mount first.
+	ids    []string
+	parent *roLayer
+}
+
+// removeMountIDs removes the graph IDs of the mount m from the driver, and
+// returns the pending removal of the IDs left if it fails.
+func (ls *layerStore) removeMountIDs(m *mountedLayer) (*pendingRemoval, error) {
+	p := &pendingRemoval{
+		name:   m.name,
+		ids:    []string{m.mountID},
+		parent: m.parent,
+	}
+	if m.initID != "" {
+		p.ids = append(p.ids, m.initID)
+	}
+	if err := ls.removeIDs(p); err != nil {
+		return p, err
+	}
+	return nil, nil
+}
+
+// removeIDs removes the graph IDs of the pending removal p from the driver,
+// in order, until one fails.
+func (ls *layerStore) removeIDs(p *pendingRemoval) error {
+	for len(p.ids) > 0 {
+		if err := ls.driver.Remove(p.ids[0]); err != nil {
+			return err
+		}
+		p.ids = p.ids[1:]
+	}
+	return nil
+}
+
+// addPendingRemoval records the pending removal p, and starts retrying the
+// pending removals if it is not yet.
+func (ls *layerStore) addPendingRemoval(p *pendingRemoval) {
+	ls.pendingL.Lock()
+	defer ls.pendingL.Unlock()
+	ls.pending = append(ls.pending, p)
+	if !ls.retrying {
+		ls.retrying = true
+		go ls.retryRemovals()
+	}
+}
+
+// retryRemovals retries the pending removals at every interval, until none
+// is left or the store is cleaned up.
+func (ls *layerStore) retryRemovals() {
+	for {
+		select {
+		case <-ls.stop:
+			return
+		case <-time.After(removalRetryInterval):
+		}
+		if ls.processRemovals() == 0 {
+			return
+		}
+	}
+}
+
+// processRemovals retries the pending removals, releasing the parents of the
+// removed layers, and returns the number of removals left.
+func (ls *layerStore) processRemovals() int {
+	ls.pendingL.Lock()
+	defer ls.pendingL.Unlock()
+	var left []*pendingRemoval
+	for _, p := range ls.pending {
+		if err := ls.removeIDs(p); err != nil {
+			logrus.Debugf("Failed to remove the mounted layer %s: %s", p.name, err)
+			left = append(left, p)
+			continue
+		}
+		logrus.Infof("Removed the mounted layer %s", p.name)
+		if err := ls.store.RemoveMount(p.name); err != nil {
+			logrus.Errorf("Error removing mount metadata: %s: %s", p.name, err)
+		}
+		if p.parent == nil {
+			continue
+		}
+		ls.layerL.Lock()
+		removed, err := ls.releaseLayer(p.parent)
+		ls.layerL.Unlock()
+		if err != nil {
+			logrus.Errorf("Error releasing the parent of the mounted layer %s: %s", p.name, err)
+			continue
+		}
+		for _, m := range removed {
+			logrus.Debugf("Removed the layer %s", m.ChainID)
+		}
+	}
+	ls.pending = left
+	if len(left) == 0 {
+		ls.retrying = false
+	}
+	return len(left)
+}
+
+// isBusyError returns whether err is the error of a driver failing to
+// remove a busy mount or device, whose removal may succeed later.
+func isBusyError(err error) bool {
+	switch e := err.(type) {
+	case *os.PathError:
+		err = e.Err
+	case *os.SyscallError:
+		err = e.Err
+	}
+	if err == syscall.EBUSY {
+		return true
+	}
+	// The drivers wrap the errors of the removal in their messages, like
+	// the "Device is Busy" of devicemapper.
+	return strings.Contains(strings.ToLower(err.Error()), "busy")
+}
+
+// pendingRemovals returns the number of pending removals.
+func (ls *layerStore) pendingRemovals() int {
+	ls.pendingL.Lock()
+	defer ls.pendingL.Unlock()
+	return len(ls.pending)
+}
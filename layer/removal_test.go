@@ -0,0 +1,153 @@
+package layer
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"syscall"
+	"testing"
+
+	"github.com/docker/docker/daemon/graphdriver"
+)
+
+type busyRemoveDriver struct {
+	graphdriver.Driver
+	busy   bool
+	broken bool
+}
+
+func (d *busyRemoveDriver) Remove(id string) error {
+	if d.busy {
+		return errors.New("device is busy")
+	}
+	if d.broken {
+		return errors.New("input/output error")
+	}
+	return d.Driver.Remove(id)
+}
+
+func TestReleaseRWLayerPendingRemoval(t *testing.T) {
+	td, err := ioutil.TempDir("", "layerstore-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(td)
+	graph, graphcleanup := newTestGraphDriver(t)
+	defer graphcleanup()
+	fms, err := NewFSMetadataStore(td)
+	if err != nil {
+		t.Fatal(err)
+	}
+	driver := &busyRemoveDriver{Driver: graph}
+	s, err := NewStoreFromGraphDriver(fms, driver)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ls := s.(*layerStore)
+	defer ls.Cleanup()
+
+	layer1, err := createLayer(ls, "", initWithFiles(newTestFile("testfile.txt", []byte("layer 1"), 0644)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	mount, err := ls.CreateRWLayer("busy-mount", layer1.ChainID(), "", nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ls.Release(layer1); err != nil {
+		t.Fatal(err)
+	}
+
+	driver.busy = true
+	metadata, err := ls.ReleaseRWLayer(mount)
+	if err != nil {
+		t.Fatalf("Expected the removal of a busy layer not to fail, got %v", err)
+	}
+	if len(metadata) != 0 {
+		t.Fatalf("Expected no layer to be removed, got %v", metadata)
+	}
+	if _, err := ls.GetRWLayer("busy-mount"); err != ErrMountDoesNotExist {
+		t.Fatalf("Expected the mount to be released, got %v", err)
+	}
+	if n := ls.pendingRemovals(); n != 1 {
+		t.Fatalf("Expected 1 pending removal, got %d", n)
+	}
+	if _, ok := ls.layerMap[layer1.ChainID()]; !ok {
+		t.Fatal("Expected the parent layer to be kept until the removal")
+	}
+	if n := ls.processRemovals(); n != 1 {
+		t.Fatalf("Expected the removal of a busy layer to be left, got %d", n)
+	}
+
+	// The pending removal is resumed by a store loaded after a restart.
+	restarted, err := NewStoreFromGraphDriver(fms, driver)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := restarted.(*layerStore).pendingRemovals(); n != 1 {
+		t.Fatalf("Expected the pending removal to be resumed after a restart, got %d", n)
+	}
+	if _, err := restarted.GetRWLayer("busy-mount"); err != ErrMountDoesNotExist {
+		t.Fatalf("Expected the mount pending removal not to be loaded, got %v", err)
+	}
+	restarted.Cleanup()
+
+	driver.busy = false
+	if n := ls.processRemovals(); n != 0 {
+		t.Fatalf("Expected no pending removal, got %d", n)
+	}
+	if _, ok := ls.layerMap[layer1.ChainID()]; ok {
+		t.Fatal("Expected the parent layer to be removed with the mounted layer")
+	}
+}
+
+func TestReleaseRWLayerRemovalError(t *testing.T) {
+	td, err := ioutil.TempDir("", "layerstore-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(td)
+	graph, graphcleanup := newTestGraphDriver(t)
+	defer graphcleanup()
+	fms, err := NewFSMetadataStore(td)
+	if err != nil {
+		t.Fatal(err)
+	}
+	driver := &busyRemoveDriver{Driver: graph}
+	s, err := NewStoreFromGraphDriver(fms, driver)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ls := s.(*layerStore)
+	defer ls.Cleanup()
+
+	mount, err := ls.CreateRWLayer("broken-mount", "", "", nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	driver.broken = true
+	if _, err := ls.ReleaseRWLayer(mount); err == nil {
+		t.Fatal("Expected the removal error of the driver to be returned")
+	}
+	if n := ls.pendingRemovals(); n != 0 {
+		t.Fatalf("Expected only the busy layers to be removed in the background, got %d pending removals", n)
+	}
+}
+
+func TestIsBusyError(t *testing.T) {
+	for _, err := range []error{
+		syscall.EBUSY,
+		&os.PathError{Op: "remove", Path: "/var/lib/docker/aufs/mnt/abc", Err: syscall.EBUSY},
+		errors.New("Error running removeDevice Device is Busy"),
+	} {
+		if !isBusyError(err) {
+			t.Fatalf("Expected %v to be a busy error", err)
+		}
+	}
+	for _, err := range []error{syscall.EIO, errors.New("no such device")} {
+		if isBusyError(err) {
+			t.Fatalf("Expected %v not to be a busy error", err)
+		}
+	}
+}
@@ -22,6 +22,8 @@ var (
 	// DefaultUnixSocket Path for the unix socket.
 	// Docker daemon by default always listens on the default unix socket
 	DefaultUnixSocket = "/var/run/docker.sock"
+	// DefaultNamedPipe defines the default named pipe used by docker on Windows
+	DefaultNamedPipe = "//./pipe/docker_engine"
 	// DefaultTCPHost constant defines the default host string used by docker on Windows
 	DefaultTCPHost = fmt.Sprintf("tcp://%s:%d", DefaultHTTPHost, DefaultHTTPPort)
 	// DefaultTLSHost constant defines the default host string used by docker for TLS sockets
@@ -73,6 +75,8 @@ func parseDockerDaemonHost(defaultTCPAddr, defaultTLSHost, defaultUnixAddr, defa
 		return parseTCPAddr(addrParts[1], defaultTCPAddr)
 	case "unix":
 		return parseUnixAddr(addrParts[1], defaultUnixAddr)
+	case "npipe":
+		return parseNpipeAddr(addrParts[1], DefaultNamedPipe)
 	case "fd":
 		return addr, nil
 	default:
@@ -95,6 +99,21 @@ func parseUnixAddr(addr string, defaultAddr string) (string, error) {
 	return fmt.Sprintf("unix://%s", addr), nil
 }
 
+// parseNpipeAddr parses and validates that the specified address is a valid
+// Windows named pipe address. It returns a formatted named pipe address, either
+// using the address parsed from addr, or the contents of defaultAddr if addr
+// is a blank string.
+func parseNpipeAddr(addr string, defaultAddr string) (string, error) {
+	addr = strings.TrimPrefix(addr, "npipe://")
+	if strings.Contains(addr, "://") {
+		return "", fmt.Errorf("Invalid proto, expected npipe: %s", addr)
+	}
+	if addr == "" {
+		addr = defaultAddr
+	}
+	return fmt.Sprintf("npipe://%s", addr), nil
+}
+
 // parseTCPAddr parses and validates that the specified address is a valid TCP
 // address. It returns a formatted TCP address, either using the address parsed
 // from tryAddr, or the contents of defaultAddr if tryAddr is a blank string.
@@ -67,9 +67,10 @@ func TestParseDockerDaemonHost(t *testing.T) {
 		"udp://127.0.0.1":               "Invalid bind address format: udp://127.0.0.1",
 		"udp://127.0.0.1:2375":          "Invalid bind address format: udp://127.0.0.1:2375",
 		"tcp://unix:///run/docker.sock": "Invalid bind address format: unix",
-		"tcp":  "Invalid bind address format: tcp",
-		"unix": "Invalid bind address format: unix",
-		"fd":   "Invalid bind address format: fd",
+		"tcp":   "Invalid bind address format: tcp",
+		"unix":  "Invalid bind address format: unix",
+		"fd":    "Invalid bind address format: fd",
+		"npipe": "Invalid bind address format: npipe",
 	}
 	valids := map[string]string{
 		"0.0.0.1:":                    "tcp://0.0.0.1:2375",
@@ -90,6 +91,8 @@ func TestParseDockerDaemonHost(t *testing.T) {
 		" tcp://:7777/path ":      "tcp://localhost:7777/path",
 		"unix:///run/docker.sock": "unix:///run/docker.sock",
 		"unix://":                 "unix:///var/run/docker.sock",
+		"npipe:////./pipe/foo":    "npipe:////./pipe/foo",
+		"npipe://":                "npipe:////./pipe/docker_engine",
 		"fd://":                   "fd://",
 		"fd://something":          "fd://something",
 		"localhost:":              "tcp://localhost:2375",
@@ -162,3 +165,12 @@ func TestParseInvalidUnixAddrInvalid(t *testing.T) {
 		t.Fatalf("Expected an %v, got %v", v, "unix:///var/run/docker.sock")
 	}
 }
+
+func TestParseNpipeAddr(t *testing.T) {
+	if _, err := parseNpipeAddr("npipe://tcp://127.0.0.1", "//./pipe/docker_engine"); err == nil || err.Error() != "Invalid proto, expected npipe: tcp://127.0.0.1" {
+		t.Fatalf("Expected an error, got %v", err)
+	}
+	if v, err := parseNpipeAddr("", "//./pipe/docker_engine"); err != nil || v != "npipe:////./pipe/docker_engine" {
+		t.Fatalf("Expected %v, got %v", "npipe:////./pipe/docker_engine", v)
+	}
+}
@@ -3,8 +3,13 @@
 package archive
 
 import (
+	"archive/tar"
+	"io/ioutil"
 	"os"
+	"path/filepath"
 	"testing"
+
+	"github.com/docker/docker/pkg/idtools"
 )
 
 func TestCanonicalTarNameForPath(t *testing.T) {
@@ -58,3 +63,37 @@ func TestChmodTarEntry(t *testing.T) {
 		}
 	}
 }
+
+func TestTarResourceRebaseWithMaps(t *testing.T) {
+	if os.Getuid() != 0 {
+		t.Skip("Test requires root to chown files")
+	}
+	tmp, err := ioutil.TempDir("", "docker-test-tar-maps")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+
+	src := filepath.Join(tmp, "file")
+	if err := ioutil.WriteFile(src, []byte("content"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Lchown(src, 100001, 100002); err != nil {
+		t.Fatal(err)
+	}
+
+	maps := []idtools.IDMap{{ContainerID: 0, HostID: 100000, Size: 65536}}
+	rdr, err := TarResourceRebaseWithMaps(src, "renamed", maps, maps)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rdr.Close()
+
+	hdr, err := tar.NewReader(rdr).Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hdr.Name != "renamed" || hdr.Uid != 1 || hdr.Gid != 2 {
+		t.Fatalf("Expected renamed owned by 1:2, got %s owned by %d:%d", hdr.Name, hdr.Uid, hdr.Gid)
+	}
+}
@@ -283,3 +283,30 @@ func clen(n []byte) int {
 	}
 	return len(n)
 }
+
+// OverlayChanges walks the upper directory rw of an overlay filesystem and
+// determines the changes for the files it holds, with respect to the lower
+// layers. The deleted files are whiteouts, character devices with the 0/0
+// device number, and the directories replacing the ones of the lower layers
+// are opaque.
+func OverlayChanges(layers []string, rw string) ([]Change, error) {
+	return changes(layers, rw, overlayDeletedFile, nil)
+}
+
+func overlayDeletedFile(root, path string, fi os.FileInfo) (string, error) {
+	if fi.Mode()&os.ModeCharDevice != 0 {
+		if s, ok := fi.Sys().(*syscall.Stat_t); ok && s.Rdev == 0 {
+			return path, nil
+		}
+	}
+	if fi.Mode()&os.ModeDir != 0 {
+		opaque, err := system.Lgetxattr(filepath.Join(root, path), "trusted.overlay.opaque")
+		if err != nil && err != syscall.ENOTSUP {
+			return "", err
+		}
+		if len(opaque) == 1 && opaque[0] == 'y' {
+			return path, nil
+		}
+	}
+	return "", nil
+}
@@ -10,6 +10,7 @@ import (
 	"strings"
 
 	"github.com/Sirupsen/logrus"
+	"github.com/docker/docker/pkg/idtools"
 	"github.com/docker/docker/pkg/system"
 )
 
@@ -95,6 +96,13 @@ func TarResource(sourceInfo CopyInfo) (content Archive, err error) {
 // TarResourceRebase is like TarResource but renames the first path element of
 // items in the resulting tar archive to match the given rebaseName if not "".
 func TarResourceRebase(sourcePath, rebaseName string) (content Archive, err error) {
+	return TarResourceRebaseWithMaps(sourcePath, rebaseName, nil, nil)
+}
+
+// TarResourceRebaseWithMaps is like TarResourceRebase but also maps the
+// owners of the items in the resulting tar archive from host IDs to IDs in
+// the given user namespace mappings.
+func TarResourceRebaseWithMaps(sourcePath, rebaseName string, uidMaps, gidMaps []idtools.IDMap) (content Archive, err error) {
 	sourcePath = normalizePath(sourcePath)
 	if _, err = os.Lstat(sourcePath); err != nil {
 		// Catches the case where the source does not exist or is not a
@@ -118,6 +126,8 @@ func TarResourceRebase(sourcePath, rebaseName string) (content Archive, err erro
 		RebaseNames: map[string]string{
 			sourceBase: rebaseName,
 		},
+		UIDMaps: uidMaps,
+		GIDMaps: gidMaps,
 	})
 }
 
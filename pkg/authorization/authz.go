@@ -2,7 +2,6 @@ package authorization
 
 import (
 	"bytes"
-	"fmt"
 	"io"
 	"io/ioutil"
 	"net/http"
@@ -85,12 +84,8 @@ func (ctx *Ctx) AuthZRequest(w http.ResponseWriter, r *http.Request) error {
 		logrus.Debugf("AuthZ request using plugin %s", plugin.Name())
 
 		authRes, err := plugin.AuthZRequest(ctx.authReq)
-		if err != nil {
-			return fmt.Errorf("plugin %s failed with error: %s", plugin.Name(), err)
-		}
-
-		if !authRes.Allow {
-			return fmt.Errorf("authorization denied by plugin %s: %s", plugin.Name(), authRes.Msg)
+		if err := checkResponse(plugin, authRes, err); err != nil {
+			return err
 		}
 	}
 
@@ -110,12 +105,8 @@ func (ctx *Ctx) AuthZResponse(rm ResponseModifier, r *http.Request) error {
 		logrus.Debugf("AuthZ response using plugin %s", plugin.Name())
 
 		authRes, err := plugin.AuthZResponse(ctx.authReq)
-		if err != nil {
-			return fmt.Errorf("plugin %s failed with error: %s", plugin.Name(), err)
-		}
-
-		if !authRes.Allow {
-			return fmt.Errorf("authorization denied by plugin %s: %s", plugin.Name(), authRes.Msg)
+		if err := checkResponse(plugin, authRes, err); err != nil {
+			return err
 		}
 	}
 
@@ -124,6 +115,21 @@ func (ctx *Ctx) AuthZResponse(rm ResponseModifier, r *http.Request) error {
 	return nil
 }
 
+// checkResponse returns a *PluginError if the plugin failed or reported an
+// error, and a *DeniedError if it did not allow the request.
+func checkResponse(plugin Plugin, authRes *Response, err error) error {
+	if err != nil {
+		return &PluginError{Plugin: plugin.Name(), Err: err.Error()}
+	}
+	if authRes.Err != "" {
+		return &PluginError{Plugin: plugin.Name(), Err: authRes.Err}
+	}
+	if !authRes.Allow {
+		return &DeniedError{Plugin: plugin.Name(), Msg: authRes.Msg}
+	}
+	return nil
+}
+
 // drainBody dump the body, it reads the body data into memory and
 // see go sources /go/src/net/http/httputil/dump.go
 func drainBody(b io.ReadCloser) (io.ReadCloser, io.ReadCloser, error) {
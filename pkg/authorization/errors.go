@@ -0,0 +1,29 @@
+package authorization
+
+import "fmt"
+
+// DeniedError is returned when a plugin denies a request or a response.
+type DeniedError struct {
+	// Plugin is the name of the plugin denying the request.
+	Plugin string
+	// Msg is the reason given by the plugin.
+	Msg string
+}
+
+func (e *DeniedError) Error() string {
+	return fmt.Sprintf("authorization denied by plugin %s: %s", e.Plugin, e.Msg)
+}
+
+// PluginError is returned when a plugin fails to authorize a request or a
+// response, either because it cannot be reached or because it reports an
+// error.
+type PluginError struct {
+	// Plugin is the name of the failing plugin.
+	Plugin string
+	// Err is the error of the plugin.
+	Err string
+}
+
+func (e *PluginError) Error() string {
+	return fmt.Sprintf("plugin %s failed with error: %s", e.Plugin, e.Err)
+}
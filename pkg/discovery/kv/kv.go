@@ -47,6 +47,33 @@ func Init() {
 	discovery.Register("etcd", &Discovery{backend: store.ETCD})
 }
 
+// New returns a discovery for the key-value store at rawurl, in the form
+// scheme://addr[,addr]/prefix with a zk, consul or etcd scheme. Unlike the
+// discoveries created with discovery.New, it is not shared with the other
+// users of the same scheme.
+func New(rawurl string, heartbeat time.Duration, ttl time.Duration, clusterOpts map[string]string) (*Discovery, error) {
+	parts := strings.SplitN(rawurl, "://", 2)
+	if len(parts) != 2 {
+		return nil, fmt.Errorf("invalid key-value store URL %q", rawurl)
+	}
+	var backend store.Backend
+	switch parts[0] {
+	case "zk":
+		backend = store.ZK
+	case "consul":
+		backend = store.CONSUL
+	case "etcd":
+		backend = store.ETCD
+	default:
+		return nil, discovery.ErrNotSupported
+	}
+	s := &Discovery{backend: backend}
+	if err := s.Initialize(parts[1], heartbeat, ttl, clusterOpts); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
 // Initialize is exported
 func (s *Discovery) Initialize(uris string, heartbeat time.Duration, ttl time.Duration, clusterOpts map[string]string) error {
 	var (
@@ -322,3 +322,11 @@ func (s *FakeStore) AtomicDelete(key string, previous *store.KVPair) (bool, erro
 
 func (s *FakeStore) Close() {
 }
+
+func (ds *DiscoverySuite) TestNew(c *check.C) {
+	_, err := New("127.0.0.1:8500", 0, 0, nil)
+	c.Assert(err, check.NotNil)
+
+	_, err = New("nodes://127.0.0.1:8500", 0, 0, nil)
+	c.Assert(err, check.Equals, discovery.ErrNotSupported)
+}
@@ -14,9 +14,26 @@ import (
 	"github.com/docker/docker/pkg/urlutil"
 )
 
+// credentialHelperScript is the git credential helper answering the
+// requests of git with the credentials given in its environment, so that
+// they do not appear in the command line of git.
+const credentialHelperScript = `#!/bin/sh
+test "$1" = get || exit 0
+echo "username=$DOCKER_GIT_USERNAME"
+echo "password=$DOCKER_GIT_PASSWORD"
+`
+
 // Clone clones a repository into a newly created directory which
 // will be under "docker-build-git"
 func Clone(remoteURL string) (string, error) {
+	return CloneWithBasicAuth(remoteURL, "", "")
+}
+
+// CloneWithBasicAuth clones a repository like Clone, authenticating with
+// username and password on HTTP transports unless both are empty. The
+// credentials are only given to the host of remoteURL: the submodules
+// hosted elsewhere are cloned without them.
+func CloneWithBasicAuth(remoteURL, username, password string) (string, error) {
 	if !urlutil.IsGitTransport(remoteURL) {
 		remoteURL = "https://" + remoteURL
 	}
@@ -30,24 +47,50 @@ func Clone(remoteURL string) (string, error) {
 		return "", err
 	}
 
+	var (
+		env    []string
+		config []string
+	)
+	if username != "" || password != "" {
+		helper, err := writeCredentialHelper()
+		if err != nil {
+			return "", err
+		}
+		defer os.RemoveAll(filepath.Dir(helper))
+		env = []string{
+			"GIT_TERMINAL_PROMPT=0",
+			"DOCKER_GIT_USERNAME=" + username,
+			"DOCKER_GIT_PASSWORD=" + password,
+		}
+		config = []string{"-c", credentialHelperConfig(u, helper)}
+	}
+
 	fragment := u.Fragment
-	clone := cloneArgs(u, root)
+	clone := append(config, cloneArgs(u, root, username, password)...)
 
-	if output, err := git(clone...); err != nil {
+	if output, err := gitWithEnv(env, clone...); err != nil {
 		return "", fmt.Errorf("Error trying to use git: %s (%s)", err, output)
 	}
 
 	return checkoutGit(fragment, root)
 }
 
-func cloneArgs(remoteURL *url.URL, root string) []string {
+func cloneArgs(remoteURL *url.URL, root, username, password string) []string {
 	args := []string{"clone", "--recursive"}
 	shallow := len(remoteURL.Fragment) == 0
 
 	if shallow && strings.HasPrefix(remoteURL.Scheme, "http") {
-		res, err := http.Head(fmt.Sprintf("%s/info/refs?service=git-upload-pack", remoteURL))
-		if err != nil || res.Header.Get("Content-Type") != "application/x-git-upload-pack-advertisement" {
-			shallow = false
+		shallow = false
+		req, err := http.NewRequest("HEAD", fmt.Sprintf("%s/info/refs?service=git-upload-pack", remoteURL), nil)
+		if err == nil {
+			if username != "" || password != "" {
+				req.SetBasicAuth(username, password)
+			}
+			res, err := http.DefaultClient.Do(req)
+			if err == nil {
+				res.Body.Close()
+				shallow = res.Header.Get("Content-Type") == "application/x-git-upload-pack-advertisement"
+			}
 		}
 	}
 
@@ -62,6 +105,14 @@ func cloneArgs(remoteURL *url.URL, root string) []string {
 	return append(args, remoteURL.String(), root)
 }
 
+// credentialHelperConfig returns the git configuration using helper as the
+// credential helper of the host of remoteURL only. The configuration is
+// passed down to the git processes cloning the submodules, which do not
+// get any credentials for the other hosts.
+func credentialHelperConfig(remoteURL *url.URL, helper string) string {
+	return fmt.Sprintf("credential.%s://%s.helper=%s", remoteURL.Scheme, remoteURL.Host, helper)
+}
+
 func checkoutGit(fragment, root string) (string, error) {
 	refAndDir := strings.SplitN(fragment, ":", 2)
 
@@ -96,5 +147,29 @@ func gitWithinDir(dir string, args ...string) ([]byte, error) {
 }
 
 func git(args ...string) ([]byte, error) {
-	return exec.Command("git", args...).CombinedOutput()
+	return gitWithEnv(nil, args...)
+}
+
+// gitWithEnv runs git with env added to the environment of the daemon.
+func gitWithEnv(env []string, args ...string) ([]byte, error) {
+	cmd := exec.Command("git", args...)
+	if len(env) > 0 {
+		cmd.Env = append(os.Environ(), env...)
+	}
+	return cmd.CombinedOutput()
+}
+
+// writeCredentialHelper writes credentialHelperScript in a new temporary
+// directory and returns its path.
+func writeCredentialHelper() (string, error) {
+	dir, err := ioutil.TempDir("", "docker-build-git-credential")
+	if err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, "credential-helper")
+	if err := ioutil.WriteFile(path, []byte(credentialHelperScript), 0700); err != nil {
+		os.RemoveAll(dir)
+		return "", err
+	}
+	return path, nil
 }
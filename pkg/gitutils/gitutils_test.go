@@ -7,8 +7,10 @@ import (
 	"net/http/httptest"
 	"net/url"
 	"os"
+	"os/exec"
 	"path/filepath"
 	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -25,13 +27,43 @@ func TestCloneArgsSmartHttp(t *testing.T) {
 		w.Header().Set("Content-Type", fmt.Sprintf("application/x-%s-advertisement", q))
 	})
 
-	args := cloneArgs(serverURL, "/tmp")
+	args := cloneArgs(serverURL, "/tmp", "", "")
 	exp := []string{"clone", "--recursive", "--depth", "1", gitURL, "/tmp"}
 	if !reflect.DeepEqual(args, exp) {
 		t.Fatalf("Expected %v, got %v", exp, args)
 	}
 }
 
+func TestCloneArgsSmartHttpWithBasicAuth(t *testing.T) {
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	serverURL, _ := url.Parse(server.URL)
+
+	serverURL.Path = "/repo.git"
+	gitURL := serverURL.String()
+
+	mux.HandleFunc("/repo.git/info/refs", func(w http.ResponseWriter, r *http.Request) {
+		if username, password, ok := r.BasicAuth(); !ok || username != "user" || password != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		q := r.URL.Query().Get("service")
+		w.Header().Set("Content-Type", fmt.Sprintf("application/x-%s-advertisement", q))
+	})
+
+	args := cloneArgs(serverURL, "/tmp", "user", "secret")
+	exp := []string{"clone", "--recursive", "--depth", "1", gitURL, "/tmp"}
+	if !reflect.DeepEqual(args, exp) {
+		t.Fatalf("Expected %v, got %v", exp, args)
+	}
+
+	args = cloneArgs(serverURL, "/tmp", "", "")
+	exp = []string{"clone", "--recursive", gitURL, "/tmp"}
+	if !reflect.DeepEqual(args, exp) {
+		t.Fatalf("Expected %v, got %v", exp, args)
+	}
+}
+
 func TestCloneArgsDumbHttp(t *testing.T) {
 	mux := http.NewServeMux()
 	server := httptest.NewServer(mux)
@@ -44,7 +76,7 @@ func TestCloneArgsDumbHttp(t *testing.T) {
 		w.Header().Set("Content-Type", "text/plain")
 	})
 
-	args := cloneArgs(serverURL, "/tmp")
+	args := cloneArgs(serverURL, "/tmp", "", "")
 	exp := []string{"clone", "--recursive", gitURL, "/tmp"}
 	if !reflect.DeepEqual(args, exp) {
 		t.Fatalf("Expected %v, got %v", exp, args)
@@ -53,7 +85,7 @@ func TestCloneArgsDumbHttp(t *testing.T) {
 
 func TestCloneArgsGit(t *testing.T) {
 	u, _ := url.Parse("git://github.com/docker/docker")
-	args := cloneArgs(u, "/tmp")
+	args := cloneArgs(u, "/tmp", "", "")
 	exp := []string{"clone", "--recursive", "--depth", "1", "git://github.com/docker/docker", "/tmp"}
 	if !reflect.DeepEqual(args, exp) {
 		t.Fatalf("Expected %v, got %v", exp, args)
@@ -62,7 +94,7 @@ func TestCloneArgsGit(t *testing.T) {
 
 func TestCloneArgsStripFragment(t *testing.T) {
 	u, _ := url.Parse("git://github.com/docker/docker#test")
-	args := cloneArgs(u, "/tmp")
+	args := cloneArgs(u, "/tmp", "", "")
 	exp := []string{"clone", "--recursive", "git://github.com/docker/docker", "/tmp"}
 	if !reflect.DeepEqual(args, exp) {
 		t.Fatalf("Expected %v, got %v", exp, args)
@@ -184,3 +216,43 @@ func TestCheckoutGit(t *testing.T) {
 		}
 	}
 }
+
+func TestCredentialHelperScopedToHost(t *testing.T) {
+	helper, err := writeCredentialHelper()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(filepath.Dir(helper))
+
+	u, _ := url.Parse("https://github.com/docker/docker")
+	config := credentialHelperConfig(u, helper)
+	env := []string{
+		"GIT_TERMINAL_PROMPT=0",
+		"GIT_ASKPASS=",
+		"SSH_ASKPASS=",
+		"DOCKER_GIT_USERNAME=user",
+		"DOCKER_GIT_PASSWORD=secret",
+	}
+	fill := func(input string) ([]byte, error) {
+		cmd := exec.Command("git", "-c", config, "credential", "fill")
+		cmd.Env = append(os.Environ(), env...)
+		cmd.Stdin = strings.NewReader(input)
+		return cmd.CombinedOutput()
+	}
+
+	out, err := fill("protocol=https\nhost=github.com\npath=docker/submodule\n\n")
+	if err != nil {
+		t.Fatalf("Expected the credentials of the host of the remote, got %v (%s)", err, out)
+	}
+	if !strings.Contains(string(out), "username=user\n") || !strings.Contains(string(out), "password=secret\n") {
+		t.Fatalf("Expected the credentials of the host of the remote, got %s", out)
+	}
+
+	out, err = fill("protocol=https\nhost=example.com\n\n")
+	if strings.Contains(string(out), "secret") {
+		t.Fatalf("Expected no credentials for another host, got %s", out)
+	}
+	if err == nil {
+		t.Fatalf("Expected asking the credentials of another host to fail, got %s", out)
+	}
+}
@@ -4,12 +4,49 @@ package graphdb
 
 import "database/sql"
 
-// NewSqliteConn opens a connection to a sqlite
-// database.
-func NewSqliteConn(root string) (*Database, error) {
-	conn, err := sql.Open("sqlite3", root)
+// MigrateSqlite imports the entities and the edges of the sqlite graph
+// database at sqlitePath, used by the previous versions, into db.
+func MigrateSqlite(sqlitePath string, db *Database) error {
+	conn, err := sql.Open("sqlite3", sqlitePath)
 	if err != nil {
-		return nil, err
+		return err
 	}
-	return NewDatabase(conn)
+	defer conn.Close()
+
+	var ids []string
+	rows, err := conn.Query("SELECT id FROM entity WHERE id != ?;", "0")
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return err
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	// The edge of the root entity has no parent
+	var edges Edges
+	rows, err = conn.Query("SELECT entity_id, parent_id, name FROM edge WHERE parent_id IS NOT NULL;")
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		edge := &Edge{}
+		if err := rows.Scan(&edge.EntityID, &edge.ParentID, &edge.Name); err != nil {
+			return err
+		}
+		edges = append(edges, edge)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	return db.importEdges(ids, edges)
 }
@@ -2,7 +2,10 @@
 
 package graphdb
 
-// NewSqliteConn return a new sqlite connection.
-func NewSqliteConn(root string) (*Database, error) {
-	panic("Not implemented")
+import "fmt"
+
+// MigrateSqlite imports the sqlite graph database at sqlitePath into db. It
+// requires cgo, and otherwise fails with the steps to migrate the database.
+func MigrateSqlite(sqlitePath string, db *Database) error {
+	return fmt.Errorf("this daemon is built without sqlite support. Start a daemon built with sqlite support once to migrate them, or remove %s to start without them", sqlitePath)
 }
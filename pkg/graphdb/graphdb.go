@@ -1,32 +1,30 @@
 package graphdb
 
 import (
-	"database/sql"
+	"errors"
 	"fmt"
 	"path"
 	"strings"
 	"sync"
+	"time"
+
+	"github.com/boltdb/bolt"
 )
 
-const (
-	createEntityTable = `
-    CREATE TABLE IF NOT EXISTS entity (
-        id text NOT NULL PRIMARY KEY
-    );`
-
-	createEdgeTable = `
-    CREATE TABLE IF NOT EXISTS edge (
-        "entity_id" text NOT NULL,
-        "parent_id" text NULL,
-        "name" text NOT NULL,
-        CONSTRAINT "parent_fk" FOREIGN KEY ("parent_id") REFERENCES "entity" ("id"),
-        CONSTRAINT "entity_fk" FOREIGN KEY ("entity_id") REFERENCES "entity" ("id")
-        );
-    `
-
-	createEdgeIndices = `
-    CREATE UNIQUE INDEX IF NOT EXISTS "name_parent_ix" ON "edge" (parent_id, name);
-    `
+var (
+	// entitiesBucket holds the ids of the entities.
+	entitiesBucket = []byte("entities")
+	// childrenBucket holds a bucket for each parent id, mapping the names
+	// of the edges to the ids of the child entities.
+	childrenBucket = []byte("children")
+	// parentsBucket holds a bucket for each entity id, with a key for each
+	// edge to the entity: the id of the parent and the name of the edge,
+	// separated by a slash.
+	parentsBucket = []byte("parents")
+
+	// ErrNonUniqueName is returned when a parent has an edge with the
+	// name already.
+	ErrNonUniqueName = errors.New("name is not unique")
 )
 
 // Entity with a unique id.
@@ -52,82 +50,36 @@ type WalkFunc func(fullPath string, entity *Entity) error
 
 // Database is a graph database for storing entities and their relationships.
 type Database struct {
-	conn *sql.DB
+	conn *bolt.DB
 	mux  sync.RWMutex
 }
 
 // IsNonUniqueNameError processes the error to check if it's caused by
-// a constraint violation.
-// This is necessary because the error isn't the same across various
-// sqlite versions.
+// setting a name already used by the parent.
 func IsNonUniqueNameError(err error) bool {
-	str := err.Error()
-	// sqlite 3.7.17-1ubuntu1 returns:
-	// Set failure: Abort due to constraint violation: columns parent_id, name are not unique
-	if strings.HasSuffix(str, "name are not unique") {
-		return true
-	}
-	// sqlite-3.8.3-1.fc20 returns:
-	// Set failure: Abort due to constraint violation: UNIQUE constraint failed: edge.parent_id, edge.name
-	if strings.Contains(str, "UNIQUE constraint failed") && strings.Contains(str, "edge.name") {
-		return true
-	}
-	// sqlite-3.6.20-1.el6 returns:
-	// Set failure: Abort due to constraint violation: constraint failed
-	if strings.HasSuffix(str, "constraint failed") {
-		return true
-	}
-	return false
+	return err == ErrNonUniqueName
 }
 
-// NewDatabase creates a new graph database initialized with a root entity.
-func NewDatabase(conn *sql.DB) (*Database, error) {
-	if conn == nil {
-		return nil, fmt.Errorf("Database connection cannot be nil")
-	}
-	db := &Database{conn: conn}
-
-	// Create root entities
-	tx, err := conn.Begin()
+// NewDatabase opens the graph database in the file at dbPath, creating it
+// if needed.
+func NewDatabase(dbPath string) (*Database, error) {
+	conn, err := bolt.Open(dbPath, 0600, &bolt.Options{Timeout: 10 * time.Second})
 	if err != nil {
 		return nil, err
 	}
-
-	if _, err := tx.Exec(createEntityTable); err != nil {
-		return nil, err
-	}
-	if _, err := tx.Exec(createEdgeTable); err != nil {
-		return nil, err
-	}
-	if _, err := tx.Exec(createEdgeIndices); err != nil {
-		return nil, err
-	}
-
-	if _, err := tx.Exec("DELETE FROM entity where id = ?", "0"); err != nil {
-		tx.Rollback()
-		return nil, err
-	}
-
-	if _, err := tx.Exec("INSERT INTO entity (id) VALUES (?);", "0"); err != nil {
-		tx.Rollback()
-		return nil, err
-	}
-
-	if _, err := tx.Exec("DELETE FROM edge where entity_id=? and name=?", "0", "/"); err != nil {
-		tx.Rollback()
-		return nil, err
-	}
-
-	if _, err := tx.Exec("INSERT INTO edge (entity_id, name) VALUES(?,?);", "0", "/"); err != nil {
-		tx.Rollback()
-		return nil, err
-	}
-
-	if err := tx.Commit(); err != nil {
+	err = conn.Update(func(tx *bolt.Tx) error {
+		for _, b := range [][]byte{entitiesBucket, childrenBucket, parentsBucket} {
+			if _, err := tx.CreateBucketIfNotExists(b); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		conn.Close()
 		return nil, err
 	}
-
-	return db, nil
+	return &Database{conn: conn}, nil
 }
 
 // Close the underlying connection to the database.
@@ -140,32 +92,22 @@ func (db *Database) Set(fullPath, id string) (*Entity, error) {
 	db.mux.Lock()
 	defer db.mux.Unlock()
 
-	tx, err := db.conn.Begin()
-	if err != nil {
-		return nil, err
-	}
-
-	var entityID string
-	if err := tx.QueryRow("SELECT id FROM entity WHERE id = ?;", id).Scan(&entityID); err != nil {
-		if err == sql.ErrNoRows {
-			if _, err := tx.Exec("INSERT INTO entity (id) VALUES(?);", id); err != nil {
-				tx.Rollback()
-				return nil, err
-			}
-		} else {
-			tx.Rollback()
-			return nil, err
-		}
-	}
 	e := &Entity{id}
-
-	parentPath, name := splitPath(fullPath)
-	if err := db.setEdge(parentPath, name, e, tx); err != nil {
-		tx.Rollback()
-		return nil, err
-	}
-
-	if err := tx.Commit(); err != nil {
+	err := db.conn.Update(func(tx *bolt.Tx) error {
+		if err := tx.Bucket(entitiesBucket).Put([]byte(id), nil); err != nil {
+			return err
+		}
+		parentPath, name := splitPath(fullPath)
+		parent, err := get(tx, parentPath)
+		if err != nil {
+			return err
+		}
+		if parent.id == e.id {
+			return fmt.Errorf("Cannot set self as child")
+		}
+		return setEdge(tx, &Edge{EntityID: id, Name: name, ParentID: parent.id})
+	})
+	if err != nil {
 		return nil, err
 	}
 	return e, nil
@@ -173,29 +115,60 @@ func (db *Database) Set(fullPath, id string) (*Entity, error) {
 
 // Exists returns true if a name already exists in the database.
 func (db *Database) Exists(name string) bool {
-	db.mux.RLock()
-	defer db.mux.RUnlock()
-
-	e, err := db.get(name)
-	if err != nil {
-		return false
-	}
-	return e != nil
+	return db.Get(name) != nil
 }
 
-func (db *Database) setEdge(parentPath, name string, e *Entity, tx *sql.Tx) error {
-	parent, err := db.get(parentPath)
+// setEdge adds the edge, failing with ErrNonUniqueName if the parent has an
+// edge with the name already.
+func setEdge(tx *bolt.Tx, edge *Edge) error {
+	children, err := tx.Bucket(childrenBucket).CreateBucketIfNotExists([]byte(edge.ParentID))
 	if err != nil {
 		return err
 	}
-	if parent.id == e.id {
-		return fmt.Errorf("Cannot set self as child")
+	if children.Get([]byte(edge.Name)) != nil {
+		return ErrNonUniqueName
 	}
-
-	if _, err := tx.Exec("INSERT INTO edge (parent_id, name, entity_id) VALUES (?,?,?);", parent.id, name, e.id); err != nil {
+	if err := children.Put([]byte(edge.Name), []byte(edge.EntityID)); err != nil {
 		return err
 	}
-	return nil
+	parents, err := tx.Bucket(parentsBucket).CreateBucketIfNotExists([]byte(edge.EntityID))
+	if err != nil {
+		return err
+	}
+	return parents.Put(parentKey(edge.ParentID, edge.Name), nil)
+}
+
+// deleteEdge removes the edge of the parent with the name, and returns the
+// id of the entity it connected, or an empty string if there is none.
+func deleteEdge(tx *bolt.Tx, parentID, name string) (string, error) {
+	children := tx.Bucket(childrenBucket).Bucket([]byte(parentID))
+	if children == nil {
+		return "", nil
+	}
+	id := children.Get([]byte(name))
+	if id == nil {
+		return "", nil
+	}
+	entityID := string(id)
+	if err := children.Delete([]byte(name)); err != nil {
+		return "", err
+	}
+	if parents := tx.Bucket(parentsBucket).Bucket([]byte(entityID)); parents != nil {
+		if err := parents.Delete(parentKey(parentID, name)); err != nil {
+			return "", err
+		}
+	}
+	return entityID, nil
+}
+
+func parentKey(parentID, name string) []byte {
+	return []byte(parentID + "/" + name)
+}
+
+func splitParentKey(k []byte) (parentID, name string) {
+	s := string(k)
+	i := strings.Index(s, "/")
+	return s[:i], s[i+1:]
 }
 
 // RootEntity returns the root "/" entity for the database.
@@ -210,15 +183,17 @@ func (db *Database) Get(name string) *Entity {
 	db.mux.RLock()
 	defer db.mux.RUnlock()
 
-	e, err := db.get(name)
-	if err != nil {
-		return nil
-	}
+	var e *Entity
+	db.conn.View(func(tx *bolt.Tx) error {
+		var err error
+		e, err = get(tx, name)
+		return err
+	})
 	return e
 }
 
-func (db *Database) get(name string) (*Entity, error) {
-	e := db.RootEntity()
+func get(tx *bolt.Tx, name string) (*Entity, error) {
+	e := &Entity{id: "0"}
 	// We always know the root name so return it if
 	// it is requested
 	if name == "/" {
@@ -232,29 +207,20 @@ func (db *Database) get(name string) (*Entity, error) {
 			continue
 		}
 
-		next := db.child(e, p)
+		next := child(tx, e, p)
 		if next == nil {
 			return nil, fmt.Errorf("Cannot find child for %s", name)
 		}
 		e = next
 	}
 	return e, nil
-
 }
 
 // List all entities by from the name.
 // The key will be the full path of the entity.
 func (db *Database) List(name string, depth int) Entities {
-	db.mux.RLock()
-	defer db.mux.RUnlock()
-
 	out := Entities{}
-	e, err := db.get(name)
-	if err != nil {
-		return out
-	}
-
-	children, err := db.children(e, name, depth, nil)
+	children, err := db.Children(name, depth)
 	if err != nil {
 		return out
 	}
@@ -287,12 +253,16 @@ func (db *Database) Children(name string, depth int) ([]WalkMeta, error) {
 	db.mux.RLock()
 	defer db.mux.RUnlock()
 
-	e, err := db.get(name)
-	if err != nil {
-		return nil, err
-	}
-
-	return db.children(e, name, depth, nil)
+	var entities []WalkMeta
+	err := db.conn.View(func(tx *bolt.Tx) error {
+		e, err := get(tx, name)
+		if err != nil {
+			return err
+		}
+		entities = children(tx, e, name, depth, nil)
+		return nil
+	})
+	return entities, err
 }
 
 // Parents returns the parents of a specified entity.
@@ -300,23 +270,23 @@ func (db *Database) Parents(name string) ([]string, error) {
 	db.mux.RLock()
 	defer db.mux.RUnlock()
 
-	e, err := db.get(name)
-	if err != nil {
-		return nil, err
-	}
-	return db.parents(e)
+	var parents []string
+	err := db.conn.View(func(tx *bolt.Tx) error {
+		e, err := get(tx, name)
+		if err != nil {
+			return err
+		}
+		for _, edge := range refPaths(tx, e.id) {
+			parents = append(parents, edge.ParentID)
+		}
+		return nil
+	})
+	return parents, err
 }
 
 // Refs returns the reference count for a specified id.
 func (db *Database) Refs(id string) int {
-	db.mux.RLock()
-	defer db.mux.RUnlock()
-
-	var count int
-	if err := db.conn.QueryRow("SELECT COUNT(*) FROM edge WHERE entity_id = ?;", id).Scan(&count); err != nil {
-		return 0
-	}
-	return count
+	return len(db.RefPaths(id))
 }
 
 // RefPaths returns all the id's path references.
@@ -325,25 +295,28 @@ func (db *Database) RefPaths(id string) Edges {
 	defer db.mux.RUnlock()
 
 	refs := Edges{}
+	db.conn.View(func(tx *bolt.Tx) error {
+		refs = refPaths(tx, id)
+		return nil
+	})
+	return refs
+}
 
-	rows, err := db.conn.Query("SELECT name, parent_id FROM edge WHERE entity_id = ?;", id)
-	if err != nil {
+func refPaths(tx *bolt.Tx, id string) Edges {
+	refs := Edges{}
+	parents := tx.Bucket(parentsBucket).Bucket([]byte(id))
+	if parents == nil {
 		return refs
 	}
-	defer rows.Close()
-
-	for rows.Next() {
-		var name string
-		var parentID string
-		if err := rows.Scan(&name, &parentID); err != nil {
-			return refs
-		}
+	parents.ForEach(func(k, v []byte) error {
+		parentID, name := splitParentKey(k)
 		refs = append(refs, &Edge{
 			EntityID: id,
 			Name:     name,
 			ParentID: parentID,
 		})
-	}
+		return nil
+	})
 	return refs
 }
 
@@ -356,16 +329,15 @@ func (db *Database) Delete(name string) error {
 		return fmt.Errorf("Cannot delete root entity")
 	}
 
-	parentPath, n := splitPath(name)
-	parent, err := db.get(parentPath)
-	if err != nil {
-		return err
-	}
-
-	if _, err := db.conn.Exec("DELETE FROM edge WHERE parent_id = ? AND name = ?;", parent.id, n); err != nil {
+	return db.conn.Update(func(tx *bolt.Tx) error {
+		parentPath, n := splitPath(name)
+		parent, err := get(tx, parentPath)
+		if err != nil {
+			return err
+		}
+		_, err = deleteEdge(tx, parent.id, n)
 		return err
-	}
-	return nil
+	})
 }
 
 // Purge removes the entity with the specified id
@@ -375,44 +347,46 @@ func (db *Database) Purge(id string) (int, error) {
 	db.mux.Lock()
 	defer db.mux.Unlock()
 
-	tx, err := db.conn.Begin()
-	if err != nil {
-		return -1, err
-	}
+	var count int
+	err := db.conn.Update(func(tx *bolt.Tx) error {
+		// Delete all edges
+		for _, edge := range refPaths(tx, id) {
+			if _, err := deleteEdge(tx, edge.ParentID, edge.Name); err != nil {
+				return err
+			}
+			count++
+		}
 
-	// Delete all edges
-	rows, err := tx.Exec("DELETE FROM edge WHERE entity_id = ?;", id)
-	if err != nil {
-		tx.Rollback()
-		return -1, err
-	}
-	changes, err := rows.RowsAffected()
-	if err != nil {
-		return -1, err
-	}
+		// Clear who's using this id as parent
+		if children := tx.Bucket(childrenBucket).Bucket([]byte(id)); children != nil {
+			var names []string
+			children.ForEach(func(k, v []byte) error {
+				names = append(names, string(k))
+				return nil
+			})
+			for _, name := range names {
+				if _, err := deleteEdge(tx, id, name); err != nil {
+					return err
+				}
+				count++
+			}
+			if err := tx.Bucket(childrenBucket).DeleteBucket([]byte(id)); err != nil {
+				return err
+			}
+		}
+		if tx.Bucket(parentsBucket).Bucket([]byte(id)) != nil {
+			if err := tx.Bucket(parentsBucket).DeleteBucket([]byte(id)); err != nil {
+				return err
+			}
+		}
 
-	// Clear who's using this id as parent
-	refs, err := tx.Exec("DELETE FROM edge WHERE parent_id = ?;", id)
-	if err != nil {
-		tx.Rollback()
-		return -1, err
-	}
-	refsCount, err := refs.RowsAffected()
+		// Delete entity
+		return tx.Bucket(entitiesBucket).Delete([]byte(id))
+	})
 	if err != nil {
 		return -1, err
 	}
-
-	// Delete entity
-	if _, err := tx.Exec("DELETE FROM entity where id = ?;", id); err != nil {
-		tx.Rollback()
-		return -1, err
-	}
-
-	if err := tx.Commit(); err != nil {
-		return -1, err
-	}
-
-	return int(changes + refsCount), nil
+	return count, nil
 }
 
 // Rename an edge for a given path
@@ -427,23 +401,24 @@ func (db *Database) Rename(currentName, newName string) error {
 		return fmt.Errorf("Cannot rename when root paths do not match %s != %s", parentPath, newParentPath)
 	}
 
-	parent, err := db.get(parentPath)
-	if err != nil {
-		return err
-	}
+	return db.conn.Update(func(tx *bolt.Tx) error {
+		parent, err := get(tx, parentPath)
+		if err != nil {
+			return err
+		}
 
-	rows, err := db.conn.Exec("UPDATE edge SET name = ? WHERE parent_id = ? AND name = ?;", newEdgeName, parent.id, name)
-	if err != nil {
-		return err
-	}
-	i, err := rows.RowsAffected()
-	if err != nil {
-		return err
-	}
-	if i == 0 {
-		return fmt.Errorf("Cannot locate edge for %s %s", parent.id, name)
-	}
-	return nil
+		if child(tx, parent, newEdgeName) != nil {
+			return ErrNonUniqueName
+		}
+		id, err := deleteEdge(tx, parent.id, name)
+		if err != nil {
+			return err
+		}
+		if id == "" {
+			return fmt.Errorf("Cannot locate edge for %s %s", parent.id, name)
+		}
+		return setEdge(tx, &Edge{EntityID: id, Name: newEdgeName, ParentID: parent.id})
+	})
 }
 
 // WalkMeta stores the walk metadata.
@@ -454,29 +429,27 @@ type WalkMeta struct {
 	Edge     *Edge
 }
 
-func (db *Database) children(e *Entity, name string, depth int, entities []WalkMeta) ([]WalkMeta, error) {
+func children(tx *bolt.Tx, e *Entity, name string, depth int, entities []WalkMeta) []WalkMeta {
 	if e == nil {
-		return entities, nil
+		return entities
 	}
-
-	rows, err := db.conn.Query("SELECT entity_id, name FROM edge where parent_id = ?;", e.id)
-	if err != nil {
-		return nil, err
+	b := tx.Bucket(childrenBucket).Bucket([]byte(e.id))
+	if b == nil {
+		return entities
 	}
-	defer rows.Close()
 
-	for rows.Next() {
-		var entityID, entityName string
-		if err := rows.Scan(&entityID, &entityName); err != nil {
-			return nil, err
-		}
-		child := &Entity{entityID}
-		edge := &Edge{
+	var edges Edges
+	b.ForEach(func(k, v []byte) error {
+		edges = append(edges, &Edge{
 			ParentID: e.id,
-			Name:     entityName,
-			EntityID: child.id,
-		}
+			Name:     string(k),
+			EntityID: string(v),
+		})
+		return nil
+	})
 
+	for _, edge := range edges {
+		child := &Entity{edge.EntityID}
 		meta := WalkMeta{
 			Parent:   e,
 			Entity:   child,
@@ -491,45 +464,48 @@ func (db *Database) children(e *Entity, name string, depth int, entities []WalkM
 			if depth != -1 {
 				nDepth--
 			}
-			entities, err = db.children(child, meta.FullPath, nDepth, entities)
-			if err != nil {
-				return nil, err
-			}
+			entities = children(tx, child, meta.FullPath, nDepth, entities)
 		}
 	}
 
-	return entities, nil
+	return entities
 }
 
-func (db *Database) parents(e *Entity) (parents []string, err error) {
-	if e == nil {
-		return parents, nil
-	}
-
-	rows, err := db.conn.Query("SELECT parent_id FROM edge where entity_id = ?;", e.id)
-	if err != nil {
-		return nil, err
+// Return the entity based on the parent path and name.
+func child(tx *bolt.Tx, parent *Entity, name string) *Entity {
+	b := tx.Bucket(childrenBucket).Bucket([]byte(parent.id))
+	if b == nil {
+		return nil
 	}
-	defer rows.Close()
-
-	for rows.Next() {
-		var parentID string
-		if err := rows.Scan(&parentID); err != nil {
-			return nil, err
-		}
-		parents = append(parents, parentID)
+	id := b.Get([]byte(name))
+	if id == nil {
+		return nil
 	}
-
-	return parents, nil
+	return &Entity{string(id)}
 }
 
-// Return the entity based on the parent path and name.
-func (db *Database) child(parent *Entity, name string) *Entity {
-	var id string
-	if err := db.conn.QueryRow("SELECT entity_id FROM edge WHERE parent_id = ? AND name = ?;", parent.id, name).Scan(&id); err != nil {
+// importEdges adds the entities and the edges of another database as is,
+// skipping the edges with a name the parent has already.
+func (db *Database) importEdges(ids []string, edges Edges) error {
+	db.mux.Lock()
+	defer db.mux.Unlock()
+
+	return db.conn.Update(func(tx *bolt.Tx) error {
+		for _, id := range ids {
+			if err := tx.Bucket(entitiesBucket).Put([]byte(id), nil); err != nil {
+				return err
+			}
+		}
+		for _, edge := range edges {
+			if err := tx.Bucket(entitiesBucket).Put([]byte(edge.EntityID), nil); err != nil {
+				return err
+			}
+			if err := setEdge(tx, edge); err != nil && err != ErrNonUniqueName {
+				return err
+			}
+		}
 		return nil
-	}
-	return &Entity{id}
+	})
 }
 
 // ID returns the id used to reference this entity.
@@ -3,6 +3,7 @@ package graphdb
 import (
 	"database/sql"
 	"fmt"
+	"io/ioutil"
 	"os"
 	"path"
 	"strconv"
@@ -12,9 +13,12 @@ import (
 )
 
 func newTestDb(t *testing.T) (*Database, string) {
-	p := path.Join(os.TempDir(), "sqlite.db")
-	conn, err := sql.Open("sqlite3", p)
-	db, err := NewDatabase(conn)
+	dir, err := ioutil.TempDir("", "docker-graphdb")
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := path.Join(dir, "links.db")
+	db, err := NewDatabase(p)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -22,7 +26,7 @@ func newTestDb(t *testing.T) (*Database, string) {
 }
 
 func destroyTestDb(dbPath string) {
-	os.Remove(dbPath)
+	os.RemoveAll(path.Dir(dbPath))
 }
 
 func TestNewDatabase(t *testing.T) {
@@ -655,3 +659,38 @@ func TestConcurrentWrites(t *testing.T) {
 		t.Fail()
 	}
 }
+
+func TestMigrateSqlite(t *testing.T) {
+	db, dbpath := newTestDb(t)
+	defer destroyTestDb(dbpath)
+
+	sqlitePath := path.Join(path.Dir(dbpath), "linkgraph.db")
+	conn, err := sql.Open("sqlite3", sqlitePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	for _, stmt := range []string{
+		`CREATE TABLE entity (id text NOT NULL PRIMARY KEY);`,
+		`CREATE TABLE edge ("entity_id" text NOT NULL, "parent_id" text NULL, "name" text NOT NULL);`,
+		`INSERT INTO entity (id) VALUES ("0"), ("1"), ("2");`,
+		`INSERT INTO edge (entity_id, name) VALUES ("0", "/");`,
+		`INSERT INTO edge (parent_id, name, entity_id) VALUES ("0", "webapp", "1"), ("0", "db", "2"), ("1", "db", "2");`,
+	} {
+		if _, err := conn.Exec(stmt); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := MigrateSqlite(sqlitePath, db); err != nil {
+		t.Fatal(err)
+	}
+	for p, id := range map[string]string{"/webapp": "1", "/db": "2", "/webapp/db": "2"} {
+		if e := db.Get(p); e == nil || e.ID() != id {
+			t.Fatalf("Expected %s to be migrated as %s, got %v", p, id, e)
+		}
+	}
+	if c := db.Refs("2"); c != 2 {
+		t.Fatalf("Expected 2 references to the db entity, got %d", c)
+	}
+}
@@ -17,7 +17,20 @@ var (
 
 // Download requests a given URL and returns an io.Reader.
 func Download(url string) (resp *http.Response, err error) {
-	if resp, err = http.Get(url); err != nil {
+	return DownloadWithBasicAuth(url, "", "")
+}
+
+// DownloadWithBasicAuth requests a given URL, authenticating with username
+// and password unless both are empty, and returns an io.Reader.
+func DownloadWithBasicAuth(url, username, password string) (resp *http.Response, err error) {
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+	if username != "" || password != "" {
+		req.SetBasicAuth(username, password)
+	}
+	if resp, err = http.DefaultClient.Do(req); err != nil {
 		return nil, err
 	}
 	if resp.StatusCode >= 400 {
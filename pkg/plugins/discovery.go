@@ -52,6 +52,73 @@ func (l *localRegistry) Plugin(name string) (*Plugin, error) {
 	return nil, ErrNotFound
 }
 
+// Scan returns the names of the plugins found in the plugin directories,
+// whether they have a UNIX socket or a spec file.
+func Scan() ([]string, error) {
+	var names []string
+	seen := make(map[string]bool)
+	add := func(name string) {
+		if !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+
+	socketNames, err := scanDir(socketsPath, ".sock")
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range socketNames {
+		add(name)
+	}
+	for _, p := range specsPaths {
+		specNames, err := scanDir(p, ".spec", ".json")
+		if err != nil {
+			return nil, err
+		}
+		for _, name := range specNames {
+			add(name)
+		}
+	}
+	return names, nil
+}
+
+// scanDir returns the names of the plugins in the directory base, having a
+// file with one of the given extensions at one of the pluginPaths.
+func scanDir(base string, exts ...string) ([]string, error) {
+	fis, err := ioutil.ReadDir(base)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var names []string
+	for _, fi := range fis {
+		for _, ext := range exts {
+			name := strings.TrimSuffix(fi.Name(), ext)
+			if !fi.IsDir() && name == fi.Name() {
+				continue
+			}
+			path := filepath.Join(base, name+ext)
+			if fi.IsDir() {
+				path = filepath.Join(base, fi.Name(), fi.Name()+ext)
+			}
+			pfi, err := os.Stat(path)
+			if err != nil || pfi.IsDir() {
+				continue
+			}
+			if ext == ".sock" && pfi.Mode()&os.ModeSocket == 0 {
+				continue
+			}
+			names = append(names, name)
+			break
+		}
+	}
+	return names, nil
+}
+
 func readPluginInfo(name, path string) (*Plugin, error) {
 	content, err := ioutil.ReadFile(path)
 	if err != nil {
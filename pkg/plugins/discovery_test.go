@@ -167,3 +167,45 @@ func TestFileJSONSpecPlugin(t *testing.T) {
 		t.Fatalf("Expected plugin Key `/usr/shared/docker/certs/example-key.pem`, got %s\n", plugin.TLSConfig.KeyFile)
 	}
 }
+
+func TestScan(t *testing.T) {
+	tmpdir, unregister := setup(t)
+	defer unregister()
+
+	for _, p := range []string{
+		filepath.Join(tmpdir, "echo.sock"),
+		filepath.Join(tmpdir, "foo", "foo.sock"),
+	} {
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		l, err := net.Listen("unix", p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer l.Close()
+	}
+	for _, p := range []string{
+		filepath.Join(tmpdir, "bar.spec"),
+		filepath.Join(tmpdir, "baz", "baz.json"),
+		filepath.Join(tmpdir, "foo.spec"),
+		filepath.Join(tmpdir, "README"),
+		filepath.Join(tmpdir, "qux", "other.spec"),
+	} {
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(p, []byte("tcp://localhost:8080"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	names, err := Scan()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"echo", "foo", "bar", "baz"}
+	if !reflect.DeepEqual(names, expected) {
+		t.Fatalf("Expected plugins %v, got %v", expected, names)
+	}
+}
@@ -108,10 +108,6 @@ func (p *Plugin) activateWithLock() error {
 	return nil
 }
 
-func load(name string) (*Plugin, error) {
-	return loadWithRetry(name, true)
-}
-
 func loadWithRetry(name string, retry bool) (*Plugin, error) {
 	registry := newLocalRegistry()
 	start := time.Now()
@@ -151,13 +147,17 @@ func loadWithRetry(name string, retry bool) (*Plugin, error) {
 }
 
 func get(name string) (*Plugin, error) {
+	return getWithRetry(name, true)
+}
+
+func getWithRetry(name string, retry bool) (*Plugin, error) {
 	storage.Lock()
 	pl, ok := storage.plugins[name]
 	storage.Unlock()
 	if ok {
 		return pl, pl.activate()
 	}
-	return load(name)
+	return loadWithRetry(name, retry)
 }
 
 // Get returns the plugin given the specified name and requested implementation.
@@ -175,6 +175,31 @@ func Get(name, imp string) (*Plugin, error) {
 	return nil, ErrNotImplements
 }
 
+// GetAll returns the plugins found in the plugin directories which implement
+// imp, activating them. The plugins which cannot be activated are skipped.
+func GetAll(imp string) ([]*Plugin, error) {
+	names, err := Scan()
+	if err != nil {
+		return nil, err
+	}
+
+	var pls []*Plugin
+	for _, name := range names {
+		pl, err := getWithRetry(name, false)
+		if err != nil {
+			logrus.Warnf("Unable to activate plugin %s: %v", name, err)
+			continue
+		}
+		for _, driver := range pl.Manifest.Implements {
+			if driver == imp {
+				pls = append(pls, pl)
+				break
+			}
+		}
+	}
+	return pls, nil
+}
+
 // Handle adds the specified function to the extpointHandlers.
 func Handle(iface string, fn func(string, *Client)) {
 	extpointHandlers[iface] = fn
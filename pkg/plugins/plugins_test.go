@@ -0,0 +1,47 @@
+package plugins
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+	"path/filepath"
+	"testing"
+)
+
+func TestGetAll(t *testing.T) {
+	tmpdir, unregister := setup(t)
+	defer unregister()
+
+	for name, implements := range map[string][]string{
+		"volume":  {"VolumeDriver"},
+		"network": {"NetworkDriver"},
+	} {
+		l, err := net.Listen("unix", filepath.Join(tmpdir, name+".sock"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer l.Close()
+
+		m := Manifest{Implements: implements}
+		mux := http.NewServeMux()
+		mux.HandleFunc("/Plugin.Activate", func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", versionMimetype)
+			json.NewEncoder(w).Encode(m)
+		})
+		go http.Serve(l, mux)
+	}
+	defer func() {
+		storage.Lock()
+		delete(storage.plugins, "volume")
+		delete(storage.plugins, "network")
+		storage.Unlock()
+	}()
+
+	pls, err := GetAll("VolumeDriver")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pls) != 1 || pls[0].Name != "volume" {
+		t.Fatalf("Expected the volume plugin only, got %v", pls)
+	}
+}
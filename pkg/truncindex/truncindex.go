@@ -6,10 +6,10 @@ package truncindex
 import (
 	"errors"
 	"fmt"
+	"sort"
 	"strings"
 	"sync"
-
-	"github.com/tchap/go-patricia/patricia"
+	"sync/atomic"
 )
 
 var (
@@ -29,66 +29,93 @@ var (
 
 // TruncIndex allows the retrieval of string identifiers by any of their unique prefixes.
 // This is used to retrieve image and container IDs by more convenient shorthand prefixes.
+//
+// The IDs are kept in a radix tree which is never modified once published:
+// the additions and deletions copy the nodes on the path of the ID and
+// publish the new root, so that the reads do not take any lock.
 type TruncIndex struct {
-	sync.RWMutex
-	trie *patricia.Trie
-	ids  map[string]struct{}
+	// mu serializes the additions and deletions.
+	mu   sync.Mutex
+	root atomic.Value
+}
+
+// node is a node of the radix tree. The ID of a node is the concatenation
+// of the prefixes of the nodes from the root.
+type node struct {
+	prefix string
+	// leaf is set if the ID of the node is in the index.
+	leaf bool
+	// count is the number of IDs in the subtree of the node.
+	count int
+	// children are sorted by the first byte of their prefix, which is
+	// unique among them.
+	children []*node
 }
 
 // NewTruncIndex creates a new TruncIndex and initializes with a list of IDs.
 func NewTruncIndex(ids []string) (idx *TruncIndex) {
-	idx = &TruncIndex{
-		ids: make(map[string]struct{}),
-
-		// Change patricia max prefix per node length,
-		// because our len(ID) always 64
-		trie: patricia.NewTrie(patricia.MaxPrefixPerNode(64)),
-	}
+	idx = &TruncIndex{}
+	root := &node{}
 	for _, id := range ids {
-		idx.addID(id)
+		if validateID(id) == nil {
+			root, _ = root.insert(id)
+		}
 	}
+	idx.root.Store(root)
 	return
 }
 
-func (idx *TruncIndex) addID(id string) error {
+func validateID(id string) error {
 	if strings.Contains(id, " ") {
 		return ErrIllegalChar
 	}
 	if id == "" {
 		return ErrEmptyPrefix
 	}
-	if _, exists := idx.ids[id]; exists {
-		return fmt.Errorf("id already exists: '%s'", id)
-	}
-	idx.ids[id] = struct{}{}
-	if inserted := idx.trie.Insert(patricia.Prefix(id), struct{}{}); !inserted {
-		return fmt.Errorf("failed to insert id: %s", id)
-	}
 	return nil
 }
 
+func (idx *TruncIndex) load() *node {
+	return idx.root.Load().(*node)
+}
+
 // Add adds a new ID to the TruncIndex.
 func (idx *TruncIndex) Add(id string) error {
-	idx.Lock()
-	defer idx.Unlock()
-	if err := idx.addID(id); err != nil {
-		return err
+	return idx.AddBatch([]string{id})
+}
+
+// AddBatch adds the IDs to the TruncIndex at once. None of them is added if
+// one of them is invalid or already exists.
+func (idx *TruncIndex) AddBatch(ids []string) error {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	root := idx.load()
+	for _, id := range ids {
+		if err := validateID(id); err != nil {
+			return err
+		}
+		var inserted bool
+		if root, inserted = root.insert(id); !inserted {
+			return fmt.Errorf("id already exists: '%s'", id)
+		}
 	}
+	idx.root.Store(root)
 	return nil
 }
 
 // Delete removes an ID from the TruncIndex. If there are multiple IDs
 // with the given prefix, an error is thrown.
 func (idx *TruncIndex) Delete(id string) error {
-	idx.Lock()
-	defer idx.Unlock()
-	if _, exists := idx.ids[id]; !exists || id == "" {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	if id == "" {
 		return fmt.Errorf("no such id: '%s'", id)
 	}
-	delete(idx.ids, id)
-	if deleted := idx.trie.Delete(patricia.Prefix(id)); !deleted {
+	root, deleted := idx.load().remove(id)
+	if !deleted {
 		return fmt.Errorf("no such id: '%s'", id)
 	}
+	idx.root.Store(root)
 	return nil
 }
 
@@ -98,34 +125,156 @@ func (idx *TruncIndex) Get(s string) (string, error) {
 	if s == "" {
 		return "", ErrEmptyPrefix
 	}
-	var (
-		id string
-	)
-	subTreeVisitFunc := func(prefix patricia.Prefix, item patricia.Item) error {
-		if id != "" {
-			// we haven't found the ID if there are two or more IDs
-			id = ""
-			return ErrAmbiguousPrefix
+	n, rest := idx.load(), s
+	for {
+		c := n.child(rest[0])
+		if c == nil {
+			return "", ErrNotExist
+		}
+		if len(rest) > len(c.prefix) {
+			if !strings.HasPrefix(rest, c.prefix) {
+				return "", ErrNotExist
+			}
+			n, rest = c, rest[len(c.prefix):]
+			continue
+		}
+		if !strings.HasPrefix(c.prefix, rest) {
+			return "", ErrNotExist
+		}
+		// A full ID is found even if it is the prefix of other IDs
+		if c.leaf && len(rest) == len(c.prefix) {
+			return s, nil
 		}
-		id = string(prefix)
-		return nil
+		if c.count > 1 {
+			return "", ErrAmbiguousPrefix
+		}
+		id := s[:len(s)-len(rest)]
+		for ; !c.leaf; c = c.children[0] {
+			id += c.prefix
+		}
+		return id + c.prefix, nil
 	}
+}
+
+// Iterate iterates over all stored IDs, and passes each of them to the given handler.
+// The IDs are those stored when Iterate is called, so the handler can modify the index.
+func (idx *TruncIndex) Iterate(handler func(id string)) {
+	idx.load().walk("", handler)
+}
 
-	idx.RLock()
-	defer idx.RUnlock()
-	if err := idx.trie.VisitSubtree(patricia.Prefix(s), subTreeVisitFunc); err != nil {
-		return "", err
+func (n *node) walk(id string, handler func(id string)) {
+	id += n.prefix
+	if n.leaf {
+		handler(id)
 	}
-	if id != "" {
-		return id, nil
+	for _, c := range n.children {
+		c.walk(id, handler)
 	}
-	return "", ErrNotExist
 }
 
-// Iterate iterates over all stored IDs, and passes each of them to the given handler.
-func (idx *TruncIndex) Iterate(handler func(id string)) {
-	idx.trie.Visit(func(prefix patricia.Prefix, item patricia.Item) error {
-		handler(string(prefix))
-		return nil
+// child returns the child of n whose prefix starts with b.
+func (n *node) child(b byte) *node {
+	if i := n.childIndex(b); i < len(n.children) && n.children[i].prefix[0] == b {
+		return n.children[i]
+	}
+	return nil
+}
+
+func (n *node) childIndex(b byte) int {
+	return sort.Search(len(n.children), func(i int) bool {
+		return n.children[i].prefix[0] >= b
 	})
 }
+
+// withChild returns a copy of n with the child at i replaced by c, or
+// inserted at i if insert is set.
+func (n *node) withChild(i int, c *node, insert bool) *node {
+	copied := *n
+	if insert {
+		copied.children = make([]*node, 0, len(n.children)+1)
+		copied.children = append(copied.children, n.children[:i]...)
+		copied.children = append(copied.children, c)
+		copied.children = append(copied.children, n.children[i:]...)
+	} else {
+		copied.children = append([]*node(nil), n.children...)
+		copied.children[i] = c
+	}
+	return &copied
+}
+
+// insert returns a copy of n with the key, relative to the ID of n, added,
+// and false if it already exists.
+func (n *node) insert(key string) (*node, bool) {
+	if key == "" {
+		if n.leaf {
+			return n, false
+		}
+		copied := *n
+		copied.leaf = true
+		copied.count++
+		return &copied, true
+	}
+	i := n.childIndex(key[0])
+	if i == len(n.children) || n.children[i].prefix[0] != key[0] {
+		copied := n.withChild(i, &node{prefix: key, leaf: true, count: 1}, true)
+		copied.count++
+		return copied, true
+	}
+	c := n.children[i]
+	common := 0
+	for common < len(c.prefix) && common < len(key) && c.prefix[common] == key[common] {
+		common++
+	}
+	if common < len(c.prefix) {
+		// split the child at the end of the common prefix
+		tail := *c
+		tail.prefix = c.prefix[common:]
+		c = &node{prefix: c.prefix[:common], count: c.count, children: []*node{&tail}}
+	}
+	c, inserted := c.insert(key[common:])
+	if !inserted {
+		return n, false
+	}
+	copied := n.withChild(i, c, false)
+	copied.count++
+	return copied, true
+}
+
+// remove returns a copy of n with the key, relative to the ID of n, removed,
+// and false if it does not exist.
+func (n *node) remove(key string) (*node, bool) {
+	if key == "" {
+		if !n.leaf {
+			return n, false
+		}
+		copied := *n
+		copied.leaf = false
+		copied.count--
+		return &copied, true
+	}
+	i := n.childIndex(key[0])
+	if i == len(n.children) || !strings.HasPrefix(key, n.children[i].prefix) {
+		return n, false
+	}
+	c, removed := n.children[i].remove(key[len(n.children[i].prefix):])
+	if !removed {
+		return n, false
+	}
+	var copied node
+	switch {
+	case c.count == 0:
+		copied = *n
+		copied.children = make([]*node, 0, len(n.children)-1)
+		copied.children = append(copied.children, n.children[:i]...)
+		copied.children = append(copied.children, n.children[i+1:]...)
+	case !c.leaf && len(c.children) == 1:
+		// merge the child with its only child
+		merged := *c.children[0]
+		merged.prefix = c.prefix + merged.prefix
+		copied = *n.withChild(i, &merged, false)
+	default:
+		copied = *n.withChild(i, c, false)
+	}
+	copied.count--
+	return &copied, true
+}
@@ -251,6 +251,35 @@ func BenchmarkTruncIndexGet500(b *testing.B) {
 	}
 }
 
+func BenchmarkTruncIndexGet50000(b *testing.B) {
+	var testSet []string
+	for i := 0; i < 50000; i++ {
+		testSet = append(testSet, stringid.GenerateNonCryptoID())
+	}
+	index := NewTruncIndex(testSet)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		id := testSet[i%len(testSet)]
+		if res, err := index.Get(id[:12]); err != nil {
+			b.Fatal(res, err)
+		}
+	}
+}
+
+func BenchmarkTruncIndexGetFullID50000(b *testing.B) {
+	var testSet []string
+	for i := 0; i < 50000; i++ {
+		testSet = append(testSet, stringid.GenerateNonCryptoID())
+	}
+	index := NewTruncIndex(testSet)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if res, err := index.Get(testSet[i%len(testSet)]); err != nil {
+			b.Fatal(res, err)
+		}
+	}
+}
+
 func BenchmarkTruncIndexDelete100(b *testing.B) {
 	var testSet []string
 	for i := 0; i < 100; i++ {
@@ -427,3 +456,102 @@ func BenchmarkTruncIndexAddGet500(b *testing.B) {
 		}
 	}
 }
+
+func TestTruncIndexAddBatch(t *testing.T) {
+	index := NewTruncIndex(nil)
+	ids := []string{"abcdef", "abc", "abd", "b"}
+	if err := index.AddBatch(ids); err != nil {
+		t.Fatal(err)
+	}
+	// A full ID is found even if it is the prefix of another ID
+	assertIndexGet(t, index, "abc", "abc", false)
+	assertIndexGet(t, index, "abcd", "abcdef", false)
+	assertIndexGet(t, index, "ab", "", true)
+	assertIndexGet(t, index, "abd", "abd", false)
+	assertIndexGet(t, index, "b", "b", false)
+
+	if err := index.AddBatch([]string{"c", "abd"}); err == nil {
+		t.Fatal("Adding an existing id in a batch should return an error")
+	}
+	if err := index.AddBatch([]string{"d", "with space"}); err == nil {
+		t.Fatal("Adding an invalid id in a batch should return an error")
+	}
+	// Nothing is added from a failed batch
+	assertIndexGet(t, index, "c", "", true)
+	assertIndexGet(t, index, "d", "", true)
+
+	if err := index.Delete("abc"); err != nil {
+		t.Fatal(err)
+	}
+	assertIndexGet(t, index, "abc", "abcdef", false)
+	if err := index.Delete("abd"); err != nil {
+		t.Fatal(err)
+	}
+	assertIndexGet(t, index, "a", "abcdef", false)
+	assertIndexGet(t, index, "abd", "", true)
+}
+
+func TestTruncIndexIterateDelete(t *testing.T) {
+	var ids []string
+	for i := 0; i < 100; i++ {
+		ids = append(ids, stringid.GenerateNonCryptoID())
+	}
+	index := NewTruncIndex(ids)
+
+	// The handler can modify the index
+	var iterated int
+	index.Iterate(func(id string) {
+		iterated++
+		if err := index.Delete(id); err != nil {
+			t.Fatal(err)
+		}
+	})
+	if iterated != len(ids) {
+		t.Fatalf("Expected %d IDs to be iterated, got %d", len(ids), iterated)
+	}
+	index.Iterate(func(id string) {
+		t.Fatalf("Expected the index to be empty, got %s", id)
+	})
+	for _, id := range ids {
+		assertIndexGet(t, index, id[:12], "", true)
+	}
+}
+
+func TestTruncIndexRandom(t *testing.T) {
+	index := NewTruncIndex(nil)
+	stored := make(map[string]bool)
+	var ids []string
+	for i := 0; i < 2000; i++ {
+		// short IDs make the prefixes shared
+		id := stringid.GenerateNonCryptoID()[:rand.Intn(4)+1]
+		if stored[id] {
+			if err := index.Delete(id); err != nil {
+				t.Fatal(err)
+			}
+			delete(stored, id)
+			continue
+		}
+		if err := index.Add(id); err != nil {
+			t.Fatal(err)
+		}
+		stored[id] = true
+		ids = append(ids, id)
+	}
+	for _, id := range ids {
+		if stored[id] {
+			assertIndexGet(t, index, id, id, false)
+		} else if res, err := index.Get(id); err == nil && (res == id || !stored[res]) {
+			t.Fatalf("Getting the deleted '%s' returned '%s'", id, res)
+		}
+	}
+	var iterated int
+	index.Iterate(func(id string) {
+		if !stored[id] {
+			t.Fatalf("An unknown ID '%s'", id)
+		}
+		iterated++
+	})
+	if iterated != len(stored) {
+		t.Fatalf("Expected %d IDs to be iterated, got %d", len(stored), iterated)
+	}
+}
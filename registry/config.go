@@ -6,6 +6,7 @@ import (
 	"net"
 	"net/url"
 	"strings"
+	"time"
 
 	registrytypes "github.com/docker/docker/api/types/registry"
 	"github.com/docker/docker/opts"
@@ -15,8 +16,9 @@ import (
 
 // Options holds command line options.
 type Options struct {
-	Mirrors            opts.ListOpts
-	InsecureRegistries opts.ListOpts
+	Mirrors             opts.ListOpts
+	MirrorProbeInterval time.Duration
+	InsecureRegistries  opts.ListOpts
 }
 
 const (
@@ -54,6 +56,7 @@ var (
 func (options *Options) InstallFlags(cmd *flag.FlagSet, usageFn func(string) string) {
 	options.Mirrors = opts.NewListOpts(ValidateMirror)
 	cmd.Var(&options.Mirrors, []string{"-registry-mirror"}, usageFn("Preferred Docker registry mirror"))
+	cmd.DurationVar(&options.MirrorProbeInterval, []string{"-registry-mirror-probe-interval"}, DefaultMirrorProbeInterval, usageFn("Interval between the health probes of the registry mirrors, 0 to always try every mirror"))
 	options.InsecureRegistries = opts.NewListOpts(ValidateIndexName)
 	cmd.Var(&options.InsecureRegistries, []string{"-insecure-registry"}, usageFn("Enable insecure registry communication"))
 	cmd.BoolVar(&V2Only, []string{"-disable-legacy-registry"}, false, "Do not contact legacy registries")
@@ -0,0 +1,106 @@
+package registry
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+)
+
+// DefaultMirrorProbeInterval is the default interval between the health
+// probes of a registry mirror.
+const DefaultMirrorProbeInterval = 30 * time.Second
+
+// mirrorProbeTimeout is how long a registry mirror has to answer a health
+// probe.
+const mirrorProbeTimeout = 5 * time.Second
+
+// mirrorStatus is the last known health of a registry mirror.
+type mirrorStatus struct {
+	healthy bool
+	checked time.Time
+}
+
+// mirrorHealth tracks the health of the registry mirrors, so that the pulls
+// skip the mirrors which are down instead of waiting for them to time out.
+// The mirrors are probed when they are first used, then again once interval
+// has passed since their last check. A zero interval disables the tracking
+// and every mirror is always tried.
+type mirrorHealth struct {
+	sync.Mutex
+	interval time.Duration
+	mirrors  map[string]*mirrorStatus
+	probe    func(mirror string, tlsConfig *tls.Config) error
+}
+
+func newMirrorHealth(interval time.Duration) *mirrorHealth {
+	return &mirrorHealth{
+		interval: interval,
+		mirrors:  make(map[string]*mirrorStatus),
+		probe:    probeMirror,
+	}
+}
+
+// available returns whether mirror should be tried, probing it if its last
+// check is older than the probe interval.
+func (h *mirrorHealth) available(mirror string, tlsConfig *tls.Config) bool {
+	if h == nil || h.interval <= 0 {
+		return true
+	}
+	h.Lock()
+	defer h.Unlock()
+
+	status, ok := h.mirrors[mirror]
+	if ok && time.Since(status.checked) < h.interval {
+		return status.healthy
+	}
+	err := h.probe(mirror, tlsConfig)
+	if err != nil {
+		logrus.Warnf("Registry mirror %s is unavailable: %v", mirror, err)
+	} else if ok && !status.healthy {
+		logrus.Infof("Registry mirror %s is available again", mirror)
+	}
+	h.mirrors[mirror] = &mirrorStatus{healthy: err == nil, checked: time.Now()}
+	return err == nil
+}
+
+// markUnhealthy records that mirror failed, so that it is skipped until it
+// is probed again.
+func (h *mirrorHealth) markUnhealthy(mirror string, err error) {
+	if h == nil || h.interval <= 0 {
+		return
+	}
+	logrus.Warnf("Marking registry mirror %s as unavailable: %v", mirror, err)
+	h.Lock()
+	h.mirrors[mirror] = &mirrorStatus{checked: time.Now()}
+	h.Unlock()
+}
+
+// probeMirror checks that mirror answers on the v2 API endpoint. Any answer
+// but a server error is healthy, as the mirror may require authentication.
+func probeMirror(mirror string, tlsConfig *tls.Config) error {
+	client := &http.Client{
+		Transport: NewTransport(tlsConfig),
+		Timeout:   mirrorProbeTimeout,
+	}
+	resp, err := client.Get(strings.TrimSuffix(mirror, "/") + "/v2/")
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= http.StatusInternalServerError {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return nil
+}
+
+// MarkMirrorUnhealthy records that a pull from the registry mirror failed
+// with err. The mirror is skipped by the following pulls until it answers a
+// health probe again.
+func (s *Service) MarkMirrorUnhealthy(mirror string, err error) {
+	s.mirrors.markUnhealthy(mirror, err)
+}
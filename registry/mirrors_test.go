@@ -0,0 +1,93 @@
+package registry
+
+import (
+	"crypto/tls"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/opts"
+	"github.com/docker/docker/reference"
+)
+
+func TestLookupPullEndpointsSkipsUnhealthyMirrors(t *testing.T) {
+	options := &Options{
+		Mirrors:             opts.NewListOpts(ValidateMirror),
+		InsecureRegistries:  opts.NewListOpts(nil),
+		MirrorProbeInterval: time.Hour,
+	}
+	options.Mirrors.Set("https://mirror-1.com")
+	options.Mirrors.Set("https://mirror-2.com")
+	s := NewService(options)
+
+	down := map[string]bool{"https://mirror-1.com/": true}
+	probes := 0
+	s.mirrors.probe = func(mirror string, tlsConfig *tls.Config) error {
+		probes++
+		if down[mirror] {
+			return errors.New("connection refused")
+		}
+		return nil
+	}
+
+	ref, err := reference.ParseNamed("busybox")
+	if err != nil {
+		t.Fatal(err)
+	}
+	mirrorURLs := func() []string {
+		endpoints, err := s.LookupPullEndpoints(ref)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var urls []string
+		for _, e := range endpoints {
+			if e.Mirror {
+				urls = append(urls, e.URL)
+			}
+		}
+		return urls
+	}
+
+	if urls := mirrorURLs(); len(urls) != 1 || urls[0] != "https://mirror-2.com/" {
+		t.Fatalf("Expected only the healthy mirror, got %v", urls)
+	}
+	if probes != 2 {
+		t.Fatalf("Expected both mirrors to be probed, got %d probes", probes)
+	}
+
+	s.MarkMirrorUnhealthy("https://mirror-2.com/", errors.New("timeout"))
+	if urls := mirrorURLs(); len(urls) != 0 {
+		t.Fatalf("Expected no mirror, got %v", urls)
+	}
+	if probes != 2 {
+		t.Fatalf("Expected the mirrors not to be probed again before the interval, got %d probes", probes)
+	}
+
+	// Once the interval passed, the mirrors are probed again
+	s.mirrors.interval = time.Nanosecond
+	delete(down, "https://mirror-1.com/")
+	if urls := mirrorURLs(); len(urls) != 2 || urls[0] != "https://mirror-1.com/" || urls[1] != "https://mirror-2.com/" {
+		t.Fatalf("Expected both mirrors in order, got %v", urls)
+	}
+}
+
+func TestProbeMirror(t *testing.T) {
+	status := http.StatusUnauthorized
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v2/" {
+			t.Errorf("Unexpected probe of %s", r.URL.Path)
+		}
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	if err := probeMirror(server.URL+"/", nil); err != nil {
+		t.Fatalf("Expected a mirror requiring authentication to be healthy, got %v", err)
+	}
+	status = http.StatusServiceUnavailable
+	if err := probeMirror(server.URL+"/", nil); err == nil {
+		t.Fatal("Expected an error probing an unavailable mirror")
+	}
+}
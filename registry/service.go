@@ -14,14 +14,20 @@ import (
 // Service is a registry service. It tracks configuration data such as a list
 // of mirrors.
 type Service struct {
-	Config *registrytypes.ServiceConfig
+	Config  *registrytypes.ServiceConfig
+	mirrors *mirrorHealth
 }
 
 // NewService returns a new instance of Service ready to be
 // installed into an engine.
 func NewService(options *Options) *Service {
+	probeInterval := DefaultMirrorProbeInterval
+	if options != nil {
+		probeInterval = options.MirrorProbeInterval
+	}
 	return &Service{
-		Config: NewServiceConfig(options),
+		Config:  NewServiceConfig(options),
+		mirrors: newMirrorHealth(probeInterval),
 	}
 }
 
@@ -148,7 +154,8 @@ func (s *Service) tlsConfigForMirror(mirror string) (*tls.Config, error) {
 
 // LookupPullEndpoints creates an list of endpoints to try to pull from, in order of preference.
 // It gives preference to v2 endpoints over v1, mirrors over the actual
-// registry, and HTTPS over plain HTTP.
+// registry, and HTTPS over plain HTTP. The mirrors are tried in the order
+// they are configured, and the unhealthy mirrors are skipped.
 func (s *Service) LookupPullEndpoints(repoName reference.Named) (endpoints []APIEndpoint, err error) {
 	return s.lookupEndpoints(repoName)
 }
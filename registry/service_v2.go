@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/Sirupsen/logrus"
 	"github.com/docker/docker/reference"
 	"github.com/docker/go-connections/tlsconfig"
 )
@@ -19,6 +20,10 @@ func (s *Service) lookupV2Endpoints(repoName reference.Named) (endpoints []APIEn
 			if err != nil {
 				return nil, err
 			}
+			if !s.mirrors.available(mirror, mirrorTLSConfig) {
+				logrus.Debugf("Skipping unhealthy registry mirror %s", mirror)
+				continue
+			}
 			endpoints = append(endpoints, APIEndpoint{
 				URL: mirror,
 				// guess mirrors are v2
@@ -45,7 +45,7 @@ func TestDecodeContainerConfig(t *testing.T) {
 			t.Fatal(err)
 		}
 
-		c, h, err := DecodeContainerConfig(bytes.NewReader(b))
+		c, h, _, err := DecodeContainerConfig(bytes.NewReader(b))
 		if err != nil {
 			t.Fatal(fmt.Errorf("Error parsing %s: %v", f, err))
 		}
@@ -64,6 +64,24 @@ func TestDecodeContainerConfig(t *testing.T) {
 	}
 }
 
+func TestDecodeContainerConfigNetworking(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("User-defined networks are not supported on Windows")
+	}
+	b := []byte(`{"Image": "busybox", "HostConfig": {"NetworkMode": "front"}, "NetworkingConfig": {"EndpointsConfig": {"back": {"Aliases": ["db"], "IPAMConfig": {"IPv4Address": "172.20.0.5"}}}}}`)
+	_, h, n, err := DecodeContainerConfig(bytes.NewReader(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h.NetworkMode != "front" {
+		t.Fatalf("Expected network mode front, got %s", h.NetworkMode)
+	}
+	back := n.EndpointsConfig["back"]
+	if back == nil || len(back.Aliases) != 1 || back.Aliases[0] != "db" || back.IPAMConfig.IPv4Address != "172.20.0.5" {
+		t.Fatalf("Unexpected endpoints config %v", n.EndpointsConfig)
+	}
+}
+
 // TestDecodeContainerConfigIsolation validates the isolation level passed
 // to the daemon in the hostConfig structure. Note this is platform specific
 // as to what level of container isolation is supported.
@@ -116,5 +134,6 @@ func callDecodeContainerConfigIsolation(isolation string) (*container.Config, *c
 	if b, err = json.Marshal(w); err != nil {
 		return nil, nil, fmt.Errorf("Error on marshal %s", err.Error())
 	}
-	return DecodeContainerConfig(bytes.NewReader(b))
+	c, h, _, err := DecodeContainerConfig(bytes.NewReader(b))
+	return c, h, err
 }
@@ -121,6 +121,27 @@ func TestUTSModeTest(t *testing.T) {
 	}
 }
 
+func TestUsernsModeTest(t *testing.T) {
+	usernsModes := map[container.UsernsMode][]bool{
+		// private, host, valid
+		"":                {true, false, true},
+		"something:weird": {true, false, false},
+		"host":            {false, true, true},
+		"host:name":       {true, false, false},
+	}
+	for usernsMode, state := range usernsModes {
+		if usernsMode.IsPrivate() != state[0] {
+			t.Fatalf("UsernsMode.IsPrivate for %v should have been %v but was %v", usernsMode, state[0], usernsMode.IsPrivate())
+		}
+		if usernsMode.IsHost() != state[1] {
+			t.Fatalf("UsernsMode.IsHost for %v should have been %v but was %v", usernsMode, state[1], usernsMode.IsHost())
+		}
+		if usernsMode.Valid() != state[2] {
+			t.Fatalf("UsernsMode.Valid for %v should have been %v but was %v", usernsMode, state[2], usernsMode.Valid())
+		}
+	}
+}
+
 func TestPidModeTest(t *testing.T) {
 	pidModes := map[container.PidMode][]bool{
 		// private, host, valid
@@ -199,3 +220,22 @@ func TestDecodeHostConfig(t *testing.T) {
 		}
 	}
 }
+
+func TestValidateNetModeLinks(t *testing.T) {
+	links := []string{"db:database"}
+	for mode, valid := range map[container.NetworkMode]bool{
+		"default":       true,
+		"bridge":        true,
+		"mynetwork":     true,
+		"host":          false,
+		"container:db2": false,
+	} {
+		err := ValidateNetMode(&container.Config{}, &container.HostConfig{NetworkMode: mode, Links: links})
+		if valid && err != nil {
+			t.Fatalf("Expected links to be valid with network mode %s, got %v", mode, err)
+		}
+		if !valid && err == nil {
+			t.Fatalf("Expected links to be rejected with network mode %s", mode)
+		}
+	}
+}
@@ -77,5 +77,26 @@ func Merge(userConf, imageConf *container.Config) error {
 			userConf.Volumes[k] = v
 		}
 	}
+
+	if userConf.StopTimeout == nil {
+		userConf.StopTimeout = imageConf.StopTimeout
+	}
+
+	if userConf.Healthcheck == nil {
+		userConf.Healthcheck = imageConf.Healthcheck
+	} else if imageConf.Healthcheck != nil {
+		if len(userConf.Healthcheck.Test) == 0 {
+			userConf.Healthcheck.Test = imageConf.Healthcheck.Test
+		}
+		if userConf.Healthcheck.Interval == 0 {
+			userConf.Healthcheck.Interval = imageConf.Healthcheck.Interval
+		}
+		if userConf.Healthcheck.Timeout == 0 {
+			userConf.Healthcheck.Timeout = imageConf.Healthcheck.Timeout
+		}
+		if userConf.Healthcheck.Retries == 0 {
+			userConf.Healthcheck.Retries = imageConf.Healthcheck.Retries
+		}
+	}
 	return nil
 }
@@ -2,6 +2,7 @@ package runconfig
 
 import (
 	"testing"
+	"time"
 
 	"github.com/docker/docker/api/types/container"
 	"github.com/docker/go-connections/nat"
@@ -82,3 +83,54 @@ func TestMerge(t *testing.T) {
 		}
 	}
 }
+
+func TestMergeHealthcheck(t *testing.T) {
+	configImage := &container.Config{
+		Healthcheck: &container.HealthConfig{
+			Test:     []string{"CMD-SHELL", "curl -f http://localhost/"},
+			Interval: 10 * time.Second,
+			Retries:  5,
+		},
+	}
+
+	configUser := &container.Config{}
+	if err := Merge(configUser, configImage); err != nil {
+		t.Fatal(err)
+	}
+	if configUser.Healthcheck == nil || len(configUser.Healthcheck.Test) != 2 {
+		t.Fatalf("Expected the image healthcheck to be inherited, got %+v", configUser.Healthcheck)
+	}
+
+	configUser = &container.Config{
+		Healthcheck: &container.HealthConfig{
+			Interval: time.Second,
+		},
+	}
+	if err := Merge(configUser, configImage); err != nil {
+		t.Fatal(err)
+	}
+	if hc := configUser.Healthcheck; len(hc.Test) != 2 || hc.Interval != time.Second || hc.Retries != 5 {
+		t.Fatalf("Expected the unset healthcheck fields to be inherited, got %+v", hc)
+	}
+}
+
+func TestMergeStopTimeout(t *testing.T) {
+	imageTimeout, userTimeout := 30, 5
+	configImage := &container.Config{StopTimeout: &imageTimeout}
+
+	configUser := &container.Config{}
+	if err := Merge(configUser, configImage); err != nil {
+		t.Fatal(err)
+	}
+	if configUser.StopTimeout == nil || *configUser.StopTimeout != imageTimeout {
+		t.Fatalf("Expected the image stop timeout to be inherited, got %v", configUser.StopTimeout)
+	}
+
+	configUser = &container.Config{StopTimeout: &userTimeout}
+	if err := Merge(configUser, configImage); err != nil {
+		t.Fatal(err)
+	}
+	if *configUser.StopTimeout != userTimeout {
+		t.Fatalf("Expected the stop timeout of the container to be kept, got %d", *configUser.StopTimeout)
+	}
+}
@@ -2,11 +2,13 @@ package opts
 
 import (
 	"fmt"
+	"net"
 	"path"
 	"strconv"
 	"strings"
 
 	"github.com/docker/docker/api/types/container"
+	networktypes "github.com/docker/docker/api/types/network"
 	"github.com/docker/docker/api/types/strslice"
 	"github.com/docker/docker/opts"
 	flag "github.com/docker/docker/pkg/mflag"
@@ -17,9 +19,9 @@ import (
 )
 
 // Parse parses the specified args for the specified command and generates a Config,
-// a HostConfig and returns them with the specified command.
+// a HostConfig and a NetworkingConfig and returns them with the specified command.
 // If the specified args are not valid, it will return an error.
-func Parse(cmd *flag.FlagSet, args []string) (*container.Config, *container.HostConfig, *flag.FlagSet, error) {
+func Parse(cmd *flag.FlagSet, args []string) (*container.Config, *container.HostConfig, *networktypes.NetworkingConfig, *flag.FlagSet, error) {
 	var (
 		// FIXME: use utils.ListOpts for attach and volumes?
 		flAttach            = opts.NewListOpts(opts.ValidateAttach)
@@ -48,12 +50,20 @@ func Parse(cmd *flag.FlagSet, args []string) (*container.Config, *container.Host
 		flCapAdd            = opts.NewListOpts(nil)
 		flCapDrop           = opts.NewListOpts(nil)
 		flGroupAdd          = opts.NewListOpts(nil)
+		flIccAllow          = opts.NewListOpts(nil)
+		flIccDeny           = opts.NewListOpts(nil)
+		flKernelModules     = opts.NewListOpts(nil)
 		flSecurityOpt       = opts.NewListOpts(nil)
+		flStorageOpt        = opts.NewListOpts(nil)
 		flLabelsFile        = opts.NewListOpts(nil)
 		flLoggingOpts       = opts.NewListOpts(nil)
+		flNetworks          = opts.NewListOpts(nil)
+		flAliases           = opts.NewListOpts(nil)
 		flPrivileged        = cmd.Bool([]string{"-privileged"}, false, "Give extended privileges to this container")
+		flPrivilegeProfile  = cmd.String([]string{"-privilege-profile"}, "", "Give all the devices or all the capabilities to this container (devices|capabilities)")
 		flPidMode           = cmd.String([]string{"-pid"}, "", "PID namespace to use")
 		flUTSMode           = cmd.String([]string{"-uts"}, "", "UTS namespace to use")
+		flUsernsMode        = cmd.String([]string{"-userns"}, "", "User namespace to use")
 		flPublishAll        = cmd.Bool([]string{"P", "-publish-all"}, false, "Publish all exposed ports to random ports")
 		flStdin             = cmd.Bool([]string{"i", "-interactive"}, false, "Keep STDIN open even if not attached")
 		flTty               = cmd.Bool([]string{"t", "-tty"}, false, "Allocate a pseudo-TTY")
@@ -75,15 +85,21 @@ func Parse(cmd *flag.FlagSet, args []string) (*container.Config, *container.Host
 		flCpusetMems        = cmd.String([]string{"-cpuset-mems"}, "", "MEMs in which to allow execution (0-3, 0,1)")
 		flBlkioWeight       = cmd.Uint16([]string{"-blkio-weight"}, 0, "Block IO (relative weight), between 10 and 1000")
 		flSwappiness        = cmd.Int64([]string{"-memory-swappiness"}, -1, "Tune container memory swappiness (0 to 100)")
-		flNetMode           = cmd.String([]string{"-net"}, "default", "Connect a container to a network")
+		flIPv4Address       = cmd.String([]string{"-ip"}, "", "Container IPv4 address in the first network (e.g. 172.30.100.104)")
 		flMacAddress        = cmd.String([]string{"-mac-address"}, "", "Container MAC address (e.g. 92:d0:c6:0a:29:33)")
 		flIpcMode           = cmd.String([]string{"-ipc"}, "", "IPC namespace to use")
 		flRestartPolicy     = cmd.String([]string{"-restart"}, "no", "Restart policy to apply when a container exits")
 		flReadonlyRootfs    = cmd.Bool([]string{"-read-only"}, false, "Mount the container's root filesystem as read only")
+		flNoBaselineMounts  = cmd.Bool([]string{"-no-baseline-mounts"}, false, "Do not bind mount the baseline mounts of the daemon")
+		flTimezone          = cmd.String([]string{"-timezone"}, "", "Timezone of the container, e.g. Europe/Paris")
+		flCPUIsolationGroup = cmd.String([]string{"-cpu-isolation-group"}, "", "CPU isolation group to run the container in")
+		flCPUIsolationCores = cmd.Int([]string{"-cpu-isolation-cores"}, 0, "Number of physical cores of the CPU isolation group")
 		flLoggingDriver     = cmd.String([]string{"-log-driver"}, "", "Logging driver for container")
 		flCgroupParent      = cmd.String([]string{"-cgroup-parent"}, "", "Optional parent cgroup for the container")
 		flVolumeDriver      = cmd.String([]string{"-volume-driver"}, "", "Optional volume driver for the container")
 		flStopSignal        = cmd.String([]string{"-stop-signal"}, signal.DefaultStopSignal, fmt.Sprintf("Signal to stop a container, %v by default", signal.DefaultStopSignal))
+		flLinkEnv           = cmd.Bool([]string{"-link-env"}, true, "Inject the environment variables of links on user-defined networks, the daemon default if not set")
+		flStopTimeout       = cmd.Int([]string{"-stop-timeout"}, 0, "Timeout (in seconds) to stop a container, the default timeout of the daemon if not set")
 		flIsolation         = cmd.String([]string{"-isolation"}, "", "Container isolation level")
 		flShmSize           = cmd.String([]string{"-shm-size"}, "", "Size of /dev/shm, default value is 64MB")
 	)
@@ -112,14 +128,25 @@ func Parse(cmd *flag.FlagSet, args []string) (*container.Config, *container.Host
 	cmd.Var(&flCapAdd, []string{"-cap-add"}, "Add Linux capabilities")
 	cmd.Var(&flCapDrop, []string{"-cap-drop"}, "Drop Linux capabilities")
 	cmd.Var(&flGroupAdd, []string{"-group-add"}, "Add additional groups to join")
+	cmd.Var(&flIccAllow, []string{"-icc-allow"}, "Allow communication with containers (name, ID or label=<key>[=<value>]) on networks with ICC disabled")
+	cmd.Var(&flIccDeny, []string{"-icc-deny"}, "Deny communication with containers (name, ID or label=<key>[=<value>]) on networks with ICC enabled")
+	cmd.Var(&flKernelModules, []string{"-kernel-module"}, "Kernel module required by the container")
 	cmd.Var(&flSecurityOpt, []string{"-security-opt"}, "Security Options")
+	cmd.Var(&flStorageOpt, []string{"-storage-opt"}, "Set storage driver options per container")
 	cmd.Var(flUlimits, []string{"-ulimit"}, "Ulimit options")
 	cmd.Var(&flLoggingOpts, []string{"-log-opt"}, "Log driver options")
+	cmd.Var(&flNetworks, []string{"-net"}, "Connect a container to a network, the first one being its network mode")
+	cmd.Var(&flAliases, []string{"-net-alias"}, "Add network-scoped alias for the container in the first network")
 
 	cmd.Require(flag.Min, 1)
 
 	if err := cmd.ParseFlags(args, true); err != nil {
-		return nil, nil, cmd, err
+		return nil, nil, nil, cmd, err
+	}
+
+	networkMode := "default"
+	if flNetworks.Len() > 0 {
+		networkMode = flNetworks.GetAll()[0]
 	}
 
 	var (
@@ -131,7 +158,7 @@ func Parse(cmd *flag.FlagSet, args []string) (*container.Config, *container.Host
 	// Validate the input mac address
 	if *flMacAddress != "" {
 		if _, err := opts.ValidateMACAddress(*flMacAddress); err != nil {
-			return nil, nil, cmd, fmt.Errorf("%s is not a valid mac address", *flMacAddress)
+			return nil, nil, nil, cmd, fmt.Errorf("%s is not a valid mac address", *flMacAddress)
 		}
 	}
 	if *flStdin {
@@ -149,7 +176,7 @@ func Parse(cmd *flag.FlagSet, args []string) (*container.Config, *container.Host
 	if *flMemoryString != "" {
 		flMemory, err = units.RAMInBytes(*flMemoryString)
 		if err != nil {
-			return nil, nil, cmd, err
+			return nil, nil, nil, cmd, err
 		}
 	}
 
@@ -157,7 +184,7 @@ func Parse(cmd *flag.FlagSet, args []string) (*container.Config, *container.Host
 	if *flMemoryReservation != "" {
 		MemoryReservation, err = units.RAMInBytes(*flMemoryReservation)
 		if err != nil {
-			return nil, nil, cmd, err
+			return nil, nil, nil, cmd, err
 		}
 	}
 
@@ -168,7 +195,7 @@ func Parse(cmd *flag.FlagSet, args []string) (*container.Config, *container.Host
 		} else {
 			memorySwap, err = units.RAMInBytes(*flMemorySwap)
 			if err != nil {
-				return nil, nil, cmd, err
+				return nil, nil, nil, cmd, err
 			}
 		}
 	}
@@ -177,20 +204,20 @@ func Parse(cmd *flag.FlagSet, args []string) (*container.Config, *container.Host
 	if *flKernelMemory != "" {
 		KernelMemory, err = units.RAMInBytes(*flKernelMemory)
 		if err != nil {
-			return nil, nil, cmd, err
+			return nil, nil, nil, cmd, err
 		}
 	}
 
 	swappiness := *flSwappiness
 	if swappiness != -1 && (swappiness < 0 || swappiness > 100) {
-		return nil, nil, cmd, fmt.Errorf("Invalid value: %d. Valid memory swappiness range is 0-100", swappiness)
+		return nil, nil, nil, cmd, fmt.Errorf("Invalid value: %d. Valid memory swappiness range is 0-100", swappiness)
 	}
 
 	var parsedShm *int64
 	if *flShmSize != "" {
 		shmSize, err := units.RAMInBytes(*flShmSize)
 		if err != nil {
-			return nil, nil, cmd, err
+			return nil, nil, nil, cmd, err
 		}
 		parsedShm = &shmSize
 	}
@@ -211,7 +238,7 @@ func Parse(cmd *flag.FlagSet, args []string) (*container.Config, *container.Host
 	for _, t := range flTmpfs.GetAll() {
 		if arr := strings.SplitN(t, ":", 2); len(arr) > 1 {
 			if _, _, err := mount.ParseTmpfsOptions(arr[1]); err != nil {
-				return nil, nil, cmd, err
+				return nil, nil, nil, cmd, err
 			}
 			tmpfs[arr[0]] = arr[1]
 		} else {
@@ -244,13 +271,13 @@ func Parse(cmd *flag.FlagSet, args []string) (*container.Config, *container.Host
 
 	ports, portBindings, err := nat.ParsePortSpecs(flPublish.GetAll())
 	if err != nil {
-		return nil, nil, cmd, err
+		return nil, nil, nil, cmd, err
 	}
 
 	// Merge in exposed ports to the map of published ports
 	for _, e := range flExpose.GetAll() {
 		if strings.Contains(e, ":") {
-			return nil, nil, cmd, fmt.Errorf("Invalid port format for --expose: %s", e)
+			return nil, nil, nil, cmd, fmt.Errorf("Invalid port format for --expose: %s", e)
 		}
 		//support two formats for expose, original format <portnum>/[<proto>] or <startport-endport>/[<proto>]
 		proto, port := nat.SplitProtoPort(e)
@@ -258,12 +285,12 @@ func Parse(cmd *flag.FlagSet, args []string) (*container.Config, *container.Host
 		//if expose a port, the start and end port are the same
 		start, end, err := nat.ParsePortRange(port)
 		if err != nil {
-			return nil, nil, cmd, fmt.Errorf("Invalid range format for --expose: %s, error: %s", e, err)
+			return nil, nil, nil, cmd, fmt.Errorf("Invalid range format for --expose: %s, error: %s", e, err)
 		}
 		for i := start; i <= end; i++ {
 			p, err := nat.NewPort(proto, strconv.FormatUint(i, 10))
 			if err != nil {
-				return nil, nil, cmd, err
+				return nil, nil, nil, cmd, err
 			}
 			if _, exists := ports[p]; !exists {
 				ports[p] = struct{}{}
@@ -276,7 +303,7 @@ func Parse(cmd *flag.FlagSet, args []string) (*container.Config, *container.Host
 	for _, device := range flDevices.GetAll() {
 		deviceMapping, err := ParseDevice(device)
 		if err != nil {
-			return nil, nil, cmd, err
+			return nil, nil, nil, cmd, err
 		}
 		deviceMappings = append(deviceMappings, deviceMapping)
 	}
@@ -284,38 +311,53 @@ func Parse(cmd *flag.FlagSet, args []string) (*container.Config, *container.Host
 	// collect all the environment variables for the container
 	envVariables, err := readKVStrings(flEnvFile.GetAll(), flEnv.GetAll())
 	if err != nil {
-		return nil, nil, cmd, err
+		return nil, nil, nil, cmd, err
 	}
 
 	// collect all the labels for the container
 	labels, err := readKVStrings(flLabelsFile.GetAll(), flLabels.GetAll())
 	if err != nil {
-		return nil, nil, cmd, err
+		return nil, nil, nil, cmd, err
 	}
 
 	ipcMode := container.IpcMode(*flIpcMode)
 	if !ipcMode.Valid() {
-		return nil, nil, cmd, fmt.Errorf("--ipc: invalid IPC mode")
+		return nil, nil, nil, cmd, fmt.Errorf("--ipc: invalid IPC mode")
 	}
 
 	pidMode := container.PidMode(*flPidMode)
 	if !pidMode.Valid() {
-		return nil, nil, cmd, fmt.Errorf("--pid: invalid PID mode")
+		return nil, nil, nil, cmd, fmt.Errorf("--pid: invalid PID mode")
 	}
 
 	utsMode := container.UTSMode(*flUTSMode)
 	if !utsMode.Valid() {
-		return nil, nil, cmd, fmt.Errorf("--uts: invalid UTS mode")
+		return nil, nil, nil, cmd, fmt.Errorf("--uts: invalid UTS mode")
+	}
+
+	usernsMode := container.UsernsMode(*flUsernsMode)
+	if !usernsMode.Valid() {
+		return nil, nil, nil, cmd, fmt.Errorf("--userns: invalid USER mode")
+	}
+
+	privilegeProfile := container.PrivilegeProfile(*flPrivilegeProfile)
+	if !privilegeProfile.Valid() {
+		return nil, nil, nil, cmd, fmt.Errorf("--privilege-profile: invalid privilege profile")
 	}
 
 	restartPolicy, err := ParseRestartPolicy(*flRestartPolicy)
 	if err != nil {
-		return nil, nil, cmd, err
+		return nil, nil, nil, cmd, err
 	}
 
 	loggingOpts, err := parseLoggingOpts(*flLoggingDriver, flLoggingOpts.GetAll())
 	if err != nil {
-		return nil, nil, cmd, err
+		return nil, nil, nil, cmd, err
+	}
+
+	storageOpts, err := parseStorageOpts(flStorageOpt.GetAll())
+	if err != nil {
+		return nil, nil, nil, cmd, err
 	}
 
 	resources := container.Resources{
@@ -366,47 +408,105 @@ func Parse(cmd *flag.FlagSet, args []string) (*container.Config, *container.Host
 		StopSignal:      *flStopSignal,
 	}
 
+	if cmd.IsSet("-stop-timeout") {
+		config.StopTimeout = flStopTimeout
+	}
+
 	hostConfig := &container.HostConfig{
-		Binds:           binds,
-		ContainerIDFile: *flContainerIDFile,
-		OomScoreAdj:     *flOomScoreAdj,
-		Privileged:      *flPrivileged,
-		PortBindings:    portBindings,
-		Links:           flLinks.GetAll(),
-		PublishAllPorts: *flPublishAll,
+		Binds:            binds,
+		ContainerIDFile:  *flContainerIDFile,
+		OomScoreAdj:      *flOomScoreAdj,
+		Privileged:       *flPrivileged,
+		PrivilegeProfile: privilegeProfile,
+		PortBindings:     portBindings,
+		Links:            flLinks.GetAll(),
+		PublishAllPorts:  *flPublishAll,
 		// Make sure the dns fields are never nil.
 		// New containers don't ever have those fields nil,
 		// but pre created containers can still have those nil values.
 		// See https://github.com/docker/docker/pull/17779
 		// for a more detailed explanation on why we don't want that.
-		DNS:            flDNS.GetAllOrEmpty(),
-		DNSSearch:      flDNSSearch.GetAllOrEmpty(),
-		DNSOptions:     flDNSOptions.GetAllOrEmpty(),
-		ExtraHosts:     flExtraHosts.GetAll(),
-		VolumesFrom:    flVolumesFrom.GetAll(),
-		NetworkMode:    container.NetworkMode(*flNetMode),
-		IpcMode:        ipcMode,
-		PidMode:        pidMode,
-		UTSMode:        utsMode,
-		CapAdd:         strslice.New(flCapAdd.GetAll()...),
-		CapDrop:        strslice.New(flCapDrop.GetAll()...),
-		GroupAdd:       flGroupAdd.GetAll(),
-		RestartPolicy:  restartPolicy,
-		SecurityOpt:    flSecurityOpt.GetAll(),
-		ReadonlyRootfs: *flReadonlyRootfs,
-		LogConfig:      container.LogConfig{Type: *flLoggingDriver, Config: loggingOpts},
-		VolumeDriver:   *flVolumeDriver,
-		Isolation:      container.IsolationLevel(*flIsolation),
-		ShmSize:        parsedShm,
-		Resources:      resources,
-		Tmpfs:          tmpfs,
+		DNS:               flDNS.GetAllOrEmpty(),
+		DNSSearch:         flDNSSearch.GetAllOrEmpty(),
+		DNSOptions:        flDNSOptions.GetAllOrEmpty(),
+		ExtraHosts:        flExtraHosts.GetAll(),
+		VolumesFrom:       flVolumesFrom.GetAll(),
+		NetworkMode:       container.NetworkMode(networkMode),
+		IpcMode:           ipcMode,
+		PidMode:           pidMode,
+		UTSMode:           utsMode,
+		UsernsMode:        usernsMode,
+		CapAdd:            strslice.New(flCapAdd.GetAll()...),
+		CapDrop:           strslice.New(flCapDrop.GetAll()...),
+		GroupAdd:          flGroupAdd.GetAll(),
+		IccAllow:          flIccAllow.GetAll(),
+		IccDeny:           flIccDeny.GetAll(),
+		KernelModules:     flKernelModules.GetAll(),
+		RestartPolicy:     restartPolicy,
+		SecurityOpt:       flSecurityOpt.GetAll(),
+		StorageOpt:        storageOpts,
+		ReadonlyRootfs:    *flReadonlyRootfs,
+		NoBaselineMounts:  *flNoBaselineMounts,
+		CPUIsolationGroup: *flCPUIsolationGroup,
+		CPUIsolationCores: *flCPUIsolationCores,
+		LogConfig:         container.LogConfig{Type: *flLoggingDriver, Config: loggingOpts},
+		VolumeDriver:      *flVolumeDriver,
+		Isolation:         container.IsolationLevel(*flIsolation),
+		ShmSize:           parsedShm,
+		Resources:         resources,
+		Timezone:          *flTimezone,
+		Tmpfs:             tmpfs,
+	}
+
+	if cmd.IsSet("-link-env") {
+		hostConfig.LinkEnv = flLinkEnv
+	}
+
+	networkingConfig, err := parseNetworkingConfig(flNetworks.GetAll(), flAliases.GetAll(), *flIPv4Address)
+	if err != nil {
+		return nil, nil, nil, cmd, err
 	}
 
 	// When allocating stdin in attached mode, close stdin at client disconnect
 	if config.OpenStdin && config.AttachStdin {
 		config.StdinOnce = true
 	}
-	return config, hostConfig, cmd, nil
+	return config, hostConfig, networkingConfig, cmd, nil
+}
+
+// parseNetworkingConfig returns the endpoints of a container connected to the
+// given networks at creation. The aliases and the IPv4 address apply to the
+// first network, which is the network mode of the container.
+func parseNetworkingConfig(networks, aliases []string, ipv4Address string) (*networktypes.NetworkingConfig, error) {
+	networkingConfig := &networktypes.NetworkingConfig{
+		EndpointsConfig: make(map[string]*networktypes.EndpointSettings),
+	}
+	if len(networks) == 0 {
+		if len(aliases) > 0 || ipv4Address != "" {
+			return nil, fmt.Errorf("--net-alias and --ip require a user-defined network given with --net")
+		}
+		return networkingConfig, nil
+	}
+	if len(networks) == 1 && len(aliases) == 0 && ipv4Address == "" {
+		return networkingConfig, nil
+	}
+
+	for _, n := range networks {
+		if _, exists := networkingConfig.EndpointsConfig[n]; exists {
+			return nil, fmt.Errorf("Network %s is given more than once", n)
+		}
+		networkingConfig.EndpointsConfig[n] = &networktypes.EndpointSettings{}
+	}
+
+	first := networkingConfig.EndpointsConfig[networks[0]]
+	first.Aliases = aliases
+	if ipv4Address != "" {
+		if ip := net.ParseIP(ipv4Address); ip == nil || ip.To4() == nil {
+			return nil, fmt.Errorf("%s is not a valid IPv4 address", ipv4Address)
+		}
+		first.IPAMConfig = &networktypes.EndpointIPAMConfig{IPv4Address: ipv4Address}
+	}
+	return networkingConfig, nil
 }
 
 // reads a file of line terminated key=value pairs and override that with override parameter
@@ -448,6 +548,19 @@ func parseLoggingOpts(loggingDriver string, loggingOpts []string) (map[string]st
 	return loggingOptsMap, nil
 }
 
+// parseStorageOpts parses the key=value storage options of a container.
+func parseStorageOpts(storageOpts []string) (map[string]string, error) {
+	m := make(map[string]string)
+	for _, option := range storageOpts {
+		opt := strings.SplitN(option, "=", 2)
+		if len(opt) != 2 || opt[0] == "" {
+			return nil, fmt.Errorf("Invalid storage option %q: must be key=value", option)
+		}
+		m[opt[0]] = opt[1]
+	}
+	return m, nil
+}
+
 // ParseRestartPolicy returns the parsed policy or an error indicating what is incorrect
 func ParseRestartPolicy(policy string) (container.RestartPolicy, error) {
 	p := container.RestartPolicy{}
@@ -485,7 +598,25 @@ func ParseRestartPolicy(policy string) (container.RestartPolicy, error) {
 		return p, fmt.Errorf("invalid restart policy %s", name)
 	}
 
-	return p, nil
+	return p, ValidateRestartPolicy(p)
+}
+
+// ValidateRestartPolicy checks that policy is one the container monitor
+// knows how to apply. An empty name is valid and means no policy.
+func ValidateRestartPolicy(policy container.RestartPolicy) error {
+	switch policy.Name {
+	case "", "no", "always", "unless-stopped":
+		if policy.MaximumRetryCount != 0 {
+			return fmt.Errorf("maximum restart count not valid with restart policy of \"%s\"", policy.Name)
+		}
+	case "on-failure":
+		if policy.MaximumRetryCount < 0 {
+			return fmt.Errorf("maximum restart count must be a positive integer")
+		}
+	default:
+		return fmt.Errorf("invalid restart policy %s", policy.Name)
+	}
+	return nil
 }
 
 // ParseDevice parses a device mapping string to a container.DeviceMapping struct
@@ -575,7 +706,9 @@ func ValidDeviceMode(mode string) bool {
 
 // ValidateDevice validates a path for devices
 // It will make sure 'val' is in the form:
-//    [host-dir:]container-path[:mode]
+//
+//	[host-dir:]container-path[:mode]
+//
 // It also validates the device mode.
 func ValidateDevice(val string) (string, error) {
 	return validatePath(val, ValidDeviceMode)
@@ -20,7 +20,8 @@ func parseRun(args []string) (*container.Config, *container.HostConfig, *flag.Fl
 	cmd := flag.NewFlagSet("run", flag.ContinueOnError)
 	cmd.SetOutput(ioutil.Discard)
 	cmd.Usage = nil
-	return Parse(cmd, args)
+	config, hostConfig, _, cmd, err := Parse(cmd, args)
+	return config, hostConfig, cmd, err
 }
 
 func parse(t *testing.T, args string) (*container.Config, *container.HostConfig, error) {
@@ -304,7 +305,7 @@ func callDecodeContainerConfig(volumes []string, binds []string) (*container.Con
 	if b, err = json.Marshal(w); err != nil {
 		return nil, nil, fmt.Errorf("Error on marshal %s", err.Error())
 	}
-	c, h, err = runconfig.DecodeContainerConfig(bytes.NewReader(b))
+	c, h, _, err = runconfig.DecodeContainerConfig(bytes.NewReader(b))
 	if err != nil {
 		return nil, nil, fmt.Errorf("Error parsing %s: %v", string(b), err)
 	}
@@ -526,6 +527,18 @@ func TestParseModes(t *testing.T) {
 	if !hostconfig.UTSMode.Valid() {
 		t.Fatalf("Expected a valid UTSMode, got %v", hostconfig.UTSMode)
 	}
+	// privilege-profile ko
+	if _, _, _, err = parseRun([]string{"--privilege-profile=network", "img", "cmd"}); err == nil || err.Error() != "--privilege-profile: invalid privilege profile" {
+		t.Fatalf("Expected an error with message '--privilege-profile: invalid privilege profile', got %v", err)
+	}
+	// privilege-profile ok
+	_, hostconfig, _, err = parseRun([]string{"--privilege-profile=devices", "img", "cmd"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !hostconfig.PrivilegeProfile.AllDevices() || hostconfig.PrivilegeProfile.AllCapabilities() {
+		t.Fatalf("Expected the devices privilege profile, got %v", hostconfig.PrivilegeProfile)
+	}
 	// shm-size ko
 	if _, _, _, err = parseRun([]string{"--shm-size=a128m", "img", "cmd"}); err == nil || err.Error() != "invalid size: 'a128m'" {
 		t.Fatalf("Expected an error with message 'invalid size: a128m', got %v", err)
@@ -545,8 +558,10 @@ func TestParseRestartPolicy(t *testing.T) {
 		"something":          "invalid restart policy something",
 		"always:2":           "maximum restart count not valid with restart policy of \"always\"",
 		"always:2:3":         "maximum restart count not valid with restart policy of \"always\"",
+		"unless-stopped:2":   "maximum restart count not valid with restart policy of \"unless-stopped\"",
 		"on-failure:invalid": `strconv.ParseInt: parsing "invalid": invalid syntax`,
 		"on-failure:2:5":     "restart count format is not valid, usage: 'on-failure:N' or 'on-failure'",
+		"on-failure:-1":      "maximum restart count must be a positive integer",
 	}
 	valids := map[string]container.RestartPolicy{
 		"": {},
@@ -558,6 +573,10 @@ func TestParseRestartPolicy(t *testing.T) {
 			Name:              "on-failure",
 			MaximumRetryCount: 1,
 		},
+		"unless-stopped": {
+			Name:              "unless-stopped",
+			MaximumRetryCount: 0,
+		},
 	}
 	for restart, expectedError := range invalids {
 		if _, _, _, err := parseRun([]string{fmt.Sprintf("--restart=%s", restart), "img", "cmd"}); err == nil || err.Error() != expectedError {
@@ -575,6 +594,64 @@ func TestParseRestartPolicy(t *testing.T) {
 	}
 }
 
+func TestParseStopTimeout(t *testing.T) {
+	config, _ := mustParse(t, "")
+	if config.StopTimeout != nil {
+		t.Fatalf("Expected no stop timeout, got %d", *config.StopTimeout)
+	}
+	config, _ = mustParse(t, "--stop-timeout=30")
+	if config.StopTimeout == nil || *config.StopTimeout != 30 {
+		t.Fatalf("Expected a stop timeout of 30 seconds, got %v", config.StopTimeout)
+	}
+}
+
+func TestParseLinkEnv(t *testing.T) {
+	_, hostconfig := mustParse(t, "")
+	if hostconfig.LinkEnv != nil {
+		t.Fatalf("Expected no link env setting, got %v", *hostconfig.LinkEnv)
+	}
+	_, hostconfig = mustParse(t, "--link-env=false")
+	if hostconfig.LinkEnv == nil || *hostconfig.LinkEnv {
+		t.Fatalf("Expected link env to be disabled, got %v", hostconfig.LinkEnv)
+	}
+}
+
+func TestParseNetworkingConfig(t *testing.T) {
+	cmd := flag.NewFlagSet("run", flag.ContinueOnError)
+	cmd.SetOutput(ioutil.Discard)
+	_, hostConfig, networkingConfig, _, err := Parse(cmd, []string{"--net=front", "--net=back", "--net-alias=web", "--net-alias=www", "--ip=172.20.0.5", "img", "cmd"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hostConfig.NetworkMode != "front" {
+		t.Fatalf("Expected network mode front, got %s", hostConfig.NetworkMode)
+	}
+	if len(networkingConfig.EndpointsConfig) != 2 {
+		t.Fatalf("Expected 2 endpoints, got %v", networkingConfig.EndpointsConfig)
+	}
+	front := networkingConfig.EndpointsConfig["front"]
+	if len(front.Aliases) != 2 || front.IPAMConfig == nil || front.IPAMConfig.IPv4Address != "172.20.0.5" {
+		t.Fatalf("Unexpected endpoint config %+v", front)
+	}
+	if back := networkingConfig.EndpointsConfig["back"]; back == nil || len(back.Aliases) != 0 || back.IPAMConfig != nil {
+		t.Fatalf("Unexpected endpoint config %+v", back)
+	}
+
+	invalids := []string{
+		"--net-alias=web",
+		"--ip=172.20.0.5",
+		"--net=front --ip=300.1.1.1",
+		"--net=front --net=front",
+	}
+	for _, args := range invalids {
+		cmd := flag.NewFlagSet("run", flag.ContinueOnError)
+		cmd.SetOutput(ioutil.Discard)
+		if _, _, _, _, err := Parse(cmd, append(strings.Split(args, " "), "img", "cmd")); err == nil {
+			t.Fatalf("Expected %q to be invalid", args)
+		}
+	}
+}
+
 func TestParseLoggingOpts(t *testing.T) {
 	// logging opts ko
 	if _, _, _, err := parseRun([]string{"--log-driver=none", "--log-opt=anything", "img", "cmd"}); err == nil || err.Error() != "Invalid logging opts for driver none" {
@@ -590,6 +667,19 @@ func TestParseLoggingOpts(t *testing.T) {
 	}
 }
 
+func TestParseStorageOpts(t *testing.T) {
+	if _, _, _, err := parseRun([]string{"--storage-opt=size", "img", "cmd"}); err == nil {
+		t.Fatal("Expected an error for a storage option without a value")
+	}
+	_, hostconfig, _, err := parseRun([]string{"--storage-opt=size=10G", "img", "cmd"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hostconfig.StorageOpt) != 1 || hostconfig.StorageOpt["size"] != "10G" {
+		t.Fatalf("Expected the storage option size=10G, got %v", hostconfig.StorageOpt)
+	}
+}
+
 func TestParseEnvfileVariables(t *testing.T) {
 	e := "open nonexistent: no such file or directory"
 	if runtime.GOOS == "windows" {
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"sync"
 
+	"github.com/Sirupsen/logrus"
 	"github.com/docker/docker/pkg/plugins"
 	"github.com/docker/docker/volume"
 )
@@ -15,6 +16,9 @@ import (
 
 var drivers = &driverExtpoint{extensions: make(map[string]volume.Driver)}
 
+// extName is the name of the subsystem implemented by the volume plugins.
+const extName = "VolumeDriver"
+
 // NewVolumeDriver returns a driver has the given name mapped on the given client.
 func NewVolumeDriver(name string, c client) volume.Driver {
 	proxy := &volumeDriverProxy{c}
@@ -82,7 +86,7 @@ func Lookup(name string) (volume.Driver, error) {
 	if ok {
 		return ext, nil
 	}
-	pl, err := plugins.Get(name, "VolumeDriver")
+	pl, err := plugins.Get(name, extName)
 	if err != nil {
 		return nil, fmt.Errorf("Error looking up volume plugin %s: %v", name, err)
 	}
@@ -98,6 +102,21 @@ func Lookup(name string) (volume.Driver, error) {
 	return d, nil
 }
 
+// Discover registers the volume plugins found in the plugin directories, so
+// that they are listed before they are looked up.
+func Discover() error {
+	pls, err := plugins.GetAll(extName)
+	if err != nil {
+		return fmt.Errorf("Error discovering volume plugins: %v", err)
+	}
+	for _, pl := range pls {
+		if Register(NewVolumeDriver(pl.Name, pl.Client), pl.Name) {
+			logrus.Infof("Registered volume plugin %s", pl.Name)
+		}
+	}
+	return nil
+}
+
 // GetDriver returns a volume driver by it's name.
 // If the driver is empty, it looks for the local driver.
 func GetDriver(name string) (volume.Driver, error) {
@@ -110,6 +129,8 @@ func GetDriver(name string) (volume.Driver, error) {
 // GetDriverList returns list of volume drivers registered.
 // If no driver is registered, empty string list will be returned.
 func GetDriverList() []string {
+	drivers.Lock()
+	defer drivers.Unlock()
 	var driverList []string
 	for driverName := range drivers.extensions {
 		driverList = append(driverList, driverName)
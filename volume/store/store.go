@@ -1,6 +1,13 @@
 package store
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
 	"sync"
 
 	"github.com/Sirupsen/logrus"
@@ -10,12 +17,111 @@ import (
 )
 
 // New initializes a VolumeStore to keep
-// reference counting of volumes in the system.
-func New() *VolumeStore {
-	return &VolumeStore{
-		vols:  make(map[string]*volumeCounter),
-		locks: &locker.Locker{},
+// reference counting of volumes in the system. The metadata of the volumes,
+// such as their labels, is kept in rootPath, and loaded from it, unless
+// rootPath is empty.
+func New(rootPath string) (*VolumeStore, error) {
+	s := &VolumeStore{
+		vols:     make(map[string]*volumeCounter),
+		labels:   make(map[string]map[string]string),
+		locks:    &locker.Locker{},
+		rootPath: rootPath,
 	}
+	if rootPath == "" {
+		return s, nil
+	}
+	if err := os.MkdirAll(rootPath, 0700); err != nil {
+		return nil, err
+	}
+	if err := s.loadMetadata(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// volumeMetadata is the metadata of a volume kept by the store, which is
+// not passed to its driver.
+type volumeMetadata struct {
+	Name   string
+	Labels map[string]string `json:",omitempty"`
+}
+
+// loadMetadata reads the metadata file of each volume in the root of the
+// store. The files which cannot be read are skipped.
+func (s *VolumeStore) loadMetadata() error {
+	files, err := ioutil.ReadDir(s.rootPath)
+	if err != nil {
+		return err
+	}
+	for _, f := range files {
+		if f.IsDir() || !strings.HasSuffix(f.Name(), ".json") {
+			continue
+		}
+		b, err := ioutil.ReadFile(filepath.Join(s.rootPath, f.Name()))
+		if err != nil {
+			logrus.Warnf("Error reading volume metadata %s: %v", f.Name(), err)
+			continue
+		}
+		var meta volumeMetadata
+		if err := json.Unmarshal(b, &meta); err != nil {
+			logrus.Warnf("Error reading volume metadata %s: %v", f.Name(), err)
+			continue
+		}
+		if meta.Name != "" && len(meta.Labels) > 0 {
+			s.labels[meta.Name] = meta.Labels
+		}
+	}
+	return nil
+}
+
+// metadataPath returns the path of the metadata file of the volume name.
+// The file is named after a hash of the name, which the drivers may accept
+// with path separators.
+func (s *VolumeStore) metadataPath(name string) string {
+	sum := sha256.Sum256([]byte(name))
+	return filepath.Join(s.rootPath, hex.EncodeToString(sum[:])+".json")
+}
+
+// setMetadata records the labels of the volume name, in its metadata file
+// if the store is persistent. No file is kept for a volume without labels.
+func (s *VolumeStore) setMetadata(name string, labels map[string]string) error {
+	if s.rootPath != "" {
+		if len(labels) == 0 {
+			if err := os.Remove(s.metadataPath(name)); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+		} else {
+			b, err := json.Marshal(volumeMetadata{Name: name, Labels: labels})
+			if err != nil {
+				return err
+			}
+			if err := ioutil.WriteFile(s.metadataPath(name), b, 0600); err != nil {
+				return err
+			}
+		}
+	}
+	s.globalLock.Lock()
+	if len(labels) > 0 {
+		s.labels[name] = labels
+	} else {
+		delete(s.labels, name)
+	}
+	s.globalLock.Unlock()
+	return nil
+}
+
+// removeMetadata removes the metadata of the volume name.
+func (s *VolumeStore) removeMetadata(name string) error {
+	s.globalLock.Lock()
+	delete(s.labels, name)
+	s.globalLock.Unlock()
+	if s.rootPath == "" {
+		return nil
+	}
+	if err := os.Remove(s.metadataPath(name)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
 }
 
 func (s *VolumeStore) get(name string) (*volumeCounter, bool) {
@@ -40,8 +146,12 @@ func (s *VolumeStore) remove(name string) {
 // VolumeStore is a struct that stores the list of volumes available and keeps track of their usage counts
 type VolumeStore struct {
 	vols       map[string]*volumeCounter
+	labels     map[string]map[string]string
 	locks      *locker.Locker
 	globalLock sync.Mutex
+	// rootPath is the directory of the metadata files of the volumes, or
+	// empty to keep the metadata in memory only.
+	rootPath string
 }
 
 // volumeCounter keeps track of references to a volume
@@ -53,12 +163,14 @@ type volumeCounter struct {
 // AddAll adds a list of volumes to the store
 func (s *VolumeStore) AddAll(vols []volume.Volume) {
 	for _, v := range vols {
-		s.vols[normaliseVolumeName(v.Name())] = &volumeCounter{v, 0}
+		s.vols[normaliseVolumeName(v.Name())] = &volumeCounter{Volume: v}
 	}
 }
 
-// Create tries to find an existing volume with the given name or create a new one from the passed in driver
-func (s *VolumeStore) Create(name, driverName string, opts map[string]string) (volume.Volume, error) {
+// Create tries to find an existing volume with the given name or create a new one from the passed in driver.
+// The labels are kept in the metadata of the store and are not passed to the
+// driver.
+func (s *VolumeStore) Create(name, driverName string, opts, labels map[string]string) (volume.Volume, error) {
 	name = normaliseVolumeName(name)
 	s.locks.Lock(name)
 	defer s.locks.Unlock(name)
@@ -87,7 +199,14 @@ func (s *VolumeStore) Create(name, driverName string, opts map[string]string) (v
 		return nil, &OpErr{Op: "create", Name: name, Err: err}
 	}
 
-	s.set(name, &volumeCounter{v, 0})
+	if err := s.setMetadata(name, labels); err != nil {
+		if err := vd.Remove(v); err != nil {
+			logrus.Errorf("Error removing volume %s after failing to save its metadata: %v", name, err)
+		}
+		return nil, &OpErr{Op: "create", Name: name, Err: err}
+	}
+
+	s.set(name, &volumeCounter{Volume: v})
 	return v, nil
 }
 
@@ -129,6 +248,9 @@ func (s *VolumeStore) Remove(v volume.Volume) error {
 	}
 
 	s.remove(name)
+	if err := s.removeMetadata(name); err != nil {
+		logrus.Warnf("Error removing the metadata of volume %s: %v", name, err)
+	}
 	return nil
 }
 
@@ -141,7 +263,7 @@ func (s *VolumeStore) Increment(v volume.Volume) {
 	logrus.Debugf("Incrementing volume reference: driver %s, name %s", v.DriverName(), v.Name())
 	vc, exists := s.get(name)
 	if !exists {
-		s.set(name, &volumeCounter{v, 1})
+		s.set(name, &volumeCounter{Volume: v, count: 1})
 		return
 	}
 	vc.count++
@@ -177,6 +299,20 @@ func (s *VolumeStore) Count(v volume.Volume) uint {
 	return vc.count
 }
 
+// Labels returns the labels the passed in volume was created with
+func (s *VolumeStore) Labels(v volume.Volume) map[string]string {
+	name := normaliseVolumeName(v.Name())
+	s.locks.Lock(name)
+	defer s.locks.Unlock(name)
+
+	if _, exists := s.get(name); !exists {
+		return nil
+	}
+	s.globalLock.Lock()
+	defer s.globalLock.Unlock()
+	return s.labels[name]
+}
+
 // List returns all the available volumes
 func (s *VolumeStore) List() []volume.Volume {
 	s.globalLock.Lock()
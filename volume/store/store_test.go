@@ -2,6 +2,9 @@ package store
 
 import (
 	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/docker/docker/volume"
@@ -11,7 +14,10 @@ import (
 
 func TestList(t *testing.T) {
 	volumedrivers.Register(vt.FakeDriver{}, "fake")
-	s := New()
+	s, err := New("")
+	if err != nil {
+		t.Fatal(err)
+	}
 	s.AddAll([]volume.Volume{vt.NewFakeVolume("fake1"), vt.NewFakeVolume("fake2")})
 	l := s.List()
 	if len(l) != 2 {
@@ -21,7 +27,10 @@ func TestList(t *testing.T) {
 
 func TestGet(t *testing.T) {
 	volumedrivers.Register(vt.FakeDriver{}, "fake")
-	s := New()
+	s, err := New("")
+	if err != nil {
+		t.Fatal(err)
+	}
 	s.AddAll([]volume.Volume{vt.NewFakeVolume("fake1"), vt.NewFakeVolume("fake2")})
 	v, err := s.Get("fake1")
 	if err != nil {
@@ -38,8 +47,11 @@ func TestGet(t *testing.T) {
 
 func TestCreate(t *testing.T) {
 	volumedrivers.Register(vt.FakeDriver{}, "fake")
-	s := New()
-	v, err := s.Create("fake1", "fake", nil)
+	s, err := New("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	v, err := s.Create("fake1", "fake", nil, nil)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -50,11 +62,11 @@ func TestCreate(t *testing.T) {
 		t.Fatalf("Expected 1 volume in the store, got %v: %v", len(l), l)
 	}
 
-	if _, err := s.Create("none", "none", nil); err == nil {
+	if _, err := s.Create("none", "none", nil, nil); err == nil {
 		t.Fatalf("Expected unknown driver error, got nil")
 	}
 
-	_, err = s.Create("fakeerror", "fake", map[string]string{"error": "create error"})
+	_, err = s.Create("fakeerror", "fake", map[string]string{"error": "create error"}, nil)
 	expected := &OpErr{Op: "create", Name: "fakeerror", Err: errors.New("create error")}
 	if err != nil && err.Error() != expected.Error() {
 		t.Fatalf("Expected create fakeError: create error, got %v", err)
@@ -63,11 +75,14 @@ func TestCreate(t *testing.T) {
 
 func TestRemove(t *testing.T) {
 	volumedrivers.Register(vt.FakeDriver{}, "fake")
-	s := New()
+	s, err := New("")
+	if err != nil {
+		t.Fatal(err)
+	}
 	if err := s.Remove(vt.NoopVolume{}); !IsNotExist(err) {
 		t.Fatalf("Expected IsNotExist error, got %v", err)
 	}
-	v, err := s.Create("fake1", "fake", nil)
+	v, err := s.Create("fake1", "fake", nil, nil)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -84,8 +99,100 @@ func TestRemove(t *testing.T) {
 	}
 }
 
+func TestLabels(t *testing.T) {
+	volumedrivers.Register(vt.FakeDriver{}, "fake")
+	s, err := New("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	v, err := s.Create("fake1", "fake", nil, map[string]string{"com.example.role": "cache"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if l := s.Labels(v); l["com.example.role"] != "cache" {
+		t.Fatalf("Expected com.example.role=cache label, got %v", l)
+	}
+
+	// Creating an existing volume does not replace its labels
+	if _, err := s.Create("fake1", "fake", nil, map[string]string{"com.example.role": "data"}); err != nil {
+		t.Fatal(err)
+	}
+	if l := s.Labels(v); l["com.example.role"] != "cache" {
+		t.Fatalf("Expected com.example.role=cache label, got %v", l)
+	}
+
+	if l := s.Labels(vt.NoopVolume{}); l != nil {
+		t.Fatalf("Expected no labels for unknown volume, got %v", l)
+	}
+}
+
+func TestLabelsPersisted(t *testing.T) {
+	volumedrivers.Register(vt.FakeDriver{}, "fake")
+	root, err := ioutil.TempDir("", "docker-volume-store")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	s, err := New(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	v1, err := s.Create("fake1", "fake", nil, map[string]string{"com.example.role": "cache"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	v2, err := s.Create("fake2", "fake", nil, map[string]string{"com.example.role": "data"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Remove(v2); err != nil {
+		t.Fatal(err)
+	}
+	v3, err := s.Create("../fake3", "fake", nil, map[string]string{"com.example.role": "logs"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Create("fake4", "fake", nil, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	// Only the volumes with labels have a metadata file, inside the root.
+	files, err := ioutil.ReadDir(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 2 {
+		t.Fatalf("Expected 2 metadata files, got %d", len(files))
+	}
+	if _, err := os.Stat(filepath.Join(filepath.Dir(root), "fake3.json")); !os.IsNotExist(err) {
+		t.Fatalf("Expected no metadata file outside of the root, got %v", err)
+	}
+
+	// The labels are loaded again by a new store, except those of the
+	// volumes removed.
+	s, err = New(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.AddAll([]volume.Volume{v1, v2})
+	if l := s.Labels(v1); l["com.example.role"] != "cache" {
+		t.Fatalf("Expected com.example.role=cache label, got %v", l)
+	}
+	if l := s.Labels(v2); l != nil {
+		t.Fatalf("Expected no labels for a removed volume, got %v", l)
+	}
+	s.AddAll([]volume.Volume{v3})
+	if l := s.Labels(v3); l["com.example.role"] != "logs" {
+		t.Fatalf("Expected com.example.role=logs label, got %v", l)
+	}
+}
+
 func TestIncrement(t *testing.T) {
-	s := New()
+	s, err := New("")
+	if err != nil {
+		t.Fatal(err)
+	}
 	v := vt.NewFakeVolume("fake1")
 	s.Increment(v)
 	if l := s.List(); len(l) != 1 {
@@ -111,7 +218,10 @@ func TestIncrement(t *testing.T) {
 }
 
 func TestDecrement(t *testing.T) {
-	s := New()
+	s, err := New("")
+	if err != nil {
+		t.Fatal(err)
+	}
 	v := vt.NoopVolume{}
 	s.Decrement(v)
 	if c := s.Count(v); c != 0 {
@@ -138,7 +248,10 @@ func TestDecrement(t *testing.T) {
 }
 
 func TestFilterByDriver(t *testing.T) {
-	s := New()
+	s, err := New("")
+	if err != nil {
+		t.Fatal(err)
+	}
 
 	s.Increment(vt.NewFakeVolume("fake1"))
 	s.Increment(vt.NewFakeVolume("fake2"))
@@ -90,6 +90,8 @@ func TestParseMountSpec(t *testing.T) {
 			"hostPath:/containerPath:ro",
 			"/hostPath:/containerPath:rw",
 			"/rw:/ro",
+			"/hostPath:/containerPath:U",
+			"name:/containerPath:ro,Z,U",
 		}
 		invalid = map[string]string{
 			"":                "Invalid volume specification",
@@ -113,6 +115,7 @@ func TestParseMountSpec(t *testing.T) {
 			"path:ro":         "Invalid volume specification",
 			"/path:/path:sw":  `invalid mode: "sw"`,
 			"/path:/path:rwz": `invalid mode: "rwz"`,
+			"/path:/path:U,U": `invalid mode: "U,U"`,
 		}
 	}
 
@@ -22,6 +22,11 @@ var labelModes = map[string]bool{
 	"z": true,
 }
 
+// ownership modes
+var ownershipModes = map[string]bool{
+	"U": true,
+}
+
 // BackwardsCompatible decides whether this mount point can be
 // used in old versions of Docker or not.
 // Only bind mounts and local volumes can be used in old versions of Docker.
@@ -142,6 +147,7 @@ func ValidMountMode(mode string) bool {
 	rwModeCount := 0
 	labelModeCount := 0
 	propagationModeCount := 0
+	ownershipModeCount := 0
 
 	for _, o := range strings.Split(mode, ",") {
 		if rwModes[o] {
@@ -153,12 +159,15 @@ func ValidMountMode(mode string) bool {
 		} else if propagationModes[o] {
 			propagationModeCount++
 			continue
+		} else if ownershipModes[o] {
+			ownershipModeCount++
+			continue
 		}
 		return false
 	}
 
 	// Only one string for each mode is allowed.
-	if rwModeCount > 1 || labelModeCount > 1 || propagationModeCount > 1 {
+	if rwModeCount > 1 || labelModeCount > 1 || propagationModeCount > 1 || ownershipModeCount > 1 {
 		return false
 	}
 	return true
@@ -180,3 +189,14 @@ func ReadWrite(mode string) bool {
 
 	return true
 }
+
+// ChownNeeded tells whether the mode asks for the source of the mount to be
+// owned by the user of the container.
+func ChownNeeded(mode string) bool {
+	for _, o := range strings.Split(mode, ",") {
+		if ownershipModes[o] {
+			return true
+		}
+	}
+	return false
+}